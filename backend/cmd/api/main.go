@@ -1,18 +1,41 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+	_ "time/tzdata" // 予約受付ルールのタイムゾーン用（OSにタイムゾーン情報がない環境向け）
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"online_medical_consultation_app/backend/internal/alerting"
+	"online_medical_consultation_app/backend/internal/backup"
+	"online_medical_consultation_app/backend/internal/clinicalcoding"
 	"online_medical_consultation_app/backend/internal/config"
+	"online_medical_consultation_app/backend/internal/contentfilter"
 	"online_medical_consultation_app/backend/internal/database"
+	"online_medical_consultation_app/backend/internal/drugpricing"
 	"online_medical_consultation_app/backend/internal/handlers"
+	"online_medical_consultation_app/backend/internal/jobs"
+	"online_medical_consultation_app/backend/internal/mail"
 	"online_medical_consultation_app/backend/internal/middleware"
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/ocr"
+	"online_medical_consultation_app/backend/internal/payments"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/push"
+	"online_medical_consultation_app/backend/internal/quota"
+	"online_medical_consultation_app/backend/internal/realtime"
 	"online_medical_consultation_app/backend/internal/repositories"
 	"online_medical_consultation_app/backend/internal/services"
+	"online_medical_consultation_app/backend/internal/storage"
+	"online_medical_consultation_app/backend/internal/transcription"
 )
 
 func main() {
@@ -45,156 +68,809 @@ func main() {
 
 	// リポジトリの初期化
 	userRepo := repositories.NewUserRepository(db)
+	loginAttemptRepo := repositories.NewLoginAttemptRepository(db)
 	slotRepo := repositories.NewSlotRepository(db)
+	slotTemplateRepo := repositories.NewSlotTemplateRepository(db)
 	appointmentRepo := repositories.NewAppointmentRepository(db)
 	messageRepo := repositories.NewMessageRepository(db)
+	attachmentRepo := repositories.NewAttachmentRepository(db)
+	storedObjectRepo := repositories.NewStoredObjectRepository(db)
 	prescriptionRepo := repositories.NewPrescriptionRepository(db)
 	auditRepo := repositories.NewAuditRepository(db)
 	videoSessionRepo := repositories.NewVideoSessionRepository(db)
+	iceCandidateRepo := repositories.NewICECandidateRepository(db)
+	videoPresenceRepo := repositories.NewVideoPresenceRepository(db)
+	auditArchiveRepo := repositories.NewAuditArchiveRepository(db)
+	dependentRepo := repositories.NewDependentRepository(db)
+	notificationRepo := repositories.NewNotificationRepository(db)
+	notificationPreferenceRepo := repositories.NewNotificationPreferenceRepository(db)
+	autoReplyRepo := repositories.NewAutoReplyRepository(db)
+	escalationRepo := repositories.NewEscalationRepository(db)
+	caseDiscussionRepo := repositories.NewCaseDiscussionRepository(db)
+	patientMergeRepo := repositories.NewPatientMergeRepository(db)
+	timeOffRepo := repositories.NewTimeOffRepository(db)
+	coverageRepo := repositories.NewCoverageRepository(db)
+	invoiceRepo := repositories.NewInvoiceRepository(db)
+	ledgerRepo := repositories.NewLedgerRepository(db)
+	tagRepo := repositories.NewTagRepository(db)
+	savedFilterRepo := repositories.NewSavedFilterRepository(db)
+	taskRepo := repositories.NewTaskRepository(db)
+	promRepo := repositories.NewPROMRepository(db)
+	credentialRepo := repositories.NewCredentialRepository(db)
+	contactChangeRepo := repositories.NewContactChangeRepository(db)
+	bookingPolicyRepo := repositories.NewBookingPolicyRepository(db)
+	clinicBrandingRepo := repositories.NewClinicBrandingRepository(db)
+	locationRepo := repositories.NewLocationRepository(db)
+	organizationRepo := repositories.NewOrganizationRepository(db)
+	subscriptionRepo := repositories.NewSubscriptionRepository(db)
+	appVersionPolicyRepo := repositories.NewAppVersionPolicyRepository(db)
+	attachmentPolicyRepo := repositories.NewAttachmentPolicyRepository(db)
+	slotSubscriptionRepo := repositories.NewSlotSubscriptionRepository(db)
+	patientDocumentRepo := repositories.NewPatientDocumentRepository(db)
+	medicalRecordRepo := repositories.NewMedicalRecordRepository(db)
+	correctionRequestRepo := repositories.NewCorrectionRequestRepository(db)
+	exportDownloadRepo := repositories.NewExportDownloadRepository(db)
+	triageRepo := repositories.NewTriageRepository(db)
+	interpreterRepo := repositories.NewInterpreterRepository(db)
+	complaintRepo := repositories.NewComplaintRepository(db)
+	legalRepo := repositories.NewLegalRepository(db)
+	messageFlagRepo := repositories.NewMessageFlagRepository(db)
+	deviceRepo := repositories.NewDeviceRepository(db)
+	transcriptRepo := repositories.NewTranscriptRepository(db)
+	recordingRepo := repositories.NewRecordingRepository(db)
+	clinicalCodingRepo := repositories.NewClinicalCodingRepository(db)
+	feedbackRepo := repositories.NewFeedbackRepository(db)
+	demoRepo := repositories.NewDemoRepository(db)
+	backupRepo := repositories.NewBackupRepository(db)
+	hl7Repo := repositories.NewHL7Repository(db)
+	breakGlassRepo := repositories.NewBreakGlassRepository(db)
+	reportSubscriptionRepo := repositories.NewReportSubscriptionRepository(db)
+
+	// WebSocket接続の管理（複数インスタンス構成ではRedis経由で全インスタンスへ配信）
+	var realtimeBroker realtime.Broker
+	switch cfg.RealtimeBroker {
+	case "local":
+		realtimeBroker = realtime.NewLocalBroker()
+	case "redis":
+		redisBroker, err := realtime.NewRedisBroker(cfg.RedisURL, cfg.RealtimeRedisChannel)
+		if err != nil {
+			log.Fatal("Failed to connect to realtime broker:", err)
+		}
+		realtimeBroker = redisBroker
+	default:
+		log.Fatal("Invalid REALTIME_BROKER (expected local or redis): ", cfg.RealtimeBroker)
+	}
+	hub := realtime.NewHub(realtimeBroker)
+
+	// ファイルのアップロード・エクスポートの利用回数の記録（複数インスタンス構成ではRedisで共有）
+	var quotaStore quota.Store
+	switch cfg.QuotaStore {
+	case "memory":
+		quotaStore = quota.NewMemoryStore()
+	case "redis":
+		redisStore, err := quota.NewRedisStore(cfg.RedisURL, "telemed:quota:")
+		if err != nil {
+			log.Fatal("Failed to connect to quota store:", err)
+		}
+		quotaStore = redisStore
+	default:
+		log.Fatal("Invalid QUOTA_STORE (expected memory or redis): ", cfg.QuotaStore)
+	}
+	defer quotaStore.Close()
+	quotaLimiter := quota.NewLimiter(quotaStore)
+	uploadQuota := middleware.Quota(quotaLimiter, quota.Rule{Name: "uploads", Window: time.Hour, PerUser: cfg.QuotaUploadsPerHourUser, PerIP: cfg.QuotaUploadsPerHourIP})
+	exportQuota := middleware.Quota(quotaLimiter, quota.Rule{Name: "exports", Window: 24 * time.Hour, PerUser: cfg.QuotaExportsPerDayUser, PerIP: cfg.QuotaExportsPerDayIP})
+	// 多数のアカウントへのパスワードの試行（アカウントごとのロックに掛からない）をIPアドレスごとに制限する
+	loginQuota := middleware.Quota(quotaLimiter, quota.Rule{Name: "logins", Window: 15 * time.Minute, PerIP: cfg.LoginAttemptsPerIP})
+
+	// ロールによるルートの制限（方針は policy パッケージで定義）
+	requireAdmin := middleware.RequireRole(policy.Admin...)
 
 	// サービスの初期化
-	authService := services.NewAuthService(userRepo, cfg.JWTSecret)
-	slotService := services.NewSlotService(slotRepo)
-	appointmentService := services.NewAppointmentService(appointmentRepo, slotRepo, userRepo)
-	chatService := services.NewChatService(messageRepo, appointmentRepo, userRepo)
-	prescriptionService := services.NewPrescriptionService(prescriptionRepo, appointmentRepo, userRepo)
-	auditService := services.NewAuditService(auditRepo, userRepo)
-	videoService := services.NewVideoService(videoSessionRepo, appointmentRepo, userRepo)
+	// 運用アラート（送信先が未設定の場合はnilで、通知しない）
+	alerter := alerting.NewAlerter(alerting.Config{
+		SlackWebhookURL:     cfg.AlertSlackWebhookURL,
+		PagerDutyRoutingKey: cfg.AlertPagerDutyRoutingKey,
+		PagerDutyURL:        cfg.AlertPagerDutyURL,
+		WebhookURL:          cfg.AlertWebhookURL,
+		WebhookSecret:       cfg.AlertWebhookSecret,
+		Timeout:             cfg.AlertTimeout,
+		Cooldown:            cfg.AlertCooldown,
+		Environment:         cfg.Environment,
+	})
+
+	auditService := services.NewAuditService(auditRepo, userRepo, alerter, cfg.AlertAuditDropThreshold, cfg.AlertAuditDropWindow, cfg.AuditPseudonymSaltRotation)
+	notificationService := services.NewNotificationService(notificationRepo, userRepo)
+	notificationService.RegisterChannel(services.NewRealtimeChannel(hub))
+	fcmProvider, apnsProvider, err := push.NewProviders(push.Config{
+		FCMProjectID:       cfg.PushFCMProjectID,
+		FCMCredentialsFile: cfg.PushFCMCredentialsFile,
+		APNsKeyFile:        cfg.PushAPNsKeyFile,
+		APNsKeyID:          cfg.PushAPNsKeyID,
+		APNsTeamID:         cfg.PushAPNsTeamID,
+		APNsTopic:          cfg.PushAPNsTopic,
+		APNsProduction:     cfg.PushAPNsProduction,
+		Timeout:            cfg.PushTimeout,
+	})
+	if err != nil {
+		log.Fatal("Invalid push notification configuration:", err)
+	}
+	deviceService := services.NewDeviceService(deviceRepo, userRepo, services.NewProviderPushSender(fcmProvider, apnsProvider, services.NewLogPushSender()))
+	pushDispatcher := services.NewPushDispatcher(deviceService, messageRepo)
+	notificationService.RegisterChannel(pushDispatcher)
+	session := services.SessionConfig{
+		AccessTokenTTL: cfg.AccessTokenTTL,
+		RefreshWindow:  cfg.SessionRefreshWindow,
+		MaxLifetime:    cfg.SessionMaxLifetime,
+	}
+	if err := services.ValidateSessionConfig(&session); err != nil {
+		log.Fatal("Invalid session configuration:", err)
+	}
+	lockout := services.LockoutConfig{
+		MaxFailures:  cfg.LoginMaxFailures,
+		BaseDuration: cfg.LoginLockoutBase,
+		MaxDuration:  cfg.LoginLockoutMax,
+	}
+	if err := services.ValidateLockoutConfig(&lockout); err != nil {
+		log.Fatal("Invalid login lockout configuration:", err)
+	}
+	authService := services.NewAuthService(userRepo, loginAttemptRepo, auditService, cfg.JWTSecret, session, lockout)
+	bookingPolicyDefaults := models.BookingPolicy{
+		MinNoticeMinutes: int(cfg.BookingMinNotice.Minutes()),
+		MaxAdvanceDays:   cfg.BookingMaxAdvanceDays,
+		OpenTime:         cfg.BookingOpenTime,
+		CloseTime:        cfg.BookingCloseTime,
+		OpenWeekdays:     cfg.BookingOpenWeekdays,
+		Timezone:         cfg.BookingTimezone,
+	}
+	if err := services.ValidateBookingPolicy(&bookingPolicyDefaults); err != nil {
+		log.Fatal("Invalid booking policy configuration:", err)
+	}
+	bookingPolicyService := services.NewBookingPolicyService(bookingPolicyRepo, userRepo, auditService, bookingPolicyDefaults)
+	brandingDefaults := models.ClinicBranding{
+		ClinicName:   cfg.ClinicName,
+		LogoURL:      cfg.ClinicLogoURL,
+		PrimaryColor: cfg.ClinicPrimaryColor,
+		SupportEmail: cfg.ClinicSupportEmail,
+		SupportPhone: cfg.ClinicSupportPhone,
+		EmailFooter:  cfg.ClinicEmailFooter,
+	}
+	if err := services.ValidateBranding(&brandingDefaults); err != nil {
+		log.Fatal("Invalid clinic branding configuration:", err)
+	}
+	brandingService := services.NewBrandingService(clinicBrandingRepo, userRepo, auditService, brandingDefaults)
+	appVersionDefaults := models.AppVersionPolicy{
+		MinIOSVersion:     cfg.AppMinVersionIOS,
+		MinAndroidVersion: cfg.AppMinVersionAndroid,
+		MinWebVersion:     cfg.AppMinVersionWeb,
+		IOSUpgradeURL:     cfg.AppUpgradeURLIOS,
+		AndroidUpgradeURL: cfg.AppUpgradeURLAndroid,
+	}
+	if err := services.ValidateAppVersionPolicy(&appVersionDefaults); err != nil {
+		log.Fatal("Invalid app version configuration:", err)
+	}
+	appVersionService := services.NewAppVersionService(appVersionPolicyRepo, userRepo, auditService, appVersionDefaults)
+	slotService := services.NewSlotService(slotRepo, slotTemplateRepo, timeOffRepo, bookingPolicyService)
+	onboardingService := services.NewOnboardingService(userRepo, auditService, cfg.PatientRequiredFields)
+	ocrProvider, err := ocr.NewProvider(ocr.Config{
+		Provider: cfg.OCRProvider,
+		APIURL:   cfg.OCRAPIURL,
+		APIKey:   cfg.OCRAPIKey,
+		Language: cfg.OCRLanguage,
+		Timeout:  cfg.OCRTimeout,
+	})
+	if err != nil {
+		log.Fatal("Invalid OCR configuration:", err)
+	}
+	patientDocumentService := services.NewPatientDocumentService(patientDocumentRepo, appointmentRepo, userRepo, storedObjectRepo, auditService, ocrProvider, cfg.UploadDir)
+	mailProvider, err := mail.NewProvider(mail.Config{
+		Provider:       cfg.MailProvider,
+		From:           cfg.MailFrom,
+		FromName:       cfg.MailFromName,
+		SMTPHost:       cfg.SMTPHost,
+		SMTPPort:       cfg.SMTPPort,
+		SMTPUsername:   cfg.SMTPUsername,
+		SMTPPassword:   cfg.SMTPPassword,
+		SendGridAPIURL: cfg.SendGridAPIURL,
+		SendGridAPIKey: cfg.SendGridAPIKey,
+		Timeout:        cfg.MailTimeout,
+	})
+	if err != nil {
+		log.Fatal("Invalid mail configuration:", err)
+	}
+	var baseSender services.ContactSender = services.NewLogContactSender()
+	if mailProvider != nil {
+		baseSender = services.NewMailContactSender(mailProvider, baseSender)
+	}
+	// メールにはクリニックのフッターを付ける
+	contactSender := services.NewBrandedContactSender(baseSender, brandingService)
+	emailNotificationService := services.NewEmailNotificationService(notificationPreferenceRepo, messageRepo, userRepo, contactSender, cfg.AppBaseURL, cfg.UnreadMessageEmailDelay)
+	notificationService.RegisterChannel(services.NewEmailChannel(emailNotificationService))
+	visitSummaryService := services.NewVisitSummaryService(appointmentRepo, taskRepo, bookingPolicyService, brandingService, auditService, contactSender, cfg.AppBaseURL)
+	documentService := services.NewDocumentService(brandingService, bookingPolicyService, cfg.AppBaseURL)
+	paymentGateway, err := payments.NewGateway(payments.Config{
+		Provider:       cfg.PaymentProvider,
+		APIURL:         cfg.StripeAPIURL,
+		SecretKey:      cfg.StripeSecretKey,
+		PublishableKey: cfg.StripePublishableKey,
+		WebhookSecret:  cfg.StripeWebhookSecret,
+		Timeout:        cfg.PaymentTimeout,
+	})
+	if err != nil {
+		log.Fatal("Invalid payment configuration:", err)
+	}
+	ledgerService := services.NewLedgerService(ledgerRepo, invoiceRepo, userRepo, auditService, cfg.PaymentCurrency)
+	tagService := services.NewTagService(tagRepo, appointmentRepo)
+	savedFilterService := services.NewSavedFilterService(savedFilterRepo, userRepo)
+	freeUsageLimits := services.NewUsageLimits(cfg.ChatPostConsultationWindow, cfg.MaxVideoSessionsPerAppointment)
+	plusUsageLimits := services.NewUsageLimits(cfg.PlusChatPostConsultationWindow, cfg.PlusMaxVideoSessionsPerAppointment)
+	usageQuotaService := services.NewUsageQuotaService(subscriptionRepo, videoSessionRepo, freeUsageLimits, plusUsageLimits, paymentGateway != nil && cfg.SubscriptionPrice > 0)
+	subscriptionService := services.NewSubscriptionService(subscriptionRepo, userRepo, usageQuotaService, notificationService, auditService, paymentGateway, cfg.PaymentCurrency, int64(cfg.SubscriptionPrice), cfg.SubscriptionPeriod)
+	paymentService := services.NewPaymentService(invoiceRepo, appointmentRepo, userRepo, notificationService, auditService, ledgerService, subscriptionService, paymentGateway, cfg.PaymentCurrency)
+	locationService := services.NewLocationService(locationRepo, userRepo, auditService)
+	calendarService := services.NewCalendarService(appointmentRepo, userRepo, locationService, auditService, cfg.AppBaseURL, cfg.AppointmentReminderLead)
+	appointmentService := services.NewAppointmentService(appointmentRepo, slotRepo, userRepo, dependentRepo, triageRepo, interpreterRepo, messageRepo, notificationService, onboardingService, patientDocumentService, bookingPolicyService, locationService, visitSummaryService, paymentService, tagService, auditService, hub, cfg.AsyncResponseSLA, cfg.AppointmentCompletionGrace, cfg.IntakeReminderLead, cfg.AppointmentReminderLead)
+	chatContentFilter, err := contentfilter.NewDefaultPipeline(contentfilter.Config{
+		ProfanityWords:  cfg.ChatProfanityWords,
+		ProfanityAction: cfg.ChatProfanityAction,
+		PIIAction:       cfg.ChatPIIAction,
+	})
+	if err != nil {
+		log.Fatal("Invalid chat content filter configuration:", err)
+	}
+	autoReplyService := services.NewAutoReplyService(autoReplyRepo, messageRepo, userRepo, bookingPolicyService, hub)
+	// localの署名付きURLはJWTとは別の鍵で署名する（片方の鍵の漏洩でもう片方を偽造させない）
+	if cfg.AttachmentStorage == "" || cfg.AttachmentStorage == "local" {
+		if cfg.AttachmentURLSecret == "" {
+			log.Fatal("ATTACHMENT_URL_SECRET is required to sign attachment download URLs")
+		}
+		if cfg.AttachmentURLSecret == cfg.JWTSecret {
+			log.Fatal("ATTACHMENT_URL_SECRET must differ from JWT_SECRET")
+		}
+	}
+	attachmentStore, err := storage.New(storage.Config{
+		Provider:          cfg.AttachmentStorage,
+		LocalDir:          filepath.Join(cfg.UploadDir, "chat-attachments"),
+		LocalURL:          "/api/v1/files/chat-attachments",
+		LocalSigningKey:   cfg.AttachmentURLSecret,
+		S3Bucket:          cfg.AttachmentS3Bucket,
+		S3Region:          cfg.AttachmentS3Region,
+		S3Endpoint:        cfg.AttachmentS3Endpoint,
+		S3AccessKeyID:     cfg.AttachmentS3AccessKeyID,
+		S3SecretAccessKey: cfg.AttachmentS3SecretAccessKey,
+		Timeout:           cfg.AttachmentStorageTimeout,
+	})
+	if err != nil {
+		log.Fatal("Invalid attachment storage configuration:", err)
+	}
+	attachmentPolicyService := services.NewAttachmentPolicyService(attachmentPolicyRepo, userRepo, auditService, cfg.MaxFileSize)
+	attachmentObjects := services.NewStoredObjectService(storedObjectRepo, services.StoredObjectsChatAttachments, attachmentStore)
+	chatService := services.NewChatService(messageRepo, attachmentRepo, appointmentRepo, userRepo, videoSessionRepo, coverageRepo, chatContentFilter, autoReplyService, usageQuotaService, pushDispatcher, hub, auditService, attachmentPolicyService, attachmentObjects, attachmentStore, cfg.AttachmentURLTTL)
+	moderationService := services.NewModerationService(messageFlagRepo, messageRepo, appointmentRepo, userRepo, notificationService, auditService)
+	drugPricing, err := drugpricing.NewRegistry(drugpricing.Config{
+		DefaultRegion: cfg.DrugPricingDefaultRegion,
+		Providers:     cfg.DrugPricingProviders,
+		DatasetFile:   cfg.DrugPricingDatasetFile,
+		APIURL:        cfg.DrugPricingAPIURL,
+		APIKey:        cfg.DrugPricingAPIKey,
+		Timeout:       cfg.DrugPricingTimeout,
+	})
+	if err != nil {
+		log.Fatal("Invalid drug pricing configuration:", err)
+	}
+	prescriptionService := services.NewPrescriptionService(prescriptionRepo, appointmentRepo, userRepo, notificationService, auditService, cfg.PrescriptionAckReminderDelay, drugPricing, documentService)
+	iceServers := services.ICEServerConfig{
+		STUNServers:       cfg.StunServers,
+		TURNURLs:          cfg.TURNURLs,
+		TURNSecret:        cfg.TURNSecret,
+		TURNCredentialTTL: cfg.TURNCredentialTTL,
+	}
+	if err := services.ValidateICEServerConfig(&iceServers); err != nil {
+		log.Fatal("Invalid ICE server configuration:", err)
+	}
+	recordingStore, err := storage.New(storage.Config{
+		Provider:          cfg.RecordingStorage,
+		LocalDir:          filepath.Join(cfg.UploadDir, "video-recordings"),
+		S3Bucket:          cfg.RecordingS3Bucket,
+		S3Region:          cfg.RecordingS3Region,
+		S3Endpoint:        cfg.RecordingS3Endpoint,
+		S3AccessKeyID:     cfg.RecordingS3AccessKeyID,
+		S3SecretAccessKey: cfg.RecordingS3SecretAccessKey,
+		Timeout:           cfg.RecordingStorageTimeout,
+	})
+	if err != nil {
+		log.Fatal("Invalid recording storage configuration:", err)
+	}
+	recordingService := services.NewRecordingService(recordingRepo, videoSessionRepo, appointmentRepo, auditService, hub, recordingStore)
+	videoService := services.NewVideoService(videoSessionRepo, iceCandidateRepo, videoPresenceRepo, appointmentRepo, userRepo, deviceService, notificationService, auditService, recordingService, usageQuotaService, hub, iceServers)
+	transcriptionProvider, err := transcription.NewProvider(transcription.Config{
+		Provider: cfg.TranscriptionProvider,
+		APIURL:   cfg.TranscriptionAPIURL,
+		APIKey:   cfg.TranscriptionAPIKey,
+		Model:    cfg.TranscriptionModel,
+		Timeout:  cfg.TranscriptionTimeout,
+	})
+	if err != nil {
+		log.Fatal("Invalid transcription configuration:", err)
+	}
+	transcriptionService := services.NewTranscriptionService(transcriptRepo, videoSessionRepo, appointmentRepo, notificationService, auditService, brandingService, transcriptionProvider, cfg.UploadDir)
+	clinicalCodingProvider, err := clinicalcoding.NewProvider(clinicalcoding.Config{
+		Provider: cfg.ClinicalCodingProvider,
+		APIURL:   cfg.ClinicalCodingAPIURL,
+		APIKey:   cfg.ClinicalCodingAPIKey,
+		Timeout:  cfg.ClinicalCodingTimeout,
+	})
+	if err != nil {
+		log.Fatal("Invalid clinical coding configuration:", err)
+	}
+	clinicalCodingService := services.NewClinicalCodingService(clinicalCodingRepo, appointmentRepo, auditService, clinicalCodingProvider)
+	dependentService := services.NewDependentService(dependentRepo, appointmentRepo, userRepo)
+	auditArchiveService := services.NewAuditArchiveService(auditArchiveRepo, userRepo, cfg.AuditArchiveDir, cfg.AuditRetentionDays, cfg.AuditRehydrateDays)
+	interpreterService := services.NewInterpreterService(interpreterRepo, appointmentRepo, userRepo)
+	complaintService := services.NewComplaintService(complaintRepo, appointmentRepo, messageRepo, videoSessionRepo, auditRepo, userRepo, notificationService, auditService)
+	presenceService := services.NewPresenceService(userRepo, appointmentRepo, hub)
+	legalService := services.NewLegalService(legalRepo, userRepo, auditService)
+	triageService := services.NewTriageService(triageRepo, userRepo, dependentRepo, auditService)
+	escalationService := services.NewEscalationService(escalationRepo, appointmentRepo, userRepo, notificationService, auditService, cfg.EscalationTimeout, cfg.OnCallAdminIDs)
+	caseDiscussionService := services.NewCaseDiscussionService(caseDiscussionRepo, messageRepo, appointmentRepo, userRepo, notificationService, auditService)
+	patientMergeService := services.NewPatientMergeService(patientMergeRepo, userRepo, auditService)
+	absenceService := services.NewDoctorAbsenceService(appointmentRepo, slotRepo, timeOffRepo, userRepo, interpreterRepo, notificationService, auditService, hub, cfg.PendingResponseTimeout)
+	coverageService := services.NewCoverageService(coverageRepo, appointmentRepo, slotRepo, userRepo, locationService, notificationService, auditService, hub)
+	taskService := services.NewTaskService(taskRepo, appointmentRepo, notificationService, auditService)
+	chatCommandService := services.NewChatCommandService(appointmentRepo, messageRepo, prescriptionService, taskService, visitSummaryService, bookingPolicyService, pushDispatcher, hub, auditService)
+	promService := services.NewPROMService(promRepo, appointmentRepo, notificationService, auditService)
+	dashboardService := services.NewDashboardService(appointmentRepo, prescriptionRepo, messageRepo, taskRepo, userRepo)
+	rosterService := services.NewRosterService(slotRepo, appointmentRepo, userRepo, bookingPolicyService, brandingService, auditService)
+	utilizationService := services.NewUtilizationService(slotRepo, userRepo, bookingPolicyService)
+	reportSubscriptionService := services.NewReportSubscriptionService(reportSubscriptionRepo, userRepo, ledgerRepo, auditRepo, utilizationService, bookingPolicyService, brandingService, auditService, contactSender)
+	feedbackService := services.NewFeedbackService(feedbackRepo, appointmentRepo, auditService)
+	performanceService := services.NewPerformanceService(appointmentRepo, userRepo, bookingPolicyService)
+	capacityService := services.NewCapacityService(appointmentRepo, slotRepo, userRepo, bookingPolicyService)
+	medicalRecordService := services.NewMedicalRecordService(medicalRecordRepo, appointmentRepo, dependentRepo, auditService)
+	correctionRequestService := services.NewCorrectionRequestService(correctionRequestRepo, medicalRecordRepo, userRepo, notificationService, auditService)
+	organizationService := services.NewOrganizationService(organizationRepo, userRepo, appointmentRepo, invoiceRepo, appointmentService, notificationService, auditService)
+	exportDownloadStore, err := storage.New(storage.Config{
+		Provider:          cfg.ExportDownloadStorage,
+		LocalDir:          cfg.ExportDownloadDir,
+		S3Bucket:          cfg.ExportDownloadS3Bucket,
+		S3Region:          cfg.ExportDownloadS3Region,
+		S3Endpoint:        cfg.ExportDownloadS3Endpoint,
+		S3AccessKeyID:     cfg.ExportDownloadS3AccessKeyID,
+		S3SecretAccessKey: cfg.ExportDownloadS3SecretAccessKey,
+		Timeout:           cfg.ExportDownloadStorageTimeout,
+	})
+	if err != nil {
+		log.Fatal("Invalid export download storage configuration:", err)
+	}
+	downloadService := services.NewDownloadService(exportDownloadRepo, exportDownloadStore, cfg.ExportSpoolDir, cfg.ExportDownloadTTL, cfg.ExportBandwidthLimit)
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal("Failed to get database instance:", err)
+	}
+	opsMonitorService := services.NewOpsMonitorService(sqlDB, transcriptRepo, alerter, services.OpsAlertThresholds{
+		DatabaseFailures:     cfg.AlertDBFailureThreshold,
+		JobFailures:          cfg.AlertJobFailureThreshold,
+		TranscriptionBacklog: int64(cfg.AlertTranscriptionBacklog),
+	})
+	healthService := services.NewHealthService(sqlDB, func() ([]string, error) {
+		return database.PendingMigrations(db)
+	}, cfg.UploadDir, map[string]storage.Store{
+		"attachments": attachmentStore,
+		"recordings":  recordingStore,
+		"exports":     exportDownloadStore,
+	})
+	credentialService := services.NewCredentialService(credentialRepo, userRepo, notificationService, auditService, cfg.UploadDir)
+	profileService := services.NewProfileService(userRepo, brandingService)
+	accountService := services.NewAccountService(contactChangeRepo, userRepo, appointmentService, notificationService, auditService, contactSender, cfg.AppBaseURL, cfg.AccountReactivationWindow)
+	backupRunner, err := backup.NewRunner(backup.Config{
+		DatabaseURL: databaseURL,
+		Storage: storage.Config{
+			Provider:          cfg.BackupStorage,
+			LocalDir:          cfg.BackupDir,
+			S3Bucket:          cfg.BackupS3Bucket,
+			S3Region:          cfg.BackupS3Region,
+			S3Endpoint:        cfg.BackupS3Endpoint,
+			S3AccessKeyID:     cfg.BackupS3AccessKeyID,
+			S3SecretAccessKey: cfg.BackupS3SecretAccessKey,
+			Timeout:           cfg.BackupStorageTimeout,
+		},
+		SpoolDir:      cfg.BackupSpoolDir,
+		PgDumpPath:    cfg.BackupPgDumpPath,
+		PgRestorePath: cfg.BackupPgRestorePath,
+		Timeout:       cfg.BackupTimeout,
+	})
+	if err != nil {
+		log.Fatal("Invalid backup configuration:", err)
+	}
+	backupService := services.NewBackupService(backupRepo, userRepo, backupRunner, auditService, cfg.BackupTimeout, cfg.BackupKeep)
+	hl7Service := services.NewHL7Service(hl7Repo, userRepo, auditService, cfg.HL7FileDropDir, cfg.HL7Timeout)
+	slotSubscriptionService := services.NewSlotSubscriptionService(slotSubscriptionRepo, userRepo, notificationService, bookingPolicyService, contactSender, cfg.AppBaseURL, cfg.SlotNotifyBatchSize, cfg.SlotNotifyCooldown)
+	scheduleConflictService := services.NewScheduleConflictService(appointmentRepo, slotRepo, userRepo, appointmentService, auditService)
+	breakGlassService := services.NewBreakGlassService(breakGlassRepo, userRepo, appointmentRepo, prescriptionRepo, clinicalCodingRepo, patientDocumentRepo, notificationService, auditService, cfg.BreakGlassDuration)
+	demoService := services.NewDemoService(demoRepo, userRepo, slotRepo, appointmentRepo, messageRepo, prescriptionRepo, chatService, patientDocumentService, bookingPolicyService, auditService, cfg.DemoPassword, cfg.DemoResetHour)
+
+	// デモモードではデモアカウントを用意し、毎日初期化する
+	var demoResetInterval time.Duration
+	if cfg.DemoMode {
+		if cfg.DemoResetHour < 0 || cfg.DemoResetHour > 23 {
+			log.Fatal("Invalid DEMO_RESET_HOUR (expected 0-23): ", cfg.DemoResetHour)
+		}
+		if err := demoService.EnsureDemoAccounts(); err != nil {
+			log.Fatal("Failed to prepare demo accounts: ", err)
+		}
+		demoResetInterval = 15 * time.Minute
+		log.Println("Demo mode enabled")
+	}
 
 	// ハンドラーの初期化
 	authHandler := handlers.NewAuthHandler(authService)
 	slotHandler := handlers.NewSlotHandler(slotService)
 	appointmentHandler := handlers.NewAppointmentHandler(appointmentService)
-	chatHandler := handlers.NewChatHandler(chatService)
+	chatHandler := handlers.NewChatHandler(chatService, chatCommandService)
+	autoReplyHandler := handlers.NewAutoReplyHandler(autoReplyService)
 	prescriptionHandler := handlers.NewPrescriptionHandler(prescriptionService)
-	auditHandler := handlers.NewAuditHandler(auditService)
-	videoHandler := handlers.NewVideoHandler(videoService)
+	auditHandler := handlers.NewAuditHandler(auditService, downloadService)
+	videoHandler := handlers.NewVideoHandler(videoService, hub, middleware.CheckOrigin(cfg.AllowedOrigins))
+	transcriptHandler := handlers.NewTranscriptHandler(transcriptionService, downloadService)
+	recordingHandler := handlers.NewRecordingHandler(recordingService, downloadService)
+	clinicalCodingHandler := handlers.NewClinicalCodingHandler(clinicalCodingService)
+	auditArchiveHandler := handlers.NewAuditArchiveHandler(auditArchiveService)
+	dependentHandler := handlers.NewDependentHandler(dependentService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService, emailNotificationService)
+	escalationHandler := handlers.NewEscalationHandler(escalationService)
+	caseDiscussionHandler := handlers.NewCaseDiscussionHandler(caseDiscussionService)
+	patientMergeHandler := handlers.NewPatientMergeHandler(patientMergeService)
+	absenceHandler := handlers.NewAbsenceHandler(absenceService)
+	coverageHandler := handlers.NewCoverageHandler(coverageService)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	subscriptionHandler := handlers.NewSubscriptionHandler(subscriptionService)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService)
+	tagHandler := handlers.NewTagHandler(tagService)
+	savedFilterHandler := handlers.NewSavedFilterHandler(savedFilterService)
+	taskHandler := handlers.NewTaskHandler(taskService)
+	promHandler := handlers.NewPROMHandler(promService)
+	dashboardHandler := handlers.NewDashboardHandler(dashboardService)
+	rosterHandler := handlers.NewRosterHandler(rosterService)
+	utilizationHandler := handlers.NewUtilizationHandler(utilizationService)
+	reportSubscriptionHandler := handlers.NewReportSubscriptionHandler(reportSubscriptionService)
+	locationHandler := handlers.NewLocationHandler(locationService)
+	calendarHandler := handlers.NewCalendarHandler(calendarService)
+	feedbackHandler := handlers.NewFeedbackHandler(feedbackService)
+	performanceHandler := handlers.NewPerformanceHandler(performanceService)
+	capacityHandler := handlers.NewCapacityHandler(capacityService)
+	medicalRecordHandler := handlers.NewMedicalRecordHandler(medicalRecordService)
+	correctionRequestHandler := handlers.NewCorrectionRequestHandler(correctionRequestService)
+	organizationHandler := handlers.NewOrganizationHandler(organizationService)
+	downloadHandler := handlers.NewDownloadHandler(downloadService)
+	visitSummaryHandler := handlers.NewVisitSummaryHandler(visitSummaryService)
+	moderationHandler := handlers.NewModerationHandler(moderationService)
+	credentialHandler := handlers.NewCredentialHandler(credentialService)
+	profileHandler := handlers.NewProfileHandler(profileService)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	patientDocumentHandler := handlers.NewPatientDocumentHandler(patientDocumentService, downloadService)
+	bookingPolicyHandler := handlers.NewBookingPolicyHandler(bookingPolicyService)
+	appVersionHandler := handlers.NewAppVersionHandler(appVersionService)
+	attachmentPolicyHandler := handlers.NewAttachmentPolicyHandler(attachmentPolicyService)
+	brandingHandler := handlers.NewBrandingHandler(brandingService)
+	triageHandler := handlers.NewTriageHandler(triageService)
+	interpreterHandler := handlers.NewInterpreterHandler(interpreterService)
+	complaintHandler := handlers.NewComplaintHandler(complaintService)
+	legalHandler := handlers.NewLegalHandler(legalService)
+	presenceHandler := handlers.NewPresenceHandler(presenceService)
+	onboardingHandler := handlers.NewOnboardingHandler(onboardingService)
+
+	realtimeHandler := handlers.NewRealtimeHandler(hub, middleware.CheckOrigin(cfg.AllowedOrigins))
+	deviceHandler := handlers.NewDeviceHandler(deviceService)
+	demoHandler := handlers.NewDemoHandler(demoService)
+	backupHandler := handlers.NewBackupHandler(backupService)
+	hl7Handler := handlers.NewHL7Handler(hl7Service)
+	breakGlassHandler := handlers.NewBreakGlassHandler(breakGlassService)
+	scheduleConflictHandler := handlers.NewScheduleConflictHandler(scheduleConflictService)
+	slotSubscriptionHandler := handlers.NewSlotSubscriptionHandler(slotSubscriptionService)
+	healthHandler := handlers.NewHealthHandler(healthService)
+	docsHandler := handlers.NewDocsHandler()
+
+	// クライアントから受信するイベントの登録
+	hub.HandleFunc("video.signal", videoService.HandleSignal)
+	hub.HandleFunc("video.candidate", videoService.HandleCandidate)
+	// 接続・切断をオンライン状態に反映する
+	hub.Observe(presenceService)
+	hub.Start()
+
+	// 定期ジョブの登録
+	scheduler := jobs.NewScheduler()
+	// ジョブの連続した失敗を運用アラートで通知する
+	scheduler.Observe(opsMonitorService)
+	scheduler.Register("audit_archive", cfg.AuditArchiveInterval, auditArchiveService.RunArchiveJob)
+	scheduler.Register("audit_pseudonym_salt_rotation", time.Hour, auditService.RunPseudonymSaltRotationJob)
+	scheduler.Register("escalation_check", time.Minute, escalationService.RunEscalationJob)
+	scheduler.Register("pending_auto_decline", 5*time.Minute, absenceService.RunAutoDeclineJob)
+	scheduler.Register("task_reminders", 5*time.Minute, taskService.RunReminderJob)
+	scheduler.Register("async_sla_check", 5*time.Minute, appointmentService.RunAsyncSLAJob)
+	scheduler.Register("appointment_auto_complete", 5*time.Minute, appointmentService.RunAutoCompleteJob)
+	scheduler.Register("intake_deadline", 5*time.Minute, appointmentService.RunIntakeDeadlineJob)
+	scheduler.Register("appointment_reminders", time.Minute, appointmentService.RunReminderJob)
+	scheduler.Register("deferred_chat_push", time.Minute, pushDispatcher.RunDeferredChatPushJob)
+	scheduler.Register("prescription_ack_reminders", 15*time.Minute, prescriptionService.RunAckReminderJob)
+	scheduler.Register("unread_message_emails", 5*time.Minute, emailNotificationService.RunUnreadMessageEmailJob)
+	scheduler.Register("account_anonymization", time.Hour, accountService.RunAnonymizationJob)
+	scheduler.Register("presence_heartbeat", time.Minute, presenceService.RunHeartbeatJob)
+	scheduler.Register("incoming_call_timeout", 15*time.Second, videoService.RunCallTimeoutJob)
+	scheduler.Register("unknown_login_cleanup", time.Hour, authService.RunUnknownLoginCleanupJob)
+	scheduler.Register("ice_candidate_cleanup", 10*time.Minute, videoService.RunICECandidateCleanupJob)
+	scheduler.Register("video_signaling_presence", time.Minute, videoService.RunSignalingPresenceJob)
+	scheduler.Register("transcription", time.Minute, transcriptionService.RunTranscriptionJob)
+	scheduler.Register("document_ocr", time.Minute, patientDocumentService.RunOCRJob)
+	scheduler.Register("slot_templates", time.Hour, slotService.RunSlotTemplateJob)
+	scheduler.Register("slot_opening_notifications", time.Minute, slotSubscriptionService.RunNotificationJob)
+	scheduler.Register("ops_health_check", cfg.AlertHealthCheckInterval, opsMonitorService.RunHealthCheckJob)
+	scheduler.Register("demo_reset", demoResetInterval, demoService.RunResetJob)
+	scheduler.Register("database_backup", cfg.BackupInterval, backupService.RunBackupJob)
+	scheduler.Register("hl7_export", cfg.HL7ExportInterval, hl7Service.RunExportJob)
+	scheduler.Register("export_download_cleanup", time.Hour, downloadService.RunCleanupJob)
+	scheduler.Register("report_subscriptions", 15*time.Minute, reportSubscriptionService.RunDeliveryJob)
+	scheduler.Start()
+	defer scheduler.Stop()
 
 	// Ginルーターの設定
-	router := gin.Default()
+	// gin.Default() のロガーはクエリ文字列（WebSocket接続のトークン）を記録するため、ミドルウェアは個別に設定する
+	router := gin.New()
 
 	// ミドルウェアの設定
-	router.Use(middleware.CORS())
+	router.Use(middleware.CORS(cfg.AllowedOrigins))
 	router.Use(middleware.Logger())
 	router.Use(middleware.Recovery())
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Audit(auditService))
+
+	// 死活監視・準備状況の確認（ロードバランサー・オーケストレーター用、認証不要）
+	router.GET("/healthz", healthHandler.Healthz)
+	router.GET("/readyz", healthHandler.Readyz)
 
 	// APIルートの設定
 	api := router.Group("/api/v1")
+	// 最低バージョンより古いアプリからのリクエストはアップデートを求めて拒否する（バージョンの確認自体は対象外）
+	api.Use(middleware.RequireSupportedAppVersion(appVersionService, "/api/v1/app-version"))
 	{
 		// 認証
 		auth := api.Group("/auth")
 		{
 			auth.POST("/register", authHandler.Register)
-			auth.POST("/login", authHandler.Login)
+			auth.POST("/login", loginQuota, authHandler.Login)
+			auth.POST("/email-change/confirm", accountHandler.ConfirmEmailChange)
+			auth.POST("/email-change/cancel", accountHandler.CancelEmailChange)
+			auth.POST("/reactivate", accountHandler.ReactivateAccount)
 		}
 
+		// 公開中の利用規約等（未ログインでも参照可能）
+		api.GET("/legal/documents/current", legalHandler.GetCurrentDocuments)
+
+		// 診療枠の公開の通知の登録解除（通知のリンクから、未ログインでも可能）
+		api.POST("/slot-subscriptions/unsubscribe", slotSubscriptionHandler.UnsubscribeByToken)
+
+		// アプリのバージョンの確認（起動時に未ログインでも参照可能）
+		api.GET("/app-version", appVersionHandler.CheckVersion)
+
+		// クリニックの表記（ログイン画面等で未ログインでも参照可能）
+		api.GET("/branding", brandingHandler.GetBranding)
+
+		// APIドキュメント（Swagger UIとOpenAPIのドキュメント、未ログインでも参照可能）
+		api.GET("/docs", docsHandler.GetUI)
+		api.GET("/docs/openapi.json", docsHandler.GetSpec)
+
+		// 処方箋のQRコードによる照合（薬局等、未ログインでも参照可能）
+		api.GET("/prescriptions/verify/:code", prescriptionHandler.VerifyPrescription)
+
+		// ローカルに保存したチャットの添付ファイルの署名付きURLでのダウンロード（署名で検証する）
+		api.GET("/files/chat-attachments/*key", chatHandler.GetSignedAttachment)
+
+		// 決済代行サービスからの支払いの結果の通知（署名で検証する）
+		api.POST("/payments/webhook", paymentHandler.HandleWebhook)
+
 		// 認証が必要なルート
 		protected := api.Group("")
 		protected.Use(middleware.Auth(cfg.JWTSecret))
+		// 退会済みアカウントの発行済みトークンを無効にする
+		protected.Use(middleware.RequireActiveAccount(accountService))
+		// 利用中のユーザーのアクセストークンを有効期限の前に更新する（ログインからの最大有効期間まで）
+		protected.Use(middleware.SlidingSession(authService))
+		// 最新の利用規約等に未同意の場合は同意関連のAPI以外を拒否する
+		protected.Use(middleware.RequireLegalAcceptance(legalService, "/api/v1/legal/"))
+		// API利用を最終アクティビティとして記録する（オンライン状態の判定用）
+		protected.Use(middleware.TrackActivity(presenceService))
 		{
-			// 医師関連（/meルートを最初に定義）
+			// WebSocket接続（チャット・ビデオ通話のシグナリング・通知）
+			protected.GET("/ws", realtimeHandler.Connect)
+			// 予約の状態の変化のみを受信する接続
+			protected.GET("/ws/appointments", realtimeHandler.ConnectAppointments)
+
+			// プッシュ通知を受け取る端末
+			protected.GET("/users/me/devices", deviceHandler.GetDevices)
+			protected.POST("/users/me/devices", deviceHandler.RegisterDevice)
+			protected.DELETE("/users/me/devices/:id", deviceHandler.DeleteDevice)
+			// 保存した検索条件（予約一覧は医師、監査ログは管理者のみ）
+			protected.GET("/users/me/saved-filters", savedFilterHandler.GetSavedFilters)
+			protected.POST("/users/me/saved-filters", savedFilterHandler.CreateSavedFilter)
+			protected.PUT("/users/me/saved-filters/:id", savedFilterHandler.UpdateSavedFilter)
+			protected.DELETE("/users/me/saved-filters/:id", savedFilterHandler.DeleteSavedFilter)
+
+			// オンライン状態の表示設定
+			protected.GET("/auth/me/presence", presenceHandler.GetPresenceSettings)
+			protected.PUT("/auth/me/presence", presenceHandler.UpdatePresenceSettings)
+
+			// 退会
+			protected.DELETE("/auth/me", accountHandler.DeactivateAccount)
+
+			// ログイン用メールアドレス・電話番号の変更
+			protected.POST("/auth/me/email", accountHandler.RequestEmailChange)
+			protected.POST("/auth/me/phone", accountHandler.RequestPhoneChange)
+			protected.POST("/auth/me/phone/verify", accountHandler.VerifyPhoneChange)
+
+			// 医師関連
 			doctors := protected.Group("/doctors")
 			{
-				// /meルートを最初に定義（パラメータ付きルートより優先）
-				doctors.GET("/me/slots", slotHandler.GetSlots)
-				doctors.POST("/me/slots", slotHandler.CreateSlot)
-				doctors.PUT("/me/slots/:id", slotHandler.UpdateSlot)
-				doctors.DELETE("/me/slots/:id", slotHandler.DeleteSlot)
-				doctors.GET("/me/profile", func(c *gin.Context) {
-					userID, _ := c.Get("user_id")
-					profile, err := userRepo.FindDoctorProfileByUserID(userID.(uint))
-					if err != nil {
-						c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
-						return
-					}
-					c.JSON(http.StatusOK, gin.H{"profile": profile})
-				})
-				doctors.PUT("/me/profile", func(c *gin.Context) {
-					log.Printf("PUT /doctors/me/profile called")
-					userID, _ := c.Get("user_id")
-					log.Printf("User ID: %v", userID)
-					
-					var req map[string]interface{}
-					if err := c.ShouldBindJSON(&req); err != nil {
-						log.Printf("JSON binding error: %v", err)
-						c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-						return
-					}
-					
-					log.Printf("Request body: %+v", req)
-					
-					profile, err := userRepo.FindDoctorProfileByUserID(userID.(uint))
-					if err != nil {
-						log.Printf("Profile not found error: %v", err)
-						c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
-						return
-					}
-					
-					log.Printf("Current profile: %+v", profile)
-					
-					// プロフィールの更新
-					if name, ok := req["name"].(string); ok {
-						profile.Name = name
-					}
-					if specialty, ok := req["specialty"].(string); ok {
-						profile.Specialty = specialty
-					}
-					if licenseNumber, ok := req["licenseNumber"].(string); ok {
-						profile.LicenseNumber = licenseNumber
-					}
-					if bio, ok := req["bio"].(string); ok {
-						profile.Bio = bio
-					}
-					
-					log.Printf("Updated profile: %+v", profile)
-					
-					if err := userRepo.UpdateDoctorProfile(profile); err != nil {
-						log.Printf("Update error: %v", err)
-						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
-						return
-					}
-					
-					log.Printf("Profile updated successfully")
-					c.JSON(http.StatusOK, gin.H{"message": "Profile updated successfully", "profile": profile})
-				})
+				doctors.GET("/online", presenceHandler.GetOnlineDoctors)
 			}
 
-			// 患者関連
-			patients := protected.Group("/patients")
+			// 医師本人の設定・予約（医師以外はルーターで拒否する）
+			doctorSelf := protected.Group("/doctors/me", middleware.RequireRole(policy.DoctorSelf...))
+			{
+				doctorSelf.GET("/slots", slotHandler.GetSlots)
+				doctorSelf.POST("/slots", slotHandler.CreateSlot)
+				doctorSelf.PUT("/slots/:id", slotHandler.UpdateSlot)
+				doctorSelf.DELETE("/slots/:id", slotHandler.DeleteSlot)
+				doctorSelf.GET("/slot-templates", slotHandler.GetSlotTemplates)
+				doctorSelf.POST("/slot-templates", slotHandler.CreateSlotTemplate)
+				doctorSelf.DELETE("/slot-templates/:id", slotHandler.DeleteSlotTemplate)
+				doctorSelf.PUT("/presence", presenceHandler.UpdatePresence)
+				doctorSelf.GET("/auto-reply", autoReplyHandler.GetAutoReply)
+				doctorSelf.PUT("/auto-reply", autoReplyHandler.UpdateAutoReply)
+				doctorSelf.GET("/time-off", absenceHandler.GetTimeOffs)
+				doctorSelf.POST("/time-off", absenceHandler.CreateTimeOff)
+				doctorSelf.DELETE("/time-off/:id", absenceHandler.DeleteTimeOff)
+				// 休診中の代診医の指定（期間内の確定済みの予約は患者の同意を得て引き継ぐ）
+				doctorSelf.GET("/coverages", coverageHandler.GetCoverages)
+				doctorSelf.POST("/coverages", coverageHandler.CreateCoverage)
+				doctorSelf.DELETE("/coverages/:id", coverageHandler.CancelCoverage)
+				doctorSelf.GET("/credentials", credentialHandler.GetMyCredentials)
+				doctorSelf.POST("/credentials", uploadQuota, credentialHandler.UploadCredential)
+				doctorSelf.PUT("/credentials/:id/visibility", credentialHandler.UpdateVisibility)
+				doctorSelf.DELETE("/credentials/:id", credentialHandler.DeleteCredential)
+				doctorSelf.GET("/profile", profileHandler.GetDoctorProfile)
+				doctorSelf.PUT("/profile", profileHandler.UpdateDoctorProfile)
+				doctorSelf.GET("/utilization", utilizationHandler.GetUtilization)
+				doctorSelf.GET("/utilization/trend", utilizationHandler.GetUtilizationTrend)
+				doctorSelf.GET("/utilization/export", exportQuota, utilizationHandler.ExportUtilization)
+
+				// 医師の予約
+				doctorSelf.GET("/appointments", appointmentHandler.GetDoctorAppointments)
+				doctorSelf.PUT("/appointments/:id/status", appointmentHandler.UpdateAppointmentStatus)
+				doctorSelf.PUT("/appointments/:id/close", appointmentHandler.CloseAsyncConsultation)
+				doctorSelf.POST("/appointments/:id/delay", appointmentHandler.ReportDelay)
+				doctorSelf.PUT("/appointments/:id/tags", tagHandler.SetAppointmentTags)
+				// 予約のタグと、タグ・ステータスで絞り込んだ予約一覧の保存
+				doctorSelf.GET("/tags", tagHandler.GetTags)
+				doctorSelf.POST("/tags", tagHandler.CreateTag)
+				doctorSelf.PUT("/tags/:id", tagHandler.UpdateTag)
+				doctorSelf.DELETE("/tags/:id", tagHandler.DeleteTag)
+				doctorSelf.GET("/appointment-views", tagHandler.GetViews)
+				doctorSelf.POST("/appointment-views", tagHandler.CreateView)
+				doctorSelf.DELETE("/appointment-views/:id", tagHandler.DeleteView)
+				doctorSelf.GET("/async-consultations", appointmentHandler.GetDoctorAsyncQueue)
+				doctorSelf.GET("/documents/search", patientDocumentHandler.SearchDocuments)
+				// 予定の不整合（診療枠のない予約・重複した予約・公開中のままの過去の枠）の確認と解消
+				doctorSelf.GET("/schedule/conflicts", scheduleConflictHandler.GetConflicts)
+				// 印刷用の週間予定表（?week=YYYY-MM-DD&initials=true で患者名をイニシャルのみにする）
+				doctorSelf.GET("/schedule/pdf", exportQuota, rosterHandler.GetDoctorSchedulePDF)
+				doctorSelf.POST("/schedule/conflicts/fix", scheduleConflictHandler.ApplyFix)
+			}
+
+			// 予約の詳細（担当医師・通訳者も参照するため患者ロールに限定しない）
+			protected.GET("/patients/appointments/:id", appointmentHandler.GetAppointmentDetails)
+
+			// 患者関連（患者以外はルーターで拒否する）
+			patients := protected.Group("/patients", middleware.RequireRole(policy.PatientSelf...))
 			{
 				patients.GET("/appointments", appointmentHandler.GetPatientAppointments)
 				patients.POST("/appointments", appointmentHandler.CreateAppointment)
-				patients.GET("/appointments/:id", appointmentHandler.GetAppointmentDetails)
+				patients.POST("/appointments/instant", appointmentHandler.CreateInstantAppointment)
+				patients.POST("/appointments/async", appointmentHandler.CreateAsyncAppointment)
 				patients.PUT("/appointments/:id/cancel", appointmentHandler.CancelAppointment)
+				patients.PUT("/appointments/:id/triage", appointmentHandler.AttachTriage)
+				patients.GET("/appointments/:id/alternatives", absenceHandler.GetAlternatives)
+				patients.GET("/me/appointment-transfers", coverageHandler.GetMyTransfers)
+				patients.PUT("/me/appointment-transfers/:id/consent", coverageHandler.RespondTransfer)
+				patients.GET("/me/invoices", paymentHandler.GetMyInvoices)
+				patients.GET("/me/invoices/:id", paymentHandler.GetInvoice)
+				patients.POST("/me/invoices/:id/pay", paymentHandler.PayInvoice)
+				patients.GET("/appointments/:id/summary", visitSummaryHandler.GetSummary)
+				patients.POST("/appointments/:id/documents", patientDocumentHandler.ShareWithAppointment)
+				patients.DELETE("/appointments/:id/documents/:documentId", patientDocumentHandler.RevokeShare)
+				patients.GET("/prescriptions/unacknowledged", prescriptionHandler.GetUnacknowledgedPrescriptions)
+				patients.PUT("/prescriptions/:id/ack", prescriptionHandler.AcknowledgePrescription)
+				patients.GET("/me/access-log", auditHandler.GetMyAccessLog)
+				patients.GET("/me/dashboard", dashboardHandler.GetPatientDashboard)
+
+				// プロフィールとオンボーディング
+				patients.GET("/me/profile", profileHandler.GetPatientProfile)
+				patients.PUT("/me/profile", profileHandler.UpdatePatientProfile)
+				patients.GET("/me/profile/completeness", onboardingHandler.GetCompleteness)
+				patients.GET("/me/onboarding", onboardingHandler.GetOnboarding)
+				patients.PUT("/me/onboarding/:step", onboardingHandler.SubmitStep)
+				patients.POST("/me/onboarding/:step/skip", onboardingHandler.SkipStep)
+
+				// 医師の診療枠の公開の通知
+				patients.GET("/me/slot-subscriptions", slotSubscriptionHandler.GetSubscriptions)
+				patients.POST("/me/slot-subscriptions", slotSubscriptionHandler.Subscribe)
+				patients.DELETE("/me/slot-subscriptions/:id", slotSubscriptionHandler.Unsubscribe)
+
+				// 有料プラン（予約ごとのチャット・ビデオの利用上限の緩和）
+				patients.GET("/me/subscription", subscriptionHandler.GetMySubscription)
+				patients.POST("/me/subscription", subscriptionHandler.Subscribe)
+
+				// 過去の診療記録（検査結果・紹介状など）
+				patients.GET("/me/documents", patientDocumentHandler.GetMyDocuments)
+				patients.POST("/me/documents", uploadQuota, patientDocumentHandler.UploadDocument)
+				patients.DELETE("/me/documents/:id", patientDocumentHandler.DeleteDocument)
+
+				// 家族アカウント
+				patients.GET("/me/dependents", dependentHandler.GetDependents)
+				patients.POST("/me/dependents", dependentHandler.CreateDependent)
+				patients.PUT("/me/dependents/:id", dependentHandler.UpdateDependent)
+				patients.DELETE("/me/dependents/:id", dependentHandler.DeleteDependent)
+				patients.GET("/me/dependents/:id/appointments", dependentHandler.GetDependentAppointments)
+
+				// 予約前の症状チェック
+				patients.GET("/me/triage", triageHandler.GetTriageAssessments)
+				patients.POST("/me/triage", triageHandler.SubmitTriage)
+				patients.GET("/me/triage/:id", triageHandler.GetTriageAssessment)
+
+				// 診療録の履歴（?dependent_id= で家族の分）
+				patients.GET("/me/medical-records", medicalRecordHandler.GetMyRecords)
 			}
 
-			// 医師の予約取得エンドポイント
-			protected.GET("/doctors/me/appointments", appointmentHandler.GetDoctorAppointments)
-			protected.PUT("/doctors/me/appointments/:id/status", appointmentHandler.UpdateAppointmentStatus)
+			// 通訳者関連
+			interpreters := protected.Group("/interpreters")
+			{
+				interpreters.GET("", interpreterHandler.GetInterpreters)
+			}
+
+			// 通訳者本人の設定・予約（通訳者以外はルーターで拒否する）
+			interpreterSelf := protected.Group("/interpreters/me", middleware.RequireRole(policy.InterpreterSelf...))
+			{
+				interpreterSelf.GET("/profile", interpreterHandler.GetProfile)
+				interpreterSelf.PUT("/profile", interpreterHandler.UpdateProfile)
+				interpreterSelf.GET("/slots", interpreterHandler.GetSlots)
+				interpreterSelf.POST("/slots", interpreterHandler.CreateSlot)
+				interpreterSelf.DELETE("/slots/:id", interpreterHandler.DeleteSlot)
+				interpreterSelf.GET("/appointments", interpreterHandler.GetAppointments)
+			}
 
 			// 医師一覧（患者用）
-			protected.GET("/doctors", func(c *gin.Context) {
-				// 実際の医師データを取得
-				doctors, err := userRepo.FindDoctors()
-				if err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch doctors"})
-					return
-				}
-				c.JSON(http.StatusOK, gin.H{"doctors": doctors})
-			})
+			protected.GET("/doctors", profileHandler.ListDoctors)
 
 					// 利用可能な診療枠（患者用）
 		protected.GET("/doctors/:doctorId/slots", slotHandler.GetAvailableSlots)
+		protected.GET("/doctors/:doctorId/credentials", credentialHandler.GetDoctorCredentials)
+		protected.GET("/credentials/:id/file", credentialHandler.GetCredentialFile)
+
+		// 症状チェックの問診票
+		protected.GET("/triage/questionnaire", triageHandler.GetQuestionnaire)
 
 		// チャット機能
 		chat := protected.Group("/appointments/:appointmentId/chat")
 		{
 			chat.GET("/messages", chatHandler.GetMessages)
 			chat.POST("/messages", chatHandler.SendMessage)
-			chat.POST("/attachments", chatHandler.UploadAttachment)
+			chat.POST("/attachments", uploadQuota, chatHandler.UploadAttachment)
+			chat.GET("/attachments/:id", chatHandler.GetAttachment)
+			chat.GET("/attachments/:id/url", chatHandler.GetAttachmentURL)
 			chat.PUT("/read", chatHandler.MarkAsRead)
 			chat.GET("/unread-count", chatHandler.GetUnreadCount)
+			chat.GET("/presence", presenceHandler.GetAppointmentPresence)
+			chat.POST("/messages/:messageId/flag", moderationHandler.FlagMessage)
 		}
+		protected.GET("/chat/unread-summary", chatHandler.GetUnreadSummary)
 
 		// 処方管理
 		prescriptions := protected.Group("/appointments/:appointmentId/prescriptions")
@@ -202,6 +878,7 @@ func main() {
 			prescriptions.GET("", prescriptionHandler.GetPrescriptions)
 			prescriptions.POST("", prescriptionHandler.CreatePrescription)
 			prescriptions.GET("/:id", prescriptionHandler.GetPrescriptionDetails)
+			prescriptions.GET("/:id/pdf", prescriptionHandler.GetPrescriptionPDF)
 			prescriptions.PUT("/:id", prescriptionHandler.UpdatePrescription)
 			prescriptions.DELETE("/:id", prescriptionHandler.DeletePrescription)
 		}
@@ -217,15 +894,321 @@ func main() {
 			video.PUT("/sessions/:sessionId/end", videoHandler.EndVideoSession)
 			video.GET("/sessions/:sessionId/offer", videoHandler.GetWebRTCOffer)
 			video.POST("/sessions/:sessionId/answer", videoHandler.SetWebRTCAnswer)
+			video.POST("/sessions/:sessionId/token/refresh", videoHandler.RefreshToken)
+			video.POST("/sessions/:sessionId/candidates", videoHandler.AddICECandidate)
+			video.GET("/sessions/:sessionId/candidates", videoHandler.GetICECandidates)
+			video.POST("/sessions/:sessionId/accept", videoHandler.AcceptCall)
+			video.POST("/sessions/:sessionId/decline", videoHandler.DeclineCall)
+			video.GET("/sessions/:sessionId/participants", videoHandler.GetCallParticipants)
+			video.GET("/sessions/:sessionId/signaling", videoHandler.ConnectSignaling)
+			video.GET("/sessions/:sessionId/peers", videoHandler.GetSignalingPeers)
+			video.GET("/sessions/:sessionId/files", chatHandler.GetSessionFiles)
+			video.POST("/sessions/:sessionId/files", uploadQuota, chatHandler.ShareSessionFile)
+			video.PUT("/sessions/:sessionId/recording-consent", videoHandler.SetRecordingConsent)
+			video.POST("/sessions/:sessionId/recordings", recordingHandler.StartRecording)
+			video.GET("/sessions/:sessionId/recordings", recordingHandler.GetRecordings)
+			video.GET("/sessions/:sessionId/recordings/:recordingId", recordingHandler.GetRecording)
+			video.PUT("/sessions/:sessionId/recordings/:recordingId/stop", recordingHandler.StopRecording)
+			video.POST("/sessions/:sessionId/recordings/:recordingId/file", uploadQuota, recordingHandler.UploadRecording)
+			video.GET("/sessions/:sessionId/recordings/:recordingId/file", exportQuota, recordingHandler.GetRecordingFile)
+			video.POST("/sessions/:sessionId/recording", uploadQuota, transcriptHandler.UploadRecording)
+			video.GET("/sessions/:sessionId/transcript", transcriptHandler.GetTranscript)
+			video.GET("/sessions/:sessionId/transcript/export", exportQuota, transcriptHandler.ExportTranscript)
+		}
+
+		// 文字起こしの検索
+		protected.GET("/transcripts/search", transcriptHandler.SearchTranscripts)
+
+		// 緊急エスカレーション
+		escalations := protected.Group("/appointments/:appointmentId/escalations")
+		{
+			escalations.POST("", escalationHandler.RaiseEscalation)
+			escalations.GET("", escalationHandler.GetEscalations)
+			escalations.PUT("/:id/acknowledge", escalationHandler.AcknowledgeEscalation)
+			escalations.PUT("/:id/resolve", escalationHandler.ResolveEscalation)
+		}
+
+		// 予約の共有タスク
+		tasks := protected.Group("/appointments/:appointmentId/tasks")
+		{
+			tasks.GET("", taskHandler.GetTasks)
+			tasks.POST("", taskHandler.CreateTask)
+			tasks.PUT("/:id", taskHandler.UpdateTask)
+			tasks.PUT("/:id/complete", taskHandler.CompleteTask)
+			tasks.PUT("/:id/reopen", taskHandler.ReopenTask)
+			tasks.DELETE("/:id", taskHandler.DeleteTask)
+		}
+		protected.GET("/tasks/me", taskHandler.GetMyTasks)
+
+		// 患者報告アウトカム（PROM）の質問票（医師が診療後に割り当て、患者が回答する）
+		proms := protected.Group("/appointments/:appointmentId/proms")
+		{
+			proms.POST("", promHandler.AssignPROM)
+			proms.GET("", promHandler.GetAppointmentPROMs)
+			proms.GET("/trends", promHandler.GetPROMTrends)
+		}
+		protected.GET("/proms/instruments", promHandler.GetInstruments)
+		protected.GET("/proms/me", promHandler.GetMyPROMs)
+		protected.POST("/proms/:id/responses", promHandler.SubmitPROM)
+		protected.PUT("/proms/:id/cancel", promHandler.CancelPROM)
+
+		// 診療記録からのICD-10コード候補（医師の確認後に問題リストへ追加）
+		coding := protected.Group("/appointments/:appointmentId/coding")
+		{
+			coding.POST("/suggestions", clinicalCodingHandler.SuggestCodes)
+			coding.GET("/suggestions", clinicalCodingHandler.GetSuggestions)
+			coding.POST("/suggestions/review", clinicalCodingHandler.ReviewSuggestions)
+		}
+		protected.GET("/appointments/:appointmentId/problems", clinicalCodingHandler.GetProblemList)
+
+		// 予約のカレンダーへの取り込み（.ics、対面診療は所在地と経路案内を含む）
+		protected.GET("/appointments/:appointmentId/calendar.ics", calendarHandler.ExportAppointment)
+
+		// 対面診療を予約できるクリニックの所在地
+		protected.GET("/locations", locationHandler.GetLocations)
+
+		// エクスポートの再取得（通信が途切れた場合にトークンでRangeを指定して途中から取得する）
+		protected.GET("/downloads/:token", downloadHandler.ResumeDownload)
+
+		// 診療録（担当医師が診察中・診察後に記載し、患者本人も閲覧できる）
+		protected.PUT("/appointments/:appointmentId/medical-record", medicalRecordHandler.SaveRecord)
+		protected.GET("/appointments/:appointmentId/medical-record", medicalRecordHandler.GetRecord)
+		protected.GET("/appointments/:appointmentId/medical-records", medicalRecordHandler.GetAppointmentHistory)
+
+		// 診療録の訂正の依頼（患者が依頼し、記載した医師が承認・却下する）
+		corrections := protected.Group("/correction-requests")
+		{
+			corrections.POST("", correctionRequestHandler.CreateRequest)
+			corrections.GET("", correctionRequestHandler.GetRequests)
+			corrections.GET("/:id", correctionRequestHandler.GetRequest)
+			corrections.PUT("/:id/resolve", correctionRequestHandler.ResolveRequest)
+			corrections.PUT("/:id/withdraw", correctionRequestHandler.WithdrawRequest)
+			corrections.PUT("/:id/assign", requireAdmin, correctionRequestHandler.AssignRequest)
+		}
+
+		// 診療後の満足度の評価（患者が評価し、担当医師も閲覧できる）
+		protected.POST("/appointments/:appointmentId/feedback", feedbackHandler.SubmitFeedback)
+		protected.GET("/appointments/:appointmentId/feedback", feedbackHandler.GetFeedback)
+
+		// 予約に共有された診療記録（担当医師・患者本人のみ）
+		protected.GET("/appointments/:appointmentId/documents", patientDocumentHandler.GetSharedDocuments)
+		protected.GET("/documents/:id/file", patientDocumentHandler.GetDocumentFile)
+
+		// 医師間の症例相談（患者の同意が必要）
+		caseDiscussions := protected.Group("/appointments/:appointmentId/case-discussions")
+		{
+			caseDiscussions.POST("", caseDiscussionHandler.OpenCaseDiscussion)
+			caseDiscussions.GET("", caseDiscussionHandler.GetCaseDiscussions)
+			caseDiscussions.PUT("/:id/consent", caseDiscussionHandler.RespondConsent)
+			caseDiscussions.PUT("/:id/close", caseDiscussionHandler.CloseCaseDiscussion)
+		}
+
+		caseThreads := protected.Group("/case-discussions")
+		{
+			caseThreads.GET("", caseDiscussionHandler.GetMyCaseDiscussions)
+			caseThreads.GET("/:id/messages", caseDiscussionHandler.GetMessages)
+			caseThreads.POST("/:id/messages", caseDiscussionHandler.SendMessage)
+			caseThreads.PUT("/:id/read", caseDiscussionHandler.MarkAsRead)
+			caseThreads.GET("/:id/unread-count", caseDiscussionHandler.GetUnreadCount)
+		}
+
+		// 通知
+		notifications := protected.Group("/notifications")
+		{
+			notifications.GET("", notificationHandler.GetNotifications)
+			notifications.PUT("/read-all", notificationHandler.MarkAllAsRead)
+			notifications.GET("/preferences", notificationHandler.GetPreferences)
+			notifications.PUT("/preferences", notificationHandler.UpdatePreferences)
+			notifications.PUT("/:id/read", notificationHandler.MarkAsRead)
+		}
+
+		// 苦情・異議申し立て
+		complaints := protected.Group("/complaints")
+		{
+			complaints.POST("", complaintHandler.FileComplaint)
+			complaints.GET("", complaintHandler.GetComplaints)
+			complaints.GET("/me", complaintHandler.GetMyComplaints)
+			complaints.GET("/:id", complaintHandler.GetComplaint)
+			complaints.PUT("/:id/assign", complaintHandler.AssignComplaint)
+			complaints.PUT("/:id/status", complaintHandler.UpdateComplaintStatus)
+			complaints.POST("/:id/chat-transcript", complaintHandler.ViewChatTranscript)
+		}
+
+		// 利用規約・プライバシーポリシー
+		legal := protected.Group("/legal")
+		{
+			legal.GET("/pending", legalHandler.GetPendingDocuments)
+			legal.POST("/accept", legalHandler.AcceptDocuments)
+			legal.GET("/acceptances", legalHandler.GetAcceptances)
+			legal.GET("/documents", legalHandler.GetDocuments)
+			legal.POST("/documents", legalHandler.CreateDocument)
+			legal.PUT("/documents/:id/publish", legalHandler.PublishDocument)
+		}
+
+		// 重複患者の検出・統合（管理者用）
+		patientAdmin := protected.Group("/admin/patients", requireAdmin)
+		{
+			patientAdmin.GET("/duplicates", patientMergeHandler.GetDuplicates)
+			patientAdmin.POST("/merge", patientMergeHandler.MergePatients)
+		}
+
+		// 緊急時アクセス（予約による権限がない患者の診療記録を理由を記録して閲覧する）
+		breakGlass := protected.Group("/break-glass", middleware.RequireRole(policy.BreakGlass...))
+		{
+			breakGlass.POST("", breakGlassHandler.OpenAccess)
+			breakGlass.GET("/patients/:patientId/record", breakGlassHandler.GetEmergencyRecord)
+			breakGlass.PUT("/:id/end", breakGlassHandler.EndAccess)
+		}
+		protected.GET("/admin/break-glass", requireAdmin, breakGlassHandler.GetAccesses)
+		protected.PUT("/admin/doctors/:id/break-glass", requireAdmin, breakGlassHandler.SetAuthorization)
+
+		// ログインの失敗が続いたアカウントのロックの解除（管理者用）
+		protected.POST("/admin/users/:id/unlock", requireAdmin, authHandler.UnlockAccount)
+
+		// 通訳者アカウントの作成（通訳者は自己登録できない）
+		protected.POST("/admin/interpreters", requireAdmin, authHandler.ProvisionInterpreter)
+
+		// 予約受付ルール（受付時間・受付期間）
+		protected.GET("/booking-policy", bookingPolicyHandler.GetPolicy)
+		protected.GET("/admin/booking-policy", requireAdmin, bookingPolicyHandler.GetPolicy)
+		protected.PUT("/admin/booking-policy", requireAdmin, bookingPolicyHandler.UpdatePolicy)
+
+		// アプリの最低バージョン（管理者用）
+		protected.GET("/admin/app-version", requireAdmin, appVersionHandler.GetPolicy)
+		protected.PUT("/admin/app-version", requireAdmin, appVersionHandler.UpdatePolicy)
+
+		// チャットの添付ファイルのロールごとの形式・サイズの制限
+		protected.GET("/attachment-policy", attachmentPolicyHandler.GetMyPolicy)
+		protected.GET("/admin/attachment-policies", requireAdmin, attachmentPolicyHandler.GetPolicies)
+		protected.PUT("/admin/attachment-policies/:role", requireAdmin, attachmentPolicyHandler.UpdatePolicy)
+
+		// クリニックの表記（PDF・メール・医師一覧に反映）
+		protected.GET("/admin/branding", requireAdmin, brandingHandler.GetBranding)
+		protected.PUT("/admin/branding", requireAdmin, brandingHandler.UpdateBranding)
+
+		// クリニックの所在地と、所在地ごとに対面診療を行う医師の管理（管理者用）
+		locations := protected.Group("/admin/locations", requireAdmin)
+		{
+			locations.GET("", locationHandler.GetAllLocations)
+			locations.POST("", locationHandler.CreateLocation)
+			locations.PUT("/:id", locationHandler.UpdateLocation)
+			locations.GET("/:id/doctors", locationHandler.GetLocationDoctors)
+			locations.PUT("/:id/doctors", locationHandler.SetLocationDoctors)
+		}
+
+		// チャットの通報のモデレーション（管理者用）
+		moderation := protected.Group("/admin/moderation/flags", requireAdmin)
+		{
+			moderation.GET("", moderationHandler.GetFlags)
+			moderation.GET("/:id", moderationHandler.GetFlag)
+			moderation.PUT("/:id/resolve", moderationHandler.ResolveFlag)
+		}
+
+		// 医師の予定の不整合の確認と解消（管理者用）
+		protected.GET("/admin/doctors/:id/schedule/conflicts", requireAdmin, scheduleConflictHandler.GetConflicts)
+		protected.POST("/admin/doctors/:id/schedule/conflicts/fix", requireAdmin, scheduleConflictHandler.ApplyFix)
+
+		// 全医師の勤務表（管理者用）
+		protected.GET("/admin/roster", requireAdmin, rosterHandler.GetRoster)
+
+		// 診療枠の稼働状況（管理者用）
+		utilizationAdmin := protected.Group("/admin/utilization", requireAdmin)
+		{
+			utilizationAdmin.GET("", utilizationHandler.GetUtilization)
+			utilizationAdmin.GET("/trend", utilizationHandler.GetUtilizationTrend)
+			utilizationAdmin.GET("/export", exportQuota, utilizationHandler.ExportUtilization)
+		}
+
+		// 医師ごとの実績（管理者用）
+		protected.GET("/admin/performance", requireAdmin, performanceHandler.GetPerformance)
+		protected.GET("/admin/performance/export", requireAdmin, exportQuota, performanceHandler.ExportPerformance)
+
+		// 無断キャンセル率による予約の受け入れ数のシミュレーション（管理者用）
+		protected.GET("/admin/capacity/simulation", requireAdmin, capacityHandler.SimulateCapacity)
+
+		// 入出金の元帳（管理者用、返金・ウォレットの付与・医師への支払いの記録）
+		ledgerAdmin := protected.Group("/admin/ledger", requireAdmin)
+		{
+			ledgerAdmin.GET("/accounts", ledgerHandler.GetAccounts)
+			ledgerAdmin.GET("/entries", ledgerHandler.GetEntries)
+			ledgerAdmin.GET("/report", ledgerHandler.GetReport)
+			ledgerAdmin.POST("/refunds", ledgerHandler.RecordRefund)
+			ledgerAdmin.POST("/wallet-credits", ledgerHandler.RecordWalletCredit)
+			ledgerAdmin.POST("/payouts", ledgerHandler.RecordPayout)
+		}
+
+		// 請求と一致しない確認待ちの支払いの承認・却下（管理者用）
+		protected.POST("/admin/payments/:id/review", requireAdmin, paymentHandler.ResolveHeldPayment)
+
+		// 定期レポート（稼働状況・入出金・監査ログ）のメール配信の登録（管理者用）
+		reportSubscriptions := protected.Group("/admin/report-subscriptions", requireAdmin)
+		{
+			reportSubscriptions.GET("", reportSubscriptionHandler.GetSubscriptions)
+			reportSubscriptions.POST("", reportSubscriptionHandler.CreateSubscription)
+			reportSubscriptions.PUT("/:id", reportSubscriptionHandler.UpdateSubscription)
+			reportSubscriptions.DELETE("/:id", reportSubscriptionHandler.DeleteSubscription)
+			reportSubscriptions.POST("/:id/send", reportSubscriptionHandler.SendNow)
 		}
 
-		// 監査ログ（管理者用）
+		// デモデータの初期化（管理者用、デモモードのみ）
+		if cfg.DemoMode {
+			protected.POST("/admin/demo/reset", requireAdmin, demoHandler.ResetDemo)
+		}
+
+		// 医師の資格証明書類の審査（管理者用）
+		credentialAdmin := protected.Group("/admin/credentials", requireAdmin)
+		{
+			credentialAdmin.GET("", credentialHandler.GetCredentialsForReview)
+			credentialAdmin.PUT("/:id/review", credentialHandler.ReviewCredential)
+		}
+
+		// データベースのバックアップ（管理者用）
+		backups := protected.Group("/admin/backups", requireAdmin)
+		{
+			backups.POST("", backupHandler.StartBackup)
+			backups.GET("", backupHandler.GetBackups)
+			backups.GET("/:id", backupHandler.GetBackup)
+		}
+
+		// HL7v2による医療機関への連携（管理者用）
+		hl7Admin := protected.Group("/admin/hl7/destinations", requireAdmin)
+		{
+			hl7Admin.GET("", hl7Handler.GetDestinations)
+			hl7Admin.POST("", hl7Handler.CreateDestination)
+			hl7Admin.PUT("/:id", hl7Handler.UpdateDestination)
+			hl7Admin.DELETE("/:id", hl7Handler.DeleteDestination)
+			hl7Admin.GET("/:id/messages", hl7Handler.GetMessages)
+		}
+
+		// 監査ログ（管理者用、利用者ごとのログは本人も閲覧できる）
 		audit := protected.Group("/audit")
 		{
-			audit.GET("/logs", auditHandler.GetAuditLogs)
+			audit.GET("/logs", requireAdmin, auditHandler.GetAuditLogs)
 			audit.GET("/users/:userId/logs", auditHandler.GetUserAuditLogs)
-			audit.GET("/entities/:entity/:entityId/logs", auditHandler.GetEntityAuditLogs)
-			audit.GET("/export", auditHandler.ExportAuditLogs)
+			audit.GET("/entities/:entity/:entityId/logs", requireAdmin, auditHandler.GetEntityAuditLogs)
+			audit.GET("/export", requireAdmin, exportQuota, auditHandler.ExportAuditLogs)
+			audit.GET("/archives", requireAdmin, auditArchiveHandler.GetArchives)
+			audit.GET("/archives/search", requireAdmin, auditArchiveHandler.SearchArchivedLogs)
+			audit.POST("/archives/:id/rehydrate", requireAdmin, auditArchiveHandler.RehydrateArchive)
+		}
+
+		// 組織（医療機関）と、組織の管理者がスタッフに委任したロールの範囲での予約・請求・監査ログの操作
+		protected.GET("/organizations", organizationHandler.GetOrganizations)
+		protected.POST("/admin/organizations", requireAdmin, organizationHandler.CreateOrganization)
+		organizations := protected.Group("/organizations/:orgId")
+		{
+			orgManage := middleware.RequireOrgPermission(organizationService, policy.OrgPermManage)
+			organizations.GET("/members", orgManage, organizationHandler.GetMembers)
+			organizations.POST("/members", orgManage, organizationHandler.AddMember)
+			organizations.DELETE("/members/:userId", orgManage, organizationHandler.RemoveMember)
+			organizations.PUT("/members/:userId/roles", orgManage, organizationHandler.SetMemberRoles)
+
+			orgAppointments := middleware.RequireOrgPermission(organizationService, policy.OrgPermAppointments)
+			organizations.GET("/appointments", orgAppointments, organizationHandler.GetAppointments)
+			organizations.PUT("/appointments/:id/cancel", orgAppointments, organizationHandler.CancelAppointment)
+
+			organizations.GET("/invoices", middleware.RequireOrgPermission(organizationService, policy.OrgPermBilling), organizationHandler.GetInvoices)
+			organizations.GET("/audit-logs", middleware.RequireOrgPermission(organizationService, policy.OrgPermAudit), organizationHandler.GetAuditLogs)
 		}
 		}
 	}
@@ -238,8 +1221,31 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("Server starting on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: router,
+	}
+
+	go func() {
+		log.Printf("Server starting on port %s", port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	// 停止シグナルを受けたらWebSocket接続を切り離してから処理中のリクエストの完了を待つ
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	log.Println("Shutting down server...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := hub.Drain(shutdownCtx); err != nil {
+		log.Printf("Warning: Realtime connections were not drained cleanly: %v", err)
+	}
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Warning: Server shutdown did not complete: %v", err)
 	}
+	log.Println("Server stopped")
 }
@@ -0,0 +1,198 @@
+// backup データベースのバックアップ・リストア用のコマンド
+//
+//	go run ./cmd/backup dump                       バックアップを作成して履歴に記録する
+//	go run ./cmd/backup list [-limit 20]           バックアップの履歴を表示する
+//	go run ./cmd/backup restore -id 12 -confirm    履歴のバックアップからリストアする（チェックサムを確認）
+//	go run ./cmd/backup restore -file path -confirm
+//
+// 接続先・保存先はAPIサーバーと同じ環境変数（DATABASE_URL, BACKUP_*）で指定する
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/backup"
+	"online_medical_consultation_app/backend/internal/config"
+	"online_medical_consultation_app/backend/internal/database"
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+	"online_medical_consultation_app/backend/internal/storage"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found")
+	}
+	cfg := config.Load()
+
+	// APIサーバーと同じ接続先を使用する
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		databaseURL = "host=localhost user=postgres password=postgres dbname=medical_consultation port=5432 sslmode=disable"
+	}
+
+	runner, err := backup.NewRunner(backup.Config{
+		DatabaseURL: databaseURL,
+		Storage: storage.Config{
+			Provider:          cfg.BackupStorage,
+			LocalDir:          cfg.BackupDir,
+			S3Bucket:          cfg.BackupS3Bucket,
+			S3Region:          cfg.BackupS3Region,
+			S3Endpoint:        cfg.BackupS3Endpoint,
+			S3AccessKeyID:     cfg.BackupS3AccessKeyID,
+			S3SecretAccessKey: cfg.BackupS3SecretAccessKey,
+			Timeout:           cfg.BackupStorageTimeout,
+		},
+		SpoolDir:      cfg.BackupSpoolDir,
+		PgDumpPath:    cfg.BackupPgDumpPath,
+		PgRestorePath: cfg.BackupPgRestorePath,
+		Timeout:       cfg.BackupTimeout,
+	})
+	if err != nil {
+		log.Fatal("Invalid backup configuration: ", err)
+	}
+
+	switch os.Args[1] {
+	case "dump":
+		dump(databaseURL, runner)
+	case "list":
+		list(databaseURL, os.Args[2:])
+	case "restore":
+		restore(databaseURL, runner, os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: backup dump | list [-limit N] | restore (-id ID | -file PATH) -confirm")
+	os.Exit(2)
+}
+
+// dump バックアップの作成（実行記録はAPIの履歴にも表示される）
+func dump(databaseURL string, runner *backup.Runner) {
+	backupRepo := repositories.NewBackupRepository(connect(databaseURL))
+
+	now := time.Now()
+	running, err := backupRepo.CountRunning()
+	if err != nil {
+		log.Fatal("Failed to check running backups: ", err)
+	}
+	if running > 0 {
+		log.Fatal("A backup is already running")
+	}
+
+	run := &models.BackupRun{
+		Status:    "running",
+		Source:    "cli",
+		ObjectKey: backup.ObjectKeyFor(now),
+		StartedAt: now,
+	}
+	if err := backupRepo.Create(run); err != nil {
+		log.Fatal("Failed to record backup: ", err)
+	}
+
+	result, dumpErr := runner.Dump(context.Background(), run.ObjectKey)
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	if dumpErr != nil {
+		run.Status = "failed"
+		run.Error = dumpErr.Error()
+	} else {
+		run.Status = "succeeded"
+		run.ObjectKey = result.ObjectKey
+		run.SizeBytes = result.SizeBytes
+		run.Checksum = result.Checksum
+	}
+	if err := backupRepo.Update(run); err != nil {
+		log.Printf("Warning: Failed to record backup result: %v", err)
+	}
+	if dumpErr != nil {
+		log.Fatal("Backup failed: ", dumpErr)
+	}
+
+	fmt.Printf("Backup %d created: %s (%d bytes, sha256 %s)\n", run.ID, runner.Location(run.ObjectKey), run.SizeBytes, run.Checksum)
+}
+
+// list バックアップの履歴の表示
+func list(databaseURL string, args []string) {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	limit := flags.Int("limit", 20, "number of backups to show")
+	flags.Parse(args)
+
+	runs, total, err := repositories.NewBackupRepository(connect(databaseURL)).FindAll(*limit, 0)
+	if err != nil {
+		log.Fatal("Failed to list backups: ", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tSOURCE\tSTARTED\tSIZE\tFILE")
+	for _, run := range runs {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\n", run.ID, run.Status, run.Source, run.StartedAt.Format(time.RFC3339), run.SizeBytes, run.ObjectKey)
+	}
+	w.Flush()
+	fmt.Printf("%d of %d backups\n", len(runs), total)
+}
+
+// restore バックアップからのリストア（既存のテーブルを削除して作り直すため、-confirm が必要）
+// 実行前にAPIサーバーを停止すること。リストア後の履歴はバックアップ作成時点のものになる
+func restore(databaseURL string, runner *backup.Runner, args []string) {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	id := flags.Uint("id", 0, "backup ID from the history (checksum is verified)")
+	file := flags.String("file", "", "path to a pg_dump custom-format file")
+	confirm := flags.Bool("confirm", false, "confirm that the current database will be overwritten")
+	flags.Parse(args)
+
+	if (*id == 0) == (*file == "") {
+		log.Fatal("Specify either -id or -file")
+	}
+
+	source := *file
+	var run *models.BackupRun
+	if *id != 0 {
+		found, err := repositories.NewBackupRepository(connect(databaseURL)).FindByID(*id)
+		if err != nil || found == nil {
+			log.Fatal("Backup not found: ", err)
+		}
+		if found.Status != "succeeded" {
+			log.Fatalf("Backup %d is %s and cannot be restored", found.ID, found.Status)
+		}
+		run, source = found, runner.Location(found.ObjectKey)
+	}
+
+	if !*confirm {
+		log.Fatalf("Restoring %s will overwrite the current database; rerun with -confirm", source)
+	}
+
+	log.Printf("Restoring %s ...", source)
+	var err error
+	if run != nil {
+		err = runner.Restore(context.Background(), run.ObjectKey, run.Checksum)
+	} else {
+		err = runner.RestoreFile(context.Background(), *file, "")
+	}
+	if err != nil {
+		log.Fatal("Restore failed: ", err)
+	}
+	log.Println("Restore completed")
+}
+
+func connect(databaseURL string) *gorm.DB {
+	db, err := database.Connect(databaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to database: ", err)
+	}
+	return db
+}
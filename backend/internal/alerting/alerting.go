@@ -0,0 +1,236 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// アラートの重要度
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// 監視する運用上のイベント
+const (
+	EventDatabaseUnreachable = "database_unreachable" // データベースへの接続が連続して失敗
+	EventJobFailing          = "job_failing"          // 定期ジョブが連続して失敗
+	EventJobBacklog          = "job_backlog"          // 処理待ちのキューが滞留
+	EventAuditWriteDropped   = "audit_write_dropped"  // 監査ログの書き込みが失敗（記録が失われた）
+)
+
+// Alert 送信するアラート（Keyが同じものは同一の障害として扱い、再送・解消を判断する）
+type Alert struct {
+	Key         string                 `json:"key"`
+	Event       string                 `json:"event"`
+	Severity    string                 `json:"severity"`
+	Summary     string                 `json:"summary"`
+	Details     map[string]interface{} `json:"details,omitempty"`
+	Resolved    bool                   `json:"resolved"`
+	Environment string                 `json:"environment"`
+	At          time.Time              `json:"at"`
+}
+
+// Sink アラートの送信先（Slack・PagerDuty・Webhook）
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, alert Alert) error
+}
+
+// Config アラートの送信先の設定（URL・キーが空の送信先は使用しない）
+type Config struct {
+	SlackWebhookURL     string
+	PagerDutyRoutingKey string
+	PagerDutyURL        string
+	WebhookURL          string
+	WebhookSecret       string
+	Timeout             time.Duration
+	Cooldown            time.Duration // 発生中の同じアラートを再送するまでの間隔
+	Environment         string
+}
+
+// Alerter 閾値の判定と送信先へのアラートの送信
+// 送信先が設定されていない場合はnilを返し、各メソッドは何もしない
+type Alerter struct {
+	sinks       []Sink
+	cooldown    time.Duration
+	timeout     time.Duration
+	environment string
+
+	mu     sync.Mutex
+	states map[string]*alertState
+}
+
+type alertState struct {
+	event       string
+	failures    int       // 連続した失敗の回数
+	windowStart time.Time // 件数を数える期間の開始
+	count       int       // 期間内の件数
+	firing      bool
+	lastSent    time.Time
+}
+
+func NewAlerter(cfg Config) *Alerter {
+	var sinks []Sink
+	if cfg.SlackWebhookURL != "" {
+		sinks = append(sinks, NewSlackSink(cfg.SlackWebhookURL, cfg.Timeout))
+	}
+	if cfg.PagerDutyRoutingKey != "" {
+		sinks = append(sinks, NewPagerDutySink(cfg.PagerDutyURL, cfg.PagerDutyRoutingKey, cfg.Timeout))
+	}
+	if cfg.WebhookURL != "" {
+		sinks = append(sinks, NewWebhookSink(cfg.WebhookURL, cfg.WebhookSecret, cfg.Timeout))
+	}
+	if len(sinks) == 0 {
+		return nil
+	}
+
+	for _, sink := range sinks {
+		log.Printf("Operational alerts enabled: %s", sink.Name())
+	}
+	return &Alerter{
+		sinks:       sinks,
+		cooldown:    cfg.Cooldown,
+		timeout:     cfg.Timeout,
+		environment: cfg.Environment,
+		states:      make(map[string]*alertState),
+	}
+}
+
+// Failure 失敗の記録（連続した失敗が閾値に達したらアラートを送る）
+func (a *Alerter) Failure(key, event string, threshold int, summary string, details map[string]interface{}) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	state := a.state(key)
+	state.failures++
+	failures := state.failures
+	a.mu.Unlock()
+
+	if failures >= threshold {
+		if details == nil {
+			details = map[string]interface{}{}
+		}
+		details["consecutive_failures"] = failures
+		a.Fire(Alert{Key: key, Event: event, Severity: SeverityCritical, Summary: summary, Details: details})
+	}
+}
+
+// Success 成功の記録（連続した失敗の回数を戻し、発生中のアラートを解消する）
+func (a *Alerter) Success(key, summary string) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	state, ok := a.states[key]
+	if ok {
+		state.failures = 0
+	}
+	a.mu.Unlock()
+
+	if ok {
+		a.Resolve(key, summary)
+	}
+}
+
+// Count 件数の記録（window内の件数が閾値に達したらアラートを送る）
+func (a *Alerter) Count(key, event string, threshold int, window time.Duration, summary string, details map[string]interface{}) {
+	if a == nil {
+		return
+	}
+	now := time.Now()
+	a.mu.Lock()
+	state := a.state(key)
+	if now.Sub(state.windowStart) > window {
+		state.windowStart = now
+		state.count = 0
+	}
+	state.count++
+	count := state.count
+	a.mu.Unlock()
+
+	if count >= threshold {
+		if details == nil {
+			details = map[string]interface{}{}
+		}
+		details["count"] = count
+		details["window"] = window.String()
+		a.Fire(Alert{Key: key, Event: event, Severity: SeverityWarning, Summary: summary, Details: details})
+	}
+}
+
+// Fire アラートの送信（発生中の同じアラートはCooldownの間は再送しない）
+func (a *Alerter) Fire(alert Alert) {
+	if a == nil {
+		return
+	}
+	now := time.Now()
+	a.mu.Lock()
+	state := a.state(alert.Key)
+	if state.firing && now.Sub(state.lastSent) < a.cooldown {
+		a.mu.Unlock()
+		return
+	}
+	state.event = alert.Event
+	state.firing = true
+	state.lastSent = now
+	a.mu.Unlock()
+
+	alert.At = now
+	a.send(alert)
+}
+
+// Resolve 発生中のアラートの解消を送る（発生していない場合は何もしない）
+func (a *Alerter) Resolve(key, summary string) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	state, ok := a.states[key]
+	if !ok || !state.firing {
+		a.mu.Unlock()
+		return
+	}
+	state.firing = false
+	state.count = 0
+	event := state.event
+	a.mu.Unlock()
+
+	a.send(Alert{Key: key, Event: event, Severity: SeverityWarning, Summary: summary, Resolved: true, At: time.Now()})
+}
+
+func (a *Alerter) state(key string) *alertState {
+	state, ok := a.states[key]
+	if !ok {
+		state = &alertState{}
+		a.states[key] = state
+	}
+	return state
+}
+
+// send 全送信先へ非同期で送る（送信の失敗はログのみ）
+func (a *Alerter) send(alert Alert) {
+	alert.Environment = a.environment
+	log.Printf("Alert %s: [%s] %s", alertStatus(alert), alert.Key, alert.Summary)
+
+	for _, sink := range a.sinks {
+		go func(sink Sink) {
+			ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+			defer cancel()
+			if err := sink.Send(ctx, alert); err != nil {
+				log.Printf("Warning: Failed to send alert %s to %s: %v", alert.Key, sink.Name(), err)
+			}
+		}(sink)
+	}
+}
+
+func alertStatus(alert Alert) string {
+	if alert.Resolved {
+		return "resolved"
+	}
+	return fmt.Sprintf("firing (%s)", alert.Severity)
+}
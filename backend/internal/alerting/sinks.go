@@ -0,0 +1,150 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SlackSink SlackのIncoming Webhookへの送信
+type SlackSink struct {
+	webhookURL string
+	client     *http.Client
+}
+
+func NewSlackSink(webhookURL string, timeout time.Duration) *SlackSink {
+	return &SlackSink{
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *SlackSink) Name() string { return "slack" }
+
+func (s *SlackSink) Send(ctx context.Context, alert Alert) error {
+	status := ":rotating_light: " + strings.ToUpper(alert.Severity)
+	if alert.Resolved {
+		status = ":white_check_mark: RESOLVED"
+	}
+
+	lines := []string{fmt.Sprintf("%s [%s] %s", status, alert.Environment, alert.Summary), "key: " + alert.Key}
+	keys := make([]string, 0, len(alert.Details))
+	for key := range alert.Details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("%s: %v", key, alert.Details[key]))
+	}
+
+	return postJSON(ctx, s.client, s.webhookURL, map[string]string{"text": strings.Join(lines, "\n")}, nil)
+}
+
+// PagerDutySink PagerDuty Events API v2への送信（Keyをdedup_keyとして発生・解消を対応付ける）
+type PagerDutySink struct {
+	apiURL     string
+	routingKey string
+	client     *http.Client
+}
+
+func NewPagerDutySink(apiURL, routingKey string, timeout time.Duration) *PagerDutySink {
+	if apiURL == "" {
+		apiURL = "https://events.pagerduty.com/v2/enqueue"
+	}
+	return &PagerDutySink{
+		apiURL:     apiURL,
+		routingKey: routingKey,
+		client:     &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *PagerDutySink) Name() string { return "pagerduty" }
+
+func (s *PagerDutySink) Send(ctx context.Context, alert Alert) error {
+	event := map[string]interface{}{
+		"routing_key":  s.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    alert.Environment + ":" + alert.Key,
+	}
+	if alert.Resolved {
+		event["event_action"] = "resolve"
+	} else {
+		event["payload"] = map[string]interface{}{
+			"summary":        alert.Summary,
+			"source":         "online-medical-consultation-" + alert.Environment,
+			"severity":       alert.Severity,
+			"timestamp":      alert.At.Format(time.RFC3339),
+			"component":      alert.Event,
+			"custom_details": alert.Details,
+		}
+	}
+	return postJSON(ctx, s.client, s.apiURL, event, nil)
+}
+
+// WebhookSink 任意のURLへのJSONの送信
+// シークレットを設定した場合は本文のHMAC-SHA256をX-Alert-Signatureヘッダーに付ける
+type WebhookSink struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewWebhookSink(url, secret string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *WebhookSink) Name() string { return "webhook" }
+
+func (s *WebhookSink) Send(ctx context.Context, alert Alert) error {
+	var sign func([]byte) string
+	if s.secret != "" {
+		sign = func(body []byte) string {
+			mac := hmac.New(sha256.New, []byte(s.secret))
+			mac.Write(body)
+			return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		}
+	}
+	return postJSON(ctx, s.client, s.url, alert, sign)
+}
+
+// postJSON JSONの送信（2xx以外はエラー）
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}, sign func([]byte) string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if sign != nil {
+		req.Header.Set("X-Alert-Signature", sign(body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("alert endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
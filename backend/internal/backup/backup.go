@@ -0,0 +1,302 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/storage"
+)
+
+// Config pg_dump / pg_restore の設定
+type Config struct {
+	DatabaseURL   string         // 接続文字列（URL形式・key=value形式のどちらも可）
+	Storage       storage.Config // バックアップファイルの保存先
+	SpoolDir      string         // pg_dump / pg_restore が読み書きする一時ファイルの置き場所（空の場合はOSの一時ディレクトリ）
+	PgDumpPath    string
+	PgRestorePath string
+	Timeout       time.Duration
+}
+
+// Result 作成したバックアップファイル
+type Result struct {
+	ObjectKey string
+	SizeBytes int64
+	Checksum  string // SHA-256
+}
+
+// Runner pg_dump / pg_restore の実行
+// パスワードはプロセスの一覧から見えないよう、接続文字列から取り除いて PGPASSWORD で渡す
+type Runner struct {
+	cfg      Config
+	store    storage.Store
+	dsn      string // パスワードを取り除いた接続文字列
+	password string
+}
+
+func NewRunner(cfg Config) (*Runner, error) {
+	if cfg.PgDumpPath == "" {
+		cfg.PgDumpPath = "pg_dump"
+	}
+	if cfg.PgRestorePath == "" {
+		cfg.PgRestorePath = "pg_restore"
+	}
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+	dsn, password, err := splitPassword(cfg.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{cfg: cfg, store: store, dsn: dsn, password: password}, nil
+}
+
+// Location バックアップファイルの保存先の表示（ログ・コマンドの出力用）
+func (r *Runner) Location(objectKey string) string {
+	return r.store.Name() + ":" + objectKey
+}
+
+// ObjectKeyFor バックアップファイル名（実行日時から決める）
+func ObjectKeyFor(at time.Time) string {
+	return fmt.Sprintf("telemed_%s.dump", at.UTC().Format("20060102T150405Z"))
+}
+
+// Dump pg_dumpのカスタム形式でバックアップを作成し、保存先にアップロードする（一時ファイルはアップロード後に削除する）
+func (r *Runner) Dump(ctx context.Context, objectKey string) (*Result, error) {
+	spool, err := r.spoolFile()
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(spool)
+
+	if err := r.run(ctx, r.cfg.PgDumpPath, "--format=custom", "--no-owner", "--no-privileges", "--file="+spool, "--dbname="+r.dsn); err != nil {
+		return nil, err
+	}
+
+	size, checksum, err := fileChecksum(spool)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(spool)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if err := r.store.Put(ctx, objectKey, file, size, "application/octet-stream"); err != nil {
+		return nil, fmt.Errorf("failed to upload backup: %v", err)
+	}
+	return &Result{ObjectKey: objectKey, SizeBytes: size, Checksum: checksum}, nil
+}
+
+// Restore 保存先のバックアップからのリストア（ダウンロードしてチェックサムの一致を確認してから実行する）
+func (r *Runner) Restore(ctx context.Context, objectKey, checksum string) error {
+	spool, err := r.spoolFile()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(spool)
+
+	if err := r.download(ctx, objectKey, spool); err != nil {
+		return err
+	}
+	return r.RestoreFile(ctx, spool, checksum)
+}
+
+// RestoreFile ローカルのバックアップファイルからのリストア（既存のテーブルは削除して作り直す、単一トランザクション）
+// checksumを指定した場合は一致を確認してから実行する
+func (r *Runner) RestoreFile(ctx context.Context, path, checksum string) error {
+	if checksum != "" {
+		if err := Verify(path, checksum); err != nil {
+			return err
+		}
+	} else if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	return r.run(ctx, r.cfg.PgRestorePath, "--clean", "--if-exists", "--no-owner", "--no-privileges", "--single-transaction", "--dbname="+r.dsn, path)
+}
+
+// Remove バックアップファイルの削除（既にない場合は何もしない）
+func (r *Runner) Remove(objectKey string) error {
+	return r.store.Delete(context.Background(), objectKey)
+}
+
+// spoolFile pg_dump / pg_restore が読み書きする一時ファイル（呼び出し側で削除する）
+func (r *Runner) spoolFile() (string, error) {
+	if r.cfg.SpoolDir != "" {
+		if err := os.MkdirAll(r.cfg.SpoolDir, 0700); err != nil {
+			return "", fmt.Errorf("failed to create backup spool directory: %v", err)
+		}
+	}
+	file, err := os.CreateTemp(r.cfg.SpoolDir, "telemed-backup-*.dump")
+	if err != nil {
+		return "", err
+	}
+	name := file.Name()
+	if err := file.Close(); err != nil {
+		os.Remove(name)
+		return "", err
+	}
+	return name, nil
+}
+
+// download 保存先のバックアップを一時ファイルに書き込む
+func (r *Runner) download(ctx context.Context, objectKey, path string) error {
+	body, err := r.store.Open(ctx, objectKey)
+	if err != nil {
+		return fmt.Errorf("failed to download backup: %v", err)
+	}
+	defer body.Close()
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download backup: %v", err)
+	}
+	return nil
+}
+
+// Verify ファイルのSHA-256の確認
+func Verify(path, checksum string) error {
+	_, actual, err := fileChecksum(path)
+	if err != nil {
+		return err
+	}
+	if actual != checksum {
+		return errors.New("backup checksum mismatch")
+	}
+	return nil
+}
+
+func (r *Runner) run(ctx context.Context, name string, args ...string) error {
+	if r.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.cfg.Timeout)
+		defer cancel()
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	if r.password != "" {
+		cmd.Env = append(os.Environ(), "PGPASSWORD="+r.password)
+	}
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if len(message) > 1000 {
+			message = message[len(message)-1000:]
+		}
+		if message == "" {
+			return fmt.Errorf("%s failed: %v", filepath.Base(name), err)
+		}
+		return fmt.Errorf("%s failed: %v: %s", filepath.Base(name), err, message)
+	}
+	return nil
+}
+
+// splitPassword 接続文字列からパスワードを取り除く（URL形式・key=value形式のどちらも可）
+func splitPassword(dsn string) (string, string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid database url: %v", err)
+		}
+		password := ""
+		if u.User != nil {
+			password, _ = u.User.Password()
+			u.User = url.User(u.User.Username())
+		}
+		query := u.Query()
+		if p := query.Get("password"); p != "" {
+			password = p
+			query.Del("password")
+			u.RawQuery = query.Encode()
+		}
+		return u.String(), password, nil
+	}
+
+	var kept []string
+	password := ""
+	for rest := strings.TrimSpace(dsn); rest != ""; rest = strings.TrimSpace(rest) {
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return "", "", errors.New("invalid database connection string")
+		}
+		key := strings.TrimSpace(rest[:eq])
+		rest = strings.TrimLeft(rest[eq+1:], " ")
+
+		// 値は空白まで、またはシングルクォートで囲んだ文字列（クォート内の \ は次の1文字をそのまま扱う）
+		var raw, value strings.Builder
+		if strings.HasPrefix(rest, "'") {
+			raw.WriteByte('\'')
+			closed := false
+			i := 1
+			for ; i < len(rest); i++ {
+				c := rest[i]
+				raw.WriteByte(c)
+				if c == '\\' && i+1 < len(rest) {
+					i++
+					raw.WriteByte(rest[i])
+					value.WriteByte(rest[i])
+					continue
+				}
+				if c == '\'' {
+					closed = true
+					i++
+					break
+				}
+				value.WriteByte(c)
+			}
+			if !closed {
+				return "", "", errors.New("invalid database connection string: unterminated quote")
+			}
+			rest = rest[i:]
+		} else {
+			end := strings.IndexAny(rest, " \t\n")
+			if end < 0 {
+				end = len(rest)
+			}
+			raw.WriteString(rest[:end])
+			value.WriteString(rest[:end])
+			rest = rest[end:]
+		}
+
+		if key == "password" {
+			password = value.String()
+			continue
+		}
+		kept = append(kept, key+"="+raw.String())
+	}
+	return strings.Join(kept, " "), password, nil
+}
+
+func fileChecksum(path string) (int64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
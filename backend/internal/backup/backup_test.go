@@ -0,0 +1,106 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"online_medical_consultation_app/backend/internal/storage"
+)
+
+func TestSplitPassword(t *testing.T) {
+	tests := []struct {
+		dsn          string
+		wantDSN      string
+		wantPassword string
+	}{
+		{
+			dsn:          "postgres://app:s3cret@db:5432/telemed?sslmode=disable",
+			wantDSN:      "postgres://app@db:5432/telemed?sslmode=disable",
+			wantPassword: "s3cret",
+		},
+		{
+			dsn:          "postgresql://app@db/telemed?password=s3cret&sslmode=require",
+			wantDSN:      "postgresql://app@db/telemed?sslmode=require",
+			wantPassword: "s3cret",
+		},
+		{
+			dsn:          "host=localhost user=postgres password=postgres dbname=medical_consultation port=5432",
+			wantDSN:      "host=localhost user=postgres dbname=medical_consultation port=5432",
+			wantPassword: "postgres",
+		},
+		{
+			dsn:          `host=db password='it\'s a secret' dbname=telemed`,
+			wantDSN:      "host=db dbname=telemed",
+			wantPassword: "it's a secret",
+		},
+		{
+			dsn:     "host=db dbname='medical records'",
+			wantDSN: "host=db dbname='medical records'",
+		},
+	}
+	for _, tt := range tests {
+		dsn, password, err := splitPassword(tt.dsn)
+		if err != nil {
+			t.Errorf("splitPassword(%q) error = %v", tt.dsn, err)
+			continue
+		}
+		if dsn != tt.wantDSN || password != tt.wantPassword {
+			t.Errorf("splitPassword(%q) = %q, %q; want %q, %q", tt.dsn, dsn, password, tt.wantDSN, tt.wantPassword)
+		}
+	}
+
+	if _, _, err := splitPassword("host=db password='unterminated"); err == nil {
+		t.Error("splitPassword() with an unterminated quote succeeded, want an error")
+	}
+}
+
+// TestDumpPassesPasswordInEnvironment pg_dump の代わりに引数と PGPASSWORD をファイルに書き出すスクリプトで確認する
+func TestDumpPassesPasswordInEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "pg_dump")
+	content := "#!/bin/sh\nfor arg in \"$@\"; do case \"$arg\" in --file=*) out=\"${arg#--file=}\";; esac; done\necho \"args: $*\" > \"$out\"\necho \"password: $PGPASSWORD\" >> \"$out\"\n"
+	if err := os.WriteFile(script, []byte(content), 0700); err != nil {
+		t.Fatalf("failed to write fake pg_dump: %v", err)
+	}
+
+	runner, err := NewRunner(Config{
+		DatabaseURL: "postgres://app:s3cret@db:5432/telemed",
+		Storage:     storage.Config{Provider: "local", LocalDir: filepath.Join(dir, "backups")},
+		SpoolDir:    filepath.Join(dir, "spool"),
+		PgDumpPath:  script,
+	})
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+
+	result, err := runner.Dump(context.Background(), "telemed_test.dump")
+	if err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+
+	body, err := runner.store.Open(context.Background(), result.ObjectKey)
+	if err != nil {
+		t.Fatalf("failed to open the uploaded backup: %v", err)
+	}
+	defer body.Close()
+	uploaded, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("failed to read the uploaded backup: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(uploaded)), "\n")
+	if len(lines) != 2 || strings.Contains(lines[0], "s3cret") || lines[1] != "password: s3cret" {
+		t.Errorf("pg_dump saw %q, want the password only in PGPASSWORD", lines)
+	}
+	if int64(len(uploaded)) != result.SizeBytes {
+		t.Errorf("Dump() size = %d, want %d", result.SizeBytes, len(uploaded))
+	}
+
+	spooled, err := os.ReadDir(filepath.Join(dir, "spool"))
+	if err != nil || len(spooled) != 0 {
+		t.Errorf("spool directory = %v, %v; want the temporary dump removed", spooled, err)
+	}
+}
@@ -0,0 +1,306 @@
+package clinicalcoding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Note 医師の診療記録（SOAP形式）
+type Note struct {
+	Subjective string `json:"subjective"`
+	Objective  string `json:"objective"`
+	Assessment string `json:"assessment"`
+	Plan       string `json:"plan"`
+}
+
+// Text 記録全体のテキスト
+func (n Note) Text() string {
+	return strings.TrimSpace(strings.Join([]string{n.Subjective, n.Objective, n.Assessment, n.Plan}, "\n"))
+}
+
+// Suggestion ICD-10コードの候補
+type Suggestion struct {
+	Code        string  `json:"code"`
+	Description string  `json:"description"`
+	Confidence  float64 `json:"confidence"` // 0〜1
+	Evidence    string  `json:"evidence"`   // 根拠となった記録中の語句
+}
+
+// Provider コード候補の提案元（自然言語処理サービス等）
+type Provider interface {
+	Name() string
+	Suggest(ctx context.Context, note Note, limit int) ([]Suggestion, error)
+}
+
+// Config コード候補の提案元の設定
+type Config struct {
+	Provider string // none | dictionary | http
+	APIURL   string
+	APIKey   string
+	Timeout  time.Duration
+}
+
+// NewProvider 設定に応じた提案元の作成（noneの場合はnilを返し、提案を無効にする）
+func NewProvider(cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case "", "none":
+		return nil, nil
+	case "dictionary":
+		return NewDictionaryProvider(), nil
+	case "http":
+		if cfg.APIURL == "" {
+			return nil, errors.New("clinical coding API URL is required for the http provider")
+		}
+		return NewHTTPProvider(cfg.APIURL, cfg.APIKey, cfg.Timeout), nil
+	}
+	return nil, fmt.Errorf("unknown clinical coding provider: %s", cfg.Provider)
+}
+
+var icd10Pattern = regexp.MustCompile(`^[A-Z][0-9][0-9A-Z](\.[0-9A-Z]{1,4})?$`)
+
+// ValidICD10 ICD-10コードの形式かどうか（例: J06.9, I10）
+func ValidICD10(code string) bool {
+	return icd10Pattern.MatchString(code)
+}
+
+// dictionaryEntry 用語とコードの対応
+type dictionaryEntry struct {
+	code        string
+	description string
+	terms       []string // 小文字で比較
+}
+
+// 外来でよく使われる病名の対応表（日本語・英語）
+var dictionary = []dictionaryEntry{
+	{"J06.9", "急性上気道感染症", []string{"上気道炎", "上気道感染", "風邪", "かぜ", "感冒", "common cold", "upper respiratory infection", "uri"}},
+	{"J02.9", "急性咽頭炎", []string{"咽頭炎", "pharyngitis", "sore throat"}},
+	{"J20.9", "急性気管支炎", []string{"気管支炎", "bronchitis"}},
+	{"J45.9", "喘息", []string{"喘息", "ぜんそく", "asthma"}},
+	{"J30.4", "アレルギー性鼻炎", []string{"アレルギー性鼻炎", "花粉症", "allergic rhinitis", "hay fever"}},
+	{"U07.1", "COVID-19", []string{"covid-19", "covid", "新型コロナ", "コロナウイルス感染症"}},
+	{"J10.1", "インフルエンザ", []string{"インフルエンザ", "influenza", "flu"}},
+	{"I10", "本態性高血圧症", []string{"高血圧", "hypertension"}},
+	{"E11.9", "2型糖尿病", []string{"2型糖尿病", "糖尿病", "type 2 diabetes", "diabetes"}},
+	{"E78.5", "脂質異常症", []string{"脂質異常症", "高脂血症", "高コレステロール", "hyperlipidemia", "dyslipidemia"}},
+	{"K21.9", "胃食道逆流症", []string{"逆流性食道炎", "胃食道逆流", "gerd", "reflux"}},
+	{"A09", "感染性胃腸炎", []string{"胃腸炎", "gastroenteritis"}},
+	{"K59.0", "便秘", []string{"便秘", "constipation"}},
+	{"N39.0", "尿路感染症", []string{"尿路感染", "膀胱炎", "urinary tract infection", "uti", "cystitis"}},
+	{"M54.5", "腰痛", []string{"腰痛", "low back pain", "lumbago"}},
+	{"R51", "頭痛", []string{"頭痛", "headache"}},
+	{"G43.9", "片頭痛", []string{"片頭痛", "偏頭痛", "migraine"}},
+	{"R50.9", "発熱", []string{"発熱", "fever"}},
+	{"R05", "咳", []string{"咳嗽", "咳", "cough"}},
+	{"G47.0", "不眠症", []string{"不眠", "insomnia"}},
+	{"F32.9", "うつ病", []string{"うつ病", "抑うつ", "depression"}},
+	{"F41.1", "全般性不安障害", []string{"不安障害", "anxiety"}},
+	{"L20.9", "アトピー性皮膚炎", []string{"アトピー性皮膚炎", "atopic dermatitis", "eczema"}},
+	{"L50.9", "蕁麻疹", []string{"蕁麻疹", "じんましん", "urticaria", "hives"}},
+}
+
+// 否定の表現（「発熱なし」「no fever」等は候補にしない）
+var (
+	negationSuffixes = []string{"なし", "無し", "はない", "は認めない", "を認めない", "否定"}
+	negationPrefixes = []string{"no ", "denies ", "without ", "negative for "}
+)
+
+// sectionWeights 記録の項目ごとの確からしさ（評価・診断に書かれた病名を優先する）
+var sectionWeights = []struct {
+	name   string
+	weight float64
+	text   func(Note) string
+}{
+	{"assessment", 0.9, func(n Note) string { return n.Assessment }},
+	{"plan", 0.6, func(n Note) string { return n.Plan }},
+	{"subjective", 0.5, func(n Note) string { return n.Subjective }},
+	{"objective", 0.5, func(n Note) string { return n.Objective }},
+}
+
+// DictionaryProvider 用語の対応表による提案（外部サービスを使わない簡易版）
+type DictionaryProvider struct{}
+
+func NewDictionaryProvider() *DictionaryProvider {
+	return &DictionaryProvider{}
+}
+
+func (p *DictionaryProvider) Name() string { return "dictionary" }
+
+func (p *DictionaryProvider) Suggest(ctx context.Context, note Note, limit int) ([]Suggestion, error) {
+	best := make(map[string]Suggestion)
+	for _, section := range sectionWeights {
+		text := strings.ToLower(section.text(note))
+		if text == "" {
+			continue
+		}
+		for _, m := range findMatches(text) {
+			if current, ok := best[m.entry.code]; !ok || current.Confidence < section.weight {
+				best[m.entry.code] = Suggestion{
+					Code:        m.entry.code,
+					Description: m.entry.description,
+					Confidence:  section.weight,
+					Evidence:    text[m.start:m.end],
+				}
+			}
+		}
+	}
+
+	suggestions := make([]Suggestion, 0, len(best))
+	for _, suggestion := range best {
+		suggestions = append(suggestions, suggestion)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Confidence != suggestions[j].Confidence {
+			return suggestions[i].Confidence > suggestions[j].Confidence
+		}
+		return suggestions[i].Code < suggestions[j].Code
+	})
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+type match struct {
+	entry      *dictionaryEntry
+	start, end int
+}
+
+// findMatches 否定されていない用語の出現（「片頭痛」の中の「頭痛」のように長い用語に含まれるものは除く）
+func findMatches(text string) []match {
+	var matches []match
+	for i := range dictionary {
+		entry := &dictionary[i]
+		for _, term := range entry.terms {
+			for offset := 0; ; {
+				j := strings.Index(text[offset:], term)
+				if j < 0 {
+					break
+				}
+				start := offset + j
+				end := start + len(term)
+				if isWordMatch(text, start, end) && !isNegated(text, start, end) {
+					matches = append(matches, match{entry: entry, start: start, end: end})
+				}
+				offset = end
+			}
+		}
+	}
+
+	result := matches[:0:0]
+	for _, m := range matches {
+		covered := false
+		for _, other := range matches {
+			if other.entry != m.entry && other.start <= m.start && m.end <= other.end && other.end-other.start > m.end-m.start {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// isWordMatch 英字の語句は単語の一部（"uri" in "during" 等）に一致させない
+func isWordMatch(text string, start, end int) bool {
+	isLetter := func(b byte) bool { return b >= 'a' && b <= 'z' }
+	if isLetter(text[start]) && start > 0 && isLetter(text[start-1]) {
+		return false
+	}
+	if isLetter(text[end-1]) && end < len(text) && isLetter(text[end]) {
+		return false
+	}
+	return true
+}
+
+func isNegated(text string, start, end int) bool {
+	after := strings.TrimLeft(text[end:], " 　")
+	for _, suffix := range negationSuffixes {
+		if strings.HasPrefix(after, suffix) {
+			return true
+		}
+	}
+	before := text[:start]
+	for _, prefix := range negationPrefixes {
+		if strings.HasSuffix(before, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// HTTPProvider 外部の自然言語処理サービスによる提案
+// POST {"note": {...}, "limit": n} → {"suggestions": [{"code", "description", "confidence", "evidence"}]}
+type HTTPProvider struct {
+	apiURL string
+	apiKey string
+	client *http.Client
+}
+
+func NewHTTPProvider(apiURL, apiKey string, timeout time.Duration) *HTTPProvider {
+	return &HTTPProvider{
+		apiURL: apiURL,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *HTTPProvider) Name() string { return "http" }
+
+func (p *HTTPProvider) Suggest(ctx context.Context, note Note, limit int) ([]Suggestion, error) {
+	body, err := json.Marshal(map[string]interface{}{"note": note, "limit": limit})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("clinical coding API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var result struct {
+		Suggestions []Suggestion `json:"suggestions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid clinical coding response: %v", err)
+	}
+
+	// 形式が正しいコードのみ採用する
+	suggestions := make([]Suggestion, 0, len(result.Suggestions))
+	for _, suggestion := range result.Suggestions {
+		suggestion.Code = strings.ToUpper(strings.TrimSpace(suggestion.Code))
+		if !ValidICD10(suggestion.Code) {
+			continue
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -10,10 +13,268 @@ type Config struct {
 	ServerPort  string
 	ServerHost  string
 	UploadDir   string
-	MaxFileSize int64
-	StunServer  string
+	MaxFileSize int64    // チャットの添付ファイルのサイズの上限の初期値（ロールごとの設定がない場合）
+	StunServers []string // ビデオ通話のSTUNサーバー（カンマ区切り）
 	Environment string
 	Debug       bool
+
+	// ビデオ通話のTURNサーバー（coturn の use-auth-secret による期限付きの認証情報を発行する）
+	TURNURLs          []string // turn:・turns: のURL（カンマ区切り、空の場合はTURNを使わない）
+	TURNSecret        string   // coturn の static-auth-secret
+	TURNCredentialTTL time.Duration
+
+	// ログインのセッション（アクセストークンの有効期限が近づくと利用中のリクエストで更新したトークンを返し、ログインから最大有効期間までは延長できる）
+	AccessTokenTTL       time.Duration
+	SessionRefreshWindow time.Duration // 有効期限までの残りがこの時間を下回ると更新する（0: 更新しない）
+	SessionMaxLifetime   time.Duration // ログインからの最大有効期間（経過後は再ログインが必要）
+
+	// ログインの総当たり対策（アカウントごとに連続した失敗でロックし、失敗が続くほどロックの時間を倍にする）
+	LoginMaxFailures   int           // ロックするまでの連続した失敗回数
+	LoginLockoutBase   time.Duration // 最初のロックの時間
+	LoginLockoutMax    time.Duration // ロックの時間の上限（前回の失敗からこの時間が経過すると失敗回数を数え直す）
+	LoginAttemptsPerIP int           // 15分あたりのIPアドレスごとのログインの試行回数の上限
+
+	// WebSocketのインスタンス間配信（local: 単一インスタンス / redis: Redis Pub/Sub）
+	RealtimeBroker       string
+	RedisURL             string
+	RealtimeRedisChannel string
+
+	// ファイルのアップロード・エクスポートの利用回数の上限（memory: 単一インスタンス / redis: 複数インスタンスで共有、0は無制限）
+	QuotaStore              string
+	QuotaUploadsPerHourUser int
+	QuotaUploadsPerHourIP   int
+	QuotaExportsPerDayUser  int
+	QuotaExportsPerDayIP    int
+
+	// 大きなエクスポートのダウンロード（生成したファイルを一時保存し、有効期間内はトークンで途中から再取得できる）
+	// 保存先は local: EXPORT_DOWNLOAD_DIR / s3: S3互換のオブジェクトストレージ（複数インスタンスの構成ではs3を使う）
+	ExportDownloadStorage           string
+	ExportDownloadDir               string
+	ExportDownloadS3Bucket          string
+	ExportDownloadS3Region          string
+	ExportDownloadS3Endpoint        string
+	ExportDownloadS3AccessKeyID     string
+	ExportDownloadS3SecretAccessKey string
+	ExportDownloadStorageTimeout    time.Duration
+	ExportSpoolDir                  string // 生成中のエクスポートの一時ファイルの置き場所（空の場合はOSの一時ディレクトリ）
+	ExportDownloadTTL               time.Duration
+	ExportBandwidthLimit            int64 // 接続ごとの送信速度の上限（バイト/秒、0は無制限）
+
+	// 停止時に接続の切り離し・処理中のリクエストの完了を待つ時間
+	ShutdownTimeout time.Duration
+
+	// メール内のリンク等に使用するフロントエンドのURL
+	AppBaseURL string
+
+	// CORS・WebSocket接続を許可するオリジン（"*" の場合はすべてのオリジンを許可する）
+	AllowedOrigins []string
+
+	// メールの送信（none: ログ出力のみ / smtp: SMTPサーバー / sendgrid: SendGrid Web API）
+	MailProvider   string
+	MailFrom       string
+	MailFromName   string
+	SMTPHost       string
+	SMTPPort       int
+	SMTPUsername   string
+	SMTPPassword   string
+	SendGridAPIURL string
+	SendGridAPIKey string
+	MailTimeout    time.Duration
+
+	// 診療チャットのメッセージが未読のままの場合にメールで知らせるまでの時間
+	UnreadMessageEmailDelay time.Duration
+
+	// 端末へのプッシュ通知（FCM: Android・Web / APNs: iOS、認証情報が空の場合はログ出力のみ）
+	PushFCMProjectID       string
+	PushFCMCredentialsFile string
+	PushAPNsKeyFile        string
+	PushAPNsKeyID          string
+	PushAPNsTeamID         string
+	PushAPNsTopic          string
+	PushAPNsProduction     bool
+	PushTimeout            time.Duration
+
+	// 監査ログのアーカイブ設定
+	AuditArchiveDir      string
+	AuditRetentionDays   int
+	AuditArchiveInterval time.Duration
+	AuditRehydrateDays   int
+
+	// 監査ログの分析用エクスポートで利用者IDの仮名化に使うソルトの切り替え間隔
+	AuditPseudonymSaltRotation time.Duration
+
+	// 緊急エスカレーション設定
+	EscalationTimeout time.Duration // 医師が未確認のまま管理者へエスカレーションするまでの時間
+	OnCallAdminIDs    []uint        // 空の場合は全管理者に通知
+
+	// 医師が応答しない保留中の予約を自動で辞退するまでの時間
+	PendingResponseTimeout time.Duration
+
+	// 非同期（チャット）相談で医師が最初に回答するまでの期限
+	AsyncResponseSLA time.Duration
+
+	// 診療枠の公開の通知（1回の実行で通知する登録数の上限と、同じ登録への通知の最短間隔）
+	SlotNotifyBatchSize int
+	SlotNotifyCooldown  time.Duration
+
+	// 診療終了（ビデオ通話の終了または診療枠の終了時刻）から自動で完了にするまでの猶予
+	AppointmentCompletionGrace time.Duration
+
+	// 問診の提出期限の何時間前に患者へリマインドするか（期限は医師ごとに設定）
+	IntakeReminderLead time.Duration
+
+	// 診療開始の何時間前に参加者へリマインドするか
+	AppointmentReminderLead time.Duration
+
+	// 患者が処方を確認していない場合に再通知するまでの時間
+	PrescriptionAckReminderDelay time.Duration
+
+	// 予約前に入力が必要な患者プロフィール項目（birthdate, phone, allergies, insurance）
+	PatientRequiredFields []string
+
+	// チャットの内容フィルタ（不適切語はカンマ区切り、対応は warn | block）
+	ChatProfanityWords  []string
+	ChatProfanityAction string
+	ChatPIIAction       string
+
+	// ビデオ診療の文字起こし（none: 無効 / whisper: OpenAI互換の音声認識API）
+	TranscriptionProvider string
+	TranscriptionAPIURL   string
+	TranscriptionAPIKey   string
+	TranscriptionModel    string
+	TranscriptionTimeout  time.Duration
+
+	// ビデオ診療の録画の保存先（local: UPLOAD_DIR/video-recordings / s3: S3互換のオブジェクトストレージ）
+	RecordingStorage           string
+	RecordingS3Bucket          string
+	RecordingS3Region          string
+	RecordingS3Endpoint        string
+	RecordingS3AccessKeyID     string
+	RecordingS3SecretAccessKey string
+	RecordingStorageTimeout    time.Duration
+
+	// チャットの添付ファイルの保存先（local: UPLOAD_DIR/chat-attachments / s3: S3互換のオブジェクトストレージ）
+	// ダウンロードは有効期限付きの署名付きURLで行う（localの場合はAPIのルートで配信し、JWTとは別の署名の鍵 ATTACHMENT_URL_SECRET が必須）
+	AttachmentStorage           string
+	AttachmentS3Bucket          string
+	AttachmentS3Region          string
+	AttachmentS3Endpoint        string
+	AttachmentS3AccessKeyID     string
+	AttachmentS3SecretAccessKey string
+	AttachmentStorageTimeout    time.Duration
+	AttachmentURLTTL            time.Duration
+	AttachmentURLSecret         string
+
+	// 患者がアップロードした診療記録の文字認識（none: 無効 / ocrspace: OCR.space API）
+	OCRProvider string
+	OCRAPIURL   string
+	OCRAPIKey   string
+	OCRLanguage string
+	OCRTimeout  time.Duration
+
+	// 診療記録からのICD-10コード候補（none: 無効 / dictionary: 内蔵の対応表 / http: 外部の自然言語処理サービス）
+	ClinicalCodingProvider string
+	ClinicalCodingAPIURL   string
+	ClinicalCodingAPIKey   string
+	ClinicalCodingTimeout  time.Duration
+
+	// 処方の費用の見積もり（地域=参照先の一覧、dataset: 薬価データセット / http: 外部の価格サービス、空の場合は見積もらない）
+	DrugPricingProviders     []string
+	DrugPricingDefaultRegion string
+	DrugPricingDatasetFile   string
+	DrugPricingAPIURL        string
+	DrugPricingAPIKey        string
+	DrugPricingTimeout       time.Duration
+
+	// 運用アラートの送信先（Slack Incoming Webhook / PagerDuty Events API v2 / 任意のWebhook、空の場合は送信しない）
+	AlertSlackWebhookURL     string
+	AlertPagerDutyRoutingKey string
+	AlertPagerDutyURL        string
+	AlertWebhookURL          string
+	AlertWebhookSecret       string // Webhookの署名（X-Alert-Signature）に使用
+	AlertTimeout             time.Duration
+	AlertCooldown            time.Duration // 発生中の同じアラートを再送するまでの間隔
+
+	// 運用アラートの閾値
+	AlertHealthCheckInterval  time.Duration // データベース接続・キューの確認間隔
+	AlertDBFailureThreshold   int           // データベースの接続確認の連続失敗回数
+	AlertJobFailureThreshold  int           // 定期ジョブの連続失敗回数
+	AlertTranscriptionBacklog int           // 文字起こしの処理待ちの件数（0: 監視しない）
+	AlertAuditDropThreshold   int           // 監査ログの書き込み失敗の件数
+	AlertAuditDropWindow      time.Duration // 監査ログの書き込み失敗を数える期間
+
+	// データベースのバックアップ（pg_dump、リストアは cmd/backup で行う）
+	// 保存先は local: BACKUP_DIR / s3: S3互換のオブジェクトストレージ（複数インスタンスの構成ではs3を使う）
+	BackupStorage           string
+	BackupDir               string
+	BackupS3Bucket          string
+	BackupS3Region          string
+	BackupS3Endpoint        string
+	BackupS3AccessKeyID     string
+	BackupS3SecretAccessKey string
+	BackupStorageTimeout    time.Duration
+	BackupSpoolDir          string // pg_dump / pg_restore の一時ファイルの置き場所（空の場合はOSの一時ディレクトリ）
+	BackupPgDumpPath        string
+	BackupPgRestorePath     string
+	BackupTimeout           time.Duration
+	BackupInterval          time.Duration // 定期バックアップの間隔（0: 管理者による実行のみ）
+	BackupKeep              int           // 保存する成功済みのバックアップの数（0: すべて保存）
+
+	// HL7v2による医療機関への連携（連携先は管理者が登録する）
+	HL7FileDropDir    string        // ファイル配置による連携先のディレクトリの基点（連携先ごとのディレクトリを作成）
+	HL7Timeout        time.Duration // 1メッセージの送信（MLLPはACKの受信まで）のタイムアウト
+	HL7ExportInterval time.Duration
+
+	// 退会後に利用を再開できる期間（経過後に個人情報を匿名化）
+	AccountReactivationWindow time.Duration
+
+	// 緊急時アクセスで診療記録を閲覧できる時間
+	BreakGlassDuration time.Duration
+
+	// 診療費のオンライン決済（none の場合は請求のみ作成し、オンライン決済は行わない）
+	PaymentProvider      string // none | stripe
+	PaymentCurrency      string // ISO 4217（小文字）
+	StripeAPIURL         string
+	StripeSecretKey      string
+	StripePublishableKey string
+	StripeWebhookSecret  string // Webhookの署名の検証用
+	PaymentTimeout       time.Duration
+
+	// 予約ごとのチャット・ビデオの利用上限（無料プランと有料プランのplus）
+	ChatPostConsultationWindow         time.Duration // 診療の完了後にチャットを送信できる期間
+	MaxVideoSessionsPerAppointment     int           // 0の場合は制限しない
+	PlusChatPostConsultationWindow     time.Duration
+	PlusMaxVideoSessionsPerAppointment int
+	SubscriptionPrice                  int           // 有料プランの利用期間ごとの料金（0の場合は有料プランを提供しない）
+	SubscriptionPeriod                 time.Duration // 支払いごとに延長する有料プランの利用期間
+
+	// 予約受付ルールの初期値（管理者が変更するまで使用）
+	BookingMinNotice      time.Duration
+	BookingMaxAdvanceDays int
+	BookingOpenTime       string
+	BookingCloseTime      string
+	BookingOpenWeekdays   string // 0=日〜6=土、カンマ区切り
+	BookingTimezone       string
+
+	// クリニックの表記の初期値（管理者が変更するまで使用）
+	ClinicName         string
+	ClinicLogoURL      string
+	ClinicPrimaryColor string
+	ClinicSupportEmail string
+	ClinicSupportPhone string
+	ClinicEmailFooter  string
+
+	// クライアントのアプリの最低バージョンの初期値（管理者が変更するまで使用、空の場合は制限しない）
+	AppMinVersionIOS     string
+	AppMinVersionAndroid string
+	AppMinVersionWeb     string
+	AppUpgradeURLIOS     string // アップデートの案内先（App Store）
+	AppUpgradeURLAndroid string // アップデートの案内先（Google Play）
+
+	// デモモード（営業デモ用に分離したデモアカウントを用意し、毎日初期化する）
+	DemoMode      bool
+	DemoPassword  string // デモアカウントの共通パスワード（デモモードでは必須）
+	DemoResetHour int    // 初期化する時刻（BookingTimezoneの時、0〜23）
 }
 
 func Load() *Config {
@@ -24,9 +285,220 @@ func Load() *Config {
 		ServerHost:  getEnv("SERVER_HOST", "localhost"),
 		UploadDir:   getEnv("UPLOAD_DIR", "./uploads"),
 		MaxFileSize: 10485760, // 10MB
-		StunServer:  getEnv("STUN_SERVER", "stun:stun.l.google.com:19302"),
+		StunServers: getEnvList("STUN_SERVER", []string{"stun:stun.l.google.com:19302", "stun:stun1.l.google.com:19302"}),
 		Environment: getEnv("ENV", "development"),
 		Debug:       getEnv("DEBUG", "true") == "true",
+
+		TURNURLs:          getEnvList("TURN_URLS", nil),
+		TURNSecret:        getEnv("TURN_SECRET", ""),
+		TURNCredentialTTL: getEnvDuration("TURN_CREDENTIAL_TTL", 24*time.Hour),
+
+		AccessTokenTTL:       getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		SessionRefreshWindow: getEnvDuration("SESSION_REFRESH_WINDOW", 5*time.Minute),
+		SessionMaxLifetime:   getEnvDuration("SESSION_MAX_LIFETIME", 12*time.Hour),
+
+		LoginMaxFailures:   getEnvInt("LOGIN_MAX_FAILURES", 5),
+		LoginLockoutBase:   getEnvDuration("LOGIN_LOCKOUT_BASE", 5*time.Minute),
+		LoginLockoutMax:    getEnvDuration("LOGIN_LOCKOUT_MAX", 24*time.Hour),
+		LoginAttemptsPerIP: getEnvInt("LOGIN_ATTEMPTS_PER_IP", 30),
+
+		RealtimeBroker:       getEnv("REALTIME_BROKER", "local"),
+		RedisURL:             getEnv("REDIS_URL", "redis://localhost:6379/0"),
+		RealtimeRedisChannel: getEnv("REALTIME_REDIS_CHANNEL", "telemed:realtime"),
+
+		QuotaStore:              getEnv("QUOTA_STORE", "memory"),
+		QuotaUploadsPerHourUser: getEnvInt("QUOTA_UPLOADS_PER_HOUR_USER", 60),
+		QuotaUploadsPerHourIP:   getEnvInt("QUOTA_UPLOADS_PER_HOUR_IP", 200),
+		QuotaExportsPerDayUser:  getEnvInt("QUOTA_EXPORTS_PER_DAY_USER", 30),
+		QuotaExportsPerDayIP:    getEnvInt("QUOTA_EXPORTS_PER_DAY_IP", 100),
+
+		ExportDownloadStorage:           getEnv("EXPORT_DOWNLOAD_STORAGE", "local"),
+		ExportDownloadDir:               getEnv("EXPORT_DOWNLOAD_DIR", "./exports"),
+		ExportDownloadS3Bucket:          getEnv("EXPORT_DOWNLOAD_S3_BUCKET", ""),
+		ExportDownloadS3Region:          getEnv("EXPORT_DOWNLOAD_S3_REGION", ""),
+		ExportDownloadS3Endpoint:        getEnv("EXPORT_DOWNLOAD_S3_ENDPOINT", ""),
+		ExportDownloadS3AccessKeyID:     getEnv("EXPORT_DOWNLOAD_S3_ACCESS_KEY_ID", ""),
+		ExportDownloadS3SecretAccessKey: getEnv("EXPORT_DOWNLOAD_S3_SECRET_ACCESS_KEY", ""),
+		ExportDownloadStorageTimeout:    getEnvDuration("EXPORT_DOWNLOAD_STORAGE_TIMEOUT", 10*time.Minute),
+		ExportSpoolDir:                  getEnv("EXPORT_SPOOL_DIR", ""),
+		ExportDownloadTTL:               getEnvDuration("EXPORT_DOWNLOAD_TTL", 24*time.Hour),
+		ExportBandwidthLimit:            int64(getEnvInt("EXPORT_BANDWIDTH_LIMIT", 2*1024*1024)),
+
+		ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+
+		AppBaseURL:     getEnv("APP_BASE_URL", "http://localhost:3000"),
+		AllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS", []string{getEnv("APP_BASE_URL", "http://localhost:3000")}),
+
+		MailProvider:   getEnv("MAIL_PROVIDER", "none"),
+		MailFrom:       getEnv("MAIL_FROM", "no-reply@example.com"),
+		MailFromName:   getEnv("MAIL_FROM_NAME", ""),
+		SMTPHost:       getEnv("SMTP_HOST", ""),
+		SMTPPort:       getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:   getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:   getEnv("SMTP_PASSWORD", ""),
+		SendGridAPIURL: getEnv("SENDGRID_API_URL", "https://api.sendgrid.com/v3/mail/send"),
+		SendGridAPIKey: getEnv("SENDGRID_API_KEY", ""),
+		MailTimeout:    getEnvDuration("MAIL_TIMEOUT", 30*time.Second),
+
+		UnreadMessageEmailDelay: getEnvDuration("UNREAD_MESSAGE_EMAIL_DELAY", 30*time.Minute),
+
+		PushFCMProjectID:       getEnv("PUSH_FCM_PROJECT_ID", ""),
+		PushFCMCredentialsFile: getEnv("PUSH_FCM_CREDENTIALS_FILE", ""),
+		PushAPNsKeyFile:        getEnv("PUSH_APNS_KEY_FILE", ""),
+		PushAPNsKeyID:          getEnv("PUSH_APNS_KEY_ID", ""),
+		PushAPNsTeamID:         getEnv("PUSH_APNS_TEAM_ID", ""),
+		PushAPNsTopic:          getEnv("PUSH_APNS_TOPIC", ""),
+		PushAPNsProduction:     getEnv("PUSH_APNS_PRODUCTION", "false") == "true",
+		PushTimeout:            getEnvDuration("PUSH_TIMEOUT", 10*time.Second),
+
+		AuditArchiveDir:      getEnv("AUDIT_ARCHIVE_DIR", "./archives/audit"),
+		AuditRetentionDays:   getEnvInt("AUDIT_RETENTION_DAYS", 365),
+		AuditArchiveInterval: getEnvDuration("AUDIT_ARCHIVE_INTERVAL", 24*time.Hour),
+		AuditRehydrateDays:   getEnvInt("AUDIT_REHYDRATE_DAYS", 30),
+
+		AuditPseudonymSaltRotation: getEnvDuration("AUDIT_PSEUDONYM_SALT_ROTATION", 30*24*time.Hour),
+
+		EscalationTimeout: getEnvDuration("ESCALATION_TIMEOUT", 5*time.Minute),
+		OnCallAdminIDs:    getEnvUintList("ONCALL_ADMIN_IDS"),
+
+		PendingResponseTimeout: getEnvDuration("PENDING_RESPONSE_TIMEOUT", 24*time.Hour),
+		AsyncResponseSLA:       getEnvDuration("ASYNC_RESPONSE_SLA", 24*time.Hour),
+
+		SlotNotifyBatchSize: getEnvInt("SLOT_NOTIFY_BATCH_SIZE", 200),
+		SlotNotifyCooldown:  getEnvDuration("SLOT_NOTIFY_COOLDOWN", 6*time.Hour),
+
+		AppointmentCompletionGrace: getEnvDuration("APPOINTMENT_COMPLETION_GRACE", 30*time.Minute),
+
+		IntakeReminderLead: getEnvDuration("INTAKE_REMINDER_LEAD", 24*time.Hour),
+
+		AppointmentReminderLead: getEnvDuration("APPOINTMENT_REMINDER_LEAD", time.Hour),
+
+		PrescriptionAckReminderDelay: getEnvDuration("PRESCRIPTION_ACK_REMINDER_DELAY", 24*time.Hour),
+
+		PatientRequiredFields: getEnvList("PATIENT_REQUIRED_FIELDS", []string{"birthdate", "phone"}),
+
+		ChatProfanityWords:  getEnvList("CHAT_PROFANITY_WORDS", nil),
+		ChatProfanityAction: getEnv("CHAT_PROFANITY_ACTION", "warn"),
+		ChatPIIAction:       getEnv("CHAT_PII_ACTION", "block"),
+
+		TranscriptionProvider: getEnv("TRANSCRIPTION_PROVIDER", "none"),
+		TranscriptionAPIURL:   getEnv("TRANSCRIPTION_API_URL", "https://api.openai.com/v1/audio/transcriptions"),
+		TranscriptionAPIKey:   getEnv("TRANSCRIPTION_API_KEY", ""),
+		TranscriptionModel:    getEnv("TRANSCRIPTION_MODEL", "whisper-1"),
+		TranscriptionTimeout:  getEnvDuration("TRANSCRIPTION_TIMEOUT", 10*time.Minute),
+
+		OCRProvider:           getEnv("OCR_PROVIDER", "none"),
+		OCRAPIURL:             getEnv("OCR_API_URL", "https://api.ocr.space/parse/image"),
+		OCRAPIKey:             getEnv("OCR_API_KEY", ""),
+		OCRLanguage:           getEnv("OCR_LANGUAGE", "jpn"),
+		OCRTimeout:            getEnvDuration("OCR_TIMEOUT", 2*time.Minute),
+
+		RecordingStorage:           getEnv("RECORDING_STORAGE", "local"),
+		RecordingS3Bucket:          getEnv("RECORDING_S3_BUCKET", ""),
+		RecordingS3Region:          getEnv("RECORDING_S3_REGION", ""),
+		RecordingS3Endpoint:        getEnv("RECORDING_S3_ENDPOINT", ""),
+		RecordingS3AccessKeyID:     getEnv("RECORDING_S3_ACCESS_KEY_ID", ""),
+		RecordingS3SecretAccessKey: getEnv("RECORDING_S3_SECRET_ACCESS_KEY", ""),
+		RecordingStorageTimeout:    getEnvDuration("RECORDING_STORAGE_TIMEOUT", 10*time.Minute),
+
+		AttachmentStorage:           getEnv("ATTACHMENT_STORAGE", "local"),
+		AttachmentS3Bucket:          getEnv("ATTACHMENT_S3_BUCKET", ""),
+		AttachmentS3Region:          getEnv("ATTACHMENT_S3_REGION", ""),
+		AttachmentS3Endpoint:        getEnv("ATTACHMENT_S3_ENDPOINT", ""),
+		AttachmentS3AccessKeyID:     getEnv("ATTACHMENT_S3_ACCESS_KEY_ID", ""),
+		AttachmentS3SecretAccessKey: getEnv("ATTACHMENT_S3_SECRET_ACCESS_KEY", ""),
+		AttachmentStorageTimeout:    getEnvDuration("ATTACHMENT_STORAGE_TIMEOUT", 2*time.Minute),
+		AttachmentURLTTL:            getEnvDuration("ATTACHMENT_URL_TTL", 15*time.Minute),
+		AttachmentURLSecret:         getEnv("ATTACHMENT_URL_SECRET", ""),
+
+		ClinicalCodingProvider: getEnv("CLINICAL_CODING_PROVIDER", "none"),
+		ClinicalCodingAPIURL:   getEnv("CLINICAL_CODING_API_URL", ""),
+		ClinicalCodingAPIKey:   getEnv("CLINICAL_CODING_API_KEY", ""),
+		ClinicalCodingTimeout:  getEnvDuration("CLINICAL_CODING_TIMEOUT", 20*time.Second),
+
+		DrugPricingProviders:     getEnvList("DRUG_PRICING_PROVIDERS", nil),
+		DrugPricingDefaultRegion: getEnv("DRUG_PRICING_DEFAULT_REGION", "JP"),
+		DrugPricingDatasetFile:   getEnv("DRUG_PRICING_DATASET_FILE", ""),
+		DrugPricingAPIURL:        getEnv("DRUG_PRICING_API_URL", ""),
+		DrugPricingAPIKey:        getEnv("DRUG_PRICING_API_KEY", ""),
+		DrugPricingTimeout:       getEnvDuration("DRUG_PRICING_TIMEOUT", 5*time.Second),
+
+		AlertSlackWebhookURL:     getEnv("ALERT_SLACK_WEBHOOK_URL", ""),
+		AlertPagerDutyRoutingKey: getEnv("ALERT_PAGERDUTY_ROUTING_KEY", ""),
+		AlertPagerDutyURL:        getEnv("ALERT_PAGERDUTY_URL", "https://events.pagerduty.com/v2/enqueue"),
+		AlertWebhookURL:          getEnv("ALERT_WEBHOOK_URL", ""),
+		AlertWebhookSecret:       getEnv("ALERT_WEBHOOK_SECRET", ""),
+		AlertTimeout:             getEnvDuration("ALERT_TIMEOUT", 10*time.Second),
+		AlertCooldown:            getEnvDuration("ALERT_COOLDOWN", 30*time.Minute),
+
+		AlertHealthCheckInterval:  getEnvDuration("ALERT_HEALTH_CHECK_INTERVAL", 30*time.Second),
+		AlertDBFailureThreshold:   getEnvInt("ALERT_DB_FAILURE_THRESHOLD", 2),
+		AlertJobFailureThreshold:  getEnvInt("ALERT_JOB_FAILURE_THRESHOLD", 3),
+		AlertTranscriptionBacklog: getEnvInt("ALERT_TRANSCRIPTION_BACKLOG", 50),
+		AlertAuditDropThreshold:   getEnvInt("ALERT_AUDIT_DROP_THRESHOLD", 5),
+		AlertAuditDropWindow:      getEnvDuration("ALERT_AUDIT_DROP_WINDOW", 5*time.Minute),
+
+		BackupStorage:           getEnv("BACKUP_STORAGE", "local"),
+		BackupDir:               getEnv("BACKUP_DIR", "./backups"),
+		BackupS3Bucket:          getEnv("BACKUP_S3_BUCKET", ""),
+		BackupS3Region:          getEnv("BACKUP_S3_REGION", ""),
+		BackupS3Endpoint:        getEnv("BACKUP_S3_ENDPOINT", ""),
+		BackupS3AccessKeyID:     getEnv("BACKUP_S3_ACCESS_KEY_ID", ""),
+		BackupS3SecretAccessKey: getEnv("BACKUP_S3_SECRET_ACCESS_KEY", ""),
+		BackupStorageTimeout:    getEnvDuration("BACKUP_STORAGE_TIMEOUT", time.Hour),
+		BackupSpoolDir:          getEnv("BACKUP_SPOOL_DIR", ""),
+		BackupPgDumpPath:        getEnv("BACKUP_PG_DUMP_PATH", "pg_dump"),
+		BackupPgRestorePath:     getEnv("BACKUP_PG_RESTORE_PATH", "pg_restore"),
+		BackupTimeout:           getEnvDuration("BACKUP_TIMEOUT", time.Hour),
+		BackupInterval:          getEnvDuration("BACKUP_INTERVAL", 0),
+		BackupKeep:              getEnvInt("BACKUP_KEEP", 14),
+
+		HL7FileDropDir:    getEnv("HL7_FILE_DROP_DIR", "./hl7"),
+		HL7Timeout:        getEnvDuration("HL7_TIMEOUT", 30*time.Second),
+		HL7ExportInterval: getEnvDuration("HL7_EXPORT_INTERVAL", time.Minute),
+
+		AccountReactivationWindow: getEnvDuration("ACCOUNT_REACTIVATION_WINDOW", 30*24*time.Hour),
+
+		BreakGlassDuration: getEnvDuration("BREAK_GLASS_DURATION", time.Hour),
+
+		PaymentProvider:      getEnv("PAYMENT_PROVIDER", "none"),
+		PaymentCurrency:      getEnv("PAYMENT_CURRENCY", "jpy"),
+		StripeAPIURL:         getEnv("STRIPE_API_URL", "https://api.stripe.com"),
+		StripeSecretKey:      getEnv("STRIPE_SECRET_KEY", ""),
+		StripePublishableKey: getEnv("STRIPE_PUBLISHABLE_KEY", ""),
+		StripeWebhookSecret:  getEnv("STRIPE_WEBHOOK_SECRET", ""),
+		PaymentTimeout:       getEnvDuration("PAYMENT_TIMEOUT", 20*time.Second),
+
+		ChatPostConsultationWindow:         getEnvDuration("CHAT_POST_CONSULTATION_WINDOW", 7*24*time.Hour),
+		MaxVideoSessionsPerAppointment:     getEnvInt("MAX_VIDEO_SESSIONS_PER_APPOINTMENT", 2),
+		PlusChatPostConsultationWindow:     getEnvDuration("PLUS_CHAT_POST_CONSULTATION_WINDOW", 30*24*time.Hour),
+		PlusMaxVideoSessionsPerAppointment: getEnvInt("PLUS_MAX_VIDEO_SESSIONS_PER_APPOINTMENT", 5),
+		SubscriptionPrice:                  getEnvInt("SUBSCRIPTION_PRICE", 0),
+		SubscriptionPeriod:                 getEnvDuration("SUBSCRIPTION_PERIOD", 30*24*time.Hour),
+
+		BookingMinNotice:      getEnvDuration("BOOKING_MIN_NOTICE", 0),
+		BookingMaxAdvanceDays: getEnvInt("BOOKING_MAX_ADVANCE_DAYS", 90),
+		BookingOpenTime:       getEnv("BOOKING_OPEN_TIME", "00:00"),
+		BookingCloseTime:      getEnv("BOOKING_CLOSE_TIME", "24:00"),
+		BookingOpenWeekdays:   getEnv("BOOKING_OPEN_WEEKDAYS", "0,1,2,3,4,5,6"),
+		BookingTimezone:       getEnv("BOOKING_TIMEZONE", "Asia/Tokyo"),
+
+		ClinicName:         getEnv("CLINIC_NAME", "オンライン診療"),
+		ClinicLogoURL:      getEnv("CLINIC_LOGO_URL", ""),
+		ClinicPrimaryColor: getEnv("CLINIC_PRIMARY_COLOR", "#1A73E8"),
+		ClinicSupportEmail: getEnv("CLINIC_SUPPORT_EMAIL", ""),
+		ClinicSupportPhone: getEnv("CLINIC_SUPPORT_PHONE", ""),
+		ClinicEmailFooter:  getEnv("CLINIC_EMAIL_FOOTER", ""),
+
+		AppMinVersionIOS:     getEnv("APP_MIN_VERSION_IOS", ""),
+		AppMinVersionAndroid: getEnv("APP_MIN_VERSION_ANDROID", ""),
+		AppMinVersionWeb:     getEnv("APP_MIN_VERSION_WEB", ""),
+		AppUpgradeURLIOS:     getEnv("APP_UPGRADE_URL_IOS", ""),
+		AppUpgradeURLAndroid: getEnv("APP_UPGRADE_URL_ANDROID", ""),
+
+		DemoMode:      getEnv("DEMO_MODE", "false") == "true",
+		DemoPassword:  getEnv("DEMO_PASSWORD", ""),
+		DemoResetHour: getEnvInt("DEMO_RESET_HOUR", 3),
 	}
 }
 
@@ -36,3 +508,45 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getEnvUintList(key string) []uint {
+	var values []uint
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if id, err := strconv.ParseUint(strings.TrimSpace(item), 10, 32); err == nil {
+			values = append(values, uint(id))
+		}
+	}
+	return values
+}
+
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
@@ -0,0 +1,249 @@
+package contentfilter
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ルールに一致した場合の対応
+const (
+	ActionWarn  = "warn"  // 送信は行い、送信者へ警告を返す
+	ActionBlock = "block" // 送信しない
+)
+
+// ルールの分類
+const (
+	CategoryProfanity = "profanity"
+	CategoryPII       = "pii"
+)
+
+// Rule 本文の判定ルール（独自のルールはこのインターフェースを実装して Pipeline.Add で追加する）
+type Rule interface {
+	Name() string
+	Match(text string) bool
+}
+
+// Hit ルールへの一致（一致した文字列そのものは含めない）
+type Hit struct {
+	Rule     string `json:"rule"`
+	Category string `json:"category"`
+	Action   string `json:"action"`
+}
+
+// Result 判定結果
+type Result struct {
+	Hits    []Hit `json:"hits"`
+	Blocked bool  `json:"blocked"`
+}
+
+// Rules 一致したルール名の一覧
+func (r Result) Rules() []string {
+	names := make([]string, len(r.Hits))
+	for i, hit := range r.Hits {
+		names[i] = hit.Rule
+	}
+	return names
+}
+
+type entry struct {
+	rule     Rule
+	category string
+	action   string
+}
+
+// Pipeline 登録順にすべてのルールを評価するフィルタ
+type Pipeline struct {
+	entries []entry
+}
+
+// Config 既定のルールの設定
+type Config struct {
+	ProfanityWords  []string // 空の場合は不適切語のルールを登録しない
+	ProfanityAction string
+	PIIAction       string
+}
+
+// NewPipeline 空のフィルタの作成
+func NewPipeline() *Pipeline {
+	return &Pipeline{}
+}
+
+// NewDefaultPipeline 不適切語リストと個人情報（クレジットカード番号・マイナンバー・SSN）のルールを登録したフィルタの作成
+func NewDefaultPipeline(cfg Config) (*Pipeline, error) {
+	p := NewPipeline()
+	if len(cfg.ProfanityWords) > 0 {
+		if err := p.Add(NewWordListRule("profanity_list", cfg.ProfanityWords), CategoryProfanity, cfg.ProfanityAction); err != nil {
+			return nil, err
+		}
+	}
+	for _, rule := range []Rule{NewCreditCardRule(), NewMyNumberRule(), NewSSNRule()} {
+		if err := p.Add(rule, CategoryPII, cfg.PIIAction); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Add ルールの追加
+func (p *Pipeline) Add(rule Rule, category, action string) error {
+	if action != ActionWarn && action != ActionBlock {
+		return errors.New("content filter action must be warn or block: " + rule.Name())
+	}
+	p.entries = append(p.entries, entry{rule: rule, category: category, action: action})
+	return nil
+}
+
+// Check 本文の判定
+func (p *Pipeline) Check(text string) Result {
+	result := Result{Hits: []Hit{}}
+	if p == nil {
+		return result
+	}
+	for _, e := range p.entries {
+		if !e.rule.Match(text) {
+			continue
+		}
+		result.Hits = append(result.Hits, Hit{Rule: e.rule.Name(), Category: e.category, Action: e.action})
+		if e.action == ActionBlock {
+			result.Blocked = true
+		}
+	}
+	return result
+}
+
+// WordListRule 語句の一覧のいずれかを含むかの判定（大文字・小文字、全角・半角スペースは区別しない）
+type WordListRule struct {
+	name  string
+	words []string
+}
+
+// NewWordListRule 語句リストのルールの作成
+func NewWordListRule(name string, words []string) *WordListRule {
+	rule := &WordListRule{name: name}
+	for _, word := range words {
+		if word = normalize(word); word != "" {
+			rule.words = append(rule.words, word)
+		}
+	}
+	return rule
+}
+
+func (r *WordListRule) Name() string { return r.name }
+
+func (r *WordListRule) Match(text string) bool {
+	text = normalize(text)
+	for _, word := range r.words {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// PatternRule 正規表現と検証関数（チェックディジット等）による判定
+type PatternRule struct {
+	name     string
+	pattern  *regexp.Regexp
+	validate func(digits string) bool
+}
+
+// NewPatternRule 正規表現のルールの作成（validateがnilの場合は一致のみで判定）
+func NewPatternRule(name string, pattern *regexp.Regexp, validate func(digits string) bool) *PatternRule {
+	return &PatternRule{name: name, pattern: pattern, validate: validate}
+}
+
+func (r *PatternRule) Name() string { return r.name }
+
+func (r *PatternRule) Match(text string) bool {
+	for _, candidate := range r.pattern.FindAllString(toHalfWidthDigits(text), -1) {
+		if r.validate == nil || r.validate(digitsOnly(candidate)) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewCreditCardRule クレジットカード番号（13〜19桁、スペース・ハイフン区切り可、Luhnチェック）
+func NewCreditCardRule() *PatternRule {
+	return NewPatternRule("credit_card", regexp.MustCompile(`(?:^|[^0-9])([0-9](?:[ -]?[0-9]){12,18})(?:[^0-9]|$)`), luhnValid)
+}
+
+// NewMyNumberRule マイナンバー（12桁、スペース・ハイフン区切り可、チェックディジット検証）
+// 区切りを含む数字の並び全体で判定し、カード番号などの一部には一致させない
+func NewMyNumberRule() *PatternRule {
+	return NewPatternRule("my_number", regexp.MustCompile(`(?:^|[^0-9])([0-9](?:[ -]?[0-9]){11,18})(?:[^0-9]|$)`), myNumberValid)
+}
+
+// NewSSNRule 米国の社会保障番号（123-45-6789 形式）
+func NewSSNRule() *PatternRule {
+	return NewPatternRule("ssn", regexp.MustCompile(`(?:^|[^0-9])[0-9]{3}-[0-9]{2}-[0-9]{4}(?:[^0-9]|$)`), nil)
+}
+
+// luhnValid Luhnアルゴリズムによる検証
+func luhnValid(digits string) bool {
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// myNumberValid マイナンバーのチェックディジットの検証
+func myNumberValid(digits string) bool {
+	if len(digits) != 12 {
+		return false
+	}
+	sum := 0
+	for n := 1; n <= 11; n++ {
+		p := int(digits[11-n] - '0')
+		q := n + 1
+		if n > 6 {
+			q = n - 5
+		}
+		sum += p * q
+	}
+	check := 11 - sum%11
+	if check >= 10 {
+		check = 0
+	}
+	return int(digits[11]-'0') == check
+}
+
+func normalize(text string) string {
+	text = strings.ToLower(text)
+	return strings.NewReplacer(" ", "", "　", "").Replace(text)
+}
+
+// toHalfWidthDigits 全角数字・全角ハイフンを半角に変換する
+func toHalfWidthDigits(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '０' && r <= '９':
+			return r - '０' + '0'
+		case r == '－':
+			return '-'
+		}
+		return r
+	}, text)
+}
+
+func digitsOnly(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -51,20 +51,25 @@ func Migrate(db *gorm.DB) error {
 	log.Println("Running database migrations...")
 
 	// テーブルの自動作成
-	if err := db.AutoMigrate(
-		&models.User{},
-		&models.PatientProfile{},
-		&models.DoctorProfile{},
-		&models.AvailabilitySlot{},
-		&models.Appointment{},
-		&models.Message{},
-		&models.VideoSession{},
-		&models.Prescription{},
-		&models.AuditLog{},
-	); err != nil {
+	if err := db.AutoMigrate(schemaModels()...); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	// ロールの制約の更新（AutoMigrateは既存の制約を変更しないため、models.User の check と一致させる）
+	if err := updateUserRoleConstraint(db); err != nil {
+		return fmt.Errorf("failed to update user role constraint: %w", err)
+	}
+
+	// チェック制約の更新（AutoMigrateは既存の制約を変更しないため）
+	if err := updateConstraints(db); err != nil {
+		return fmt.Errorf("failed to update constraints: %w", err)
+	}
+
+	// 予約の日時の導入前の予約に診療枠の時刻を設定する
+	if err := backfillAppointmentSchedules(db); err != nil {
+		return fmt.Errorf("failed to backfill appointment schedules: %w", err)
+	}
+
 	// インデックスの作成
 	if err := createIndexes(db); err != nil {
 		return fmt.Errorf("failed to create indexes: %w", err)
@@ -79,6 +84,184 @@ func Migrate(db *gorm.DB) error {
 	return nil
 }
 
+// schemaModels マイグレーションで作成するテーブルのモデル（未適用のマイグレーションの確認にも使う）
+func schemaModels() []interface{} {
+	return []interface{}{
+		&models.User{},
+		&models.UnknownLoginAttempt{},
+		&models.PatientProfile{},
+		&models.DoctorProfile{},
+		&models.InterpreterProfile{},
+		&models.InterpreterSlot{},
+		&models.Dependent{},
+		&models.AvailabilitySlot{},
+		&models.SlotTemplate{},
+		&models.Appointment{},
+		&models.Tag{},
+		&models.AppointmentTag{},
+		&models.AppointmentSavedView{},
+		&models.TriageAssessment{},
+		&models.Message{},
+		&models.StoredObject{},
+		&models.Attachment{},
+		&models.VideoSession{},
+		&models.VideoParticipant{},
+		&models.ICECandidate{},
+		&models.VideoPresence{},
+		&models.Transcript{},
+		&models.TranscriptSegment{},
+		&models.DeviceToken{},
+		&models.Prescription{},
+		&models.Invoice{},
+		&models.Payment{},
+		&models.LedgerAccount{},
+		&models.JournalEntry{},
+		&models.JournalLine{},
+		&models.AuditLog{},
+		&models.SavedFilter{},
+		&models.AuditArchive{},
+		&models.AuditArchiveEntry{},
+		&models.AuditPseudonymSalt{},
+		&models.BackupRun{},
+		&models.ExportDownload{},
+		&models.ReportSubscription{},
+		&models.HL7Destination{},
+		&models.HL7Message{},
+		&models.BreakGlassAccess{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.Escalation{},
+		&models.Complaint{},
+		&models.ComplaintEvidence{},
+		&models.LegalDocument{},
+		&models.LegalAcceptance{},
+		&models.CaseDiscussion{},
+		&models.DoctorTimeOff{},
+		&models.DoctorCoverage{},
+		&models.AppointmentTransfer{},
+		&models.AppointmentTask{},
+		&models.PROMAssignment{},
+		&models.MedicalRecord{},
+		&models.CorrectionRequest{},
+		&models.CodingSuggestion{},
+		&models.ProblemListEntry{},
+		&models.AppointmentFeedback{},
+		&models.DoctorCredential{},
+		&models.ContactChangeRequest{},
+		&models.PatientDocument{},
+		&models.PatientDocumentValue{},
+		&models.AppointmentDocumentGrant{},
+		&models.BookingPolicy{},
+		&models.ClinicBranding{},
+		&models.ClinicLocation{},
+		&models.DoctorLocation{},
+		&models.Organization{},
+		&models.OrganizationMember{},
+		&models.OrganizationRoleGrant{},
+		&models.PatientSubscription{},
+		&models.SubscriptionPayment{},
+		&models.AppVersionPolicy{},
+		&models.AttachmentPolicy{},
+		&models.Recording{},
+		&models.SlotSubscription{},
+		&models.DoctorAutoReply{},
+		&models.MessageFlag{},
+	}
+}
+
+// PendingMigrations モデルに対してデータベースにないテーブル・カラム（"テーブル" または "テーブル.カラム"）
+func PendingMigrations(db *gorm.DB) ([]string, error) {
+	var rows []struct {
+		TableName  string
+		ColumnName string
+	}
+	if err := db.Raw(`
+		SELECT table_name, column_name FROM information_schema.columns
+		WHERE table_schema = current_schema()
+	`).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	columns := make(map[string]map[string]bool)
+	for _, row := range rows {
+		if columns[row.TableName] == nil {
+			columns[row.TableName] = make(map[string]bool)
+		}
+		columns[row.TableName][row.ColumnName] = true
+	}
+
+	var pending []string
+	for _, model := range schemaModels() {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		table := stmt.Schema.Table
+		existing, ok := columns[table]
+		if !ok {
+			pending = append(pending, table)
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !field.IgnoreMigration && !existing[field.DBName] {
+				pending = append(pending, table+"."+field.DBName)
+			}
+		}
+	}
+	return pending, nil
+}
+
+// updateUserRoleConstraint 既存のデータベースのロールの制約を作り直す
+// 当初は patient, doctor のみで、通訳者・組織のスタッフ・管理者（ロールによるアクセス制御の admin）を順に追加した
+func updateUserRoleConstraint(db *gorm.DB) error {
+	return db.Exec(`
+		ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_role;
+		ALTER TABLE users ADD CONSTRAINT chk_users_role CHECK (role IN ('patient','doctor','admin','interpreter','staff'));
+	`).Error
+}
+
+func updateConstraints(db *gorm.DB) error {
+	// 診療枠の予約済みの状態の追加に合わせて制約を作り直す
+	if err := db.Exec(`
+		ALTER TABLE availability_slots DROP CONSTRAINT IF EXISTS chk_availability_slots_status;
+		ALTER TABLE availability_slots ADD CONSTRAINT chk_availability_slots_status CHECK (status IN ('open','blocked','booked'));
+	`).Error; err != nil {
+		return err
+	}
+
+	// 確認待ちの支払いの状態の追加に合わせて制約を作り直し、完了として記録していた確認待ちの支払いを移す
+	if err := db.Exec(`
+		ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_status;
+		UPDATE payments SET status = 'requires_review' WHERE status = 'succeeded' AND review_reason IS NOT NULL AND review_reason <> '';
+		ALTER TABLE payments ADD CONSTRAINT chk_payments_status CHECK (status IN ('requires_payment','processing','requires_review','succeeded','failed','cancelled'));
+	`).Error; err != nil {
+		return err
+	}
+
+	// 同じ内容の添付ファイルは同じキーを参照するため、キーの一意制約を外す
+	if err := db.Exec(`DROP INDEX IF EXISTS idx_attachments_object_key`).Error; err != nil {
+		return err
+	}
+
+	// 予約済みの状態の導入前に予約された診療枠を予約済みにする
+	return db.Exec(`
+		UPDATE availability_slots SET status = 'booked'
+		WHERE status = 'open' AND EXISTS (
+			SELECT 1 FROM appointments
+			WHERE appointments.slot_id = availability_slots.id
+			AND appointments.status IN ('pending','confirmed','completed')
+			AND appointments.deleted_at IS NULL
+		)
+	`).Error
+}
+
+func backfillAppointmentSchedules(db *gorm.DB) error {
+	return db.Exec(`
+		UPDATE appointments SET scheduled_start = availability_slots.start_time, scheduled_end = availability_slots.end_time
+		FROM availability_slots
+		WHERE availability_slots.id = appointments.slot_id AND appointments.scheduled_start IS NULL
+	`).Error
+}
+
 func createIndexes(db *gorm.DB) error {
 	// 予約の重複防止インデックス
 	if err := db.Exec(`
@@ -96,6 +279,13 @@ func createIndexes(db *gorm.DB) error {
 		CREATE INDEX IF NOT EXISTS idx_messages_appointment_id ON messages(appointment_id);
 		CREATE INDEX IF NOT EXISTS idx_slots_doctor_id ON availability_slots(doctor_id);
 		CREATE INDEX IF NOT EXISTS idx_slots_start_time ON availability_slots(start_time);
+		CREATE INDEX IF NOT EXISTS idx_audit_logs_at_id ON audit_logs(at DESC, id DESC);
+		CREATE INDEX IF NOT EXISTS idx_audit_logs_entity_at ON audit_logs(entity, entity_id, at DESC, id DESC);
+		CREATE INDEX IF NOT EXISTS idx_audit_logs_user_at ON audit_logs(user_id, at DESC, id DESC);
+		CREATE INDEX IF NOT EXISTS idx_audit_archive_entries_entity ON audit_archive_entries(entity, entity_id);
+		CREATE INDEX IF NOT EXISTS idx_interpreter_slots_time ON interpreter_slots(start_time, end_time) WHERE appointment_id IS NULL;
+		CREATE UNIQUE INDEX IF NOT EXISTS uniq_problem_list_active ON problem_list_entries(patient_id, COALESCE(dependent_id, 0), code) WHERE status = 'active';
+		CREATE UNIQUE INDEX IF NOT EXISTS uniq_recordings_in_progress ON recordings(video_session_id) WHERE status = 'recording';
 	`).Error; err != nil {
 		return err
 	}
@@ -0,0 +1,94 @@
+package drugpricing
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// datasetColumns 薬価データセットの列（1行目は列名）
+var datasetColumns = []string{"region", "medication_name", "unit", "unit_price", "currency"}
+
+// LoadDataset 薬価データセット（CSV）の読み込み（地域・正規化した薬剤名ごと）
+func LoadDataset(path string) (map[string]map[string]Price, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid drug pricing dataset: %v", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range datasetColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("drug pricing dataset is missing column: %s", name)
+		}
+	}
+
+	dataset := make(map[string]map[string]Price)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid drug pricing dataset: %v", err)
+		}
+
+		region, err := NormalizeRegion(record[columns["region"]])
+		if err != nil {
+			return nil, fmt.Errorf("drug pricing dataset line %d: %v", line, err)
+		}
+		unitPrice, err := strconv.ParseFloat(strings.TrimSpace(record[columns["unit_price"]]), 64)
+		if err != nil || unitPrice < 0 {
+			return nil, fmt.Errorf("drug pricing dataset line %d: invalid unit price", line)
+		}
+		price := Price{
+			MedicationName: strings.TrimSpace(record[columns["medication_name"]]),
+			Unit:           strings.TrimSpace(record[columns["unit"]]),
+			UnitPrice:      unitPrice,
+			Currency:       strings.ToUpper(strings.TrimSpace(record[columns["currency"]])),
+		}
+		if price.MedicationName == "" || price.Currency == "" {
+			return nil, fmt.Errorf("drug pricing dataset line %d: medication name and currency are required", line)
+		}
+
+		if dataset[region] == nil {
+			dataset[region] = make(map[string]Price)
+		}
+		dataset[region][normalizeName(price.MedicationName)] = price
+	}
+	return dataset, nil
+}
+
+// DatasetProvider 薬価データセットによる参照（起動時に読み込んだ1地域分）
+type DatasetProvider struct {
+	prices map[string]Price
+}
+
+func NewDatasetProvider(prices map[string]Price) *DatasetProvider {
+	return &DatasetProvider{prices: prices}
+}
+
+func (p *DatasetProvider) Name() string { return "dataset" }
+
+func (p *DatasetProvider) Lookup(ctx context.Context, region, medicationName string) (*Price, error) {
+	price, ok := p.prices[normalizeName(medicationName)]
+	if !ok {
+		return nil, nil
+	}
+	return &price, nil
+}
@@ -0,0 +1,135 @@
+package drugpricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Price 薬剤の単価（1錠・1包等の最小単位あたり）
+type Price struct {
+	MedicationName string  `json:"medication_name"`
+	Unit           string  `json:"unit"`
+	UnitPrice      float64 `json:"unit_price"`
+	Currency       string  `json:"currency"` // ISO 4217（JPY等）
+}
+
+// Provider 薬価の参照先（薬価データセット・外部の価格サービス等）
+type Provider interface {
+	Name() string
+	// Lookup 薬剤名から単価を取得する（該当する薬剤がない場合はnil）
+	Lookup(ctx context.Context, region, medicationName string) (*Price, error)
+}
+
+// Config 地域ごとの薬価の参照先の設定
+type Config struct {
+	DefaultRegion string   // 患者の地域が未設定の場合に使用
+	Providers     []string // 地域=参照先（dataset | http）の一覧（例: JP=dataset, US=http）
+	DatasetFile   string   // 薬価データセット（CSV: region,medication_name,unit,unit_price,currency）
+	APIURL        string
+	APIKey        string
+	Timeout       time.Duration
+}
+
+var regionPattern = regexp.MustCompile(`^[A-Z]{2}(-[A-Z0-9]{1,3})?$`)
+
+// NormalizeRegion 地域コードの正規化（ISO 3166-1/3166-2の形式、例: JP, JP-13, US-CA）
+func NormalizeRegion(region string) (string, error) {
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if !regionPattern.MatchString(region) {
+		return "", fmt.Errorf("invalid region: %s", region)
+	}
+	return region, nil
+}
+
+// Registry 地域ごとの参照先
+type Registry struct {
+	defaultRegion string
+	providers     map[string]Provider
+}
+
+// NewRegistry 設定に応じた参照先の作成（参照先が1つもない場合はnilを返し、見積もりを無効にする）
+func NewRegistry(cfg Config) (*Registry, error) {
+	if len(cfg.Providers) == 0 {
+		return nil, nil
+	}
+
+	registry := &Registry{providers: make(map[string]Provider)}
+	var dataset map[string]map[string]Price
+	for _, entry := range cfg.Providers {
+		region, kind, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid drug pricing provider entry: %s (expected REGION=provider)", entry)
+		}
+		region, err := NormalizeRegion(region)
+		if err != nil {
+			return nil, err
+		}
+
+		switch strings.TrimSpace(kind) {
+		case "dataset":
+			if dataset == nil {
+				if cfg.DatasetFile == "" {
+					return nil, errors.New("drug pricing dataset file is required for the dataset provider")
+				}
+				if dataset, err = LoadDataset(cfg.DatasetFile); err != nil {
+					return nil, err
+				}
+			}
+			if len(dataset[region]) == 0 {
+				return nil, fmt.Errorf("drug pricing dataset has no prices for region %s", region)
+			}
+			registry.providers[region] = NewDatasetProvider(dataset[region])
+		case "http":
+			if cfg.APIURL == "" {
+				return nil, errors.New("drug pricing API URL is required for the http provider")
+			}
+			registry.providers[region] = NewHTTPProvider(cfg.APIURL, cfg.APIKey, cfg.Timeout)
+		default:
+			return nil, fmt.Errorf("unknown drug pricing provider: %s", kind)
+		}
+	}
+
+	if cfg.DefaultRegion != "" {
+		region, err := NormalizeRegion(cfg.DefaultRegion)
+		if err != nil {
+			return nil, err
+		}
+		registry.defaultRegion = region
+	}
+	return registry, nil
+}
+
+// For 地域の参照先（都道府県・州の設定がない場合は国の参照先、地域が未設定の場合は既定の地域）
+// 該当する参照先がない場合はnil
+func (r *Registry) For(region string) (string, Provider) {
+	if region == "" {
+		region = r.defaultRegion
+	}
+	if provider, ok := r.providers[region]; ok {
+		return region, provider
+	}
+	if country, _, ok := strings.Cut(region, "-"); ok {
+		if provider, ok := r.providers[country]; ok {
+			return country, provider
+		}
+	}
+	return region, nil
+}
+
+// normalizeName 薬剤名の比較用の正規化（全角英数字・空白の違いと大文字小文字を無視する）
+func normalizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= '！' && r <= '～':
+			return r - '！' + '!'
+		case r == '　':
+			return ' '
+		}
+		return r
+	}, name)
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
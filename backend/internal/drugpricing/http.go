@@ -0,0 +1,72 @@
+package drugpricing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPProvider 外部の価格サービスによる参照
+// GET {APIURL}?region=JP&name=... に対し {"unit":"錠","unit_price":10.1,"currency":"JPY"} を返し、該当なしは404
+type HTTPProvider struct {
+	apiURL string
+	apiKey string
+	client *http.Client
+}
+
+func NewHTTPProvider(apiURL, apiKey string, timeout time.Duration) *HTTPProvider {
+	return &HTTPProvider{
+		apiURL: apiURL,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *HTTPProvider) Name() string { return "http" }
+
+func (p *HTTPProvider) Lookup(ctx context.Context, region, medicationName string) (*Price, error) {
+	query := url.Values{"region": {region}, "name": {medicationName}}
+	separator := "?"
+	if strings.Contains(p.apiURL, "?") {
+		separator = "&"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.apiURL+separator+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("drug pricing API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var price Price
+	if err := json.NewDecoder(resp.Body).Decode(&price); err != nil {
+		return nil, fmt.Errorf("invalid drug pricing response: %v", err)
+	}
+	if price.UnitPrice < 0 || price.Currency == "" {
+		return nil, fmt.Errorf("invalid drug pricing response for %s", medicationName)
+	}
+	if price.MedicationName == "" {
+		price.MedicationName = medicationName
+	}
+	price.Currency = strings.ToUpper(price.Currency)
+	return &price, nil
+}
@@ -0,0 +1,123 @@
+package drugpricing
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	numberPattern = regexp.MustCompile(`\d+(?:\.\d+)?`)
+	// 数量として数える単位（mg・mL等の含量の場合は1回1単位とみなす）
+	countUnitPattern    = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(錠|カプセル|cap|capsules?|tab|tablets?|包|袋|個|枚|本|滴|drops?|吸入|puffs?|噴霧|sprays?|回分|単位)?`)
+	timesPerDayPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`1日\s*(\d+)\s*回`),
+		regexp.MustCompile(`(\d+)\s*(?:times?|x)\s*(?:a|per|/)?\s*(?:day|daily)`),
+	}
+	asNeededPattern  = regexp.MustCompile(`(\d+)\s*回分`)
+	durationPatterns = []struct {
+		pattern *regexp.Regexp
+		days    float64
+	}{
+		{regexp.MustCompile(`(\d+)\s*(?:日分|日間|日|days?)`), 1},
+		{regexp.MustCompile(`(\d+)\s*(?:週間|週|weeks?)`), 7},
+		{regexp.MustCompile(`(\d+)\s*(?:ヶ月|か月|カ月|ケ月|months?)`), 30},
+	}
+)
+
+// timesPerDayKeywords 英語の用法（略語を含む）の1日の服用回数
+var timesPerDayKeywords = []struct {
+	terms []string
+	times float64
+}{
+	{[]string{"qid", "q.i.d", "four times"}, 4},
+	{[]string{"tid", "t.i.d", "three times"}, 3},
+	{[]string{"bid", "b.i.d", "twice"}, 2},
+	{[]string{"qd", "q.d", "once", "daily", "at bedtime"}, 1},
+}
+
+// mealTimings 日本語の用法の服用のタイミング（「朝夕食後」は2回）
+var mealTimings = [][]string{
+	{"朝"},
+	{"昼"},
+	{"夕"},
+	{"就寝前", "寝る前", "眠前"},
+}
+
+// EstimateQuantity 1回量・用法・日数から処方される数量（最小単位の数）を推定する
+// 頓用で回数が指定されていない等、推定できない場合はfalse
+func EstimateQuantity(dosage, frequency, duration string) (float64, bool) {
+	dosage = normalizeName(dosage)
+	frequency = normalizeName(frequency)
+	duration = normalizeName(duration)
+
+	perDose := 1.0
+	if m := countUnitPattern.FindStringSubmatch(dosage); m != nil && m[2] != "" {
+		perDose, _ = strconv.ParseFloat(m[1], 64)
+	}
+	if perDose <= 0 {
+		return 0, false
+	}
+
+	// 頓用（10回分等）は回数をそのまま使う
+	for _, text := range []string{duration, frequency} {
+		if m := asNeededPattern.FindStringSubmatch(text); m != nil {
+			doses, _ := strconv.ParseFloat(m[1], 64)
+			return perDose * doses, doses > 0
+		}
+	}
+
+	timesPerDay := parseTimesPerDay(frequency)
+	days := parseDays(duration)
+	if timesPerDay <= 0 || days <= 0 {
+		return 0, false
+	}
+	return perDose * timesPerDay * days, true
+}
+
+func parseTimesPerDay(frequency string) float64 {
+	for _, pattern := range timesPerDayPatterns {
+		if m := pattern.FindStringSubmatch(frequency); m != nil {
+			times, _ := strconv.ParseFloat(m[1], 64)
+			return times
+		}
+	}
+	if strings.Contains(frequency, "毎食") {
+		return 3
+	}
+	timings := 0
+	for _, terms := range mealTimings {
+		for _, term := range terms {
+			if strings.Contains(frequency, term) {
+				timings++
+				break
+			}
+		}
+	}
+	if timings > 0 {
+		return float64(timings)
+	}
+	for _, keyword := range timesPerDayKeywords {
+		for _, term := range keyword.terms {
+			if strings.Contains(frequency, term) {
+				return keyword.times
+			}
+		}
+	}
+	return 0
+}
+
+func parseDays(duration string) float64 {
+	for _, d := range durationPatterns {
+		if m := d.pattern.FindStringSubmatch(duration); m != nil {
+			value, _ := strconv.ParseFloat(m[1], 64)
+			return value * d.days
+		}
+	}
+	// 単位のない数字は日数とみなす
+	if m := numberPattern.FindString(duration); m != "" && m == strings.TrimSpace(duration) {
+		value, _ := strconv.ParseFloat(m, 64)
+		return value
+	}
+	return 0
+}
@@ -0,0 +1,273 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// A4用紙のサイズと余白（ポイント）
+const (
+	pdfPageWidth   = 595.0
+	pdfPageHeight  = 842.0
+	pdfMargin      = 50.0
+	pdfBodySize    = 10.5
+	pdfHeadingSize = 16.0
+	pdfLineSpacing = 1.6
+	pdfHeaderSize  = 9.0
+	pdfFooterSize  = 8.0
+)
+
+type pdfLine struct {
+	text     string
+	size     float64
+	y        float64
+	graphics string // 文字の代わりに描画する図形（QRコード等の描画命令）
+}
+
+// PDFBranding 各ページに表示するクリニックの表記
+type PDFBranding struct {
+	Header string // ページ上部の表記（クリニック名）
+	Color  string // ヘッダーの文字と罫線の色（#RRGGBB、空の場合は黒）
+	Footer string // ページ下部の表記（問い合わせ先等、改行で複数行）
+}
+
+// PDFDocument 日本語を含むテキストだけのPDF文書（A4縦、自動改行・改ページ）
+// フォントは埋め込まず、閲覧環境の日本語ゴシック体（HeiseiKakuGo-W5）を使用する
+type PDFDocument struct {
+	pages    [][]pdfLine
+	y        float64
+	branding PDFBranding
+	footer   []string // 折り返し済みのフッター
+}
+
+// NewPDFDocument PDF文書の作成
+func NewPDFDocument() *PDFDocument {
+	return NewBrandedPDFDocument(PDFBranding{})
+}
+
+// NewBrandedPDFDocument 各ページにヘッダー・フッターを表示するPDF文書の作成
+func NewBrandedPDFDocument(branding PDFBranding) *PDFDocument {
+	d := &PDFDocument{branding: branding}
+	if branding.Footer != "" {
+		maxWidth := (pdfPageWidth - pdfMargin*2) / pdfFooterSize
+		for _, paragraph := range strings.Split(branding.Footer, "\n") {
+			d.footer = append(d.footer, wrapPDFText(paragraph, maxWidth)...)
+		}
+	}
+	d.newPage()
+	return d
+}
+
+// Heading 見出しの追加
+func (d *PDFDocument) Heading(text string) {
+	d.write(text, pdfHeadingSize)
+}
+
+// Text 本文の追加（改行文字で段落を分け、行幅を超える場合は折り返す）
+func (d *PDFDocument) Text(text string) {
+	d.write(text, pdfBodySize)
+}
+
+// Blank 空行の追加
+func (d *PDFDocument) Blank() {
+	d.advance(pdfBodySize)
+}
+
+// QRCode QRコードの追加（modules[行][列]、trueが黒、周囲に4モジュール分の余白を付けて左寄せで描画）
+// 幅はwidthポイントで、ページに収まらない場合は改ページする
+func (d *PDFDocument) QRCode(modules [][]bool, width float64) {
+	if len(modules) == 0 {
+		return
+	}
+	module := width / float64(len(modules)+8)
+	if d.y-width < pdfMargin+d.footerHeight() {
+		d.newPage()
+	}
+	d.y -= width
+	left, top := pdfMargin+module*4, d.y+width-module*4
+
+	// 横に連続する黒のモジュールは1つの矩形にまとめる
+	var graphics strings.Builder
+	graphics.WriteString("q 0 0 0 rg\n")
+	for row, line := range modules {
+		for col := 0; col < len(line); col++ {
+			if !line[col] {
+				continue
+			}
+			start := col
+			for col+1 < len(line) && line[col+1] {
+				col++
+			}
+			fmt.Fprintf(&graphics, "%.2f %.2f %.2f %.2f re\n", left+module*float64(start), top-module*float64(row+1), module*float64(col-start+1), module)
+		}
+	}
+	graphics.WriteString("f Q\n")
+
+	page := len(d.pages) - 1
+	d.pages[page] = append(d.pages[page], pdfLine{y: d.y, graphics: graphics.String()})
+}
+
+// WriteTo PDFの書き出し
+func (d *PDFDocument) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// 1: カタログ / 2: ページツリー / 3-5: フォント / 6以降: ページと内容を交互に
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+i*2)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type0 /BaseFont /HeiseiKakuGo-W5 /Encoding /UniJIS-UCS2-H /DescendantFonts [4 0 R] >>")
+	object("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /HeiseiKakuGo-W5 /CIDSystemInfo << /Registry (Adobe) /Ordering (Japan1) /Supplement 2 >> /FontDescriptor 5 0 R /DW 1000 /W [1 95 500 231 632 500] >>")
+	object("<< /Type /FontDescriptor /FontName /HeiseiKakuGo-W5 /Flags 4 /FontBBox [-92 -250 1010 922] /ItalicAngle 0 /Ascent 752 /Descent -221 /CapHeight 737 /StemV 114 >>")
+
+	for i, lines := range d.pages {
+		var content strings.Builder
+		d.writeBranding(&content)
+		for _, line := range lines {
+			if line.graphics != "" {
+				content.WriteString(line.graphics)
+				continue
+			}
+			fmt.Fprintf(&content, "BT /F1 %.1f Tf %.1f %.1f Td <%s> Tj ET\n", line.size, pdfMargin, line.y, encodePDFText(line.text))
+		}
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, 7+i*2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.WriteTo(w)
+}
+
+func (d *PDFDocument) write(text string, size float64) {
+	maxWidth := (pdfPageWidth - pdfMargin*2) / size
+	for _, paragraph := range strings.Split(text, "\n") {
+		for _, line := range wrapPDFText(paragraph, maxWidth) {
+			d.advance(size)
+			page := len(d.pages) - 1
+			d.pages[page] = append(d.pages[page], pdfLine{text: line, size: size, y: d.y})
+		}
+	}
+}
+
+// advance 1行分下へ進める（下余白・フッターに達した場合は改ページ）
+func (d *PDFDocument) advance(size float64) {
+	d.y -= size * pdfLineSpacing
+	if d.y < pdfMargin+d.footerHeight() {
+		d.newPage()
+		d.y -= size * pdfLineSpacing
+	}
+}
+
+func (d *PDFDocument) newPage() {
+	d.pages = append(d.pages, nil)
+	d.y = pdfPageHeight - pdfMargin - d.headerHeight()
+}
+
+// headerHeight ヘッダーと罫線が占める高さ
+func (d *PDFDocument) headerHeight() float64 {
+	if d.branding.Header == "" {
+		return 0
+	}
+	return pdfHeaderSize * pdfLineSpacing * 2
+}
+
+// footerHeight フッターが占める高さ（本文との間隔を含む）
+func (d *PDFDocument) footerHeight() float64 {
+	if len(d.footer) == 0 {
+		return 0
+	}
+	return pdfFooterSize * pdfLineSpacing * float64(len(d.footer)+1)
+}
+
+// writeBranding ページのヘッダー（クリニック名と罫線）とフッターの描画
+// 色の指定は本文に影響しないようにグラフィックス状態の保存・復元で囲む
+func (d *PDFDocument) writeBranding(content *strings.Builder) {
+	if d.branding.Header == "" && len(d.footer) == 0 {
+		return
+	}
+	content.WriteString("q\n")
+	defer content.WriteString("Q\n")
+	if d.branding.Header != "" {
+		r, g, b := parsePDFColor(d.branding.Color)
+		y := pdfPageHeight - pdfMargin - pdfHeaderSize
+		fmt.Fprintf(content, "BT %.3f %.3f %.3f rg /F1 %.1f Tf %.1f %.1f Td <%s> Tj ET\n", r, g, b, pdfHeaderSize, pdfMargin, y, encodePDFText(d.branding.Header))
+		rule := y - pdfHeaderSize*0.6
+		fmt.Fprintf(content, "%.3f %.3f %.3f RG 0.8 w %.1f %.1f m %.1f %.1f l S\n", r, g, b, pdfMargin, rule, pdfPageWidth-pdfMargin, rule)
+	}
+	for i, line := range d.footer {
+		y := pdfMargin + pdfFooterSize*pdfLineSpacing*float64(len(d.footer)-1-i)
+		fmt.Fprintf(content, "BT 0.4 0.4 0.4 rg /F1 %.1f Tf %.1f %.1f Td <%s> Tj ET\n", pdfFooterSize, pdfMargin, y, encodePDFText(line))
+	}
+}
+
+// parsePDFColor #RRGGBB をPDFの色（0〜1）へ変換（不正な値は黒）
+func parsePDFColor(hex string) (float64, float64, float64) {
+	var r, g, b uint8
+	if len(hex) != 7 || hex[0] != '#' {
+		return 0, 0, 0
+	}
+	if _, err := fmt.Sscanf(hex[1:], "%02x%02x%02x", &r, &g, &b); err != nil {
+		return 0, 0, 0
+	}
+	return float64(r) / 255, float64(g) / 255, float64(b) / 255
+}
+
+// wrapPDFText 文字幅（全角1・半角0.5、文字サイズ単位）で行を折り返す
+func wrapPDFText(text string, maxWidth float64) []string {
+	if text == "" {
+		return []string{""}
+	}
+
+	var lines []string
+	var current []rune
+	width := 0.0
+	for _, r := range text {
+		w := pdfRuneWidth(r)
+		if width+w > maxWidth && len(current) > 0 {
+			lines = append(lines, string(current))
+			current = current[:0]
+			width = 0
+		}
+		current = append(current, r)
+		width += w
+	}
+	return append(lines, string(current))
+}
+
+func pdfRuneWidth(r rune) float64 {
+	if r < 0x80 || (r >= 0xFF61 && r <= 0xFF9F) {
+		return 0.5
+	}
+	return 1
+}
+
+// encodePDFText UCS-2（ビッグエンディアン）の16進文字列へ変換（基本多言語面外の文字は「?」にする）
+func encodePDFText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if r > 0xFFFF || utf16.IsSurrogate(r) {
+			r = '?'
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	return b.String()
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type AbsenceHandler struct {
+	absenceService *services.DoctorAbsenceService
+}
+
+func NewAbsenceHandler(absenceService *services.DoctorAbsenceService) *AbsenceHandler {
+	return &AbsenceHandler{
+		absenceService: absenceService,
+	}
+}
+
+// CreateTimeOff 休診期間の登録（医師用）
+func (h *AbsenceHandler) CreateTimeOff(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CreateTimeOffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.absenceService.CreateTimeOff(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Time off registered successfully",
+		"result":  result,
+	})
+}
+
+// GetTimeOffs 休診期間一覧の取得（医師用）
+func (h *AbsenceHandler) GetTimeOffs(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	timeOffs, err := h.absenceService.GetTimeOffs(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch time off"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"time_off": timeOffs})
+}
+
+// DeleteTimeOff 休診期間の削除（医師用）
+func (h *AbsenceHandler) DeleteTimeOff(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	timeOffID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time off ID"})
+		return
+	}
+
+	if err := h.absenceService.DeleteTimeOff(userID.(uint), uint(timeOffID)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Time off deleted successfully"})
+}
+
+// GetAlternatives 辞退された予約の代替候補の取得（患者用）
+func (h *AbsenceHandler) GetAlternatives(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	alternatives, err := h.absenceService.GetAlternatives(uint(appointmentID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"alternatives": alternatives})
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type AccountHandler struct {
+	accountService *services.AccountService
+}
+
+func NewAccountHandler(accountService *services.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+// RequestEmailChange メールアドレス変更の申請
+func (h *AccountHandler) RequestEmailChange(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request, err := h.accountService.RequestEmailChange(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Verification links have been sent to both the current and new email addresses",
+		"request": request,
+	})
+}
+
+// ConfirmEmailChange 確認リンクによるメールアドレス変更の承認（未ログインでも可）
+func (h *AccountHandler) ConfirmEmailChange(c *gin.Context) {
+	var req services.ContactChangeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request, err := h.accountService.ConfirmEmailChange(req.Token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message := "Confirmation recorded, waiting for the other address"
+	if request.Status == "completed" {
+		message = "Email changed successfully"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "status": request.Status})
+}
+
+// CancelEmailChange 旧アドレスからのメールアドレス変更の取り消し（未ログインでも可）
+func (h *AccountHandler) CancelEmailChange(c *gin.Context) {
+	var req services.ContactChangeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accountService.CancelEmailChange(req.Token); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email change cancelled"})
+}
+
+// RequestPhoneChange 電話番号変更の申請（SMSで確認コードを送信）
+func (h *AccountHandler) RequestPhoneChange(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.ChangePhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request, err := h.accountService.RequestPhoneChange(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":    "Verification code has been sent to the new phone number",
+		"expires_at": request.ExpiresAt,
+	})
+}
+
+// VerifyPhoneChange 確認コードの検証と電話番号の変更
+func (h *AccountHandler) VerifyPhoneChange(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.VerifyPhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := h.accountService.VerifyPhoneChange(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Phone number changed successfully", "profile": profile})
+}
+
+// DeactivateAccount 本人によるアカウントの退会
+func (h *AccountHandler) DeactivateAccount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.DeactivateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.accountService.DeactivateAccount(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Account deactivated successfully",
+		"result":  result,
+	})
+}
+
+// ReactivateAccount 退会済みアカウントの利用再開（ログインできないため認証不要）
+func (h *AccountHandler) ReactivateAccount(c *gin.Context) {
+	var req services.ReactivateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accountService.ReactivateAccount(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account reactivated successfully"})
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type AppVersionHandler struct {
+	appVersionService *services.AppVersionService
+}
+
+func NewAppVersionHandler(appVersionService *services.AppVersionService) *AppVersionHandler {
+	return &AppVersionHandler{
+		appVersionService: appVersionService,
+	}
+}
+
+// CheckVersion アプリのバージョンの確認（起動時に呼び出す、未ログインでも可能）
+// ?platform=&version= またはX-App-Platform・X-App-Versionのヘッダーで指定する
+func (h *AppVersionHandler) CheckVersion(c *gin.Context) {
+	platform := c.Query("platform")
+	if platform == "" {
+		platform = c.GetHeader("X-App-Platform")
+	}
+	version := c.Query("version")
+	if version == "" {
+		version = c.GetHeader("X-App-Version")
+	}
+
+	status, err := h.appVersionService.CheckVersion(platform, version)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"app_version": status})
+}
+
+// GetPolicy アプリの最低バージョンの設定の取得（管理者用）
+func (h *AppVersionHandler) GetPolicy(c *gin.Context) {
+	policy, err := h.appVersionService.GetPolicy()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch app version policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policy": policy})
+}
+
+// UpdatePolicy アプリの最低バージョンの設定の更新（管理者用）
+func (h *AppVersionHandler) UpdatePolicy(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.UpdateAppVersionPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.appVersionService.UpdatePolicy(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "App version policy updated successfully",
+		"policy":  policy,
+	})
+}
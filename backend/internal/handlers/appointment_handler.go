@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"online_medical_consultation_app/backend/internal/services"
+	"online_medical_consultation_app/backend/internal/views"
 )
 
 type AppointmentHandler struct {
@@ -35,13 +38,158 @@ func (h *AppointmentHandler) CreateAppointment(c *gin.Context) {
 	req.PatientID = userID.(uint)
 	appointment, err := h.appointmentService.CreateAppointment(req)
 	if err != nil {
+		var conflict *services.AppointmentConflictError
+		if errors.As(err, &conflict) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":                   err.Error(),
+				"code":                    "appointment_conflict",
+				"conflicting_appointment": views.Appointment(viewerFromContext(c), &conflict.Appointment),
+			})
+			return
+		}
+		if err.Error() == "time slot is already booked" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "slot_unavailable"})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":     "Appointment created successfully",
-		"appointment": appointment,
+		"appointment": views.Appointment(viewerFromContext(c), appointment),
+	})
+}
+
+// CreateInstantAppointment 即時診療の予約（患者用）
+func (h *AppointmentHandler) CreateInstantAppointment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CreateInstantAppointmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.PatientID = userID.(uint)
+	appointment, err := h.appointmentService.CreateInstantAppointment(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Instant consultation created successfully",
+		"appointment": views.Appointment(viewerFromContext(c), appointment),
+	})
+}
+
+// CreateAsyncAppointment 非同期（チャット）相談の作成（患者用）
+func (h *AppointmentHandler) CreateAsyncAppointment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CreateAsyncAppointmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.PatientID = userID.(uint)
+	appointment, err := h.appointmentService.CreateAsyncAppointment(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Async consultation created successfully",
+		"appointment": views.Appointment(viewerFromContext(c), appointment),
+	})
+}
+
+// GetDoctorAsyncQueue 未完了の非同期相談一覧（医師用）
+func (h *AppointmentHandler) GetDoctorAsyncQueue(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointments, err := h.appointmentService.GetDoctorAsyncQueue(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch async consultations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"appointments": views.Appointments(viewerFromContext(c), appointments)})
+}
+
+// CloseAsyncConsultation 非同期相談の終了（医師用）
+func (h *AppointmentHandler) CloseAsyncConsultation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	appointment, err := h.appointmentService.CloseAsyncConsultation(uint(appointmentID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Consultation closed successfully",
+		"appointment": views.Appointment(viewerFromContext(c), appointment),
+	})
+}
+
+// ReportDelay 医師の遅れの連絡（医師用）
+func (h *AppointmentHandler) ReportDelay(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	var req services.ReportDelayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.DoctorID = userID.(uint)
+	req.AppointmentID = uint(appointmentID)
+
+	appointment, err := h.appointmentService.ReportDelay(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Delay reported successfully",
+		"appointment": views.Appointment(viewerFromContext(c), appointment),
 	})
 }
 
@@ -53,16 +201,17 @@ func (h *AppointmentHandler) GetPatientAppointments(c *gin.Context) {
 		return
 	}
 
-	appointments, err := h.appointmentService.GetPatientAppointments(userID.(uint))
+	page := parsePage(c, 20, 100)
+	appointments, total, err := h.appointmentService.GetPatientAppointments(userID.(uint), page.PerPage, page.Offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"appointments": appointments})
+	c.JSON(http.StatusOK, pageResponse(views.Appointments(viewerFromContext(c), appointments), total, page))
 }
 
-// GetDoctorAppointments 医師の予約一覧取得
+// GetDoctorAppointments 医師の予約一覧取得（?status=&tags=1,2&match=all|any、または保存した条件 ?view=）
 func (h *AppointmentHandler) GetDoctorAppointments(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -70,13 +219,38 @@ func (h *AppointmentHandler) GetDoctorAppointments(c *gin.Context) {
 		return
 	}
 
-	appointments, err := h.appointmentService.GetDoctorAppointments(userID.(uint))
+	query := services.DoctorAppointmentQuery{
+		Status:   c.Query("status"),
+		MatchAll: c.Query("match") == "all",
+	}
+	if viewStr := c.Query("view"); viewStr != "" {
+		viewID, err := strconv.ParseUint(viewStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid view ID"})
+			return
+		}
+		value := uint(viewID)
+		query.ViewID = &value
+	}
+	if tagsStr := c.Query("tags"); tagsStr != "" {
+		for _, value := range strings.Split(tagsStr, ",") {
+			tagID, err := strconv.ParseUint(strings.TrimSpace(value), 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+				return
+			}
+			query.TagIDs = append(query.TagIDs, uint(tagID))
+		}
+	}
+
+	page := parsePage(c, 20, 100)
+	appointments, total, err := h.appointmentService.GetDoctorAppointments(userID.(uint), query, page.PerPage, page.Offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"appointments": appointments})
+	c.JSON(http.StatusOK, pageResponse(views.Appointments(viewerFromContext(c), appointments), total, page))
 }
 
 // UpdateAppointmentStatus 予約ステータスの更新（医師用）
@@ -110,7 +284,7 @@ func (h *AppointmentHandler) UpdateAppointmentStatus(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":     "Appointment status updated successfully",
-		"appointment": appointment,
+		"appointment": views.Appointment(viewerFromContext(c), appointment),
 	})
 }
 
@@ -136,6 +310,35 @@ func (h *AppointmentHandler) CancelAppointment(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Appointment cancelled successfully"})
 }
 
+// AttachTriage 予約後の問診結果の提出（患者のみ）
+func (h *AppointmentHandler) AttachTriage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	var req services.AttachTriageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	appointment, err := h.appointmentService.AttachTriage(uint(appointmentID), userID.(uint), req.TriageID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"appointment": views.Appointment(viewerFromContext(c), appointment)})
+}
+
 // GetAppointmentDetails 予約詳細の取得
 func (h *AppointmentHandler) GetAppointmentDetails(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -156,5 +359,5 @@ func (h *AppointmentHandler) GetAppointmentDetails(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"appointment": appointment})
+	c.JSON(http.StatusOK, gin.H{"appointment": views.Appointment(viewerFromContext(c), appointment)})
 }
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type AttachmentPolicyHandler struct {
+	attachmentPolicyService *services.AttachmentPolicyService
+}
+
+func NewAttachmentPolicyHandler(attachmentPolicyService *services.AttachmentPolicyService) *AttachmentPolicyHandler {
+	return &AttachmentPolicyHandler{
+		attachmentPolicyService: attachmentPolicyService,
+	}
+}
+
+// GetMyPolicy 自分のロールで添付できる形式とサイズの上限
+func (h *AttachmentPolicyHandler) GetMyPolicy(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	policy, err := h.attachmentPolicyService.GetPolicyForUser(userID.(uint))
+	if err != nil {
+		c.JSON(attachmentPolicyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policy": policy})
+}
+
+// GetPolicies ロールごとの添付ファイルの制限の一覧（管理者用）
+func (h *AttachmentPolicyHandler) GetPolicies(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	policies, err := h.attachmentPolicyService.GetPolicies(userID.(uint))
+	if err != nil {
+		c.JSON(attachmentPolicyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policies": policies})
+}
+
+// UpdatePolicy ロールの添付ファイルの制限の更新（管理者用）
+func (h *AttachmentPolicyHandler) UpdatePolicy(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.UpdateAttachmentPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.attachmentPolicyService.UpdatePolicy(userID.(uint), c.Param("role"), req)
+	if err != nil {
+		c.JSON(attachmentPolicyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Attachment policy updated successfully",
+		"policy":  policy,
+	})
+}
+
+func attachmentPolicyErrorStatus(err error) int {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "unauthorized"):
+		return http.StatusForbidden
+	case strings.HasPrefix(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
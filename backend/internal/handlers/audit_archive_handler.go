@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type AuditArchiveHandler struct {
+	archiveService *services.AuditArchiveService
+}
+
+func NewAuditArchiveHandler(archiveService *services.AuditArchiveService) *AuditArchiveHandler {
+	return &AuditArchiveHandler{
+		archiveService: archiveService,
+	}
+}
+
+// GetArchives 監査ログアーカイブ一覧の取得（管理者用）
+func (h *AuditArchiveHandler) GetArchives(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit := 50 // デフォルト値
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 200 {
+			limit = l
+		}
+	}
+
+	offset := 0
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	archives, err := h.archiveService.GetArchives(limit, offset, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"archives": archives})
+}
+
+// SearchArchivedLogs アーカイブ済み監査ログの索引検索（管理者用）
+func (h *AuditArchiveHandler) SearchArchivedLogs(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.AuditArchiveSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Limit <= 0 || req.Limit > 1000 {
+		req.Limit = 100
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	entries, err := h.archiveService.SearchArchivedLogs(req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+// RehydrateArchive アーカイブの復元（管理者用）
+func (h *AuditArchiveHandler) RehydrateArchive(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	archiveID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid archive ID"})
+		return
+	}
+
+	archive, err := h.archiveService.RehydrateArchive(uint(archiveID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Archive rehydrated successfully",
+		"archive": archive,
+	})
+}
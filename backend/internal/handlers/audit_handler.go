@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"strconv"
 
@@ -9,12 +10,14 @@ import (
 )
 
 type AuditHandler struct {
-	auditService *services.AuditService
+	auditService    *services.AuditService
+	downloadService *services.DownloadService
 }
 
-func NewAuditHandler(auditService *services.AuditService) *AuditHandler {
+func NewAuditHandler(auditService *services.AuditService, downloadService *services.DownloadService) *AuditHandler {
 	return &AuditHandler{
-		auditService: auditService,
+		auditService:    auditService,
+		downloadService: downloadService,
 	}
 }
 
@@ -27,41 +30,20 @@ func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
 	}
 
 	// クエリパラメータの取得
-	entity := c.Query("entity")
-	entityID := c.Query("entity_id")
-	action := c.Query("action")
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
-	
-	limit := 100 // デフォルト値
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 1000 {
-			limit = l
-		}
-	}
-
-	offset := 0
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
-		}
-	}
-
-	logs, err := h.auditService.GetAuditLogs(services.AuditLogFilter{
-		Entity:    entity,
-		EntityID:  entityID,
-		Action:    action,
-		StartDate: startDate,
-		EndDate:   endDate,
-		Limit:     limit,
-		Offset:    offset,
-	}, userID.(uint))
+	filter := parseAuditPageParams(c, 100, 1000)
+	filter.Entity = c.Query("entity")
+	filter.EntityID = c.Query("entity_id")
+	filter.Action = c.Query("action")
+	filter.StartDate = c.Query("start_date")
+	filter.EndDate = c.Query("end_date")
+
+	page, err := h.auditService.GetAuditLogs(filter, userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"audit_logs": logs})
+	c.JSON(http.StatusOK, page)
 }
 
 // GetUserAuditLogs 特定ユーザーの監査ログ取得
@@ -79,27 +61,15 @@ func (h *AuditHandler) GetUserAuditLogs(c *gin.Context) {
 	}
 
 	// クエリパラメータの取得
-	limit := 50 // デフォルト値
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 200 {
-			limit = l
-		}
-	}
+	filter := parseAuditPageParams(c, 50, 200)
 
-	offset := 0
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
-		}
-	}
-
-	logs, err := h.auditService.GetUserAuditLogs(uint(targetUserID), limit, offset, userID.(uint))
+	page, err := h.auditService.GetUserAuditLogs(uint(targetUserID), filter, userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"audit_logs": logs})
+	c.JSON(http.StatusOK, page)
 }
 
 // GetEntityAuditLogs 特定エンティティの監査ログ取得
@@ -114,30 +84,43 @@ func (h *AuditHandler) GetEntityAuditLogs(c *gin.Context) {
 	entityID := c.Param("entityId")
 
 	// クエリパラメータの取得
-	limit := 50 // デフォルト値
+	filter := parseAuditPageParams(c, 50, 200)
+
+	page, err := h.auditService.GetEntityAuditLogs(entity, entityID, filter, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// parseAuditPageParams ページネーション関連のクエリパラメータの取得
+// limit, offset, cursor, include_total
+func parseAuditPageParams(c *gin.Context, defaultLimit, maxLimit int) services.AuditLogFilter {
+	filter := services.AuditLogFilter{Limit: defaultLimit}
+
 	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 200 {
-			limit = l
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= maxLimit {
+			filter.Limit = l
 		}
 	}
 
-	offset := 0
 	if offsetStr := c.Query("offset"); offsetStr != "" {
 		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
+			filter.Offset = o
 		}
 	}
 
-	logs, err := h.auditService.GetEntityAuditLogs(entity, entityID, limit, offset, userID.(uint))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
+	filter.Severity = c.Query("severity")
+	filter.Cursor = c.Query("cursor")
+	filter.IncludeTotal = c.Query("include_total") == "true"
 
-	c.JSON(http.StatusOK, gin.H{"audit_logs": logs})
+	return filter
 }
 
 // ExportAuditLogs 監査ログのエクスポート（管理者用）
+// 大量データに対応するため、チャンク転送でストリーミングする
 func (h *AuditHandler) ExportAuditLogs(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -149,36 +132,92 @@ func (h *AuditHandler) ExportAuditLogs(c *gin.Context) {
 	entity := c.Query("entity")
 	entityID := c.Query("entity_id")
 	action := c.Query("action")
+	severity := c.Query("severity")
 	startDate := c.Query("start_date")
 	endDate := c.Query("end_date")
 	format := c.Query("format")
 	if format == "" {
 		format = "csv"
 	}
+	// mode=pseudonymized: 分析用に利用者IDを仮名化してメタデータを除く
+	mode := c.Query("mode")
 
-	if format != "csv" && format != "json" {
+	if !services.IsSupportedAuditExportFormat(format) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported export format"})
 		return
 	}
 
-	data, filename, err := h.auditService.ExportAuditLogs(services.AuditLogFilter{
+	limit := 0 // デフォルトは件数制限なし
+	if limitStr := c.Query("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = l
+	}
+
+	offset := 0
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		o, err := strconv.Atoi(offsetStr)
+		if err != nil || o < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+			return
+		}
+		offset = o
+	}
+
+	auditExport, err := h.auditService.ExportAuditLogs(services.AuditLogFilter{
 		Entity:    entity,
 		EntityID:  entityID,
 		Action:    action,
+		Severity:  severity,
 		StartDate: startDate,
 		EndDate:   endDate,
-	}, format, userID.(uint))
+		Limit:     limit,
+		Offset:    offset,
+	}, format, mode, userID.(uint))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// ファイルのダウンロード
-	c.Header("Content-Disposition", "attachment; filename="+filename)
-	if format == "csv" {
-		c.Header("Content-Type", "text/csv")
-	} else {
-		c.Header("Content-Type", "application/json")
+	// ファイルのダウンロード（Content-Lengthを指定せずチャンク転送、途中から再取得できる）
+	if err := streamExport(c, h.downloadService, userID.(uint), auditExport.Filename, auditExport.ContentType, auditExport.Stream); err != nil {
+		// ヘッダー送信後のため、エラーはログにのみ記録する
+		log.Printf("Audit export failed: %v", err)
+		c.Error(err)
 	}
-	c.Data(http.StatusOK, c.GetHeader("Content-Type"), data)
+}
+
+// GetMyAccessLog 自分の診療データの閲覧履歴の取得（患者用）
+func (h *AuditHandler) GetMyAccessLog(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// クエリパラメータの取得
+	limit := 50 // デフォルト値
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 200 {
+			limit = l
+		}
+	}
+
+	offset := 0
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	logs, err := h.auditService.GetPatientAccessLog(userID.(uint), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"access_log": logs})
 }
@@ -1,7 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"online_medical_consultation_app/backend/internal/services"
@@ -46,6 +51,22 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	response, err := h.authService.Login(req)
+	if errors.Is(err, services.ErrAccountDeactivated) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "account_deactivated"})
+		return
+	}
+	var locked *services.AccountLockedError
+	if errors.As(err, &locked) {
+		retryAfter := int(math.Ceil(time.Until(locked.LockedUntil).Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":        err.Error(),
+			"code":         "account_locked",
+			"locked_until": locked.LockedUntil,
+			"retry_after":  retryAfter,
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -92,3 +113,70 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Profile updated successfully"})
 }
+
+// UnlockAccount ログインの失敗によるアカウントのロックの解除（管理者用）
+func (h *AuthHandler) UnlockAccount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	user, err := h.authService.UnlockAccount(userID.(uint), uint(targetID))
+	if err != nil {
+		c.JSON(authErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Account unlocked successfully",
+		"user":    user,
+	})
+}
+
+// ProvisionInterpreter 通訳者アカウントの作成（管理者用）
+func (h *AuthHandler) ProvisionInterpreter(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.ProvisionInterpreterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.authService.ProvisionInterpreter(userID.(uint), req)
+	if err != nil {
+		c.JSON(authErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Interpreter created successfully",
+		"user":    user,
+	})
+}
+
+func authErrorStatus(err error) int {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "unauthorized"):
+		return http.StatusForbidden
+	case err.Error() == "user already exists":
+		return http.StatusConflict
+	case err.Error() == "this email domain is reserved", strings.HasPrefix(err.Error(), "at least one language"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type AutoReplyHandler struct {
+	autoReplyService *services.AutoReplyService
+}
+
+func NewAutoReplyHandler(autoReplyService *services.AutoReplyService) *AutoReplyHandler {
+	return &AutoReplyHandler{
+		autoReplyService: autoReplyService,
+	}
+}
+
+// GetAutoReply 時間外の自動返信の設定の取得（医師用）
+func (h *AutoReplyHandler) GetAutoReply(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	autoReply, err := h.autoReplyService.GetAutoReply(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch auto-reply settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"auto_reply": autoReply})
+}
+
+// UpdateAutoReply 時間外の自動返信の設定の変更（医師用）
+func (h *AutoReplyHandler) UpdateAutoReply(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.UpdateAutoReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	autoReply, err := h.autoReplyService.UpdateAutoReply(userID.(uint), req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "only doctors can configure auto-reply" {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Auto-reply settings updated successfully",
+		"auto_reply": autoReply,
+	})
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type BookingPolicyHandler struct {
+	bookingPolicyService *services.BookingPolicyService
+}
+
+func NewBookingPolicyHandler(bookingPolicyService *services.BookingPolicyService) *BookingPolicyHandler {
+	return &BookingPolicyHandler{
+		bookingPolicyService: bookingPolicyService,
+	}
+}
+
+// GetPolicy 予約受付ルールの取得
+func (h *BookingPolicyHandler) GetPolicy(c *gin.Context) {
+	policy, err := h.bookingPolicyService.GetPolicy()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch booking policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policy": policy})
+}
+
+// UpdatePolicy 予約受付ルールの更新（管理者用）
+func (h *BookingPolicyHandler) UpdatePolicy(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.UpdateBookingPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.bookingPolicyService.UpdatePolicy(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Booking policy updated successfully",
+		"policy":  policy,
+	})
+}
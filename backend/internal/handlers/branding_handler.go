@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type BrandingHandler struct {
+	brandingService *services.BrandingService
+}
+
+func NewBrandingHandler(brandingService *services.BrandingService) *BrandingHandler {
+	return &BrandingHandler{
+		brandingService: brandingService,
+	}
+}
+
+// GetBranding クリニックの表記の取得（ロゴ・ブランドカラー・問い合わせ先等）
+func (h *BrandingHandler) GetBranding(c *gin.Context) {
+	branding, err := h.brandingService.GetBranding()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch branding"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"branding": branding})
+}
+
+// UpdateBranding クリニックの表記の更新（管理者用）
+func (h *BrandingHandler) UpdateBranding(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.UpdateBrandingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	branding, err := h.brandingService.UpdateBranding(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Branding updated successfully",
+		"branding": branding,
+	})
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type BreakGlassHandler struct {
+	breakGlassService *services.BreakGlassService
+}
+
+func NewBreakGlassHandler(breakGlassService *services.BreakGlassService) *BreakGlassHandler {
+	return &BreakGlassHandler{
+		breakGlassService: breakGlassService,
+	}
+}
+
+// OpenAccess 緊急時アクセスの開始（許可された医師・管理者用、理由の記録が必須）
+func (h *BreakGlassHandler) OpenAccess(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.OpenBreakGlassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	access, err := h.breakGlassService.Open(userID.(uint), req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.HasPrefix(err.Error(), "unauthorized") {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Break-glass access opened",
+		"access":  access,
+	})
+}
+
+// GetEmergencyRecord 緊急時アクセスによる患者の診療記録の閲覧
+func (h *BreakGlassHandler) GetEmergencyRecord(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	patientID, err := strconv.ParseUint(c.Param("patientId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	record, err := h.breakGlassService.GetEmergencyRecord(userID.(uint), uint(patientID))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"record": record})
+}
+
+// EndAccess 緊急時アクセスの期限前の終了
+func (h *BreakGlassHandler) EndAccess(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	accessID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid break-glass access ID"})
+		return
+	}
+
+	if err := h.breakGlassService.End(userID.(uint), uint(accessID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Break-glass access ended"})
+}
+
+// GetAccesses 緊急時アクセスの履歴（管理者用、patient_idで絞り込み可）
+func (h *BreakGlassHandler) GetAccesses(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var patientID uint64
+	if patientIDStr := c.Query("patient_id"); patientIDStr != "" {
+		var err error
+		patientID, err = strconv.ParseUint(patientIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+			return
+		}
+	}
+
+	limit, offset := parseLimitOffset(c, 50, 200)
+	accesses, total, err := h.breakGlassService.GetAccesses(userID.(uint), uint(patientID), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"accesses": accesses,
+		"total":    total,
+	})
+}
+
+// SetAuthorization 医師への緊急時アクセスの許可・取り消し（管理者用）
+func (h *BreakGlassHandler) SetAuthorization(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	doctorID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid doctor ID"})
+		return
+	}
+
+	var req services.SetBreakGlassAuthorizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.breakGlassService.SetAuthorization(userID.(uint), uint(doctorID), *req.Authorized); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Break-glass authorization updated",
+		"authorized": *req.Authorized,
+	})
+}
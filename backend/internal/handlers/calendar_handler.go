@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type CalendarHandler struct {
+	calendarService *services.CalendarService
+}
+
+func NewCalendarHandler(calendarService *services.CalendarService) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+	}
+}
+
+// ExportAppointment 予約のカレンダーへの取り込み用ファイル（.ics）の取得（予約の参加者用）
+func (h *CalendarHandler) ExportAppointment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	data, err := h.calendarService.ExportAppointment(userID.(uint), uint(appointmentID))
+	if err != nil {
+		c.JSON(calendarErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("appointment-%d.ics", appointmentID)))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", data)
+}
+
+func calendarErrorStatus(err error) int {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "unauthorized"):
+		return http.StatusForbidden
+	case err.Error() == "appointment has no scheduled time":
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
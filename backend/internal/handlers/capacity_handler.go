@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type CapacityHandler struct {
+	capacityService *services.CapacityService
+}
+
+func NewCapacityHandler(capacityService *services.CapacityService) *CapacityHandler {
+	return &CapacityHandler{
+		capacityService: capacityService,
+	}
+}
+
+// SimulateCapacity 無断キャンセル率による1日の予約の受け入れ数のシミュレーション
+// （?doctor_id=&weeks=&slots_per_day=&max_overbook=&max_overflow_risk=）
+func (h *CapacityHandler) SimulateCapacity(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CapacitySimulationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	simulation, err := h.capacityService.SimulateCapacity(userID.(uint), req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.HasPrefix(err.Error(), "unauthorized") {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"simulation": simulation})
+}
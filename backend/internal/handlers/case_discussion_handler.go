@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+	"online_medical_consultation_app/backend/internal/views"
+)
+
+type CaseDiscussionHandler struct {
+	caseDiscussionService *services.CaseDiscussionService
+}
+
+func NewCaseDiscussionHandler(caseDiscussionService *services.CaseDiscussionService) *CaseDiscussionHandler {
+	return &CaseDiscussionHandler{
+		caseDiscussionService: caseDiscussionService,
+	}
+}
+
+// OpenCaseDiscussion 症例相談の開始（担当医師用）
+func (h *CaseDiscussionHandler) OpenCaseDiscussion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	var req services.OpenCaseDiscussionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	discussion, err := h.caseDiscussionService.OpenCaseDiscussion(uint(appointmentID), userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":         "Case discussion opened, awaiting patient consent",
+		"case_discussion": discussion,
+	})
+}
+
+// GetCaseDiscussions 予約の症例相談一覧の取得
+func (h *CaseDiscussionHandler) GetCaseDiscussions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	discussions, err := h.caseDiscussionService.GetCaseDiscussions(uint(appointmentID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"case_discussions": discussions})
+}
+
+// RespondConsent 症例相談への同意・拒否（患者用）
+func (h *CaseDiscussionHandler) RespondConsent(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	discussionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case discussion ID"})
+		return
+	}
+
+	var req services.CaseDiscussionConsentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	discussion, err := h.caseDiscussionService.RespondConsent(uint(appointmentID), uint(discussionID), userID.(uint), *req.Approve)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Consent recorded successfully",
+		"case_discussion": discussion,
+	})
+}
+
+// CloseCaseDiscussion 症例相談の終了（参加医師用）
+func (h *CaseDiscussionHandler) CloseCaseDiscussion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	discussionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case discussion ID"})
+		return
+	}
+
+	discussion, err := h.caseDiscussionService.CloseCaseDiscussion(uint(appointmentID), uint(discussionID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Case discussion closed successfully",
+		"case_discussion": discussion,
+	})
+}
+
+// GetMyCaseDiscussions 自分が参加する症例相談一覧の取得（医師用）
+func (h *CaseDiscussionHandler) GetMyCaseDiscussions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	discussions, err := h.caseDiscussionService.GetDoctorCaseDiscussions(userID.(uint), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch case discussions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"case_discussions": discussions})
+}
+
+// SendMessage 症例相談スレッドへのメッセージ送信
+func (h *CaseDiscussionHandler) SendMessage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	discussionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case discussion ID"})
+		return
+	}
+
+	var req services.SendMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message, err := h.caseDiscussionService.SendMessage(uint(discussionID), userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Message sent successfully",
+		"data":    views.Message(viewerFromContext(c), message),
+	})
+}
+
+// GetMessages 症例相談スレッドのメッセージ一覧の取得
+func (h *CaseDiscussionHandler) GetMessages(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	discussionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case discussion ID"})
+		return
+	}
+
+	limit, offset := parseLimitOffset(c, 50, 100)
+	messages, err := h.caseDiscussionService.GetMessages(uint(discussionID), userID.(uint), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"messages": views.Messages(viewerFromContext(c), messages)})
+}
+
+// MarkAsRead 症例相談スレッドのメッセージを既読にする
+func (h *CaseDiscussionHandler) MarkAsRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	discussionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case discussion ID"})
+		return
+	}
+
+	if err := h.caseDiscussionService.MarkMessagesAsRead(uint(discussionID), userID.(uint)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Messages marked as read"})
+}
+
+// GetUnreadCount 症例相談スレッドの未読メッセージ数の取得
+func (h *CaseDiscussionHandler) GetUnreadCount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	discussionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case discussion ID"})
+		return
+	}
+
+	count, err := h.caseDiscussionService.GetUnreadCount(uint(discussionID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread_count": count})
+}
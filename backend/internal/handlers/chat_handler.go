@@ -1,20 +1,27 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"online_medical_consultation_app/backend/internal/services"
+	"online_medical_consultation_app/backend/internal/storage"
+	"online_medical_consultation_app/backend/internal/views"
 )
 
 type ChatHandler struct {
-	chatService *services.ChatService
+	chatService        *services.ChatService
+	chatCommandService *services.ChatCommandService
 }
 
-func NewChatHandler(chatService *services.ChatService) *ChatHandler {
+func NewChatHandler(chatService *services.ChatService, chatCommandService *services.ChatCommandService) *ChatHandler {
 	return &ChatHandler{
-		chatService: chatService,
+		chatService:        chatService,
+		chatCommandService: chatCommandService,
 	}
 }
 
@@ -41,16 +48,46 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 	req.SenderUserID = userID.(uint)
 	req.AppointmentID = uint(appointmentID)
 
-	message, err := h.chatService.SendMessage(req)
+	// 医師のコマンド（/prescribe 等）は対応する操作を実行し、結果をシステムメッセージとして投稿する
+	if viewerFromContext(c).Role == "doctor" && services.IsChatCommand(req.Body) {
+		message, err := h.chatCommandService.Execute(req.AppointmentID, req.SenderUserID, req.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{
+			"message": "Command executed successfully",
+			"data":    views.Message(viewerFromContext(c), message),
+		})
+		return
+	}
+
+	message, warnings, err := h.chatService.SendMessage(req)
 	if err != nil {
+		if writeQuotaExceeded(c, err) {
+			return
+		}
+		var blocked *services.MessageBlockedError
+		if errors.As(err, &blocked) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":      err.Error(),
+				"code":       "message_blocked",
+				"violations": blocked.Hits,
+			})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	response := gin.H{
 		"message": "Message sent successfully",
-		"data":    message,
-	})
+		"data":    views.Message(viewerFromContext(c), message),
+	}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	c.JSON(http.StatusCreated, response)
 }
 
 // GetMessages メッセージ一覧の取得
@@ -88,7 +125,7 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"messages": messages})
+	c.JSON(http.StatusOK, gin.H{"messages": views.Messages(viewerFromContext(c), messages)})
 }
 
 // UploadAttachment 添付ファイルのアップロード
@@ -112,38 +149,179 @@ func (h *ChatHandler) UploadAttachment(c *gin.Context) {
 		return
 	}
 
-	// ファイルサイズのチェック（10MB制限）
-	if file.Size > 10*1024*1024 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File size must be less than 10MB"})
+	// ファイルのアップロード（形式・サイズはロールごとの制限で確認する）
+	attachment, attachmentURL, err := h.chatService.UploadAttachment(file, uint(appointmentID), userID.(uint))
+	if err != nil {
+		if writeAttachmentRejected(c, err) || writeQuotaExceeded(c, err) {
+			return
+		}
+		c.JSON(attachmentErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	// ファイル形式のチェック
-	allowedTypes := map[string]bool{
-		"image/jpeg": true,
-		"image/png":  true,
-		"image/gif":  true,
-		"application/pdf": true,
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "File uploaded successfully",
+		"url":        attachmentURL,
+		"attachment": attachment,
+	})
+}
+
+// ShareSessionFile ビデオ通話中のファイル共有（予約の記録として診療チャットに残す）
+func (h *ChatHandler) ShareSessionFile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
 	}
 
-	if !allowedTypes[file.Header.Get("Content-Type")] {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Only JPEG, PNG, GIF images and PDF files are allowed"})
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
 		return
 	}
 
-	// ファイルのアップロード
-	attachmentURL, err := h.chatService.UploadAttachment(file, uint(appointmentID), userID.(uint))
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+
+	message, warnings, err := h.chatService.ShareSessionFile(uint(appointmentID), uint(sessionID), userID.(uint), file, c.PostForm("caption"))
+	if err != nil {
+		if writeAttachmentRejected(c, err) || writeQuotaExceeded(c, err) {
+			return
+		}
+		var blocked *services.MessageBlockedError
+		if errors.As(err, &blocked) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":      err.Error(),
+				"code":       "message_blocked",
+				"violations": blocked.Hits,
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"message": "File shared successfully",
+		"data":    views.Message(viewerFromContext(c), message),
+	}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetAttachment 添付ファイルのダウンロード（予約の参加者のみ、ファイルはAPIから直接返す）
+func (h *ChatHandler) GetAttachment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, attachmentID, ok := parseAttachmentParams(c)
+	if !ok {
+		return
+	}
+
+	file, err := h.chatService.OpenAttachment(appointmentID, attachmentID, userID.(uint))
+	if err != nil {
+		c.JSON(attachmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Body.Close()
+
+	c.DataFromReader(http.StatusOK, file.Size, file.ContentType, file.Body, map[string]string{
+		"Content-Disposition":    fmt.Sprintf("inline; filename=%q", file.Filename),
+		"Cache-Control":          "private, no-store",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// GetAttachmentURL 添付ファイルの署名付きURLの発行（認証ヘッダーを付けられない <img> 等での表示用）
+func (h *ChatHandler) GetAttachmentURL(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, attachmentID, ok := parseAttachmentParams(c)
+	if !ok {
+		return
+	}
+
+	signedURL, expiresAt, err := h.chatService.GetAttachmentURL(appointmentID, attachmentID, userID.(uint))
+	if err != nil {
+		c.JSON(attachmentErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "File uploaded successfully",
-		"url":     attachmentURL,
+		"url":        signedURL,
+		"expires_at": expiresAt,
 	})
 }
 
+// GetSignedAttachment 署名付きURLによる添付ファイルのダウンロード（ローカルに保存した場合のみ、認証は署名で行う）
+func (h *ChatHandler) GetSignedAttachment(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	file, err := h.chatService.OpenSignedAttachment(key, c.Query("filename"), c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidSignature) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Body.Close()
+
+	c.DataFromReader(http.StatusOK, file.Size, file.ContentType, file.Body, map[string]string{
+		"Content-Disposition":    fmt.Sprintf("attachment; filename=%q", file.Filename),
+		"Cache-Control":          "private, no-store",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// GetSessionFiles ビデオセッション中に共有されたファイルの一覧
+func (h *ChatHandler) GetSessionFiles(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	files, err := h.chatService.GetSessionFiles(uint(appointmentID), uint(sessionID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"files": files})
+}
+
 // MarkAsRead メッセージを既読にする
 func (h *ChatHandler) MarkAsRead(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -188,3 +366,72 @@ func (h *ChatHandler) GetUnreadCount(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"unread_count": count})
 }
+
+// GetUnreadSummary 全予約の未読メッセージ数の取得（バッジ表示用）
+func (h *ChatHandler) GetUnreadSummary(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	summary, err := h.chatService.GetUnreadSummary(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":        summary.Total,
+		"appointments": summary.Appointments,
+	})
+}
+
+// parseAttachmentParams パスの予約IDと添付ファイルIDの解析（不正な場合は400を返す）
+func parseAttachmentParams(c *gin.Context) (uint, uint, bool) {
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return 0, 0, false
+	}
+	attachmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return 0, 0, false
+	}
+	return uint(appointmentID), uint(attachmentID), true
+}
+
+func attachmentErrorStatus(err error) int {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "unauthorized"):
+		return http.StatusForbidden
+	case err.Error() == "unsupported attachment type", err.Error() == "consultation is closed",
+		err.Error() == "invalid image file", err.Error() == "image is too large":
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeAttachmentRejected ロールの制限により拒否した添付ファイルのエラー（サイズの超過は413、形式は415）
+// 拒否以外のエラーの場合はfalse
+func writeAttachmentRejected(c *gin.Context, err error) bool {
+	var rejected *services.AttachmentRejectedError
+	if !errors.As(err, &rejected) {
+		return false
+	}
+	status := http.StatusUnsupportedMediaType
+	if rejected.Code == services.AttachmentCodeTooLarge {
+		status = http.StatusRequestEntityTooLarge
+	}
+	c.JSON(status, gin.H{
+		"error":          err.Error(),
+		"code":           rejected.Code,
+		"max_size_bytes": rejected.MaxSizeBytes,
+		"allowed_types":  rejected.AllowedTypes,
+	})
+	return true
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type ComplaintHandler struct {
+	complaintService *services.ComplaintService
+}
+
+func NewComplaintHandler(complaintService *services.ComplaintService) *ComplaintHandler {
+	return &ComplaintHandler{
+		complaintService: complaintService,
+	}
+}
+
+// FileComplaint 苦情の申し立て（患者用）
+func (h *ComplaintHandler) FileComplaint(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.FileComplaintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	complaint, err := h.complaintService.FileComplaint(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Complaint filed successfully",
+		"complaint": complaint,
+	})
+}
+
+// GetMyComplaints 自分の苦情一覧の取得
+func (h *ComplaintHandler) GetMyComplaints(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, offset := parseLimitOffset(c, 20, 100)
+	complaints, err := h.complaintService.GetMyComplaints(userID.(uint), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"complaints": complaints})
+}
+
+// GetComplaints 苦情一覧の取得（管理者用）
+func (h *ComplaintHandler) GetComplaints(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var assignedAdminID *uint
+	if assignedStr := c.Query("assigned_admin_id"); assignedStr != "" {
+		id, err := strconv.ParseUint(assignedStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid admin ID"})
+			return
+		}
+		adminID := uint(id)
+		assignedAdminID = &adminID
+	}
+
+	limit, offset := parseLimitOffset(c, 50, 200)
+	complaints, err := h.complaintService.GetComplaints(c.Query("status"), assignedAdminID, limit, offset, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"complaints": complaints})
+}
+
+// GetComplaint 苦情詳細の取得
+func (h *ComplaintHandler) GetComplaint(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	complaintID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid complaint ID"})
+		return
+	}
+
+	complaint, err := h.complaintService.GetComplaint(uint(complaintID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"complaint": complaint})
+}
+
+// AssignComplaint 担当管理者の割り当て（管理者用）
+func (h *ComplaintHandler) AssignComplaint(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	complaintID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid complaint ID"})
+		return
+	}
+
+	var req services.AssignComplaintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	complaint, err := h.complaintService.AssignComplaint(uint(complaintID), req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Complaint assigned successfully",
+		"complaint": complaint,
+	})
+}
+
+// UpdateComplaintStatus 苦情ステータスの更新（管理者用）
+func (h *ComplaintHandler) UpdateComplaintStatus(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	complaintID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid complaint ID"})
+		return
+	}
+
+	var req services.UpdateComplaintStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	complaint, err := h.complaintService.UpdateComplaintStatus(uint(complaintID), req, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Complaint status updated successfully",
+		"complaint": complaint,
+	})
+}
+
+// ViewChatTranscript 苦情の対象の予約の診療チャットを閲覧専用で取得（管理者用、閲覧の理由が必須）
+// 閲覧ごとに透かしを付けて監査ログに記録する。応答はキャッシュさせない
+func (h *ComplaintHandler) ViewChatTranscript(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	complaintID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid complaint ID"})
+		return
+	}
+
+	var req services.ViewChatTranscriptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	transcript, err := h.complaintService.ViewChatTranscript(uint(complaintID), userID.(uint), req)
+	if err != nil {
+		switch {
+		case err.Error() == "insufficient permissions", err.Error() == "complaint is assigned to another admin":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case strings.HasSuffix(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transcript": transcript})
+}
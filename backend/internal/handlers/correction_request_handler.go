@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type CorrectionRequestHandler struct {
+	correctionRequestService *services.CorrectionRequestService
+}
+
+func NewCorrectionRequestHandler(correctionRequestService *services.CorrectionRequestService) *CorrectionRequestHandler {
+	return &CorrectionRequestHandler{
+		correctionRequestService: correctionRequestService,
+	}
+}
+
+// CreateRequest 診療録の訂正の依頼（患者用）
+func (h *CorrectionRequestHandler) CreateRequest(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CreateCorrectionRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request, err := h.correctionRequestService.CreateRequest(userID.(uint), req)
+	if err != nil {
+		c.JSON(correctionRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"correction_request": request})
+}
+
+// GetRequests 訂正の依頼の一覧（患者は自身の依頼、医師は対応する依頼、管理者はすべて）
+func (h *CorrectionRequestHandler) GetRequests(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	page := parsePage(c, 20, 100)
+	requests, total, err := h.correctionRequestService.GetRequests(userID.(uint), c.Query("status"), page.PerPage, page.Offset)
+	if err != nil {
+		c.JSON(correctionRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse(requests, total, page))
+}
+
+// GetRequest 訂正の依頼の取得
+func (h *CorrectionRequestHandler) GetRequest(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	requestID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid correction request ID"})
+		return
+	}
+
+	request, err := h.correctionRequestService.GetRequest(userID.(uint), uint(requestID))
+	if err != nil {
+		c.JSON(correctionRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"correction_request": request})
+}
+
+// ResolveRequest 訂正の依頼の承認・却下（対応する医師用）
+func (h *CorrectionRequestHandler) ResolveRequest(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	requestID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid correction request ID"})
+		return
+	}
+
+	var req services.ResolveCorrectionRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request, err := h.correctionRequestService.ResolveRequest(userID.(uint), uint(requestID), req)
+	if err != nil {
+		c.JSON(correctionRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Correction request resolved successfully",
+		"correction_request": request,
+	})
+}
+
+// WithdrawRequest 訂正の依頼の取り下げ（依頼した患者用）
+func (h *CorrectionRequestHandler) WithdrawRequest(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	requestID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid correction request ID"})
+		return
+	}
+
+	request, err := h.correctionRequestService.WithdrawRequest(userID.(uint), uint(requestID))
+	if err != nil {
+		c.JSON(correctionRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Correction request withdrawn successfully",
+		"correction_request": request,
+	})
+}
+
+// AssignRequest 対応する医師の変更（管理者用）
+func (h *CorrectionRequestHandler) AssignRequest(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	requestID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid correction request ID"})
+		return
+	}
+
+	var req services.AssignCorrectionRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request, err := h.correctionRequestService.AssignRequest(userID.(uint), uint(requestID), req)
+	if err != nil {
+		c.JSON(correctionRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Correction request assigned successfully",
+		"correction_request": request,
+	})
+}
+
+func correctionRequestErrorStatus(err error) int {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "unauthorized"), strings.HasPrefix(err.Error(), "only patients"):
+		return http.StatusForbidden
+	case strings.HasSuffix(err.Error(), "is not open"), strings.HasSuffix(err.Error(), "already open"):
+		return http.StatusConflict
+	case strings.HasPrefix(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+	"online_medical_consultation_app/backend/internal/views"
+)
+
+type CoverageHandler struct {
+	coverageService *services.CoverageService
+}
+
+func NewCoverageHandler(coverageService *services.CoverageService) *CoverageHandler {
+	return &CoverageHandler{
+		coverageService: coverageService,
+	}
+}
+
+// CreateCoverage 代診医の指定（休診する医師用）
+func (h *CoverageHandler) CreateCoverage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CreateCoverageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	coverage, err := h.coverageService.CreateCoverage(userID.(uint), req)
+	if err != nil {
+		c.JSON(coverageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Covering doctor assigned successfully",
+		"coverage": views.Coverage(viewerFromContext(c), coverage),
+	})
+}
+
+// GetCoverages 今後の代診の一覧（休診する・代診医を務める医師用）
+func (h *CoverageHandler) GetCoverages(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	coverages, err := h.coverageService.GetCoverages(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch coverages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"coverages": views.Coverages(viewerFromContext(c), coverages)})
+}
+
+// CancelCoverage 代診の取り消し（休診する医師用）
+func (h *CoverageHandler) CancelCoverage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	coverageID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coverage ID"})
+		return
+	}
+
+	if err := h.coverageService.CancelCoverage(userID.(uint), uint(coverageID)); err != nil {
+		c.JSON(coverageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Coverage cancelled successfully"})
+}
+
+// GetMyTransfers 同意待ちの予約の引き継ぎの一覧（患者用）
+func (h *CoverageHandler) GetMyTransfers(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	transfers, err := h.coverageService.GetMyTransfers(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch appointment transfers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transfers": views.Transfers(viewerFromContext(c), transfers)})
+}
+
+// RespondTransfer 予約の引き継ぎへの同意・拒否（患者用）
+func (h *CoverageHandler) RespondTransfer(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	transferID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment transfer ID"})
+		return
+	}
+
+	var req services.TransferConsentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer, err := h.coverageService.RespondTransfer(uint(transferID), userID.(uint), *req.Approve)
+	if err != nil {
+		c.JSON(coverageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Consent recorded successfully",
+		"transfer": transfer,
+	})
+}
+
+func coverageErrorStatus(err error) int {
+	switch {
+	case strings.HasPrefix(err.Error(), "only"):
+		return http.StatusForbidden
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
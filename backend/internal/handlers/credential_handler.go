@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type CredentialHandler struct {
+	credentialService *services.CredentialService
+}
+
+func NewCredentialHandler(credentialService *services.CredentialService) *CredentialHandler {
+	return &CredentialHandler{
+		credentialService: credentialService,
+	}
+}
+
+// UploadCredential 資格証明書類のアップロード（医師用）
+func (h *CredentialHandler) UploadCredential(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.UploadCredentialRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+
+	credential, err := h.credentialService.UploadCredential(userID.(uint), req, file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Credential uploaded, awaiting review",
+		"credential": credential,
+	})
+}
+
+// GetMyCredentials 自分の資格証明書類一覧（医師用）
+func (h *CredentialHandler) GetMyCredentials(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	credentials, err := h.credentialService.GetMyCredentials(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch credentials"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credentials": credentials})
+}
+
+// GetDoctorCredentials 医師の資格証明書類一覧
+func (h *CredentialHandler) GetDoctorCredentials(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	doctorID, err := strconv.ParseUint(c.Param("doctorId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid doctor ID"})
+		return
+	}
+
+	credentials, err := h.credentialService.GetDoctorCredentials(uint(doctorID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch credentials"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credentials": credentials})
+}
+
+// UpdateVisibility 公開設定の変更（医師用）
+func (h *CredentialHandler) UpdateVisibility(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	credentialID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credential ID"})
+		return
+	}
+
+	var req services.UpdateCredentialVisibilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	credential, err := h.credentialService.UpdateVisibility(uint(credentialID), userID.(uint), *req.IsPublic)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credential": credential})
+}
+
+// DeleteCredential 資格証明書類の削除（医師用）
+func (h *CredentialHandler) DeleteCredential(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	credentialID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credential ID"})
+		return
+	}
+
+	if err := h.credentialService.DeleteCredential(uint(credentialID), userID.(uint)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Credential deleted successfully"})
+}
+
+// GetCredentialFile 資格証明書類ファイルのダウンロード
+func (h *CredentialHandler) GetCredentialFile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	credentialID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credential ID"})
+		return
+	}
+
+	credential, file, err := h.credentialService.OpenCredentialFile(uint(credentialID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, credential.FileSize, credential.ContentType, file, map[string]string{
+		"Content-Disposition": fmt.Sprintf("inline; filename=%q", credential.FileName),
+		"Cache-Control":       "private, no-store",
+	})
+}
+
+// GetCredentialsForReview 審査対象の資格証明書類一覧（管理者用）
+func (h *CredentialHandler) GetCredentialsForReview(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	status := c.DefaultQuery("status", "pending")
+	limit, offset := parseLimitOffset(c, 50, 200)
+	credentials, err := h.credentialService.GetCredentialsForReview(userID.(uint), status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credentials": credentials})
+}
+
+// ReviewCredential 資格証明書類の承認・却下（管理者用）
+func (h *CredentialHandler) ReviewCredential(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	credentialID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credential ID"})
+		return
+	}
+
+	var req services.ReviewCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	credential, err := h.credentialService.ReviewCredential(uint(credentialID), userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Credential reviewed successfully",
+		"credential": credential,
+	})
+}
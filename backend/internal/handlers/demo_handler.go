@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type DemoHandler struct {
+	demoService *services.DemoService
+}
+
+func NewDemoHandler(demoService *services.DemoService) *DemoHandler {
+	return &DemoHandler{
+		demoService: demoService,
+	}
+}
+
+// ResetDemo デモデータの即時の初期化（管理者用、デモモードでのみ有効）
+func (h *DemoHandler) ResetDemo(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	result, err := h.demoService.ResetNow(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Demo data reset successfully",
+		"result":  result,
+	})
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+	"online_medical_consultation_app/backend/internal/views"
+)
+
+type DependentHandler struct {
+	dependentService *services.DependentService
+}
+
+func NewDependentHandler(dependentService *services.DependentService) *DependentHandler {
+	return &DependentHandler{
+		dependentService: dependentService,
+	}
+}
+
+// CreateDependent 家族アカウントの登録（患者用）
+func (h *DependentHandler) CreateDependent(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.DependentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dependent, err := h.dependentService.CreateDependent(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Dependent created successfully",
+		"dependent": dependent,
+	})
+}
+
+// GetDependents 家族アカウント一覧の取得（患者用）
+func (h *DependentHandler) GetDependents(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	dependents, err := h.dependentService.GetDependents(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"dependents": dependents})
+}
+
+// UpdateDependent 家族アカウントの更新（患者用）
+func (h *DependentHandler) UpdateDependent(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	dependentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dependent ID"})
+		return
+	}
+
+	var req services.DependentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dependent, err := h.dependentService.UpdateDependent(uint(dependentID), userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Dependent updated successfully",
+		"dependent": dependent,
+	})
+}
+
+// DeleteDependent 家族アカウントの削除（患者用）
+func (h *DependentHandler) DeleteDependent(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	dependentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dependent ID"})
+		return
+	}
+
+	if err := h.dependentService.DeleteDependent(uint(dependentID), userID.(uint)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Dependent deleted successfully"})
+}
+
+// GetDependentAppointments 家族アカウントの受診履歴の取得（患者用）
+func (h *DependentHandler) GetDependentAppointments(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	dependentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dependent ID"})
+		return
+	}
+
+	page := parsePage(c, 20, 100)
+	appointments, total, err := h.dependentService.GetDependentAppointments(uint(dependentID), userID.(uint), page.PerPage, page.Offset)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse(views.Appointments(viewerFromContext(c), appointments), total, page))
+}
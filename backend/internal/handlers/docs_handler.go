@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/openapi"
+)
+
+// Swagger UI（CDNから読み込み、同じディレクトリの openapi.json を表示する）
+const swaggerUIHTML = `<!DOCTYPE html>
+<html lang="ja">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Online Medical Consultation API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+<script>
+window.onload = function () {
+  window.ui = SwaggerUIBundle({
+    url: window.location.pathname.replace(/\/$/, "") + "/openapi.json",
+    dom_id: "#swagger-ui",
+    persistAuthorization: true
+  });
+};
+</script>
+</body>
+</html>
+`
+
+type DocsHandler struct {
+	spec *openapi.Document
+}
+
+// NewDocsHandler APIドキュメントの生成（起動時に1度だけ生成する）
+func NewDocsHandler() *DocsHandler {
+	return &DocsHandler{
+		spec: openapi.Build(openapi.Info{
+			Title:       "Online Medical Consultation API",
+			Description: "オンライン診療アプリのAPI。認証が必要なAPIはログインで取得したトークンを Authorization: Bearer で指定する",
+			Version:     "1.0.0",
+		}, "/api/v1", docsTags, docsRoutes),
+	}
+}
+
+// GetUI Swagger UIの表示
+func (h *DocsHandler) GetUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIHTML))
+}
+
+// GetSpec OpenAPIのドキュメント（JSON）
+func (h *DocsHandler) GetSpec(c *gin.Context) {
+	c.JSON(http.StatusOK, h.spec)
+}
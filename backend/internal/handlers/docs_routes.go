@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/contentfilter"
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/openapi"
+	"online_medical_consultation_app/backend/internal/repositories"
+	"online_medical_consultation_app/backend/internal/services"
+	"online_medical_consultation_app/backend/internal/views"
+)
+
+// APIドキュメントのタグ（表示順）
+var docsTags = []openapi.Tag{
+	{Name: "auth", Description: "ユーザー登録・ログイン"},
+	{Name: "appointments", Description: "予約（患者・医師）"},
+	{Name: "chat", Description: "予約ごとのチャット"},
+	{Name: "prescriptions", Description: "処方"},
+	{Name: "video", Description: "ビデオ通話・録画・文字起こし"},
+	{Name: "audit", Description: "監査ログ"},
+}
+
+// 一覧の取得範囲のクエリパラメータ
+var (
+	pageQuery = []openapi.Param{
+		{Name: "page", Type: "integer", Description: "ページ番号（1始まり）"},
+		{Name: "per_page", Type: "integer", Description: "1ページの件数"},
+		{Name: "cursor", Description: "前回のレスポンスの next_cursor（page より優先する）"},
+	}
+	limitOffsetQuery = []openapi.Param{
+		{Name: "limit", Type: "integer"},
+		{Name: "offset", Type: "integer"},
+	}
+	auditLogQuery = []openapi.Param{
+		{Name: "entity", Description: "対象の種類"},
+		{Name: "entity_id", Description: "対象のID"},
+		{Name: "action", Description: "操作"},
+		{Name: "severity", Description: "重要度"},
+		{Name: "start_date", Description: "開始日（YYYY-MM-DD）"},
+		{Name: "end_date", Description: "終了日（YYYY-MM-DD）"},
+		{Name: "cursor", Description: "前回のレスポンスの next_cursor"},
+		{Name: "include_total", Type: "boolean", Description: "総件数を含める"},
+		{Name: "limit", Type: "integer"},
+		{Name: "offset", Type: "integer"},
+	}
+)
+
+// docsRoutes APIドキュメントに載せるルート（ルートを追加・変更した場合は合わせて更新する）
+var docsRoutes = []openapi.Route{
+	// 認証
+	{Method: http.MethodPost, Path: "/auth/register", Tag: "auth", Summary: "ユーザー登録", Public: true,
+		Body: services.RegisterRequest{}, Status: http.StatusCreated,
+		Response: openapi.Fields{"message": "", "user": models.User{}}},
+	{Method: http.MethodPost, Path: "/auth/login", Tag: "auth", Summary: "ログイン", Public: true,
+		Description: "退会済みのアカウントは403（code: account_deactivated）。連続して失敗したアカウントは一定時間ロックし、429（code: account_locked、locked_until・Retry-After）",
+		Body:        services.LoginRequest{}, Response: services.LoginResponse{}},
+
+	// 予約
+	{Method: http.MethodGet, Path: "/patients/appointments", Tag: "appointments", Summary: "患者の予約一覧",
+		Query: pageQuery, Response: openapi.Page{Item: views.AppointmentView{}}},
+	{Method: http.MethodPost, Path: "/patients/appointments", Tag: "appointments", Summary: "予約の作成",
+		Description: "同じ時間帯の予約がある場合は409（code: appointment_conflict / slot_unavailable）",
+		Body:        services.CreateAppointmentRequest{}, Status: http.StatusCreated,
+		Response: openapi.Fields{"message": "", "appointment": views.AppointmentView{}}},
+	{Method: http.MethodPost, Path: "/patients/appointments/instant", Tag: "appointments", Summary: "即時の診療の作成",
+		Body: services.CreateInstantAppointmentRequest{}, Status: http.StatusCreated,
+		Response: openapi.Fields{"message": "", "appointment": views.AppointmentView{}}},
+	{Method: http.MethodPost, Path: "/patients/appointments/async", Tag: "appointments", Summary: "非同期の相談の作成",
+		Body: services.CreateAsyncAppointmentRequest{}, Status: http.StatusCreated,
+		Response: openapi.Fields{"message": "", "appointment": views.AppointmentView{}}},
+	{Method: http.MethodGet, Path: "/patients/appointments/:id", Tag: "appointments", Summary: "予約の詳細",
+		Response: openapi.Fields{"appointment": views.AppointmentView{}}},
+	{Method: http.MethodPut, Path: "/patients/appointments/:id/cancel", Tag: "appointments", Summary: "予約のキャンセル",
+		Response: openapi.Fields{"message": ""}},
+	{Method: http.MethodPut, Path: "/patients/appointments/:id/triage", Tag: "appointments", Summary: "問診結果の添付",
+		Body: services.AttachTriageRequest{}, Response: openapi.Fields{"appointment": views.AppointmentView{}}},
+	{Method: http.MethodGet, Path: "/doctors/me/appointments", Tag: "appointments", Summary: "医師の予約一覧",
+		Query: append([]openapi.Param{
+			{Name: "status", Description: "ステータス"},
+			{Name: "tags", Description: "タグID（カンマ区切り）"},
+			{Name: "match", Description: "all の場合はすべてのタグを含む予約のみ", Enum: []string{"any", "all"}},
+			{Name: "view", Type: "integer", Description: "保存した絞り込み条件のID"},
+		}, pageQuery...),
+		Response: openapi.Page{Item: views.AppointmentView{}}},
+	{Method: http.MethodPut, Path: "/doctors/me/appointments/:id/status", Tag: "appointments", Summary: "予約のステータスの更新",
+		Body:     services.UpdateAppointmentStatusRequest{},
+		Response: openapi.Fields{"message": "", "appointment": views.AppointmentView{}}},
+	{Method: http.MethodPut, Path: "/doctors/me/appointments/:id/close", Tag: "appointments", Summary: "非同期の相談の終了",
+		Response: openapi.Fields{"message": "", "appointment": views.AppointmentView{}}},
+	{Method: http.MethodPost, Path: "/doctors/me/appointments/:id/delay", Tag: "appointments", Summary: "開始の遅れの連絡",
+		Body:     services.ReportDelayRequest{},
+		Response: openapi.Fields{"message": "", "appointment": views.AppointmentView{}}},
+	{Method: http.MethodGet, Path: "/doctors/me/async-consultations", Tag: "appointments", Summary: "回答待ちの非同期の相談",
+		Response: openapi.Fields{"appointments": []views.AppointmentView{}}},
+
+	// チャット
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/chat/messages", Tag: "chat", Summary: "メッセージ一覧",
+		Query: limitOffsetQuery, Response: openapi.Fields{"messages": []views.MessageView{}}},
+	{Method: http.MethodPost, Path: "/appointments/:appointmentId/chat/messages", Tag: "chat", Summary: "メッセージの送信",
+		Description: "禁止された内容を含む場合は422（code: message_blocked）",
+		Body:        services.SendMessageRequest{}, Status: http.StatusCreated,
+		Response: openapi.Fields{"message": "", "data": views.MessageView{}, "warnings": []contentfilter.Hit{}}},
+	{Method: http.MethodPost, Path: "/appointments/:appointmentId/chat/attachments", Tag: "chat", Summary: "添付ファイルのアップロード",
+		Description: "許可されていない形式は415（code: attachment_type_not_allowed）、上限を超える場合は413（code: attachment_too_large）",
+		Form:        []openapi.Param{{Name: "file", Type: "file", Required: true}},
+		Response:    openapi.Fields{"message": "", "url": "", "attachment": models.Attachment{}}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/chat/attachments/:id", Tag: "chat", Summary: "添付ファイルのダウンロード",
+		Produces: "application/octet-stream"},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/chat/attachments/:id/url", Tag: "chat", Summary: "添付ファイルの署名付きURL",
+		Response: openapi.Fields{"url": "", "expires_at": time.Time{}}},
+	{Method: http.MethodPut, Path: "/appointments/:appointmentId/chat/read", Tag: "chat", Summary: "既読にする",
+		Response: openapi.Fields{"message": ""}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/chat/unread-count", Tag: "chat", Summary: "未読の件数",
+		Response: openapi.Fields{"unread_count": int64(0)}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/chat/presence", Tag: "chat", Summary: "参加者のオンライン状態",
+		Response: openapi.Fields{"participants": []services.UserPresence{}}},
+	{Method: http.MethodPost, Path: "/appointments/:appointmentId/chat/messages/:messageId/flag", Tag: "chat", Summary: "メッセージの通報",
+		Body: services.FlagMessageRequest{}, Status: http.StatusCreated,
+		Response: openapi.Fields{"message": "", "flag": models.MessageFlag{}}},
+	{Method: http.MethodGet, Path: "/chat/unread-summary", Tag: "chat", Summary: "予約ごとの未読の件数",
+		Response: openapi.Fields{"total": services.UnreadSummary{}.Total, "appointments": services.UnreadSummary{}.Appointments}},
+
+	// 処方
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/prescriptions", Tag: "prescriptions", Summary: "処方一覧",
+		Query: pageQuery,
+		Response: openapi.Page{Item: views.PrescriptionView{},
+			Extra: openapi.Fields{"cost_estimates": []services.PrescriptionCostEstimate{}}}},
+	{Method: http.MethodPost, Path: "/appointments/:appointmentId/prescriptions", Tag: "prescriptions", Summary: "処方の作成",
+		Body: services.CreatePrescriptionRequest{}, Status: http.StatusCreated,
+		Response: openapi.Fields{"message": "", "prescription": views.PrescriptionView{}}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/prescriptions/:id", Tag: "prescriptions", Summary: "処方の詳細",
+		Response: openapi.Fields{"prescription": views.PrescriptionView{}, "cost_estimate": services.PrescriptionCostEstimate{}}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/prescriptions/:id/pdf", Tag: "prescriptions", Summary: "処方箋のPDF",
+		Produces: "application/pdf"},
+	{Method: http.MethodPut, Path: "/appointments/:appointmentId/prescriptions/:id", Tag: "prescriptions", Summary: "処方の更新",
+		Body:     services.UpdatePrescriptionRequest{},
+		Response: openapi.Fields{"message": "", "prescription": views.PrescriptionView{}}},
+	{Method: http.MethodDelete, Path: "/appointments/:appointmentId/prescriptions/:id", Tag: "prescriptions", Summary: "処方の削除",
+		Response: openapi.Fields{"message": ""}},
+	{Method: http.MethodGet, Path: "/prescriptions/verify/:code", Tag: "prescriptions", Summary: "処方箋のQRコードによる照合", Public: true,
+		Response: openapi.Fields{"verification": services.PrescriptionVerification{}}},
+	{Method: http.MethodGet, Path: "/patients/prescriptions/unacknowledged", Tag: "prescriptions", Summary: "未確認の処方一覧",
+		Response: openapi.Fields{"prescriptions": []views.PrescriptionView{}, "cost_estimates": []services.PrescriptionCostEstimate{}}},
+	{Method: http.MethodPut, Path: "/patients/prescriptions/:id/ack", Tag: "prescriptions", Summary: "処方の確認",
+		Response: openapi.Fields{"message": "", "prescription": views.PrescriptionView{}, "cost_estimate": services.PrescriptionCostEstimate{}}},
+
+	// ビデオ通話
+	{Method: http.MethodPost, Path: "/appointments/:appointmentId/video/sessions", Tag: "video", Summary: "ビデオ通話の作成",
+		Body: services.CreateVideoSessionRequest{}, Status: http.StatusCreated,
+		Response: openapi.Fields{"message": "", "session": models.VideoSession{}}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/video/sessions", Tag: "video", Summary: "予約のビデオ通話一覧",
+		Response: openapi.Fields{"sessions": []models.VideoSession{}}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/video/sessions/:sessionId", Tag: "video", Summary: "ビデオ通話の詳細",
+		Response: openapi.Fields{"session": models.VideoSession{}}},
+	{Method: http.MethodPost, Path: "/appointments/:appointmentId/video/sessions/:sessionId/join", Tag: "video", Summary: "ビデオ通話への参加",
+		Response: openapi.Fields{"session": models.VideoSession{}, "signaling_info": services.SignalingInfo{}}},
+	{Method: http.MethodPut, Path: "/appointments/:appointmentId/video/sessions/:sessionId/start", Tag: "video", Summary: "ビデオ通話の開始",
+		Response: openapi.Fields{"message": ""}},
+	{Method: http.MethodPut, Path: "/appointments/:appointmentId/video/sessions/:sessionId/end", Tag: "video", Summary: "ビデオ通話の終了",
+		Response: openapi.Fields{"message": ""}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/video/sessions/:sessionId/offer", Tag: "video", Summary: "WebRTCのオファーの取得",
+		Response: openapi.Fields{"offer": services.WebRTCOffer{}}},
+	{Method: http.MethodPost, Path: "/appointments/:appointmentId/video/sessions/:sessionId/answer", Tag: "video", Summary: "WebRTCのアンサーの送信",
+		Body: services.WebRTCAnswerRequest{}, Response: openapi.Fields{"message": ""}},
+	{Method: http.MethodPost, Path: "/appointments/:appointmentId/video/sessions/:sessionId/token/refresh", Tag: "video", Summary: "シグナリングのトークンの更新",
+		Response: openapi.Fields{"signaling_info": services.SignalingInfo{}}},
+	{Method: http.MethodPost, Path: "/appointments/:appointmentId/video/sessions/:sessionId/candidates", Tag: "video", Summary: "ICE候補の送信",
+		Body: services.ICECandidateRequest{}, Status: http.StatusCreated,
+		Response: openapi.Fields{"message": "", "queued": 0}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/video/sessions/:sessionId/candidates", Tag: "video", Summary: "相手のICE候補の取得",
+		Query:    []openapi.Param{{Name: "ack", Type: "integer", Description: "受信済みの最後の候補のID（これ以前の候補は削除する）"}},
+		Response: openapi.Fields{"candidates": []services.QueuedICECandidate{}}},
+	{Method: http.MethodPost, Path: "/appointments/:appointmentId/video/sessions/:sessionId/accept", Tag: "video", Summary: "着信の応答",
+		Response: openapi.Fields{"participant": models.VideoParticipant{}}},
+	{Method: http.MethodPost, Path: "/appointments/:appointmentId/video/sessions/:sessionId/decline", Tag: "video", Summary: "着信の拒否",
+		Response: openapi.Fields{"participant": models.VideoParticipant{}}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/video/sessions/:sessionId/participants", Tag: "video", Summary: "通話の参加者",
+		Response: openapi.Fields{"participants": []models.VideoParticipant{}}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/video/sessions/:sessionId/signaling", Tag: "video", Summary: "シグナリングのWebSocket接続",
+		Status: http.StatusSwitchingProtocols},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/video/sessions/:sessionId/peers", Tag: "video", Summary: "シグナリングに接続中の参加者",
+		Response: openapi.Fields{"peers": []services.SignalingPeer{}}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/video/sessions/:sessionId/files", Tag: "video", Summary: "通話中に共有したファイル",
+		Response: openapi.Fields{"files": []views.MessageView{}}},
+	{Method: http.MethodPost, Path: "/appointments/:appointmentId/video/sessions/:sessionId/files", Tag: "video", Summary: "通話中のファイルの共有",
+		Form: []openapi.Param{
+			{Name: "file", Type: "file", Required: true},
+			{Name: "caption", Description: "ファイルの説明"},
+		},
+		Status:   http.StatusCreated,
+		Response: openapi.Fields{"message": "", "data": views.MessageView{}, "warnings": []contentfilter.Hit{}}},
+	{Method: http.MethodPut, Path: "/appointments/:appointmentId/video/sessions/:sessionId/recording-consent", Tag: "video", Summary: "録画への同意",
+		Body: services.RecordingConsentRequest{}, Response: openapi.Fields{"session": models.VideoSession{}}},
+	{Method: http.MethodPost, Path: "/appointments/:appointmentId/video/sessions/:sessionId/recordings", Tag: "video", Summary: "録画の開始",
+		Status: http.StatusCreated, Response: openapi.Fields{"recording": models.Recording{}}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/video/sessions/:sessionId/recordings", Tag: "video", Summary: "録画一覧",
+		Response: openapi.Fields{"recordings": []models.Recording{}}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/video/sessions/:sessionId/recordings/:recordingId", Tag: "video", Summary: "録画の詳細",
+		Response: openapi.Fields{"recording": models.Recording{}}},
+	{Method: http.MethodPut, Path: "/appointments/:appointmentId/video/sessions/:sessionId/recordings/:recordingId/stop", Tag: "video", Summary: "録画の停止",
+		Response: openapi.Fields{"recording": models.Recording{}}},
+	{Method: http.MethodPost, Path: "/appointments/:appointmentId/video/sessions/:sessionId/recordings/:recordingId/file", Tag: "video", Summary: "録画ファイルのアップロード",
+		Form:     []openapi.Param{{Name: "file", Type: "file", Required: true}},
+		Response: openapi.Fields{"message": "", "recording": models.Recording{}}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/video/sessions/:sessionId/recordings/:recordingId/file", Tag: "video", Summary: "録画ファイルのダウンロード",
+		Produces: "application/octet-stream"},
+	{Method: http.MethodPost, Path: "/appointments/:appointmentId/video/sessions/:sessionId/recording", Tag: "video", Summary: "文字起こし用の音声のアップロード",
+		Form:   []openapi.Param{{Name: "file", Type: "file", Required: true}},
+		Status: http.StatusAccepted, Response: openapi.Fields{"message": "", "transcript": models.Transcript{}}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/video/sessions/:sessionId/transcript", Tag: "video", Summary: "文字起こし",
+		Query:    []openapi.Param{{Name: "q", Description: "検索語（一致する発言のみ返す）"}},
+		Response: openapi.Fields{"transcript": models.Transcript{}}},
+	{Method: http.MethodGet, Path: "/appointments/:appointmentId/video/sessions/:sessionId/transcript/export", Tag: "video", Summary: "文字起こしのダウンロード",
+		Query:    []openapi.Param{{Name: "format", Enum: []string{"txt", "pdf"}}},
+		Produces: "application/octet-stream"},
+	{Method: http.MethodGet, Path: "/transcripts/search", Tag: "video", Summary: "文字起こしの検索",
+		Query:    append([]openapi.Param{{Name: "q", Description: "検索語", Required: true}}, limitOffsetQuery...),
+		Response: openapi.Fields{"results": []repositories.TranscriptSearchHit{}}},
+
+	// 監査ログ
+	{Method: http.MethodGet, Path: "/audit/logs", Tag: "audit", Summary: "監査ログの検索（管理者）",
+		Query: auditLogQuery, Response: repositories.AuditLogPage{}},
+	{Method: http.MethodGet, Path: "/audit/users/:userId/logs", Tag: "audit", Summary: "利用者の監査ログ",
+		Query: auditLogQuery, Response: repositories.AuditLogPage{}},
+	{Method: http.MethodGet, Path: "/audit/entities/:entity/:entityId/logs", Tag: "audit", Summary: "対象ごとの監査ログ（管理者）",
+		PathParams: []openapi.Param{{Name: "entityId", Description: "対象のID"}},
+		Query:      auditLogQuery, Response: repositories.AuditLogPage{}},
+	{Method: http.MethodGet, Path: "/audit/export", Tag: "audit", Summary: "監査ログのエクスポート（管理者）",
+		Query: []openapi.Param{
+			{Name: "format", Description: "ファイル形式（デフォルトは csv）"},
+			{Name: "mode", Description: "pseudonymized の場合は利用者IDを仮名化する", Enum: []string{"pseudonymized"}},
+			{Name: "entity"}, {Name: "entity_id"}, {Name: "action"}, {Name: "severity"},
+			{Name: "start_date"}, {Name: "end_date"},
+			{Name: "limit", Type: "integer"}, {Name: "offset", Type: "integer"},
+		},
+		Produces: "application/octet-stream"},
+	{Method: http.MethodGet, Path: "/audit/archives", Tag: "audit", Summary: "アーカイブ一覧（管理者）",
+		Query: limitOffsetQuery, Response: openapi.Fields{"archives": []models.AuditArchive{}}},
+	{Method: http.MethodGet, Path: "/audit/archives/search", Tag: "audit", Summary: "アーカイブした監査ログの検索（管理者）",
+		Query: []openapi.Param{
+			{Name: "user_id", Type: "integer"}, {Name: "patient_id", Type: "integer"},
+			{Name: "entity"}, {Name: "entity_id"}, {Name: "action"},
+			{Name: "start_date"}, {Name: "end_date"},
+			{Name: "limit", Type: "integer"}, {Name: "offset", Type: "integer"},
+		},
+		Response: openapi.Fields{"entries": []models.AuditArchiveEntry{}}},
+	{Method: http.MethodPost, Path: "/audit/archives/:id/rehydrate", Tag: "audit", Summary: "アーカイブの一時的な復元（管理者）",
+		Response: openapi.Fields{"message": "", "archive": models.AuditArchive{}}},
+	{Method: http.MethodGet, Path: "/patients/me/access-log", Tag: "audit", Summary: "自分のカルテ等へのアクセス履歴（患者）",
+		Query: limitOffsetQuery, Response: openapi.Fields{"access_log": []models.AuditLog{}}},
+}
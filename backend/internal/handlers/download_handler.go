@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type DownloadHandler struct {
+	downloadService *services.DownloadService
+}
+
+func NewDownloadHandler(downloadService *services.DownloadService) *DownloadHandler {
+	return &DownloadHandler{
+		downloadService: downloadService,
+	}
+}
+
+// ResumeDownload エクスポートの再取得（X-Download-Token のトークン、Range指定で途中から取得できる）
+// 保存先が署名付きURLを発行できる場合はそのURLにリダイレクトする
+func (h *DownloadHandler) ResumeDownload(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	exportFile, err := h.downloadService.Open(c.Param("token"), userID.(uint))
+	if err != nil {
+		switch err.Error() {
+		case "download not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "download is not ready":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.Header("Cache-Control", "private, no-store")
+	if exportFile.URL != "" {
+		c.Redirect(http.StatusFound, exportFile.URL)
+		return
+	}
+	defer exportFile.Body.Close()
+
+	download := exportFile.Download
+	c.Header("Content-Type", download.ContentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", download.Filename))
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	if file, ok := exportFile.Body.(*os.File); ok {
+		serveFile(c, h.downloadService, download.Filename, *download.CompletedAt, file)
+		return
+	}
+
+	c.Header("Content-Length", strconv.FormatInt(download.SizeBytes, 10))
+	c.Status(http.StatusOK)
+	writer := newThrottledWriter(c.Request.Context(), c.Writer, h.downloadService.BandwidthLimit())
+	if _, err := io.Copy(writer, exportFile.Body); err != nil {
+		log.Printf("Failed to send export download %d: %v", download.ID, err)
+	}
+}
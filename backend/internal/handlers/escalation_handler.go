@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type EscalationHandler struct {
+	escalationService *services.EscalationService
+}
+
+func NewEscalationHandler(escalationService *services.EscalationService) *EscalationHandler {
+	return &EscalationHandler{
+		escalationService: escalationService,
+	}
+}
+
+// RaiseEscalation 緊急エスカレーションの発報（患者用）
+func (h *EscalationHandler) RaiseEscalation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	var req services.RaiseEscalationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	escalation, err := h.escalationService.RaiseEscalation(uint(appointmentID), userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Escalation raised successfully",
+		"escalation": escalation,
+	})
+}
+
+// GetEscalations エスカレーション一覧の取得
+func (h *EscalationHandler) GetEscalations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	escalations, err := h.escalationService.GetEscalations(uint(appointmentID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"escalations": escalations})
+}
+
+// AcknowledgeEscalation エスカレーションの確認（医師・管理者用）
+func (h *EscalationHandler) AcknowledgeEscalation(c *gin.Context) {
+	h.respond(c, h.escalationService.AcknowledgeEscalation, "Escalation acknowledged successfully")
+}
+
+// ResolveEscalation エスカレーションの解決（医師・管理者用）
+func (h *EscalationHandler) ResolveEscalation(c *gin.Context) {
+	h.respond(c, h.escalationService.ResolveEscalation, "Escalation resolved successfully")
+}
+
+// respond 確認・解決の共通処理
+func (h *EscalationHandler) respond(c *gin.Context, action func(appointmentID, escalationID, userID uint) (*models.Escalation, error), message string) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	escalationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid escalation ID"})
+		return
+	}
+
+	escalation, err := action(uint(appointmentID), uint(escalationID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    message,
+		"escalation": escalation,
+	})
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type HealthHandler struct {
+	healthService *services.HealthService
+}
+
+func NewHealthHandler(healthService *services.HealthService) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+// Healthz 死活監視（データベースに接続できない場合は503）
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	writeHealthReport(c, h.healthService.Liveness(c.Request.Context()))
+}
+
+// Readyz 準備状況の確認（未適用のマイグレーションがある、保存先を利用できない場合などは503）
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	writeHealthReport(c, h.healthService.Readiness(c.Request.Context()))
+}
+
+func writeHealthReport(c *gin.Context, report *services.HealthReport) {
+	c.Header("Cache-Control", "no-store")
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type HL7Handler struct {
+	hl7Service *services.HL7Service
+}
+
+func NewHL7Handler(hl7Service *services.HL7Service) *HL7Handler {
+	return &HL7Handler{
+		hl7Service: hl7Service,
+	}
+}
+
+// GetDestinations HL7v2の連携先の一覧（管理者用）
+func (h *HL7Handler) GetDestinations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	destinations, err := h.hl7Service.GetDestinations(userID.(uint))
+	if err != nil {
+		c.JSON(hl7ErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"destinations": destinations})
+}
+
+// CreateDestination HL7v2の連携先の登録（管理者用）
+func (h *HL7Handler) CreateDestination(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.HL7DestinationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	destination, err := h.hl7Service.CreateDestination(userID.(uint), req)
+	if err != nil {
+		c.JSON(hl7ErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "HL7 destination created successfully",
+		"destination": destination,
+	})
+}
+
+// UpdateDestination HL7v2の連携先の更新（管理者用、send_fromの指定で送信し直す）
+func (h *HL7Handler) UpdateDestination(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	destinationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid destination ID"})
+		return
+	}
+
+	var req services.HL7DestinationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	destination, err := h.hl7Service.UpdateDestination(userID.(uint), uint(destinationID), req)
+	if err != nil {
+		c.JSON(hl7ErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "HL7 destination updated successfully",
+		"destination": destination,
+	})
+}
+
+// DeleteDestination HL7v2の連携先の削除（管理者用）
+func (h *HL7Handler) DeleteDestination(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	destinationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid destination ID"})
+		return
+	}
+
+	if err := h.hl7Service.DeleteDestination(userID.(uint), uint(destinationID)); err != nil {
+		c.JSON(hl7ErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "HL7 destination deleted successfully"})
+}
+
+// GetMessages HL7v2の連携先への送信履歴（管理者用）
+func (h *HL7Handler) GetMessages(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	destinationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid destination ID"})
+		return
+	}
+
+	limit, offset := parseLimitOffset(c, 50, 200)
+	messages, total, err := h.hl7Service.GetMessages(userID.(uint), uint(destinationID), limit, offset)
+	if err != nil {
+		c.JSON(hl7ErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"messages": messages,
+		"total":    total,
+	})
+}
+
+func hl7ErrorStatus(err error) int {
+	switch {
+	case strings.HasPrefix(err.Error(), "unauthorized"):
+		return http.StatusForbidden
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
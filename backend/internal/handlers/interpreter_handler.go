@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+	"online_medical_consultation_app/backend/internal/views"
+)
+
+type InterpreterHandler struct {
+	interpreterService *services.InterpreterService
+}
+
+func NewInterpreterHandler(interpreterService *services.InterpreterService) *InterpreterHandler {
+	return &InterpreterHandler{
+		interpreterService: interpreterService,
+	}
+}
+
+// GetInterpreters 通訳者一覧の取得（?language=en で絞り込み）
+func (h *InterpreterHandler) GetInterpreters(c *gin.Context) {
+	interpreters, err := h.interpreterService.GetInterpreters(c.Query("language"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"interpreters": interpreters})
+}
+
+// GetProfile 通訳者プロフィールの取得
+func (h *InterpreterHandler) GetProfile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	profile, err := h.interpreterService.GetProfile(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+// UpdateProfile 通訳者プロフィールの更新
+func (h *InterpreterHandler) UpdateProfile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.InterpreterProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := h.interpreterService.UpdateProfile(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Profile updated successfully", "profile": profile})
+}
+
+// GetSlots 対応可能枠一覧の取得
+func (h *InterpreterHandler) GetSlots(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	slots, err := h.interpreterService.GetSlots(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"slots": slots})
+}
+
+// CreateSlot 対応可能枠の作成
+func (h *InterpreterHandler) CreateSlot(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CreateInterpreterSlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slot, err := h.interpreterService.CreateSlot(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Slot created successfully", "slot": slot})
+}
+
+// DeleteSlot 対応可能枠の削除
+func (h *InterpreterHandler) DeleteSlot(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	slotID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid slot ID"})
+		return
+	}
+
+	if err := h.interpreterService.DeleteSlot(uint(slotID), userID.(uint)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Slot deleted successfully"})
+}
+
+// GetAppointments 割り当てられた予約一覧の取得
+func (h *InterpreterHandler) GetAppointments(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	page := parsePage(c, 20, 100)
+	appointments, total, err := h.interpreterService.GetAssignedAppointments(userID.(uint), page.PerPage, page.Offset)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse(views.Appointments(viewerFromContext(c), appointments), total, page))
+}
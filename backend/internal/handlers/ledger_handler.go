@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type LedgerHandler struct {
+	ledgerService *services.LedgerService
+}
+
+func NewLedgerHandler(ledgerService *services.LedgerService) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerService: ledgerService,
+	}
+}
+
+// GetAccounts 勘定の一覧と残高（管理者用、?type=asset|liability|equity|revenue|expense）
+func (h *LedgerHandler) GetAccounts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	accounts, err := h.ledgerService.GetAccounts(userID.(uint), c.Query("type"))
+	if err != nil {
+		c.JSON(ledgerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accounts": accounts})
+}
+
+// GetEntries 仕訳の一覧（管理者用、?kind=payment|refund|wallet_credit|payout&account_id=）
+func (h *LedgerHandler) GetEntries(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var accountID *uint
+	if accountIDStr := c.Query("account_id"); accountIDStr != "" {
+		id, err := strconv.ParseUint(accountIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+			return
+		}
+		value := uint(id)
+		accountID = &value
+	}
+
+	page := parsePage(c, 50, 200)
+	entries, total, err := h.ledgerService.GetEntries(userID.(uint), c.Query("kind"), accountID, page.PerPage, page.Offset)
+	if err != nil {
+		c.JSON(ledgerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse(entries, total, page))
+}
+
+// GetReport 元帳の整合性の確認（管理者用、試算表と不整合な仕訳・勘定）
+func (h *LedgerHandler) GetReport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	report, err := h.ledgerService.GetReport(userID.(uint))
+	if err != nil {
+		c.JSON(ledgerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// RecordRefund 返金の記録（管理者用）
+func (h *LedgerHandler) RecordRefund(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.RecordRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.ledgerService.RecordRefund(userID.(uint), req)
+	if err != nil {
+		c.JSON(ledgerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"entry": entry})
+}
+
+// RecordWalletCredit 患者のウォレットへの付与（管理者用）
+func (h *LedgerHandler) RecordWalletCredit(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.RecordWalletCreditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.ledgerService.RecordWalletCredit(userID.(uint), req)
+	if err != nil {
+		c.JSON(ledgerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"entry": entry})
+}
+
+// RecordPayout 医師への支払いの記録（管理者用）
+func (h *LedgerHandler) RecordPayout(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.RecordPayoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.ledgerService.RecordPayout(userID.(uint), req)
+	if err != nil {
+		c.JSON(ledgerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"entry": entry})
+}
+
+func ledgerErrorStatus(err error) int {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "unauthorized"):
+		return http.StatusForbidden
+	case err.Error() == "journal entry has already been recorded", err.Error() == "insufficient balance":
+		return http.StatusConflict
+	case strings.HasPrefix(err.Error(), "invalid"), strings.HasPrefix(err.Error(), "refund exceeds"),
+		err.Error() == "amount must be positive", err.Error() == "reference is required":
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
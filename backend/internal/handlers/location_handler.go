@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type LocationHandler struct {
+	locationService *services.LocationService
+}
+
+func NewLocationHandler(locationService *services.LocationService) *LocationHandler {
+	return &LocationHandler{
+		locationService: locationService,
+	}
+}
+
+// GetLocations 対面診療を予約できる所在地の一覧（doctor_idを指定するとその医師の所在地のみ）
+func (h *LocationHandler) GetLocations(c *gin.Context) {
+	var doctorID uint
+	if value := c.Query("doctor_id"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid doctor ID"})
+			return
+		}
+		doctorID = uint(parsed)
+	}
+
+	locations, err := h.locationService.GetLocations(doctorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch locations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"locations": locations})
+}
+
+// GetAllLocations 停止中を含む所在地の一覧（管理者用）
+func (h *LocationHandler) GetAllLocations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	locations, err := h.locationService.GetAllLocations(userID.(uint))
+	if err != nil {
+		c.JSON(locationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"locations": locations})
+}
+
+// CreateLocation 所在地の登録（管理者用）
+func (h *LocationHandler) CreateLocation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.SaveLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	location, err := h.locationService.CreateLocation(userID.(uint), req)
+	if err != nil {
+		c.JSON(locationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"location": location})
+}
+
+// UpdateLocation 所在地の更新・停止（管理者用）
+func (h *LocationHandler) UpdateLocation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	locationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		return
+	}
+
+	var req services.SaveLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	location, err := h.locationService.UpdateLocation(userID.(uint), uint(locationID), req)
+	if err != nil {
+		c.JSON(locationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Location updated successfully",
+		"location": location,
+	})
+}
+
+// GetLocationDoctors 所在地で対面診療を行う医師の一覧（管理者用）
+func (h *LocationHandler) GetLocationDoctors(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	locationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		return
+	}
+
+	doctorIDs, err := h.locationService.GetLocationDoctors(userID.(uint), uint(locationID))
+	if err != nil {
+		c.JSON(locationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"doctor_ids": doctorIDs})
+}
+
+// SetLocationDoctors 所在地で対面診療を行う医師の設定（管理者用）
+func (h *LocationHandler) SetLocationDoctors(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	locationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		return
+	}
+
+	var req services.SetLocationDoctorsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	doctorIDs, err := h.locationService.SetLocationDoctors(userID.(uint), uint(locationID), req)
+	if err != nil {
+		c.JSON(locationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Location doctors updated successfully",
+		"doctor_ids": doctorIDs,
+	})
+}
+
+func locationErrorStatus(err error) int {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "unauthorized"):
+		return http.StatusForbidden
+	case strings.HasPrefix(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type MedicalRecordHandler struct {
+	medicalRecordService *services.MedicalRecordService
+}
+
+func NewMedicalRecordHandler(medicalRecordService *services.MedicalRecordService) *MedicalRecordHandler {
+	return &MedicalRecordHandler{
+		medicalRecordService: medicalRecordService,
+	}
+}
+
+// SaveRecord 予約の診療録の記載・更新（担当医師用、診察中・診察後）
+func (h *MedicalRecordHandler) SaveRecord(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	var req services.SaveMedicalRecordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	record, err := h.medicalRecordService.SaveRecord(uint(appointmentID), userID.(uint), req)
+	if err != nil {
+		c.JSON(medicalRecordErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Medical record saved successfully",
+		"record":  record,
+	})
+}
+
+// GetRecord 予約の診療録の取得（担当医師・患者用）
+func (h *MedicalRecordHandler) GetRecord(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	record, err := h.medicalRecordService.GetRecord(uint(appointmentID), userID.(uint))
+	if err != nil {
+		c.JSON(medicalRecordErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"record": record})
+}
+
+// GetAppointmentHistory 予約の患者の過去の診療録の一覧（担当医師・患者用）
+func (h *MedicalRecordHandler) GetAppointmentHistory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	page := parsePage(c, 50, 200)
+	records, total, err := h.medicalRecordService.GetAppointmentHistory(uint(appointmentID), userID.(uint), page.PerPage, page.Offset)
+	if err != nil {
+		c.JSON(medicalRecordErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse(records, total, page))
+}
+
+// GetMyRecords 自分の診療録の履歴（患者用、?dependent_id= で家族の分）
+func (h *MedicalRecordHandler) GetMyRecords(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var dependentID *uint
+	if dependentIDStr := c.Query("dependent_id"); dependentIDStr != "" {
+		id, err := strconv.ParseUint(dependentIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dependent ID"})
+			return
+		}
+		value := uint(id)
+		dependentID = &value
+	}
+
+	page := parsePage(c, 50, 200)
+	records, total, err := h.medicalRecordService.GetMyRecords(userID.(uint), dependentID, page.PerPage, page.Offset)
+	if err != nil {
+		c.JSON(medicalRecordErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse(records, total, page))
+}
+
+func medicalRecordErrorStatus(err error) int {
+	switch {
+	case strings.HasPrefix(err.Error(), "unauthorized"):
+		return http.StatusForbidden
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type OnboardingHandler struct {
+	onboardingService *services.OnboardingService
+}
+
+func NewOnboardingHandler(onboardingService *services.OnboardingService) *OnboardingHandler {
+	return &OnboardingHandler{
+		onboardingService: onboardingService,
+	}
+}
+
+// GetCompleteness プロフィール完成度の取得（患者用）
+func (h *OnboardingHandler) GetCompleteness(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	completeness, err := h.onboardingService.GetCompleteness(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"completeness": completeness})
+}
+
+// GetOnboarding オンボーディングの進行状態の取得（患者用）
+func (h *OnboardingHandler) GetOnboarding(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	state, err := h.onboardingService.GetOnboarding(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"onboarding": state})
+}
+
+// SubmitStep オンボーディングのステップの送信（患者用）
+func (h *OnboardingHandler) SubmitStep(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.OnboardingStepRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	state, err := h.onboardingService.SubmitStep(userID.(uint), c.Param("step"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"onboarding": state})
+}
+
+// SkipStep オンボーディングのステップのスキップ（患者用）
+func (h *OnboardingHandler) SkipStep(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	state, err := h.onboardingService.SkipStep(userID.(uint), c.Param("step"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"onboarding": state})
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+	"online_medical_consultation_app/backend/internal/views"
+)
+
+type OrganizationHandler struct {
+	organizationService *services.OrganizationService
+}
+
+func NewOrganizationHandler(organizationService *services.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationService: organizationService,
+	}
+}
+
+// CreateOrganization 組織の作成（管理者用）
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	organization, err := h.organizationService.CreateOrganization(userID.(uint), req)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"organization": organization})
+}
+
+// GetOrganizations 組織の一覧（管理者はすべて、それ以外は所属する組織）
+func (h *OrganizationHandler) GetOrganizations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	organizations, err := h.organizationService.GetOrganizations(userID.(uint))
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"organizations": organizations})
+}
+
+// GetMembers 組織に所属する医師・スタッフと委任されたロールの一覧（組織の管理者用）
+func (h *OrganizationHandler) GetMembers(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	organizationID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.organizationService.GetMembers(userID.(uint), organizationID)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// AddMember 組織への所属の追加（組織の管理者はスタッフのみ追加できる）
+func (h *OrganizationHandler) AddMember(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	organizationID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	var req services.AddOrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.organizationService.AddMember(userID.(uint), organizationID, req)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"member": member})
+}
+
+// RemoveMember 組織からの所属の解除（委任したロールも取り消す）
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	organizationID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.organizationService.RemoveMember(userID.(uint), organizationID, uint(memberID)); err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// SetMemberRoles スタッフに委任するロールの設定（組織の管理者用）
+func (h *OrganizationHandler) SetMemberRoles(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	organizationID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req services.SetOrganizationRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	roles, err := h.organizationService.SetMemberRoles(userID.(uint), organizationID, uint(memberID), req)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Roles updated successfully",
+		"roles":   roles,
+	})
+}
+
+// GetAppointments 組織に所属する医師の予約の一覧（予約の管理を委任されたスタッフ用）
+func (h *OrganizationHandler) GetAppointments(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	organizationID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	page := parsePage(c, 20, 100)
+	appointments, total, err := h.organizationService.GetAppointments(userID.(uint), organizationID, c.Query("status"), page.PerPage, page.Offset)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse(views.Appointments(viewerFromContext(c), appointments), total, page))
+}
+
+// CancelAppointment 組織に所属する医師の予約のキャンセル（予約の管理を委任されたスタッフ用）
+func (h *OrganizationHandler) CancelAppointment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	organizationID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+	appointmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	appointment, err := h.organizationService.CancelAppointment(userID.(uint), organizationID, uint(appointmentID))
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Appointment cancelled successfully",
+		"appointment": views.Appointment(viewerFromContext(c), appointment),
+	})
+}
+
+// GetInvoices 組織に所属する医師の予約の請求の一覧（請求の閲覧を委任されたスタッフ用）
+func (h *OrganizationHandler) GetInvoices(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	organizationID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	page := parsePage(c, 50, 200)
+	invoices, total, err := h.organizationService.GetInvoices(userID.(uint), organizationID, c.Query("status"), page.PerPage, page.Offset)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse(invoices, total, page))
+}
+
+// GetAuditLogs 組織に所属するメンバーの操作の監査ログ（監査ログの閲覧を委任されたスタッフ用）
+func (h *OrganizationHandler) GetAuditLogs(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	organizationID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	filter := parseAuditPageParams(c, 100, 1000)
+	filter.Entity = c.Query("entity")
+	filter.EntityID = c.Query("entity_id")
+	filter.Action = c.Query("action")
+	filter.StartDate = c.Query("start_date")
+	filter.EndDate = c.Query("end_date")
+
+	page, err := h.organizationService.GetAuditLogs(userID.(uint), organizationID, filter)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// parseOrganizationID パスの組織IDの取得（不正な場合は400を返してfalse）
+func parseOrganizationID(c *gin.Context) (uint, bool) {
+	organizationID, err := strconv.ParseUint(c.Param("orgId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return 0, false
+	}
+	return uint(organizationID), true
+}
+
+func organizationErrorStatus(err error) int {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "unauthorized"):
+		return http.StatusForbidden
+	case strings.HasPrefix(err.Error(), "user is already"), err.Error() == "appointment cannot be cancelled":
+		return http.StatusConflict
+	case strings.HasPrefix(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
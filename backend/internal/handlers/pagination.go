@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 指定できるページ番号の上限（オフセットの桁あふれを防ぐ）
+const maxPageNumber = 1000000
+
+// parseLimitOffset クエリパラメータ limit / offset の取得（不正な値はデフォルト値を使用）
+func parseLimitOffset(c *gin.Context, defaultLimit, maxLimit int) (int, int) {
+	limit := defaultLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= maxLimit {
+			limit = l
+		}
+	}
+
+	offset := 0
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	return limit, offset
+}
+
+// pageParams 一覧の取得範囲
+type pageParams struct {
+	Page    int // 1始まり
+	PerPage int
+	Offset  int
+}
+
+// parsePage クエリパラメータ page / per_page / cursor の取得（不正な値はデフォルト値を使用）
+// cursor は前回のレスポンスの next_cursor で、指定した場合は page より優先する。
+// 従来の limit / offset も per_page / page の代わりに指定できる
+func parsePage(c *gin.Context, defaultPerPage, maxPerPage int) pageParams {
+	perPage := defaultPerPage
+	perPageStr := c.Query("per_page")
+	if perPageStr == "" {
+		perPageStr = c.Query("limit")
+	}
+	if perPageStr != "" {
+		if n, err := strconv.Atoi(perPageStr); err == nil && n > 0 && n <= maxPerPage {
+			perPage = n
+		}
+	}
+
+	offset := 0
+	switch {
+	case c.Query("cursor") != "":
+		if o, ok := decodePageCursor(c.Query("cursor")); ok {
+			offset = o
+		}
+	case c.Query("page") != "":
+		if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 && p <= maxPageNumber {
+			offset = (p - 1) * perPage
+		}
+	case c.Query("offset") != "":
+		if o, err := strconv.Atoi(c.Query("offset")); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	return pageParams{Page: offset/perPage + 1, PerPage: perPage, Offset: offset}
+}
+
+// pageResponse 一覧のレスポンスの共通の形式（次のページがない場合 next_cursor はnull）
+func pageResponse(items interface{}, total int64, page pageParams) gin.H {
+	var nextCursor *string
+	if next := page.Offset + page.PerPage; int64(next) < total {
+		cursor := encodePageCursor(next)
+		nextCursor = &cursor
+	}
+	return gin.H{
+		"items":       items,
+		"total":       total,
+		"page":        page.Page,
+		"per_page":    page.PerPage,
+		"next_cursor": nextCursor,
+	}
+}
+
+// encodePageCursor 次のページの位置を表すカーソル（クライアントは内容を解釈せずそのまま返す）
+func encodePageCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodePageCursor(cursor string) (int, bool) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, false
+	}
+	offset, err := strconv.Atoi(string(decoded))
+	if err != nil || offset < 0 {
+		return 0, false
+	}
+	return offset, true
+}
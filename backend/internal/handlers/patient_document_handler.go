@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type PatientDocumentHandler struct {
+	documentService *services.PatientDocumentService
+	downloadService *services.DownloadService
+}
+
+func NewPatientDocumentHandler(documentService *services.PatientDocumentService, downloadService *services.DownloadService) *PatientDocumentHandler {
+	return &PatientDocumentHandler{
+		documentService: documentService,
+		downloadService: downloadService,
+	}
+}
+
+// UploadDocument 診療記録のアップロード（患者用）
+func (h *PatientDocumentHandler) UploadDocument(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.UploadPatientDocumentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+
+	document, err := h.documentService.UploadDocument(userID.(uint), req, file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"document": document})
+}
+
+// GetMyDocuments 自分の診療記録一覧（患者用）
+func (h *PatientDocumentHandler) GetMyDocuments(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	documents, err := h.documentService.GetMyDocuments(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch documents"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"documents": documents})
+}
+
+// SearchDocuments 共有された診療記録の検索（医師用、?q=&patient_id=&doc_type=&limit=&offset=）
+func (h *PatientDocumentHandler) SearchDocuments(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.SearchDocumentsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, offset := parseLimitOffset(c, 20, 100)
+	documents, err := h.documentService.SearchDocuments(userID.(uint), req, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search documents"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"documents": documents})
+}
+
+// DeleteDocument 診療記録の削除（患者用）
+func (h *PatientDocumentHandler) DeleteDocument(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	documentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document ID"})
+		return
+	}
+
+	if err := h.documentService.DeleteDocument(uint(documentID), userID.(uint)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Document deleted successfully"})
+}
+
+// ShareWithAppointment 診療記録を予約の担当医師に共有（患者用）
+func (h *PatientDocumentHandler) ShareWithAppointment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	var req services.ShareDocumentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	grants, err := h.documentService.ShareWithAppointment(uint(appointmentID), userID.(uint), req.DocumentIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shared_documents": grants})
+}
+
+// RevokeShare 予約への共有の取り消し（患者用）
+func (h *PatientDocumentHandler) RevokeShare(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+	documentID, err := strconv.ParseUint(c.Param("documentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document ID"})
+		return
+	}
+
+	if err := h.documentService.RevokeShare(uint(appointmentID), uint(documentID), userID.(uint)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Document share revoked"})
+}
+
+// GetSharedDocuments 予約に共有された診療記録の一覧
+func (h *PatientDocumentHandler) GetSharedDocuments(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	grants, err := h.documentService.GetSharedDocuments(uint(appointmentID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shared_documents": grants})
+}
+
+// GetDocumentFile 診療記録ファイルのダウンロード
+func (h *PatientDocumentHandler) GetDocumentFile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	documentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document ID"})
+		return
+	}
+
+	document, file, err := h.documentService.OpenDocumentFile(uint(documentID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	// Range指定による途中からの再取得に対応する（アップロード後にファイルは変わらないため作成日時を更新日時とする）
+	c.Header("Content-Type", document.ContentType)
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", document.FileName))
+	c.Header("Cache-Control", "private, no-store")
+	serveFile(c, h.downloadService, document.FileName, document.CreatedAt, file)
+}
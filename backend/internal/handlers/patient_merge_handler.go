@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type PatientMergeHandler struct {
+	patientMergeService *services.PatientMergeService
+}
+
+func NewPatientMergeHandler(patientMergeService *services.PatientMergeService) *PatientMergeHandler {
+	return &PatientMergeHandler{
+		patientMergeService: patientMergeService,
+	}
+}
+
+// GetDuplicates 重複の可能性がある患者アカウント一覧（管理者用）
+func (h *PatientMergeHandler) GetDuplicates(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	groups, err := h.patientMergeService.FindDuplicates(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"duplicates": groups})
+}
+
+// MergePatients 患者アカウントの統合（管理者用）
+func (h *PatientMergeHandler) MergePatients(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.MergePatientsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.patientMergeService.MergePatients(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Patients merged successfully",
+		"result":  result,
+	})
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/payments"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+// Webhookの本文の上限
+const paymentWebhookMaxBytes = 1 << 16
+
+type PaymentHandler struct {
+	paymentService *services.PaymentService
+}
+
+func NewPaymentHandler(paymentService *services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+	}
+}
+
+// GetMyInvoices 自分の請求の一覧（患者用、?status=open|paid|void）
+func (h *PaymentHandler) GetMyInvoices(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	page := parsePage(c, 50, 200)
+	invoices, total, err := h.paymentService.GetMyInvoices(userID.(uint), c.Query("status"), page.PerPage, page.Offset)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse(invoices, total, page))
+}
+
+// GetInvoice 請求の詳細（請求先の患者・担当医師用）
+func (h *PaymentHandler) GetInvoice(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	invoiceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return
+	}
+
+	invoice, err := h.paymentService.GetInvoice(uint(invoiceID), userID.(uint))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invoice": invoice})
+}
+
+// PayInvoice 請求の支払いの開始（患者用、返したclient_secretでフロントエンドから支払う）
+func (h *PaymentHandler) PayInvoice(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	invoiceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return
+	}
+
+	checkout, err := h.paymentService.PayInvoice(uint(invoiceID), userID.(uint))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"checkout": checkout})
+}
+
+// ResolveHeldPayment 請求と一致しない確認待ちの支払いの承認・却下（管理者用）
+func (h *PaymentHandler) ResolveHeldPayment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	paymentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return
+	}
+
+	var req services.ResolveHeldPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.paymentService.ResolveHeldPayment(userID.(uint), uint(paymentID), req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"payment": payment})
+}
+
+// HandleWebhook 決済代行サービスからの支払いの結果の通知（署名で検証するため認証なし）
+func (h *PaymentHandler) HandleWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, paymentWebhookMaxBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	if err := h.paymentService.HandleWebhook(payload, c.GetHeader("Stripe-Signature")); err != nil {
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// 決済代行サービスに再送させる
+		log.Printf("Failed to handle payment webhook: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to handle webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"received": true})
+}
+
+func paymentErrorStatus(err error) int {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "unauthorized"):
+		return http.StatusForbidden
+	case err.Error() == "payment is not held for review":
+		return http.StatusConflict
+	case err.Error() == "online payment is not available":
+		return http.StatusServiceUnavailable
+	case err.Error() == "failed to start payment":
+		return http.StatusBadGateway
+	default:
+		return http.StatusBadRequest
+	}
+}
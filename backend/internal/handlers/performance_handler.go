@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type PerformanceHandler struct {
+	performanceService *services.PerformanceService
+}
+
+func NewPerformanceHandler(performanceService *services.PerformanceService) *PerformanceHandler {
+	return &PerformanceHandler{
+		performanceService: performanceService,
+	}
+}
+
+// GetPerformance 医師ごと・期間ごとの実績（?period=week|month&from=&to=&doctor_id=）
+func (h *PerformanceHandler) GetPerformance(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	doctorID, ok := parseDoctorIDQuery(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.performanceService.GetPerformance(userID.(uint), doctorID, c.Query("period"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"performance": report})
+}
+
+// ExportPerformance 実績のCSVダウンロード（?period=week|month&from=&to=&doctor_id=）
+func (h *PerformanceHandler) ExportPerformance(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	doctorID, ok := parseDoctorIDQuery(c)
+	if !ok {
+		return
+	}
+
+	export, err := h.performanceService.ExportPerformance(userID.(uint), doctorID, c.Query("period"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+export.Filename)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", export.Data)
+}
@@ -3,9 +3,11 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"online_medical_consultation_app/backend/internal/services"
+	"online_medical_consultation_app/backend/internal/views"
 )
 
 type PrescriptionHandler struct {
@@ -49,7 +51,7 @@ func (h *PrescriptionHandler) CreatePrescription(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":      "Prescription created successfully",
-		"prescription": prescription,
+		"prescription": views.Prescription(viewerFromContext(c), prescription),
 	})
 }
 
@@ -67,13 +69,16 @@ func (h *PrescriptionHandler) GetPrescriptions(c *gin.Context) {
 		return
 	}
 
-	prescriptions, err := h.prescriptionService.GetPrescriptions(uint(appointmentID), userID.(uint))
+	page := parsePage(c, 20, 100)
+	prescriptions, total, err := h.prescriptionService.GetPrescriptions(uint(appointmentID), userID.(uint), page.PerPage, page.Offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"prescriptions": prescriptions})
+	response := pageResponse(views.Prescriptions(viewerFromContext(c), prescriptions), total, page)
+	response["cost_estimates"] = h.prescriptionService.EstimateCosts(prescriptions, userID.(uint))
+	c.JSON(http.StatusOK, response)
 }
 
 // GetPrescriptionDetails 処方詳細の取得
@@ -96,7 +101,59 @@ func (h *PrescriptionHandler) GetPrescriptionDetails(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"prescription": prescription})
+	c.JSON(http.StatusOK, gin.H{
+		"prescription":  views.Prescription(viewerFromContext(c), prescription),
+		"cost_estimate": h.prescriptionService.EstimateCost(prescription, userID.(uint)),
+	})
+}
+
+// GetPrescriptionPDF 処方箋のPDFのダウンロード（照合用のQRコード付き）
+func (h *PrescriptionHandler) GetPrescriptionPDF(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	prescriptionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid prescription ID"})
+		return
+	}
+
+	filename, data, err := h.prescriptionService.GetPrescriptionPDF(uint(appointmentID), uint(prescriptionID), userID.(uint))
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case strings.HasPrefix(err.Error(), "unauthorized"):
+			status = http.StatusForbidden
+		case strings.HasSuffix(err.Error(), "not found"):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// VerifyPrescription 処方箋のQRコードの照合コードによる処方の確認（未ログインでも可）
+func (h *PrescriptionHandler) VerifyPrescription(c *gin.Context) {
+	verification, err := h.prescriptionService.VerifyPrescription(c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"verification": verification})
 }
 
 // UpdatePrescription 処方の更新（医師用）
@@ -130,7 +187,7 @@ func (h *PrescriptionHandler) UpdatePrescription(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":      "Prescription updated successfully",
-		"prescription": prescription,
+		"prescription": views.Prescription(viewerFromContext(c), prescription),
 	})
 }
 
@@ -155,3 +212,50 @@ func (h *PrescriptionHandler) DeletePrescription(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Prescription deleted successfully"})
 }
+
+// AcknowledgePrescription 処方内容の確認（患者用）
+func (h *PrescriptionHandler) AcknowledgePrescription(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	prescriptionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid prescription ID"})
+		return
+	}
+
+	prescription, err := h.prescriptionService.AcknowledgePrescription(uint(prescriptionID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Prescription acknowledged successfully",
+		"prescription":  views.Prescription(viewerFromContext(c), prescription),
+		"cost_estimate": h.prescriptionService.EstimateCost(prescription, userID.(uint)),
+	})
+}
+
+// GetUnacknowledgedPrescriptions 未確認の処方一覧（患者用）
+func (h *PrescriptionHandler) GetUnacknowledgedPrescriptions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	prescriptions, err := h.prescriptionService.GetUnacknowledgedPrescriptions(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prescriptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"prescriptions":  views.Prescriptions(viewerFromContext(c), prescriptions),
+		"cost_estimates": h.prescriptionService.EstimateCosts(prescriptions, userID.(uint)),
+	})
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type PROMHandler struct {
+	promService *services.PROMService
+}
+
+func NewPROMHandler(promService *services.PROMService) *PROMHandler {
+	return &PROMHandler{
+		promService: promService,
+	}
+}
+
+// GetInstruments 割り当てられる質問票（PHQ-9・GAD-7等）の一覧
+func (h *PROMHandler) GetInstruments(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"instruments": h.promService.GetInstruments()})
+}
+
+// AssignPROM 質問票の割り当て（予約の担当医師用）
+func (h *PROMHandler) AssignPROM(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	var req services.AssignPROMRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	assignment, err := h.promService.AssignPROM(uint(appointmentID), userID.(uint), req)
+	if err != nil {
+		c.JSON(promErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Questionnaire assigned successfully",
+		"assignment": assignment,
+	})
+}
+
+// GetAppointmentPROMs 予約で割り当てた質問票の一覧
+func (h *PROMHandler) GetAppointmentPROMs(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	assignments, err := h.promService.GetAppointmentPROMs(uint(appointmentID), userID.(uint))
+	if err != nil {
+		c.JSON(promErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"assignments": assignments})
+}
+
+// GetPROMTrends 予約の患者の質問票の点数の推移（?instrument=phq9 で絞り込み）
+func (h *PROMHandler) GetPROMTrends(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("appointmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	trends, err := h.promService.GetPROMTrends(uint(appointmentID), userID.(uint), c.Query("instrument"))
+	if err != nil {
+		c.JSON(promErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"trends": trends})
+}
+
+// GetMyPROMs 自分に割り当てられた質問票の一覧（患者用、?status=pending で絞り込み）
+func (h *PROMHandler) GetMyPROMs(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	assignments, err := h.promService.GetMyPROMs(userID.(uint), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"assignments": assignments})
+}
+
+// SubmitPROM 質問票への回答（患者用）
+func (h *PROMHandler) SubmitPROM(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	assignmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid questionnaire ID"})
+		return
+	}
+
+	var req services.SubmitPROMRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	assignment, err := h.promService.SubmitPROM(uint(assignmentID), userID.(uint), req)
+	if err != nil {
+		c.JSON(promErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Questionnaire submitted successfully",
+		"assignment": assignment,
+	})
+}
+
+// CancelPROM 未回答の質問票の取り消し（割り当てた医師用）
+func (h *PROMHandler) CancelPROM(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	assignmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid questionnaire ID"})
+		return
+	}
+
+	if err := h.promService.CancelPROM(uint(assignmentID), userID.(uint)); err != nil {
+		c.JSON(promErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Questionnaire cancelled successfully"})
+}
+
+func promErrorStatus(err error) int {
+	switch {
+	case strings.HasPrefix(err.Error(), "unauthorized"):
+		return http.StatusForbidden
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
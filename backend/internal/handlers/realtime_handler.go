@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"online_medical_consultation_app/backend/internal/realtime"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type RealtimeHandler struct {
+	hub      *realtime.Hub
+	upgrader websocket.Upgrader
+}
+
+// checkOrigin はCORSと同じ許可したオリジンの確認（middleware.CheckOrigin）
+func NewRealtimeHandler(hub *realtime.Hub, checkOrigin func(r *http.Request) bool) *RealtimeHandler {
+	return &RealtimeHandler{
+		hub: hub,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  4096,
+			WriteBufferSize: 4096,
+			CheckOrigin:     checkOrigin,
+		},
+	}
+}
+
+// Connect WebSocket接続（チャット・ビデオ通話のシグナリング・通知の受信）
+// ブラウザはヘッダーを指定できないため、トークンは ?token= でも受け付ける
+func (h *RealtimeHandler) Connect(c *gin.Context) {
+	h.serve(c, "")
+}
+
+// ConnectAppointments 予約の状態の変化（確定・キャンセル・医師の遅れ等）のみを受信するWebSocket接続
+// 予約一覧を定期的に再取得する代わりに、イベントを受け取った時に再取得する
+func (h *RealtimeHandler) ConnectAppointments(c *gin.Context) {
+	h.serve(c, services.AppointmentStreamTopic)
+}
+
+func (h *RealtimeHandler) serve(c *gin.Context, topic string) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// 停止処理中は別のインスタンスへの再接続を促す
+	if h.hub.Draining() {
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": realtime.ErrDraining.Error()})
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgradeがエラーレスポンスを返している
+		return
+	}
+
+	h.hub.ServeTopic(conn, userID.(uint), topic)
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type RecordingHandler struct {
+	recordingService *services.RecordingService
+	downloadService  *services.DownloadService
+}
+
+func NewRecordingHandler(recordingService *services.RecordingService, downloadService *services.DownloadService) *RecordingHandler {
+	return &RecordingHandler{
+		recordingService: recordingService,
+		downloadService:  downloadService,
+	}
+}
+
+// StartRecording 録画の開始（患者・医師の双方が同意した通話中のセッション）
+func (h *RecordingHandler) StartRecording(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	recording, err := h.recordingService.StartRecording(uint(sessionID), userID.(uint))
+	if err != nil {
+		c.JSON(recordingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"recording": recording})
+}
+
+// StopRecording 録画の停止
+func (h *RecordingHandler) StopRecording(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, recordingID, ok := parseRecordingParams(c)
+	if !ok {
+		return
+	}
+
+	recording, err := h.recordingService.StopRecording(sessionID, recordingID, userID.(uint))
+	if err != nil {
+		c.JSON(recordingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recording": recording})
+}
+
+// UploadRecording 録画ファイルのアップロード（録画を開始した参加者、停止後に1回のみ）
+func (h *RecordingHandler) UploadRecording(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, recordingID, ok := parseRecordingParams(c)
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+
+	recording, err := h.recordingService.UploadRecording(sessionID, recordingID, userID.(uint), file)
+	if err != nil {
+		c.JSON(recordingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Recording uploaded successfully",
+		"recording": recording,
+	})
+}
+
+// GetRecordings セッションの録画の一覧
+func (h *RecordingHandler) GetRecordings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	recordings, err := h.recordingService.GetRecordings(uint(sessionID), userID.(uint))
+	if err != nil {
+		c.JSON(recordingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recordings": recordings})
+}
+
+// GetRecording 録画の詳細
+func (h *RecordingHandler) GetRecording(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, recordingID, ok := parseRecordingParams(c)
+	if !ok {
+		return
+	}
+
+	recording, err := h.recordingService.GetRecording(sessionID, recordingID, userID.(uint))
+	if err != nil {
+		c.JSON(recordingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recording": recording})
+}
+
+// GetRecordingFile 録画ファイルのダウンロード（ローカルに保存した録画はRange指定による途中からの再取得に対応する）
+func (h *RecordingHandler) GetRecordingFile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, recordingID, ok := parseRecordingParams(c)
+	if !ok {
+		return
+	}
+
+	recordingFile, err := h.recordingService.OpenRecording(sessionID, recordingID, userID.(uint))
+	if err != nil {
+		c.JSON(recordingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer recordingFile.Body.Close()
+
+	recording := recordingFile.Recording
+	c.Header("Content-Type", recording.ContentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", recordingFile.Filename))
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	if file, ok := recordingFile.Body.(*os.File); ok {
+		serveFile(c, h.downloadService, recordingFile.Filename, *recording.UploadedAt, file)
+		return
+	}
+
+	c.Header("Content-Length", strconv.FormatInt(recording.SizeBytes, 10))
+	c.Status(http.StatusOK)
+	writer := newThrottledWriter(c.Request.Context(), c.Writer, h.downloadService.BandwidthLimit())
+	if _, err := io.Copy(writer, recordingFile.Body); err != nil {
+		log.Printf("Failed to send recording %d: %v", recording.ID, err)
+	}
+}
+
+// parseRecordingParams パスのセッションIDと録画IDの解析（不正な場合は400を返す）
+func parseRecordingParams(c *gin.Context) (uint, uint, bool) {
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return 0, 0, false
+	}
+	recordingID, err := strconv.ParseUint(c.Param("recordingId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recording ID"})
+		return 0, 0, false
+	}
+	return uint(sessionID), uint(recordingID), true
+}
+
+func recordingErrorStatus(err error) int {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "unauthorized"), strings.HasPrefix(err.Error(), "only the"):
+		return http.StatusForbidden
+	case err.Error() == "recording access is temporarily unavailable":
+		return http.StatusServiceUnavailable
+	case err.Error() == "failed to store recording":
+		return http.StatusBadGateway
+	case err.Error() == "recording is already in progress", err.Error() == "recording is not in progress", err.Error() == "recording has already been uploaded":
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
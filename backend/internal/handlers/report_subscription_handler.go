@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type ReportSubscriptionHandler struct {
+	reportSubscriptionService *services.ReportSubscriptionService
+}
+
+func NewReportSubscriptionHandler(reportSubscriptionService *services.ReportSubscriptionService) *ReportSubscriptionHandler {
+	return &ReportSubscriptionHandler{
+		reportSubscriptionService: reportSubscriptionService,
+	}
+}
+
+// GetSubscriptions 自分の定期レポートの配信の一覧（管理者用）
+func (h *ReportSubscriptionHandler) GetSubscriptions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	subscriptions, err := h.reportSubscriptionService.GetSubscriptions(userID.(uint))
+	if err != nil {
+		c.JSON(reportSubscriptionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subscriptions})
+}
+
+// CreateSubscription 定期レポートの配信の登録（管理者用）
+func (h *ReportSubscriptionHandler) CreateSubscription(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CreateReportSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.reportSubscriptionService.CreateSubscription(userID.(uint), req)
+	if err != nil {
+		c.JSON(reportSubscriptionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"subscription": subscription})
+}
+
+// UpdateSubscription 配信の形式の変更・停止・再開（管理者用）
+func (h *ReportSubscriptionHandler) UpdateSubscription(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	subscriptionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	var req services.UpdateReportSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.reportSubscriptionService.UpdateSubscription(userID.(uint), uint(subscriptionID), req)
+	if err != nil {
+		c.JSON(reportSubscriptionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Report subscription updated successfully",
+		"subscription": subscription,
+	})
+}
+
+// DeleteSubscription 配信の登録の削除（管理者用）
+func (h *ReportSubscriptionHandler) DeleteSubscription(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	subscriptionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	if err := h.reportSubscriptionService.DeleteSubscription(userID.(uint), uint(subscriptionID)); err != nil {
+		c.JSON(reportSubscriptionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Report subscription deleted successfully"})
+}
+
+// SendNow 直近の期間のレポートを直ちに送る（管理者用）
+func (h *ReportSubscriptionHandler) SendNow(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	subscriptionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	subscription, err := h.reportSubscriptionService.SendNow(userID.(uint), uint(subscriptionID))
+	if err != nil {
+		c.JSON(reportSubscriptionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Report sent successfully",
+		"subscription": subscription,
+	})
+}
+
+func reportSubscriptionErrorStatus(err error) int {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "unauthorized"):
+		return http.StatusForbidden
+	case err.Error() == "already subscribed to this report":
+		return http.StatusConflict
+	case strings.HasPrefix(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	case strings.HasPrefix(err.Error(), "failed to send report"):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
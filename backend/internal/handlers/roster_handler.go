@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type RosterHandler struct {
+	rosterService *services.RosterService
+}
+
+func NewRosterHandler(rosterService *services.RosterService) *RosterHandler {
+	return &RosterHandler{
+		rosterService: rosterService,
+	}
+}
+
+// GetRoster 全医師の診療枠と予約の一覧（管理者用、?date=YYYY-MM-DD&span=day|week）
+func (h *RosterHandler) GetRoster(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	roster, err := h.rosterService.GetRoster(userID.(uint), c.Query("date"), c.Query("span"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roster": roster})
+}
+
+// GetDoctorSchedulePDF 医師の週間予定表のPDF（医師用、?week=YYYY-MM-DD&initials=true）
+func (h *RosterHandler) GetDoctorSchedulePDF(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	filename, data, err := h.rosterService.RenderDoctorSchedulePDF(userID.(uint), c.Query("week"), c.Query("initials") == "true")
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case strings.HasSuffix(err.Error(), "not found"):
+			status = http.StatusNotFound
+		case strings.HasPrefix(err.Error(), "invalid"):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, "application/pdf", data)
+}
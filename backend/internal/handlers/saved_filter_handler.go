@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type SavedFilterHandler struct {
+	savedFilterService *services.SavedFilterService
+}
+
+func NewSavedFilterHandler(savedFilterService *services.SavedFilterService) *SavedFilterHandler {
+	return &SavedFilterHandler{
+		savedFilterService: savedFilterService,
+	}
+}
+
+// GetSavedFilters 保存した検索条件の一覧（?scope=appointments|audit_logs）
+func (h *SavedFilterHandler) GetSavedFilters(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	filters, err := h.savedFilterService.GetFilters(userID.(uint), c.Query("scope"))
+	if err != nil {
+		c.JSON(savedFilterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"filters": filters})
+}
+
+// CreateSavedFilter 検索条件の保存
+func (h *SavedFilterHandler) CreateSavedFilter(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.SavedFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := h.savedFilterService.CreateFilter(userID.(uint), req)
+	if err != nil {
+		c.JSON(savedFilterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"filter": filter})
+}
+
+// UpdateSavedFilter 保存した検索条件の変更
+func (h *SavedFilterHandler) UpdateSavedFilter(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	filterID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filter ID"})
+		return
+	}
+
+	var req services.SavedFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := h.savedFilterService.UpdateFilter(userID.(uint), uint(filterID), req)
+	if err != nil {
+		c.JSON(savedFilterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"filter": filter})
+}
+
+// DeleteSavedFilter 保存した検索条件の削除
+func (h *SavedFilterHandler) DeleteSavedFilter(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	filterID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filter ID"})
+		return
+	}
+
+	if err := h.savedFilterService.DeleteFilter(userID.(uint), uint(filterID)); err != nil {
+		c.JSON(savedFilterErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Saved filter deleted successfully"})
+}
+
+func savedFilterErrorStatus(err error) int {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "unauthorized"):
+		return http.StatusForbidden
+	case strings.HasPrefix(err.Error(), "invalid"), strings.HasPrefix(err.Error(), "too many"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
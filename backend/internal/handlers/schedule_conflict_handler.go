@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type ScheduleConflictHandler struct {
+	scheduleConflictService *services.ScheduleConflictService
+}
+
+func NewScheduleConflictHandler(scheduleConflictService *services.ScheduleConflictService) *ScheduleConflictHandler {
+	return &ScheduleConflictHandler{
+		scheduleConflictService: scheduleConflictService,
+	}
+}
+
+// GetConflicts 予定の不整合のレポート（医師は自分の予定、管理者は /admin/doctors/:id で指定した医師）
+func (h *ScheduleConflictHandler) GetConflicts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	doctorID, ok := scheduleDoctorID(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	report, err := h.scheduleConflictService.GetReport(userID.(uint), doctorID)
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.HasPrefix(err.Error(), "unauthorized") {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// ApplyFix レポートで提示した解消方法の実行
+func (h *ScheduleConflictHandler) ApplyFix(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	doctorID, ok := scheduleDoctorID(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	var fix services.ScheduleFix
+	if err := c.ShouldBindJSON(&fix); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.scheduleConflictService.ApplyFix(userID.(uint), doctorID, fix)
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.HasPrefix(err.Error(), "unauthorized") {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Schedule fix applied",
+		"report":  report,
+	})
+}
+
+// scheduleDoctorID 対象の医師（:idがない場合はログイン中の医師）
+func scheduleDoctorID(c *gin.Context, userID uint) (uint, bool) {
+	idStr := c.Param("id")
+	if idStr == "" {
+		return userID, true
+	}
+	doctorID, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid doctor ID"})
+		return 0, false
+	}
+	return uint(doctorID), true
+}
@@ -46,7 +46,7 @@ func (h *SlotHandler) CreateSlot(c *gin.Context) {
 	})
 }
 
-// GetSlots 医師の診療枠一覧取得
+// GetSlots 医師の診療枠一覧取得（終了した枠は ?include_past=true の場合のみ）
 func (h *SlotHandler) GetSlots(c *gin.Context) {
 	// ユーザーIDを取得（JWTから）
 	userID, exists := c.Get("user_id")
@@ -55,15 +55,14 @@ func (h *SlotHandler) GetSlots(c *gin.Context) {
 		return
 	}
 
-	slots, err := h.slotService.GetSlotsByDoctorID(userID.(uint))
+	page := parsePage(c, 50, 200)
+	slots, total, err := h.slotService.GetSlotsByDoctorID(userID.(uint), c.Query("include_past") == "true", page.PerPage, page.Offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"slots": slots,
-	})
+	c.JSON(http.StatusOK, pageResponse(slots, total, page))
 }
 
 // UpdateSlot 診療枠の更新
@@ -124,6 +123,72 @@ func (h *SlotHandler) DeleteSlot(c *gin.Context) {
 	})
 }
 
+// CreateSlotTemplate 毎週繰り返す診療枠のひな形の登録（医師用）
+func (h *SlotHandler) CreateSlotTemplate(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.CreateSlotTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, created, err := h.slotService.CreateSlotTemplate(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":       "Slot template created successfully",
+		"template":      template,
+		"created_slots": created,
+	})
+}
+
+// GetSlotTemplates 毎週繰り返す診療枠のひな形一覧（医師用）
+func (h *SlotHandler) GetSlotTemplates(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	templates, err := h.slotService.GetSlotTemplates(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"templates": templates})
+}
+
+// DeleteSlotTemplate ひな形の削除（作成済みの診療枠は残る、医師用）
+func (h *SlotHandler) DeleteSlotTemplate(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	templateID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid slot template ID"})
+		return
+	}
+
+	if err := h.slotService.DeleteSlotTemplate(uint(templateID), userID.(uint)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Slot template deleted successfully"})
+}
+
 // GetAvailableSlots 利用可能な診療枠の取得（患者用）
 func (h *SlotHandler) GetAvailableSlots(c *gin.Context) {
 	log.Printf("GetAvailableSlots called with params: %+v", c.Params)
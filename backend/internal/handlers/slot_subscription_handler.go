@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type SlotSubscriptionHandler struct {
+	slotSubscriptionService *services.SlotSubscriptionService
+}
+
+func NewSlotSubscriptionHandler(slotSubscriptionService *services.SlotSubscriptionService) *SlotSubscriptionHandler {
+	return &SlotSubscriptionHandler{
+		slotSubscriptionService: slotSubscriptionService,
+	}
+}
+
+// Subscribe 医師の診療枠の公開の通知を登録（患者用）
+func (h *SlotSubscriptionHandler) Subscribe(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.SubscribeSlotsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.slotSubscriptionService.Subscribe(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Subscribed to slot openings",
+		"subscription": subscription,
+	})
+}
+
+// GetSubscriptions 登録中の医師の一覧（患者用）
+func (h *SlotSubscriptionHandler) GetSubscriptions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	subscriptions, err := h.slotSubscriptionService.GetSubscriptions(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscriptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subscriptions})
+}
+
+// Unsubscribe 登録の解除（患者用）
+func (h *SlotSubscriptionHandler) Unsubscribe(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	subscriptionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	if err := h.slotSubscriptionService.Unsubscribe(uint(subscriptionID), userID.(uint)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Unsubscribed from slot openings"})
+}
+
+// UnsubscribeByToken 通知の登録解除リンクによる解除（未ログインでも可）
+func (h *SlotSubscriptionHandler) UnsubscribeByToken(c *gin.Context) {
+	var req services.UnsubscribeSlotsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.slotSubscriptionService.UnsubscribeByToken(req.Token); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Unsubscribed from slot openings"})
+}
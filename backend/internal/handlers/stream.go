@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+// DownloadTokenHeader エクスポートを途中から再取得するためのトークン（GET /downloads/:token で Range を指定して取得する）
+const DownloadTokenHeader = "X-Download-Token"
+
+// DownloadExpiresHeader 再取得用のトークンの有効期限（RFC3339）
+const DownloadExpiresHeader = "X-Download-Expires-At"
+
+// エクスポートの送信でフラッシュする間隔（バイト）
+const exportFlushThreshold = 256 * 1024
+
+// flushWriter 一定量書き込むごとにクライアントへフラッシュするライター
+type flushWriter struct {
+	w         gin.ResponseWriter
+	threshold int
+	pending   int
+}
+
+func newFlushWriter(w gin.ResponseWriter, threshold int) *flushWriter {
+	return &flushWriter{w: w, threshold: threshold}
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.pending += n
+	if f.pending >= f.threshold {
+		f.Flush()
+	}
+	return n, err
+}
+
+// Flush バッファ済みのデータを送信する
+func (f *flushWriter) Flush() {
+	f.w.Flush()
+	f.pending = 0
+}
+
+// throttledWriter 送信速度を上限（バイト/秒）以下に抑えるライター（接続ごとに作成する）
+type throttledWriter struct {
+	ctx   context.Context
+	w     io.Writer
+	rate  int64
+	start time.Time
+	sent  int64
+}
+
+func newThrottledWriter(ctx context.Context, w io.Writer, rate int64) *throttledWriter {
+	return &throttledWriter{ctx: ctx, w: w, rate: rate, start: time.Now()}
+}
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	if t.rate <= 0 {
+		return t.w.Write(p)
+	}
+
+	// 0.1秒分ずつ送信し、送信量に対して経過時間が足りない分だけ待つ
+	chunk := int(t.rate / 10)
+	if chunk < 1024 {
+		chunk = 1024
+	}
+	written := 0
+	for written < len(p) {
+		end := written + chunk
+		if end > len(p) {
+			end = len(p)
+		}
+		n, err := t.w.Write(p[written:end])
+		written += n
+		t.sent += int64(n)
+		if err != nil {
+			return written, err
+		}
+
+		wait := time.Duration(t.sent*int64(time.Second)/t.rate) - time.Since(t.start)
+		if wait > 0 {
+			select {
+			case <-t.ctx.Done():
+				return written, t.ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+	}
+	return written, nil
+}
+
+// throttledResponseWriter 本文の送信速度を抑えるレスポンスライター（http.ServeContent用）
+type throttledResponseWriter struct {
+	gin.ResponseWriter
+	throttle *throttledWriter
+}
+
+func (w *throttledResponseWriter) Write(p []byte) (int, error) {
+	return w.throttle.Write(p)
+}
+
+// spoolTeeWriter エクスポートを一時保存しながらクライアントへ送信するライター
+// クライアントとの通信が途切れても生成は最後まで続け、トークンで途中から再取得できるようにする
+type spoolTeeWriter struct {
+	spool     *services.ExportSpool
+	client    io.Writer
+	clientErr error
+}
+
+func (w *spoolTeeWriter) Write(p []byte) (int, error) {
+	n, err := w.spool.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if w.clientErr == nil {
+		if _, err := w.client.Write(p); err != nil {
+			w.clientErr = err
+		}
+	}
+	return n, nil
+}
+
+// streamExport エクスポートのチャンク転送（接続ごとに送信速度を抑え、再取得用のトークンをヘッダーで返す）
+// 一時保存を開始できない場合は再取得用のトークンなしで送信する
+func streamExport(c *gin.Context, downloadService *services.DownloadService, userID uint, filename, contentType string, stream func(io.Writer) error) error {
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Content-Type", contentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	spool, err := downloadService.Begin(userID, filename, contentType)
+	if err != nil {
+		log.Printf("Warning: Failed to start resumable download for %s: %v", filename, err)
+		spool = nil
+	} else {
+		c.Header(DownloadTokenHeader, spool.Token)
+		c.Header(DownloadExpiresHeader, spool.Download.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	c.Status(http.StatusOK)
+
+	flusher := newFlushWriter(c.Writer, exportFlushThreshold)
+	var writer io.Writer = newThrottledWriter(c.Request.Context(), flusher, downloadService.BandwidthLimit())
+	if spool != nil {
+		writer = &spoolTeeWriter{spool: spool, client: writer}
+	}
+
+	if err := stream(writer); err != nil {
+		if spool != nil {
+			downloadService.Abort(spool)
+		}
+		return err
+	}
+	if spool != nil {
+		if err := downloadService.Complete(spool); err != nil {
+			log.Printf("Warning: Failed to complete resumable download for %s: %v", filename, err)
+		}
+	}
+	flusher.Flush()
+	return nil
+}
+
+// serveFile ファイルの送信（Range指定による途中からの再取得に対応し、接続ごとに送信速度を抑える）
+func serveFile(c *gin.Context, downloadService *services.DownloadService, filename string, modTime time.Time, file *os.File) {
+	writer := &throttledResponseWriter{
+		ResponseWriter: c.Writer,
+		throttle:       newThrottledWriter(c.Request.Context(), c.Writer, downloadService.BandwidthLimit()),
+	}
+	http.ServeContent(writer, c.Request, filename, modTime, file)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+// 有料プランの確認・購入の案内先
+const subscriptionUpgradePath = "/api/v1/patients/me/subscription"
+
+type SubscriptionHandler struct {
+	subscriptionService *services.SubscriptionService
+}
+
+func NewSubscriptionHandler(subscriptionService *services.SubscriptionService) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		subscriptionService: subscriptionService,
+	}
+}
+
+// GetMySubscription 自分の現在のプランと予約ごとのチャット・ビデオの利用上限（患者用）
+func (h *SubscriptionHandler) GetMySubscription(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	status, err := h.subscriptionService.GetMySubscription(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscription"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Subscribe 有料プランの支払いの開始（患者用、返したclient_secretでフロントエンドから支払う）
+func (h *SubscriptionHandler) Subscribe(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	checkout, err := h.subscriptionService.Subscribe(userID.(uint))
+	if err != nil {
+		c.JSON(subscriptionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"checkout": checkout})
+}
+
+func subscriptionErrorStatus(err error) int {
+	switch err.Error() {
+	case "subscriptions are not available":
+		return http.StatusServiceUnavailable
+	case "failed to start payment":
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeQuotaExceeded 予約ごとの利用上限の超過のエラー（403、有料プランで緩和される場合は購入の案内を含める）
+// 超過以外のエラーの場合はfalse
+func writeQuotaExceeded(c *gin.Context, err error) bool {
+	var exceeded *services.QuotaExceededError
+	if !errors.As(err, &exceeded) {
+		return false
+	}
+	response := gin.H{
+		"error": err.Error(),
+		"code":  "quota_exceeded",
+		"quota": exceeded.Quota,
+		"plan":  exceeded.Plan,
+		"limit": exceeded.Limit,
+	}
+	if exceeded.UpgradeAvailable {
+		response["upgrade"] = gin.H{
+			"plan": models.PlanPlus,
+			"url":  subscriptionUpgradePath,
+		}
+	}
+	c.JSON(http.StatusForbidden, response)
+	return true
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type TranscriptHandler struct {
+	transcriptionService *services.TranscriptionService
+	downloadService      *services.DownloadService
+}
+
+func NewTranscriptHandler(transcriptionService *services.TranscriptionService, downloadService *services.DownloadService) *TranscriptHandler {
+	return &TranscriptHandler{
+		transcriptionService: transcriptionService,
+		downloadService:      downloadService,
+	}
+}
+
+// UploadRecording 診療の録音のアップロード（患者が同意したセッションのみ、非同期で文字起こし）
+func (h *TranscriptHandler) UploadRecording(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+
+	transcript, err := h.transcriptionService.UploadRecording(uint(sessionID), userID.(uint), file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":    "Recording uploaded, transcription queued",
+		"transcript": transcript,
+	})
+}
+
+// GetTranscript セッションの文字起こし（?q= で一致する区間のみ）
+func (h *TranscriptHandler) GetTranscript(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	transcript, err := h.transcriptionService.GetTranscript(uint(sessionID), userID.(uint), c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transcript": transcript})
+}
+
+// ExportTranscript 文字起こしのダウンロード（?format=txt|pdf）
+func (h *TranscriptHandler) ExportTranscript(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	transcriptExport, err := h.transcriptionService.ExportTranscript(uint(sessionID), userID.(uint), c.DefaultQuery("format", "txt"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 途中から再取得できるようチャンク転送する
+	err = streamExport(c, h.downloadService, userID.(uint), transcriptExport.Filename, transcriptExport.ContentType, func(w io.Writer) error {
+		_, err := w.Write(transcriptExport.Data)
+		return err
+	})
+	if err != nil {
+		log.Printf("Transcript export failed: %v", err)
+		c.Error(err)
+	}
+}
+
+// SearchTranscripts 参加した診療の文字起こしの検索（?q=&limit=&offset=）
+func (h *TranscriptHandler) SearchTranscripts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	results, err := h.transcriptionService.SearchTranscripts(userID.(uint), c.Query("q"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type TriageHandler struct {
+	triageService *services.TriageService
+}
+
+func NewTriageHandler(triageService *services.TriageService) *TriageHandler {
+	return &TriageHandler{
+		triageService: triageService,
+	}
+}
+
+// GetQuestionnaire 問診票の取得
+func (h *TriageHandler) GetQuestionnaire(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"questions": h.triageService.GetQuestionnaire()})
+}
+
+// SubmitTriage 問診の回答（患者用）
+func (h *TriageHandler) SubmitTriage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req services.SubmitTriageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	assessment, err := h.triageService.SubmitTriage(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Triage assessment created successfully",
+		"triage":  assessment,
+		"advice":  services.TriageAdvice(assessment),
+	})
+}
+
+// GetTriageAssessments 問診結果一覧の取得（患者用）
+func (h *TriageHandler) GetTriageAssessments(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, offset := parseLimitOffset(c, 20, 100)
+
+	assessments, err := h.triageService.GetTriageAssessments(userID.(uint), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"triage_assessments": assessments})
+}
+
+// GetTriageAssessment 問診結果の取得（患者用）
+func (h *TriageHandler) GetTriageAssessment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	assessmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid triage ID"})
+		return
+	}
+
+	assessment, err := h.triageService.GetTriageAssessment(uint(assessmentID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"triage": assessment,
+		"advice": services.TriageAdvice(assessment),
+	})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type UtilizationHandler struct {
+	utilizationService *services.UtilizationService
+}
+
+func NewUtilizationHandler(utilizationService *services.UtilizationService) *UtilizationHandler {
+	return &UtilizationHandler{
+		utilizationService: utilizationService,
+	}
+}
+
+// GetUtilization 医師ごと・週ごとの診療枠の稼働状況（?from=&to=&doctor_id=）
+// 医師が呼び出した場合は自身の稼働状況のみ返す
+func (h *UtilizationHandler) GetUtilization(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	doctorID, ok := parseDoctorIDQuery(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.utilizationService.GetUtilization(userID.(uint), doctorID, c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"utilization": report})
+}
+
+// GetUtilizationTrend 直近の週ごとの稼働状況の推移（?weeks=&doctor_id=）
+func (h *UtilizationHandler) GetUtilizationTrend(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	doctorID, ok := parseDoctorIDQuery(c)
+	if !ok {
+		return
+	}
+
+	weeks := 0
+	if weeksStr := c.Query("weeks"); weeksStr != "" {
+		w, err := strconv.Atoi(weeksStr)
+		if err != nil || w < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid weeks"})
+			return
+		}
+		weeks = w
+	}
+
+	trend, err := h.utilizationService.GetUtilizationTrend(userID.(uint), doctorID, weeks)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"trend": trend})
+}
+
+// ExportUtilization 稼働状況のCSVダウンロード（?from=&to=&doctor_id=）
+func (h *UtilizationHandler) ExportUtilization(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	doctorID, ok := parseDoctorIDQuery(c)
+	if !ok {
+		return
+	}
+
+	export, err := h.utilizationService.ExportUtilization(userID.(uint), doctorID, c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+export.Filename)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", export.Data)
+}
+
+// parseDoctorIDQuery 任意のdoctor_idクエリの取得（未指定は0）
+func parseDoctorIDQuery(c *gin.Context) (uint, bool) {
+	doctorIDStr := c.Query("doctor_id")
+	if doctorIDStr == "" {
+		return 0, true
+	}
+	doctorID, err := strconv.ParseUint(doctorIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid doctor ID"})
+		return 0, false
+	}
+	return uint(doctorID), true
+}
@@ -3,18 +3,31 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/realtime"
 	"online_medical_consultation_app/backend/internal/services"
 )
 
 type VideoHandler struct {
 	videoService *services.VideoService
+	hub          *realtime.Hub
+	upgrader     websocket.Upgrader
 }
 
-func NewVideoHandler(videoService *services.VideoService) *VideoHandler {
+// checkOrigin はCORSと同じ許可したオリジンの確認（middleware.CheckOrigin）
+func NewVideoHandler(videoService *services.VideoService, hub *realtime.Hub, checkOrigin func(r *http.Request) bool) *VideoHandler {
 	return &VideoHandler{
 		videoService: videoService,
+		hub:          hub,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  4096,
+			WriteBufferSize: 4096,
+			CheckOrigin:     checkOrigin,
+		},
 	}
 }
 
@@ -43,6 +56,9 @@ func (h *VideoHandler) CreateVideoSession(c *gin.Context) {
 
 	session, err := h.videoService.CreateVideoSession(&req, userID.(uint))
 	if err != nil {
+		if writeQuotaExceeded(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -205,7 +221,7 @@ func (h *VideoHandler) GetWebRTCOffer(c *gin.Context) {
 
 	offer, err := h.videoService.GetWebRTCOffer(uint(sessionID), userID.(uint))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(signalingErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -233,9 +249,259 @@ func (h *VideoHandler) SetWebRTCAnswer(c *gin.Context) {
 	}
 
 	if err := h.videoService.SetWebRTCAnswer(uint(sessionID), userID.(uint), req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(signalingErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "WebRTC answer set successfully"})
 }
+
+// RefreshToken 通話中のルームトークンの再発行
+func (h *VideoHandler) RefreshToken(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	signalingInfo, err := h.videoService.RefreshSignalingInfo(uint(sessionID), userID.(uint))
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.HasPrefix(err.Error(), "unauthorized") || strings.HasPrefix(err.Error(), "participant has not been admitted") {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"signaling_info": signalingInfo})
+}
+
+// AddICECandidate ICE候補の送信（他の参加者の受信待ちに登録する）
+func (h *VideoHandler) AddICECandidate(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	var req services.ICECandidateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	candidates, err := h.videoService.AddICECandidate(uint(sessionID), userID.(uint), req.Candidate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "ICE candidate queued successfully",
+		"queued":  len(candidates),
+	})
+}
+
+// GetICECandidates 自分宛ての受信待ちのICE候補の取得（ackに取得済みの最後のIDを指定すると、それ以前を削除する）
+func (h *VideoHandler) GetICECandidates(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	var ackID uint64
+	if ack := c.Query("ack"); ack != "" {
+		ackID, err = strconv.ParseUint(ack, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ack ID"})
+			return
+		}
+	}
+
+	candidates, err := h.videoService.GetICECandidates(uint(sessionID), userID.(uint), uint(ackID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"candidates": candidates})
+}
+
+// AcceptCall 着信への応答
+func (h *VideoHandler) AcceptCall(c *gin.Context) {
+	h.respondCall(c, h.videoService.AcceptCall)
+}
+
+// DeclineCall 着信の拒否
+func (h *VideoHandler) DeclineCall(c *gin.Context) {
+	h.respondCall(c, h.videoService.DeclineCall)
+}
+
+func (h *VideoHandler) respondCall(c *gin.Context, respond func(sessionID, userID uint) (*models.VideoParticipant, error)) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	participant, err := respond(uint(sessionID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"participant": participant})
+}
+
+// GetCallParticipants セッションの呼び出し状況
+func (h *VideoHandler) GetCallParticipants(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	participants, err := h.videoService.GetCallParticipants(uint(sessionID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"participants": participants})
+}
+
+// SetRecordingConsent 録音・録画・文字起こしへの同意・撤回（患者・医師がそれぞれ自分の同意を設定する）
+func (h *VideoHandler) SetRecordingConsent(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	var req services.RecordingConsentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.videoService.SetRecordingConsent(uint(sessionID), userID.(uint), *req.Consent)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session})
+}
+
+// ConnectSignaling セッションのシグナリングのWebSocket接続
+// 同じセッションの参加者の間でオファー・アンサー・ICE候補・切断を中継し、接続中の参加者を知らせる
+// ブラウザはヘッダーを指定できないため、トークンは ?token= でも受け付ける
+func (h *VideoHandler) ConnectSignaling(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	room, member, err := h.videoService.OpenSignaling(uint(sessionID), userID.(uint))
+	if err != nil {
+		c.JSON(signalingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// 停止処理中は別のインスタンスへの再接続を促す
+	if h.hub.Draining() {
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": realtime.ErrDraining.Error()})
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgradeがエラーレスポンスを返している
+		return
+	}
+
+	h.hub.ServeRoom(conn, userID.(uint), room, member)
+}
+
+// GetSignalingPeers シグナリングに接続中の参加者の取得
+func (h *VideoHandler) GetSignalingPeers(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	peers, err := h.videoService.GetSignalingPeers(uint(sessionID), userID.(uint))
+	if err != nil {
+		c.JSON(signalingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"peers": peers})
+}
+
+// signalingErrorStatus シグナリングのエラーのステータスコード
+func signalingErrorStatus(err error) int {
+	message := err.Error()
+	switch {
+	case strings.HasPrefix(message, "unauthorized") || strings.HasPrefix(message, "participant has not been admitted"):
+		return http.StatusForbidden
+	case strings.HasSuffix(message, "not found") || message == "no pending offer":
+		return http.StatusNotFound
+	case message == "video session has ended":
+		return http.StatusGone
+	}
+	return http.StatusBadRequest
+}
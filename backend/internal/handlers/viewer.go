@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/views"
+)
+
+// viewerFromContext 認証済みのユーザーをレスポンスの受け手とする（相手のユーザー情報の絞り込み用）
+func viewerFromContext(c *gin.Context) views.Viewer {
+	var viewer views.Viewer
+	if userID, exists := c.Get("user_id"); exists {
+		viewer.UserID, _ = userID.(uint)
+	}
+	if role, exists := c.Get("user_role"); exists {
+		viewer.Role, _ = role.(string)
+	}
+	return viewer
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/services"
+)
+
+type VisitSummaryHandler struct {
+	visitSummaryService *services.VisitSummaryService
+}
+
+func NewVisitSummaryHandler(visitSummaryService *services.VisitSummaryService) *VisitSummaryHandler {
+	return &VisitSummaryHandler{
+		visitSummaryService: visitSummaryService,
+	}
+}
+
+// GetSummary 完了した診療のサマリー（患者用、?format=pdf でPDFをダウンロード）
+func (h *VisitSummaryHandler) GetSummary(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	appointmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported format"})
+		return
+	}
+
+	summary, err := h.visitSummaryService.GetSummary(uint(appointmentID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, gin.H{"summary": summary})
+		return
+	}
+
+	filename, data, err := h.visitSummaryService.RenderPDF(summary)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate summary PDF"})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, "application/pdf", data)
+}
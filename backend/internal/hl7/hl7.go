@@ -0,0 +1,275 @@
+package hl7
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// バージョンと区切り文字（MSH-1・MSH-2）
+const (
+	Version           = "2.5.1"
+	encodingChars     = `^~\&`
+	segmentTerminator = "\r"
+	timestampLayout   = "20060102150405-0700"
+	dateLayout        = "20060102"
+)
+
+// ADTのイベント（患者・外来の予約）
+const (
+	EventRegisterPerson = "A28" // 患者の登録
+	EventUpdatePerson   = "A31" // 患者情報の変更
+	EventPreAdmit       = "A05" // 予約の確定（来院前の登録）
+	EventCancelPreAdmit = "A38" // 予約のキャンセル
+	EventEndVisit       = "A03" // 診療の終了
+)
+
+// ORMの指示（ORC-1）
+const (
+	OrderNew    = "NW"
+	OrderChange = "XO"
+	OrderCancel = "CA"
+)
+
+// adtStructures イベントごとのメッセージ構造（MSH-9.3）
+var adtStructures = map[string]string{
+	EventRegisterPerson: "ADT_A05",
+	EventUpdatePerson:   "ADT_A05",
+	EventPreAdmit:       "ADT_A05",
+	EventCancelPreAdmit: "ADT_A38",
+	EventEndVisit:       "ADT_A03",
+}
+
+// Header メッセージの送信元・送信先（MSH）
+type Header struct {
+	SendingApplication   string
+	SendingFacility      string
+	ReceivingApplication string
+	ReceivingFacility    string
+	ProcessingID         string // P: 本番 / T: テスト
+	ControlID            string
+	At                   time.Time
+}
+
+// Patient 患者（PID）
+type Patient struct {
+	ID         string // 送信元での患者番号（PID-3）
+	FamilyName string
+	GivenName  string
+	Birthdate  *time.Time
+	Sex        string // M | F | O | U
+	Phone      string
+	Address    string
+}
+
+// NextOfKin 代理予約の場合の保護者（NK1）
+type NextOfKin struct {
+	FamilyName   string
+	GivenName    string
+	Relationship string // PAR | CHD | SPO | OTH（患者から見た続柄）
+	Phone        string
+}
+
+// Visit 外来の診療（PV1）
+type Visit struct {
+	ID           string // 予約番号（PV1-19）
+	DoctorID     string
+	DoctorFamily string
+	DoctorGiven  string
+	Start        time.Time
+	End          *time.Time
+}
+
+// Order 処方（ORC・RXO、処方項目ごとに1組）
+type Order struct {
+	Control      string // NW | XO | CA
+	PlacerID     string // 処方番号（項目ごとに -1, -2... を付ける）
+	OrderedAt    time.Time
+	DoctorID     string
+	DoctorFamily string
+	DoctorGiven  string
+	Items        []OrderItem
+	Notes        string
+}
+
+// OrderItem 処方項目
+type OrderItem struct {
+	Medication   string
+	Dosage       string
+	Frequency    string
+	Duration     string
+	Instructions string
+}
+
+// BuildADT ADTメッセージの作成（患者の登録・変更ではvisitはnil）
+func BuildADT(h Header, event string, patient Patient, kin *NextOfKin, visit *Visit) (string, error) {
+	structure, ok := adtStructures[event]
+	if !ok {
+		return "", fmt.Errorf("unsupported ADT event: %s", event)
+	}
+
+	segments := []string{
+		msh(h, "ADT", event, structure),
+		segment("EVN", Escape(event), formatTime(h.At)),
+		pid(h, patient),
+	}
+	if kin != nil {
+		segments = append(segments, nk1(*kin))
+	}
+	segments = append(segments, pv1(visit))
+	return encode(segments), nil
+}
+
+// BuildORM 処方のORMメッセージの作成
+func BuildORM(h Header, patient Patient, kin *NextOfKin, visit *Visit, order Order) (string, error) {
+	if len(order.Items) == 0 {
+		return "", fmt.Errorf("order %s has no items", order.PlacerID)
+	}
+
+	segments := []string{
+		msh(h, "ORM", "O01", "ORM_O01"),
+		pid(h, patient),
+	}
+	if kin != nil {
+		segments = append(segments, nk1(*kin))
+	}
+	segments = append(segments, pv1(visit))
+	for i, item := range order.Items {
+		placer := component(fmt.Sprintf("%s-%d", order.PlacerID, i+1), h.SendingApplication)
+		provider := component(order.DoctorID, order.DoctorFamily, order.DoctorGiven)
+		segments = append(segments,
+			segment("ORC", order.Control, placer, "", "", "", "", "", "", formatTime(order.OrderedAt), "", "", provider),
+			segment("RXO", component("", item.Medication), "", "", "", "", "",
+				component("", strings.TrimSpace(strings.Join([]string{item.Dosage, item.Frequency, item.Duration}, " ")))),
+		)
+		if item.Instructions != "" {
+			segments = append(segments, segment("NTE", "1", "P", Escape(item.Instructions)))
+		}
+	}
+	if order.Notes != "" {
+		segments = append(segments, segment("NTE", "1", "P", Escape(order.Notes)))
+	}
+	return encode(segments), nil
+}
+
+// Content 送信ごとに変わるMSH・EVN（制御番号・日時）を除いたメッセージの内容（同じ内容の再送の判定に使用）
+func Content(message string) string {
+	var segments []string
+	for _, seg := range strings.Split(message, segmentTerminator) {
+		if strings.HasPrefix(seg, "MSH|") || strings.HasPrefix(seg, "EVN|") {
+			continue
+		}
+		segments = append(segments, seg)
+	}
+	return strings.Join(segments, segmentTerminator)
+}
+
+// Escape フィールドの値の区切り文字のエスケープ
+func Escape(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch r {
+		case '\\':
+			b.WriteString(`\E\`)
+		case '|':
+			b.WriteString(`\F\`)
+		case '^':
+			b.WriteString(`\S\`)
+		case '&':
+			b.WriteString(`\T\`)
+		case '~':
+			b.WriteString(`\R\`)
+		case '\r':
+			b.WriteString(`\X0D\`)
+		case '\n':
+			b.WriteString(`\X0A\`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func msh(h Header, messageType, event, structure string) string {
+	// MSH-1（フィールドの区切り文字）は区切り文字そのものを兼ねるため、MSH-2から並べる
+	return segment("MSH", encodingChars, Escape(h.SendingApplication), Escape(h.SendingFacility),
+		Escape(h.ReceivingApplication), Escape(h.ReceivingFacility), formatTime(h.At), "",
+		messageType+"^"+event+"^"+structure, Escape(h.ControlID), Escape(h.ProcessingID), Version,
+		"", "", "AL", "NE", "JPN", "UNICODE UTF-8")
+}
+
+func pid(h Header, p Patient) string {
+	identifier := component(p.ID, "", "", h.SendingFacility, "PI")
+	sex := p.Sex
+	if sex == "" {
+		sex = "U"
+	}
+	return segment("PID", "1", "", identifier, "", component(p.FamilyName, p.GivenName), "",
+		formatDate(p.Birthdate), sex, "", "", component(p.Address), "", Escape(p.Phone))
+}
+
+func nk1(k NextOfKin) string {
+	return segment("NK1", "1", component(k.FamilyName, k.GivenName), component(k.Relationship), "", Escape(k.Phone))
+}
+
+func pv1(v *Visit) string {
+	// 患者の登録・変更は診療を伴わない（N: 該当なし）
+	if v == nil {
+		return segment("PV1", "1", "N")
+	}
+	fields := make([]string, 45)
+	fields[0] = "1"
+	fields[1] = "O" // 外来
+	fields[6] = component(v.DoctorID, v.DoctorFamily, v.DoctorGiven)
+	fields[18] = Escape(v.ID)
+	fields[43] = formatTime(v.Start)
+	if v.End != nil {
+		fields[44] = formatTime(*v.End)
+	}
+	return segment("PV1", fields...)
+}
+
+// segment セグメントの作成（フィールドは作成済みの値、末尾の空のフィールドは省く）
+func segment(name string, fields ...string) string {
+	last := len(fields)
+	for last > 0 && fields[last-1] == "" {
+		last--
+	}
+	return strings.Join(append([]string{name}, fields[:last]...), "|")
+}
+
+// component 成分（^区切り）の作成（各成分の値はエスケープする）
+func component(parts ...string) string {
+	escaped := make([]string, len(parts))
+	for i, part := range parts {
+		escaped[i] = Escape(part)
+	}
+	return strings.TrimRight(strings.Join(escaped, "^"), "^")
+}
+
+func encode(segments []string) string {
+	return strings.Join(segments, segmentTerminator) + segmentTerminator
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(timestampLayout)
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(dateLayout)
+}
+
+// SplitName 氏名を姓・名に分ける（空白で区切られていない場合はすべて姓とする）
+func SplitName(name string) (family, given string) {
+	fields := strings.Fields(strings.ReplaceAll(name, "　", " "))
+	if len(fields) == 0 {
+		return "", ""
+	}
+	return fields[0], strings.Join(fields[1:], " ")
+}
@@ -0,0 +1,170 @@
+package hl7
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MLLPのフレーム（開始 0x0B、終了 0x1C 0x0D）
+const (
+	mllpStart    = 0x0B
+	mllpEnd      = 0x1C
+	mllpTrailer  = 0x0D
+	maxACKBytes  = 64 * 1024
+	fileDropMode = 0o640
+)
+
+// Sender メッセージの送信方法
+type Sender interface {
+	// Send メッセージを送信し、受信側が受け付けたことを確認する
+	Send(ctx context.Context, controlID, message string) error
+}
+
+// MLLPSender MLLP（TCP）による送信（1メッセージごとに接続し、ACKを確認する）
+type MLLPSender struct {
+	address string
+	useTLS  bool
+	timeout time.Duration
+}
+
+func NewMLLPSender(host string, port int, useTLS bool, timeout time.Duration) *MLLPSender {
+	return &MLLPSender{
+		address: net.JoinHostPort(host, fmt.Sprint(port)),
+		useTLS:  useTLS,
+		timeout: timeout,
+	}
+}
+
+func (s *MLLPSender) Send(ctx context.Context, controlID, message string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var conn net.Conn
+	var err error
+	if s.useTLS {
+		dialer := &tls.Dialer{}
+		conn, err = dialer.DialContext(ctx, "tcp", s.address)
+	} else {
+		dialer := &net.Dialer{}
+		conn, err = dialer.DialContext(ctx, "tcp", s.address)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	frame := make([]byte, 0, len(message)+3)
+	frame = append(frame, mllpStart)
+	frame = append(frame, message...)
+	frame = append(frame, mllpEnd, mllpTrailer)
+	if _, err := conn.Write(frame); err != nil {
+		return err
+	}
+
+	ack, err := readFrame(bufio.NewReader(conn))
+	if err != nil {
+		return fmt.Errorf("failed to read ACK: %v", err)
+	}
+	return CheckACK(ack, controlID)
+}
+
+func readFrame(r *bufio.Reader) (string, error) {
+	if _, err := r.ReadBytes(mllpStart); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if c == mllpEnd {
+			if next, err := r.ReadByte(); err == nil && next != mllpTrailer {
+				return "", errors.New("invalid MLLP frame trailer")
+			}
+			return b.String(), nil
+		}
+		if b.Len() >= maxACKBytes {
+			return "", errors.New("ACK too large")
+		}
+		b.WriteByte(c)
+	}
+}
+
+// CheckACK ACKの確認（MSA-1がAA・CAで、MSA-2が送信したメッセージの制御番号であること）
+func CheckACK(ack, controlID string) error {
+	for _, seg := range strings.FieldsFunc(ack, func(r rune) bool { return r == '\r' || r == '\n' }) {
+		fields := strings.Split(seg, "|")
+		if fields[0] != "MSA" {
+			continue
+		}
+		code, acked, text := field(fields, 1), field(fields, 2), field(fields, 3)
+		if acked != controlID {
+			return fmt.Errorf("ACK for unexpected message: %s", acked)
+		}
+		switch code {
+		case "AA", "CA":
+			return nil
+		default:
+			if text == "" {
+				text = "no reason given"
+			}
+			return fmt.Errorf("message rejected (%s): %s", code, text)
+		}
+	}
+	return errors.New("ACK has no MSA segment")
+}
+
+func field(fields []string, i int) string {
+	if i < len(fields) {
+		return fields[i]
+	}
+	return ""
+}
+
+// FileDropSender ディレクトリへのファイルの配置（受信側が定期的に取り込む）
+// 取り込み途中のファイルを読まれないよう、一時ファイルに書き込んでから名前を変更する
+type FileDropSender struct {
+	directory string
+}
+
+func NewFileDropSender(directory string) *FileDropSender {
+	return &FileDropSender{directory: directory}
+}
+
+func (s *FileDropSender) Send(ctx context.Context, controlID, message string) error {
+	if err := os.MkdirAll(s.directory, 0o750); err != nil {
+		return err
+	}
+
+	name := filepath.Join(s.directory, controlID+".hl7")
+	temp, err := os.CreateTemp(s.directory, ".tmp-"+controlID+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.WriteString(message); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Chmod(fileDropMode); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), name)
+}
@@ -0,0 +1,108 @@
+// Package ics iCalendar（RFC 5545）形式の予定の出力（予約をカレンダーアプリに取り込むために使用する）
+package ics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 1行の最大長（オクテット、超える場合は折り返す）
+const maxLineOctets = 75
+
+// Event 予定
+type Event struct {
+	UID         string // 予定を一意に識別するID（同じUIDで再度取り込むと更新される）
+	Sequence    int    // 更新の回数（取り込み済みの予定を更新する場合に増やす）
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	Latitude    *float64 // 緯度・経度の両方がある場合のみ GEO を出力する
+	Longitude   *float64
+	URL         string
+	Status      string        // CONFIRMED | TENTATIVE | CANCELLED（空の場合は出力しない）
+	Alarm       time.Duration // 開始の何分前に通知するか（0の場合は通知しない）
+}
+
+// Write 予定をiCalendar形式で出力する
+func Write(w io.Writer, prodID string, events ...Event) error {
+	var buf bytes.Buffer
+	writeLine(&buf, "BEGIN:VCALENDAR")
+	writeLine(&buf, "VERSION:2.0")
+	writeLine(&buf, "PRODID:"+escapeText(prodID))
+	writeLine(&buf, "CALSCALE:GREGORIAN")
+	writeLine(&buf, "METHOD:PUBLISH")
+
+	stamp := formatTime(time.Now())
+	for _, event := range events {
+		writeLine(&buf, "BEGIN:VEVENT")
+		writeLine(&buf, "UID:"+escapeText(event.UID))
+		writeLine(&buf, "DTSTAMP:"+stamp)
+		writeLine(&buf, fmt.Sprintf("SEQUENCE:%d", event.Sequence))
+		writeLine(&buf, "DTSTART:"+formatTime(event.Start))
+		writeLine(&buf, "DTEND:"+formatTime(event.End))
+		writeLine(&buf, "SUMMARY:"+escapeText(event.Summary))
+		if event.Description != "" {
+			writeLine(&buf, "DESCRIPTION:"+escapeText(event.Description))
+		}
+		if event.Location != "" {
+			writeLine(&buf, "LOCATION:"+escapeText(event.Location))
+		}
+		if event.Latitude != nil && event.Longitude != nil {
+			writeLine(&buf, fmt.Sprintf("GEO:%.6f;%.6f", *event.Latitude, *event.Longitude))
+		}
+		if event.URL != "" {
+			writeLine(&buf, "URL:"+event.URL)
+		}
+		if event.Status != "" {
+			writeLine(&buf, "STATUS:"+event.Status)
+		}
+		if event.Alarm > 0 {
+			writeLine(&buf, "BEGIN:VALARM")
+			writeLine(&buf, "ACTION:DISPLAY")
+			writeLine(&buf, "DESCRIPTION:"+escapeText(event.Summary))
+			writeLine(&buf, fmt.Sprintf("TRIGGER:-PT%dM", int(event.Alarm.Minutes())))
+			writeLine(&buf, "END:VALARM")
+		}
+		writeLine(&buf, "END:VEVENT")
+	}
+
+	writeLine(&buf, "END:VCALENDAR")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// formatTime UTCの日時（例: 20240102T030405Z）
+func formatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escapeText TEXT型の値のエスケープ
+func escapeText(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", `\n`)
+	return replacer.Replace(value)
+}
+
+// writeLine 1行の出力（75オクテットを超える場合は文字の途中で切らないように折り返す）
+func writeLine(buf *bytes.Buffer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// 継続行は先頭の空白の分だけ短くする
+		limit = maxLineOctets - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,149 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"strings"
+)
+
+// Blurhashを求める際の縮小後の最大の辺の長さ（プレースホルダーには細部は不要）
+const blurhashSampleSize = 32
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// Blurhash 画像のプレースホルダーの文字列（横長は4×3、縦長は3×4の成分）
+func Blurhash(img image.Image) string {
+	bounds := img.Bounds()
+	if bounds.Dx() <= 0 || bounds.Dy() <= 0 {
+		return ""
+	}
+	componentsX, componentsY := 4, 3
+	if bounds.Dy() > bounds.Dx() {
+		componentsX, componentsY = 3, 4
+	}
+
+	sample := downsample(img, blurhashSampleSize)
+	w, h := sample.Bounds().Dx(), sample.Bounds().Dy()
+
+	factors := make([][3]float64, 0, componentsX*componentsY)
+	for j := 0; j < componentsY; j++ {
+		for i := 0; i < componentsX; i++ {
+			normalization := 2.0
+			if i == 0 && j == 0 {
+				normalization = 1
+			}
+			var r, g, b float64
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					basis := normalization *
+						math.Cos(math.Pi*float64(i)*float64(x)/float64(w)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(h))
+					offset := sample.PixOffset(x, y)
+					r += basis * srgbToLinear(sample.Pix[offset])
+					g += basis * srgbToLinear(sample.Pix[offset+1])
+					b += basis * srgbToLinear(sample.Pix[offset+2])
+				}
+			}
+			scale := 1 / float64(w*h)
+			factors = append(factors, [3]float64{r * scale, g * scale, b * scale})
+		}
+	}
+
+	var hash strings.Builder
+	hash.WriteString(encodeBase83((componentsX-1)+(componentsY-1)*9, 1))
+
+	maximum := 1.0
+	if len(factors) > 1 {
+		actual := 0.0
+		for _, factor := range factors[1:] {
+			for _, value := range factor {
+				actual = math.Max(actual, math.Abs(value))
+			}
+		}
+		quantised := int(math.Max(0, math.Min(82, math.Floor(actual*166-0.5))))
+		maximum = float64(quantised+1) / 166
+		hash.WriteString(encodeBase83(quantised, 1))
+	} else {
+		hash.WriteString(encodeBase83(0, 1))
+	}
+
+	dc := factors[0]
+	hash.WriteString(encodeBase83(linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4))
+	for _, factor := range factors[1:] {
+		hash.WriteString(encodeBase83(quantiseAC(factor[0], maximum)*19*19+quantiseAC(factor[1], maximum)*19+quantiseAC(factor[2], maximum), 2))
+	}
+	return hash.String()
+}
+
+// downsample 最大の辺が size 以下になるよう縮小した画像（各画素は対応する範囲の平均）
+func downsample(img image.Image, size int) *image.NRGBA {
+	bounds := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= size && h <= size {
+		return src
+	}
+	dw, dh := size, size
+	if w > h {
+		dh = max(1, h*size/w)
+	} else {
+		dw = max(1, w*size/h)
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*h/dh, max((y+1)*h/dh, y*h/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*w/dw, max((x+1)*w/dw, x*w/dw+1)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					offset := src.PixOffset(sx, sy)
+					for c := 0; c < 4; c++ {
+						sum[c] += int(src.Pix[offset+c])
+					}
+				}
+			}
+			count := (y1 - y0) * (x1 - x0)
+			offset := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8(sum[c] / count)
+			}
+		}
+	}
+	return dst
+}
+
+func srgbToLinear(value uint8) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func quantiseAC(value, maximum float64) int {
+	v := value / maximum
+	signed := math.Copysign(math.Pow(math.Abs(v), 0.5), v)
+	return int(math.Max(0, math.Min(18, math.Floor(signed*9+9.5))))
+}
+
+func encodeBase83(value, length int) string {
+	result := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		result[i] = base83Chars[value%83]
+		value /= 83
+	}
+	return string(result)
+}
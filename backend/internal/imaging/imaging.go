@@ -0,0 +1,217 @@
+// Package imaging アップロードした画像の表示用の情報の取得と、位置情報などのメタデータの除去
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// 展開後のメモリ使用量を抑えるための画素数の上限（約4000万画素）
+const maxPixels = 40_000_000
+
+// JPEGを再エンコードする際の品質
+const jpegQuality = 90
+
+var (
+	ErrInvalidImage  = errors.New("invalid image file")
+	ErrImageTooLarge = errors.New("image is too large")
+)
+
+// 形式ごとの Content-Type（image.DecodeConfig が返す形式名）
+var contentTypeFormats = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// Result メタデータを除去した画像と表示用の情報
+type Result struct {
+	Data     []byte // 再エンコードした画像（EXIF・テキストチャンクなどを含まない）
+	Width    int    // 向きを補正した後の幅
+	Height   int    // 向きを補正した後の高さ
+	Blurhash string // 読み込み中に表示するプレースホルダー
+}
+
+// Supported 処理できる画像の形式かどうか
+func Supported(contentType string) bool {
+	_, ok := contentTypeFormats[contentType]
+	return ok
+}
+
+// Process 画像の再エンコード（EXIFの向きを画素に反映してからメタデータを除き、寸法とBlurhashを求める）
+// 宣言された形式と実際の形式が異なる画像は受け付けない
+func Process(data []byte, contentType string) (*Result, error) {
+	format, ok := contentTypeFormats[contentType]
+	if !ok {
+		return nil, ErrInvalidImage
+	}
+	config, actual, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || actual != format {
+		return nil, ErrInvalidImage
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrInvalidImage
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	var buf bytes.Buffer
+	var preview image.Image
+	switch format {
+	case "jpeg":
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrInvalidImage
+		}
+		img = applyOrientation(img, jpegOrientation(data))
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+		preview = img
+	case "png":
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrInvalidImage
+		}
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		preview = img
+	case "gif":
+		// アニメーションはフレームと表示間隔のみ残す（コメント・アプリケーション拡張は除く）
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil || len(anim.Image) == 0 {
+			return nil, ErrInvalidImage
+		}
+		// フレーム数の多いアニメーションは再エンコードに時間がかかるため、全フレームの画素数も制限する
+		if config.Width*config.Height*len(anim.Image) > maxPixels*4 {
+			return nil, ErrImageTooLarge
+		}
+		if err := gif.EncodeAll(&buf, anim); err != nil {
+			return nil, err
+		}
+		preview = anim.Image[0]
+	}
+
+	bounds := preview.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if format == "gif" {
+		width, height = config.Width, config.Height
+	}
+	return &Result{
+		Data:     buf.Bytes(),
+		Width:    width,
+		Height:   height,
+		Blurhash: Blurhash(preview),
+	}, nil
+}
+
+// jpegOrientation JPEGのEXIFの向き（1〜8、ない場合は1）
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 1
+		}
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 {
+			// 画像データの開始以降にEXIFはない
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if size < 2 || pos+2+size > len(data) {
+			return 1
+		}
+		segment := data[pos+4 : pos+2+size]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return exifOrientation(segment[6:])
+		}
+		pos += 2 + size
+	}
+	return 1
+}
+
+// exifOrientation TIFF形式のEXIFのIFD0からの向き（タグ0x0112）の取得
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			value := int(order.Uint16(tiff[entry+8:]))
+			if value < 1 || value > 8 {
+				return 1
+			}
+			return value
+		}
+	}
+	return 1
+}
+
+// applyOrientation EXIFの向きの画素への反映（反転・回転）
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	bounds := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	w, h := bounds.Dx(), bounds.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], src.Pix[src.PixOffset(x, y):src.PixOffset(x, y)+4])
+		}
+	}
+	return dst
+}
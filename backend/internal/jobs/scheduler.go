@@ -0,0 +1,122 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job 定期実行ジョブ
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func() error
+
+	running *sync.Mutex
+}
+
+// Observer ジョブの実行結果の通知先（失敗の監視等）
+type Observer interface {
+	JobFinished(name string, err error)
+}
+
+// Scheduler 定期実行ジョブのスケジューラー
+type Scheduler struct {
+	jobs      []Job
+	observers []Observer
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		stop: make(chan struct{}),
+	}
+}
+
+// Register ジョブの登録（Start前に呼び出す）
+func (s *Scheduler) Register(name string, interval time.Duration, run func() error) {
+	if interval <= 0 {
+		log.Printf("Job %s disabled (interval=%s)", name, interval)
+		return
+	}
+	s.jobs = append(s.jobs, Job{Name: name, Interval: interval, Run: run, running: &sync.Mutex{}})
+}
+
+// Observe 実行結果の通知先の登録（Start前に呼び出す）
+func (s *Scheduler) Observe(observer Observer) {
+	s.observers = append(s.observers, observer)
+}
+
+// Start 登録済みジョブの実行を開始する
+func (s *Scheduler) Start() {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(job)
+	}
+}
+
+// Stop すべてのジョブを停止し、実行中のジョブの完了を待つ
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// RunNow ジョブを即時実行する（管理者による手動実行用）
+func (s *Scheduler) RunNow(name string) bool {
+	for _, job := range s.jobs {
+		if job.Name == name {
+			go s.run(job)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Scheduler) loop(job Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.run(job)
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *Scheduler) run(job Job) {
+	// 同一ジョブの多重実行を防ぐ
+	if !job.running.TryLock() {
+		log.Printf("Job %s is already running, skipped", job.Name)
+		return
+	}
+	defer job.running.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %s panicked: %v", job.Name, r)
+			s.notify(job.Name, fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	started := time.Now()
+	if err := job.Run(); err != nil {
+		log.Printf("Job %s failed: %v", job.Name, err)
+		s.notify(job.Name, err)
+		return
+	}
+	log.Printf("Job %s completed in %s", job.Name, time.Since(started))
+	s.notify(job.Name, nil)
+}
+
+func (s *Scheduler) notify(name string, err error) {
+	for _, observer := range s.observers {
+		observer.JobFinished(name, err)
+	}
+}
@@ -0,0 +1,324 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message 送信するメール（本文はテキスト形式）
+type Message struct {
+	To          string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// Attachment メールに添付するファイル
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Provider メールの送信事業者
+type Provider interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config メール送信の設定
+type Config struct {
+	Provider string // none | smtp | sendgrid
+	From     string // 差出人のメールアドレス
+	FromName string // 差出人の表示名
+
+	SMTPHost     string
+	SMTPPort     int // 465は接続時からTLS、それ以外はSTARTTLSに対応していれば使用する
+	SMTPUsername string
+	SMTPPassword string
+
+	SendGridAPIURL string
+	SendGridAPIKey string
+
+	Timeout time.Duration
+}
+
+// NewProvider 設定に応じた事業者の作成（noneの場合はnilを返し、メールを送信しない）
+func NewProvider(cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case "", "none":
+		return nil, nil
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, errors.New("SMTP host is required for the smtp mail provider")
+		}
+	case "sendgrid":
+		if cfg.SendGridAPIKey == "" {
+			return nil, errors.New("SendGrid API key is required for the sendgrid mail provider")
+		}
+	default:
+		return nil, fmt.Errorf("unknown mail provider: %s", cfg.Provider)
+	}
+
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return nil, fmt.Errorf("invalid mail sender address: %v", err)
+	}
+	if cfg.Provider == "smtp" {
+		return NewSMTPProvider(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From, cfg.FromName, cfg.Timeout), nil
+	}
+	return NewSendGridProvider(cfg.SendGridAPIURL, cfg.SendGridAPIKey, cfg.From, cfg.FromName, cfg.Timeout), nil
+}
+
+// SMTPProvider SMTPサーバーからの送信（ユーザー名を設定した場合はPLAIN認証、TLS接続時のみ）
+type SMTPProvider struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	fromName string
+	timeout  time.Duration
+}
+
+func NewSMTPProvider(host string, port int, username, password, from, fromName string, timeout time.Duration) *SMTPProvider {
+	if port == 0 {
+		port = 587
+	}
+	return &SMTPProvider{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+		fromName: fromName,
+		timeout:  timeout,
+	}
+}
+
+func (p *SMTPProvider) Name() string { return "smtp" }
+
+func (p *SMTPProvider) Send(ctx context.Context, msg Message) error {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	address := net.JoinHostPort(p.host, strconv.Itoa(p.port))
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	tlsConfig := &tls.Config{ServerName: p.host}
+	if p.port == 465 {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, p.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && p.port != 465 {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if p.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", p.username, p.password, p.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(p.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(p.compose(msg)); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// compose ヘッダーと本文（UTF-8、Base64）からメッセージを組み立てる
+// 添付ファイルがある場合は multipart/mixed で本文の後に添付する
+func (p *SMTPProvider) compose(msg Message) []byte {
+	from := (&mail.Address{Name: p.fromName, Address: p.from}).String()
+	headers := []string{
+		"From: " + from,
+		"To: " + msg.To,
+		"Subject: " + mime.BEncoding.Encode("UTF-8", msg.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + p.messageID(),
+		"MIME-Version: 1.0",
+	}
+
+	var buf bytes.Buffer
+	if len(msg.Attachments) == 0 {
+		headers = append(headers, "Content-Type: text/plain; charset=UTF-8", "Content-Transfer-Encoding: base64")
+		buf.WriteString(strings.Join(headers, "\r\n"))
+		buf.WriteString("\r\n\r\n")
+		writeBase64Lines(&buf, []byte(msg.Body))
+		return buf.Bytes()
+	}
+
+	boundary := p.boundary()
+	headers = append(headers, fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q", boundary))
+	buf.WriteString(strings.Join(headers, "\r\n"))
+	buf.WriteString("\r\n\r\n")
+
+	buf.WriteString("--" + boundary + "\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\nContent-Transfer-Encoding: base64\r\n\r\n")
+	writeBase64Lines(&buf, []byte(msg.Body))
+	for _, attachment := range msg.Attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		filename := mime.BEncoding.Encode("UTF-8", attachment.Filename)
+		buf.WriteString("--" + boundary + "\r\n")
+		buf.WriteString(fmt.Sprintf("Content-Type: %s; name=%q\r\n", contentType, filename))
+		buf.WriteString(fmt.Sprintf("Content-Disposition: attachment; filename=%q\r\n", filename))
+		buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+		writeBase64Lines(&buf, attachment.Data)
+	}
+	buf.WriteString("--" + boundary + "--\r\n")
+	return buf.Bytes()
+}
+
+// writeBase64Lines Base64で76文字ごとに改行して書き込む
+func writeBase64Lines(buf *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+}
+
+// boundary マルチパートの区切り（本文・添付ファイルのBase64には現れない文字を含める）
+func (p *SMTPProvider) boundary() string {
+	random := make([]byte, 12)
+	rand.Read(random)
+	return "=_" + hex.EncodeToString(random)
+}
+
+func (p *SMTPProvider) messageID() string {
+	random := make([]byte, 12)
+	rand.Read(random)
+	domain := p.host
+	if at := strings.LastIndex(p.from, "@"); at >= 0 {
+		domain = p.from[at+1:]
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(random), domain)
+}
+
+// SendGridProvider SendGrid Web API v3（/v3/mail/send）からの送信
+type SendGridProvider struct {
+	apiURL   string
+	apiKey   string
+	from     string
+	fromName string
+	client   *http.Client
+}
+
+func NewSendGridProvider(apiURL, apiKey, from, fromName string, timeout time.Duration) *SendGridProvider {
+	if apiURL == "" {
+		apiURL = "https://api.sendgrid.com/v3/mail/send"
+	}
+	return &SendGridProvider{
+		apiURL:   apiURL,
+		apiKey:   apiKey,
+		from:     from,
+		fromName: fromName,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *SendGridProvider) Name() string { return "sendgrid" }
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+func (p *SendGridProvider) Send(ctx context.Context, msg Message) error {
+	payload := map[string]interface{}{
+		"personalizations": []map[string]interface{}{
+			{"to": []sendGridAddress{{Email: msg.To}}},
+		},
+		"from":    sendGridAddress{Email: p.from, Name: p.fromName},
+		"subject": msg.Subject,
+		"content": []map[string]string{
+			{"type": "text/plain", "value": msg.Body},
+		},
+	}
+	if len(msg.Attachments) > 0 {
+		attachments := make([]map[string]string, len(msg.Attachments))
+		for i, attachment := range msg.Attachments {
+			attachments[i] = map[string]string{
+				"content":     base64.StdEncoding.EncodeToString(attachment.Data),
+				"filename":    attachment.Filename,
+				"type":        attachment.ContentType,
+				"disposition": "attachment",
+			}
+		}
+		payload["attachments"] = attachments
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 受け付けた場合は202を返す
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("SendGrid API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
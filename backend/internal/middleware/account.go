@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountStatusChecker アカウントの利用可否の確認
+type AccountStatusChecker interface {
+	IsAccountActive(userID uint) (bool, error)
+}
+
+// RequireActiveAccount 退会済みアカウントのトークンによるリクエストを拒否するミドルウェア
+func RequireActiveAccount(checker AccountStatusChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("user_id")
+		if !exists {
+			c.Next()
+			return
+		}
+		userID, ok := value.(uint)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		active, err := checker.IsAccountActive(userID)
+		if err != nil {
+			// 確認できない場合は利用を妨げない
+			log.Printf("Warning: Failed to check account status for user %d: %v", userID, err)
+			c.Next()
+			return
+		}
+
+		if !active {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Account is deactivated",
+				"code":  "account_deactivated",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AppVersionChecker クライアントのアプリのバージョンの確認
+type AppVersionChecker interface {
+	AppUpgradeRequired(platform, version string) (status interface{}, required bool, err error)
+}
+
+// RequireSupportedAppVersion 最低バージョンより古いアプリからのリクエストを拒否するミドルウェア
+// X-App-Platform（ios/android/web）と X-App-Version のヘッダーで判定し、426とアップデートの案内を返す
+// ヘッダーのないリクエスト・exemptPrefixes に一致するパスは対象外
+func RequireSupportedAppVersion(checker AppVersionChecker, exemptPrefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, prefix := range exemptPrefixes {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		version := c.GetHeader("X-App-Version")
+		platform := c.GetHeader("X-App-Platform")
+		if version == "" || platform == "" {
+			c.Next()
+			return
+		}
+
+		status, required, err := checker.AppUpgradeRequired(platform, version)
+		if err != nil {
+			// 確認できない場合は利用を妨げない
+			log.Printf("Warning: Failed to check app version %s/%s: %v", platform, version, err)
+			c.Next()
+			return
+		}
+
+		if required {
+			c.JSON(http.StatusUpgradeRequired, gin.H{
+				"error":   "This version of the app is no longer supported",
+				"code":    "app_upgrade_required",
+				"upgrade": status,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditRecorder 監査ログの書き込み先
+type AuditRecorder interface {
+	CreateAuditLog(userID *uint, action, entity, entityID string, meta interface{}) error
+}
+
+// 監査ログに記録するリクエストボディの最大サイズ
+const auditBodyLimit = 64 * 1024
+
+// 監査ログに記録する文字列値の最大長
+const auditValueLimit = 200
+
+// 監査ログでマスクするキー（部分一致、小文字で比較）
+var auditSensitiveKeys = []string{"password", "token", "secret", "otp", "answer"}
+
+// 動詞として扱うパスの末尾セグメント
+var auditActionSegments = map[string]bool{
+	"cancel":      true,
+	"status":      true,
+	"read":        true,
+	"join":        true,
+	"start":       true,
+	"end":         true,
+	"answer":      true,
+	"login":       true,
+	"acknowledge": true,
+	"resolve":     true,
+	"assign":      true,
+	"publish":     true,
+	"accept":      true,
+	"decline":     true,
+	"consent":     true,
+	"close":       true,
+	"skip":        true,
+	"merge":       true,
+	"complete":    true,
+	"reopen":      true,
+	"review":      true,
+	"visibility":  true,
+	"confirm":     true,
+	"verify":      true,
+	"reactivate":  true,
+	"unlock":      true,
+	"withdraw":    true,
+	"ack":         true,
+	"flag":        true,
+	"reset":       true,
+	"delay":       true,
+	"fix":         true,
+	"refresh":     true,
+	"stop":        true,
+}
+
+// Audit 更新系リクエスト（POST/PUT/DELETE）を自動的に監査ログへ記録するミドルウェア
+//
+// 操作者・エンティティ・エンティティID・リクエストID・レスポンスのステータスを記録するため、
+// ハンドラーごとの記録は不要（RequestID の後に登録する）。
+func Audit(recorder AuditRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if method != http.MethodPost && method != http.MethodPut && method != http.MethodDelete {
+			c.Next()
+			return
+		}
+
+		summary := summarizeRequestBody(c)
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			// 未定義のルートは記録しない
+			return
+		}
+
+		var userID *uint
+		if value, exists := c.Get("user_id"); exists {
+			if id, ok := value.(uint); ok {
+				userID = &id
+			}
+		}
+
+		action, entity, entityID := inferAuditTarget(method, route, c.Params)
+
+		params := make(map[string]string, len(c.Params))
+		for _, p := range c.Params {
+			params[p.Key] = p.Value
+		}
+
+		meta := map[string]interface{}{
+			"method":      method,
+			"route":       route,
+			"path":        c.Request.URL.Path,
+			"params":      params,
+			"status_code": c.Writer.Status(),
+			"client_ip":   c.ClientIP(),
+		}
+		if requestID := c.GetString("request_id"); requestID != "" {
+			meta["request_id"] = requestID
+		}
+		if summary != nil {
+			meta["request"] = summary
+		}
+		if len(c.Errors) > 0 {
+			meta["errors"] = c.Errors.String()
+		}
+
+		// 非同期で記録（レスポンスを遅延させない）
+		go func() {
+			if err := recorder.CreateAuditLog(userID, action, entity, entityID, meta); err != nil {
+				log.Printf("Warning: Failed to create audit log: %v", err)
+			}
+		}()
+	}
+}
+
+// inferAuditTarget ルート定義からアクション・エンティティ・エンティティIDを推定する
+func inferAuditTarget(method, route string, params gin.Params) (string, string, string) {
+	action := map[string]string{
+		http.MethodPost:   "create",
+		http.MethodPut:    "update",
+		http.MethodDelete: "delete",
+	}[method]
+
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(route, "/api/v1"), "/"), "/")
+
+	// 末尾の動詞セグメントはアクションとして扱う
+	if n := len(segments); n > 1 && auditActionSegments[segments[n-1]] {
+		action = segments[n-1]
+		segments = segments[:n-1]
+	}
+
+	entity := ""
+	entityID := ""
+	for i, segment := range segments {
+		if segment == "" || strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			continue
+		}
+		entity = singularize(segment)
+		entityID = ""
+		if i+1 < len(segments) && strings.HasPrefix(segments[i+1], ":") {
+			entityID = params.ByName(strings.TrimPrefix(segments[i+1], ":"))
+		}
+	}
+
+	if entity == "" {
+		entity = "unknown"
+	}
+	return action, entity, entityID
+}
+
+// singularize リソース名を単数形のエンティティ名に変換する
+func singularize(resource string) string {
+	resource = strings.ReplaceAll(resource, "-", "_")
+	switch {
+	case resource == "me":
+		return "user"
+	case resource == "sessions":
+		return "video_session"
+	case strings.HasSuffix(resource, "ies"):
+		return strings.TrimSuffix(resource, "ies") + "y"
+	case strings.HasSuffix(resource, "ses"):
+		return strings.TrimSuffix(resource, "es")
+	case strings.HasSuffix(resource, "s"):
+		return strings.TrimSuffix(resource, "s")
+	}
+	return resource
+}
+
+// summarizeRequestBody リクエストボディの要約を作成する（機密情報はマスク）
+func summarizeRequestBody(c *gin.Context) interface{} {
+	contentType := c.ContentType()
+
+	if strings.HasPrefix(contentType, "multipart/form-data") {
+		// ファイル本体は読み込まず、サイズのみ記録する
+		return map[string]interface{}{
+			"content_type":   contentType,
+			"content_length": c.Request.ContentLength,
+		}
+	}
+
+	if c.Request.Body == nil || !strings.Contains(contentType, "json") {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, auditBodyLimit+1))
+	if err != nil {
+		return nil
+	}
+	// 後続のハンドラーが読めるようにボディを戻す
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+	if len(body) > auditBodyLimit {
+		return map[string]interface{}{"truncated": true, "content_length": c.Request.ContentLength}
+	}
+
+	var parsed interface{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil
+	}
+	return sanitizeValue("", parsed)
+}
+
+// sanitizeValue 機密キーのマスクと長い文字列の切り詰め
+func sanitizeValue(key string, value interface{}) interface{} {
+	if isSensitiveKey(key) {
+		return "[REDACTED]"
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		sanitized := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			sanitized[k] = sanitizeValue(k, item)
+		}
+		return sanitized
+	case []interface{}:
+		sanitized := make([]interface{}, len(v))
+		for i, item := range v {
+			sanitized[i] = sanitizeValue("", item)
+		}
+		return sanitized
+	case string:
+		if runes := []rune(v); len(runes) > auditValueLimit {
+			return string(runes[:auditValueLimit]) + "..."
+		}
+		return v
+	}
+	return value
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range auditSensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,15 +3,21 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"online_medical_consultation_app/backend/internal/policy"
 )
 
 // Auth JWT認証ミドルウェア
 func Auth(jwtSecret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+		// WebSocket接続はブラウザからヘッダーを指定できないためクエリのトークンを使用する
+		if authHeader == "" && strings.EqualFold(c.GetHeader("Upgrade"), "websocket") && c.Query("token") != "" {
+			authHeader = "Bearer " + c.Query("token")
+		}
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
 			c.Abort()
@@ -69,14 +75,24 @@ func Auth(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
+		// トークンの有効期限とログイン時刻（auth_timeがない以前のトークンは発行時刻）をセッションの更新用に設定
+		if expiresAt, err := claims.GetExpirationTime(); err == nil && expiresAt != nil {
+			c.Set("token_expires_at", expiresAt.Time)
+		}
+		if authTime, ok := claims["auth_time"].(float64); ok {
+			c.Set("session_started_at", time.Unix(int64(authTime), 0))
+		} else if issuedAt, err := claims.GetIssuedAt(); err == nil && issuedAt != nil {
+			c.Set("session_started_at", issuedAt.Time)
+		}
+
 		c.Set("user_id", uint(userID))
 		c.Set("user_role", role)
 		c.Next()
 	}
 }
 
-// RequireRole 特定のロールを要求するミドルウェア
-func RequireRole(requiredRole string) gin.HandlerFunc {
+// RequireRole 特定のロール（いずれか）を要求するミドルウェア
+func RequireRole(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("user_role")
 		if !exists {
@@ -85,7 +101,8 @@ func RequireRole(requiredRole string) gin.HandlerFunc {
 			return
 		}
 
-		if userRole != requiredRole {
+		role, _ := userRole.(string)
+		if !policy.Allows(role, allowedRoles...) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 			c.Abort()
 			return
@@ -97,10 +114,15 @@ func RequireRole(requiredRole string) gin.HandlerFunc {
 
 // RequirePatient 患者ロールを要求するミドルウェア
 func RequirePatient() gin.HandlerFunc {
-	return RequireRole("patient")
+	return RequireRole(policy.RolePatient)
 }
 
 // RequireDoctor 医師ロールを要求するミドルウェア
 func RequireDoctor() gin.HandlerFunc {
-	return RequireRole("doctor")
+	return RequireRole(policy.RoleDoctor)
+}
+
+// RequireAdmin 管理者ロールを要求するミドルウェア
+func RequireAdmin() gin.HandlerFunc {
+	return RequireRole(policy.RoleAdmin)
 }
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LegalAcceptanceChecker 未同意の法的文書の確認
+type LegalAcceptanceChecker interface {
+	PendingLegalDocuments(userID uint) (documents interface{}, pending bool, err error)
+}
+
+// RequireLegalAcceptance 最新の利用規約等に同意していないユーザーのリクエストを拒否するミドルウェア
+// 451を返し、同意が必要な文書をレスポンスに含める（exemptPrefixes に一致するパスは対象外）
+func RequireLegalAcceptance(checker LegalAcceptanceChecker, exemptPrefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, prefix := range exemptPrefixes {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		value, exists := c.Get("user_id")
+		if !exists {
+			c.Next()
+			return
+		}
+		userID, ok := value.(uint)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		documents, pending, err := checker.PendingLegalDocuments(userID)
+		if err != nil {
+			// 確認できない場合は利用を妨げない
+			log.Printf("Warning: Failed to check legal acceptance for user %d: %v", userID, err)
+			c.Next()
+			return
+		}
+
+		if pending {
+			c.JSON(http.StatusUnavailableForLegalReasons, gin.H{
+				"error":     "Acceptance of the latest terms is required",
+				"code":      "legal_acceptance_required",
+				"documents": documents,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1,20 +1,29 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// CORS CORS設定ミドルウェア
-func CORS() gin.HandlerFunc {
+// CORS CORS設定ミドルウェア（許可したオリジンからのリクエストにのみ Access-Control-Allow-Origin を返す）
+func CORS(allowedOrigins []string) gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Header("Vary", "Origin")
+		if origin := c.GetHeader("Origin"); origin != "" && originAllowed(allowedOrigins, origin) {
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-App-Platform, X-App-Version")
 		c.Header("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		// スライディング方式で更新したアクセストークン・エクスポートの再取得用のトークンをブラウザから読めるようにする
+		c.Header("Access-Control-Expose-Headers", RefreshedTokenHeader+", "+RefreshedTokenExpiresHeader+", X-Download-Token, X-Download-Expires-At, Content-Disposition, X-Request-ID")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -25,14 +34,33 @@ func CORS() gin.HandlerFunc {
 	})
 }
 
+// CheckOrigin WebSocket接続のオリジンの確認（CORSと同じ許可したオリジンのみ接続できる）
+// Originヘッダーを送らないブラウザ以外のクライアント（モバイルアプリ等）は認証のトークンで確認する
+func CheckOrigin(allowedOrigins []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		return origin == "" || originAllowed(allowedOrigins, origin)
+	}
+}
+
+func originAllowed(allowedOrigins []string, origin string) bool {
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
 // Logger カスタムログミドルウェア
+// WebSocket接続のトークン（?token=）・検索条件を記録しないよう、クエリ文字列を除いたパスのみ記録する
 func Logger() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\"\n",
 			param.ClientIP,
 			param.TimeStamp.Format(time.RFC1123),
 			param.Method,
-			param.Path,
+			param.Request.URL.Path,
 			param.Request.Proto,
 			param.StatusCode,
 			param.Latency,
@@ -52,11 +80,14 @@ func Recovery() gin.HandlerFunc {
 	})
 }
 
+// クライアントが指定できるリクエストIDの形式（監査ログ・ログに記録するため長さと文字を制限する）
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
 // RequestID リクエストIDを生成するミドルウェア
 func RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
+		if !requestIDPattern.MatchString(requestID) {
 			requestID = generateRequestID()
 		}
 		c.Header("X-Request-ID", requestID)
@@ -76,6 +107,9 @@ func RateLimit() gin.HandlerFunc {
 
 
 func generateRequestID() string {
-	// UUIDの生成は後で実装
-	return "req-" + time.Now().Format("20060102150405")
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "req-" + time.Now().Format("20060102150405.000000000")
+	}
+	return "req-" + hex.EncodeToString(b)
 }
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoggerOmitsQueryString(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var out bytes.Buffer
+	defaultWriter := gin.DefaultWriter
+	gin.DefaultWriter = &out
+	t.Cleanup(func() { gin.DefaultWriter = defaultWriter })
+
+	router := gin.New()
+	router.Use(Logger())
+	router.GET("/ws", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ws?token=secret-jwt", nil))
+
+	if !strings.Contains(out.String(), "GET /ws ") {
+		t.Fatalf("log line %q does not contain the request path", out.String())
+	}
+	if strings.Contains(out.String(), "secret-jwt") {
+		t.Errorf("log line %q contains the query string token", out.String())
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationAuthorizer 組織内で委任されたロールによる権限の確認
+type OrganizationAuthorizer interface {
+	HasOrgPermission(userID, organizationID uint, permission string) (bool, error)
+}
+
+// RequireOrgPermission パスの組織（:orgId）で操作の権限を持つ利用者のみ許可するミドルウェア
+//
+// 管理者は常に許可し、スタッフは組織の管理者から委任されたロールが許可する操作のみ許可する
+// （ロールごとの権限は policy パッケージで定義する）。
+func RequireOrgPermission(authorizer OrganizationAuthorizer, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+		userID, _ := value.(uint)
+
+		organizationID, err := strconv.ParseUint(c.Param("orgId"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+			c.Abort()
+			return
+		}
+
+		allowed, err := authorizer.HasOrgPermission(userID, uint(organizationID), permission)
+		if err != nil {
+			log.Printf("Warning: Failed to check organization permission for user %d: %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// ActivityRecorder ユーザーのアクティビティの記録先（オンライン状態の判定用）
+type ActivityRecorder interface {
+	RecordActivity(userID uint)
+}
+
+// TrackActivity 認証済みリクエストを最終アクティビティとして記録するミドルウェア
+func TrackActivity(recorder ActivityRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if value, exists := c.Get("user_id"); exists {
+			if userID, ok := value.(uint); ok {
+				recorder.RecordActivity(userID)
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"online_medical_consultation_app/backend/internal/quota"
+)
+
+// Quota ファイルのアップロード・エクスポート等の重い処理の利用回数の制限（認証前のログインではIPアドレスごとにのみ制限する）
+// 上限に達した場合は429を返す。記録先の障害時は利用を妨げないよう制限せずに通す
+func Quota(limiter *quota.Limiter, rule quota.Rule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var userID uint
+		if id, exists := c.Get("user_id"); exists {
+			userID = id.(uint)
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), time.Second)
+		defer cancel()
+		result, err := limiter.Allow(ctx, rule, userID, c.ClientIP())
+		if err != nil {
+			log.Printf("Warning: Quota check for %s failed: %v", rule.Name, err)
+			c.Next()
+			return
+		}
+
+		if result.Remaining >= 0 {
+			c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		}
+		if !result.Allowed {
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Too many " + rule.Name + ", please try again later",
+				"scope":       result.Scope,
+				"retry_after": retryAfter,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RefreshedTokenHeader 更新したアクセストークンを返すレスポンスヘッダー（以降のリクエストではこのトークンを使用する）
+const RefreshedTokenHeader = "X-Refreshed-Token"
+
+// RefreshedTokenExpiresHeader 更新したアクセストークンの有効期限（RFC3339）
+const RefreshedTokenExpiresHeader = "X-Refreshed-Token-Expires-At"
+
+// SessionRefresher ログインのセッションの確認・アクセストークンの更新
+type SessionRefresher interface {
+	IsSessionActive(sessionStart time.Time) bool
+	RefreshSession(userID uint, role string, sessionStart, expiresAt time.Time) (string, time.Time, error)
+}
+
+// SlidingSession 利用中のユーザーのアクセストークンを有効期限の前に更新するミドルウェア（Authの後に使用する）
+// 更新したトークンはレスポンスヘッダーで返す。ログインからの最大有効期間を過ぎたトークンは拒否する
+func SlidingSession(refresher SessionRefresher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, hasUser := c.Get("user_id")
+		role, hasRole := c.Get("user_role")
+		sessionStart, hasStart := c.Get("session_started_at")
+		expiresAt, hasExpiry := c.Get("token_expires_at")
+		if !hasUser || !hasRole || !hasStart || !hasExpiry {
+			c.Next()
+			return
+		}
+
+		if !refresher.IsSessionActive(sessionStart.(time.Time)) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Session expired",
+				"code":  "session_expired",
+			})
+			c.Abort()
+			return
+		}
+
+		// WebSocket接続はレスポンスヘッダーを受け取れないため更新しない
+		if strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+			c.Next()
+			return
+		}
+
+		token, newExpiresAt, err := refresher.RefreshSession(userID.(uint), role.(string), sessionStart.(time.Time), expiresAt.(time.Time))
+		if err != nil {
+			// 更新できない場合も現在のトークンの利用は妨げない
+			log.Printf("Warning: Failed to refresh session for user %v: %v", userID, err)
+		} else if token != "" {
+			c.Header(RefreshedTokenHeader, token)
+			c.Header(RefreshedTokenExpiresHeader, newExpiresAt.UTC().Format(time.RFC3339))
+		}
+
+		c.Next()
+	}
+}
@@ -12,7 +12,17 @@ type User struct {
 	ID           uint           `gorm:"primaryKey" json:"id"`
 	Email        string         `gorm:"uniqueIndex;not null" json:"email"`
 	PasswordHash string         `gorm:"not null" json:"-"`
-	Role         string         `gorm:"not null;check:role IN ('patient','doctor')" json:"role"`
+	Role         string         `gorm:"not null;check:role IN ('patient','doctor','admin','interpreter','staff')" json:"role"`
+	DeactivatedAt *time.Time    `gorm:"index" json:"deactivated_at,omitempty"` // 本人による退会（再開可能期間の起点）
+	AnonymizedAt  *time.Time    `json:"anonymized_at,omitempty"`              // 再開可能期間の経過後に個人情報を消去した日時
+	ChatSuspendedUntil *time.Time `json:"chat_suspended_until,omitempty"`     // モデレーションによるチャット送信の停止期限
+	LastSeenAt    *time.Time    `gorm:"index" json:"-"`                         // 最終アクティビティ（WebSocket接続・API利用）
+	HidePresence  bool          `gorm:"not null;default:false" json:"hide_presence"` // オンライン状態・最終アクセスを他のユーザーに表示しない
+	IsDemo        bool          `gorm:"not null;default:false;index" json:"is_demo"` // デモ用アカウント（実データから分離し、定期的に初期化する）
+	BreakGlassAuthorized bool   `gorm:"not null;default:false" json:"break_glass_authorized"` // 緊急時アクセスを許可された医師（管理者は常に許可）
+	FailedLoginCount  int        `gorm:"not null;default:0" json:"-"`      // 連続したログインの失敗回数（ログインの成功・ロックの解除で0に戻す）
+	LastFailedLoginAt *time.Time `json:"-"`
+	LockedUntil       *time.Time `json:"locked_until,omitempty"`        // ログインの失敗が続いたことによるロックの期限
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
@@ -20,6 +30,15 @@ type User struct {
 	// リレーション
 	PatientProfile *PatientProfile `gorm:"foreignKey:UserID;references:ID" json:"patient_profile,omitempty"`
 	DoctorProfile  *DoctorProfile  `gorm:"foreignKey:UserID;references:ID" json:"doctor_profile,omitempty"`
+	InterpreterProfile *InterpreterProfile `gorm:"foreignKey:UserID;references:ID" json:"interpreter_profile,omitempty"`
+}
+
+// UnknownLoginAttempt 登録されていないメールアドレスへのログインの失敗（User の失敗回数・ロックと同じ扱い、全インスタンスで共有）
+type UnknownLoginAttempt struct {
+	EmailHash         string     `gorm:"primaryKey" json:"-"` // 正規化したメールアドレスのSHA-256（入力されたメールアドレスは保存しない）
+	FailedLoginCount  int        `gorm:"not null;default:0" json:"failed_login_count"`
+	LastFailedLoginAt time.Time  `gorm:"not null;index" json:"last_failed_login_at"`
+	LockedUntil       *time.Time `json:"locked_until,omitempty"`
 }
 
 // PatientProfile 患者プロフィール
@@ -29,6 +48,12 @@ type PatientProfile struct {
 	Birthdate *time.Time     `json:"birthdate"`
 	Phone     string         `json:"phone"`
 	Address   string         `json:"address"`
+	Allergies *string        `json:"allergies"` // nil: 未回答 / 空文字: アレルギーなし
+	InsuranceProvider string `json:"insurance_provider"`
+	InsuranceNumber   string `json:"insurance_number"`
+	PricingRegion     string `json:"pricing_region"` // 処方の薬価の見積もりに使う地域（JP, JP-13等、空の場合は既定の地域）
+	OnboardingStep    string `gorm:"not null;default:'basic_info'" json:"onboarding_step"`
+	OnboardingCompletedAt *time.Time `json:"onboarding_completed_at"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -44,6 +69,11 @@ type DoctorProfile struct {
 	Specialty     string         `json:"specialty"`
 	LicenseNumber string         `json:"license_number"`
 	Bio           string         `json:"bio"`
+	Languages     string         `gorm:"not null;default:'ja'" json:"languages"` // 診療可能な言語コード（カンマ区切り、例: "ja,en"）
+	AcceptsInstant bool          `gorm:"not null;default:false" json:"accepts_instant"` // 即時診療の受付中（オンライン）
+	LastSeenAt    *time.Time     `json:"last_seen_at"`                                  // 最終ハートビート
+	IntakeLeadHours int          `gorm:"not null;default:0" json:"intake_lead_hours"`   // 診療開始の何時間前までに問診の完了を求めるか（0: 求めない）
+	ConsultationFee int64        `gorm:"not null;default:0" json:"consultation_fee"`    // 診療費（決済通貨の最小単位、0: 請求しない）
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
@@ -52,13 +82,59 @@ type DoctorProfile struct {
 	User User `gorm:"foreignKey:UserID;references:ID" json:"user"`
 }
 
+// InterpreterProfile 医療通訳者プロフィール
+type InterpreterProfile struct {
+	UserID    uint           `gorm:"primaryKey" json:"user_id"`
+	Name      string         `gorm:"not null" json:"name"`
+	Languages string         `gorm:"not null" json:"languages"` // 対応言語コード（カンマ区切り、例: "en,zh,vi"）
+	Bio       string         `json:"bio"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// リレーション
+	User User `gorm:"foreignKey:UserID;references:ID" json:"user"`
+}
+
+// InterpreterSlot 通訳者の対応可能枠
+type InterpreterSlot struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	InterpreterID uint           `gorm:"not null;index" json:"interpreter_id"`
+	StartTime     time.Time      `gorm:"not null" json:"start_time"`
+	EndTime       time.Time      `gorm:"not null" json:"end_time"`
+	AppointmentID *uint          `gorm:"index" json:"appointment_id"` // 割り当て済みの予約
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// リレーション
+	Interpreter User `gorm:"foreignKey:InterpreterID;references:ID" json:"-"`
+}
+
+// Dependent 家族アカウント（患者が管理する被扶養者：子ども・高齢の親など）
+type Dependent struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	GuardianID   uint           `gorm:"not null;index" json:"guardian_id"`
+	Name         string         `gorm:"not null" json:"name"`
+	Birthdate    *time.Time     `json:"birthdate"`
+	Relationship string         `gorm:"not null;check:relationship IN ('child','parent','spouse','other')" json:"relationship"`
+	Gender       string         `json:"gender"`
+	Notes        string         `json:"notes"` // アレルギー・既往歴など医師向けメモ
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// リレーション
+	Guardian User `gorm:"foreignKey:GuardianID;references:ID" json:"-"`
+}
+
 // AvailabilitySlot 診療可能枠
 type AvailabilitySlot struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	DoctorID  uint           `gorm:"not null" json:"doctor_id"`
 	StartTime time.Time      `gorm:"not null" json:"start_time"`
 	EndTime   time.Time      `gorm:"not null" json:"end_time"`
-	Status    string         `gorm:"not null;default:'open';check:status IN ('open','blocked')" json:"status"`
+	Status    string         `gorm:"not null;default:'open';check:status IN ('open','blocked','booked')" json:"status"` // 予約の作成時にbooked、キャンセル時にopenへ戻す
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -68,6 +144,35 @@ type AvailabilitySlot struct {
 	Appointment *Appointment `gorm:"foreignKey:SlotID;references:ID" json:"appointment,omitempty"`
 }
 
+// SlotTemplate 医師の毎週繰り返す診療枠のひな形（定期ジョブで指定した週数先までの診療枠を作成する）
+// 時刻は予約受付ルールのタイムゾーンで解釈する。削除しても作成済みの診療枠は残る
+type SlotTemplate struct {
+	ID                  uint           `gorm:"primaryKey" json:"id"`
+	DoctorID            uint           `gorm:"not null;index" json:"doctor_id"`
+	Weekdays            string         `gorm:"not null" json:"weekdays"`   // 曜日（0=日〜6=土、カンマ区切り）
+	StartTime           string         `gorm:"not null" json:"start_time"` // HH:MM
+	EndTime             string         `gorm:"not null" json:"end_time"`   // HH:MM（24:00まで）
+	SlotMinutes         int            `gorm:"not null;check:slot_minutes BETWEEN 5 AND 240" json:"slot_minutes"`
+	WeeksAhead          int            `gorm:"not null;default:4;check:weeks_ahead BETWEEN 1 AND 12" json:"weeks_ahead"`
+	MaterializedThrough *time.Time     `json:"materialized_through,omitempty"` // 診療枠を作成済みの期間の終わり（この日以降が未作成）
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// DoctorAutoReply 医師の時間外の自動返信の設定（時刻は予約受付ルールのタイムゾーンで解釈する）
+// 対応時間外に患者からメッセージが届いた場合は自動返信し、医師への通知を次の対応時間の開始まで遅らせる
+type DoctorAutoReply struct {
+	DoctorID       uint      `gorm:"primaryKey" json:"doctor_id"`
+	Enabled        bool      `gorm:"not null" json:"enabled"`
+	Message        string    `gorm:"type:text;not null" json:"message"`    // {{next_available}} は次の対応時間の開始日時に置き換える
+	ActiveStart    string    `gorm:"not null" json:"active_start"`         // HH:MM
+	ActiveEnd      string    `gorm:"not null" json:"active_end"`           // HH:MM（24:00まで）
+	ActiveWeekdays string    `gorm:"not null" json:"active_weekdays"`      // 対応する曜日（0=日〜6=土、カンマ区切り）
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
 // MarshalJSON カスタムJSONマーシャリング
 func (s AvailabilitySlot) MarshalJSON() ([]byte, error) {
 	type Alias AvailabilitySlot
@@ -92,8 +197,32 @@ type Appointment struct {
 	PatientID uint           `gorm:"not null" json:"patient_id"`
 	DoctorID  uint           `gorm:"not null" json:"doctor_id"`
 	SlotID    *uint          `json:"slot_id"`
+	ScheduledStart *time.Time `gorm:"index" json:"scheduled_start,omitempty"` // 予約した日時（診療枠の時刻、枠のない即時・非同期相談はなし）
+	ScheduledEnd   *time.Time `json:"scheduled_end,omitempty"`
+	DependentID *uint        `gorm:"index" json:"dependent_id"` // 家族（被扶養者）の代理予約の場合
 	Status    string         `gorm:"not null;default:'pending';check:status IN ('pending','confirmed','cancelled','completed')" json:"status"`
 	Notes     string         `json:"notes"`
+	IsUrgent  bool           `gorm:"not null;default:false" json:"is_urgent"`
+	InterpreterLanguage string `json:"interpreter_language"` // 通訳を依頼した言語コード
+	InterpreterID *uint        `gorm:"index" json:"interpreter_id"`
+	ConsultationLanguage string `json:"consultation_language,omitempty"` // 患者が希望した診療言語コード（医師が対応しない場合は通訳を手配）
+	IsInstant bool           `gorm:"not null;default:false" json:"is_instant"` // 枠を選ばずに即時開始する診療
+	CancelReason string      `json:"cancel_reason,omitempty"` // 自動辞退の理由（doctor_no_response / doctor_time_off / intake_incomplete）
+	IntakeDueAt       *time.Time `gorm:"index" json:"intake_due_at,omitempty"` // 問診の提出期限（医師が事前の問診を求める場合）
+	IntakeCompletedAt *time.Time `json:"intake_completed_at,omitempty"`
+	IntakeRemindedAt  *time.Time `json:"-"`
+	ReminderSentAt    *time.Time `json:"-"` // 診療開始前のリマインドの送信日時
+	IsAsync   bool           `gorm:"not null;default:false" json:"is_async"` // 日時を決めないチャットでの非同期相談
+	ResponseDueAt   *time.Time `gorm:"index" json:"response_due_at,omitempty"` // 非同期相談の回答期限（SLA）
+	FirstResponseAt *time.Time `json:"first_response_at,omitempty"`
+	SLABreachedAt   *time.Time `json:"sla_breached_at,omitempty"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"` // 診療の完了日時（診療後のチャットの利用期間の起点）
+	DelayMinutes     int        `gorm:"not null;default:0" json:"delay_minutes"` // 医師が連絡した開始の遅れ（定時性の集計に使用）
+	DelayReportedAt  *time.Time `json:"delay_reported_at,omitempty"`
+	EstimatedStartAt *time.Time `json:"estimated_start_at,omitempty"` // 遅れを反映した開始見込み時刻（待合室の表示用）
+	VisitType        string     `gorm:"not null;default:'online';check:visit_type IN ('online','in_person')" json:"visit_type"` // 診療の形式（対面の場合は受診する所在地を指定する）
+	LocationID       *uint      `gorm:"index" json:"location_id,omitempty"`
+	Tags             []Tag      `gorm:"-" json:"tags,omitempty"` // 医師が付けたタグ（医師の予約一覧でのみ読み込む）
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -102,9 +231,95 @@ type Appointment struct {
 	Patient       User            `gorm:"foreignKey:PatientID;references:ID" json:"patient"`
 	Doctor        User            `gorm:"foreignKey:DoctorID;references:ID" json:"doctor"`
 	Slot          *AvailabilitySlot `gorm:"foreignKey:SlotID;references:ID" json:"slot,omitempty"`
+	Dependent     *Dependent      `gorm:"foreignKey:DependentID;references:ID" json:"dependent,omitempty"`
+	Location      *ClinicLocation `gorm:"foreignKey:LocationID;references:ID" json:"location,omitempty"`
+	Interpreter   *User           `gorm:"foreignKey:InterpreterID;references:ID" json:"interpreter,omitempty"`
+	Triage        *TriageAssessment `gorm:"foreignKey:AppointmentID;references:ID" json:"triage,omitempty"`
 	Messages      []Message       `gorm:"foreignKey:AppointmentID;references:ID" json:"messages,omitempty"`
 	Prescriptions []Prescription  `gorm:"foreignKey:AppointmentID;references:ID" json:"prescriptions,omitempty"`
 	VideoSessions []VideoSession  `gorm:"foreignKey:AppointmentID;references:ID" json:"video_sessions,omitempty"`
+	SharedDocuments []AppointmentDocumentGrant `gorm:"foreignKey:AppointmentID;references:ID" json:"shared_documents,omitempty"`
+}
+
+// IsParticipant 予約の参加者（患者・医師・通訳者）かどうか
+func (a *Appointment) IsParticipant(userID uint) bool {
+	return a.PatientID == userID || a.DoctorID == userID || (a.InterpreterID != nil && *a.InterpreterID == userID)
+}
+
+// SetSchedule 予約の日時を診療枠の時刻にする
+func (a *Appointment) SetSchedule(slot *AvailabilitySlot) {
+	start, end := slot.StartTime, slot.EndTime
+	a.ScheduledStart = &start
+	a.ScheduledEnd = &end
+}
+
+// ParticipantIDs 予約の参加者（患者・医師・通訳者）のユーザーID
+func (a *Appointment) ParticipantIDs() []uint {
+	ids := []uint{a.PatientID, a.DoctorID}
+	if a.InterpreterID != nil {
+		ids = append(ids, *a.InterpreterID)
+	}
+	return ids
+}
+
+// Tag 医師が定義する予約のタグ（例: 慢性疾患・経過観察・検査結果待ち、医師ごとに管理し患者には見せない）
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	DoctorID  uint      `gorm:"not null;uniqueIndex:idx_tags_doctor_name" json:"doctor_id"`
+	Name      string    `gorm:"not null;uniqueIndex:idx_tags_doctor_name" json:"name"`
+	Color     string    `gorm:"not null;default:''" json:"color"` // 表示色（#RRGGBB、未指定の場合は空）
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AppointmentTag 予約へのタグの付与
+type AppointmentTag struct {
+	AppointmentID uint      `gorm:"primaryKey" json:"appointment_id"`
+	TagID         uint      `gorm:"primaryKey;index" json:"tag_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AppointmentSavedView 医師の予約一覧の保存した絞り込み条件（タグ・ステータス）
+type AppointmentSavedView struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	DoctorID  uint      `gorm:"not null;index" json:"doctor_id"`
+	Name      string    `gorm:"not null" json:"name"`
+	TagIDs    string    `gorm:"not null;default:''" json:"tag_ids"`      // 絞り込むタグのID（カンマ区切り）
+	MatchAll  bool      `gorm:"not null;default:false" json:"match_all"` // すべてのタグが付いた予約に限る（falseの場合はいずれか）
+	Status    string    `gorm:"not null;default:''" json:"status"`       // 絞り込むステータス（空の場合はすべて）
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StoredObject 内容の重複を除いて保存したファイル（同じ内容のファイルは保存先ごとに1つだけ保存する）
+// 添付ファイル・診療記録は保存先のキーで参照し、参照がなくなった時点でファイルを削除する
+type StoredObject struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	Storage   string    `gorm:"not null;uniqueIndex:idx_stored_object_content" json:"storage"`  // 保存先（chat_attachments | patient_documents）
+	Checksum  string    `gorm:"not null;uniqueIndex:idx_stored_object_content" json:"checksum"` // 内容のSHA-256（16進数）
+	ObjectKey string    `gorm:"not null;uniqueIndex" json:"-"`
+	SizeBytes int64     `gorm:"not null" json:"size_bytes"`
+	RefCount  int       `gorm:"not null;default:0" json:"ref_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Attachment チャットの添付ファイル（ファイルは保存先のキーで保存し、参加者のみ取得できる）
+// 画像はEXIFなどのメタデータを除いて再エンコードした版を保存する
+type Attachment struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	AppointmentID uint      `gorm:"not null;index" json:"appointment_id"`
+	UploadedByID  uint      `gorm:"not null;index" json:"uploaded_by_id"`
+	Filename      string    `gorm:"not null" json:"filename"` // アップロードしたファイル名
+	ContentType   string    `gorm:"not null" json:"content_type"`
+	SizeBytes     int64     `gorm:"not null" json:"size_bytes"`
+	Storage       string    `gorm:"not null" json:"-"`                                  // local | s3
+	ObjectKey     string    `gorm:"not null;index:idx_attachments_object_ref" json:"-"` // 同じ内容の添付ファイルは同じキーを参照する
+	Checksum      string    `json:"checksum,omitempty"`                                 // 内容のSHA-256（16進数）
+	Width         *int      `json:"width,omitempty"`                                    // 画像の幅（EXIFの向きを補正した後）
+	Height        *int      `json:"height,omitempty"`                                   // 画像の高さ
+	Blurhash      string    `json:"blurhash,omitempty"`                                 // 画像の読み込み中に表示するプレースホルダー
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 // Message チャットメッセージ
@@ -112,9 +327,19 @@ type Message struct {
 	ID            uint           `gorm:"primaryKey" json:"id"`
 	AppointmentID uint           `gorm:"not null" json:"appointment_id"`
 	SenderUserID  uint           `gorm:"not null" json:"sender_user_id"`
+	Channel       string         `gorm:"not null;default:'patient';index" json:"channel"` // patient: 患者との診療チャット / case_discussion: 医師間の症例相談
+	CaseDiscussionID *uint       `gorm:"index" json:"case_discussion_id,omitempty"`
+	VideoSessionID *uint         `gorm:"index" json:"video_session_id,omitempty"` // ビデオ通話中に共有したファイル
 	Body          string         `json:"body"`
 	AttachmentURL *string        `json:"attachment_url"`
+	AttachmentID  *uint          `gorm:"index" json:"attachment_id,omitempty"` // 添付ファイルのメタデータ
 	ReadAt        *time.Time     `json:"read_at"`
+	EmailNotifiedAt *time.Time   `json:"-"` // 未読のままのメッセージをメールで知らせた日時
+	NotifyAfter   *time.Time     `json:"-"`                                   // 医師の対応時間外に届いたメッセージの通知を遅らせる日時
+	PushNotifiedAt *time.Time    `json:"-"`                                   // 通知を遅らせたメッセージをプッシュ通知した日時
+	IsAutoReply   bool           `gorm:"not null;default:false" json:"is_auto_reply"` // 時間外の自動返信
+	SystemEvent   string         `json:"system_event,omitempty"`                      // 医師のチャットのコマンドによるシステムメッセージの種類（通常のメッセージは空）
+	SystemDataJSON string        `json:"system_data_json,omitempty"`                  // JSON文字列（コマンドで実行した操作の結果）
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
@@ -122,6 +347,36 @@ type Message struct {
 	// リレーション
 	Appointment Appointment `gorm:"foreignKey:AppointmentID;references:ID" json:"appointment"`
 	Sender      User        `gorm:"foreignKey:SenderUserID;references:ID" json:"sender"`
+	Attachment  *Attachment `gorm:"foreignKey:AttachmentID;references:ID" json:"attachment,omitempty"`
+}
+
+// モデレーションの対応内容
+const (
+	ModerationDismiss = "dismiss" // 問題なしとして却下
+	ModerationWarn    = "warn"    // 送信者へ警告
+	ModerationSuspend = "suspend" // 送信者のチャット送信を一定期間停止
+)
+
+// MessageFlag チャットメッセージの通報（管理者のモデレーション対象）
+// 同じメッセージへの未対応の通報は、管理者の対応時にまとめて解決する
+type MessageFlag struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	MessageID      uint       `gorm:"not null;uniqueIndex:idx_message_flag_reporter" json:"message_id"`
+	ReporterID     uint       `gorm:"not null;uniqueIndex:idx_message_flag_reporter" json:"reporter_id"`
+	AppointmentID  uint       `gorm:"not null;index" json:"appointment_id"`
+	Reason         string     `gorm:"not null;check:reason IN ('harassment','spam','other')" json:"reason"`
+	Comment        string     `json:"comment"`
+	Status         string     `gorm:"not null;default:'open';index;check:status IN ('open','resolved')" json:"status"`
+	Action         string     `json:"action,omitempty"` // dismiss | warn | suspend
+	ResolutionNote string     `json:"resolution_note,omitempty"`
+	ResolvedByID   *uint      `json:"resolved_by_id,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// リレーション
+	Message  Message `gorm:"foreignKey:MessageID;references:ID" json:"message"`
+	Reporter User    `gorm:"foreignKey:ReporterID;references:ID" json:"reporter"`
 }
 
 // VideoSession ビデオセッション
@@ -129,6 +384,13 @@ type VideoSession struct {
 	ID            uint           `gorm:"primaryKey" json:"id"`
 	AppointmentID uint           `gorm:"not null" json:"appointment_id"`
 	RoomID        string         `gorm:"not null" json:"room_id"`
+	RecordingConsent   string     `gorm:"not null;default:'not_requested';check:recording_consent IN ('not_requested','requested','granted','declined')" json:"recording_consent"` // 録音・文字起こしへの患者の同意
+	RecordingConsentAt *time.Time `json:"recording_consent_at"`
+	DoctorRecordingConsent   string     `gorm:"not null;default:'not_requested';check:doctor_recording_consent IN ('not_requested','requested','granted','declined')" json:"doctor_recording_consent"` // 録画への医師の同意（録画は患者・医師の双方の同意が必要）
+	DoctorRecordingConsentAt *time.Time `json:"doctor_recording_consent_at"`
+	PendingOffer       string     `gorm:"type:text" json:"-"` // 応答待ちのSDPオファー（RTCSessionDescriptionInit のJSON、アンサーの送信で消去）
+	PendingOfferFromID *uint      `json:"-"`
+	PendingOfferAt     *time.Time `json:"-"`
 	StartedAt     *time.Time     `json:"started_at"`
 	EndedAt       *time.Time     `json:"ended_at"`
 	CreatedAt     time.Time      `json:"created_at"`
@@ -139,6 +401,132 @@ type VideoSession struct {
 	Appointment Appointment `gorm:"foreignKey:AppointmentID;references:ID" json:"appointment"`
 }
 
+// Transcript ビデオ診療の文字起こし（録音に患者が同意したセッションのみ、診療記録の一部として扱う）
+type Transcript struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	VideoSessionID uint       `gorm:"not null;uniqueIndex" json:"video_session_id"`
+	AppointmentID  uint       `gorm:"not null;index" json:"appointment_id"`
+	UploadedByID   uint       `gorm:"not null" json:"uploaded_by_id"`
+	Status         string     `gorm:"not null;default:'pending';index;check:status IN ('pending','processing','completed','failed')" json:"status"`
+	Language       string     `gorm:"not null;default:'ja'" json:"language"`
+	Provider       string     `json:"provider,omitempty"`
+	AudioPath      string     `json:"-"` // 文字起こしの完了後に削除する
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	LastError      string     `json:"last_error,omitempty"`
+	CompletedAt    *time.Time `json:"completed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// リレーション
+	Segments []TranscriptSegment `gorm:"foreignKey:TranscriptID;references:ID" json:"segments,omitempty"`
+}
+
+// 録画の状態
+const (
+	RecordingInProgress = "recording" // 録画中（録画している参加者の端末に保存中）
+	RecordingStopped    = "stopped"   // 停止済み・ファイルのアップロード待ち
+	RecordingAvailable  = "available" // ファイルを保存済み
+)
+
+// Recording ビデオ診療の録画（患者・医師の双方が同意したセッションのみ、診療記録の一部として扱う）
+// 録画は開始した参加者の端末で行い、停止後にファイルをアップロードして保存先（ローカル・S3）に保存する
+type Recording struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	VideoSessionID uint       `gorm:"not null;index" json:"video_session_id"`
+	AppointmentID  uint       `gorm:"not null;index" json:"appointment_id"`
+	Status         string     `gorm:"not null;default:'recording';check:status IN ('recording','stopped','available')" json:"status"`
+	StartedByID    uint       `gorm:"not null" json:"started_by_id"`
+	StartedAt      time.Time  `gorm:"not null" json:"started_at"`
+	StoppedByID    *uint      `json:"stopped_by_id,omitempty"` // 同意の撤回・セッションの終了による停止の場合はnil
+	StoppedAt      *time.Time `json:"stopped_at,omitempty"`
+	StopReason     string     `json:"stop_reason,omitempty"` // stopped / consent_withdrawn / session_ended
+	Storage        string     `json:"storage,omitempty"`     // local / s3
+	ObjectKey      string     `json:"-"`
+	ContentType    string     `json:"content_type,omitempty"`
+	SizeBytes      int64      `gorm:"not null;default:0" json:"size_bytes"`
+	Checksum       string     `json:"checksum,omitempty"` // SHA-256
+	UploadedAt     *time.Time `json:"uploaded_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TranscriptSegment 文字起こしの区間（録音の先頭からのミリ秒）
+type TranscriptSegment struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	TranscriptID uint   `gorm:"not null;index" json:"transcript_id"`
+	Seq          int    `gorm:"not null" json:"seq"`
+	StartMs      int64  `gorm:"not null" json:"start_ms"`
+	EndMs        int64  `gorm:"not null" json:"end_ms"`
+	Speaker      string `json:"speaker,omitempty"`
+	Text         string `gorm:"not null" json:"text"`
+}
+
+// 着信への応答状態
+const (
+	CallRinging  = "ringing"
+	CallAccepted = "accepted"
+	CallDeclined = "declined"
+	CallMissed   = "missed" // 応答がないまま時間切れ、または発信側が終了した
+)
+
+// VideoParticipant ビデオセッションへの呼び出し（医師の開始時に患者を呼び出す）
+type VideoParticipant struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	VideoSessionID uint       `gorm:"not null;uniqueIndex:idx_video_participant" json:"video_session_id"`
+	UserID         uint       `gorm:"not null;uniqueIndex:idx_video_participant" json:"user_id"`
+	CallerID       uint       `gorm:"not null" json:"caller_id"`
+	State          string     `gorm:"not null;default:'ringing';index;check:state IN ('ringing','accepted','declined','missed')" json:"state"`
+	RangAt         time.Time  `gorm:"not null" json:"rang_at"`
+	RespondedAt    *time.Time `json:"responded_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ICECandidate 参加者ごとの受信待ちのICE候補（トリクルICE）
+// 受信側が取得を確認するまで保持し、送信者の切断・セッションの終了時に削除する
+type ICECandidate struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	VideoSessionID uint      `gorm:"not null;index:idx_ice_candidate_queue,priority:1" json:"video_session_id"`
+	RecipientID    uint      `gorm:"not null;index:idx_ice_candidate_queue,priority:2" json:"recipient_id"`
+	SenderID       uint      `gorm:"not null" json:"sender_id"`
+	Candidate      string    `gorm:"type:text;not null" json:"-"` // RTCIceCandidateInit のJSON
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// VideoPresence ビデオ通話のシグナリングに接続中の参加者（接続ごと、切断時に削除）
+// どのインスタンスに接続していても参加者の在室が分かるようデータベースに記録し、
+// 接続中のインスタンスが定期的に更新する（更新が途絶えた接続は異常終了とみなして削除する）
+type VideoPresence struct {
+	ID             uint      `gorm:"primaryKey" json:"-"`
+	VideoSessionID uint      `gorm:"not null;index" json:"video_session_id"`
+	UserID         uint      `gorm:"not null" json:"user_id"`
+	ConnectedAt    time.Time `gorm:"not null" json:"connected_at"`
+	LastSeenAt     time.Time `gorm:"not null;index" json:"-"`
+}
+
+// DeviceToken プッシュ通知の送信先として登録された端末
+type DeviceToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	Platform  string    `gorm:"not null;check:platform IN ('ios','android','web')" json:"platform"`
+	Token     string    `gorm:"not null;uniqueIndex" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SlotSubscription 医師の診療枠の公開の通知を希望する患者の登録
+type SlotSubscription struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	PatientID        uint       `gorm:"not null;uniqueIndex:idx_slot_subscription_patient_doctor" json:"patient_id"`
+	DoctorID         uint       `gorm:"not null;uniqueIndex:idx_slot_subscription_patient_doctor;index" json:"doctor_id"`
+	UnsubscribeToken string     `gorm:"not null;uniqueIndex" json:"-"` // 通知に記載する登録解除リンクのトークン（ログイン不要）
+	NotifiedThrough  *time.Time `gorm:"index" json:"notified_through,omitempty"` // この時刻までに作成された枠は通知済み
+	CreatedAt        time.Time  `json:"created_at"`
+
+	// リレーション
+	Doctor User `gorm:"foreignKey:DoctorID;references:ID" json:"doctor"`
+}
+
 // Prescription 処方
 type Prescription struct {
 	ID                uint           `gorm:"primaryKey" json:"id"`
@@ -146,6 +534,10 @@ type Prescription struct {
 	ItemsJSON         string         `gorm:"not null" json:"items_json"` // JSON文字列
 	Notes             string         `json:"notes"`
 	CreatedByDoctorID uint           `gorm:"not null" json:"created_by_doctor_id"`
+	AcknowledgedAt    *time.Time     `json:"acknowledged_at,omitempty"` // 患者が処方内容を確認した日時
+	LastNotifiedAt    *time.Time     `gorm:"index" json:"-"`              // 患者へ最後に通知（再通知）した日時
+	AckReminderCount  int            `gorm:"not null;default:0" json:"-"`
+	VerificationCode  *string        `gorm:"uniqueIndex" json:"-"`        // 処方箋PDFのQRコードで内容を照合するコード（処方の変更時に再発行する）
 	CreatedAt         time.Time      `json:"created_at"`
 	UpdatedAt         time.Time      `json:"updated_at"`
 	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
@@ -159,10 +551,12 @@ type Prescription struct {
 type AuditLog struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	UserID    *uint          `json:"user_id"`
+	PatientID *uint          `gorm:"index" json:"patient_id"` // 閲覧対象の患者（PHIアクセスログ用）
 	Action    string         `gorm:"not null" json:"action"`
 	Entity    string         `gorm:"not null" json:"entity"`
 	EntityID  string         `gorm:"not null" json:"entity_id"`
 	MetaJSON  string         `json:"meta_json"` // JSON文字列
+	Severity  string         `gorm:"not null;default:'info';index;check:severity IN ('info','high')" json:"severity"` // high: 緊急時アクセス等の要確認の記録
 	At        time.Time      `gorm:"not null;default:now()" json:"at"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
@@ -172,6 +566,860 @@ type AuditLog struct {
 	User *User `gorm:"foreignKey:UserID;references:ID" json:"user,omitempty"`
 }
 
+// 保存した検索条件の対象
+const (
+	SavedFilterAppointments = "appointments" // 医師の予約一覧
+	SavedFilterAuditLogs    = "audit_logs"   // 監査ログの検索（管理者）
+)
+
+// SavedFilter 利用者が保存した検索条件（一覧のクエリパラメーターをJSONで保存する）
+type SavedFilter struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index:idx_saved_filters_user_scope" json:"user_id"`
+	Scope     string    `gorm:"not null;index:idx_saved_filters_user_scope;check:scope IN ('appointments','audit_logs')" json:"scope"`
+	Name      string    `gorm:"not null" json:"name"`
+	QueryJSON string    `gorm:"type:text;not null" json:"query_json"` // 検索条件（パラメーター名と値のJSONオブジェクト）
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AuditArchive 監査ログのアーカイブ（圧縮NDJSONファイル）
+type AuditArchive struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	ObjectKey       string     `gorm:"not null;uniqueIndex" json:"object_key"`
+	Format          string     `gorm:"not null;default:'ndjson.gz'" json:"format"`
+	FromAt          time.Time  `gorm:"not null" json:"from_at"`
+	ToAt            time.Time  `gorm:"not null" json:"to_at"`
+	RecordCount     int        `gorm:"not null" json:"record_count"`
+	SizeBytes       int64      `gorm:"not null" json:"size_bytes"`
+	Checksum        string     `gorm:"not null" json:"checksum"` // SHA-256
+	Status          string     `gorm:"not null;default:'archived';check:status IN ('archived','rehydrated')" json:"status"`
+	RehydratedAt    *time.Time `json:"rehydrated_at"`
+	RehydratedUntil *time.Time `json:"rehydrated_until"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// AuditArchiveEntry アーカイブ済み監査ログの検索用インデックス
+type AuditArchiveEntry struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ArchiveID  uint      `gorm:"not null;index" json:"archive_id"`
+	AuditLogID uint      `gorm:"not null;uniqueIndex" json:"audit_log_id"`
+	UserID     *uint     `gorm:"index" json:"user_id"`
+	PatientID  *uint     `gorm:"index" json:"patient_id"`
+	Action     string    `gorm:"not null" json:"action"`
+	Entity     string    `gorm:"not null" json:"entity"`
+	EntityID   string    `gorm:"not null" json:"entity_id"`
+	At         time.Time `gorm:"not null;index" json:"at"`
+}
+
+// AuditPseudonymSalt 分析用エクスポートで利用者IDの仮名化（HMAC）に使うソルト
+// 定期的に新しいソルトへ切り替えて古いソルトは削除し、期間をまたいだ仮名の突き合わせをできなくする
+type AuditPseudonymSalt struct {
+	ID        uint      `gorm:"primaryKey" json:"id"` // エクスポートに記載するソルトの版
+	Salt      string    `gorm:"not null" json:"-"`    // 16進文字列
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// ExportDownload エクスポートの再開可能なダウンロード
+// 生成したファイルを一時保存し、通信が途切れた場合もトークンで途中から再取得できる（有効期限の経過後に削除）
+type ExportDownload struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	TokenHash   string     `gorm:"not null;uniqueIndex" json:"-"`
+	ObjectKey   string     `gorm:"not null" json:"-"` // 一時保存ディレクトリ内のファイル名
+	Filename    string     `gorm:"not null" json:"filename"`
+	ContentType string     `gorm:"not null" json:"content_type"`
+	SizeBytes   int64      `gorm:"not null;default:0" json:"size_bytes"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // 生成の完了（完了前は再取得できない）
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// 定期レポートの種類（集計期間は配信日の前週・前月）
+const (
+	ReportWeeklyUtilization = "weekly_utilization" // 診療枠の稼働状況（週次）
+	ReportMonthlyRevenue    = "monthly_revenue"    // 入出金の集計（月次）
+	ReportAuditSummary      = "audit_summary"      // 監査ログの操作ごとの件数（週次）
+)
+
+// ReportSubscription 定期レポートのメール配信の登録（登録した管理者のメールアドレスへCSV・PDFを添付して送る）
+type ReportSubscription struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	ReportType string     `gorm:"not null;check:report_type IN ('weekly_utilization','monthly_revenue','audit_summary')" json:"report_type"`
+	Format     string     `gorm:"not null;default:'csv';check:format IN ('csv','pdf')" json:"format"`
+	Active     bool       `gorm:"not null;default:true" json:"active"`
+	NextRunAt  time.Time  `gorm:"not null;index" json:"next_run_at"` // 次回の配信日時
+	LastSentAt *time.Time `json:"last_sent_at,omitempty"`
+	LastError  string     `json:"last_error,omitempty"` // 前回の配信に失敗した場合の理由
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// BackupRun データベースのバックアップ（pg_dump）の実行記録
+type BackupRun struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Status        string     `gorm:"not null;default:'running';index;check:status IN ('running','succeeded','failed','expired')" json:"status"`
+	Source        string     `gorm:"not null;check:source IN ('manual','scheduled','cli')" json:"source"` // 実行の契機
+	TriggeredByID *uint      `json:"triggered_by_id,omitempty"` // 管理者による実行の場合
+	ObjectKey     string     `json:"object_key,omitempty"`      // バックアップディレクトリ内のファイル名
+	SizeBytes     int64      `json:"size_bytes"`
+	Checksum      string     `json:"checksum,omitempty"` // SHA-256
+	Error         string     `json:"error,omitempty"`
+	StartedAt     time.Time  `gorm:"not null;index" json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// HL7Destination HL7v2による連携先の医療機関（FHIRに未対応の病院へ患者・予約・処方を送信する）
+// 送信済みの位置（更新日時・ID）を対象ごとに記録し、以降に作成・更新されたものを順に送信する
+type HL7Destination struct {
+	ID                   uint   `gorm:"primaryKey" json:"id"`
+	Name                 string `gorm:"not null" json:"name"`
+	Transport            string `gorm:"not null;check:transport IN ('mllp','file')" json:"transport"`
+	Host                 string `json:"host,omitempty"` // MLLPの送信先
+	Port                 int    `json:"port,omitempty"`
+	UseTLS               bool   `gorm:"not null;default:false" json:"use_tls"`
+	Directory            string `json:"directory,omitempty"` // ファイル配置の場合の HL7_FILE_DROP_DIR 内のディレクトリ
+	SendingApplication   string `gorm:"not null" json:"sending_application"` // MSH-3
+	SendingFacility      string `gorm:"not null" json:"sending_facility"`    // MSH-4（患者番号の発番者にも使用）
+	ReceivingApplication string `json:"receiving_application"`               // MSH-5
+	ReceivingFacility    string `json:"receiving_facility"`                  // MSH-6
+	ProcessingID         string `gorm:"not null;default:'P';check:processing_id IN ('P','T')" json:"processing_id"` // P: 本番 / T: テスト
+	MessageTypes         string `gorm:"not null" json:"message_types"` // 送信するメッセージの種類（ADT,ORM のカンマ区切り）
+	Enabled              bool   `gorm:"not null" json:"enabled"`
+	PatientsSyncedAt      time.Time  `gorm:"not null" json:"-"`
+	PatientsSyncedID      uint       `gorm:"not null;default:0" json:"-"`
+	AppointmentsSyncedAt  time.Time  `gorm:"not null" json:"-"`
+	AppointmentsSyncedID  uint       `gorm:"not null;default:0" json:"-"`
+	PrescriptionsSyncedAt time.Time  `gorm:"not null" json:"-"`
+	PrescriptionsSyncedID uint       `gorm:"not null;default:0" json:"-"`
+	LastSentAt            *time.Time `json:"last_sent_at,omitempty"`
+	LastError             string     `json:"last_error,omitempty"` // 送信に失敗している間のみ（次の送信の成功で消去）
+	LastErrorAt           *time.Time `json:"last_error_at,omitempty"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// HL7Message HL7v2の連携先へ送信したメッセージ（送信履歴の確認と、同じ内容の再送の防止に使用）
+type HL7Message struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	DestinationID uint      `gorm:"not null;index:idx_hl7_messages_entity" json:"destination_id"`
+	Entity        string    `gorm:"not null;index:idx_hl7_messages_entity" json:"entity"` // patient | appointment | prescription
+	EntityID      uint      `gorm:"not null;index:idx_hl7_messages_entity" json:"entity_id"`
+	MessageType   string    `gorm:"not null" json:"message_type"` // ADT^A31、ORM^O01等
+	ControlID     string    `gorm:"not null;uniqueIndex" json:"control_id"` // MSH-10
+	ContentHash   string    `gorm:"not null" json:"-"`                      // MSHを除いた内容のSHA-256
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// BreakGlassAccess 緊急時アクセス（予約による権限がない患者の診療記録を理由を記録して一定時間閲覧する）
+type BreakGlassAccess struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	UserID        uint       `gorm:"not null;index" json:"user_id"`    // 閲覧する医師・管理者
+	PatientID     uint       `gorm:"not null;index" json:"patient_id"`
+	Justification string     `gorm:"not null" json:"justification"`
+	ExpiresAt     time.Time  `gorm:"not null;index" json:"expires_at"`
+	EndedAt       *time.Time `json:"ended_at,omitempty"` // 期限前に終了した場合
+	CreatedAt     time.Time  `json:"created_at"`
+
+	// リレーション
+	User    User `gorm:"foreignKey:UserID;references:ID" json:"user"`
+	Patient User `gorm:"foreignKey:PatientID;references:ID" json:"patient"`
+}
+
+// Notification アプリ内通知
+type Notification struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	Type      string     `gorm:"not null" json:"type"`
+	Title     string     `gorm:"not null" json:"title"`
+	Body      string     `json:"body"`
+	Priority  string     `gorm:"not null;default:'normal';check:priority IN ('normal','high')" json:"priority"`
+	DataJSON  string     `json:"data_json"` // JSON文字列
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// メール通知の種類（通知設定の項目）
+const (
+	EmailNotificationAppointmentCreated   = "appointment_created"
+	EmailNotificationAppointmentConfirmed = "appointment_confirmed"
+	EmailNotificationAppointmentCancelled = "appointment_cancelled"
+	EmailNotificationPrescription         = "prescription"
+	EmailNotificationChatMessage          = "chat_message"
+)
+
+// NotificationPreference ユーザーごとのメール通知の設定（未設定のユーザーはすべて受け取る）
+type NotificationPreference struct {
+	ID                   uint      `gorm:"primaryKey" json:"-"`
+	UserID               uint      `gorm:"not null;uniqueIndex" json:"user_id"`
+	EmailEnabled         bool      `gorm:"not null" json:"email_enabled"` // メール通知全体の受信
+	AppointmentCreated   bool      `gorm:"not null" json:"appointment_created"`
+	AppointmentConfirmed bool      `gorm:"not null" json:"appointment_confirmed"`
+	AppointmentCancelled bool      `gorm:"not null" json:"appointment_cancelled"`
+	Prescription         bool      `gorm:"not null" json:"prescription"`
+	ChatMessage          bool      `gorm:"not null" json:"chat_message"` // 未読のチャットメッセージ
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// TriageAssessment 予約前の症状チェック（問診）結果
+type TriageAssessment struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	PatientID          uint      `gorm:"not null;index" json:"patient_id"`
+	DependentID        *uint     `json:"dependent_id"`
+	AppointmentID      *uint     `gorm:"uniqueIndex" json:"appointment_id"`
+	AnswersJSON        string    `gorm:"not null" json:"answers_json"` // JSON文字列
+	Urgency            string    `gorm:"not null;check:urgency IN ('routine','soon','urgent','emergency')" json:"urgency"`
+	SuggestedSpecialty string    `json:"suggested_specialty"`
+	RedFlagsJSON       string    `json:"red_flags_json"` // JSON配列
+	HasRedFlag         bool      `gorm:"not null;default:false" json:"has_red_flag"`
+	Summary            string    `json:"summary"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// Complaint 患者からの苦情・異議申し立て
+type Complaint struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	ComplainantID   uint           `gorm:"not null;index" json:"complainant_id"`
+	AppointmentID   *uint          `gorm:"index" json:"appointment_id"`
+	VideoSessionID  *uint          `json:"video_session_id"`
+	Category        string         `gorm:"not null;check:category IN ('quality','conduct','technical','billing','privacy','other')" json:"category"`
+	Subject         string         `gorm:"not null" json:"subject"`
+	Description     string         `gorm:"not null" json:"description"`
+	Status          string         `gorm:"not null;default:'open';check:status IN ('open','investigating','resolved')" json:"status"`
+	AssignedAdminID *uint          `gorm:"index" json:"assigned_admin_id"`
+	Resolution      string         `json:"resolution"`
+	ResolvedAt      *time.Time     `json:"resolved_at"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// リレーション
+	Complainant   User                `gorm:"foreignKey:ComplainantID;references:ID" json:"complainant"`
+	AssignedAdmin *User               `gorm:"foreignKey:AssignedAdminID;references:ID" json:"assigned_admin,omitempty"`
+	Evidence      []ComplaintEvidence `gorm:"foreignKey:ComplaintID;references:ID" json:"evidence,omitempty"`
+}
+
+// ComplaintEvidence 苦情に自動添付された証跡（監査ログ・セッション記録のスナップショット）
+type ComplaintEvidence struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ComplaintID uint      `gorm:"not null;index" json:"complaint_id"`
+	Source      string    `gorm:"not null;check:source IN ('audit_log','video_session')" json:"source"`
+	SourceID    uint      `gorm:"not null" json:"source_id"`
+	Action      string    `json:"action"`
+	At          time.Time `gorm:"not null" json:"at"`
+	DetailJSON  string    `json:"detail_json"` // JSON文字列
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// LegalDocument 利用規約・プライバシーポリシーなどの法的文書（バージョン管理）
+type LegalDocument struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	DocType     string     `gorm:"not null;uniqueIndex:idx_legal_documents_type_version;check:doc_type IN ('terms','privacy')" json:"doc_type"`
+	Version     string     `gorm:"not null;uniqueIndex:idx_legal_documents_type_version" json:"version"`
+	Title       string     `gorm:"not null" json:"title"`
+	Content     string     `gorm:"not null" json:"content"`
+	PublishedAt *time.Time `gorm:"index" json:"published_at"` // 未公開の場合はnil
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// LegalAcceptance ユーザーによる法的文書への同意記録
+type LegalAcceptance struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"not null;uniqueIndex:idx_legal_acceptances_user_document" json:"user_id"`
+	DocumentID uint      `gorm:"not null;uniqueIndex:idx_legal_acceptances_user_document" json:"document_id"`
+	AcceptedAt time.Time `gorm:"not null" json:"accepted_at"`
+	ClientIP   string    `json:"client_ip"`
+	UserAgent  string    `json:"user_agent"`
+
+	// リレーション
+	Document LegalDocument `gorm:"foreignKey:DocumentID;references:ID" json:"document"`
+}
+
+// Escalation 診療中の緊急エスカレーション
+type Escalation struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	AppointmentID    uint       `gorm:"not null;index" json:"appointment_id"`
+	RaisedByUserID   uint       `gorm:"not null" json:"raised_by_user_id"`
+	Reason           string     `gorm:"not null" json:"reason"`
+	Status           string     `gorm:"not null;default:'open';check:status IN ('open','acknowledged','escalated','resolved')" json:"status"`
+	AcknowledgedByID *uint      `json:"acknowledged_by_id"`
+	AcknowledgedAt   *time.Time `json:"acknowledged_at"`
+	EscalatedAt      *time.Time `json:"escalated_at"`
+	ResolvedAt       *time.Time `json:"resolved_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+
+	// リレーション
+	Appointment Appointment `gorm:"foreignKey:AppointmentID;references:ID" json:"-"`
+}
+
+// DoctorTimeOff 医師の休診期間
+type DoctorTimeOff struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	DoctorID  uint      `gorm:"not null;index" json:"doctor_id"`
+	StartTime time.Time `gorm:"not null" json:"start_time"`
+	EndTime   time.Time `gorm:"not null" json:"end_time"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DoctorCoverage 休診中の医師の代診（期間中は代診医が担当患者のチャットを閲覧・返信できる）
+type DoctorCoverage struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	DoctorID         uint      `gorm:"not null;index" json:"doctor_id"`          // 休診する医師
+	CoveringDoctorID uint      `gorm:"not null;index" json:"covering_doctor_id"` // 代診医
+	StartTime        time.Time `gorm:"not null" json:"start_time"`
+	EndTime          time.Time `gorm:"not null" json:"end_time"`
+	Status           string    `gorm:"not null;default:'active';check:status IN ('active','cancelled')" json:"status"`
+	Note             string    `json:"note"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	// リレーション
+	Doctor         *User                 `gorm:"foreignKey:DoctorID;references:ID" json:"doctor,omitempty"`
+	CoveringDoctor *User                 `gorm:"foreignKey:CoveringDoctorID;references:ID" json:"covering_doctor,omitempty"`
+	Transfers      []AppointmentTransfer `gorm:"foreignKey:CoverageID;references:ID" json:"transfers,omitempty"`
+}
+
+// AppointmentTransfer 代診期間中の確定済み予約の代診医への引き継ぎ（患者の同意後に担当医師を変更する）
+type AppointmentTransfer struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	CoverageID    uint       `gorm:"not null;index" json:"coverage_id"`
+	AppointmentID uint       `gorm:"not null;index" json:"appointment_id"`
+	FromDoctorID  uint       `gorm:"not null" json:"from_doctor_id"`
+	ToDoctorID    uint       `gorm:"not null" json:"to_doctor_id"`
+	PatientID     uint       `gorm:"not null;index" json:"patient_id"`
+	Status        string     `gorm:"not null;default:'pending';check:status IN ('pending','accepted','declined','cancelled')" json:"status"`
+	RespondedAt   *time.Time `json:"responded_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// リレーション
+	Appointment *Appointment `gorm:"foreignKey:AppointmentID;references:ID" json:"appointment,omitempty"`
+	ToDoctor    *User        `gorm:"foreignKey:ToDoctorID;references:ID" json:"to_doctor,omitempty"`
+}
+
+// Invoice 予約の診療費の請求（予約の確定時に医師の診療費で作成する、1予約につき1件）
+type Invoice struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	AppointmentID uint       `gorm:"not null;uniqueIndex" json:"appointment_id"`
+	PatientID     uint       `gorm:"not null;index" json:"patient_id"`
+	DoctorID      uint       `gorm:"not null;index" json:"doctor_id"`
+	Amount        int64      `gorm:"not null" json:"amount"`   // 決済通貨の最小単位
+	Currency      string     `gorm:"not null" json:"currency"` // ISO 4217（小文字）
+	Description   string     `json:"description"`
+	Status        string     `gorm:"not null;default:'open';index;check:status IN ('open','paid','void')" json:"status"`
+	PaidAt        *time.Time `json:"paid_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// リレーション
+	Appointment *Appointment `gorm:"foreignKey:AppointmentID;references:ID" json:"appointment,omitempty"`
+	Payments    []Payment    `gorm:"foreignKey:InvoiceID;references:ID" json:"payments,omitempty"`
+}
+
+// Payment 請求の支払い（決済代行サービスの PaymentIntent、支払いをやり直した場合は請求に複数件）
+type Payment struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	InvoiceID        uint      `gorm:"not null;index" json:"invoice_id"`
+	Provider         string    `gorm:"not null" json:"provider"`
+	ProviderIntentID string    `gorm:"not null;uniqueIndex" json:"provider_intent_id"`
+	ClientSecret     string    `gorm:"not null" json:"-"` // フロントエンドでの支払いに使用（支払いの開始時のみ返す）
+	Amount           int64     `gorm:"not null" json:"amount"`
+	Currency         string    `gorm:"not null" json:"currency"`
+	Status           string    `gorm:"not null;default:'requires_payment';check:status IN ('requires_payment','processing','requires_review','succeeded','failed','cancelled')" json:"status"`
+	FailureMessage   string    `json:"failure_message,omitempty"`
+	ReviewReason     string    `json:"review_reason,omitempty"` // 金額・通貨が請求と一致しないため請求に反映せず、管理者の確認待ち（requires_review）にした理由
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// PaidAmount 請求の完了した支払いの合計（確認待ちの支払いは含めない、Payments を読み込んだ請求のみ）
+func (i *Invoice) PaidAmount() int64 {
+	var total int64
+	for _, payment := range i.Payments {
+		if payment.Status == "succeeded" {
+			total += payment.Amount
+		}
+	}
+	return total
+}
+
+// 勘定の種類（資産・費用は借方、負債・収益・純資産は貸方が増加）
+const (
+	LedgerAsset     = "asset"
+	LedgerLiability = "liability"
+	LedgerEquity    = "equity"
+	LedgerRevenue   = "revenue"
+	LedgerExpense   = "expense"
+)
+
+// LedgerAccount 複式簿記の勘定（通貨ごとに作成し、残高は借方の合計−貸方の合計で記録する）
+type LedgerAccount struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Code        string    `gorm:"not null;uniqueIndex:idx_ledger_accounts_code_currency" json:"code"` // 例: liabilities:doctor_payable:12
+	Currency    string    `gorm:"not null;uniqueIndex:idx_ledger_accounts_code_currency" json:"currency"`
+	Type        string    `gorm:"not null;check:type IN ('asset','liability','equity','revenue','expense')" json:"type"`
+	OwnerID     *uint     `gorm:"index" json:"owner_id,omitempty"`            // 医師・患者ごとの勘定の場合のみ
+	NonNegative bool      `gorm:"not null;default:false" json:"non_negative"` // 通常の残高の向きに対して負にできない勘定
+	Balance     int64     `gorm:"not null;default:0" json:"balance"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// 仕訳の種類
+const (
+	JournalPayment      = "payment"
+	JournalRefund       = "refund"
+	JournalWalletCredit = "wallet_credit"
+	JournalPayout       = "payout"
+)
+
+// JournalEntry 仕訳（借方・貸方の合計が一致する明細の組、記帳後は変更しない）
+type JournalEntry struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Reference   string    `gorm:"not null;uniqueIndex" json:"reference"` // 二重記帳を防ぐキー（例: payment:12）
+	Kind        string    `gorm:"not null;index;check:kind IN ('payment','refund','wallet_credit','payout')" json:"kind"`
+	Currency    string    `gorm:"not null" json:"currency"`
+	Description string    `json:"description"`
+	InvoiceID   *uint     `gorm:"index" json:"invoice_id,omitempty"`
+	PaymentID   *uint     `gorm:"index" json:"payment_id,omitempty"`
+	CreatedByID *uint     `json:"created_by_id,omitempty"` // 管理者が記録した場合のみ
+	PostedAt    time.Time `gorm:"not null;index" json:"posted_at"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// リレーション
+	Lines []JournalLine `gorm:"foreignKey:JournalEntryID;references:ID" json:"lines,omitempty"`
+}
+
+// JournalLine 仕訳の明細（借方・貸方のどちらか一方のみ正の金額）
+type JournalLine struct {
+	ID             uint  `gorm:"primaryKey" json:"id"`
+	JournalEntryID uint  `gorm:"not null;index" json:"journal_entry_id"`
+	AccountID      uint  `gorm:"not null;index" json:"account_id"`
+	Debit          int64 `gorm:"not null;default:0;check:chk_journal_lines_side,(debit > 0 AND credit = 0) OR (debit = 0 AND credit > 0)" json:"debit"`
+	Credit         int64 `gorm:"not null;default:0" json:"credit"`
+
+	// リレーション
+	Account *LedgerAccount `gorm:"foreignKey:AccountID;references:ID" json:"account,omitempty"`
+}
+
+// AppointmentTask 予約に紐付く共有タスク（検査結果のアップロード、毎日の血圧測定など）
+type AppointmentTask struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	AppointmentID  uint       `gorm:"not null;index" json:"appointment_id"`
+	CreatedByID    uint       `gorm:"not null" json:"created_by_id"`
+	AssigneeID     uint       `gorm:"not null;index" json:"assignee_id"` // 予約の患者または医師
+	Title          string     `gorm:"not null" json:"title"`
+	Description    string     `json:"description"`
+	Status         string     `gorm:"not null;default:'open';check:status IN ('open','done')" json:"status"`
+	DueAt          *time.Time `json:"due_at"`
+	RemindAt       *time.Time `gorm:"index" json:"remind_at"`
+	RemindedAt     *time.Time `json:"reminded_at"`
+	CompletedAt    *time.Time `json:"completed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// リレーション
+	Appointment Appointment `gorm:"foreignKey:AppointmentID;references:ID" json:"-"`
+}
+
+// PROMAssignment 患者報告アウトカム（PROM）の質問票の割り当て（医師が診療後に割り当て、患者が回答する）
+// 回答時に採点し、患者ごとの点数の推移と医師への通知（基準点以上・前回からの悪化）に使用する
+type PROMAssignment struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	AppointmentID  uint       `gorm:"not null;index" json:"appointment_id"`
+	PatientID      uint       `gorm:"not null;index:idx_prom_assignments_patient" json:"patient_id"`
+	DependentID    *uint      `json:"dependent_id,omitempty"` // 家族の予約の場合（保護者が家族について回答する）
+	DoctorID       uint       `gorm:"not null;index" json:"doctor_id"`
+	Instrument     string     `gorm:"not null;index:idx_prom_assignments_patient" json:"instrument"` // phq9 | gad7
+	Status         string     `gorm:"not null;default:'pending';check:status IN ('pending','completed','cancelled')" json:"status"`
+	AlertThreshold int        `gorm:"not null" json:"alert_threshold"` // この点数以上で医師へ通知
+	DueAt          *time.Time `json:"due_at,omitempty"`
+	AnswersJSON    string     `json:"answers_json,omitempty"` // JSON文字列（質問ID: 点数）
+	Score          *int       `json:"score,omitempty"`
+	Severity       string     `json:"severity,omitempty"`
+	AlertReasons   string     `json:"alert_reasons,omitempty"` // 医師へ通知した理由（、区切り）
+	CompletedAt    *time.Time `gorm:"index" json:"completed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// CodingSuggestion 診療記録から提案されたICD-10コード（医師が確認するまで問題リストには追加しない）
+type CodingSuggestion struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	AppointmentID uint       `gorm:"not null;index" json:"appointment_id"`
+	DoctorID      uint       `gorm:"not null" json:"doctor_id"`
+	Code          string     `gorm:"not null" json:"code"`
+	Description   string     `json:"description"`
+	Confidence    float64    `json:"confidence"`
+	Evidence      string     `json:"evidence"`
+	Provider      string     `gorm:"not null" json:"provider"`
+	Status        string     `gorm:"not null;default:'pending';check:status IN ('pending','confirmed','rejected')" json:"status"`
+	ReviewedAt    *time.Time `json:"reviewed_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ProblemListEntry 患者の問題リスト（ICD-10コードで管理する病名）
+type ProblemListEntry struct {
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	PatientID           uint       `gorm:"not null;index" json:"patient_id"`
+	DependentID         *uint      `gorm:"index" json:"dependent_id"` // 家族（被扶養者）の問題リストの場合
+	Code                string     `gorm:"not null" json:"code"`
+	Description         string     `json:"description"`
+	Status              string     `gorm:"not null;default:'active';check:status IN ('active','resolved')" json:"status"`
+	SourceAppointmentID *uint      `json:"source_appointment_id"`
+	SuggestionID        *uint      `json:"suggestion_id"` // 提案を確認して追加した場合
+	AddedByID           uint       `gorm:"not null" json:"added_by_id"`
+	ResolvedAt          *time.Time `json:"resolved_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// MedicalRecord 診療録（1予約につき1件、担当医師が診療中に記載し、患者は自身・家族の記録を閲覧できる）
+type MedicalRecord struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	AppointmentID uint      `gorm:"not null;uniqueIndex" json:"appointment_id"`
+	PatientID     uint      `gorm:"not null;index:idx_medical_records_subject" json:"patient_id"`
+	DependentID   *uint     `gorm:"index:idx_medical_records_subject" json:"dependent_id,omitempty"` // 家族の予約の場合
+	DoctorID      uint      `gorm:"not null;index" json:"doctor_id"`
+	Diagnosis     string    `gorm:"type:text" json:"diagnosis"`
+	Symptoms      string    `gorm:"type:text" json:"symptoms"`
+	VitalsJSON    string    `gorm:"not null;default:'{}'" json:"vitals_json"`    // JSON文字列（体温・血圧・脈拍等、未測定の項目は省略）
+	AllergiesJSON string    `gorm:"not null;default:'[]'" json:"allergies_json"` // JSON文字列（アレルギーの一覧）
+	VisitSummary  string    `gorm:"type:text" json:"visit_summary"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// リレーション
+	Doctor *User `gorm:"foreignKey:DoctorID;references:ID" json:"doctor,omitempty"`
+}
+
+// CorrectionRequest 患者による診療録の訂正の依頼（診療録を記載した医師が対応し、承認した場合は訂正前の記載を残す）
+type CorrectionRequest struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	PatientID       uint       `gorm:"not null;index" json:"patient_id"`
+	DependentID     *uint      `json:"dependent_id,omitempty"` // 家族の診療録の場合
+	DoctorID        uint       `gorm:"not null;index" json:"doctor_id"` // 対応する医師
+	AppointmentID   uint       `gorm:"not null;index" json:"appointment_id"`
+	MedicalRecordID uint       `gorm:"not null;index" json:"medical_record_id"`
+	Field           string     `gorm:"not null;check:field IN ('diagnosis','symptoms','vitals','allergies','visit_summary','other')" json:"field"`
+	CurrentValue    string     `gorm:"type:text" json:"current_value"` // 依頼した時点の記載（vitals・allergiesはJSON文字列）
+	Description     string     `gorm:"type:text;not null" json:"description"` // 誤りの内容
+	ProposedValue   string     `gorm:"type:text" json:"proposed_value"`       // 患者が求める訂正の内容
+	Status          string     `gorm:"not null;default:'open';index;check:status IN ('open','accepted','rejected','withdrawn')" json:"status"`
+	Resolution      string     `gorm:"type:text" json:"resolution"` // 医師の回答（訂正しない場合はその理由）
+	CorrectedValue  *string    `gorm:"type:text" json:"corrected_value,omitempty"` // 承認して診療録に反映した記載
+	ResolvedByID    *uint      `json:"resolved_by_id,omitempty"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// リレーション
+	Patient User `gorm:"foreignKey:PatientID;references:ID" json:"patient"`
+	Doctor  User `gorm:"foreignKey:DoctorID;references:ID" json:"doctor"`
+}
+
+// AppointmentFeedback 診療後の患者による満足度の評価（1予約につき1件）
+type AppointmentFeedback struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	AppointmentID uint      `gorm:"not null;uniqueIndex" json:"appointment_id"`
+	DoctorID      uint      `gorm:"not null;index" json:"doctor_id"`
+	Rating        int       `gorm:"not null;check:rating BETWEEN 1 AND 5" json:"rating"`
+	Comment       string    `json:"comment"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// DoctorCredential 医師の資格証明書類（学位記・認定証など）
+// 審査中は本人と管理者のみ閲覧でき、承認済みかつ公開設定のものは誰でも閲覧できる
+type DoctorCredential struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	DoctorID     uint       `gorm:"not null;index" json:"doctor_id"`
+	Title        string     `gorm:"not null" json:"title"`
+	DocType      string     `gorm:"not null;check:doc_type IN ('diploma','certification','license','other')" json:"doc_type"`
+	FilePath     string     `gorm:"not null" json:"-"` // 非公開ディレクトリ内のパス
+	FileName     string     `gorm:"not null" json:"file_name"`
+	ContentType  string     `gorm:"not null" json:"content_type"`
+	FileSize     int64      `gorm:"not null" json:"file_size"`
+	Status       string     `gorm:"not null;default:'pending';check:status IN ('pending','approved','rejected')" json:"status"`
+	IsPublic     bool       `gorm:"not null;default:false" json:"is_public"`
+	ReviewedByID *uint      `json:"reviewed_by_id,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote   string     `json:"review_note,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// PatientDocument 患者が保管する過去の診療記録（検査結果・紹介状など）
+type PatientDocument struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	PatientID   uint           `gorm:"not null;index" json:"patient_id"`
+	Title       string         `gorm:"not null" json:"title"`
+	DocType     string         `gorm:"not null;check:doc_type IN ('lab_result','imaging','referral','prescription','other')" json:"doc_type"`
+	RecordedAt  *time.Time     `json:"recorded_at"` // 検査日・発行日
+	FilePath    string         `gorm:"not null" json:"-"` // 非公開ディレクトリ内のパス（同じ内容の診療記録は同じファイルを参照する）
+	Checksum    string         `json:"-"`                 // 内容のSHA-256（16進数、重複を除く前の診療記録は空）
+	FileName    string         `gorm:"not null" json:"file_name"`
+	ContentType string         `gorm:"not null" json:"content_type"`
+	FileSize    int64          `gorm:"not null" json:"file_size"`
+	OCRStatus       string     `gorm:"not null;default:'skipped';index;check:ocr_status IN ('skipped','pending','processing','completed','failed')" json:"ocr_status"` // 文字認識・分類の処理状況（無効の場合はskipped）
+	OCRAttempts     int        `gorm:"not null;default:0" json:"-"`
+	OCRError        string     `json:"ocr_error,omitempty"`
+	OCRProvider     string     `json:"-"`
+	ExtractedText   string     `json:"-"` // 認識したテキスト（医師の検索用）
+	DetectedDocType string     `json:"detected_doc_type,omitempty"` // 内容から推定した種類（患者が選んだ種類は変更しない）
+	ProcessedAt     *time.Time `json:"processed_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// リレーション
+	Values []PatientDocumentValue `gorm:"foreignKey:DocumentID;references:ID" json:"values,omitempty"`
+}
+
+// PatientDocumentValue 診療記録の文字認識で抽出した値（検査項目の結果・日付）
+type PatientDocumentValue struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	DocumentID uint       `gorm:"not null;index" json:"document_id"`
+	Kind       string     `gorm:"not null;check:kind IN ('test','date')" json:"kind"`
+	Name       string     `gorm:"not null;index" json:"name"` // 検査項目名（正規化した名称）・日付の見出し
+	Value      string     `gorm:"not null" json:"value"`
+	Unit       string     `json:"unit,omitempty"`
+	Flag       string     `json:"flag,omitempty"` // H / L（基準値外の表示がある場合）
+	Date       *time.Time `json:"date,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// AppointmentDocumentGrant 予約単位での診療記録の共有（担当医師のみ閲覧可能）
+type AppointmentDocumentGrant struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	AppointmentID uint      `gorm:"not null;uniqueIndex:idx_appointment_document" json:"appointment_id"`
+	DocumentID    uint      `gorm:"not null;uniqueIndex:idx_appointment_document;index" json:"document_id"`
+	PatientID     uint      `gorm:"not null" json:"patient_id"`
+	DoctorID      uint      `gorm:"not null;index" json:"doctor_id"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	// リレーション
+	Document PatientDocument `gorm:"foreignKey:DocumentID;references:ID" json:"document"`
+}
+
+// BookingPolicy 予約受付のルール（プラットフォーム全体で1件のみ）
+type BookingPolicy struct {
+	ID               uint      `gorm:"primaryKey" json:"-"`
+	MinNoticeMinutes int       `gorm:"not null" json:"min_notice_minutes"` // 診療開始の何分前まで予約できるか
+	MaxAdvanceDays   int       `gorm:"not null" json:"max_advance_days"`   // 何日先まで予約できるか
+	OpenTime         string    `gorm:"not null" json:"open_time"`          // 受付時間の開始（HH:MM）
+	CloseTime        string    `gorm:"not null" json:"close_time"`         // 受付時間の終了（HH:MM、24:00まで）
+	OpenWeekdays     string    `gorm:"not null" json:"open_weekdays"`      // 受付曜日（0=日〜6=土、カンマ区切り）
+	Timezone         string    `gorm:"not null" json:"timezone"`
+	UpdatedByID      *uint     `json:"updated_by_id,omitempty"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// ClinicBranding クリニックの表記（ロゴ・ブランドカラー・問い合わせ先・メールのフッター、プラットフォーム全体で1件のみ）
+// PDF・メール・医師一覧に反映し、ホワイトラベルでの提供に使用する
+type ClinicBranding struct {
+	ID           uint      `gorm:"primaryKey" json:"-"`
+	ClinicName   string    `gorm:"not null" json:"clinic_name"`
+	LogoURL      string    `json:"logo_url"`
+	PrimaryColor string    `gorm:"not null" json:"primary_color"` // #RRGGBB
+	SupportEmail string    `json:"support_email"`
+	SupportPhone string    `json:"support_phone"`
+	EmailFooter  string    `json:"email_footer"`
+	UpdatedByID  *uint     `json:"updated_by_id,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// 予約の診療の形式
+const (
+	VisitOnline   = "online"
+	VisitInPerson = "in_person"
+)
+
+// ClinicLocation 対面診療を行うクリニックの所在地（管理者が登録する）
+type ClinicLocation struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Name          string    `gorm:"not null" json:"name"`
+	PostalCode    string    `json:"postal_code"`
+	Address       string    `gorm:"not null" json:"address"`
+	Phone         string    `json:"phone"`
+	Latitude      *float64  `json:"latitude,omitempty"`
+	Longitude     *float64  `json:"longitude,omitempty"`
+	AccessNotes   string    `gorm:"type:text" json:"access_notes"` // 最寄り駅からの道順・駐車場等の案内
+	DirectionsURL string    `gorm:"not null" json:"directions_url"` // 地図アプリでの経路案内のURL（所在地から生成する）
+	Active        bool      `gorm:"not null" json:"active"`           // 停止した所在地は新しい予約に指定できない
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// DoctorLocation 医師が対面診療を行う所在地（管理者が登録する）
+type DoctorLocation struct {
+	DoctorID   uint      `gorm:"primaryKey" json:"doctor_id"`
+	LocationID uint      `gorm:"primaryKey;index" json:"location_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Organization 医療機関（所属する医師・スタッフの単位、組織の管理者がスタッフにロールを委任する）
+type Organization struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"not null;uniqueIndex" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OrganizationMember 組織に所属する医師・スタッフ（医師は複数の組織に所属できる）
+type OrganizationMember struct {
+	OrganizationID uint      `gorm:"primaryKey" json:"organization_id"`
+	UserID         uint      `gorm:"primaryKey;index" json:"user_id"`
+	IsOrgAdmin     bool      `gorm:"not null;default:false" json:"is_org_admin"` // 組織の管理者（スタッフの所属とロールを管理する）
+	CreatedAt      time.Time `json:"created_at"`
+
+	// リレーション
+	User  User     `gorm:"foreignKey:UserID;references:ID" json:"user"`
+	Roles []string `gorm:"-" json:"roles"` // 委任されたロール（一覧の取得時に読み込む）
+}
+
+// OrganizationRoleGrant 組織の管理者がスタッフに委任したロール（取り消すと削除する、経緯は監査ログに残す）
+type OrganizationRoleGrant struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"not null;uniqueIndex:idx_org_role_grants_member_role" json:"organization_id"`
+	UserID         uint      `gorm:"not null;uniqueIndex:idx_org_role_grants_member_role;index" json:"user_id"`
+	Role           string    `gorm:"not null;uniqueIndex:idx_org_role_grants_member_role;check:role IN ('scheduler','billing_clerk','auditor')" json:"role"`
+	GrantedByID    uint      `gorm:"not null" json:"granted_by_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// 患者の利用プラン（無料のプランは契約を保存しない）
+const (
+	PlanFree = "free"
+	PlanPlus = "plus"
+)
+
+// PatientSubscription 患者の有料プランの契約（患者ごとに1件、期限までの利用期間を支払いごとに延長する）
+type PatientSubscription struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	PatientID   uint      `gorm:"not null;uniqueIndex" json:"patient_id"`
+	Plan        string    `gorm:"not null;default:'plus';check:plan IN ('plus')" json:"plan"`
+	ActiveUntil time.Time `gorm:"not null" json:"active_until"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// IsActive 期限内の契約かどうか
+func (s *PatientSubscription) IsActive(now time.Time) bool {
+	return s.ActiveUntil.After(now)
+}
+
+// SubscriptionPayment 有料プランの利用期間の支払い（PaymentIntent ごとに1件）
+type SubscriptionPayment struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	PatientID        uint       `gorm:"not null;index" json:"patient_id"`
+	Plan             string     `gorm:"not null" json:"plan"`
+	Provider         string     `gorm:"not null" json:"provider"`
+	ProviderIntentID string     `gorm:"not null;uniqueIndex" json:"provider_intent_id"`
+	ClientSecret     string     `gorm:"not null" json:"-"` // フロントエンドでの支払いに使用（支払いの開始時のみ返す）
+	Amount           int64      `gorm:"not null" json:"amount"`
+	Currency         string     `gorm:"not null" json:"currency"`
+	Status           string     `gorm:"not null;default:'requires_payment';check:status IN ('requires_payment','processing','succeeded','failed','cancelled')" json:"status"`
+	FailureMessage   string     `json:"failure_message,omitempty"`
+	PaidAt           *time.Time `json:"paid_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// AppVersionPolicy クライアントのアプリの最低バージョン（プラットフォーム全体で1件のみ）
+// 不具合のあるモバイルアプリの古いバージョンを利用停止にし、アップデートを求めるために使用する
+type AppVersionPolicy struct {
+	ID                uint      `gorm:"primaryKey" json:"-"`
+	MinIOSVersion     string    `json:"min_ios_version"` // 空の場合は制限しない
+	MinAndroidVersion string    `json:"min_android_version"`
+	MinWebVersion     string    `json:"min_web_version"`
+	IOSUpgradeURL     string    `json:"ios_upgrade_url"` // App Storeのページ
+	AndroidUpgradeURL string    `json:"android_upgrade_url"`
+	UpgradeMessage    string    `json:"upgrade_message"` // アップデートを求める画面に表示する文言
+	UpdatedByID       *uint     `json:"updated_by_id,omitempty"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// AttachmentPolicy ロールごとのチャットの添付ファイルの制限（保存していないロールは初期値を使う）
+// 医師にはDICOMや動画など、より大きなファイルの添付を許可できる
+type AttachmentPolicy struct {
+	ID           uint      `gorm:"primaryKey" json:"-"`
+	Role         string    `gorm:"not null;uniqueIndex" json:"role"`
+	MaxSizeBytes int64     `gorm:"not null" json:"max_size_bytes"`
+	AllowedTypes string    `gorm:"not null" json:"allowed_types"` // 添付できる形式（Content-Type、カンマ区切り）
+	UpdatedByID  *uint     `json:"updated_by_id,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// 連絡先変更の種別
+const (
+	ContactChangeEmail = "email"
+	ContactChangePhone = "phone"
+)
+
+// ContactChangeRequest メールアドレス・電話番号の変更申請（確認完了まで反映しない）
+type ContactChangeRequest struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	UserID         uint       `gorm:"not null;index" json:"user_id"`
+	Kind           string     `gorm:"not null;check:kind IN ('email','phone')" json:"kind"`
+	OldValue       string     `json:"old_value"`
+	NewValue       string     `gorm:"not null" json:"new_value"`
+	NewTokenHash   string     `gorm:"not null;index" json:"-"` // 新しい連絡先へ送ったトークン（電話番号の場合はOTP）のハッシュ
+	OldTokenHash   string     `gorm:"index" json:"-"`          // 旧メールアドレスへ送ったトークンのハッシュ
+	NewConfirmedAt *time.Time `json:"new_confirmed_at"`
+	OldConfirmedAt *time.Time `json:"old_confirmed_at"`
+	Attempts       int        `gorm:"not null;default:0" json:"-"`
+	Status         string     `gorm:"not null;default:'pending';check:status IN ('pending','completed','cancelled','expired')" json:"status"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	CompletedAt    *time.Time `json:"completed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// メッセージのチャネル種別
+const (
+	MessageChannelPatient        = "patient"
+	MessageChannelCaseDiscussion = "case_discussion"
+)
+
+// CaseDiscussion 医師間の症例相談スレッド（患者の同意が必要）
+type CaseDiscussion struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	AppointmentID      uint       `gorm:"not null;index" json:"appointment_id"`
+	RequestingDoctorID uint       `gorm:"not null;index" json:"requesting_doctor_id"`
+	ConsultantDoctorID uint       `gorm:"not null;index" json:"consultant_doctor_id"`
+	Subject            string     `gorm:"not null" json:"subject"`
+	Status             string     `gorm:"not null;default:'pending_consent';check:status IN ('pending_consent','active','declined','closed')" json:"status"`
+	ConsentedAt        *time.Time `json:"consented_at"`
+	ClosedAt           *time.Time `json:"closed_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	// リレーション
+	Appointment      Appointment `gorm:"foreignKey:AppointmentID;references:ID" json:"-"`
+	RequestingDoctor User        `gorm:"foreignKey:RequestingDoctorID;references:ID" json:"requesting_doctor"`
+	ConsultantDoctor User        `gorm:"foreignKey:ConsultantDoctorID;references:ID" json:"consultant_doctor"`
+}
+
+// IsMember 相談スレッドに参加できる医師かどうか
+func (d *CaseDiscussion) IsMember(userID uint) bool {
+	return d.RequestingDoctorID == userID || d.ConsultantDoctorID == userID
+}
+
 // TableName テーブル名の指定
 func (User) TableName() string           { return "users" }
 func (PatientProfile) TableName() string { return "patient_profiles" }
@@ -184,3 +1432,39 @@ func (Message) TableName() string      { return "messages" }
 func (VideoSession) TableName() string { return "video_sessions" }
 func (Prescription) TableName() string { return "prescriptions" }
 func (AuditLog) TableName() string     { return "audit_logs" }
+func (AuditArchive) TableName() string      { return "audit_archives" }
+func (AuditArchiveEntry) TableName() string { return "audit_archive_entries" }
+func (AuditPseudonymSalt) TableName() string { return "audit_pseudonym_salts" }
+func (BackupRun) TableName() string         { return "backup_runs" }
+func (HL7Destination) TableName() string    { return "hl7_destinations" }
+func (HL7Message) TableName() string        { return "hl7_messages" }
+func (BreakGlassAccess) TableName() string  { return "break_glass_accesses" }
+func (Dependent) TableName() string         { return "dependents" }
+func (Notification) TableName() string      { return "notifications" }
+func (NotificationPreference) TableName() string { return "notification_preferences" }
+func (Escalation) TableName() string        { return "escalations" }
+func (TriageAssessment) TableName() string  { return "triage_assessments" }
+func (InterpreterProfile) TableName() string { return "interpreter_profiles" }
+func (InterpreterSlot) TableName() string    { return "interpreter_slots" }
+func (Complaint) TableName() string          { return "complaints" }
+func (ComplaintEvidence) TableName() string  { return "complaint_evidence" }
+func (LegalDocument) TableName() string      { return "legal_documents" }
+func (LegalAcceptance) TableName() string    { return "legal_acceptances" }
+func (CaseDiscussion) TableName() string     { return "case_discussions" }
+func (DoctorTimeOff) TableName() string      { return "doctor_time_offs" }
+func (DoctorCoverage) TableName() string     { return "doctor_coverages" }
+func (Invoice) TableName() string            { return "invoices" }
+func (Payment) TableName() string            { return "payments" }
+func (AppointmentTransfer) TableName() string { return "appointment_transfers" }
+func (AppointmentTask) TableName() string    { return "appointment_tasks" }
+func (PROMAssignment) TableName() string     { return "prom_assignments" }
+func (DoctorCredential) TableName() string   { return "doctor_credentials" }
+func (ContactChangeRequest) TableName() string { return "contact_change_requests" }
+func (PatientDocument) TableName() string      { return "patient_documents" }
+func (AppointmentDocumentGrant) TableName() string { return "appointment_document_grants" }
+func (BookingPolicy) TableName() string        { return "booking_policies" }
+func (ClinicBranding) TableName() string       { return "clinic_brandings" }
+func (SlotSubscription) TableName() string     { return "slot_subscriptions" }
+func (DoctorAutoReply) TableName() string      { return "doctor_auto_replies" }
+func (CodingSuggestion) TableName() string     { return "coding_suggestions" }
+func (ProblemListEntry) TableName() string     { return "problem_list_entries" }
@@ -0,0 +1,22 @@
+package models
+
+import "testing"
+
+func TestInvoicePaidAmountExcludesHeldPayments(t *testing.T) {
+	invoice := Invoice{
+		Amount: 5000,
+		Payments: []Payment{
+			{Amount: 5000, Status: "failed"},
+			{Amount: 4000, Status: "requires_review", ReviewReason: "amount mismatch: paid 4000, invoiced 5000"},
+			{Amount: 5000, Status: "processing"},
+		},
+	}
+	if got := invoice.PaidAmount(); got != 0 {
+		t.Errorf("PaidAmount() = %d, want 0 while the payment is held for review", got)
+	}
+
+	invoice.Payments[1].Status = "succeeded"
+	if got := invoice.PaidAmount(); got != 4000 {
+		t.Errorf("PaidAmount() = %d, want 4000 after the held payment is approved", got)
+	}
+}
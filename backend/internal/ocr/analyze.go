@@ -0,0 +1,241 @@
+package ocr
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 抽出した値の種類
+const (
+	ValueTest = "test" // 検査項目の結果
+	ValueDate = "date" // 検査日・発行日等の日付
+)
+
+// Value 認識したテキストから抽出した値
+type Value struct {
+	Kind  string
+	Name  string // 検査項目名（正規化した名称）・日付の見出し
+	Value string
+	Unit  string
+	Flag  string // H / L（基準値外の表示がある場合）
+	Date  *time.Time
+}
+
+// Analysis 文書の分類と抽出した値
+type Analysis struct {
+	DocType string // lab_result | imaging | referral | prescription | other
+	Values  []Value
+}
+
+// Analyze 認識したテキストから文書の種類を推定し、検査項目の結果と日付を抽出する
+func Analyze(text string) Analysis {
+	values := extractTests(text)
+	values = append(values, extractDates(text)...)
+	return Analysis{DocType: classify(text, values), Values: values}
+}
+
+// docTypeKeywords 文書の種類ごとの手がかりとなる語句（英字は小文字で比較）
+var docTypeKeywords = map[string][]string{
+	"lab_result":   {"検査結果", "検査報告", "基準値", "血液検査", "生化学", "尿検査", "検体", "reference range", "laboratory", "lab result"},
+	"imaging":      {"読影", "画像診断", "レントゲン", "x線", "単純ct", "造影", "mri", "超音波", "エコー", "radiology", "impression"},
+	"referral":     {"紹介状", "診療情報提供書", "御侍史", "御机下", "紹介目的", "ご紹介", "referral", "dear dr"},
+	"prescription": {"処方箋", "処方せん", "院外処方", "用法", "用量", "調剤", "日分", "prescription", "dispense"},
+}
+
+// docTypeOrder 得点が同じ場合の優先順
+var docTypeOrder = []string{"referral", "prescription", "imaging", "lab_result"}
+
+// classify 語句の出現数（検査結果は抽出した検査項目の数も加える）で文書の種類を推定する
+func classify(text string, values []Value) string {
+	lower := strings.ToLower(text)
+	scores := make(map[string]int, len(docTypeKeywords))
+	for docType, keywords := range docTypeKeywords {
+		for _, keyword := range keywords {
+			scores[docType] += strings.Count(lower, keyword)
+		}
+	}
+	for _, value := range values {
+		if value.Kind == ValueTest {
+			scores["lab_result"]++
+		}
+	}
+
+	best, bestScore := "other", 0
+	for _, docType := range docTypeOrder {
+		if scores[docType] > bestScore {
+			best, bestScore = docType, scores[docType]
+		}
+	}
+	return best
+}
+
+// labTest 検査項目と表記の揺れ
+type labTest struct {
+	name    string
+	aliases []string
+}
+
+var labTests = []labTest{
+	{"HbA1c", []string{"HbA1c", "ヘモグロビンA1c", "A1c"}},
+	{"血糖", []string{"空腹時血糖", "随時血糖", "血糖", "Glucose", "GLU", "FBS"}},
+	{"AST", []string{"AST", "GOT"}},
+	{"ALT", []string{"ALT", "GPT"}},
+	{"γ-GTP", []string{"γ-GTP", "γ-GT", "γGTP", "GGT"}},
+	{"ALP", []string{"ALP"}},
+	{"LDH", []string{"LDH", "LD"}},
+	{"総ビリルビン", []string{"総ビリルビン", "T-Bil", "T-BIL"}},
+	{"総蛋白", []string{"総蛋白", "総タンパク", "TP"}},
+	{"アルブミン", []string{"アルブミン", "ALB", "Alb"}},
+	{"総コレステロール", []string{"総コレステロール", "T-Cho", "T-CHO", "TC"}},
+	{"LDLコレステロール", []string{"LDLコレステロール", "LDL-C", "LDL"}},
+	{"HDLコレステロール", []string{"HDLコレステロール", "HDL-C", "HDL"}},
+	{"中性脂肪", []string{"中性脂肪", "トリグリセライド", "TG"}},
+	{"尿素窒素", []string{"尿素窒素", "BUN", "UN"}},
+	{"クレアチニン", []string{"クレアチニン", "CRE", "Cre", "Cr"}},
+	{"eGFR", []string{"eGFR"}},
+	{"尿酸", []string{"尿酸", "UA"}},
+	{"ナトリウム", []string{"ナトリウム", "Na"}},
+	{"カリウム", []string{"カリウム", "K"}},
+	{"クロール", []string{"クロール", "Cl"}},
+	{"CRP", []string{"CRP"}},
+	{"白血球数", []string{"白血球数", "白血球", "WBC"}},
+	{"赤血球数", []string{"赤血球数", "赤血球", "RBC"}},
+	{"ヘモグロビン", []string{"ヘモグロビン", "血色素量", "Hb", "Hgb"}},
+	{"ヘマトクリット", []string{"ヘマトクリット", "Ht", "Hct"}},
+	{"血小板数", []string{"血小板数", "血小板", "PLT", "Plt"}},
+	{"TSH", []string{"TSH"}},
+	{"FT4", []string{"FT4", "F-T4"}},
+	{"PSA", []string{"PSA"}},
+}
+
+// testResultPattern 検査項目名（括弧内の別名を含む）に続く結果（値・単位・H/L）
+var testResultPattern = regexp.MustCompile(`^(?:\s*[(（][^)）]*[)）])?[\s:：=]*([<>≦≧]?\s*-?\d+(?:\.\d+)?)\s*([HhLl](?:\s|$))?\s*((?:[a-zA-Zμµ%]|10\^?\d|/|\.)+(?:/[a-zA-Zμµ0-9.]+)?)?\s*([HL](?:\s|$))?`)
+
+// extractTests 行ごとに検査項目名とその直後の値を抽出する（同じ項目は最初の結果のみ）
+func extractTests(text string) []Value {
+	var values []Value
+	found := make(map[string]bool)
+	for _, line := range strings.Split(text, "\n") {
+		for _, test := range labTests {
+			if found[test.name] {
+				continue
+			}
+			if value, ok := matchTest(line, test); ok {
+				found[test.name] = true
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func matchTest(line string, test labTest) (Value, bool) {
+	for _, alias := range test.aliases {
+		for start := 0; start < len(line); {
+			index := strings.Index(line[start:], alias)
+			if index < 0 {
+				break
+			}
+			begin := start + index
+			end := begin + len(alias)
+			start = end
+			// 英字の表記は前後が英字でない場合のみ（HbA1cのHb・Naと他の語の一部を区別する）
+			if isASCIILetter(lastByte(line[:begin])) || isASCIILetter(firstByte(line[end:])) {
+				continue
+			}
+			match := testResultPattern.FindStringSubmatch(line[end:])
+			if match == nil {
+				continue
+			}
+			flag := strings.ToUpper(strings.TrimSpace(match[2] + match[4]))
+			return Value{
+				Kind:  ValueTest,
+				Name:  test.name,
+				Value: strings.ReplaceAll(match[1], " ", ""),
+				Unit:  match[3],
+				Flag:  flag,
+			}, true
+		}
+	}
+	return Value{}, false
+}
+
+func isASCIILetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func lastByte(s string) byte {
+	if s == "" {
+		return 0
+	}
+	return s[len(s)-1]
+}
+
+func firstByte(s string) byte {
+	if s == "" {
+		return 0
+	}
+	return s[0]
+}
+
+var (
+	// 2024-04-01 / 2024/4/1 / 2024.04.01 / 2024年4月1日
+	westernDatePattern = regexp.MustCompile(`(\d{4})\s*[-/.年]\s*(\d{1,2})\s*[-/.月]\s*(\d{1,2})\s*日?`)
+	// 令和6年4月1日（R6.4.1）
+	reiwaDatePattern = regexp.MustCompile(`(?:令和|R)\s*(\d{1,2}|元)\s*[年.]\s*(\d{1,2})\s*[月.]\s*(\d{1,2})\s*日?`)
+	// 日付の直前の見出し（採取日: 等）
+	dateLabelPattern = regexp.MustCompile(`([^\s:：]{0,10}(?:日|[Dd]ate))\s*[:：]?\s*$`)
+)
+
+// extractDates 日付を抽出する（同じ日付は最初のもののみ、見出しがあれば名称とする）
+func extractDates(text string) []Value {
+	var values []Value
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(text, "\n") {
+		var matches []dateMatch
+		for _, m := range westernDatePattern.FindAllStringSubmatchIndex(line, -1) {
+			year, _ := strconv.Atoi(line[m[2]:m[3]])
+			matches = append(matches, dateMatch{start: m[0], year: year, month: line[m[4]:m[5]], day: line[m[6]:m[7]]})
+		}
+		for _, m := range reiwaDatePattern.FindAllStringSubmatchIndex(line, -1) {
+			year := 1
+			if era := line[m[2]:m[3]]; era != "元" {
+				year, _ = strconv.Atoi(era)
+			}
+			matches = append(matches, dateMatch{start: m[0], year: 2018 + year, month: line[m[4]:m[5]], day: line[m[6]:m[7]]})
+		}
+		sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+		for _, m := range matches {
+			month, _ := strconv.Atoi(m.month)
+			day, _ := strconv.Atoi(m.day)
+			date := time.Date(m.year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+			// 存在しない日付（繰り上がったもの）や範囲外の年は除く
+			if date.Month() != time.Month(month) || date.Day() != day || m.year < 1900 || m.year > 2100 {
+				continue
+			}
+			key := date.Format("2006-01-02")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			name := ""
+			if label := dateLabelPattern.FindStringSubmatch(line[:m.start]); label != nil {
+				name = label[1]
+			}
+			values = append(values, Value{Kind: ValueDate, Name: name, Value: key, Date: &date})
+		}
+	}
+	return values
+}
+
+type dateMatch struct {
+	start int
+	year  int
+	month string
+	day   string
+}
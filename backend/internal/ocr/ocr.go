@@ -0,0 +1,157 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Provider 文字認識（OCR）の事業者
+type Provider interface {
+	Name() string
+	Recognize(ctx context.Context, path, contentType string) (string, error)
+}
+
+// Config 文字認識の設定
+type Config struct {
+	Provider string // none | ocrspace
+	APIURL   string
+	APIKey   string
+	Language string // 認識する言語（OCR.spaceの言語コード、例: jpn, eng）
+	Timeout  time.Duration
+}
+
+// NewProvider 設定に応じた事業者の作成（noneの場合はnilを返し、文字認識を無効にする）
+func NewProvider(cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case "", "none":
+		return nil, nil
+	case "ocrspace":
+		if cfg.APIKey == "" {
+			return nil, errors.New("OCR API key is required for the ocrspace provider")
+		}
+		return NewOCRSpaceProvider(cfg.APIURL, cfg.APIKey, cfg.Language, cfg.Timeout), nil
+	}
+	return nil, fmt.Errorf("unknown OCR provider: %s", cfg.Provider)
+}
+
+// OCRSpaceProvider OCR.space の文字認識API（PDF・画像に対応、PDFは全ページを認識する）
+type OCRSpaceProvider struct {
+	apiURL   string
+	apiKey   string
+	language string
+	client   *http.Client
+}
+
+func NewOCRSpaceProvider(apiURL, apiKey, language string, timeout time.Duration) *OCRSpaceProvider {
+	return &OCRSpaceProvider{
+		apiURL:   apiURL,
+		apiKey:   apiKey,
+		language: language,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *OCRSpaceProvider) Name() string { return "ocrspace" }
+
+type ocrSpaceResponse struct {
+	ParsedResults []struct {
+		ParsedText string `json:"ParsedText"`
+	} `json:"ParsedResults"`
+	IsErroredOnProcessing bool            `json:"IsErroredOnProcessing"`
+	ErrorMessage          json.RawMessage `json:"ErrorMessage"` // 文字列または文字列の配列
+}
+
+var ocrSpaceFileTypes = map[string]string{
+	"application/pdf": "PDF",
+	"image/jpeg":      "JPG",
+	"image/png":       "PNG",
+}
+
+func (p *OCRSpaceProvider) Recognize(ctx context.Context, path, contentType string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return "", err
+	}
+	fields := map[string]string{
+		"language":  p.language,
+		"filetype":  ocrSpaceFileTypes[contentType],
+		"scale":     "true",
+		"OCREngine": "2",
+	}
+	for key, value := range fields {
+		if value == "" {
+			continue
+		}
+		if err := writer.WriteField(key, value); err != nil {
+			return "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("apikey", p.apiKey)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("OCR API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var result ocrSpaceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("invalid OCR response: %v", err)
+	}
+	if result.IsErroredOnProcessing {
+		return "", fmt.Errorf("OCR failed: %s", errorMessage(result.ErrorMessage))
+	}
+
+	pages := make([]string, 0, len(result.ParsedResults))
+	for _, parsed := range result.ParsedResults {
+		pages = append(pages, strings.TrimSpace(parsed.ParsedText))
+	}
+	return strings.Join(pages, "\n\n"), nil
+}
+
+func errorMessage(raw json.RawMessage) string {
+	var messages []string
+	if err := json.Unmarshal(raw, &messages); err == nil {
+		return strings.Join(messages, "; ")
+	}
+	var message string
+	if err := json.Unmarshal(raw, &message); err == nil {
+		return message
+	}
+	return "unknown error"
+}
@@ -0,0 +1,301 @@
+// Package openapi APIのルートの一覧からのOpenAPI 3.0のドキュメントの生成
+// リクエスト・レスポンスのスキーマはGoの型（jsonタグ・bindingタグ）から求める
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Document OpenAPIのドキュメント
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Servers    []Server                         `json:"servers,omitempty"`
+	Tags       []Tag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+	Security   []map[string][]string            `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+type Operation struct {
+	Tags        []string               `json:"tags,omitempty"`
+	Summary     string                 `json:"summary,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  []*Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody           `json:"requestBody,omitempty"`
+	Responses   map[string]*Response   `json:"responses"`
+	Security    *[]map[string][]string `json:"security,omitempty"` // 空の場合は認証不要
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema JSONスキーマ（OpenAPI 3.0のサブセット）
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Route ドキュメントに載せるAPIの定義
+type Route struct {
+	Method      string
+	Path        string // Ginの形式（:id・*key）、サーバーのURLからの相対パス
+	Tag         string
+	Summary     string
+	Description string
+	Public      bool    // 認証不要
+	PathParams  []Param // パスパラメータの型・説明（指定しない場合はIDのみ整数とみなす）
+	Query       []Param
+	Body        interface{} // JSONのリクエストの型の値
+	Form        []Param     // multipart/form-data の項目（Type が file の場合はファイル）
+	Status      int         // 成功時のステータス（0の場合は200）
+	Response    interface{} // 成功時のレスポンス（Fields・Page・型の値、nilの場合は本文なし）
+	Produces    string      // JSON以外のレスポンスの Content-Type（ファイルのダウンロード等）
+}
+
+// Param パラメータ（Type は string / integer / boolean / file）
+type Param struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+	Enum        []string
+}
+
+// Fields レスポンスのオブジェクトの項目（値は型を表す値、例: "" は文字列）
+type Fields map[string]interface{}
+
+// Page 一覧のレスポンスの共通の形式（items・total・page・per_page・next_cursor）
+type Page struct {
+	Item  interface{}
+	Extra Fields // 一覧以外に返す項目
+}
+
+// エラーのレスポンスのスキーマ名とBearer認証の名前
+const (
+	errorSchemaName = "Error"
+	bearerAuthName  = "bearerAuth"
+)
+
+var ginParamPattern = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// Build ルートの一覧からのドキュメントの生成（tags はタグの表示順と説明）
+func Build(info Info, serverURL string, tags []Tag, routes []Route) *Document {
+	g := newGenerator()
+	g.schemas[errorSchemaName] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"error": {Type: "string"},
+			"code":  {Type: "string", Description: "機械的に判別するためのエラーの種類（一部のエラーのみ）"},
+		},
+		Required: []string{"error"},
+	}
+
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Servers: []Server{{URL: serverURL}},
+		Tags:    tags,
+		Paths:   make(map[string]map[string]*Operation),
+		Components: Components{
+			Schemas: g.schemas,
+			SecuritySchemes: map[string]*SecurityScheme{
+				bearerAuthName: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		Security: []map[string][]string{{bearerAuthName: {}}},
+	}
+
+	for _, route := range routes {
+		path := ginParamPattern.ReplaceAllString(route.Path, "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*Operation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = g.operation(route)
+	}
+	return doc
+}
+
+func (g *generator) operation(route Route) *Operation {
+	op := &Operation{
+		Summary:     route.Summary,
+		Description: route.Description,
+		Responses:   make(map[string]*Response),
+	}
+	if route.Tag != "" {
+		op.Tags = []string{route.Tag}
+	}
+	if route.Public {
+		op.Security = &[]map[string][]string{}
+	}
+
+	for _, match := range ginParamPattern.FindAllStringSubmatch(route.Path, -1) {
+		op.Parameters = append(op.Parameters, pathParameter(match[1], route.PathParams))
+	}
+	for _, param := range route.Query {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Required:    param.Required,
+			Schema:      paramSchema(param),
+		})
+	}
+
+	switch {
+	case route.Body != nil:
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: g.schemaOf(reflect.TypeOf(route.Body))}},
+		}
+	case len(route.Form) > 0:
+		form := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for _, param := range route.Form {
+			schema := paramSchema(param)
+			schema.Description = param.Description
+			form.Properties[param.Name] = schema
+			if param.Required {
+				form.Required = append(form.Required, param.Name)
+			}
+		}
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"multipart/form-data": {Schema: form}},
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := &Response{Description: http.StatusText(status)}
+	switch {
+	case route.Produces != "":
+		response.Content = map[string]*MediaType{route.Produces: {Schema: &Schema{Type: "string", Format: "binary"}}}
+	case route.Response != nil:
+		response.Content = map[string]*MediaType{"application/json": {Schema: g.responseSchema(route.Response)}}
+	}
+	op.Responses[strconv.Itoa(status)] = response
+	op.Responses["default"] = &Response{
+		Description: "エラー",
+		Content:     map[string]*MediaType{"application/json": {Schema: &Schema{Ref: schemaRef(errorSchemaName)}}},
+	}
+	return op
+}
+
+// responseSchema レスポンスの定義（Fields・Page・型の値）のスキーマ
+func (g *generator) responseSchema(response interface{}) *Schema {
+	switch r := response.(type) {
+	case Fields:
+		return g.fieldsSchema(r)
+	case Page:
+		schema := g.fieldsSchema(r.Extra)
+		schema.Properties["items"] = &Schema{Type: "array", Items: g.schemaOf(reflect.TypeOf(r.Item))}
+		schema.Properties["total"] = &Schema{Type: "integer", Format: "int64"}
+		schema.Properties["page"] = &Schema{Type: "integer"}
+		schema.Properties["per_page"] = &Schema{Type: "integer"}
+		schema.Properties["next_cursor"] = &Schema{Type: "string", Nullable: true, Description: "次のページの取得に指定するカーソル（次のページがない場合はnull）"}
+		return schema
+	default:
+		return g.schemaOf(reflect.TypeOf(response))
+	}
+}
+
+func (g *generator) fieldsSchema(fields Fields) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		schema.Properties[name] = g.responseSchema(fields[name])
+	}
+	return schema
+}
+
+func pathParameter(name string, overrides []Param) *Parameter {
+	for _, param := range overrides {
+		if param.Name == name {
+			return &Parameter{Name: name, In: "path", Description: param.Description, Required: true, Schema: paramSchema(param)}
+		}
+	}
+	schema := &Schema{Type: "string"}
+	if name == "id" || strings.HasSuffix(name, "Id") {
+		schema = &Schema{Type: "integer"}
+	}
+	return &Parameter{Name: name, In: "path", Required: true, Schema: schema}
+}
+
+func paramSchema(param Param) *Schema {
+	switch param.Type {
+	case "file":
+		return &Schema{Type: "string", Format: "binary"}
+	case "":
+		return &Schema{Type: "string", Enum: param.Enum}
+	default:
+		return &Schema{Type: param.Type, Enum: param.Enum}
+	}
+}
+
+func schemaRef(name string) string {
+	return "#/components/schemas/" + name
+}
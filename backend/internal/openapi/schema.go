@@ -0,0 +1,152 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// コンポーネント名に使えない文字（ジェネリック型の型引数など）
+var invalidSchemaNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// generator Goの型からのスキーマの生成（名前付きの構造体はコンポーネントとして登録して参照する）
+type generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newGenerator() *generator {
+	return &generator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// schemaOf 型のスキーマ（encoding/json と同じ規則で項目名を求める）
+func (g *generator) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		// 任意のJSON
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &Schema{Ref: schemaRef(g.register(t))}
+	default:
+		// interface{} など（ロールにより内容が異なる項目）
+		return &Schema{}
+	}
+}
+
+// register 名前付きの構造体のコンポーネントへの登録（循環する参照に対応するため、項目より先に名前を登録する）
+func (g *generator) register(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := invalidSchemaNameChars.ReplaceAllString(t.Name(), "_")
+	if _, taken := g.schemas[name]; taken {
+		// 別のパッケージの同名の型はパッケージ名で区別する
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	g.names[t] = name
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.structSchema(t)
+	return name
+}
+
+// structSchema 構造体の項目のスキーマ（埋め込みの構造体の項目は展開し、同名の項目は外側を優先する）
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.schemaOf(field.Type)
+		rules := strings.Split(field.Tag.Get("binding"), ",")
+		for _, rule := range rules {
+			if rule == "required" {
+				schema.Required = append(schema.Required, name)
+			}
+			if values, ok := strings.CutPrefix(rule, "oneof="); ok && property.Ref == "" {
+				property.Enum = strings.Fields(values)
+			}
+		}
+		schema.Properties[name] = property
+	}
+
+	for _, et := range embedded {
+		inner := g.structSchema(et)
+		for name, property := range inner.Properties {
+			if _, ok := schema.Properties[name]; !ok {
+				schema.Properties[name] = property
+			}
+		}
+		for _, name := range inner.Required {
+			if !slices.Contains(schema.Required, name) {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+	}
+	return schema
+}
@@ -0,0 +1,300 @@
+// Package payments 診療費の決済（Stripe PaymentIntents）
+//
+// 予約の確定時に請求額の PaymentIntent を作成し、患者はフロントエンドで client_secret を使って支払う。
+// 支払いの結果は Webhook で受け取り、署名を検証してから反映する。
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PaymentIntent の状態（Stripeの status をまとめたもの）
+const (
+	StatusRequiresPayment = "requires_payment"
+	StatusProcessing      = "processing"
+	StatusSucceeded       = "succeeded"
+	StatusFailed          = "failed"
+	StatusCancelled       = "cancelled"
+)
+
+// ErrInvalidSignature Webhookの署名が一致しない・期限切れ
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// IntentParams 作成する PaymentIntent
+type IntentParams struct {
+	Amount         int64  // 最小通貨単位（円の場合は円）
+	Currency       string // ISO 4217（小文字）
+	Description    string
+	Metadata       map[string]string
+	IdempotencyKey string // 同じ請求で重複して作成しないためのキー
+}
+
+// Intent 作成した PaymentIntent
+type Intent struct {
+	ID           string
+	ClientSecret string
+	Status       string
+	Amount       int64
+	Currency     string
+}
+
+// Event Webhookで受け取った PaymentIntent の状態の変化
+type Event struct {
+	ID             string
+	Type           string
+	IntentID       string
+	Status         string // 状態の変化を伴わないイベントの場合は空
+	Amount         int64
+	Currency       string // ISO 4217（小文字）
+	FailureMessage string
+}
+
+// Gateway 決済代行サービス
+type Gateway interface {
+	Name() string
+	CreateIntent(ctx context.Context, params IntentParams) (*Intent, error)
+	ParseWebhook(payload []byte, signature string) (*Event, error)
+	PublishableKey() string
+}
+
+// Config 決済代行サービスの設定
+type Config struct {
+	Provider         string // none | stripe
+	APIURL           string
+	SecretKey        string
+	PublishableKey   string
+	WebhookSecret    string
+	WebhookTolerance time.Duration // Webhookの署名の時刻の許容範囲
+	Timeout          time.Duration
+}
+
+// NewGateway 設定に応じた決済代行サービスの作成（noneの場合はnilを返し、オンライン決済を無効にする）
+func NewGateway(cfg Config) (Gateway, error) {
+	switch cfg.Provider {
+	case "", "none":
+		return nil, nil
+	case "stripe":
+		if cfg.SecretKey == "" {
+			return nil, errors.New("stripe secret key is required")
+		}
+		if cfg.WebhookSecret == "" {
+			return nil, errors.New("stripe webhook secret is required")
+		}
+		return NewStripeGateway(cfg), nil
+	}
+	return nil, fmt.Errorf("unknown payment provider: %s", cfg.Provider)
+}
+
+// StripeGateway Stripe API による決済
+type StripeGateway struct {
+	apiURL           string
+	secretKey        string
+	publishableKey   string
+	webhookSecret    string
+	webhookTolerance time.Duration
+	client           *http.Client
+}
+
+func NewStripeGateway(cfg Config) *StripeGateway {
+	apiURL := strings.TrimRight(cfg.APIURL, "/")
+	if apiURL == "" {
+		apiURL = "https://api.stripe.com"
+	}
+	tolerance := cfg.WebhookTolerance
+	if tolerance <= 0 {
+		tolerance = 5 * time.Minute
+	}
+	return &StripeGateway{
+		apiURL:           apiURL,
+		secretKey:        cfg.SecretKey,
+		publishableKey:   cfg.PublishableKey,
+		webhookSecret:    cfg.WebhookSecret,
+		webhookTolerance: tolerance,
+		client:           &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (g *StripeGateway) Name() string { return "stripe" }
+
+// PublishableKey フロントエンドで Stripe.js を初期化するための公開可能キー
+func (g *StripeGateway) PublishableKey() string { return g.publishableKey }
+
+// stripeIntent Stripe API の PaymentIntent オブジェクト（使用する項目のみ）
+type stripeIntent struct {
+	ID               string `json:"id"`
+	ClientSecret     string `json:"client_secret"`
+	Status           string `json:"status"`
+	Amount           int64  `json:"amount"`
+	Currency         string `json:"currency"`
+	LastPaymentError *struct {
+		Message string `json:"message"`
+	} `json:"last_payment_error"`
+}
+
+func (g *StripeGateway) CreateIntent(ctx context.Context, params IntentParams) (*Intent, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(params.Amount, 10))
+	form.Set("currency", strings.ToLower(params.Currency))
+	form.Set("automatic_payment_methods[enabled]", "true")
+	if params.Description != "" {
+		form.Set("description", params.Description)
+	}
+	for key, value := range params.Metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.apiURL+"/v1/payment_intents", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+g.secretKey)
+	if params.IdempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", params.IdempotencyKey)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, fmt.Errorf("stripe API returned %d: %s", resp.StatusCode, apiErr.Error.Message)
+		}
+		return nil, fmt.Errorf("stripe API returned %d", resp.StatusCode)
+	}
+
+	var intent stripeIntent
+	if err := json.NewDecoder(resp.Body).Decode(&intent); err != nil {
+		return nil, fmt.Errorf("invalid stripe response: %v", err)
+	}
+	return &Intent{
+		ID:           intent.ID,
+		ClientSecret: intent.ClientSecret,
+		Status:       intentStatus(intent.Status),
+		Amount:       intent.Amount,
+		Currency:     intent.Currency,
+	}, nil
+}
+
+// ParseWebhook Stripe-Signature ヘッダーを検証し、PaymentIntent のイベントを読み取る
+func (g *StripeGateway) ParseWebhook(payload []byte, signature string) (*Event, error) {
+	if err := g.verifySignature(payload, signature, time.Now()); err != nil {
+		return nil, err
+	}
+
+	var event struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			Object json.RawMessage `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %v", err)
+	}
+
+	result := &Event{ID: event.ID, Type: event.Type}
+	if !strings.HasPrefix(event.Type, "payment_intent.") {
+		return result, nil
+	}
+
+	var intent stripeIntent
+	if err := json.Unmarshal(event.Data.Object, &intent); err != nil {
+		return nil, fmt.Errorf("invalid payment intent in webhook: %v", err)
+	}
+	result.IntentID = intent.ID
+	result.Amount = intent.Amount
+	result.Currency = strings.ToLower(intent.Currency)
+	switch event.Type {
+	case "payment_intent.succeeded":
+		result.Status = StatusSucceeded
+	case "payment_intent.processing":
+		result.Status = StatusProcessing
+	case "payment_intent.payment_failed":
+		result.Status = StatusFailed
+		if intent.LastPaymentError != nil {
+			result.FailureMessage = intent.LastPaymentError.Message
+		}
+	case "payment_intent.canceled":
+		result.Status = StatusCancelled
+	}
+	return result, nil
+}
+
+// verifySignature "t=<時刻>,v1=<署名>" 形式のヘッダーの検証（署名は "<時刻>.<本文>" のHMAC-SHA256）
+func (g *StripeGateway) verifySignature(payload []byte, header string, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if diff := now.Sub(time.Unix(seconds, 0)); diff > g.webhookTolerance || diff < -g.webhookTolerance {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(g.webhookSecret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// intentStatus Stripeの status を決済の状態に変換する
+func intentStatus(status string) string {
+	switch status {
+	case "succeeded":
+		return StatusSucceeded
+	case "processing":
+		return StatusProcessing
+	case "canceled":
+		return StatusCancelled
+	default:
+		// requires_payment_method / requires_confirmation / requires_action / requires_capture
+		return StatusRequiresPayment
+	}
+}
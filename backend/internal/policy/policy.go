@@ -0,0 +1,79 @@
+// Package policy ロールによるアクセス制御の方針
+//
+// ルートグループ単位の制限（/doctors/me/* は医師のみ、/admin/* は管理者のみ等）をここで定義し、
+// ルーターのミドルウェアとサービスの権限確認の両方から参照する。
+// 予約の担当者かどうか等、個々のデータに対する権限の確認は引き続き各サービスで行う。
+//
+// 組織（医療機関）の管理者がスタッフに委任するロールは組織ごとに付与し、
+// 組織に所属する医師の予約・請求・監査ログに限って操作を許可する。
+package policy
+
+import "online_medical_consultation_app/backend/internal/models"
+
+// ロール
+const (
+	RolePatient     = "patient"
+	RoleDoctor      = "doctor"
+	RoleInterpreter = "interpreter"
+	RoleAdmin       = "admin"
+	RoleStaff       = "staff" // 医師・患者以外の職員（組織で委任されたロールの範囲でのみ操作できる）
+)
+
+// 組織の管理者がスタッフに委任するロール
+const (
+	OrgRoleScheduler    = "scheduler"     // 予約の管理
+	OrgRoleBillingClerk = "billing_clerk" // 請求の閲覧
+	OrgRoleAuditor      = "auditor"       // 監査ログの閲覧
+)
+
+// 組織内の操作の権限
+const (
+	OrgPermManage       = "manage"       // 所属するスタッフとロールの管理（組織の管理者）
+	OrgPermAppointments = "appointments" // 所属する医師の予約の一覧・キャンセル
+	OrgPermBilling      = "billing"      // 所属する医師の予約の請求の一覧
+	OrgPermAudit        = "audit"        // 所属するメンバーの操作の監査ログの閲覧
+)
+
+// OrgRoles 委任できるロール
+var OrgRoles = []string{OrgRoleScheduler, OrgRoleBillingClerk, OrgRoleAuditor}
+
+// 委任されたロールごとに許可する組織内の操作
+var orgRolePermissions = map[string][]string{
+	OrgRoleScheduler:    {OrgPermAppointments},
+	OrgRoleBillingClerk: {OrgPermBilling},
+	OrgRoleAuditor:      {OrgPermAudit},
+}
+
+// ルートグループごとにアクセスを許可するロール
+var (
+	DoctorSelf      = []string{RoleDoctor}            // /doctors/me/*
+	PatientSelf     = []string{RolePatient}           // /patients/*
+	InterpreterSelf = []string{RoleInterpreter}       // /interpreters/me/*
+	Admin           = []string{RoleAdmin}             // /admin/*・監査ログ
+	BreakGlass      = []string{RoleDoctor, RoleAdmin} // 緊急時アクセス（医師は個別の許可も必要）
+)
+
+// Allows ロールがいずれかの許可されたロールに該当するかどうか
+func Allows(role string, allowed ...string) bool {
+	for _, r := range allowed {
+		if role == r {
+			return true
+		}
+	}
+	return false
+}
+
+// OrgAllows 委任されたロールのいずれかが組織内の操作を許可するかどうか
+func OrgAllows(roles []string, permission string) bool {
+	for _, role := range roles {
+		if Allows(permission, orgRolePermissions[role]...) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin 利用者が管理者かどうか（nilの場合はfalse）
+func IsAdmin(user *models.User) bool {
+	return user != nil && user.Role == RoleAdmin
+}
@@ -0,0 +1,214 @@
+package prom
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 重症度（低い順）
+const (
+	SeverityMinimal          = "minimal"
+	SeverityMild             = "mild"
+	SeverityModerate         = "moderate"
+	SeverityModeratelySevere = "moderately_severe"
+	SeveritySevere           = "severe"
+)
+
+// Option 回答の選択肢と点数
+type Option struct {
+	Value int    `json:"value"`
+	Label string `json:"label"`
+}
+
+// Question 質問票の項目
+type Question struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// Band 合計点による重症度の区分（Min点以上）
+type Band struct {
+	Min      int    `json:"min"`
+	Severity string `json:"severity"`
+	Label    string `json:"label"`
+}
+
+// ItemAlert 合計点によらず医師へ通知する項目の回答（希死念慮等）
+type ItemAlert struct {
+	QuestionID string
+	Min        int
+	Message    string
+}
+
+// Instrument 患者報告アウトカム（PROM）の質問票
+type Instrument struct {
+	Code         string     `json:"code"`
+	Name         string     `json:"name"`
+	Instructions string     `json:"instructions"`
+	Questions    []Question `json:"questions"`
+	Options      []Option   `json:"options"` // 全項目で共通の選択肢
+	Bands        []Band     `json:"bands"`   // Minの昇順
+	// DefaultAlertThreshold 医師が指定しない場合の通知の基準点（この点数以上で通知）
+	DefaultAlertThreshold int `json:"default_alert_threshold"`
+	// WorseningDelta 前回の回答からこの点数以上増加した場合に悪化として通知する
+	WorseningDelta int         `json:"worsening_delta"`
+	ItemAlerts     []ItemAlert `json:"-"`
+}
+
+// Result 採点結果
+type Result struct {
+	Score    int
+	Severity string
+	Alerts   []string // 項目の回答による通知の理由
+}
+
+// frequencyOptions この2週間の頻度（PHQ-9・GAD-7共通）
+var frequencyOptions = []Option{
+	{Value: 0, Label: "全くない"},
+	{Value: 1, Label: "数日"},
+	{Value: 2, Label: "半分以上"},
+	{Value: 3, Label: "ほとんど毎日"},
+}
+
+// PHQ9 うつ病の重症度（Patient Health Questionnaire-9）
+var PHQ9 = Instrument{
+	Code:         "phq9",
+	Name:         "PHQ-9（こころとからだの質問票）",
+	Instructions: "この2週間、次のような問題にどのくらい頻繁に悩まされていますか。",
+	Questions: []Question{
+		{ID: "q1", Text: "物事に対してほとんど興味がない、または楽しめない"},
+		{ID: "q2", Text: "気分が落ち込む、憂うつになる、または絶望的な気持ちになる"},
+		{ID: "q3", Text: "寝付きが悪い、途中で目がさめる、または逆に眠り過ぎる"},
+		{ID: "q4", Text: "疲れた感じがする、または気力がない"},
+		{ID: "q5", Text: "あまり食欲がない、または食べ過ぎる"},
+		{ID: "q6", Text: "自分はダメな人間だ、人生の敗北者だと気に病む、または自分自身あるいは家族に申し訳がないと感じる"},
+		{ID: "q7", Text: "新聞を読む、またはテレビを見ることなどに集中することが難しい"},
+		{ID: "q8", Text: "他人が気づくぐらいに動きや話し方が遅くなる、あるいは反対に、そわそわしたり落ちつかず、ふだんよりも動き回ることがある"},
+		{ID: "q9", Text: "死んだ方がましだ、あるいは自分を何らかの方法で傷つけようと思ったことがある"},
+	},
+	Options: frequencyOptions,
+	Bands: []Band{
+		{Min: 0, Severity: SeverityMinimal, Label: "なし〜最小限"},
+		{Min: 5, Severity: SeverityMild, Label: "軽度"},
+		{Min: 10, Severity: SeverityModerate, Label: "中等度"},
+		{Min: 15, Severity: SeverityModeratelySevere, Label: "中等度〜重度"},
+		{Min: 20, Severity: SeveritySevere, Label: "重度"},
+	},
+	DefaultAlertThreshold: 10,
+	WorseningDelta:        5,
+	ItemAlerts: []ItemAlert{
+		{QuestionID: "q9", Min: 1, Message: "希死念慮・自傷の考えあり（項目9）"},
+	},
+}
+
+// GAD7 不安障害の重症度（Generalized Anxiety Disorder-7）
+var GAD7 = Instrument{
+	Code:         "gad7",
+	Name:         "GAD-7（不安の質問票）",
+	Instructions: "この2週間、次のような問題にどのくらい頻繁に悩まされていますか。",
+	Questions: []Question{
+		{ID: "q1", Text: "緊張感、不安感または神経過敏を感じる"},
+		{ID: "q2", Text: "心配することを止められない、または心配をコントロールできない"},
+		{ID: "q3", Text: "いろいろなことを心配しすぎる"},
+		{ID: "q4", Text: "くつろぐことが難しい"},
+		{ID: "q5", Text: "じっとしていることができないほど落ち着かない"},
+		{ID: "q6", Text: "いらいらしやすい、または怒りっぽい"},
+		{ID: "q7", Text: "何か恐ろしいことが起こるのではないかと恐れを感じる"},
+	},
+	Options: frequencyOptions,
+	Bands: []Band{
+		{Min: 0, Severity: SeverityMinimal, Label: "なし〜最小限"},
+		{Min: 5, Severity: SeverityMild, Label: "軽度"},
+		{Min: 10, Severity: SeverityModerate, Label: "中等度"},
+		{Min: 15, Severity: SeveritySevere, Label: "重度"},
+	},
+	DefaultAlertThreshold: 10,
+	WorseningDelta:        4,
+}
+
+// Instruments 医師が割り当てられる質問票
+var Instruments = []Instrument{PHQ9, GAD7}
+
+// Find コードに対応する質問票（該当なしはnil）
+func Find(code string) *Instrument {
+	for i := range Instruments {
+		if Instruments[i].Code == code {
+			return &Instruments[i]
+		}
+	}
+	return nil
+}
+
+// MaxScore 合計点の最大値
+func (i *Instrument) MaxScore() int {
+	highest := 0
+	for _, o := range i.Options {
+		if o.Value > highest {
+			highest = o.Value
+		}
+	}
+	return highest * len(i.Questions)
+}
+
+// SeverityFor 合計点に対応する重症度の区分
+func (i *Instrument) SeverityFor(score int) Band {
+	band := i.Bands[0]
+	for _, b := range i.Bands {
+		if score >= b.Min {
+			band = b
+		}
+	}
+	return band
+}
+
+// Score 回答を検証して採点する（全項目の回答が必須）
+func (i *Instrument) Score(answers map[string]int) (*Result, error) {
+	if err := i.Validate(answers); err != nil {
+		return nil, err
+	}
+
+	result := &Result{Alerts: []string{}}
+	for _, q := range i.Questions {
+		result.Score += answers[q.ID]
+	}
+	result.Severity = i.SeverityFor(result.Score).Severity
+
+	for _, alert := range i.ItemAlerts {
+		if answers[alert.QuestionID] >= alert.Min {
+			result.Alerts = append(result.Alerts, alert.Message)
+		}
+	}
+	return result, nil
+}
+
+// Validate 回答の検証（全項目の回答・選択肢の点数）
+func (i *Instrument) Validate(answers map[string]int) error {
+	known := make(map[string]bool, len(i.Questions))
+	for _, q := range i.Questions {
+		known[q.ID] = true
+
+		value, ok := answers[q.ID]
+		if !ok {
+			return fmt.Errorf("answer required: %s", q.ID)
+		}
+		if !hasOption(i.Options, value) {
+			return fmt.Errorf("invalid answer: %s", q.ID)
+		}
+	}
+
+	for id := range answers {
+		if !known[id] {
+			return errors.New("unknown question: " + id)
+		}
+	}
+	return nil
+}
+
+func hasOption(options []Option, value int) bool {
+	for _, o := range options {
+		if o.Value == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// apnsTokenLifetime 認証トークンを再利用する時間（APNsは20分〜60分ごとの更新を求める）
+const apnsTokenLifetime = 50 * time.Minute
+
+// APNsProvider Apple Push Notification service（トークンベース認証、HTTP/2）
+type APNsProvider struct {
+	key    *ecdsa.PrivateKey
+	keyID  string
+	teamID string
+	topic  string
+	apiURL string
+	client *http.Client
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+func NewAPNsProvider(keyFile, keyID, teamID, topic string, production bool, timeout time.Duration) (*APNsProvider, error) {
+	raw, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid APNs key: %v", err)
+	}
+
+	apiURL := "https://api.sandbox.push.apple.com"
+	if production {
+		apiURL = "https://api.push.apple.com"
+	}
+	return &APNsProvider{
+		key:    key,
+		keyID:  keyID,
+		teamID: teamID,
+		topic:  topic,
+		apiURL: apiURL,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (p *APNsProvider) Name() string { return "apns" }
+
+func (p *APNsProvider) Send(ctx context.Context, token string, n Notification) error {
+	authToken, err := p.authToken()
+	if err != nil {
+		return fmt.Errorf("failed to sign APNs token: %v", err)
+	}
+
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{"title": n.Title, "body": n.Body},
+			"sound": "default",
+		},
+	}
+	for key, value := range n.Data {
+		if key != "aps" {
+			payload[key] = value
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL+"/3/device/"+token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("authorization", "bearer "+authToken)
+	req.Header.Set("apns-topic", p.topic)
+	req.Header.Set("apns-push-type", "alert")
+	if n.Priority == "high" {
+		req.Header.Set("apns-priority", "10")
+	} else {
+		req.Header.Set("apns-priority", "5")
+	}
+	if n.TTL > 0 {
+		req.Header.Set("apns-expiration", strconv.FormatInt(time.Now().Add(n.TTL).Unix(), 10))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var result struct {
+		Reason string `json:"reason"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode == http.StatusGone || result.Reason == "BadDeviceToken" || result.Reason == "Unregistered" {
+		return ErrInvalidToken
+	}
+	return fmt.Errorf("APNs returned %d: %s", resp.StatusCode, result.Reason)
+}
+
+// authToken 認証トークン（ES256で署名したJWT）の取得
+func (p *APNsProvider) authToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Since(p.issuedAt) < apnsTokenLifetime {
+		return p.token, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": p.teamID,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = p.keyID
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		return "", err
+	}
+	p.token = signed
+	p.issuedAt = now
+	return signed, nil
+}
@@ -0,0 +1,202 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// FCMProvider Firebase Cloud Messaging HTTP v1 API（サービスアカウントで取得したアクセストークンを使用）
+type FCMProvider struct {
+	projectID   string
+	clientEmail string
+	privateKey  *rsa.PrivateKey
+	tokenURL    string
+	apiURL      string
+	client      *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+type fcmServiceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+func NewFCMProvider(projectID, credentialsFile, apiURL string, timeout time.Duration) (*FCMProvider, error) {
+	raw, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	var account fcmServiceAccount
+	if err := json.Unmarshal(raw, &account); err != nil {
+		return nil, fmt.Errorf("invalid service account file: %v", err)
+	}
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid service account key: %v", err)
+	}
+	if projectID == "" {
+		projectID = account.ProjectID
+	}
+	if projectID == "" || account.ClientEmail == "" {
+		return nil, errors.New("project ID and client email are required")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	if apiURL == "" {
+		apiURL = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+	}
+
+	return &FCMProvider{
+		projectID:   projectID,
+		clientEmail: account.ClientEmail,
+		privateKey:  privateKey,
+		tokenURL:    account.TokenURI,
+		apiURL:      fmt.Sprintf(apiURL, projectID),
+		client:      &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (p *FCMProvider) Name() string { return "fcm" }
+
+func (p *FCMProvider) Send(ctx context.Context, token string, n Notification) error {
+	accessToken, err := p.token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to obtain FCM access token: %v", err)
+	}
+
+	priority, urgency := "NORMAL", "normal"
+	if n.Priority == "high" {
+		priority, urgency = "HIGH", "high"
+	}
+	android := map[string]interface{}{"priority": priority}
+	webpushHeaders := map[string]string{"Urgency": urgency}
+	if n.TTL > 0 {
+		android["ttl"] = fmt.Sprintf("%ds", int(n.TTL.Seconds()))
+		webpushHeaders["TTL"] = fmt.Sprintf("%d", int(n.TTL.Seconds()))
+	}
+	payload := map[string]interface{}{
+		"message": map[string]interface{}{
+			"token":        token,
+			"notification": map[string]string{"title": n.Title, "body": n.Body},
+			"data":         n.Data,
+			"android":      android,
+			"webpush":      map[string]interface{}{"headers": webpushHeaders},
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var result struct {
+		Error struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("FCM API returned %d", resp.StatusCode)
+	}
+	for _, detail := range result.Error.Details {
+		if detail.ErrorCode == "UNREGISTERED" {
+			return ErrInvalidToken
+		}
+	}
+	if result.Error.Status == "NOT_FOUND" {
+		return ErrInvalidToken
+	}
+	return fmt.Errorf("FCM API returned %d: %s %s", resp.StatusCode, result.Error.Status, result.Error.Message)
+}
+
+// token アクセストークンの取得（有効期限の1分前まで再利用する）
+func (p *FCMProvider) token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.accessToken != "" && time.Now().Before(p.expiresAt.Add(-time.Minute)) {
+		return p.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   p.clientEmail,
+		"scope": fcmScope,
+		"aud":   p.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(p.privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, readError(resp.Body))
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.AccessToken == "" {
+		return "", errors.New("token endpoint returned no access token")
+	}
+	p.accessToken = result.AccessToken
+	p.expiresAt = now.Add(time.Duration(result.ExpiresIn) * time.Second)
+	return p.accessToken, nil
+}
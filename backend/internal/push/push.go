@@ -0,0 +1,70 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken 端末のトークンが無効（アプリの削除・登録解除等）で、登録を削除すべき
+var ErrInvalidToken = errors.New("invalid device token")
+
+// Notification 端末へ送る通知
+type Notification struct {
+	Title    string
+	Body     string
+	Priority string        // normal | high（端末のスリープ中でも即時に届ける）
+	TTL      time.Duration // 0の場合は配信事業者の既定
+	Data     map[string]string
+}
+
+// Provider プッシュ通知の配信事業者
+type Provider interface {
+	Name() string
+	Send(ctx context.Context, token string, n Notification) error
+}
+
+// Config プッシュ通知の設定（認証情報が空の事業者は使用しない）
+type Config struct {
+	FCMProjectID       string
+	FCMCredentialsFile string // サービスアカウントの鍵（JSON）
+	FCMAPIURL          string
+
+	APNsKeyFile    string // 認証キー（.p8）
+	APNsKeyID      string
+	APNsTeamID     string
+	APNsTopic      string // アプリのバンドルID
+	APNsProduction bool   // falseの場合は開発用（sandbox）の環境へ送る
+
+	Timeout time.Duration
+}
+
+// NewProviders 設定に応じたFCM（Android・Web）・APNs（iOS）の作成（設定がない事業者はnil）
+func NewProviders(cfg Config) (fcm Provider, apns Provider, err error) {
+	if cfg.FCMCredentialsFile != "" {
+		provider, err := NewFCMProvider(cfg.FCMProjectID, cfg.FCMCredentialsFile, cfg.FCMAPIURL, cfg.Timeout)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid FCM configuration: %v", err)
+		}
+		fcm = provider
+	}
+	if cfg.APNsKeyFile != "" {
+		if cfg.APNsKeyID == "" || cfg.APNsTeamID == "" || cfg.APNsTopic == "" {
+			return nil, nil, errors.New("APNs key ID, team ID and topic are required")
+		}
+		provider, err := NewAPNsProvider(cfg.APNsKeyFile, cfg.APNsKeyID, cfg.APNsTeamID, cfg.APNsTopic, cfg.APNsProduction, cfg.Timeout)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid APNs configuration: %v", err)
+		}
+		apns = provider
+	}
+	return fcm, apns, nil
+}
+
+func readError(body io.Reader) string {
+	message, _ := io.ReadAll(io.LimitReader(body, 1024))
+	return strings.TrimSpace(string(message))
+}
@@ -0,0 +1,474 @@
+package qrcode
+
+import (
+	"errors"
+)
+
+// 誤り訂正レベルM（約15%の読み取り不良を訂正できる、印刷物向け）のバージョンごとの構成
+// バージョン10（バイトモードで213バイト）までに対応する
+type blockSpec struct {
+	ecPerBlock   int // ブロックあたりの誤り訂正コード語数
+	shortBlocks  int // データコード語数 dataPerBlock のブロック数
+	longBlocks   int // データコード語数 dataPerBlock+1 のブロック数
+	dataPerBlock int
+}
+
+var versionsM = []blockSpec{
+	{}, // バージョンは1から
+	{ecPerBlock: 10, shortBlocks: 1, dataPerBlock: 16},
+	{ecPerBlock: 16, shortBlocks: 1, dataPerBlock: 28},
+	{ecPerBlock: 26, shortBlocks: 1, dataPerBlock: 44},
+	{ecPerBlock: 18, shortBlocks: 2, dataPerBlock: 32},
+	{ecPerBlock: 24, shortBlocks: 2, dataPerBlock: 43},
+	{ecPerBlock: 16, shortBlocks: 4, dataPerBlock: 27},
+	{ecPerBlock: 18, shortBlocks: 4, dataPerBlock: 31},
+	{ecPerBlock: 22, shortBlocks: 2, longBlocks: 2, dataPerBlock: 38},
+	{ecPerBlock: 22, shortBlocks: 3, longBlocks: 2, dataPerBlock: 36},
+	{ecPerBlock: 26, shortBlocks: 4, longBlocks: 1, dataPerBlock: 43},
+}
+
+// 位置合わせパターンの中心座標（バージョン2以降）
+var alignmentPositions = [][]int{
+	nil, nil,
+	{6, 18}, {6, 22}, {6, 26}, {6, 30}, {6, 34},
+	{6, 22, 38}, {6, 24, 42}, {6, 26, 46}, {6, 28, 50},
+}
+
+const (
+	formatBitsM      = 0 // 形式情報の誤り訂正レベルM
+	formatGenerator  = 0x537
+	formatMask       = 0x5412
+	versionGenerator = 0x1F25
+	gfPolynomial     = 0x11D
+)
+
+// Encode 文字列をQRコード（バイトモード・誤り訂正レベルM）に変換する
+// 戻り値は modules[行][列] で、trueが黒（周囲の余白は含まない）
+func Encode(text string) ([][]bool, error) {
+	q, _, err := encode([]byte(text), -1)
+	if err != nil {
+		return nil, err
+	}
+	return q.modules, nil
+}
+
+// encode データの符号化（maskに-1を指定した場合は減点の最も少ないマスクを選び、選んだマスクを返す）
+func encode(data []byte, mask int) (*symbol, int, error) {
+	version := 0
+	for v := 1; v < len(versionsM); v++ {
+		if 4+countBits(v)+len(data)*8 <= versionsM[v].dataCodewords()*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, 0, errors.New("qrcode: data too long")
+	}
+
+	q := newSymbol(version)
+	q.drawFunctionPatterns()
+	q.drawCodewords(q.addErrorCorrection(encodeData(data, version)))
+
+	if mask < 0 {
+		mask = q.bestMask()
+	}
+	q.applyMask(mask)
+	q.drawFormatBits(mask)
+	return q, mask, nil
+}
+
+// bestMask 減点の最も少ないマスク
+func (q *symbol) bestMask() int {
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if penalty := q.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		q.applyMask(mask) // XORのため再適用で元に戻る
+	}
+	return best
+}
+
+func (b blockSpec) dataCodewords() int {
+	return b.shortBlocks*b.dataPerBlock + b.longBlocks*(b.dataPerBlock+1)
+}
+
+// countBits バイトモードの文字数指示子のビット数
+func countBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// encodeData モード指示子・文字数・データ・終端・埋め草のデータコード語
+func encodeData(data []byte, version int) []byte {
+	var bits bitBuffer
+	bits.append(0x4, 4) // バイトモード
+	bits.append(len(data), countBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	capacity := versionsM[version].dataCodewords() * 8
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	return bits.bytes()
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 != 0)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	result := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			result[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return result
+}
+
+type symbol struct {
+	version    int
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+func newSymbol(version int) *symbol {
+	size := 17 + version*4
+	q := &symbol{version: version, size: size}
+	q.modules = make([][]bool, size)
+	q.isFunction = make([][]bool, size)
+	for y := range q.modules {
+		q.modules[y] = make([]bool, size)
+		q.isFunction[y] = make([]bool, size)
+	}
+	return q
+}
+
+func (q *symbol) setFunction(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.isFunction[y][x] = true
+}
+
+// drawFunctionPatterns タイミング・位置検出・位置合わせパターン、形式情報・型番情報の領域
+func (q *symbol) drawFunctionPatterns() {
+	for i := 0; i < q.size; i++ {
+		q.setFunction(6, i, i%2 == 0)
+		q.setFunction(i, 6, i%2 == 0)
+	}
+
+	q.drawFinder(3, 3)
+	q.drawFinder(q.size-4, 3)
+	q.drawFinder(3, q.size-4)
+
+	positions := alignmentPositions[q.version]
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// 位置検出パターンと重なる3か所を除く
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			q.drawAlignment(x, y)
+		}
+	}
+
+	// 領域の確保（値はマスクの選択後に描画する）
+	q.drawFormatBits(0)
+	q.drawVersion()
+}
+
+// drawFinder 位置検出パターンと分離パターン（中心座標を指定）
+func (q *symbol) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || x >= q.size || y < 0 || y >= q.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			q.setFunction(x, y, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawAlignment 位置合わせパターン（中心座標を指定）
+func (q *symbol) drawAlignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			q.setFunction(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits 誤り訂正レベルとマスクの形式情報（BCH(15,5)、2か所）
+func (q *symbol) drawFormatBits(mask int) {
+	data := formatBitsM<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*formatGenerator
+	}
+	bits := (data<<10 | rem) ^ formatMask
+
+	for i := 0; i <= 5; i++ {
+		q.setFunction(8, i, bit(bits, i))
+	}
+	q.setFunction(8, 7, bit(bits, 6))
+	q.setFunction(8, 8, bit(bits, 7))
+	q.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		q.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.setFunction(q.size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunction(8, q.size-15+i, bit(bits, i))
+	}
+	q.setFunction(8, q.size-8, true) // 常に黒のモジュール
+}
+
+// drawVersion 型番情報（バージョン7以降、BCH(18,6)、2か所）
+func (q *symbol) drawVersion() {
+	if q.version < 7 {
+		return
+	}
+	rem := q.version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*versionGenerator
+	}
+	bits := q.version<<12 | rem
+
+	for i := 0; i < 18; i++ {
+		a, b := q.size-11+i%3, i/3
+		q.setFunction(a, b, bit(bits, i))
+		q.setFunction(b, a, bit(bits, i))
+	}
+}
+
+// addErrorCorrection ブロックごとにリード・ソロモン符号を付け、データ・誤り訂正コード語をそれぞれ交互に並べる
+func (q *symbol) addErrorCorrection(data []byte) []byte {
+	spec := versionsM[q.version]
+	divisor := rsDivisor(spec.ecPerBlock)
+
+	var dataBlocks, ecBlocks [][]byte
+	offset := 0
+	for i := 0; i < spec.shortBlocks+spec.longBlocks; i++ {
+		length := spec.dataPerBlock
+		if i >= spec.shortBlocks {
+			length++
+		}
+		block := data[offset : offset+length]
+		offset += length
+		dataBlocks = append(dataBlocks, block)
+		ecBlocks = append(ecBlocks, rsRemainder(block, divisor))
+	}
+
+	result := make([]byte, 0, len(data)+len(ecBlocks)*spec.ecPerBlock)
+	for i := 0; i <= spec.dataPerBlock; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < spec.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// drawCodewords 右下から2列ずつ上下に折り返しながら機能パターン以外へコード語を配置する
+func (q *symbol) drawCodewords(codewords []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // 縦のタイミングパターンの列を飛ばす
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < q.size; vert++ {
+			y := vert
+			if upward {
+				y = q.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if q.isFunction[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				q.modules[y][x] = codewords[i/8]>>(7-i%8)&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+// applyMask 機能パターン以外のモジュールをマスクパターンで反転する
+func (q *symbol) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !q.isFunction[y][x] {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty マスクの評価（同色の連続・2×2の塊・位置検出パターンに似た並び・黒の比率の偏り）
+func (q *symbol) penalty() int {
+	penalty := 0
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return q.modules[x][y]
+		}
+		return q.modules[y][x]
+	}
+
+	for _, vertical := range []bool{false, true} {
+		for y := 0; y < q.size; y++ {
+			run := 1
+			for x := 1; x <= q.size; x++ {
+				if x < q.size && at(x, y, vertical) == at(x-1, y, vertical) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += 3 + run - 5
+				}
+				run = 1
+			}
+
+			for x := 0; x+11 <= q.size; x++ {
+				if matchesFinderLike(func(i int) bool { return at(x+i, y, vertical) }) {
+					penalty += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < q.size && y+1 < q.size {
+				c := q.modules[y][x]
+				if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+					penalty += 3
+				}
+			}
+		}
+	}
+	total := q.size * q.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return penalty + k*10
+}
+
+var finderLike = [2][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+func matchesFinderLike(at func(int) bool) bool {
+	for _, pattern := range finderLike {
+		matched := true
+		for i, dark := range pattern {
+			if at(i) != dark {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// rsDivisor リード・ソロモン符号の生成多項式（最高次の係数1を除く）
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder データを生成多項式で割った余り（誤り訂正コード語）
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coefficient := range divisor {
+			result[i] ^= gfMultiply(coefficient, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply GF(2^8)（既約多項式 x^8+x^4+x^3+x^2+1）の乗算
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*gfPolynomial
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+func bit(value, i int) bool {
+	return value>>i&1 != 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
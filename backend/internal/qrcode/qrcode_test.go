@@ -0,0 +1,148 @@
+package qrcode
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// バイトモード・誤り訂正レベルMのバージョンごとの最大バイト数
+var capacityM = []int{0, 14, 26, 42, 62, 84, 106, 122, 152, 180, 213}
+
+// testText 全てのバイト値を含むテスト用のデータ
+func testText(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte((i*37 + 11) % 256)
+	}
+	return string(b)
+}
+
+type goldenSymbol struct {
+	version, mask, length int
+	rows                  []string
+}
+
+// loadGolden 別の実装（rsc.io/qr/coding）で生成した、バージョン・マスクを指定したシンボル
+// 各バージョンの最大バイト数と、前のバージョンの最大バイト数+1のデータを、マスクを順に変えて符号化している
+func loadGolden(t *testing.T) []goldenSymbol {
+	t.Helper()
+	file, err := os.Open("testdata/golden.txt")
+	if err != nil {
+		t.Fatalf("failed to open golden symbols: %v", err)
+	}
+	defer file.Close()
+
+	var symbols []goldenSymbol
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case strings.HasPrefix(line, "version="):
+			var symbol goldenSymbol
+			if _, err := fmt.Sscanf(line, "version=%d mask=%d length=%d", &symbol.version, &symbol.mask, &symbol.length); err != nil {
+				t.Fatalf("invalid golden header %q: %v", line, err)
+			}
+			symbols = append(symbols, symbol)
+		case line != "":
+			symbols[len(symbols)-1].rows = append(symbols[len(symbols)-1].rows, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("failed to read golden symbols: %v", err)
+	}
+	return symbols
+}
+
+func TestEncodeMatchesReference(t *testing.T) {
+	symbols := loadGolden(t)
+	versions, masks := map[int]bool{}, map[int]bool{}
+	for _, golden := range symbols {
+		versions[golden.version] = true
+		masks[golden.mask] = true
+
+		q, _, err := encode([]byte(testText(golden.length)), golden.mask)
+		if err != nil {
+			t.Errorf("version %d mask %d: encode(%d bytes) error = %v", golden.version, golden.mask, golden.length, err)
+			continue
+		}
+		if q.version != golden.version || q.size != len(golden.rows) {
+			t.Errorf("encode(%d bytes) = version %d (size %d), want version %d (size %d)", golden.length, q.version, q.size, golden.version, len(golden.rows))
+			continue
+		}
+		mismatches := 0
+		for y, row := range golden.rows {
+			for x, c := range row {
+				if q.modules[y][x] != (c == '#') {
+					if mismatches == 0 {
+						t.Errorf("version %d mask %d: module (%d, %d) differs from the reference", golden.version, golden.mask, x, y)
+					}
+					mismatches++
+				}
+			}
+		}
+		if mismatches > 1 {
+			t.Errorf("version %d mask %d: %d modules differ from the reference", golden.version, golden.mask, mismatches)
+		}
+	}
+	if len(versions) != len(capacityM)-1 || len(masks) != 8 {
+		t.Errorf("golden symbols cover versions %v and masks %v, want every version and mask", versions, masks)
+	}
+}
+
+func TestEncodeVersionBoundaries(t *testing.T) {
+	for version := 1; version < len(capacityM); version++ {
+		for _, length := range []int{capacityM[version-1] + 1, capacityM[version]} {
+			modules, err := Encode(testText(length))
+			if err != nil {
+				t.Errorf("Encode(%d bytes) error = %v", length, err)
+				continue
+			}
+			if size := 17 + version*4; len(modules) != size {
+				t.Errorf("Encode(%d bytes) size = %d, want %d (version %d)", length, len(modules), size, version)
+			}
+		}
+	}
+
+	if _, err := Encode(testText(capacityM[len(capacityM)-1] + 1)); err == nil {
+		t.Errorf("Encode(%d bytes) succeeded, want an error beyond version %d", capacityM[len(capacityM)-1]+1, len(capacityM)-1)
+	}
+}
+
+func TestEncodeChoosesLowestPenaltyMask(t *testing.T) {
+	for _, text := range []string{"", "https://example.com/prescriptions/verify?code=AB12CD34", testText(capacityM[7])} {
+		best, bestPenalty := -1, 0
+		for mask := 0; mask < 8; mask++ {
+			q, _, err := encode([]byte(text), mask)
+			if err != nil {
+				t.Fatalf("encode(%q, %d) error = %v", text, mask, err)
+			}
+			if penalty := q.penalty(); best < 0 || penalty < bestPenalty {
+				best, bestPenalty = mask, penalty
+			}
+		}
+
+		_, chosen, err := encode([]byte(text), -1)
+		if err != nil {
+			t.Fatalf("encode(%q) error = %v", text, err)
+		}
+		if chosen != best {
+			t.Errorf("encode(%q) chose mask %d, want mask %d with the lowest penalty %d", text, chosen, best, bestPenalty)
+		}
+
+		modules, err := Encode(text)
+		if err != nil {
+			t.Fatalf("Encode(%q) error = %v", text, err)
+		}
+		expected, _, _ := encode([]byte(text), best)
+		for y := range modules {
+			for x := range modules[y] {
+				if modules[y][x] != expected.modules[y][x] {
+					t.Fatalf("Encode(%q) differs from the symbol with mask %d at (%d, %d)", text, best, x, y)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Rule 利用回数の上限（期間内にユーザーごと・IPアドレスごとに許可する回数、0は無制限）
+type Rule struct {
+	Name    string // uploads | exports | logins
+	Window  time.Duration
+	PerUser int
+	PerIP   int
+}
+
+// Result 上限の判定結果
+type Result struct {
+	Allowed    bool
+	Scope      string // 上限に達した単位（user | ip）
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Store 期間ごとの回数の記録先
+type Store interface {
+	Name() string
+	// Increment 回数を1増やし、増やした後の回数と期間の残り時間を返す（期間は最初の記録から数える）
+	Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error)
+	Close() error
+}
+
+// Limiter ユーザー・IPアドレスごとの利用回数の上限の判定
+type Limiter struct {
+	store Store
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store}
+}
+
+// Allow 利用の記録と上限の判定（上限に達した後の利用も数え、連続した試行では期間が延びない）
+func (l *Limiter) Allow(ctx context.Context, rule Rule, userID uint, ip string) (*Result, error) {
+	checks := []struct {
+		scope string
+		key   string
+		limit int
+	}{
+		{"user", fmt.Sprintf("%s:user:%d", rule.Name, userID), rule.PerUser},
+		{"ip", fmt.Sprintf("%s:ip:%s", rule.Name, ip), rule.PerIP},
+	}
+
+	result := &Result{Allowed: true, Remaining: -1}
+	for _, check := range checks {
+		if check.limit <= 0 || (check.scope == "user" && userID == 0) || (check.scope == "ip" && ip == "") {
+			continue
+		}
+		count, ttl, err := l.store.Increment(ctx, check.key, rule.Window)
+		if err != nil {
+			return nil, err
+		}
+
+		remaining := check.limit - int(count)
+		if remaining < 0 {
+			remaining = 0
+		}
+		if result.Remaining < 0 || remaining < result.Remaining {
+			result.Limit = check.limit
+			result.Remaining = remaining
+		}
+		if int(count) > check.limit && result.Allowed {
+			result.Allowed = false
+			result.Scope = check.scope
+			result.Limit = check.limit
+			result.RetryAfter = ttl
+		}
+	}
+	return result, nil
+}
+
+// MemoryStore 単一インスタンス用（プロセス内で記録）
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	sweep   time.Time
+}
+
+type memoryEntry struct {
+	count     int64
+	expiresAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+func (s *MemoryStore) Name() string { return "memory" }
+
+func (s *MemoryStore) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 期限切れの記録を定期的に削除する
+	if now.Sub(s.sweep) > time.Minute {
+		for k, entry := range s.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.sweep = now
+	}
+
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		entry = &memoryEntry{expiresAt: now.Add(window)}
+		s.entries[key] = entry
+	}
+	entry.count++
+	return entry.count, entry.expiresAt.Sub(now), nil
+}
+
+func (s *MemoryStore) Close() error { return nil }
+
+// incrementScript 回数の加算と期間の設定を1回の操作で行う
+var incrementScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {count, ttl}
+`)
+
+// RedisStore Redisによる複数インスタンス間で共有する記録
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore Redisへの接続（redisURLは redis://[:password@]host:port/db 形式）
+func NewRedisStore(redisURL, prefix string) (*RedisStore, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(opts)
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &RedisStore{client: client, prefix: prefix}, nil
+}
+
+func (s *RedisStore) Name() string { return "redis" }
+
+func (s *RedisStore) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	values, err := incrementScript.Run(ctx, s.client, []string{s.prefix + key}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(values) != 2 {
+		return 0, 0, fmt.Errorf("unexpected quota script result: %v", values)
+	}
+	return values[0], time.Duration(values[1]) * time.Millisecond, nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,103 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Broker インスタンス間でイベントを配信する仕組み
+// 発行したイベントは発行元を含むすべてのインスタンスの購読者へ届く
+type Broker interface {
+	Name() string
+	Publish(ctx context.Context, payload []byte) error
+	// Subscribe ctxが終了するまで受信したイベントをhandleへ渡す
+	Subscribe(ctx context.Context, handle func(payload []byte)) error
+	Close() error
+}
+
+// LocalBroker 単一インスタンス用（プロセス内で配信）
+type LocalBroker struct {
+	mu       sync.RWMutex
+	handlers []func(payload []byte)
+}
+
+func NewLocalBroker() *LocalBroker {
+	return &LocalBroker{}
+}
+
+func (b *LocalBroker) Name() string { return "local" }
+
+func (b *LocalBroker) Publish(ctx context.Context, payload []byte) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, handle := range b.handlers {
+		handle(payload)
+	}
+	return nil
+}
+
+func (b *LocalBroker) Subscribe(ctx context.Context, handle func(payload []byte)) error {
+	b.mu.Lock()
+	b.handlers = append(b.handlers, handle)
+	b.mu.Unlock()
+
+	<-ctx.Done()
+	return nil
+}
+
+func (b *LocalBroker) Close() error { return nil }
+
+// RedisBroker Redis Pub/Subによる複数インスタンス間の配信
+type RedisBroker struct {
+	client  *redis.Client
+	channel string
+}
+
+// NewRedisBroker Redisへの接続（redisURLは redis://[:password@]host:port/db 形式）
+func NewRedisBroker(redisURL, channel string) (*RedisBroker, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(opts)
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &RedisBroker{client: client, channel: channel}, nil
+}
+
+func (b *RedisBroker) Name() string { return "redis" }
+
+func (b *RedisBroker) Publish(ctx context.Context, payload []byte) error {
+	return b.client.Publish(ctx, b.channel, payload).Err()
+}
+
+// Subscribe 接続が切れた場合はクライアントが自動で再接続・再購読する
+func (b *RedisBroker) Subscribe(ctx context.Context, handle func(payload []byte)) error {
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			handle([]byte(msg.Payload))
+		}
+	}
+}
+
+func (b *RedisBroker) Close() error {
+	return b.client.Close()
+}
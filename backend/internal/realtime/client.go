@@ -0,0 +1,121 @@
+package realtime
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+	closeGrace     = 5 * time.Second // 切断要求への応答を待つ時間
+	maxMessageSize = 64 * 1024
+	sendBufferSize = 64
+)
+
+// client 1つのWebSocket接続（同じユーザーが複数の端末・タブから接続できる）
+type client struct {
+	hub    *Hub
+	conn   *websocket.Conn
+	userID uint
+	topic  string // 受け取るイベントの種類の接頭辞（空の場合はすべて）
+	room   string // ルームの接続（このルームへのイベントのみ受け取る）
+	member RoomMember
+	send   chan []byte
+	stop   chan struct{}
+	once   sync.Once
+}
+
+func newClient(hub *Hub, conn *websocket.Conn, userID uint, topic string) *client {
+	return &client{
+		hub:    hub,
+		conn:   conn,
+		userID: userID,
+		topic:  topic,
+		send:   make(chan []byte, sendBufferSize),
+		stop:   make(chan struct{}),
+	}
+}
+
+// accepts この接続へ送るイベントかどうか（ルームへのイベントはそのルームの接続のみ）
+func (c *client) accepts(room, eventType string) bool {
+	return room == c.room && strings.HasPrefix(eventType, c.topic)
+}
+
+// enqueue 送信待ちへの追加（受信が追いつかないクライアントは切断する）
+func (c *client) enqueue(message []byte) {
+	select {
+	case <-c.stop:
+	case c.send <- message:
+	default:
+		go c.closeWith(websocket.CloseTryAgainLater, "client is too slow")
+	}
+}
+
+func (c *client) sendError(message string) {
+	data, _ := json.Marshal(map[string]string{"message": message})
+	event, _ := json.Marshal(Event{Type: "error", Data: data})
+	c.enqueue(event)
+}
+
+// closeWith 切断を要求し、クライアントの応答を一定時間待つ
+func (c *client) closeWith(code int, reason string) {
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeWait))
+	c.conn.SetReadDeadline(time.Now().Add(closeGrace))
+}
+
+func (c *client) shutdown() {
+	c.once.Do(func() {
+		close(c.stop)
+		c.conn.Close()
+	})
+}
+
+func (c *client) readPump() {
+	defer c.shutdown()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	for {
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.hub.handle(c, message)
+	}
+}
+
+func (c *client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.shutdown()
+	}()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case message := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrDraining 停止処理中のため新しい接続を受け付けない
+var ErrDraining = errors.New("realtime hub is draining")
+
+// Event クライアントとやり取りするメッセージ（{"type": "...", "data": {...}}）
+type Event struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// InboundHandler クライアントから受信したイベントの処理（エラーは送信元のクライアントへ返す）
+type InboundHandler func(userID uint, data json.RawMessage) error
+
+// RoomMember ルームの接続（ビデオ通話のシグナリング等）の参加者の処理
+type RoomMember interface {
+	// Join 接続の登録後の参加（返したイベントを最初に送り、エラーの場合は切断する）
+	Join() (*Event, error)
+	// Handle 受信したイベントの処理（エラーは送信元のクライアントへ返す）
+	Handle(eventType string, data json.RawMessage) error
+	// Leave 切断時の退出
+	Leave()
+}
+
+// ConnectionObserver このインスタンスへの接続・切断の通知先（オンライン状態の記録等）
+type ConnectionObserver interface {
+	UserConnected(userID uint)
+	UserDisconnected(userID uint)
+}
+
+// envelope ブローカー経由で配信する宛先付きのイベント
+type envelope struct {
+	UserIDs []uint `json:"user_ids"`
+	Room    string `json:"room,omitempty"` // ルームの接続のみへ送るイベント
+	Event   Event  `json:"event"`
+}
+
+// Hub WebSocket接続の管理（チャット・ビデオ通話のシグナリング・通知）
+// イベントはブローカーを経由して全インスタンスへ配信し、各インスタンスが自身に接続中のユーザーへ送る
+type Hub struct {
+	broker    Broker
+	mu        sync.RWMutex
+	clients   map[uint]map[*client]struct{}
+	handlers  map[string]InboundHandler
+	observers []ConnectionObserver
+	draining  bool
+	active    sync.WaitGroup
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func NewHub(broker Broker) *Hub {
+	return &Hub{
+		broker:   broker,
+		clients:  make(map[uint]map[*client]struct{}),
+		handlers: make(map[string]InboundHandler),
+		done:     make(chan struct{}),
+	}
+}
+
+// HandleFunc クライアントから受信するイベントの処理の登録（Start前に呼び出す）
+func (h *Hub) HandleFunc(eventType string, handler InboundHandler) {
+	h.handlers[eventType] = handler
+}
+
+// Observe 接続・切断の通知先の登録（Start前に呼び出す）
+func (h *Hub) Observe(observer ConnectionObserver) {
+	h.observers = append(h.observers, observer)
+}
+
+// Start ブローカーの購読を開始する（購読が失敗した場合は一定時間後に再試行）
+func (h *Hub) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+
+	go func() {
+		defer close(h.done)
+		for {
+			if err := h.broker.Subscribe(ctx, h.dispatch); err != nil {
+				log.Printf("Warning: Realtime broker (%s) subscription failed: %v", h.broker.Name(), err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}()
+}
+
+// Publish ユーザーへのイベント送信（どのインスタンスに接続していても届く）
+func (h *Hub) Publish(userIDs []uint, eventType string, data interface{}) error {
+	return h.publish(userIDs, "", eventType, data)
+}
+
+// PublishRoom ルームに接続中のユーザーへのイベント送信（通常の接続には送らない）
+func (h *Hub) PublishRoom(room string, userIDs []uint, eventType string, data interface{}) error {
+	return h.publish(userIDs, room, eventType, data)
+}
+
+func (h *Hub) publish(userIDs []uint, room, eventType string, data interface{}) error {
+	if h == nil || len(userIDs) == 0 {
+		return nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(envelope{UserIDs: userIDs, Room: room, Event: Event{Type: eventType, Data: raw}})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return h.broker.Publish(ctx, payload)
+}
+
+// Serve 接続の登録と送受信（接続が閉じるまで戻らない）
+func (h *Hub) Serve(conn *websocket.Conn, userID uint) error {
+	return h.ServeTopic(conn, userID, "")
+}
+
+// ServeTopic 種類が接頭辞（"appointment." 等）に一致するイベントのみを送る接続（空の場合はすべて送る）
+func (h *Hub) ServeTopic(conn *websocket.Conn, userID uint, topic string) error {
+	return h.serve(newClient(h, conn, userID, topic))
+}
+
+// ServeRoom ルームの接続（同じルームへのイベントのみを送り、受信したイベントはmemberが処理する）
+// 接続の登録後に参加させるため、参加の直後に他の参加者から届くイベントも取りこぼさない
+func (h *Hub) ServeRoom(conn *websocket.Conn, userID uint, room string, member RoomMember) error {
+	c := newClient(h, conn, userID, "")
+	c.room = room
+	c.member = member
+	return h.serve(c)
+}
+
+func (h *Hub) serve(c *client) error {
+	if !h.register(c) {
+		c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "server is shutting down"), time.Now().Add(writeWait))
+		c.conn.Close()
+		return ErrDraining
+	}
+	defer h.active.Done()
+	for _, observer := range h.observers {
+		observer.UserConnected(c.userID)
+	}
+
+	go c.writePump()
+	if c.member != nil {
+		welcome, err := c.member.Join()
+		if err != nil {
+			c.closeWith(websocket.ClosePolicyViolation, err.Error())
+		} else {
+			if welcome != nil {
+				if message, err := json.Marshal(welcome); err == nil {
+					c.enqueue(message)
+				}
+			}
+			defer c.member.Leave()
+		}
+	}
+	c.readPump()
+	h.unregister(c)
+	for _, observer := range h.observers {
+		observer.UserDisconnected(c.userID)
+	}
+	return nil
+}
+
+// Draining 停止処理中かどうか
+func (h *Hub) Draining() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.draining
+}
+
+// ConnectionCount このインスタンスに接続中のクライアント数
+func (h *Hub) ConnectionCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	count := 0
+	for _, clients := range h.clients {
+		count += len(clients)
+	}
+	return count
+}
+
+// ConnectedUserIDs このインスタンスに接続中のユーザー
+func (h *Hub) ConnectedUserIDs() []uint {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	ids := make([]uint, 0, len(h.clients))
+	for userID := range h.clients {
+		ids = append(ids, userID)
+	}
+	return ids
+}
+
+// Drain 停止前の接続の切り離し
+// 新しい接続を拒否し、接続中のクライアントへ再接続を促す切断（1001 Going Away）を送る
+// ctxの期限までにクライアントが切断しない場合は強制的に閉じる
+func (h *Hub) Drain(ctx context.Context) error {
+	h.mu.Lock()
+	h.draining = true
+	var clients []*client
+	for _, userClients := range h.clients {
+		for c := range userClients {
+			clients = append(clients, c)
+		}
+	}
+	h.mu.Unlock()
+
+	log.Printf("Draining %d realtime connections", len(clients))
+	for _, c := range clients {
+		c.closeWith(websocket.CloseGoingAway, "server is shutting down")
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		h.active.Wait()
+		close(finished)
+	}()
+
+	var err error
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		err = ctx.Err()
+		for _, c := range clients {
+			c.conn.Close()
+		}
+	}
+
+	if h.cancel != nil {
+		h.cancel()
+		<-h.done
+	}
+	if closeErr := h.broker.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (h *Hub) register(c *client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.draining {
+		return false
+	}
+	if h.clients[c.userID] == nil {
+		h.clients[c.userID] = make(map[*client]struct{})
+	}
+	h.clients[c.userID][c] = struct{}{}
+	h.active.Add(1)
+	return true
+}
+
+func (h *Hub) unregister(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if userClients, ok := h.clients[c.userID]; ok {
+		delete(userClients, c)
+		if len(userClients) == 0 {
+			delete(h.clients, c.userID)
+		}
+	}
+}
+
+// dispatch ブローカーから受信したイベントをこのインスタンスに接続中の宛先へ送る
+func (h *Hub) dispatch(payload []byte) {
+	var env envelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		log.Printf("Warning: Invalid realtime event: %v", err)
+		return
+	}
+	message, err := json.Marshal(env.Event)
+	if err != nil {
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, userID := range env.UserIDs {
+		for c := range h.clients[userID] {
+			if c.accepts(env.Room, env.Event.Type) {
+				c.enqueue(message)
+			}
+		}
+	}
+}
+
+// handle クライアントから受信したイベントの処理
+func (h *Hub) handle(c *client, message []byte) {
+	var event Event
+	if err := json.Unmarshal(message, &event); err != nil {
+		c.sendError("invalid event format")
+		return
+	}
+	if c.member != nil {
+		if err := c.member.Handle(event.Type, event.Data); err != nil {
+			c.sendError(err.Error())
+		}
+		return
+	}
+	handler, ok := h.handlers[event.Type]
+	if !ok {
+		c.sendError("unknown event type: " + event.Type)
+		return
+	}
+	if err := handler(c.userID, event.Data); err != nil {
+		c.sendError(err.Error())
+	}
+}
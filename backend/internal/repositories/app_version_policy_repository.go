@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+// appVersionPolicyID アプリの最低バージョンは1件のみ保存する
+const appVersionPolicyID = 1
+
+type AppVersionPolicyRepository interface {
+	Find() (*models.AppVersionPolicy, error)
+	Save(policy *models.AppVersionPolicy) error
+}
+
+type appVersionPolicyRepository struct {
+	db *gorm.DB
+}
+
+func NewAppVersionPolicyRepository(db *gorm.DB) AppVersionPolicyRepository {
+	return &appVersionPolicyRepository{
+		db: db,
+	}
+}
+
+// Find 保存済みのアプリの最低バージョンを取得（未設定の場合はnil）
+func (r *appVersionPolicyRepository) Find() (*models.AppVersionPolicy, error) {
+	var policy models.AppVersionPolicy
+	err := r.db.First(&policy, appVersionPolicyID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Save アプリの最低バージョンの保存
+func (r *appVersionPolicyRepository) Save(policy *models.AppVersionPolicy) error {
+	policy.ID = appVersionPolicyID
+	return r.db.Save(policy).Error
+}
@@ -1,17 +1,45 @@
 package repositories
 
 import (
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"online_medical_consultation_app/backend/internal/models"
 )
 
+// AppointmentPerformance 医師別の実績集計に使う予約ごとの値
+type AppointmentPerformance struct {
+	AppointmentID uint
+	DoctorID      uint
+	Status        string
+	IsAsync       bool
+	ScheduledAt   time.Time  // 診療枠の開始時刻（枠のない即時・非同期相談は予約の作成日時）
+	SlotEndTime   *time.Time // 診療枠の終了時刻
+	VideoStarted  bool       // ビデオ通話が開始されたか
+	VideoSeconds  float64    // 終了したビデオ通話の合計時間
+	Prescriptions int64
+	Rating        *int
+	DelayMinutes  int // 医師が連絡した開始の遅れ
+}
+
+// DoctorAppointmentFilter 医師の予約一覧の絞り込み条件
+type DoctorAppointmentFilter struct {
+	Status   string // 空の場合はすべて
+	TagIDs   []uint // 空の場合は絞り込まない
+	MatchAll bool   // すべてのタグが付いた予約に限る（falseの場合はいずれか）
+}
+
 type AppointmentRepository interface {
 	Create(appointment *models.Appointment) error
+	BookSlot(appointment *models.Appointment) (bool, error)
+	BookInstant(appointment *models.Appointment) (bool, error)
 	FindByID(id uint) (*models.Appointment, error)
 	FindByPatientID(patientID uint) ([]models.Appointment, error)
-	FindByDoctorID(doctorID uint) ([]models.Appointment, error)
+	FindPageByPatientID(patientID uint, limit, offset int) ([]models.Appointment, int64, error)
+	FindPageByDoctorID(doctorID uint, filter DoctorAppointmentFilter, limit, offset int) ([]models.Appointment, int64, error)
+	FindPageByDoctorIDs(doctorIDs []uint, status string, limit, offset int) ([]models.Appointment, int64, error)
 	FindByDoctorAndTimeRange(doctorID uint, startTime, endTime time.Time) ([]models.Appointment, error)
 	Update(appointment *models.Appointment) error
 	Delete(id uint) error
@@ -20,6 +48,35 @@ type AppointmentRepository interface {
 	FindConfirmedByDoctor(doctorID uint) ([]models.Appointment, error)
 	FindUpcomingByPatient(patientID uint) ([]models.Appointment, error)
 	FindCompletedByPatient(patientID uint) ([]models.Appointment, error)
+	FindPageByDependentID(dependentID uint, limit, offset int) ([]models.Appointment, int64, error)
+	FindPageByInterpreterID(interpreterID uint, limit, offset int) ([]models.Appointment, int64, error)
+	FindPendingCreatedBefore(before time.Time) ([]models.Appointment, error)
+	FindPendingByDoctorInRange(doctorID uint, start, end time.Time) ([]models.Appointment, error)
+	FindConfirmedByDoctorInRange(doctorID uint, start, end time.Time) ([]models.Appointment, error)
+	FindDoctorOverlapping(doctorID uint, start, end time.Time) ([]models.Appointment, error)
+	TransferDoctor(appointmentID, fromDoctorID, toDoctorID uint) (bool, error)
+	DeclinePending(appointmentID uint, reason string) (bool, error)
+	FindOpenAsyncByDoctor(doctorID uint) ([]models.Appointment, error)
+	FindActiveByPatientWithDoctor(patientID uint, limit int) ([]models.Appointment, error)
+	FindAsyncOverdue(now time.Time) ([]models.Appointment, error)
+	MarkAsyncResponded(appointmentID uint, respondedAt time.Time) (bool, error)
+	MarkSLABreached(appointmentID uint, breachedAt time.Time) (bool, error)
+	FindOpenByParticipant(userID uint) ([]models.Appointment, error)
+	FindPatientOverlapping(patientID uint, start, end time.Time) ([]models.Appointment, error)
+	FindInstantCreatedInRange(start, end time.Time, doctorID uint) ([]models.Appointment, error)
+	FindCompletable(endedBefore time.Time) ([]models.Appointment, error)
+	MarkCompleted(appointmentID uint, completedAt time.Time) (bool, error)
+	FindForSummary(id uint) (*models.Appointment, error)
+	MarkIntakeCompleted(appointmentID uint, completedAt time.Time) error
+	FindIntakeReminderDue(dueBefore time.Time) ([]models.Appointment, error)
+	MarkIntakeReminded(appointmentID uint, remindedAt time.Time) (bool, error)
+	FindIntakeOverdue(now time.Time) ([]models.Appointment, error)
+	CancelIntakeIncomplete(appointmentID uint) (bool, error)
+	FindReminderDue(now, startBefore time.Time) ([]models.Appointment, error)
+	MarkReminderSent(appointmentID uint, sentAt time.Time) (bool, error)
+	RecordDelay(appointmentID uint, delayMinutes int, estimatedStartAt, reportedAt time.Time) (bool, error)
+	FindScheduledOpenByDoctor(doctorID uint) ([]models.Appointment, error)
+	FindPerformance(start, end time.Time, doctorID uint) ([]AppointmentPerformance, error)
 }
 
 type appointmentRepository struct {
@@ -37,6 +94,87 @@ func (r *appointmentRepository) Create(appointment *models.Appointment) error {
 	return r.db.Create(appointment).Error
 }
 
+// BookSlot 診療枠を行ロック（SELECT ... FOR UPDATE）した上で予約を作成し、枠を予約済みにする
+// 枠が公開中でない・担当医師の枠でない・キャンセルされていない予約が既にある・医師の他の予約と日時が重なる場合はfalse
+func (r *appointmentRepository) BookSlot(appointment *models.Appointment) (bool, error) {
+	booked := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var slot models.AvailabilitySlot
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&slot, *appointment.SlotID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if slot.DoctorID != appointment.DoctorID || slot.Status != "open" {
+			return nil
+		}
+		appointment.SetSchedule(&slot)
+
+		var active int64
+		if err := tx.Model(&models.Appointment{}).
+			Where("slot_id = ? AND status <> ?", slot.ID, "cancelled").
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return nil
+		}
+
+		// 重なる別の枠の予約を防ぐ
+		free, err := doctorScheduleFree(tx, slot.DoctorID, slot.StartTime, slot.EndTime)
+		if err != nil || !free {
+			return err
+		}
+
+		if err := tx.Create(appointment).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&slot).Update("status", "booked").Error; err != nil {
+			return err
+		}
+		booked = true
+		return nil
+	})
+	return booked, err
+}
+
+// BookInstant 医師の行をロックした上で即時診療の予約を作成する（予約の日時が医師の他の予約と重なる場合はfalse）
+func (r *appointmentRepository) BookInstant(appointment *models.Appointment) (bool, error) {
+	if appointment.ScheduledStart == nil || appointment.ScheduledEnd == nil {
+		return false, errors.New("instant appointment must have a schedule")
+	}
+	booked := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		free, err := doctorScheduleFree(tx, appointment.DoctorID, *appointment.ScheduledStart, *appointment.ScheduledEnd)
+		if err != nil || !free {
+			return err
+		}
+		if err := tx.Create(appointment).Error; err != nil {
+			return err
+		}
+		booked = true
+		return nil
+	})
+	return booked, err
+}
+
+// doctorScheduleFree 予約した日時が指定期間と重なる医師の未完了（保留中・確定済み）の予約がないかどうか
+// 同じ医師の予約の作成を直列化するため、トランザクション内で医師の行をロックしてから確認する
+func doctorScheduleFree(tx *gorm.DB, doctorID uint, start, end time.Time) (bool, error) {
+	var doctor models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&doctor, doctorID).Error; err != nil {
+		return false, err
+	}
+
+	var overlapping int64
+	err := tx.Model(&models.Appointment{}).
+		Where("doctor_id = ? AND status IN ?", doctorID, []string{"pending", "confirmed"}).
+		Where("scheduled_start < ? AND scheduled_end > ?", end, start).
+		Count(&overlapping).Error
+	return overlapping == 0, err
+}
+
 // FindByID IDで予約を取得
 func (r *appointmentRepository) FindByID(id uint) (*models.Appointment, error) {
 	var appointment models.Appointment
@@ -54,18 +192,76 @@ func (r *appointmentRepository) FindByPatientID(patientID uint) ([]models.Appoin
 	return appointments, err
 }
 
-// FindByDoctorID 医師IDで予約一覧を取得
-func (r *appointmentRepository) FindByDoctorID(doctorID uint) ([]models.Appointment, error) {
+// FindPageByPatientID 患者IDで予約一覧（新しい順）の指定範囲と総件数を取得
+func (r *appointmentRepository) FindPageByPatientID(patientID uint, limit, offset int) ([]models.Appointment, int64, error) {
+	return r.findPage(r.db.Where("patient_id = ?", patientID), limit, offset)
+}
+
+// FindPageByDoctorID 医師IDで予約一覧（新しい順）の指定範囲と総件数を取得（ステータス・タグで絞り込む）
+func (r *appointmentRepository) FindPageByDoctorID(doctorID uint, filter DoctorAppointmentFilter, limit, offset int) ([]models.Appointment, int64, error) {
+	query := r.db.Where("doctor_id = ?", doctorID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if len(filter.TagIDs) > 0 {
+		tagged := r.db.Model(&models.AppointmentTag{}).Select("appointment_id").Where("tag_id IN ?", filter.TagIDs)
+		if filter.MatchAll {
+			tagged = tagged.Group("appointment_id").Having("COUNT(DISTINCT tag_id) = ?", len(filter.TagIDs))
+		}
+		query = query.Where("id IN (?)", tagged)
+	}
+	return r.findPage(query, limit, offset)
+}
+
+// FindPageByDoctorIDs 複数の医師の予約一覧（組織のスタッフ用、statusが空の場合はすべて）
+// 予約の管理に必要な患者・医師・日時・所在地のみ読み込み、チャット・処方等は読み込まない
+func (r *appointmentRepository) FindPageByDoctorIDs(doctorIDs []uint, status string, limit, offset int) ([]models.Appointment, int64, error) {
+	if len(doctorIDs) == 0 {
+		return []models.Appointment{}, 0, nil
+	}
+	query := r.db.Model(&models.Appointment{}).Where("doctor_id IN ?", doctorIDs)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
 	var appointments []models.Appointment
-	err := r.db.Where("doctor_id = ?", doctorID).Order("created_at DESC").Find(&appointments).Error
-	return appointments, err
+	err := query.Preload("Patient.PatientProfile").
+		Preload("Doctor.DoctorProfile").
+		Preload("Slot").
+		Preload("Dependent").
+		Preload("Location").
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&appointments).Error
+	return appointments, total, err
+}
+
+// findPage 予約一覧（新しい順）の指定範囲と総件数
+func (r *appointmentRepository) findPage(query *gorm.DB, limit, offset int) ([]models.Appointment, int64, error) {
+	query = query.Model(&models.Appointment{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var appointments []models.Appointment
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&appointments).Error
+	return appointments, total, err
 }
 
-// FindByDoctorAndTimeRange 医師IDと時間範囲で予約を取得
+// FindByDoctorAndTimeRange 医師IDと時間範囲で予約を取得（予約した日時が範囲と重なるもの）
 func (r *appointmentRepository) FindByDoctorAndTimeRange(doctorID uint, startTime, endTime time.Time) ([]models.Appointment, error) {
 	var appointments []models.Appointment
-	err := r.db.Where("doctor_id = ? AND ((start_time <= ? AND end_time >= ?) OR (start_time <= ? AND end_time >= ?) OR (start_time >= ? AND end_time <= ?))",
-		doctorID, startTime, startTime, endTime, endTime, startTime, endTime).Find(&appointments).Error
+	err := r.db.Where("doctor_id = ? AND scheduled_start < ? AND scheduled_end > ?", doctorID, endTime, startTime).
+		Order("scheduled_start ASC").
+		Find(&appointments).Error
 	return appointments, err
 }
 
@@ -81,7 +277,7 @@ func (r *appointmentRepository) Delete(id uint) error {
 
 // LoadRelations 関連データの読み込み
 func (r *appointmentRepository) LoadRelations(appointment *models.Appointment) error {
-	return r.db.Preload("Patient").Preload("Doctor").Preload("Slot").Preload("Messages").Preload("Prescriptions").Preload("VideoSessions").First(appointment, appointment.ID).Error
+	return r.db.Preload("Patient").Preload("Doctor").Preload("Slot").Preload("Dependent").Preload("Location").Preload("Interpreter").Preload("Triage").Preload("Messages", "channel = ?", models.MessageChannelPatient).Preload("Prescriptions").Preload("VideoSessions").Preload("SharedDocuments.Document").First(appointment, appointment.ID).Error
 }
 
 // FindPendingByDoctor 医師の保留中予約を取得
@@ -94,21 +290,328 @@ func (r *appointmentRepository) FindPendingByDoctor(doctorID uint) ([]models.App
 // FindConfirmedByDoctor 医師の確定済み予約を取得
 func (r *appointmentRepository) FindConfirmedByDoctor(doctorID uint) ([]models.Appointment, error) {
 	var appointments []models.Appointment
-	err := r.db.Where("doctor_id = ? AND status = ?", doctorID, "confirmed").Order("start_time ASC").Find(&appointments).Error
+	err := r.db.Where("doctor_id = ? AND status = ?", doctorID, "confirmed").Order("scheduled_start ASC").Find(&appointments).Error
 	return appointments, err
 }
 
 // FindUpcomingByPatient 患者の今後の予約を取得
 func (r *appointmentRepository) FindUpcomingByPatient(patientID uint) ([]models.Appointment, error) {
 	var appointments []models.Appointment
-	err := r.db.Where("patient_id = ? AND status IN (?, ?) AND start_time > ?", 
-		patientID, "pending", "confirmed", time.Now()).Order("start_time ASC").Find(&appointments).Error
+	err := r.db.Where("patient_id = ? AND status IN (?, ?) AND scheduled_start > ?",
+		patientID, "pending", "confirmed", time.Now()).Order("scheduled_start ASC").Find(&appointments).Error
 	return appointments, err
 }
 
 // FindCompletedByPatient 患者の完了済み予約を取得
 func (r *appointmentRepository) FindCompletedByPatient(patientID uint) ([]models.Appointment, error) {
 	var appointments []models.Appointment
-	err := r.db.Where("patient_id = ? AND status = ?", patientID, "completed").Order("start_time DESC").Find(&appointments).Error
+	err := r.db.Where("patient_id = ? AND status = ?", patientID, "completed").Order("scheduled_start DESC").Find(&appointments).Error
+	return appointments, err
+}
+
+// FindPageByDependentID 家族（被扶養者）の予約一覧（新しい順）の指定範囲と総件数を取得
+func (r *appointmentRepository) FindPageByDependentID(dependentID uint, limit, offset int) ([]models.Appointment, int64, error) {
+	return r.findPage(r.db.Where("dependent_id = ?", dependentID), limit, offset)
+}
+
+// FindPageByInterpreterID 通訳者が割り当てられた予約一覧（新しい順）の指定範囲と総件数を取得
+func (r *appointmentRepository) FindPageByInterpreterID(interpreterID uint, limit, offset int) ([]models.Appointment, int64, error) {
+	return r.findPage(r.db.Preload("Patient").Preload("Doctor").Where("interpreter_id = ?", interpreterID), limit, offset)
+}
+
+// FindPendingCreatedBefore 指定時刻より前に作成され、まだ保留中の予約を取得
+func (r *appointmentRepository) FindPendingCreatedBefore(before time.Time) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := r.db.Where("status = ? AND created_at < ?", "pending", before).Order("created_at ASC").Find(&appointments).Error
+	return appointments, err
+}
+
+// FindPendingByDoctorInRange 予約した日時が指定期間と重なる医師の保留中予約を取得
+func (r *appointmentRepository) FindPendingByDoctorInRange(doctorID uint, start, end time.Time) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := r.db.Where("appointments.doctor_id = ? AND appointments.status = ?", doctorID, "pending").
+		Where("appointments.scheduled_start < ? AND appointments.scheduled_end > ?", end, start).
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// FindConfirmedByDoctorInRange 予約した日時が指定期間と重なる医師の確定済み予約を診療枠とあわせて取得
+func (r *appointmentRepository) FindConfirmedByDoctorInRange(doctorID uint, start, end time.Time) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := r.db.Preload("Slot").
+		Where("appointments.doctor_id = ? AND appointments.status = ?", doctorID, "confirmed").
+		Where("appointments.scheduled_start < ? AND appointments.scheduled_end > ?", end, start).
+		Order("appointments.scheduled_start ASC").
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// FindDoctorOverlapping 予約した日時が指定期間と重なる医師の未完了（保留中・確定済み）の予約を取得
+// 引き継いだ予約の枠は元の医師のものであるため、枠ではなく予約の担当医師で絞り込む
+func (r *appointmentRepository) FindDoctorOverlapping(doctorID uint, start, end time.Time) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := r.db.Where("appointments.doctor_id = ? AND appointments.status IN ?", doctorID, []string{"pending", "confirmed"}).
+		Where("appointments.scheduled_start < ? AND appointments.scheduled_end > ?", end, start).
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// TransferDoctor 確定済みの予約の担当医師を変更する（担当医師が変わった・確定済みでなくなった場合はfalse）
+func (r *appointmentRepository) TransferDoctor(appointmentID, fromDoctorID, toDoctorID uint) (bool, error) {
+	result := r.db.Model(&models.Appointment{}).
+		Where("id = ? AND doctor_id = ? AND status = ?", appointmentID, fromDoctorID, "confirmed").
+		Update("doctor_id", toDoctorID)
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeclinePending 保留中の予約を辞退扱いでキャンセルし、診療枠との紐付けを解除する
+// 既に医師が応答済みの場合は更新せずfalseを返す
+func (r *appointmentRepository) DeclinePending(appointmentID uint, reason string) (bool, error) {
+	result := r.db.Model(&models.Appointment{}).
+		Where("id = ? AND status = ?", appointmentID, "pending").
+		Updates(map[string]interface{}{
+			"status":        "cancelled",
+			"cancel_reason": reason,
+			"slot_id":       nil,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindOpenAsyncByDoctor 医師の未完了の非同期相談を回答期限の早い順に取得
+func (r *appointmentRepository) FindOpenAsyncByDoctor(doctorID uint) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := r.db.Where("doctor_id = ? AND is_async = ? AND status IN ?", doctorID, true, []string{"pending", "confirmed"}).
+		Order("status DESC, response_due_at ASC").
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// FindAsyncOverdue 回答期限を過ぎても医師が回答していない非同期相談を取得
+func (r *appointmentRepository) FindAsyncOverdue(now time.Time) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := r.db.Where("is_async = ? AND status = ? AND response_due_at < ? AND sla_breached_at IS NULL", true, "pending", now).
+		Order("response_due_at ASC").
+		Find(&appointments).Error
 	return appointments, err
 }
+
+// MarkAsyncResponded 医師の最初の回答で非同期相談を対応中にする（回答済みの場合はfalse）
+func (r *appointmentRepository) MarkAsyncResponded(appointmentID uint, respondedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.Appointment{}).
+		Where("id = ? AND is_async = ? AND status = ?", appointmentID, true, "pending").
+		Updates(map[string]interface{}{
+			"status":            "confirmed",
+			"first_response_at": respondedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkSLABreached 回答期限超過を記録する（記録済みの場合はfalse）
+func (r *appointmentRepository) MarkSLABreached(appointmentID uint, breachedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.Appointment{}).
+		Where("id = ? AND sla_breached_at IS NULL", appointmentID).
+		Update("sla_breached_at", breachedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindActiveByPatientWithDoctor 患者の未完了（保留中・確定済み）の予約を医師情報とあわせて取得
+func (r *appointmentRepository) FindActiveByPatientWithDoctor(patientID uint, limit int) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := r.db.Preload("Doctor.DoctorProfile").Preload("Slot").
+		Where("patient_id = ? AND status IN ?", patientID, []string{"pending", "confirmed"}).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// FindOpenByParticipant 患者・医師・通訳者として参加している未完了の予約を取得
+func (r *appointmentRepository) FindOpenByParticipant(userID uint) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := r.db.Where("status IN ? AND (patient_id = ? OR doctor_id = ? OR interpreter_id = ?)",
+		[]string{"pending", "confirmed"}, userID, userID, userID).
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// FindPatientOverlapping 予約した日時が指定期間と重なる患者の未完了（保留中・確定済み）の予約を取得
+func (r *appointmentRepository) FindPatientOverlapping(patientID uint, start, end time.Time) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := r.db.Preload("Doctor.DoctorProfile").Preload("Slot").
+		Where("appointments.patient_id = ? AND appointments.status IN ?", patientID, []string{"pending", "confirmed"}).
+		Where("appointments.scheduled_start < ? AND appointments.scheduled_end > ?", end, start).
+		Order("appointments.scheduled_start ASC").
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// FindInstantCreatedInRange 指定期間に受け付けた、キャンセルされていない即時診療を取得
+// doctorIDが0の場合は全医師が対象
+func (r *appointmentRepository) FindInstantCreatedInRange(start, end time.Time, doctorID uint) ([]models.Appointment, error) {
+	query := r.db.Preload("Patient.PatientProfile").Preload("Dependent").
+		Where("is_instant = ? AND status <> ? AND created_at >= ? AND created_at < ?", true, "cancelled", start, end)
+	if doctorID != 0 {
+		query = query.Where("doctor_id = ?", doctorID)
+	}
+
+	var appointments []models.Appointment
+	err := query.Order("doctor_id ASC, created_at ASC").Find(&appointments).Error
+	return appointments, err
+}
+
+// FindCompletable 診療が終わった確定済みの予約を取得
+// ビデオ通話が指定時刻より前に終了したもの、または予約した終了時刻が指定時刻より前のもの（通話中のものは除く）
+func (r *appointmentRepository) FindCompletable(endedBefore time.Time) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := r.db.Where("status = ? AND is_async = ?", "confirmed", false).
+		Where("NOT EXISTS (SELECT 1 FROM video_sessions WHERE video_sessions.appointment_id = appointments.id AND video_sessions.started_at IS NOT NULL AND video_sessions.ended_at IS NULL AND video_sessions.deleted_at IS NULL)").
+		Where("EXISTS (SELECT 1 FROM video_sessions WHERE video_sessions.appointment_id = appointments.id AND video_sessions.ended_at < ? AND video_sessions.deleted_at IS NULL)"+
+			" OR appointments.scheduled_end < ?", endedBefore, endedBefore).
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// MarkCompleted 確定済みの予約を完了にする（既に完了・キャンセル済みの場合はfalse）
+func (r *appointmentRepository) MarkCompleted(appointmentID uint, completedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.Appointment{}).
+		Where("id = ? AND status = ?", appointmentID, "confirmed").
+		Updates(map[string]interface{}{
+			"status":       "completed",
+			"completed_at": completedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindForSummary 診療サマリーの作成に必要な関連データ（患者・医師のプロフィール、診療枠、処方）とあわせて予約を取得
+func (r *appointmentRepository) FindForSummary(id uint) (*models.Appointment, error) {
+	var appointment models.Appointment
+	err := r.db.Preload("Patient.PatientProfile").Preload("Doctor.DoctorProfile").Preload("Slot").Preload("Dependent").
+		Preload("Prescriptions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		First(&appointment, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+// MarkIntakeCompleted 事前の問診の完了を記録する
+func (r *appointmentRepository) MarkIntakeCompleted(appointmentID uint, completedAt time.Time) error {
+	return r.db.Model(&models.Appointment{}).
+		Where("id = ? AND intake_completed_at IS NULL", appointmentID).
+		Update("intake_completed_at", completedAt).Error
+}
+
+// FindIntakeReminderDue 問診の提出期限が近づいている未提出の予約を取得（リマインド済みは除く）
+func (r *appointmentRepository) FindIntakeReminderDue(dueBefore time.Time) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := r.db.Where("status IN ? AND intake_due_at <= ? AND intake_completed_at IS NULL AND intake_reminded_at IS NULL",
+		[]string{"pending", "confirmed"}, dueBefore).
+		Order("intake_due_at ASC").
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// MarkIntakeReminded 問診のリマインド送信を記録する（記録済みの場合はfalse）
+func (r *appointmentRepository) MarkIntakeReminded(appointmentID uint, remindedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.Appointment{}).
+		Where("id = ? AND intake_reminded_at IS NULL", appointmentID).
+		Update("intake_reminded_at", remindedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindIntakeOverdue 問診の提出期限を過ぎても未提出の予約を取得
+func (r *appointmentRepository) FindIntakeOverdue(now time.Time) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := r.db.Where("status IN ? AND intake_due_at <= ? AND intake_completed_at IS NULL",
+		[]string{"pending", "confirmed"}, now).
+		Order("intake_due_at ASC").
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// FindReminderDue 予約した日時が近づいている確定済みの予約を取得（リマインド済みは除く、診療枠を含む）
+func (r *appointmentRepository) FindReminderDue(now, startBefore time.Time) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := r.db.Preload("Slot").Preload("Location").
+		Where("appointments.status = ? AND appointments.reminder_sent_at IS NULL", "confirmed").
+		Where("appointments.scheduled_start > ? AND appointments.scheduled_start <= ?", now, startBefore).
+		Order("appointments.scheduled_start ASC").
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// MarkReminderSent 診療開始前のリマインドの送信を記録する（記録済みの場合はfalse）
+func (r *appointmentRepository) MarkReminderSent(appointmentID uint, sentAt time.Time) (bool, error) {
+	result := r.db.Model(&models.Appointment{}).
+		Where("id = ? AND reminder_sent_at IS NULL", appointmentID).
+		Update("reminder_sent_at", sentAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CancelIntakeIncomplete 問診が未提出の予約をキャンセルし、診療枠との紐付けを解除する
+// 直前に問診が提出された・既にキャンセルされた場合は更新せずfalseを返す
+func (r *appointmentRepository) CancelIntakeIncomplete(appointmentID uint) (bool, error) {
+	result := r.db.Model(&models.Appointment{}).
+		Where("id = ? AND status IN ? AND intake_completed_at IS NULL", appointmentID, []string{"pending", "confirmed"}).
+		Updates(map[string]interface{}{
+			"status":        "cancelled",
+			"cancel_reason": "intake_incomplete",
+			"slot_id":       nil,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// RecordDelay 医師の遅れと開始見込み時刻を記録する（確定済みでない場合はfalse）
+func (r *appointmentRepository) RecordDelay(appointmentID uint, delayMinutes int, estimatedStartAt, reportedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.Appointment{}).
+		Where("id = ? AND status = ?", appointmentID, "confirmed").
+		Updates(map[string]interface{}{
+			"delay_minutes":      delayMinutes,
+			"estimated_start_at": estimatedStartAt,
+			"delay_reported_at":  reportedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindScheduledOpenByDoctor 医師の枠を指定する予約（即時診療・非同期相談を除く）のうち未完了のものを取得
+// 削除済みの診療枠も含めて読み込む（整合性の確認用）
+func (r *appointmentRepository) FindScheduledOpenByDoctor(doctorID uint) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := r.db.Preload("Slot", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).
+		Preload("Patient.PatientProfile").
+		Where("doctor_id = ? AND status IN ? AND is_instant = ? AND is_async = ?", doctorID, []string{"pending", "confirmed"}, false, false).
+		Order("created_at ASC, id ASC").
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// FindPerformance 指定期間に予定された予約ごとのビデオ通話時間・処方数・評価を取得
+// doctorIDが0の場合はデモアカウントを除く全医師が対象
+func (r *appointmentRepository) FindPerformance(start, end time.Time, doctorID uint) ([]AppointmentPerformance, error) {
+	query := r.db.Table("appointments").
+		Select(`appointments.id AS appointment_id, appointments.doctor_id, appointments.status, appointments.is_async,
+			COALESCE(appointments.scheduled_start, appointments.created_at) AS scheduled_at,
+			appointments.scheduled_end AS slot_end_time,
+			EXISTS (SELECT 1 FROM video_sessions WHERE video_sessions.appointment_id = appointments.id AND video_sessions.started_at IS NOT NULL AND video_sessions.deleted_at IS NULL) AS video_started,
+			(SELECT COALESCE(SUM(EXTRACT(EPOCH FROM video_sessions.ended_at - video_sessions.started_at)), 0) FROM video_sessions
+				WHERE video_sessions.appointment_id = appointments.id AND video_sessions.started_at IS NOT NULL AND video_sessions.ended_at IS NOT NULL AND video_sessions.deleted_at IS NULL) AS video_seconds,
+			(SELECT COUNT(*) FROM prescriptions WHERE prescriptions.appointment_id = appointments.id AND prescriptions.deleted_at IS NULL) AS prescriptions,
+			appointment_feedbacks.rating, appointments.delay_minutes`).
+		Joins("LEFT JOIN appointment_feedbacks ON appointment_feedbacks.appointment_id = appointments.id").
+		Where("appointments.deleted_at IS NULL").
+		Where("COALESCE(appointments.scheduled_start, appointments.created_at) >= ? AND COALESCE(appointments.scheduled_start, appointments.created_at) < ?", start, end)
+	if doctorID != 0 {
+		query = query.Where("appointments.doctor_id = ?", doctorID)
+	} else {
+		// 全医師の集計ではデモアカウントの実績を含めない
+		query = query.Where("appointments.doctor_id IN (?)", r.db.Model(&models.User{}).Select("id").Where("is_demo = ?", false))
+	}
+
+	var rows []AppointmentPerformance
+	err := query.Order("appointments.doctor_id ASC, scheduled_at ASC").Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+func TestBookSlotRejectsOverlappingBooking(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewAppointmentRepository(db)
+	doctor := createTestUser(t, db, "doctor")
+	patient := createTestUser(t, db, "patient")
+	other := createTestUser(t, db, "patient")
+
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	first := &models.AvailabilitySlot{DoctorID: doctor.ID, StartTime: start, EndTime: start.Add(30 * time.Minute), Status: "open"}
+	overlapping := &models.AvailabilitySlot{DoctorID: doctor.ID, StartTime: start.Add(15 * time.Minute), EndTime: start.Add(45 * time.Minute), Status: "open"}
+	for _, slot := range []*models.AvailabilitySlot{first, overlapping} {
+		if err := db.Create(slot).Error; err != nil {
+			t.Fatalf("failed to create slot: %v", err)
+		}
+	}
+
+	booked, err := repo.BookSlot(&models.Appointment{PatientID: patient.ID, DoctorID: doctor.ID, SlotID: &first.ID, Status: "pending"})
+	if err != nil || !booked {
+		t.Fatalf("BookSlot(first) = %v, %v; want true, nil", booked, err)
+	}
+
+	found, err := repo.FindByDoctorAndTimeRange(doctor.ID, overlapping.StartTime, overlapping.EndTime)
+	if err != nil {
+		t.Fatalf("FindByDoctorAndTimeRange() error = %v", err)
+	}
+	if len(found) != 1 || found[0].SlotID == nil || *found[0].SlotID != first.ID {
+		t.Fatalf("FindByDoctorAndTimeRange() = %+v, want the appointment in the first slot", found)
+	}
+
+	booked, err = repo.BookSlot(&models.Appointment{PatientID: other.ID, DoctorID: doctor.ID, SlotID: &overlapping.ID, Status: "pending"})
+	if err != nil {
+		t.Fatalf("BookSlot(overlapping) error = %v", err)
+	}
+	if booked {
+		t.Fatal("BookSlot(overlapping) = true, want false for a slot overlapping the doctor's booked appointment")
+	}
+
+	instantStart, instantEnd := start.Add(20*time.Minute), start.Add(50*time.Minute)
+	booked, err = repo.BookInstant(&models.Appointment{PatientID: other.ID, DoctorID: doctor.ID, Status: "confirmed", IsInstant: true, ScheduledStart: &instantStart, ScheduledEnd: &instantEnd})
+	if err != nil {
+		t.Fatalf("BookInstant() error = %v", err)
+	}
+	if booked {
+		t.Fatal("BookInstant() = true, want false for a time overlapping the doctor's booked appointment")
+	}
+}
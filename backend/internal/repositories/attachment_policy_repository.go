@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type AttachmentPolicyRepository interface {
+	FindAll() ([]models.AttachmentPolicy, error)
+	FindByRole(role string) (*models.AttachmentPolicy, error)
+	Save(policy *models.AttachmentPolicy) error
+}
+
+type attachmentPolicyRepository struct {
+	db *gorm.DB
+}
+
+func NewAttachmentPolicyRepository(db *gorm.DB) AttachmentPolicyRepository {
+	return &attachmentPolicyRepository{
+		db: db,
+	}
+}
+
+// FindAll 保存済みのロールごとの添付ファイルの制限
+func (r *attachmentPolicyRepository) FindAll() ([]models.AttachmentPolicy, error) {
+	var policies []models.AttachmentPolicy
+	err := r.db.Order("role ASC").Find(&policies).Error
+	return policies, err
+}
+
+// FindByRole ロールの添付ファイルの制限の取得（未設定の場合はnil）
+func (r *attachmentPolicyRepository) FindByRole(role string) (*models.AttachmentPolicy, error) {
+	var policy models.AttachmentPolicy
+	err := r.db.Where("role = ?", role).First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Save ロールの添付ファイルの制限の保存（ロールごとに1件）
+func (r *attachmentPolicyRepository) Save(policy *models.AttachmentPolicy) error {
+	existing, err := r.FindByRole(policy.Role)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		policy.ID = existing.ID
+	}
+	return r.db.Save(policy).Error
+}
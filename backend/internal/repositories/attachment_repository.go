@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type AttachmentRepository interface {
+	Create(attachment *models.Attachment) error
+	FindByID(id uint) (*models.Attachment, error)
+	Delete(id uint) error
+}
+
+type attachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAttachmentRepository(db *gorm.DB) AttachmentRepository {
+	return &attachmentRepository{
+		db: db,
+	}
+}
+
+func (r *attachmentRepository) Create(attachment *models.Attachment) error {
+	return r.db.Create(attachment).Error
+}
+
+// FindByID 添付ファイルの取得（ない場合はnil）
+func (r *attachmentRepository) FindByID(id uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.db.First(&attachment, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// Delete 添付ファイルの記録の削除
+func (r *attachmentRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Attachment{}, id).Error
+}
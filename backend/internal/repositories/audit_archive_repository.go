@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type AuditArchiveRepository interface {
+	FindArchivableLogs(before time.Time, limit int) ([]models.AuditLog, error)
+	CreateArchive(archive *models.AuditArchive, logs []models.AuditLog) error
+	FindByID(id uint) (*models.AuditArchive, error)
+	FindAll(limit, offset int) ([]models.AuditArchive, error)
+	SearchEntries(filter AuditArchiveEntryFilter, limit, offset int) ([]models.AuditArchiveEntry, error)
+	RestoreLogs(archive *models.AuditArchive, logs []models.AuditLog) error
+	FindExpiredRehydrations(now time.Time) ([]models.AuditArchive, error)
+	PurgeRehydratedLogs(archive *models.AuditArchive) error
+}
+
+// AuditArchiveEntryFilter アーカイブ索引の検索条件
+type AuditArchiveEntryFilter struct {
+	UserID    *uint
+	PatientID *uint
+	Entity    string
+	EntityID  string
+	Action    string
+	From      *time.Time
+	To        *time.Time
+}
+
+type auditArchiveRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditArchiveRepository(db *gorm.DB) AuditArchiveRepository {
+	return &auditArchiveRepository{
+		db: db,
+	}
+}
+
+// FindArchivableLogs アーカイブ対象（未アーカイブかつ期限切れ）の監査ログを取得
+func (r *auditArchiveRepository) FindArchivableLogs(before time.Time, limit int) ([]models.AuditLog, error) {
+	var auditLogs []models.AuditLog
+	err := r.db.Unscoped().
+		Where("at < ?", before).
+		Where("id NOT IN (SELECT audit_log_id FROM audit_archive_entries)").
+		Order("id ASC").
+		Limit(limit).
+		Find(&auditLogs).Error
+	return auditLogs, err
+}
+
+// CreateArchive アーカイブ情報と索引を登録し、元の監査ログを削除する
+func (r *auditArchiveRepository) CreateArchive(archive *models.AuditArchive, logs []models.AuditLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(archive).Error; err != nil {
+			return err
+		}
+
+		entries := make([]models.AuditArchiveEntry, 0, len(logs))
+		ids := make([]uint, 0, len(logs))
+		for _, log := range logs {
+			entries = append(entries, models.AuditArchiveEntry{
+				ArchiveID:  archive.ID,
+				AuditLogID: log.ID,
+				UserID:     log.UserID,
+				PatientID:  log.PatientID,
+				Action:     log.Action,
+				Entity:     log.Entity,
+				EntityID:   log.EntityID,
+				At:         log.At,
+			})
+			ids = append(ids, log.ID)
+		}
+
+		if err := tx.CreateInBatches(entries, 1000).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&models.AuditLog{}).Error
+	})
+}
+
+// FindByID IDでアーカイブを取得
+func (r *auditArchiveRepository) FindByID(id uint) (*models.AuditArchive, error) {
+	var archive models.AuditArchive
+	if err := r.db.First(&archive, id).Error; err != nil {
+		return nil, err
+	}
+	return &archive, nil
+}
+
+// FindAll アーカイブ一覧を取得
+func (r *auditArchiveRepository) FindAll(limit, offset int) ([]models.AuditArchive, error) {
+	var archives []models.AuditArchive
+	err := r.db.Order("from_at DESC").Limit(limit).Offset(offset).Find(&archives).Error
+	return archives, err
+}
+
+// SearchEntries アーカイブ索引を検索
+func (r *auditArchiveRepository) SearchEntries(filter AuditArchiveEntryFilter, limit, offset int) ([]models.AuditArchiveEntry, error) {
+	query := r.db.Model(&models.AuditArchiveEntry{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.PatientID != nil {
+		query = query.Where("patient_id = ?", *filter.PatientID)
+	}
+	if filter.Entity != "" {
+		query = query.Where("entity = ?", filter.Entity)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.From != nil {
+		query = query.Where("at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("at <= ?", *filter.To)
+	}
+
+	var entries []models.AuditArchiveEntry
+	err := query.Order("at DESC").Limit(limit).Offset(offset).Find(&entries).Error
+	return entries, err
+}
+
+// RestoreLogs アーカイブから監査ログを復元する（既に存在するログはスキップ）
+func (r *auditArchiveRepository) RestoreLogs(archive *models.AuditArchive, logs []models.AuditLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(logs) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Omit("User").
+				CreateInBatches(logs, 1000).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(archive).Error
+	})
+}
+
+// FindExpiredRehydrations 復元期限が切れたアーカイブを取得
+func (r *auditArchiveRepository) FindExpiredRehydrations(now time.Time) ([]models.AuditArchive, error) {
+	var archives []models.AuditArchive
+	err := r.db.Where("status = ? AND rehydrated_until < ?", "rehydrated", now).Find(&archives).Error
+	return archives, err
+}
+
+// PurgeRehydratedLogs 復元した監査ログを再び削除し、アーカイブ状態に戻す
+func (r *auditArchiveRepository) PurgeRehydratedLogs(archive *models.AuditArchive) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().
+			Where("id IN (SELECT audit_log_id FROM audit_archive_entries WHERE archive_id = ?)", archive.ID).
+			Delete(&models.AuditLog{}).Error; err != nil {
+			return err
+		}
+
+		archive.Status = "archived"
+		archive.RehydratedAt = nil
+		archive.RehydratedUntil = nil
+		return tx.Save(archive).Error
+	})
+}
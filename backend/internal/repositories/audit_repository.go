@@ -1,6 +1,11 @@
 package repositories
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 	"online_medical_consultation_app/backend/internal/models"
 	"gorm.io/gorm"
@@ -15,6 +20,53 @@ type AuditStatistics struct {
 	TopUsers      []uint
 }
 
+// AuditCursor キーセットページネーション用のカーソル（at, id）
+type AuditCursor struct {
+	At time.Time
+	ID uint
+}
+
+// AuditLogPage 監査ログの1ページ分の結果
+type AuditLogPage struct {
+	Logs       []models.AuditLog `json:"audit_logs"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	Total      *int64            `json:"total,omitempty"`
+}
+
+// EncodeAuditCursor カーソルを不透明な文字列に変換する
+func EncodeAuditCursor(cursor AuditCursor) string {
+	raw := fmt.Sprintf("%s|%d", cursor.At.UTC().Format(time.RFC3339Nano), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeAuditCursor 文字列からカーソルを復元する
+func DecodeAuditCursor(value string) (*AuditCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("invalid cursor")
+	}
+	at, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &AuditCursor{At: at, ID: uint(id)}, nil
+}
+
+// AuditActionCount 期間内の操作・重要度ごとの件数
+type AuditActionCount struct {
+	Action   string `json:"action"`
+	Severity string `json:"severity"`
+	Count    int64  `json:"count"`
+}
+
 type AuditRepository interface {
 	Create(log *models.AuditLog) error
 	FindByID(id uint) (*models.AuditLog, error)
@@ -23,8 +75,16 @@ type AuditRepository interface {
 	FindByDateRange(startDate, endDate time.Time, limit, offset int) ([]models.AuditLog, error)
 	FindByAction(action string, limit, offset int) ([]models.AuditLog, error)
 	GetStatistics(startDate, endDate time.Time) (*AuditStatistics, error)
+	CountByAction(from, to time.Time) ([]AuditActionCount, error)
 	FindWithFilter(query *gorm.DB, limit, offset int) ([]models.AuditLog, error)
+	StreamWithFilter(query *gorm.DB, limit, offset int, fn func(*models.AuditLog) error) error
+	FindPage(query *gorm.DB, cursor *AuditCursor, limit, offset int, withTotal bool) (*AuditLogPage, error)
+	FindAccessByPatientID(patientID uint, limit, offset int) ([]models.AuditLog, error)
+	FindByAppointment(appointmentID uint, sessionIDs []uint, limit int) ([]models.AuditLog, error)
 	LoadRelations(log *models.AuditLog) error
+	FindCurrentPseudonymSalt() (*models.AuditPseudonymSalt, error)
+	CreatePseudonymSalt(salt *models.AuditPseudonymSalt) error
+	DeletePseudonymSaltsBefore(id uint) (int64, error)
 	GetDB() *gorm.DB
 }
 
@@ -75,6 +135,40 @@ func (r *auditRepository) FindByEntity(entity, entityID string, limit, offset in
 	return auditLogs, err
 }
 
+// FindAccessByPatientID 患者データへの閲覧ログ一覧を取得
+func (r *auditRepository) FindAccessByPatientID(patientID uint, limit, offset int) ([]models.AuditLog, error) {
+	var auditLogs []models.AuditLog
+	err := r.db.Preload("User").Preload("User.DoctorProfile").
+		Where("patient_id = ? AND action = ?", patientID, "view").
+		Order("at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&auditLogs).Error
+	return auditLogs, err
+}
+
+// FindByAppointment 予約に関連する監査ログ（予約・ビデオセッション・予約配下のリソース）を時系列で取得
+func (r *auditRepository) FindByAppointment(appointmentID uint, sessionIDs []uint, limit int) ([]models.AuditLog, error) {
+	id := fmt.Sprintf("%d", appointmentID)
+	query := r.db.Where("(entity = ? AND entity_id = ?)", "appointment", id).
+		// 予約配下のルート（/appointments/:appointmentId/...）への操作
+		Or("meta_json LIKE ?", `%"appointmentId":"`+id+`"%`).
+		// サービス層で記録したログ
+		Or("meta_json LIKE ?", `%"appointment_id":`+id+`,%`).
+		Or("meta_json LIKE ?", `%"appointment_id":`+id+`}%`)
+	if len(sessionIDs) > 0 {
+		ids := make([]string, len(sessionIDs))
+		for i, sessionID := range sessionIDs {
+			ids[i] = fmt.Sprintf("%d", sessionID)
+		}
+		query = query.Or("(entity = ? AND entity_id IN ?)", "video_session", ids)
+	}
+
+	var auditLogs []models.AuditLog
+	err := query.Order("at ASC, id ASC").Limit(limit).Find(&auditLogs).Error
+	return auditLogs, err
+}
+
 // FindWithFilter フィルタ付きで監査ログ一覧を取得
 func (r *auditRepository) FindWithFilter(query *gorm.DB, limit, offset int) ([]models.AuditLog, error) {
 	var auditLogs []models.AuditLog
@@ -85,6 +179,71 @@ func (r *auditRepository) FindWithFilter(query *gorm.DB, limit, offset int) ([]m
 	return auditLogs, err
 }
 
+// FindPage キーセットページネーションで監査ログを取得（新しい順）
+// cursorが指定された場合はoffsetを無視する
+func (r *auditRepository) FindPage(query *gorm.DB, cursor *AuditCursor, limit, offset int, withTotal bool) (*AuditLogPage, error) {
+	page := &AuditLogPage{}
+
+	if withTotal {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Model(&models.AuditLog{}).Count(&total).Error; err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	pageQuery := query.Session(&gorm.Session{}).Preload("User").Order("at DESC, id DESC").Limit(limit + 1)
+	if cursor != nil {
+		pageQuery = pageQuery.Where("(at, id) < (?, ?)", cursor.At, cursor.ID)
+	} else if offset > 0 {
+		pageQuery = pageQuery.Offset(offset)
+	}
+
+	var auditLogs []models.AuditLog
+	if err := pageQuery.Find(&auditLogs).Error; err != nil {
+		return nil, err
+	}
+
+	// limit+1件目があれば次ページが存在する
+	if len(auditLogs) > limit {
+		auditLogs = auditLogs[:limit]
+		last := auditLogs[len(auditLogs)-1]
+		page.NextCursor = EncodeAuditCursor(AuditCursor{At: last.At, ID: last.ID})
+	}
+	page.Logs = auditLogs
+
+	return page, nil
+}
+
+// StreamWithFilter フィルタ付きで監査ログを1件ずつ読み込む（大量エクスポート用）
+// limitが0以下の場合は件数制限なし
+func (r *auditRepository) StreamWithFilter(query *gorm.DB, limit, offset int, fn func(*models.AuditLog) error) error {
+	query = query.Model(&models.AuditLog{}).Order("at ASC, id ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var auditLog models.AuditLog
+		if err := r.db.ScanRows(rows, &auditLog); err != nil {
+			return err
+		}
+		if err := fn(&auditLog); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 // Update 監査ログの更新
 func (r *auditRepository) Update(auditLog *models.AuditLog) error {
 	return r.db.Save(auditLog).Error
@@ -100,6 +259,29 @@ func (r *auditRepository) LoadRelations(auditLog *models.AuditLog) error {
 	return r.db.Preload("User").First(auditLog, auditLog.ID).Error
 }
 
+// FindCurrentPseudonymSalt 最新の仮名化のソルト（未作成の場合はnil）
+func (r *auditRepository) FindCurrentPseudonymSalt() (*models.AuditPseudonymSalt, error) {
+	var salt models.AuditPseudonymSalt
+	if err := r.db.Order("id DESC").First(&salt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &salt, nil
+}
+
+// CreatePseudonymSalt 仮名化のソルトの作成
+func (r *auditRepository) CreatePseudonymSalt(salt *models.AuditPseudonymSalt) error {
+	return r.db.Create(salt).Error
+}
+
+// DeletePseudonymSaltsBefore 指定した版より古い仮名化のソルトの削除
+func (r *auditRepository) DeletePseudonymSaltsBefore(id uint) (int64, error) {
+	result := r.db.Where("id < ?", id).Delete(&models.AuditPseudonymSalt{})
+	return result.RowsAffected, result.Error
+}
+
 // GetDB データベースインスタンスを取得
 func (r *auditRepository) GetDB() *gorm.DB {
 	return r.db
@@ -167,6 +349,18 @@ func (r *auditRepository) GetAuditLogStats() (map[string]interface{}, error) {
 	return stats, nil
 }
 
+// CountByAction 期間内（fromを含みtoを含まない）の操作・重要度ごとの件数（件数の多い順）
+func (r *auditRepository) CountByAction(from, to time.Time) ([]AuditActionCount, error) {
+	var rows []AuditActionCount
+	err := r.db.Model(&models.AuditLog{}).
+		Where("at >= ? AND at < ?", from, to).
+		Select("action, severity, COUNT(*) AS count").
+		Group("action, severity").
+		Order("count DESC, action ASC, severity ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
 // GetStatistics 監査ログの統計情報を取得
 func (r *auditRepository) GetStatistics(startDate, endDate time.Time) (*AuditStatistics, error) {
 	stats := &AuditStatistics{}
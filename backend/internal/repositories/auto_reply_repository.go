@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type AutoReplyRepository interface {
+	FindByDoctorID(doctorID uint) (*models.DoctorAutoReply, error)
+	Save(autoReply *models.DoctorAutoReply) error
+}
+
+type autoReplyRepository struct {
+	db *gorm.DB
+}
+
+func NewAutoReplyRepository(db *gorm.DB) AutoReplyRepository {
+	return &autoReplyRepository{
+		db: db,
+	}
+}
+
+// FindByDoctorID 医師の自動返信の設定を取得（未設定の場合はnil）
+func (r *autoReplyRepository) FindByDoctorID(doctorID uint) (*models.DoctorAutoReply, error) {
+	var autoReply models.DoctorAutoReply
+	err := r.db.First(&autoReply, doctorID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &autoReply, nil
+}
+
+// Save 自動返信の設定の保存（未設定の医師は作成する）
+func (r *autoReplyRepository) Save(autoReply *models.DoctorAutoReply) error {
+	return r.db.Save(autoReply).Error
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type BackupRepository interface {
+	Create(run *models.BackupRun) error
+	Update(run *models.BackupRun) error
+	FindByID(id uint) (*models.BackupRun, error)
+	FindAll(limit, offset int) ([]models.BackupRun, int64, error)
+	CountRunning() (int64, error)
+	FailStale(startedBefore time.Time, reason string) (int64, error)
+	FindSucceededBeyond(keep int) ([]models.BackupRun, error)
+}
+
+type backupRepository struct {
+	db *gorm.DB
+}
+
+func NewBackupRepository(db *gorm.DB) BackupRepository {
+	return &backupRepository{
+		db: db,
+	}
+}
+
+func (r *backupRepository) Create(run *models.BackupRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *backupRepository) Update(run *models.BackupRun) error {
+	return r.db.Save(run).Error
+}
+
+func (r *backupRepository) FindByID(id uint) (*models.BackupRun, error) {
+	var run models.BackupRun
+	if err := r.db.First(&run, id).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// FindAll 実行履歴を新しい順に取得（総件数とあわせて返す）
+func (r *backupRepository) FindAll(limit, offset int) ([]models.BackupRun, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.BackupRun{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var runs []models.BackupRun
+	err := r.db.Order("started_at DESC, id DESC").Limit(limit).Offset(offset).Find(&runs).Error
+	return runs, total, err
+}
+
+// CountRunning 実行中のバックアップの件数
+func (r *backupRepository) CountRunning() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.BackupRun{}).Where("status = ?", "running").Count(&count).Error
+	return count, err
+}
+
+// FailStale 指定時刻より前に開始して終了していないバックアップを失敗として記録する（プロセスの停止等で中断したもの）
+func (r *backupRepository) FailStale(startedBefore time.Time, reason string) (int64, error) {
+	result := r.db.Model(&models.BackupRun{}).
+		Where("status = ? AND started_at < ?", "running", startedBefore).
+		Updates(map[string]interface{}{"status": "failed", "error": reason, "finished_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
+
+// FindSucceededBeyond 新しい順にkeep件を超える成功済みのバックアップ（保存数を超えた古いもの）
+func (r *backupRepository) FindSucceededBeyond(keep int) ([]models.BackupRun, error) {
+	var runs []models.BackupRun
+	err := r.db.Where("status = ?", "succeeded").
+		Order("started_at DESC, id DESC").
+		Offset(keep).
+		Find(&runs).Error
+	return runs, err
+}
@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+// bookingPolicyID 予約受付ルールは1件のみ保存する
+const bookingPolicyID = 1
+
+type BookingPolicyRepository interface {
+	Find() (*models.BookingPolicy, error)
+	Save(policy *models.BookingPolicy) error
+}
+
+type bookingPolicyRepository struct {
+	db *gorm.DB
+}
+
+func NewBookingPolicyRepository(db *gorm.DB) BookingPolicyRepository {
+	return &bookingPolicyRepository{
+		db: db,
+	}
+}
+
+// Find 保存済みの予約受付ルールを取得（未設定の場合はnil）
+func (r *bookingPolicyRepository) Find() (*models.BookingPolicy, error) {
+	var policy models.BookingPolicy
+	err := r.db.First(&policy, bookingPolicyID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Save 予約受付ルールの保存
+func (r *bookingPolicyRepository) Save(policy *models.BookingPolicy) error {
+	policy.ID = bookingPolicyID
+	return r.db.Save(policy).Error
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type BreakGlassRepository interface {
+	Create(access *models.BreakGlassAccess) error
+	FindByID(id uint) (*models.BreakGlassAccess, error)
+	FindActive(userID, patientID uint, now time.Time) (*models.BreakGlassAccess, error)
+	End(id uint, endedAt time.Time) (bool, error)
+	FindAll(patientID uint, limit, offset int) ([]models.BreakGlassAccess, int64, error)
+}
+
+type breakGlassRepository struct {
+	db *gorm.DB
+}
+
+func NewBreakGlassRepository(db *gorm.DB) BreakGlassRepository {
+	return &breakGlassRepository{
+		db: db,
+	}
+}
+
+func (r *breakGlassRepository) Create(access *models.BreakGlassAccess) error {
+	return r.db.Create(access).Error
+}
+
+func (r *breakGlassRepository) FindByID(id uint) (*models.BreakGlassAccess, error) {
+	var access models.BreakGlassAccess
+	if err := r.db.First(&access, id).Error; err != nil {
+		return nil, err
+	}
+	return &access, nil
+}
+
+// FindActive 有効な（期限内で終了していない）緊急時アクセスを取得（ない場合はnil）
+func (r *breakGlassRepository) FindActive(userID, patientID uint, now time.Time) (*models.BreakGlassAccess, error) {
+	var accesses []models.BreakGlassAccess
+	err := r.db.Where("user_id = ? AND patient_id = ? AND ended_at IS NULL AND expires_at > ?", userID, patientID, now).
+		Order("expires_at DESC").
+		Limit(1).
+		Find(&accesses).Error
+	if err != nil || len(accesses) == 0 {
+		return nil, err
+	}
+	return &accesses[0], nil
+}
+
+// End 期限前の終了（既に終了・期限切れの場合はfalse）
+func (r *breakGlassRepository) End(id uint, endedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.BreakGlassAccess{}).
+		Where("id = ? AND ended_at IS NULL AND expires_at > ?", id, endedAt).
+		Update("ended_at", endedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindAll 緊急時アクセスを新しい順に取得（patientIDが0の場合は全患者、総件数とあわせて返す）
+func (r *breakGlassRepository) FindAll(patientID uint, limit, offset int) ([]models.BreakGlassAccess, int64, error) {
+	query := r.db.Model(&models.BreakGlassAccess{})
+	if patientID != 0 {
+		query = query.Where("patient_id = ?", patientID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var accesses []models.BreakGlassAccess
+	err := query.Preload("User.DoctorProfile").
+		Preload("Patient.PatientProfile").
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&accesses).Error
+	return accesses, total, err
+}
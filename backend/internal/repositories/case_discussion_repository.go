@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type CaseDiscussionRepository interface {
+	Create(discussion *models.CaseDiscussion) error
+	FindByID(id uint) (*models.CaseDiscussion, error)
+	FindByAppointmentID(appointmentID uint) ([]models.CaseDiscussion, error)
+	FindByDoctorID(doctorID uint, status string) ([]models.CaseDiscussion, error)
+	FindOpenByAppointmentAndConsultant(appointmentID, consultantDoctorID uint) (*models.CaseDiscussion, error)
+	Update(discussion *models.CaseDiscussion) error
+}
+
+type caseDiscussionRepository struct {
+	db *gorm.DB
+}
+
+func NewCaseDiscussionRepository(db *gorm.DB) CaseDiscussionRepository {
+	return &caseDiscussionRepository{
+		db: db,
+	}
+}
+
+// Create 症例相談スレッドの作成
+func (r *caseDiscussionRepository) Create(discussion *models.CaseDiscussion) error {
+	return r.db.Omit("Appointment", "RequestingDoctor", "ConsultantDoctor").Create(discussion).Error
+}
+
+// FindByID IDで症例相談スレッドを取得
+func (r *caseDiscussionRepository) FindByID(id uint) (*models.CaseDiscussion, error) {
+	var discussion models.CaseDiscussion
+	err := r.db.Preload("RequestingDoctor.DoctorProfile").
+		Preload("ConsultantDoctor.DoctorProfile").
+		First(&discussion, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &discussion, nil
+}
+
+// FindByAppointmentID 予約IDで症例相談スレッド一覧を取得
+func (r *caseDiscussionRepository) FindByAppointmentID(appointmentID uint) ([]models.CaseDiscussion, error) {
+	var discussions []models.CaseDiscussion
+	err := r.db.Preload("RequestingDoctor.DoctorProfile").
+		Preload("ConsultantDoctor.DoctorProfile").
+		Where("appointment_id = ?", appointmentID).
+		Order("created_at DESC").
+		Find(&discussions).Error
+	return discussions, err
+}
+
+// FindByDoctorID 医師が参加する症例相談スレッド一覧を取得（statusが空の場合は全件）
+func (r *caseDiscussionRepository) FindByDoctorID(doctorID uint, status string) ([]models.CaseDiscussion, error) {
+	var discussions []models.CaseDiscussion
+	query := r.db.Preload("RequestingDoctor.DoctorProfile").
+		Preload("ConsultantDoctor.DoctorProfile").
+		Where("requesting_doctor_id = ? OR consultant_doctor_id = ?", doctorID, doctorID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("updated_at DESC").Find(&discussions).Error
+	return discussions, err
+}
+
+// FindOpenByAppointmentAndConsultant 同じ相談先との未終了のスレッドを取得（存在しない場合はnil）
+func (r *caseDiscussionRepository) FindOpenByAppointmentAndConsultant(appointmentID, consultantDoctorID uint) (*models.CaseDiscussion, error) {
+	var discussion models.CaseDiscussion
+	err := r.db.Where("appointment_id = ? AND consultant_doctor_id = ? AND status IN ?",
+		appointmentID, consultantDoctorID, []string{"pending_consent", "active"}).
+		First(&discussion).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &discussion, nil
+}
+
+// Update 症例相談スレッドの更新
+func (r *caseDiscussionRepository) Update(discussion *models.CaseDiscussion) error {
+	return r.db.Omit("Appointment", "RequestingDoctor", "ConsultantDoctor").Save(discussion).Error
+}
@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+// clinicBrandingID クリニックの表記は1件のみ保存する
+const clinicBrandingID = 1
+
+type ClinicBrandingRepository interface {
+	Find() (*models.ClinicBranding, error)
+	Save(branding *models.ClinicBranding) error
+}
+
+type clinicBrandingRepository struct {
+	db *gorm.DB
+}
+
+func NewClinicBrandingRepository(db *gorm.DB) ClinicBrandingRepository {
+	return &clinicBrandingRepository{
+		db: db,
+	}
+}
+
+// Find 保存済みのクリニックの表記を取得（未設定の場合はnil）
+func (r *clinicBrandingRepository) Find() (*models.ClinicBranding, error) {
+	var branding models.ClinicBranding
+	err := r.db.First(&branding, clinicBrandingID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &branding, nil
+}
+
+// Save クリニックの表記の保存
+func (r *clinicBrandingRepository) Save(branding *models.ClinicBranding) error {
+	branding.ID = clinicBrandingID
+	return r.db.Save(branding).Error
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type ClinicalCodingRepository interface {
+	CreateSuggestions(suggestions []models.CodingSuggestion) error
+	FindSuggestionsByAppointmentID(appointmentID uint) ([]models.CodingSuggestion, error)
+	FindSuggestionsByIDs(appointmentID uint, ids []uint) ([]models.CodingSuggestion, error)
+	ReviewSuggestions(appointmentID uint, ids []uint, status string, reviewedAt time.Time) (int64, error)
+	FindProblems(patientID uint, dependentID *uint) ([]models.ProblemListEntry, error)
+	AddProblems(entries []models.ProblemListEntry) ([]models.ProblemListEntry, error)
+}
+
+type clinicalCodingRepository struct {
+	db *gorm.DB
+}
+
+func NewClinicalCodingRepository(db *gorm.DB) ClinicalCodingRepository {
+	return &clinicalCodingRepository{
+		db: db,
+	}
+}
+
+// CreateSuggestions コード候補の保存
+func (r *clinicalCodingRepository) CreateSuggestions(suggestions []models.CodingSuggestion) error {
+	if len(suggestions) == 0 {
+		return nil
+	}
+	return r.db.Create(&suggestions).Error
+}
+
+// FindSuggestionsByAppointmentID 予約のコード候補を新しい順に取得
+func (r *clinicalCodingRepository) FindSuggestionsByAppointmentID(appointmentID uint) ([]models.CodingSuggestion, error) {
+	var suggestions []models.CodingSuggestion
+	err := r.db.Where("appointment_id = ?", appointmentID).
+		Order("created_at DESC, confidence DESC").
+		Find(&suggestions).Error
+	return suggestions, err
+}
+
+// FindSuggestionsByIDs 予約のコード候補をIDで取得
+func (r *clinicalCodingRepository) FindSuggestionsByIDs(appointmentID uint, ids []uint) ([]models.CodingSuggestion, error) {
+	var suggestions []models.CodingSuggestion
+	err := r.db.Where("appointment_id = ? AND id IN ?", appointmentID, ids).Find(&suggestions).Error
+	return suggestions, err
+}
+
+// ReviewSuggestions 未確認のコード候補の確定・却下（確認済みのものは変更しない）
+func (r *clinicalCodingRepository) ReviewSuggestions(appointmentID uint, ids []uint, status string, reviewedAt time.Time) (int64, error) {
+	result := r.db.Model(&models.CodingSuggestion{}).
+		Where("appointment_id = ? AND id IN ? AND status = ?", appointmentID, ids, "pending").
+		Updates(map[string]interface{}{"status": status, "reviewed_at": reviewedAt})
+	return result.RowsAffected, result.Error
+}
+
+// FindProblems 患者（または家族）の問題リストを取得
+func (r *clinicalCodingRepository) FindProblems(patientID uint, dependentID *uint) ([]models.ProblemListEntry, error) {
+	query := r.db.Where("patient_id = ?", patientID)
+	if dependentID != nil {
+		query = query.Where("dependent_id = ?", *dependentID)
+	} else {
+		query = query.Where("dependent_id IS NULL")
+	}
+
+	var entries []models.ProblemListEntry
+	err := query.Order("status ASC, created_at DESC").Find(&entries).Error
+	return entries, err
+}
+
+// AddProblems 問題リストへの追加（すでに有効な同じコードは追加せず、追加した病名のみ返す）
+func (r *clinicalCodingRepository) AddProblems(entries []models.ProblemListEntry) ([]models.ProblemListEntry, error) {
+	var added []models.ProblemListEntry
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, entry := range entries {
+			query := tx.Model(&models.ProblemListEntry{}).
+				Where("patient_id = ? AND code = ? AND status = ?", entry.PatientID, entry.Code, "active")
+			if entry.DependentID != nil {
+				query = query.Where("dependent_id = ?", *entry.DependentID)
+			} else {
+				query = query.Where("dependent_id IS NULL")
+			}
+			var count int64
+			if err := query.Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+			added = append(added, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
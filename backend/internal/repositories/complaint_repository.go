@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type ComplaintRepository interface {
+	Create(complaint *models.Complaint, evidence []models.ComplaintEvidence) error
+	FindByID(id uint) (*models.Complaint, error)
+	FindByComplainantID(complainantID uint, limit, offset int) ([]models.Complaint, error)
+	FindAll(status string, assignedAdminID *uint, limit, offset int) ([]models.Complaint, error)
+	Update(complaint *models.Complaint) error
+}
+
+type complaintRepository struct {
+	db *gorm.DB
+}
+
+func NewComplaintRepository(db *gorm.DB) ComplaintRepository {
+	return &complaintRepository{
+		db: db,
+	}
+}
+
+// Create 苦情と証跡をまとめて登録
+func (r *complaintRepository) Create(complaint *models.Complaint, evidence []models.ComplaintEvidence) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Complainant", "AssignedAdmin", "Evidence").Create(complaint).Error; err != nil {
+			return err
+		}
+		if len(evidence) == 0 {
+			return nil
+		}
+		for i := range evidence {
+			evidence[i].ComplaintID = complaint.ID
+		}
+		return tx.CreateInBatches(evidence, 500).Error
+	})
+}
+
+// FindByID IDで苦情を取得（証跡を含む）
+func (r *complaintRepository) FindByID(id uint) (*models.Complaint, error) {
+	var complaint models.Complaint
+	err := r.db.Preload("Complainant").Preload("AssignedAdmin").
+		Preload("Evidence", func(db *gorm.DB) *gorm.DB { return db.Order("at ASC, id ASC") }).
+		First(&complaint, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &complaint, nil
+}
+
+// FindByComplainantID 申立者の苦情一覧を取得
+func (r *complaintRepository) FindByComplainantID(complainantID uint, limit, offset int) ([]models.Complaint, error) {
+	var complaints []models.Complaint
+	err := r.db.Where("complainant_id = ?", complainantID).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&complaints).Error
+	return complaints, err
+}
+
+// FindAll 苦情一覧を取得（管理者用）
+func (r *complaintRepository) FindAll(status string, assignedAdminID *uint, limit, offset int) ([]models.Complaint, error) {
+	query := r.db.Preload("Complainant").Preload("AssignedAdmin")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if assignedAdminID != nil {
+		query = query.Where("assigned_admin_id = ?", *assignedAdminID)
+	}
+
+	var complaints []models.Complaint
+	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&complaints).Error
+	return complaints, err
+}
+
+// Update 苦情の更新
+func (r *complaintRepository) Update(complaint *models.Complaint) error {
+	return r.db.Omit("Complainant", "AssignedAdmin", "Evidence").Save(complaint).Error
+}
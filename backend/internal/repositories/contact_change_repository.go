@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type ContactChangeRepository interface {
+	Create(request *models.ContactChangeRequest) error
+	FindPendingByTokenHash(tokenHash string) (*models.ContactChangeRequest, error)
+	FindPendingByUser(userID uint, kind string) (*models.ContactChangeRequest, error)
+	CancelPendingByUser(userID uint, kind string) error
+	Update(request *models.ContactChangeRequest) error
+}
+
+type contactChangeRepository struct {
+	db *gorm.DB
+}
+
+func NewContactChangeRepository(db *gorm.DB) ContactChangeRepository {
+	return &contactChangeRepository{
+		db: db,
+	}
+}
+
+// Create 変更申請の作成
+func (r *contactChangeRepository) Create(request *models.ContactChangeRequest) error {
+	return r.db.Create(request).Error
+}
+
+// FindPendingByTokenHash 新旧いずれかのトークンに一致する未完了の申請を取得
+func (r *contactChangeRepository) FindPendingByTokenHash(tokenHash string) (*models.ContactChangeRequest, error) {
+	var request models.ContactChangeRequest
+	err := r.db.Where("status = ? AND (new_token_hash = ? OR old_token_hash = ?)", "pending", tokenHash, tokenHash).
+		First(&request).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// FindPendingByUser ユーザーの未完了の申請を取得（最新のもの）
+func (r *contactChangeRepository) FindPendingByUser(userID uint, kind string) (*models.ContactChangeRequest, error) {
+	var request models.ContactChangeRequest
+	err := r.db.Where("user_id = ? AND kind = ? AND status = ?", userID, kind, "pending").
+		Order("created_at DESC").
+		First(&request).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// CancelPendingByUser 未完了の申請をすべて取り消す（再申請時）
+func (r *contactChangeRepository) CancelPendingByUser(userID uint, kind string) error {
+	return r.db.Model(&models.ContactChangeRequest{}).
+		Where("user_id = ? AND kind = ? AND status = ?", userID, kind, "pending").
+		Update("status", "cancelled").Error
+}
+
+// Update 変更申請の更新
+func (r *contactChangeRepository) Update(request *models.ContactChangeRequest) error {
+	return r.db.Save(request).Error
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+// CorrectionRequestFilter 訂正の依頼の一覧の条件（nil・空の項目は絞り込まない）
+type CorrectionRequestFilter struct {
+	PatientID *uint
+	DoctorID  *uint
+	Status    string
+}
+
+type CorrectionRequestRepository interface {
+	Create(request *models.CorrectionRequest) error
+	FindByID(id uint) (*models.CorrectionRequest, error)
+	FindPage(filter CorrectionRequestFilter, limit, offset int) ([]models.CorrectionRequest, int64, error)
+	FindOpen(medicalRecordID uint, field string) (*models.CorrectionRequest, error)
+	Update(request *models.CorrectionRequest) error
+}
+
+type correctionRequestRepository struct {
+	db *gorm.DB
+}
+
+func NewCorrectionRequestRepository(db *gorm.DB) CorrectionRequestRepository {
+	return &correctionRequestRepository{
+		db: db,
+	}
+}
+
+func (r *correctionRequestRepository) Create(request *models.CorrectionRequest) error {
+	return r.db.Omit("Patient", "Doctor").Create(request).Error
+}
+
+// FindByID 訂正の依頼の取得（ない場合はnil）
+func (r *correctionRequestRepository) FindByID(id uint) (*models.CorrectionRequest, error) {
+	var request models.CorrectionRequest
+	err := r.db.Preload("Patient.PatientProfile").Preload("Doctor.DoctorProfile").First(&request, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// FindPage 訂正の依頼の一覧（新しい順）
+func (r *correctionRequestRepository) FindPage(filter CorrectionRequestFilter, limit, offset int) ([]models.CorrectionRequest, int64, error) {
+	query := r.db.Model(&models.CorrectionRequest{})
+	if filter.PatientID != nil {
+		query = query.Where("patient_id = ?", *filter.PatientID)
+	}
+	if filter.DoctorID != nil {
+		query = query.Where("doctor_id = ?", *filter.DoctorID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var requests []models.CorrectionRequest
+	err := query.Preload("Patient.PatientProfile").Preload("Doctor.DoctorProfile").
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&requests).Error
+	return requests, total, err
+}
+
+// FindOpen 診療録の同じ項目への対応中の依頼（ない場合はnil）
+func (r *correctionRequestRepository) FindOpen(medicalRecordID uint, field string) (*models.CorrectionRequest, error) {
+	var request models.CorrectionRequest
+	err := r.db.Where("medical_record_id = ? AND field = ? AND status = ?", medicalRecordID, field, "open").First(&request).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *correctionRequestRepository) Update(request *models.CorrectionRequest) error {
+	return r.db.Omit("Patient", "Doctor").Save(request).Error
+}
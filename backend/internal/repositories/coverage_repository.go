@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type CoverageRepository interface {
+	Create(coverage *models.DoctorCoverage) error
+	FindByID(id uint) (*models.DoctorCoverage, error)
+	FindUpcomingByDoctor(doctorID uint, since time.Time) ([]models.DoctorCoverage, error)
+	FindOverlapping(doctorID uint, start, end time.Time) ([]models.DoctorCoverage, error)
+	Cancel(id uint) (bool, error)
+	IsCovering(doctorID, coveringDoctorID uint, at time.Time) (bool, error)
+	CreateTransfer(transfer *models.AppointmentTransfer) error
+	FindTransferByID(id uint) (*models.AppointmentTransfer, error)
+	FindPendingTransfersByPatient(patientID uint) ([]models.AppointmentTransfer, error)
+	RespondTransfer(id uint, status string, respondedAt time.Time) (bool, error)
+	CancelPendingTransfers(coverageID uint) (int64, error)
+}
+
+type coverageRepository struct {
+	db *gorm.DB
+}
+
+func NewCoverageRepository(db *gorm.DB) CoverageRepository {
+	return &coverageRepository{
+		db: db,
+	}
+}
+
+// Create 代診の登録
+func (r *coverageRepository) Create(coverage *models.DoctorCoverage) error {
+	return r.db.Create(coverage).Error
+}
+
+// FindByID IDで代診を引き継ぎの一覧とあわせて取得（存在しない場合はnil）
+func (r *coverageRepository) FindByID(id uint) (*models.DoctorCoverage, error) {
+	var coverage models.DoctorCoverage
+	err := r.db.Preload("Doctor.DoctorProfile").Preload("CoveringDoctor.DoctorProfile").Preload("Transfers").First(&coverage, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &coverage, nil
+}
+
+// FindUpcomingByDoctor 指定時刻以降に終了する、医師が休診する・代診医を務める有効な代診を取得
+func (r *coverageRepository) FindUpcomingByDoctor(doctorID uint, since time.Time) ([]models.DoctorCoverage, error) {
+	var coverages []models.DoctorCoverage
+	err := r.db.Preload("Doctor.DoctorProfile").Preload("CoveringDoctor.DoctorProfile").Preload("Transfers").
+		Where("(doctor_id = ? OR covering_doctor_id = ?) AND status = ? AND end_time > ?", doctorID, doctorID, "active", since).
+		Order("start_time ASC").
+		Find(&coverages).Error
+	return coverages, err
+}
+
+// FindOverlapping 指定期間と重なる医師が休診する有効な代診を取得
+func (r *coverageRepository) FindOverlapping(doctorID uint, start, end time.Time) ([]models.DoctorCoverage, error) {
+	var coverages []models.DoctorCoverage
+	err := r.db.Where("doctor_id = ? AND status = ? AND start_time < ? AND end_time > ?", doctorID, "active", end, start).
+		Find(&coverages).Error
+	return coverages, err
+}
+
+// Cancel 有効な代診の取り消し（取り消し済みの場合はfalse）
+func (r *coverageRepository) Cancel(id uint) (bool, error) {
+	result := r.db.Model(&models.DoctorCoverage{}).
+		Where("id = ? AND status = ?", id, "active").
+		Update("status", "cancelled")
+	return result.RowsAffected > 0, result.Error
+}
+
+// IsCovering 指定時刻に代診医が医師の代診期間中かどうか
+func (r *coverageRepository) IsCovering(doctorID, coveringDoctorID uint, at time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.DoctorCoverage{}).
+		Where("doctor_id = ? AND covering_doctor_id = ? AND status = ? AND start_time <= ? AND end_time > ?", doctorID, coveringDoctorID, "active", at, at).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CreateTransfer 予約の引き継ぎの作成
+func (r *coverageRepository) CreateTransfer(transfer *models.AppointmentTransfer) error {
+	return r.db.Create(transfer).Error
+}
+
+// FindTransferByID IDで予約の引き継ぎを取得（存在しない場合はnil）
+func (r *coverageRepository) FindTransferByID(id uint) (*models.AppointmentTransfer, error) {
+	var transfer models.AppointmentTransfer
+	if err := r.db.First(&transfer, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// FindPendingTransfersByPatient 患者の同意待ちの予約の引き継ぎを予約・代診医とあわせて取得
+func (r *coverageRepository) FindPendingTransfersByPatient(patientID uint) ([]models.AppointmentTransfer, error) {
+	var transfers []models.AppointmentTransfer
+	err := r.db.Preload("Appointment.Slot").Preload("ToDoctor.DoctorProfile").
+		Where("patient_id = ? AND status = ?", patientID, "pending").
+		Order("created_at ASC").
+		Find(&transfers).Error
+	return transfers, err
+}
+
+// RespondTransfer 同意待ちの予約の引き継ぎへの回答の記録（回答済み・取り消し済みの場合はfalse）
+func (r *coverageRepository) RespondTransfer(id uint, status string, respondedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.AppointmentTransfer{}).
+		Where("id = ? AND status = ?", id, "pending").
+		Updates(map[string]interface{}{
+			"status":       status,
+			"responded_at": respondedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CancelPendingTransfers 代診の同意待ちの予約の引き継ぎをすべて取り消す
+func (r *coverageRepository) CancelPendingTransfers(coverageID uint) (int64, error) {
+	result := r.db.Model(&models.AppointmentTransfer{}).
+		Where("coverage_id = ? AND status = ?", coverageID, "pending").
+		Update("status", "cancelled")
+	return result.RowsAffected, result.Error
+}
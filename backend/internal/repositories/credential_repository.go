@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type CredentialRepository interface {
+	Create(credential *models.DoctorCredential) error
+	FindByID(id uint) (*models.DoctorCredential, error)
+	FindByDoctorID(doctorID uint) ([]models.DoctorCredential, error)
+	FindPublicByDoctorID(doctorID uint) ([]models.DoctorCredential, error)
+	FindByStatus(status string, limit, offset int) ([]models.DoctorCredential, error)
+	Update(credential *models.DoctorCredential) error
+	Delete(id uint) error
+}
+
+type credentialRepository struct {
+	db *gorm.DB
+}
+
+func NewCredentialRepository(db *gorm.DB) CredentialRepository {
+	return &credentialRepository{
+		db: db,
+	}
+}
+
+// Create 資格証明書類の登録
+func (r *credentialRepository) Create(credential *models.DoctorCredential) error {
+	return r.db.Create(credential).Error
+}
+
+// FindByID IDで資格証明書類を取得
+func (r *credentialRepository) FindByID(id uint) (*models.DoctorCredential, error) {
+	var credential models.DoctorCredential
+	if err := r.db.First(&credential, id).Error; err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// FindByDoctorID 医師の資格証明書類をすべて取得
+func (r *credentialRepository) FindByDoctorID(doctorID uint) ([]models.DoctorCredential, error) {
+	var credentials []models.DoctorCredential
+	err := r.db.Where("doctor_id = ?", doctorID).Order("created_at DESC").Find(&credentials).Error
+	return credentials, err
+}
+
+// FindPublicByDoctorID 承認済みかつ公開設定の資格証明書類を取得
+func (r *credentialRepository) FindPublicByDoctorID(doctorID uint) ([]models.DoctorCredential, error) {
+	var credentials []models.DoctorCredential
+	err := r.db.Where("doctor_id = ? AND status = ? AND is_public = ?", doctorID, "approved", true).
+		Order("created_at DESC").
+		Find(&credentials).Error
+	return credentials, err
+}
+
+// FindByStatus ステータスで資格証明書類を取得（審査待ちの一覧など、古い順）
+func (r *credentialRepository) FindByStatus(status string, limit, offset int) ([]models.DoctorCredential, error) {
+	var credentials []models.DoctorCredential
+	query := r.db.Model(&models.DoctorCredential{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at ASC").Limit(limit).Offset(offset).Find(&credentials).Error
+	return credentials, err
+}
+
+// Update 資格証明書類の更新
+func (r *credentialRepository) Update(credential *models.DoctorCredential) error {
+	return r.db.Save(credential).Error
+}
+
+// Delete 資格証明書類の削除
+func (r *credentialRepository) Delete(id uint) error {
+	return r.db.Delete(&models.DoctorCredential{}, id).Error
+}
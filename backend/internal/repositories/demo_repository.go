@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+// DemoPurgeResult デモデータの削除結果
+type DemoPurgeResult struct {
+	Appointments int64
+	Files        []string                 // 削除したデータが参照していたファイル（録音）
+	Documents    []models.PatientDocument // 削除した診療記録（同じ内容の他の診療記録とファイルを共有する場合がある）
+	Attachments  []models.Attachment      // 削除したチャットの添付ファイル
+}
+
+type DemoRepository interface {
+	FindDemoUsers() ([]models.User, error)
+	SetPasswordHash(userID uint, passwordHash string) error
+	Purge(userIDs []uint) (*DemoPurgeResult, error)
+}
+
+type demoRepository struct {
+	db *gorm.DB
+}
+
+func NewDemoRepository(db *gorm.DB) DemoRepository {
+	return &demoRepository{
+		db: db,
+	}
+}
+
+// FindDemoUsers デモアカウントの一覧
+func (r *demoRepository) FindDemoUsers() ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("is_demo = ?", true).Order("id ASC").Find(&users).Error
+	return users, err
+}
+
+// SetPasswordHash デモアカウントのパスワードの更新（デモアカウント以外は更新しない）
+func (r *demoRepository) SetPasswordHash(userID uint, passwordHash string) error {
+	return r.db.Model(&models.User{}).Where("id = ? AND is_demo = ?", userID, true).Update("password_hash", passwordHash).Error
+}
+
+// Purge デモアカウントの予約・診療記録・通知等を物理削除する（単一トランザクション）
+// アカウントとプロフィール・同意記録は残し、監査ログは削除しない
+func (r *demoRepository) Purge(userIDs []uint) (*DemoPurgeResult, error) {
+	result := &DemoPurgeResult{}
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Unscoped()
+		appointments := func() *gorm.DB {
+			return db.Model(&models.Appointment{}).Select("id").Where("patient_id IN ? OR doctor_id IN ?", userIDs, userIDs)
+		}
+		transcripts := func() *gorm.DB {
+			return db.Model(&models.Transcript{}).Select("id").Where("appointment_id IN (?)", appointments())
+		}
+		videoSessions := func() *gorm.DB {
+			return db.Model(&models.VideoSession{}).Select("id").Where("appointment_id IN (?)", appointments())
+		}
+		patientDocuments := func() *gorm.DB {
+			return db.Model(&models.PatientDocument{}).Select("id").Where("patient_id IN ?", userIDs)
+		}
+		invoices := func() *gorm.DB {
+			return db.Model(&models.Invoice{}).Select("id").Where("appointment_id IN (?)", appointments())
+		}
+		complaints := func() *gorm.DB {
+			return db.Model(&models.Complaint{}).Select("id").Where("appointment_id IN (?) OR complainant_id IN ?", appointments(), userIDs)
+		}
+
+		if err := db.Where("patient_id IN ?", userIDs).Find(&result.Documents).Error; err != nil {
+			return err
+		}
+		if err := db.Model(&models.Transcript{}).Where("appointment_id IN (?) AND audio_path <> ''", appointments()).Pluck("audio_path", &result.Files).Error; err != nil {
+			return err
+		}
+		if err := db.Where("appointment_id IN (?)", appointments()).Find(&result.Attachments).Error; err != nil {
+			return err
+		}
+
+		// 参照する側から順に削除する
+		deletes := []struct {
+			model interface{}
+			where string
+			args  []interface{}
+		}{
+			{&models.TranscriptSegment{}, "transcript_id IN (?)", []interface{}{transcripts()}},
+			{&models.Transcript{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.VideoParticipant{}, "video_session_id IN (?)", []interface{}{videoSessions()}},
+			{&models.ICECandidate{}, "video_session_id IN (?)", []interface{}{videoSessions()}},
+			{&models.VideoPresence{}, "video_session_id IN (?)", []interface{}{videoSessions()}},
+			{&models.ComplaintEvidence{}, "complaint_id IN (?)", []interface{}{complaints()}},
+			{&models.Complaint{}, "appointment_id IN (?) OR complainant_id IN ?", []interface{}{appointments(), userIDs}},
+			{&models.MessageFlag{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.Message{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.Attachment{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.VideoSession{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.Prescription{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.Payment{}, "invoice_id IN (?)", []interface{}{invoices()}},
+			{&models.Invoice{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.Escalation{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.AppointmentTask{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.AppointmentTag{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.PROMAssignment{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.MedicalRecord{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.AppointmentTransfer{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.DoctorCoverage{}, "doctor_id IN ? OR covering_doctor_id IN ?", []interface{}{userIDs, userIDs}},
+			{&models.CodingSuggestion{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.AppointmentFeedback{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.AppointmentDocumentGrant{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.CaseDiscussion{}, "appointment_id IN (?)", []interface{}{appointments()}},
+			{&models.TriageAssessment{}, "patient_id IN ? OR appointment_id IN (?)", []interface{}{userIDs, appointments()}},
+			{&models.ProblemListEntry{}, "patient_id IN ?", []interface{}{userIDs}},
+			{&models.PatientDocumentValue{}, "document_id IN (?)", []interface{}{patientDocuments()}},
+		}
+		for _, d := range deletes {
+			if err := db.Where(d.where, d.args...).Delete(d.model).Error; err != nil {
+				return err
+			}
+		}
+
+		// 通訳者の枠はデモデータではないため解放のみ行う
+		if err := db.Model(&models.InterpreterSlot{}).Where("appointment_id IN (?)", appointments()).Update("appointment_id", nil).Error; err != nil {
+			return err
+		}
+
+		deleted := db.Where("patient_id IN ? OR doctor_id IN ?", userIDs, userIDs).Delete(&models.Appointment{})
+		if deleted.Error != nil {
+			return deleted.Error
+		}
+		result.Appointments = deleted.RowsAffected
+
+		owned := []struct {
+			model  interface{}
+			column string
+		}{
+			{&models.Notification{}, "user_id"},
+			{&models.NotificationPreference{}, "user_id"},
+			{&models.DeviceToken{}, "user_id"},
+			{&models.SlotSubscription{}, "patient_id"},
+			{&models.ContactChangeRequest{}, "user_id"},
+			{&models.AvailabilitySlot{}, "doctor_id"},
+			{&models.SlotTemplate{}, "doctor_id"},
+			{&models.DoctorAutoReply{}, "doctor_id"},
+			{&models.DoctorTimeOff{}, "doctor_id"},
+			{&models.PatientDocument{}, "patient_id"},
+			{&models.Dependent{}, "guardian_id"},
+		}
+		for _, o := range owned {
+			if err := db.Where(o.column+" IN ?", userIDs).Delete(o.model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
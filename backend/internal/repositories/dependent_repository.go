@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type DependentRepository interface {
+	Create(dependent *models.Dependent) error
+	FindByID(id uint) (*models.Dependent, error)
+	FindByGuardianID(guardianID uint) ([]models.Dependent, error)
+	Update(dependent *models.Dependent) error
+	Delete(id uint) error
+}
+
+type dependentRepository struct {
+	db *gorm.DB
+}
+
+func NewDependentRepository(db *gorm.DB) DependentRepository {
+	return &dependentRepository{
+		db: db,
+	}
+}
+
+// Create 家族アカウントの作成
+func (r *dependentRepository) Create(dependent *models.Dependent) error {
+	return r.db.Create(dependent).Error
+}
+
+// FindByID IDで家族アカウントを取得
+func (r *dependentRepository) FindByID(id uint) (*models.Dependent, error) {
+	var dependent models.Dependent
+	if err := r.db.First(&dependent, id).Error; err != nil {
+		return nil, err
+	}
+	return &dependent, nil
+}
+
+// FindByGuardianID 保護者IDで家族アカウント一覧を取得
+func (r *dependentRepository) FindByGuardianID(guardianID uint) ([]models.Dependent, error) {
+	var dependents []models.Dependent
+	err := r.db.Where("guardian_id = ?", guardianID).Order("created_at ASC").Find(&dependents).Error
+	return dependents, err
+}
+
+// Update 家族アカウントの更新
+func (r *dependentRepository) Update(dependent *models.Dependent) error {
+	return r.db.Save(dependent).Error
+}
+
+// Delete 家族アカウントの削除
+func (r *dependentRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Dependent{}, id).Error
+}
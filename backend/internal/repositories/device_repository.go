@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type DeviceRepository interface {
+	Save(device *models.DeviceToken) error
+	FindByUserID(userID uint) ([]models.DeviceToken, error)
+	DeleteByUser(id, userID uint) (bool, error)
+	DeleteByToken(token string) error
+}
+
+type deviceRepository struct {
+	db *gorm.DB
+}
+
+func NewDeviceRepository(db *gorm.DB) DeviceRepository {
+	return &deviceRepository{
+		db: db,
+	}
+}
+
+// Save 端末の登録（同じトークンが登録済みの場合は利用者・プラットフォームを更新する）
+func (r *deviceRepository) Save(device *models.DeviceToken) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "updated_at"}),
+	}).Create(device).Error
+}
+
+// FindByUserID 利用者の登録済み端末を取得
+func (r *deviceRepository) FindByUserID(userID uint) ([]models.DeviceToken, error) {
+	var devices []models.DeviceToken
+	err := r.db.Where("user_id = ?", userID).Order("updated_at DESC").Find(&devices).Error
+	return devices, err
+}
+
+// DeleteByUser 利用者本人の端末の登録解除（該当がない場合はfalse）
+func (r *deviceRepository) DeleteByUser(id, userID uint) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.DeviceToken{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteByToken 無効になったトークンの削除
+func (r *deviceRepository) DeleteByToken(token string) error {
+	return r.db.Where("token = ?", token).Delete(&models.DeviceToken{}).Error
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type EscalationRepository interface {
+	Create(escalation *models.Escalation) error
+	FindByID(id uint) (*models.Escalation, error)
+	FindByAppointmentID(appointmentID uint) ([]models.Escalation, error)
+	FindActiveByAppointmentID(appointmentID uint) (*models.Escalation, error)
+	FindOpenCreatedBefore(before time.Time) ([]models.Escalation, error)
+	Update(escalation *models.Escalation) error
+}
+
+type escalationRepository struct {
+	db *gorm.DB
+}
+
+func NewEscalationRepository(db *gorm.DB) EscalationRepository {
+	return &escalationRepository{
+		db: db,
+	}
+}
+
+// Create エスカレーションの作成
+func (r *escalationRepository) Create(escalation *models.Escalation) error {
+	return r.db.Create(escalation).Error
+}
+
+// FindByID IDでエスカレーションを取得
+func (r *escalationRepository) FindByID(id uint) (*models.Escalation, error) {
+	var escalation models.Escalation
+	if err := r.db.First(&escalation, id).Error; err != nil {
+		return nil, err
+	}
+	return &escalation, nil
+}
+
+// FindByAppointmentID 予約IDでエスカレーション一覧を取得
+func (r *escalationRepository) FindByAppointmentID(appointmentID uint) ([]models.Escalation, error) {
+	var escalations []models.Escalation
+	err := r.db.Where("appointment_id = ?", appointmentID).Order("created_at DESC").Find(&escalations).Error
+	return escalations, err
+}
+
+// FindActiveByAppointmentID 予約の未解決エスカレーションを取得（存在しない場合はnil）
+func (r *escalationRepository) FindActiveByAppointmentID(appointmentID uint) (*models.Escalation, error) {
+	var escalations []models.Escalation
+	err := r.db.Where("appointment_id = ? AND status <> ?", appointmentID, "resolved").
+		Order("created_at DESC").Limit(1).Find(&escalations).Error
+	if err != nil || len(escalations) == 0 {
+		return nil, err
+	}
+	return &escalations[0], nil
+}
+
+// FindOpenCreatedBefore 指定時刻より前に作成され、未確認のままのエスカレーションを取得
+func (r *escalationRepository) FindOpenCreatedBefore(before time.Time) ([]models.Escalation, error) {
+	var escalations []models.Escalation
+	err := r.db.Preload("Appointment").
+		Where("status = ? AND created_at < ?", "open", before).
+		Order("created_at ASC").
+		Find(&escalations).Error
+	return escalations, err
+}
+
+// Update エスカレーションの更新
+func (r *escalationRepository) Update(escalation *models.Escalation) error {
+	return r.db.Omit("Appointment").Save(escalation).Error
+}
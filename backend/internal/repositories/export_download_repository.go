@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type ExportDownloadRepository interface {
+	Create(download *models.ExportDownload) error
+	FindByTokenHash(tokenHash string) (*models.ExportDownload, error)
+	MarkCompleted(id uint, sizeBytes int64, completedAt time.Time) error
+	Delete(id uint) error
+	FindExpired(now time.Time, limit int) ([]models.ExportDownload, error)
+}
+
+type exportDownloadRepository struct {
+	db *gorm.DB
+}
+
+func NewExportDownloadRepository(db *gorm.DB) ExportDownloadRepository {
+	return &exportDownloadRepository{
+		db: db,
+	}
+}
+
+func (r *exportDownloadRepository) Create(download *models.ExportDownload) error {
+	return r.db.Create(download).Error
+}
+
+// FindByTokenHash トークンのハッシュによる取得（存在しない場合はnil）
+func (r *exportDownloadRepository) FindByTokenHash(tokenHash string) (*models.ExportDownload, error) {
+	var download models.ExportDownload
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&download).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &download, nil
+}
+
+// MarkCompleted 生成の完了の記録
+func (r *exportDownloadRepository) MarkCompleted(id uint, sizeBytes int64, completedAt time.Time) error {
+	return r.db.Model(&models.ExportDownload{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"size_bytes":   sizeBytes,
+		"completed_at": completedAt,
+	}).Error
+}
+
+func (r *exportDownloadRepository) Delete(id uint) error {
+	return r.db.Delete(&models.ExportDownload{}, id).Error
+}
+
+// FindExpired 有効期限を過ぎたダウンロード（古い順）
+func (r *exportDownloadRepository) FindExpired(now time.Time, limit int) ([]models.ExportDownload, error) {
+	var downloads []models.ExportDownload
+	err := r.db.Where("expires_at <= ?", now).Order("expires_at ASC, id ASC").Limit(limit).Find(&downloads).Error
+	return downloads, err
+}
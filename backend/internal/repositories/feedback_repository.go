@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type FeedbackRepository interface {
+	Create(feedback *models.AppointmentFeedback) error
+	FindByAppointmentID(appointmentID uint) (*models.AppointmentFeedback, error)
+}
+
+type feedbackRepository struct {
+	db *gorm.DB
+}
+
+func NewFeedbackRepository(db *gorm.DB) FeedbackRepository {
+	return &feedbackRepository{
+		db: db,
+	}
+}
+
+// Create 評価の保存
+func (r *feedbackRepository) Create(feedback *models.AppointmentFeedback) error {
+	return r.db.Create(feedback).Error
+}
+
+// FindByAppointmentID 予約の評価を取得（未評価の場合はnil）
+func (r *feedbackRepository) FindByAppointmentID(appointmentID uint) (*models.AppointmentFeedback, error) {
+	var feedbacks []models.AppointmentFeedback
+	if err := r.db.Where("appointment_id = ?", appointmentID).Limit(1).Find(&feedbacks).Error; err != nil {
+		return nil, err
+	}
+	if len(feedbacks) == 0 {
+		return nil, nil
+	}
+	return &feedbacks[0], nil
+}
@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+// HL7連携の送信対象（送信済みの位置の列名の接頭辞）
+const (
+	HL7EntityPatients      = "patients"
+	HL7EntityAppointments  = "appointments"
+	HL7EntityPrescriptions = "prescriptions"
+)
+
+// hl7PrescriptionChangedAt 処方の変更日時（取り消し（論理削除）では updated_at が更新されないため deleted_at も含める）
+const hl7PrescriptionChangedAt = "GREATEST(prescriptions.updated_at, COALESCE(prescriptions.deleted_at, prescriptions.updated_at))"
+
+type HL7Repository interface {
+	CreateDestination(destination *models.HL7Destination) error
+	UpdateDestination(destination *models.HL7Destination, resetCursors bool) error
+	FindDestinationByID(id uint) (*models.HL7Destination, error)
+	FindDestinations() ([]models.HL7Destination, error)
+	FindEnabledDestinations() ([]models.HL7Destination, error)
+	DeleteDestination(id uint) (bool, error)
+	AdvanceCursor(destinationID uint, entity string, at time.Time, entityID uint) error
+	RecordSent(message *models.HL7Message, entity string, at time.Time) error
+	RecordError(destinationID uint, message string) error
+	FindLastMessage(destinationID uint, entity string, entityID uint) (*models.HL7Message, error)
+	FindMessages(destinationID uint, limit, offset int) ([]models.HL7Message, int64, error)
+	FindChangedPatients(after time.Time, afterID uint, before time.Time, limit int) ([]models.PatientProfile, error)
+	FindChangedAppointments(after time.Time, afterID uint, before time.Time, limit int) ([]models.Appointment, error)
+	FindChangedPrescriptions(after time.Time, afterID uint, before time.Time, limit int) ([]models.Prescription, error)
+}
+
+type hl7Repository struct {
+	db *gorm.DB
+}
+
+func NewHL7Repository(db *gorm.DB) HL7Repository {
+	return &hl7Repository{
+		db: db,
+	}
+}
+
+func (r *hl7Repository) CreateDestination(destination *models.HL7Destination) error {
+	return r.db.Create(destination).Error
+}
+
+// UpdateDestination 連携先の設定の更新（送信済みの位置は送信ジョブが更新するため、resetCursorsの場合のみ変更する）
+func (r *hl7Repository) UpdateDestination(destination *models.HL7Destination, resetCursors bool) error {
+	columns := []string{
+		"name", "transport", "host", "port", "use_tls", "directory",
+		"sending_application", "sending_facility", "receiving_application", "receiving_facility",
+		"processing_id", "message_types", "enabled",
+	}
+	if resetCursors {
+		columns = append(columns,
+			"patients_synced_at", "patients_synced_id",
+			"appointments_synced_at", "appointments_synced_id",
+			"prescriptions_synced_at", "prescriptions_synced_id")
+	}
+	return r.db.Model(destination).Select(columns).Updates(destination).Error
+}
+
+func (r *hl7Repository) FindDestinationByID(id uint) (*models.HL7Destination, error) {
+	var destination models.HL7Destination
+	if err := r.db.First(&destination, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &destination, nil
+}
+
+func (r *hl7Repository) FindDestinations() ([]models.HL7Destination, error) {
+	var destinations []models.HL7Destination
+	err := r.db.Order("name, id").Find(&destinations).Error
+	return destinations, err
+}
+
+func (r *hl7Repository) FindEnabledDestinations() ([]models.HL7Destination, error) {
+	var destinations []models.HL7Destination
+	err := r.db.Where("enabled = ?", true).Order("id").Find(&destinations).Error
+	return destinations, err
+}
+
+func (r *hl7Repository) DeleteDestination(id uint) (bool, error) {
+	result := r.db.Delete(&models.HL7Destination{}, id)
+	return result.RowsAffected > 0, result.Error
+}
+
+// AdvanceCursor 送信済みの位置の更新（送信不要と判定したものを含む）
+func (r *hl7Repository) AdvanceCursor(destinationID uint, entity string, at time.Time, entityID uint) error {
+	return r.db.Model(&models.HL7Destination{}).
+		Where("id = ?", destinationID).
+		UpdateColumns(map[string]interface{}{
+			entity + "_synced_at": at,
+			entity + "_synced_id": entityID,
+		}).Error
+}
+
+// RecordSent 送信したメッセージの記録と送信済みの位置の更新（送信に成功したため、直前のエラーは消去する）
+func (r *hl7Repository) RecordSent(message *models.HL7Message, entity string, at time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.HL7Destination{}).
+			Where("id = ?", message.DestinationID).
+			UpdateColumns(map[string]interface{}{
+				entity + "_synced_at": at,
+				entity + "_synced_id": message.EntityID,
+				"last_sent_at":        message.CreatedAt,
+				"last_error":          "",
+				"last_error_at":       nil,
+			}).Error
+	})
+}
+
+func (r *hl7Repository) RecordError(destinationID uint, message string) error {
+	return r.db.Model(&models.HL7Destination{}).
+		Where("id = ?", destinationID).
+		UpdateColumns(map[string]interface{}{
+			"last_error":    message,
+			"last_error_at": time.Now(),
+		}).Error
+}
+
+// FindLastMessage 対象について最後に送信したメッセージ（未送信の場合はnil）
+func (r *hl7Repository) FindLastMessage(destinationID uint, entity string, entityID uint) (*models.HL7Message, error) {
+	var message models.HL7Message
+	err := r.db.Where("destination_id = ? AND entity = ? AND entity_id = ?", destinationID, entity, entityID).
+		Order("id DESC").
+		First(&message).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &message, nil
+}
+
+// FindMessages 送信履歴を新しい順に取得（総件数とあわせて返す）
+func (r *hl7Repository) FindMessages(destinationID uint, limit, offset int) ([]models.HL7Message, int64, error) {
+	query := r.db.Model(&models.HL7Message{}).Where("destination_id = ?", destinationID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var messages []models.HL7Message
+	err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&messages).Error
+	return messages, total, err
+}
+
+// FindChangedPatients 送信済みの位置より後に作成・更新された患者（デモ用・匿名化済みのアカウントは除く）
+// beforeより後の更新は書き込み中のトランザクションを取りこぼさないよう次回に回す
+func (r *hl7Repository) FindChangedPatients(after time.Time, afterID uint, before time.Time, limit int) ([]models.PatientProfile, error) {
+	var profiles []models.PatientProfile
+	err := r.db.Joins("JOIN users ON users.id = patient_profiles.user_id AND users.deleted_at IS NULL").
+		Where("users.role = ? AND users.is_demo = ? AND users.anonymized_at IS NULL", "patient", false).
+		Where(hl7CursorCondition("patient_profiles.updated_at", "patient_profiles.user_id"), after, after, afterID).
+		Where("patient_profiles.updated_at < ?", before).
+		Order("patient_profiles.updated_at, patient_profiles.user_id").
+		Limit(limit).
+		Find(&profiles).Error
+	return profiles, err
+}
+
+// FindChangedAppointments 送信済みの位置より後に作成・更新された予約（デモ用のアカウントの予約は除く）
+func (r *hl7Repository) FindChangedAppointments(after time.Time, afterID uint, before time.Time, limit int) ([]models.Appointment, error) {
+	var appointments []models.Appointment
+	err := r.db.Joins("JOIN users ON users.id = appointments.patient_id").
+		Where("users.is_demo = ? AND users.anonymized_at IS NULL", false).
+		Where(hl7CursorCondition("appointments.updated_at", "appointments.id"), after, after, afterID).
+		Where("appointments.updated_at < ?", before).
+		Preload("Patient.PatientProfile").
+		Preload("Doctor.DoctorProfile").
+		Preload("Slot").
+		Preload("Dependent").
+		Order("appointments.updated_at, appointments.id").
+		Limit(limit).
+		Find(&appointments).Error
+	return appointments, err
+}
+
+// FindChangedPrescriptions 送信済みの位置より後に作成・更新・取り消しされた処方（取り消し済みのものを含む）
+func (r *hl7Repository) FindChangedPrescriptions(after time.Time, afterID uint, before time.Time, limit int) ([]models.Prescription, error) {
+	var prescriptions []models.Prescription
+	err := r.db.Unscoped().
+		Joins("JOIN appointments ON appointments.id = prescriptions.appointment_id").
+		Joins("JOIN users ON users.id = appointments.patient_id").
+		Where("users.is_demo = ? AND users.anonymized_at IS NULL", false).
+		Where(hl7CursorCondition(hl7PrescriptionChangedAt, "prescriptions.id"), after, after, afterID).
+		Where(hl7PrescriptionChangedAt+" < ?", before).
+		Preload("Appointment.Patient.PatientProfile").
+		Preload("Appointment.Doctor.DoctorProfile").
+		Preload("Appointment.Slot").
+		Preload("Appointment.Dependent").
+		Order(hl7PrescriptionChangedAt + ", prescriptions.id").
+		Limit(limit).
+		Find(&prescriptions).Error
+	return prescriptions, err
+}
+
+// hl7CursorCondition 送信済みの位置（変更日時・ID）より後の条件（引数は 日時, 日時, ID の順）
+func hl7CursorCondition(changedAt, id string) string {
+	return fmt.Sprintf("(%s > ? OR (%s = ? AND %s > ?))", changedAt, changedAt, id)
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type ICECandidateRepository interface {
+	CreateBatch(candidates []models.ICECandidate) error
+	CountBySender(sessionID, senderID uint) (int64, error)
+	FindQueued(sessionID, recipientID uint) ([]models.ICECandidate, error)
+	Acknowledge(sessionID, recipientID, throughID uint) (int64, error)
+	DeleteForParticipant(sessionID, userID uint) (int64, error)
+	DeleteBySession(sessionID uint) (int64, error)
+	DeleteStale(createdBefore time.Time) (int64, error)
+}
+
+type iceCandidateRepository struct {
+	db *gorm.DB
+}
+
+func NewICECandidateRepository(db *gorm.DB) ICECandidateRepository {
+	return &iceCandidateRepository{
+		db: db,
+	}
+}
+
+// CreateBatch 受信者ごとのICE候補の登録
+func (r *iceCandidateRepository) CreateBatch(candidates []models.ICECandidate) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+	return r.db.Create(&candidates).Error
+}
+
+// CountBySender セッションで送信者が登録した受信待ちのICE候補の件数
+func (r *iceCandidateRepository) CountBySender(sessionID, senderID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ICECandidate{}).
+		Where("video_session_id = ? AND sender_id = ?", sessionID, senderID).
+		Count(&count).Error
+	return count, err
+}
+
+// FindQueued 受信者の受信待ちのICE候補を登録順に取得
+func (r *iceCandidateRepository) FindQueued(sessionID, recipientID uint) ([]models.ICECandidate, error) {
+	var candidates []models.ICECandidate
+	err := r.db.Where("video_session_id = ? AND recipient_id = ?", sessionID, recipientID).
+		Order("id ASC").
+		Find(&candidates).Error
+	return candidates, err
+}
+
+// Acknowledge 受信者が取得を確認したICE候補（throughID以前）の削除
+func (r *iceCandidateRepository) Acknowledge(sessionID, recipientID, throughID uint) (int64, error) {
+	result := r.db.Where("video_session_id = ? AND recipient_id = ? AND id <= ?", sessionID, recipientID, throughID).
+		Delete(&models.ICECandidate{})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteForParticipant 参加者が送信した・参加者宛てのICE候補の削除（切断した参加者の候補は再接続で使えない）
+func (r *iceCandidateRepository) DeleteForParticipant(sessionID, userID uint) (int64, error) {
+	result := r.db.Where("video_session_id = ? AND (sender_id = ? OR recipient_id = ?)", sessionID, userID, userID).
+		Delete(&models.ICECandidate{})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteBySession セッションのICE候補の削除
+func (r *iceCandidateRepository) DeleteBySession(sessionID uint) (int64, error) {
+	result := r.db.Where("video_session_id = ?", sessionID).Delete(&models.ICECandidate{})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteStale 終了したセッションのICE候補と、指定時刻より前に登録されたICE候補の削除
+func (r *iceCandidateRepository) DeleteStale(createdBefore time.Time) (int64, error) {
+	result := r.db.Where("created_at < ? OR video_session_id IN (?)", createdBefore,
+		r.db.Model(&models.VideoSession{}).Select("id").Where("ended_at IS NOT NULL")).
+		Delete(&models.ICECandidate{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type InterpreterRepository interface {
+	FindProfiles(language string) ([]models.InterpreterProfile, error)
+	CreateSlot(slot *models.InterpreterSlot) error
+	FindSlotByID(id uint) (*models.InterpreterSlot, error)
+	FindSlotsByInterpreterID(interpreterID uint) ([]models.InterpreterSlot, error)
+	DeleteSlot(id uint) error
+	FindAvailableSlots(language string, startTime, endTime time.Time) ([]models.InterpreterSlot, error)
+	AssignSlot(slotID, appointmentID uint) error
+	ReleaseByAppointmentID(appointmentID uint) error
+}
+
+type interpreterRepository struct {
+	db *gorm.DB
+}
+
+func NewInterpreterRepository(db *gorm.DB) InterpreterRepository {
+	return &interpreterRepository{
+		db: db,
+	}
+}
+
+// languageCondition 対応言語（カンマ区切り）に指定言語が含まれる条件
+const languageCondition = "',' || interpreter_profiles.languages || ',' LIKE ?"
+
+// FindProfiles 通訳者一覧を取得（言語指定時はその言語に対応する通訳者のみ）
+func (r *interpreterRepository) FindProfiles(language string) ([]models.InterpreterProfile, error) {
+	query := r.db.Preload("User")
+	if language != "" {
+		query = query.Where(languageCondition, "%,"+language+",%")
+	}
+
+	var profiles []models.InterpreterProfile
+	err := query.Order("user_id ASC").Find(&profiles).Error
+	return profiles, err
+}
+
+// CreateSlot 対応可能枠の作成
+func (r *interpreterRepository) CreateSlot(slot *models.InterpreterSlot) error {
+	return r.db.Create(slot).Error
+}
+
+// FindSlotByID IDで対応可能枠を取得
+func (r *interpreterRepository) FindSlotByID(id uint) (*models.InterpreterSlot, error) {
+	var slot models.InterpreterSlot
+	if err := r.db.First(&slot, id).Error; err != nil {
+		return nil, err
+	}
+	return &slot, nil
+}
+
+// FindSlotsByInterpreterID 通訳者の対応可能枠一覧を取得
+func (r *interpreterRepository) FindSlotsByInterpreterID(interpreterID uint) ([]models.InterpreterSlot, error) {
+	var slots []models.InterpreterSlot
+	err := r.db.Where("interpreter_id = ?", interpreterID).Order("start_time ASC").Find(&slots).Error
+	return slots, err
+}
+
+// DeleteSlot 対応可能枠の削除
+func (r *interpreterRepository) DeleteSlot(id uint) error {
+	return r.db.Delete(&models.InterpreterSlot{}, id).Error
+}
+
+// FindAvailableSlots 指定言語に対応し、指定時間帯を含む未割り当ての枠を取得（開始が早い順）
+func (r *interpreterRepository) FindAvailableSlots(language string, startTime, endTime time.Time) ([]models.InterpreterSlot, error) {
+	var slots []models.InterpreterSlot
+	err := r.db.
+		Joins("JOIN interpreter_profiles ON interpreter_profiles.user_id = interpreter_slots.interpreter_id AND interpreter_profiles.deleted_at IS NULL").
+		Where("interpreter_slots.appointment_id IS NULL").
+		Where("interpreter_slots.start_time <= ? AND interpreter_slots.end_time >= ?", startTime, endTime).
+		Where(languageCondition, "%,"+language+",%").
+		Order("interpreter_slots.start_time ASC").
+		Find(&slots).Error
+	return slots, err
+}
+
+// AssignSlot 枠を予約に割り当てる（未割り当ての場合のみ）
+func (r *interpreterRepository) AssignSlot(slotID, appointmentID uint) error {
+	result := r.db.Model(&models.InterpreterSlot{}).
+		Where("id = ? AND appointment_id IS NULL", slotID).
+		Update("appointment_id", appointmentID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ReleaseByAppointmentID 予約に割り当てた枠を解放する
+func (r *interpreterRepository) ReleaseByAppointmentID(appointmentID uint) error {
+	return r.db.Model(&models.InterpreterSlot{}).
+		Where("appointment_id = ?", appointmentID).
+		Update("appointment_id", nil).Error
+}
@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type InvoiceRepository interface {
+	CreateIfAbsent(invoice *models.Invoice) (bool, error)
+	FindByID(id uint) (*models.Invoice, error)
+	FindByPatient(patientID uint, status string, limit, offset int) ([]models.Invoice, int64, error)
+	FindByDoctors(doctorIDs []uint, status string, limit, offset int) ([]models.Invoice, int64, error)
+	MarkPaid(id uint, paidAt time.Time) (bool, error)
+	CreatePayment(payment *models.Payment) error
+	FindPaymentByID(id uint) (*models.Payment, error)
+	FindPaymentByIntentID(intentID string) (*models.Payment, error)
+	FindOpenPayment(invoiceID uint) (*models.Payment, error)
+	UpdatePaymentStatus(id uint, status, failureMessage string) (bool, error)
+	HoldPayment(id uint, reason string) (bool, error)
+	ResolveHeldPayment(id uint, status, failureMessage string) (bool, error)
+}
+
+type invoiceRepository struct {
+	db *gorm.DB
+}
+
+func NewInvoiceRepository(db *gorm.DB) InvoiceRepository {
+	return &invoiceRepository{
+		db: db,
+	}
+}
+
+// CreateIfAbsent 予約の請求の作成（既に請求がある場合は作成せずfalseを返す）
+func (r *invoiceRepository) CreateIfAbsent(invoice *models.Invoice) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "appointment_id"}},
+		DoNothing: true,
+	}).Create(invoice)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindByID IDで請求を支払いの履歴・予約とあわせて取得（存在しない場合はnil）
+func (r *invoiceRepository) FindByID(id uint) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := r.db.Preload("Appointment.Slot").
+		Preload("Payments", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		First(&invoice, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// FindByPatient 患者の請求（新しい順、statusを指定した場合はその状態のみ）と総件数
+func (r *invoiceRepository) FindByPatient(patientID uint, status string, limit, offset int) ([]models.Invoice, int64, error) {
+	query := r.db.Model(&models.Invoice{}).Where("patient_id = ?", patientID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var invoices []models.Invoice
+	err := query.Preload("Appointment.Slot").
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&invoices).Error
+	return invoices, total, err
+}
+
+// FindByDoctors 複数の医師の予約の請求の一覧（組織のスタッフ用、statusが空の場合はすべて）
+func (r *invoiceRepository) FindByDoctors(doctorIDs []uint, status string, limit, offset int) ([]models.Invoice, int64, error) {
+	if len(doctorIDs) == 0 {
+		return []models.Invoice{}, 0, nil
+	}
+	query := r.db.Model(&models.Invoice{}).Where("doctor_id IN ?", doctorIDs)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var invoices []models.Invoice
+	err := query.Preload("Payments").
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&invoices).Error
+	return invoices, total, err
+}
+
+// MarkPaid 未払いの請求を支払い済みにする（支払い済み・無効の場合はfalse）
+func (r *invoiceRepository) MarkPaid(id uint, paidAt time.Time) (bool, error) {
+	result := r.db.Model(&models.Invoice{}).
+		Where("id = ? AND status = ?", id, "open").
+		Updates(map[string]interface{}{
+			"status":  "paid",
+			"paid_at": paidAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CreatePayment 支払いの作成
+func (r *invoiceRepository) CreatePayment(payment *models.Payment) error {
+	return r.db.Create(payment).Error
+}
+
+// FindPaymentByID IDで支払いを取得（存在しない場合はnil）
+func (r *invoiceRepository) FindPaymentByID(id uint) (*models.Payment, error) {
+	var payment models.Payment
+	if err := r.db.First(&payment, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// FindPaymentByIntentID 決済代行サービスの PaymentIntent のIDで支払いを取得（存在しない場合はnil）
+func (r *invoiceRepository) FindPaymentByIntentID(intentID string) (*models.Payment, error) {
+	var payment models.Payment
+	if err := r.db.Where("provider_intent_id = ?", intentID).First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// FindOpenPayment 請求の支払い待ちの最新の支払い（ない場合はnil）
+func (r *invoiceRepository) FindOpenPayment(invoiceID uint) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.Where("invoice_id = ? AND status = ?", invoiceID, "requires_payment").
+		Order("created_at DESC, id DESC").
+		First(&payment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// UpdatePaymentStatus 支払いの状態の更新
+// Webhookは順不同・重複して届くため、完了した支払い・確認待ちの支払いと同じ状態への更新は行わずfalseを返す
+func (r *invoiceRepository) UpdatePaymentStatus(id uint, status, failureMessage string) (bool, error) {
+	result := r.db.Model(&models.Payment{}).
+		Where("id = ? AND status NOT IN ?", id, []string{"succeeded", "requires_review", status}).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"failure_message": failureMessage,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// HoldPayment 請求に反映しない支払いとして管理者の確認待ち（requires_review）にする（確認待ち・完了済みの場合はfalse）
+func (r *invoiceRepository) HoldPayment(id uint, reason string) (bool, error) {
+	result := r.db.Model(&models.Payment{}).
+		Where("id = ? AND status NOT IN ?", id, []string{"requires_review", "succeeded"}).
+		Updates(map[string]interface{}{
+			"status":        "requires_review",
+			"review_reason": reason,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ResolveHeldPayment 確認待ちの支払いの管理者の判断の反映（確認待ちでない場合はfalse）
+func (r *invoiceRepository) ResolveHeldPayment(id uint, status, failureMessage string) (bool, error) {
+	result := r.db.Model(&models.Payment{}).
+		Where("id = ? AND status = ?", id, "requires_review").
+		Updates(map[string]interface{}{
+			"status":          status,
+			"failure_message": failureMessage,
+		})
+	return result.RowsAffected > 0, result.Error
+}
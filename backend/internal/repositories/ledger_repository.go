@@ -0,0 +1,292 @@
+package repositories
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+// 仕訳の記帳の不変条件の違反（記帳は取り消され、残高は変わらない）
+var (
+	ErrUnbalancedEntry     = errors.New("journal entry is not balanced")
+	ErrInsufficientBalance = errors.New("insufficient ledger balance")
+	ErrCurrencyMismatch    = errors.New("ledger account currency does not match entry")
+)
+
+// LedgerTrialBalance 通貨ごとの借方・貸方の合計（試算表）
+type LedgerTrialBalance struct {
+	Currency string `json:"currency"`
+	Debits   int64  `json:"debits"`
+	Credits  int64  `json:"credits"`
+}
+
+// LedgerKindTotal 期間内の通貨・仕訳の種類ごとの件数と金額
+type LedgerKindTotal struct {
+	Currency string `json:"currency"`
+	Kind     string `json:"kind"`
+	Entries  int64  `json:"entries"`
+	Amount   int64  `json:"amount"`
+}
+
+type LedgerRepository interface {
+	FindOrCreateAccount(account *models.LedgerAccount) (*models.LedgerAccount, error)
+	FindAccounts(accountType string, ownerID *uint) ([]models.LedgerAccount, error)
+	Post(entry *models.JournalEntry) (bool, error)
+	FindEntryByReference(reference string) (*models.JournalEntry, error)
+	FindEntries(kind string, accountID *uint, limit, offset int) ([]models.JournalEntry, int64, error)
+	SumLinesByInvoice(invoiceID uint, kind string, accountID uint) (int64, error)
+	TrialBalance() ([]LedgerTrialBalance, error)
+	SumEntriesByKind(from, to time.Time) ([]LedgerKindTotal, error)
+	FindUnbalancedEntryIDs() ([]uint, error)
+	FindMismatchedAccountIDs() ([]uint, error)
+}
+
+type ledgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) LedgerRepository {
+	return &ledgerRepository{
+		db: db,
+	}
+}
+
+// FindOrCreateAccount 勘定の取得（コード・通貨の勘定がない場合は作成する）
+// 負にできない勘定として指定した場合は、既存の勘定も負にできない勘定にする（返金・医師への支払いの上限は記帳時のこの確認による）
+func (r *ledgerRepository) FindOrCreateAccount(account *models.LedgerAccount) (*models.LedgerAccount, error) {
+	if err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}, {Name: "currency"}},
+		DoNothing: true,
+	}).Create(account).Error; err != nil {
+		return nil, err
+	}
+
+	var existing models.LedgerAccount
+	if err := r.db.Where("code = ? AND currency = ?", account.Code, account.Currency).First(&existing).Error; err != nil {
+		return nil, err
+	}
+	if account.NonNegative && !existing.NonNegative {
+		if err := r.db.Model(&existing).Update("non_negative", true).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &existing, nil
+}
+
+// FindAccounts 勘定の一覧（種類・所有者を指定した場合はその勘定のみ）
+func (r *ledgerRepository) FindAccounts(accountType string, ownerID *uint) ([]models.LedgerAccount, error) {
+	query := r.db.Model(&models.LedgerAccount{})
+	if accountType != "" {
+		query = query.Where("type = ?", accountType)
+	}
+	if ownerID != nil {
+		query = query.Where("owner_id = ?", *ownerID)
+	}
+
+	var accounts []models.LedgerAccount
+	err := query.Order("code ASC, currency ASC").Find(&accounts).Error
+	return accounts, err
+}
+
+// Post 仕訳の記帳（同じ参照の仕訳が記帳済みの場合は記帳せずfalseを返す）
+// 1つのトランザクションで勘定を行ロックし、次の不変条件を確認したうえで明細と勘定の残高を更新する
+//   - 明細が2件以上あり、各明細は借方・貸方のどちらか一方のみ正の金額
+//   - 借方の合計と貸方の合計が一致する
+//   - 全ての勘定が仕訳と同じ通貨
+//   - 負にできない勘定の残高が負にならない
+func (r *ledgerRepository) Post(entry *models.JournalEntry) (bool, error) {
+	posted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&models.JournalEntry{}).Where("reference = ?", entry.Reference).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return nil
+		}
+
+		deltas, err := entryDeltas(entry.Lines)
+		if err != nil {
+			return err
+		}
+
+		// デッドロックを避けるため勘定はID順にロックする
+		ids := make([]uint, 0, len(deltas))
+		for id := range deltas {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		var accounts []models.LedgerAccount
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", ids).
+			Order("id ASC").
+			Find(&accounts).Error; err != nil {
+			return err
+		}
+		if len(accounts) != len(ids) {
+			return errors.New("ledger account not found")
+		}
+
+		for _, account := range accounts {
+			balance, err := postedBalance(account, entry.Currency, deltas[account.ID])
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&models.LedgerAccount{}).
+				Where("id = ?", account.ID).
+				Update("balance", balance).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		posted = true
+		return nil
+	})
+	return posted, err
+}
+
+// FindEntryByReference 参照で仕訳を取得（ない場合はnil）
+func (r *ledgerRepository) FindEntryByReference(reference string) (*models.JournalEntry, error) {
+	var entry models.JournalEntry
+	err := r.db.Preload("Lines").Where("reference = ?", reference).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// FindEntries 仕訳の一覧（新しい順、種類・勘定を指定した場合はその仕訳のみ）と総件数
+func (r *ledgerRepository) FindEntries(kind string, accountID *uint, limit, offset int) ([]models.JournalEntry, int64, error) {
+	query := r.db.Model(&models.JournalEntry{})
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if accountID != nil {
+		query = query.Where("id IN (?)", r.db.Model(&models.JournalLine{}).Select("journal_entry_id").Where("account_id = ?", *accountID))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.JournalEntry
+	err := query.Preload("Lines.Account").
+		Order("posted_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&entries).Error
+	return entries, total, err
+}
+
+// SumLinesByInvoice 請求の指定した種類の仕訳の、勘定への借方の合計（返金済みの金額の確認用）
+func (r *ledgerRepository) SumLinesByInvoice(invoiceID uint, kind string, accountID uint) (int64, error) {
+	var total int64
+	err := r.db.Model(&models.JournalLine{}).
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.journal_entry_id").
+		Where("journal_entries.invoice_id = ? AND journal_entries.kind = ? AND journal_lines.account_id = ?", invoiceID, kind, accountID).
+		Select("COALESCE(SUM(journal_lines.debit), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// TrialBalance 通貨ごとの全明細の借方・貸方の合計
+func (r *ledgerRepository) TrialBalance() ([]LedgerTrialBalance, error) {
+	var rows []LedgerTrialBalance
+	err := r.db.Model(&models.JournalLine{}).
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.journal_entry_id").
+		Select("journal_entries.currency AS currency, COALESCE(SUM(journal_lines.debit), 0) AS debits, COALESCE(SUM(journal_lines.credit), 0) AS credits").
+		Group("journal_entries.currency").
+		Order("journal_entries.currency ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// SumEntriesByKind 期間内（fromを含みtoを含まない）に記帳した仕訳の通貨・種類ごとの件数と金額（金額は借方の合計）
+func (r *ledgerRepository) SumEntriesByKind(from, to time.Time) ([]LedgerKindTotal, error) {
+	var rows []LedgerKindTotal
+	err := r.db.Model(&models.JournalLine{}).
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.journal_entry_id").
+		Where("journal_entries.posted_at >= ? AND journal_entries.posted_at < ?", from, to).
+		Select("journal_entries.currency AS currency, journal_entries.kind AS kind, COUNT(DISTINCT journal_entries.id) AS entries, COALESCE(SUM(journal_lines.debit), 0) AS amount").
+		Group("journal_entries.currency, journal_entries.kind").
+		Order("journal_entries.currency ASC, journal_entries.kind ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// FindUnbalancedEntryIDs 借方・貸方の合計が一致しない仕訳（記帳時の確認により通常は存在しない）
+func (r *ledgerRepository) FindUnbalancedEntryIDs() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.JournalLine{}).
+		Select("journal_entry_id").
+		Group("journal_entry_id").
+		Having("SUM(debit) <> SUM(credit) OR COUNT(*) < 2").
+		Order("journal_entry_id ASC").
+		Pluck("journal_entry_id", &ids).Error
+	return ids, err
+}
+
+// FindMismatchedAccountIDs 記録した残高が明細の合計と一致しない勘定
+func (r *ledgerRepository) FindMismatchedAccountIDs() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.LedgerAccount{}).
+		Joins("LEFT JOIN (SELECT account_id, SUM(debit) - SUM(credit) AS total FROM journal_lines GROUP BY account_id) sums ON sums.account_id = ledger_accounts.id").
+		Where("ledger_accounts.balance <> COALESCE(sums.total, 0)").
+		Order("ledger_accounts.id ASC").
+		Pluck("ledger_accounts.id", &ids).Error
+	return ids, err
+}
+
+// entryDeltas 仕訳の明細の確認と勘定ごとの残高の増減（借方を正とする）
+// 明細が2件未満、借方・貸方の両方または一方も正でない明細、借方と貸方の合計の不一致は ErrUnbalancedEntry
+func entryDeltas(lines []models.JournalLine) (map[uint]int64, error) {
+	if len(lines) < 2 {
+		return nil, ErrUnbalancedEntry
+	}
+	var debits, credits int64
+	deltas := make(map[uint]int64)
+	for _, line := range lines {
+		if (line.Debit > 0) == (line.Credit > 0) || line.Debit < 0 || line.Credit < 0 {
+			return nil, ErrUnbalancedEntry
+		}
+		debits += line.Debit
+		credits += line.Credit
+		deltas[line.AccountID] += line.Debit - line.Credit
+	}
+	if debits != credits {
+		return nil, ErrUnbalancedEntry
+	}
+	return deltas, nil
+}
+
+// postedBalance 記帳後の勘定の残高
+// 勘定が仕訳と異なる通貨の場合は ErrCurrencyMismatch、負にできない勘定の残高が負になる場合は ErrInsufficientBalance
+func postedBalance(account models.LedgerAccount, currency string, delta int64) (int64, error) {
+	if account.Currency != currency {
+		return 0, ErrCurrencyMismatch
+	}
+	balance := account.Balance + delta
+	if account.NonNegative && normalBalance(account.Type, balance) < 0 {
+		return 0, ErrInsufficientBalance
+	}
+	return balance, nil
+}
+
+// normalBalance 勘定の通常の向き（資産・費用は借方、それ以外は貸方）を正とした残高
+func normalBalance(accountType string, balance int64) int64 {
+	if accountType == models.LedgerAsset || accountType == models.LedgerExpense {
+		return balance
+	}
+	return -balance
+}
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+func TestNormalBalance(t *testing.T) {
+	tests := []struct {
+		accountType string
+		balance     int64
+		want        int64
+	}{
+		{models.LedgerAsset, 100, 100},
+		{models.LedgerExpense, -50, -50},
+		{models.LedgerLiability, -100, 100},
+		{models.LedgerRevenue, 30, -30},
+		{models.LedgerEquity, -70, 70},
+	}
+	for _, tt := range tests {
+		if got := normalBalance(tt.accountType, tt.balance); got != tt.want {
+			t.Errorf("normalBalance(%q, %d) = %d, want %d", tt.accountType, tt.balance, got, tt.want)
+		}
+	}
+}
+
+func TestEntryDeltas(t *testing.T) {
+	deltas, err := entryDeltas([]models.JournalLine{
+		{AccountID: 1, Debit: 1000},
+		{AccountID: 2, Credit: 600},
+		{AccountID: 2, Credit: 400},
+	})
+	if err != nil {
+		t.Fatalf("entryDeltas() error = %v", err)
+	}
+	if deltas[1] != 1000 || deltas[2] != -1000 {
+		t.Errorf("entryDeltas() = %v, want map[1:1000 2:-1000]", deltas)
+	}
+}
+
+func TestEntryDeltasRejectsInvalidLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []models.JournalLine
+	}{
+		{"no lines", nil},
+		{"single line", []models.JournalLine{{AccountID: 1, Debit: 100}}},
+		{"unbalanced", []models.JournalLine{{AccountID: 1, Debit: 100}, {AccountID: 2, Credit: 90}}},
+		{"both sides", []models.JournalLine{{AccountID: 1, Debit: 100, Credit: 100}, {AccountID: 2, Debit: 0, Credit: 0}}},
+		{"zero line", []models.JournalLine{{AccountID: 1, Debit: 100}, {AccountID: 2, Credit: 100}, {AccountID: 3}}},
+		{"negative debit", []models.JournalLine{{AccountID: 1, Debit: -100}, {AccountID: 2, Debit: 100}}},
+		{"negative credit", []models.JournalLine{{AccountID: 1, Credit: 100}, {AccountID: 2, Credit: -100}}},
+	}
+	for _, tt := range tests {
+		if _, err := entryDeltas(tt.lines); !errors.Is(err, ErrUnbalancedEntry) {
+			t.Errorf("%s: entryDeltas() error = %v, want %v", tt.name, err, ErrUnbalancedEntry)
+		}
+	}
+}
+
+func TestPostedBalance(t *testing.T) {
+	tests := []struct {
+		name    string
+		account models.LedgerAccount
+		delta   int64
+		want    int64
+		wantErr error
+	}{
+		{
+			name:    "asset debit",
+			account: models.LedgerAccount{Type: models.LedgerAsset, Currency: "jpy", Balance: 100, NonNegative: true},
+			delta:   50,
+			want:    150,
+		},
+		{
+			name:    "liability credit",
+			account: models.LedgerAccount{Type: models.LedgerLiability, Currency: "jpy", Balance: -100, NonNegative: true},
+			delta:   -50,
+			want:    -150,
+		},
+		{
+			name:    "liability down to zero",
+			account: models.LedgerAccount{Type: models.LedgerLiability, Currency: "jpy", Balance: -100, NonNegative: true},
+			delta:   100,
+			want:    0,
+		},
+		{
+			name:    "liability overdrawn",
+			account: models.LedgerAccount{Type: models.LedgerLiability, Currency: "jpy", Balance: -100, NonNegative: true},
+			delta:   101,
+			wantErr: ErrInsufficientBalance,
+		},
+		{
+			name:    "asset overdrawn",
+			account: models.LedgerAccount{Type: models.LedgerAsset, Currency: "jpy", Balance: 100, NonNegative: true},
+			delta:   -101,
+			wantErr: ErrInsufficientBalance,
+		},
+		{
+			name:    "expense may go negative",
+			account: models.LedgerAccount{Type: models.LedgerExpense, Currency: "jpy", Balance: 0},
+			delta:   -100,
+			want:    -100,
+		},
+		{
+			name:    "currency mismatch",
+			account: models.LedgerAccount{Type: models.LedgerAsset, Currency: "usd", Balance: 100, NonNegative: true},
+			delta:   50,
+			wantErr: ErrCurrencyMismatch,
+		},
+	}
+	for _, tt := range tests {
+		got, err := postedBalance(tt.account, "jpy", tt.delta)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: postedBalance() error = %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("%s: postedBalance() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
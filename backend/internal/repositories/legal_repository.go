@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type LegalRepository interface {
+	CreateDocument(document *models.LegalDocument) error
+	FindDocumentByID(id uint) (*models.LegalDocument, error)
+	FindDocuments(docType string) ([]models.LegalDocument, error)
+	FindCurrentDocuments() ([]models.LegalDocument, error)
+	PublishDocument(id uint, publishedAt time.Time) error
+	CreateAcceptance(acceptance *models.LegalAcceptance) error
+	FindAcceptedDocumentIDs(userID uint, documentIDs []uint) ([]uint, error)
+	FindAcceptancesByUserID(userID uint) ([]models.LegalAcceptance, error)
+}
+
+type legalRepository struct {
+	db *gorm.DB
+}
+
+func NewLegalRepository(db *gorm.DB) LegalRepository {
+	return &legalRepository{
+		db: db,
+	}
+}
+
+// CreateDocument 法的文書の作成
+func (r *legalRepository) CreateDocument(document *models.LegalDocument) error {
+	return r.db.Create(document).Error
+}
+
+// FindDocumentByID IDで法的文書を取得
+func (r *legalRepository) FindDocumentByID(id uint) (*models.LegalDocument, error) {
+	var document models.LegalDocument
+	if err := r.db.First(&document, id).Error; err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// FindDocuments 法的文書の一覧を取得（種別指定時はその種別のみ）
+func (r *legalRepository) FindDocuments(docType string) ([]models.LegalDocument, error) {
+	query := r.db.Model(&models.LegalDocument{})
+	if docType != "" {
+		query = query.Where("doc_type = ?", docType)
+	}
+
+	var documents []models.LegalDocument
+	err := query.Order("created_at DESC").Find(&documents).Error
+	return documents, err
+}
+
+// FindCurrentDocuments 種別ごとに最新の公開済み文書を取得
+func (r *legalRepository) FindCurrentDocuments() ([]models.LegalDocument, error) {
+	var documents []models.LegalDocument
+	err := r.db.Raw(`
+		SELECT DISTINCT ON (doc_type) *
+		FROM legal_documents
+		WHERE published_at IS NOT NULL AND published_at <= ?
+		ORDER BY doc_type, published_at DESC, id DESC
+	`, time.Now()).Scan(&documents).Error
+	return documents, err
+}
+
+// PublishDocument 法的文書の公開
+func (r *legalRepository) PublishDocument(id uint, publishedAt time.Time) error {
+	return r.db.Model(&models.LegalDocument{}).Where("id = ?", id).Update("published_at", publishedAt).Error
+}
+
+// CreateAcceptance 同意の記録（同一文書への重複同意は無視）
+func (r *legalRepository) CreateAcceptance(acceptance *models.LegalAcceptance) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Omit("Document").Create(acceptance).Error
+}
+
+// FindAcceptedDocumentIDs 指定した文書のうちユーザーが同意済みの文書IDを取得
+func (r *legalRepository) FindAcceptedDocumentIDs(userID uint, documentIDs []uint) ([]uint, error) {
+	var ids []uint
+	if len(documentIDs) == 0 {
+		return ids, nil
+	}
+	err := r.db.Model(&models.LegalAcceptance{}).
+		Where("user_id = ? AND document_id IN ?", userID, documentIDs).
+		Pluck("document_id", &ids).Error
+	return ids, err
+}
+
+// FindAcceptancesByUserID ユーザーの同意履歴を取得
+func (r *legalRepository) FindAcceptancesByUserID(userID uint) ([]models.LegalAcceptance, error) {
+	var acceptances []models.LegalAcceptance
+	err := r.db.Preload("Document").Where("user_id = ?", userID).Order("accepted_at DESC").Find(&acceptances).Error
+	return acceptances, err
+}
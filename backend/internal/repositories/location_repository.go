@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type LocationRepository interface {
+	Create(location *models.ClinicLocation) error
+	FindByID(id uint) (*models.ClinicLocation, error)
+	FindAll(activeOnly bool, doctorID uint) ([]models.ClinicLocation, error)
+	Update(location *models.ClinicLocation) error
+	FindDoctorIDs(locationID uint) ([]uint, error)
+	ReplaceDoctors(locationID uint, doctorIDs []uint) error
+	IsDoctorAt(doctorID, locationID uint) (bool, error)
+}
+
+type locationRepository struct {
+	db *gorm.DB
+}
+
+func NewLocationRepository(db *gorm.DB) LocationRepository {
+	return &locationRepository{
+		db: db,
+	}
+}
+
+func (r *locationRepository) Create(location *models.ClinicLocation) error {
+	return r.db.Create(location).Error
+}
+
+// FindByID 所在地の取得（ない場合はnil）
+func (r *locationRepository) FindByID(id uint) (*models.ClinicLocation, error) {
+	var location models.ClinicLocation
+	err := r.db.First(&location, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
+
+// FindAll 所在地の一覧（doctorIDが0以外の場合はその医師が対面診療を行う所在地のみ）
+func (r *locationRepository) FindAll(activeOnly bool, doctorID uint) ([]models.ClinicLocation, error) {
+	query := r.db.Model(&models.ClinicLocation{})
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	if doctorID != 0 {
+		query = query.Where("id IN (?)", r.db.Model(&models.DoctorLocation{}).Select("location_id").Where("doctor_id = ?", doctorID))
+	}
+
+	var locations []models.ClinicLocation
+	err := query.Order("name ASC, id ASC").Find(&locations).Error
+	return locations, err
+}
+
+func (r *locationRepository) Update(location *models.ClinicLocation) error {
+	return r.db.Save(location).Error
+}
+
+// FindDoctorIDs 所在地で対面診療を行う医師のID
+func (r *locationRepository) FindDoctorIDs(locationID uint) ([]uint, error) {
+	var doctorIDs []uint
+	err := r.db.Model(&models.DoctorLocation{}).Where("location_id = ?", locationID).Order("doctor_id ASC").Pluck("doctor_id", &doctorIDs).Error
+	return doctorIDs, err
+}
+
+// ReplaceDoctors 所在地で対面診療を行う医師の置き換え
+func (r *locationRepository) ReplaceDoctors(locationID uint, doctorIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("location_id = ?", locationID).Delete(&models.DoctorLocation{}).Error; err != nil {
+			return err
+		}
+		if len(doctorIDs) == 0 {
+			return nil
+		}
+		assignments := make([]models.DoctorLocation, 0, len(doctorIDs))
+		for _, doctorID := range doctorIDs {
+			assignments = append(assignments, models.DoctorLocation{DoctorID: doctorID, LocationID: locationID})
+		}
+		return tx.Create(&assignments).Error
+	})
+}
+
+// IsDoctorAt 医師が所在地で対面診療を行うかどうか
+func (r *locationRepository) IsDoctorAt(doctorID, locationID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.DoctorLocation{}).Where("doctor_id = ? AND location_id = ?", doctorID, locationID).Count(&count).Error
+	return count > 0, err
+}
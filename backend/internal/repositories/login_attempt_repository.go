@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type LoginAttemptRepository interface {
+	FindUnknown(emailHash string) (*models.UnknownLoginAttempt, error)
+	RecordUnknownFailure(emailHash string, at, resetBefore time.Time) (int, error)
+	SetUnknownLockedUntil(emailHash string, until time.Time) error
+	DeleteStaleUnknown(lastFailedBefore, now time.Time) (int64, error)
+}
+
+type loginAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{
+		db: db,
+	}
+}
+
+// FindUnknown 登録されていないメールアドレスへのログインの失敗の記録
+func (r *loginAttemptRepository) FindUnknown(emailHash string) (*models.UnknownLoginAttempt, error) {
+	var attempt models.UnknownLoginAttempt
+	err := r.db.Where("email_hash = ?", emailHash).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// RecordUnknownFailure ログインの失敗の記録（前回の失敗が resetBefore より前の場合は1回目として数え直す）
+func (r *loginAttemptRepository) RecordUnknownFailure(emailHash string, at, resetBefore time.Time) (int, error) {
+	attempt := models.UnknownLoginAttempt{EmailHash: emailHash, FailedLoginCount: 1, LastFailedLoginAt: at}
+	err := r.db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "email_hash"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failed_login_count":   gorm.Expr("CASE WHEN unknown_login_attempts.last_failed_login_at < ? THEN 1 ELSE unknown_login_attempts.failed_login_count + 1 END", resetBefore),
+				"last_failed_login_at": at,
+			}),
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "failed_login_count"}}},
+	).Create(&attempt).Error
+	if err != nil {
+		return 0, err
+	}
+	return attempt.FailedLoginCount, nil
+}
+
+// SetUnknownLockedUntil ログインのロックの期限の設定
+func (r *loginAttemptRepository) SetUnknownLockedUntil(emailHash string, until time.Time) error {
+	return r.db.Model(&models.UnknownLoginAttempt{}).Where("email_hash = ?", emailHash).UpdateColumn("locked_until", until).Error
+}
+
+// DeleteStaleUnknown 失敗回数を数え直す時間が経過し、ロック中でもない記録の削除
+func (r *loginAttemptRepository) DeleteStaleUnknown(lastFailedBefore, now time.Time) (int64, error) {
+	result := r.db.Where("last_failed_login_at < ? AND (locked_until IS NULL OR locked_until <= ?)", lastFailedBefore, now).
+		Delete(&models.UnknownLoginAttempt{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordUnknownFailureCountsAndResets(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewLoginAttemptRepository(db)
+	now := time.Now()
+
+	for want := 1; want <= 3; want++ {
+		got, err := repo.RecordUnknownFailure("hash", now, now.Add(-time.Hour))
+		if err != nil {
+			t.Fatalf("RecordUnknownFailure() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("RecordUnknownFailure() = %d, want %d", got, want)
+		}
+	}
+
+	// 前回の失敗から数え直しの時間が経過した場合は1回目として数える
+	later := now.Add(2 * time.Hour)
+	got, err := repo.RecordUnknownFailure("hash", later, later.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("RecordUnknownFailure() error = %v", err)
+	}
+	if got != 1 {
+		t.Errorf("RecordUnknownFailure() after the reset window = %d, want 1", got)
+	}
+
+	if err := repo.SetUnknownLockedUntil("hash", later.Add(time.Minute)); err != nil {
+		t.Fatalf("SetUnknownLockedUntil() error = %v", err)
+	}
+	attempt, err := repo.FindUnknown("hash")
+	if err != nil || attempt == nil || attempt.LockedUntil == nil {
+		t.Fatalf("FindUnknown() = %+v, %v; want a locked attempt", attempt, err)
+	}
+	if deleted, err := repo.DeleteStaleUnknown(later.Add(time.Hour), later); err != nil || deleted != 0 {
+		t.Errorf("DeleteStaleUnknown() while locked = %d, %v; want 0, nil", deleted, err)
+	}
+	if deleted, err := repo.DeleteStaleUnknown(later.Add(time.Hour), later.Add(2*time.Minute)); err != nil || deleted != 1 {
+		t.Errorf("DeleteStaleUnknown() after the lock = %d, %v; want 1, nil", deleted, err)
+	}
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type MedicalRecordRepository interface {
+	Upsert(record *models.MedicalRecord) error
+	FindByAppointmentID(appointmentID uint) (*models.MedicalRecord, error)
+	FindBySubject(patientID uint, dependentID *uint, limit, offset int) ([]models.MedicalRecord, int64, error)
+	FindByPatientID(patientID uint) ([]models.MedicalRecord, error)
+	AmendField(id uint, column, value string) error
+}
+
+type medicalRecordRepository struct {
+	db *gorm.DB
+}
+
+func NewMedicalRecordRepository(db *gorm.DB) MedicalRecordRepository {
+	return &medicalRecordRepository{
+		db: db,
+	}
+}
+
+// Upsert 予約の診療録の作成・更新（同じ予約の診療録が既にある場合は記載内容を置き換える）
+func (r *medicalRecordRepository) Upsert(record *models.MedicalRecord) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "appointment_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"doctor_id", "diagnosis", "symptoms", "vitals_json", "allergies_json", "visit_summary", "updated_at"}),
+	}).Create(record).Error
+}
+
+// FindByAppointmentID 予約の診療録（未記載の場合はnil）
+func (r *medicalRecordRepository) FindByAppointmentID(appointmentID uint) (*models.MedicalRecord, error) {
+	var record models.MedicalRecord
+	if err := r.db.Preload("Doctor.DoctorProfile").Where("appointment_id = ?", appointmentID).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// FindBySubject 患者本人または家族の診療録（新しい順）と総件数
+func (r *medicalRecordRepository) FindBySubject(patientID uint, dependentID *uint, limit, offset int) ([]models.MedicalRecord, int64, error) {
+	query := r.db.Model(&models.MedicalRecord{}).Where("patient_id = ?", patientID)
+	if dependentID != nil {
+		query = query.Where("dependent_id = ?", *dependentID)
+	} else {
+		query = query.Where("dependent_id IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var records []models.MedicalRecord
+	err := query.Preload("Doctor.DoctorProfile").
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&records).Error
+	return records, total, err
+}
+
+// AmendField 訂正の依頼による診療録の記載の訂正（diagnosis・symptoms・visit_summary のみ）
+func (r *medicalRecordRepository) AmendField(id uint, column, value string) error {
+	switch column {
+	case "diagnosis", "symptoms", "visit_summary":
+	default:
+		return fmt.Errorf("medical record field %s cannot be amended", column)
+	}
+	return r.db.Model(&models.MedicalRecord{}).Where("id = ?", id).Update(column, value).Error
+}
+
+// FindByPatientID 患者本人の診療録（家族の分を除く、新しい順）
+func (r *medicalRecordRepository) FindByPatientID(patientID uint) ([]models.MedicalRecord, error) {
+	var records []models.MedicalRecord
+	err := r.db.Where("patient_id = ? AND dependent_id IS NULL", patientID).
+		Order("created_at DESC, id DESC").
+		Find(&records).Error
+	return records, err
+}
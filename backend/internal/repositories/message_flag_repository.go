@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type MessageFlagRepository interface {
+	Create(flag *models.MessageFlag) (bool, error)
+	FindByID(id uint) (*models.MessageFlag, error)
+	FindAll(status string, limit, offset int) ([]models.MessageFlag, error)
+	ResolveOpenByMessage(messageID uint, action, note string, resolvedByID uint, resolvedAt time.Time) (int64, error)
+}
+
+type messageFlagRepository struct {
+	db *gorm.DB
+}
+
+func NewMessageFlagRepository(db *gorm.DB) MessageFlagRepository {
+	return &messageFlagRepository{
+		db: db,
+	}
+}
+
+// Create 通報の登録（同じ利用者が既に通報済みの場合はfalse）
+func (r *messageFlagRepository) Create(flag *models.MessageFlag) (bool, error) {
+	result := r.db.Omit("Message", "Reporter").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(flag)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindByID IDで通報を取得（メッセージと送信者を含む）
+func (r *messageFlagRepository) FindByID(id uint) (*models.MessageFlag, error) {
+	var flag models.MessageFlag
+	err := r.db.Preload("Message.Sender").Preload("Reporter").First(&flag, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// FindAll 通報一覧を取得（管理者用、古い順）
+func (r *messageFlagRepository) FindAll(status string, limit, offset int) ([]models.MessageFlag, error) {
+	query := r.db.Preload("Message.Sender").Preload("Reporter")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var flags []models.MessageFlag
+	err := query.Order("created_at ASC").Limit(limit).Offset(offset).Find(&flags).Error
+	return flags, err
+}
+
+// ResolveOpenByMessage メッセージへの未対応の通報をまとめて解決する
+func (r *messageFlagRepository) ResolveOpenByMessage(messageID uint, action, note string, resolvedByID uint, resolvedAt time.Time) (int64, error) {
+	result := r.db.Model(&models.MessageFlag{}).
+		Where("message_id = ? AND status = ?", messageID, "open").
+		Updates(map[string]interface{}{
+			"status":          "resolved",
+			"action":          action,
+			"resolution_note": note,
+			"resolved_by_id":  resolvedByID,
+			"resolved_at":     resolvedAt,
+		})
+	return result.RowsAffected, result.Error
+}
@@ -11,11 +11,28 @@ type MessageRepository interface {
 	Create(message *models.Message) error
 	FindByID(id uint) (*models.Message, error)
 	FindByAppointmentID(appointmentID uint, limit, offset int) ([]models.Message, error)
+	FindTranscript(appointmentID uint) ([]models.Message, error)
 	Update(message *models.Message) error
 	Delete(id uint) error
 	LoadRelations(message *models.Message) error
 	MarkAsRead(appointmentID, userID uint) error
 	GetUnreadCount(appointmentID, userID uint) (int, error)
+	FindByCaseDiscussionID(caseDiscussionID uint, limit, offset int) ([]models.Message, error)
+	MarkCaseDiscussionAsRead(caseDiscussionID, userID uint) error
+	GetCaseDiscussionUnreadCount(caseDiscussionID, userID uint) (int, error)
+	GetUnreadCountsByUser(userID uint) ([]AppointmentUnreadCount, error)
+	FindSharedFilesBySession(videoSessionID uint) ([]models.Message, error)
+	FindUnreadForEmail(createdAfter, createdBefore time.Time) ([]models.Message, error)
+	MarkEmailNotified(ids []uint, notifiedAt time.Time) error
+	HasAutoReplySince(appointmentID uint, since time.Time) (bool, error)
+	FindDeferredForPush(notifyAfter, notifyBefore time.Time) ([]models.Message, error)
+	MarkPushNotified(id uint, notifiedAt time.Time) (bool, error)
+}
+
+// AppointmentUnreadCount 予約ごとの未読メッセージ数
+type AppointmentUnreadCount struct {
+	AppointmentID uint `json:"appointment_id"`
+	UnreadCount   int  `json:"unread_count"`
 }
 
 type messageRepository struct {
@@ -46,7 +63,7 @@ func (r *messageRepository) FindByID(id uint) (*models.Message, error) {
 // FindByAppointmentID 予約IDでメッセージ一覧を取得
 func (r *messageRepository) FindByAppointmentID(appointmentID uint, limit, offset int) ([]models.Message, error) {
 	var messages []models.Message
-	err := r.db.Where("appointment_id = ?", appointmentID).
+	err := r.db.Where("appointment_id = ? AND channel = ?", appointmentID, models.MessageChannelPatient).
 		Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -54,11 +71,78 @@ func (r *messageRepository) FindByAppointmentID(appointmentID uint, limit, offse
 	return messages, err
 }
 
+// FindTranscript 予約の診療チャットを削除済みのメッセージも含めて送信順に取得（苦情の審査用）
+func (r *messageRepository) FindTranscript(appointmentID uint) ([]models.Message, error) {
+	var messages []models.Message
+	err := r.db.Unscoped().Preload("Sender").
+		Where("appointment_id = ? AND channel = ?", appointmentID, models.MessageChannelPatient).
+		Order("created_at ASC, id ASC").
+		Find(&messages).Error
+	return messages, err
+}
+
+// FindUnreadForEmail 指定期間に送信され（通知を遅らせたものは遅らせた日時で判断）、未読のままメールで知らせていない
+// 診療チャットのメッセージ（予約を含む、自動返信は除く）
+func (r *messageRepository) FindUnreadForEmail(createdAfter, createdBefore time.Time) ([]models.Message, error) {
+	var messages []models.Message
+	err := r.db.Preload("Appointment").
+		Where("channel = ? AND read_at IS NULL AND email_notified_at IS NULL AND is_auto_reply = ?", models.MessageChannelPatient, false).
+		Where("COALESCE(notify_after, created_at) > ? AND COALESCE(notify_after, created_at) <= ?", createdAfter, createdBefore).
+		Order("created_at ASC").
+		Find(&messages).Error
+	return messages, err
+}
+
+// MarkEmailNotified メールで知らせたメッセージの記録
+func (r *messageRepository) MarkEmailNotified(ids []uint, notifiedAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.Message{}).Where("id IN ?", ids).Update("email_notified_at", notifiedAt).Error
+}
+
+// HasAutoReplySince 指定日時以降に予約のチャットへ自動返信したか
+func (r *messageRepository) HasAutoReplySince(appointmentID uint, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Message{}).
+		Where("appointment_id = ? AND channel = ? AND is_auto_reply = ? AND created_at >= ?", appointmentID, models.MessageChannelPatient, true, since).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindDeferredForPush 通知を遅らせた日時が指定期間内で、未読のままプッシュ通知していないメッセージ（予約を含む）
+func (r *messageRepository) FindDeferredForPush(notifyAfter, notifyBefore time.Time) ([]models.Message, error) {
+	var messages []models.Message
+	err := r.db.Preload("Appointment").
+		Where("channel = ? AND read_at IS NULL AND push_notified_at IS NULL AND notify_after > ? AND notify_after <= ?",
+			models.MessageChannelPatient, notifyAfter, notifyBefore).
+		Order("notify_after ASC").
+		Find(&messages).Error
+	return messages, err
+}
+
+// MarkPushNotified 遅らせたプッシュ通知の送信を記録する（記録済みの場合はfalse）
+func (r *messageRepository) MarkPushNotified(id uint, notifiedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.Message{}).
+		Where("id = ? AND push_notified_at IS NULL", id).
+		Update("push_notified_at", notifiedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindSharedFilesBySession ビデオセッション中に共有されたファイル（添付付きメッセージ）を共有順に取得
+func (r *messageRepository) FindSharedFilesBySession(videoSessionID uint) ([]models.Message, error) {
+	var messages []models.Message
+	err := r.db.Where("video_session_id = ? AND attachment_url IS NOT NULL", videoSessionID).
+		Order("created_at").
+		Find(&messages).Error
+	return messages, err
+}
+
 // FindUnreadByAppointmentID 予約IDで未読メッセージ一覧を取得
 func (r *messageRepository) FindUnreadByAppointmentID(appointmentID, userID uint) ([]models.Message, error) {
 	var messages []models.Message
-	err := r.db.Where("appointment_id = ? AND sender_user_id != ? AND read_at IS NULL", 
-		appointmentID, userID).Order("created_at ASC").Find(&messages).Error
+	err := r.db.Where("appointment_id = ? AND channel = ? AND sender_user_id != ? AND read_at IS NULL", 
+		appointmentID, models.MessageChannelPatient, userID).Order("created_at ASC").Find(&messages).Error
 	return messages, err
 }
 
@@ -74,15 +158,15 @@ func (r *messageRepository) Delete(id uint) error {
 
 // LoadRelations 関連データの読み込み
 func (r *messageRepository) LoadRelations(message *models.Message) error {
-	return r.db.Preload("Appointment").Preload("Sender").First(message, message.ID).Error
+	return r.db.Preload("Appointment").Preload("Sender").Preload("Attachment").First(message, message.ID).Error
 }
 
 // MarkAsRead メッセージを既読にする
 func (r *messageRepository) MarkAsRead(appointmentID, userID uint) error {
 	now := time.Now()
 	return r.db.Model(&models.Message{}).
-		Where("appointment_id = ? AND sender_user_id != ? AND read_at IS NULL", 
-			appointmentID, userID).
+		Where("appointment_id = ? AND channel = ? AND sender_user_id != ? AND read_at IS NULL", 
+			appointmentID, models.MessageChannelPatient, userID).
 		Update("read_at", now).Error
 }
 
@@ -90,8 +174,8 @@ func (r *messageRepository) MarkAsRead(appointmentID, userID uint) error {
 func (r *messageRepository) GetUnreadCount(appointmentID, userID uint) (int, error) {
 	var count int64
 	err := r.db.Model(&models.Message{}).
-		Where("appointment_id = ? AND sender_user_id != ? AND read_at IS NULL", 
-			appointmentID, userID).
+		Where("appointment_id = ? AND channel = ? AND sender_user_id != ? AND read_at IS NULL", 
+			appointmentID, models.MessageChannelPatient, userID).
 		Count(&count).Error
 	return int(count), err
 }
@@ -100,10 +184,54 @@ func (r *messageRepository) GetUnreadCount(appointmentID, userID uint) (int, err
 func (r *messageRepository) FindRecentMessages(userID uint, limit int) ([]models.Message, error) {
 	var messages []models.Message
 	err := r.db.Joins("JOIN appointments ON messages.appointment_id = appointments.id").
-		Where("(appointments.patient_id = ? OR appointments.doctor_id = ?) AND messages.sender_user_id != ?", 
-			userID, userID, userID).
+		Where("(appointments.patient_id = ? OR appointments.doctor_id = ?) AND messages.channel = ? AND messages.sender_user_id != ?", 
+			userID, userID, models.MessageChannelPatient, userID).
 		Order("messages.created_at DESC").
 		Limit(limit).
 		Find(&messages).Error
 	return messages, err
 }
+
+// FindByCaseDiscussionID 症例相談スレッドのメッセージ一覧を取得
+func (r *messageRepository) FindByCaseDiscussionID(caseDiscussionID uint, limit, offset int) ([]models.Message, error) {
+	var messages []models.Message
+	err := r.db.Where("case_discussion_id = ? AND channel = ?", caseDiscussionID, models.MessageChannelCaseDiscussion).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&messages).Error
+	return messages, err
+}
+
+// MarkCaseDiscussionAsRead 症例相談スレッドのメッセージを既読にする
+func (r *messageRepository) MarkCaseDiscussionAsRead(caseDiscussionID, userID uint) error {
+	now := time.Now()
+	return r.db.Model(&models.Message{}).
+		Where("case_discussion_id = ? AND channel = ? AND sender_user_id != ? AND read_at IS NULL",
+			caseDiscussionID, models.MessageChannelCaseDiscussion, userID).
+		Update("read_at", now).Error
+}
+
+// GetCaseDiscussionUnreadCount 症例相談スレッドの未読メッセージ数を取得
+func (r *messageRepository) GetCaseDiscussionUnreadCount(caseDiscussionID, userID uint) (int, error) {
+	var count int64
+	err := r.db.Model(&models.Message{}).
+		Where("case_discussion_id = ? AND channel = ? AND sender_user_id != ? AND read_at IS NULL",
+			caseDiscussionID, models.MessageChannelCaseDiscussion, userID).
+		Count(&count).Error
+	return int(count), err
+}
+
+// GetUnreadCountsByUser ユーザーが参加する予約ごとの未読メッセージ数を一括で取得（未読のある予約のみ）
+func (r *messageRepository) GetUnreadCountsByUser(userID uint) ([]AppointmentUnreadCount, error) {
+	var counts []AppointmentUnreadCount
+	err := r.db.Model(&models.Message{}).
+		Select("messages.appointment_id, COUNT(*) AS unread_count").
+		Joins("JOIN appointments ON appointments.id = messages.appointment_id AND appointments.deleted_at IS NULL").
+		Where("appointments.patient_id = ? OR appointments.doctor_id = ? OR appointments.interpreter_id = ?", userID, userID, userID).
+		Where("messages.channel = ? AND messages.sender_user_id != ? AND messages.read_at IS NULL", models.MessageChannelPatient, userID).
+		Group("messages.appointment_id").
+		Order("messages.appointment_id").
+		Scan(&counts).Error
+	return counts, err
+}
@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type NotificationPreferenceRepository interface {
+	FindByUserID(userID uint) (*models.NotificationPreference, error)
+	Save(preference *models.NotificationPreference) error
+}
+
+type notificationPreferenceRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationPreferenceRepository(db *gorm.DB) NotificationPreferenceRepository {
+	return &notificationPreferenceRepository{
+		db: db,
+	}
+}
+
+// FindByUserID ユーザーの通知設定を取得（未設定の場合はnil）
+func (r *notificationPreferenceRepository) FindByUserID(userID uint) (*models.NotificationPreference, error) {
+	var preference models.NotificationPreference
+	err := r.db.Where("user_id = ?", userID).First(&preference).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &preference, nil
+}
+
+// Save 通知設定の保存（未設定のユーザーは作成する）
+func (r *notificationPreferenceRepository) Save(preference *models.NotificationPreference) error {
+	return r.db.Save(preference).Error
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type NotificationRepository interface {
+	Create(notification *models.Notification) error
+	FindByID(id uint) (*models.Notification, error)
+	FindByUserID(userID uint, unreadOnly bool, limit, offset int) ([]models.Notification, error)
+	CountUnread(userID uint) (int64, error)
+	MarkAsRead(id uint) error
+	MarkAllAsRead(userID uint) error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{
+		db: db,
+	}
+}
+
+// Create 通知の作成
+func (r *notificationRepository) Create(notification *models.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+// FindByID IDで通知を取得
+func (r *notificationRepository) FindByID(id uint) (*models.Notification, error) {
+	var notification models.Notification
+	if err := r.db.First(&notification, id).Error; err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// FindByUserID ユーザーの通知一覧を取得
+func (r *notificationRepository) FindByUserID(userID uint, unreadOnly bool, limit, offset int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	query := r.db.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&notifications).Error
+	return notifications, err
+}
+
+// CountUnread 未読通知数を取得
+func (r *notificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// MarkAsRead 通知を既読にする
+func (r *notificationRepository) MarkAsRead(id uint) error {
+	return r.db.Model(&models.Notification{}).Where("id = ? AND read_at IS NULL", id).Update("read_at", time.Now()).Error
+}
+
+// MarkAllAsRead ユーザーの通知をすべて既読にする
+func (r *notificationRepository) MarkAllAsRead(userID uint) error {
+	return r.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Update("read_at", time.Now()).Error
+}
@@ -0,0 +1,171 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type OrganizationRepository interface {
+	Create(organization *models.Organization) error
+	FindByID(id uint) (*models.Organization, error)
+	FindAll() ([]models.Organization, error)
+	FindByMember(userID uint) ([]models.Organization, error)
+	SaveMember(member *models.OrganizationMember) error
+	FindMember(organizationID, userID uint) (*models.OrganizationMember, error)
+	FindMembers(organizationID uint) ([]models.OrganizationMember, error)
+	RemoveMember(organizationID, userID uint) (bool, error)
+	FindMemberIDs(organizationID uint, role string) ([]uint, error)
+	FindRoles(organizationID, userID uint) ([]string, error)
+	FindRolesByOrganization(organizationID uint) ([]models.OrganizationRoleGrant, error)
+	ReplaceRoles(organizationID, userID uint, roles []string, grantedByID uint) error
+}
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{
+		db: db,
+	}
+}
+
+func (r *organizationRepository) Create(organization *models.Organization) error {
+	return r.db.Create(organization).Error
+}
+
+// FindByID 組織の取得（ない場合はnil）
+func (r *organizationRepository) FindByID(id uint) (*models.Organization, error) {
+	var organization models.Organization
+	err := r.db.First(&organization, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// FindAll 組織の一覧（名称順）
+func (r *organizationRepository) FindAll() ([]models.Organization, error) {
+	var organizations []models.Organization
+	err := r.db.Order("name ASC, id ASC").Find(&organizations).Error
+	return organizations, err
+}
+
+// FindByMember 利用者が所属する組織の一覧
+func (r *organizationRepository) FindByMember(userID uint) ([]models.Organization, error) {
+	var organizations []models.Organization
+	err := r.db.Where("id IN (?)", r.db.Model(&models.OrganizationMember{}).Select("organization_id").Where("user_id = ?", userID)).
+		Order("name ASC, id ASC").
+		Find(&organizations).Error
+	return organizations, err
+}
+
+// SaveMember 組織への所属の追加（所属済みの場合は組織の管理者かどうかを更新する）
+func (r *organizationRepository) SaveMember(member *models.OrganizationMember) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"is_org_admin"}),
+	}).Create(member).Error
+}
+
+// FindMember 組織への所属の取得（所属していない場合はnil）
+func (r *organizationRepository) FindMember(organizationID, userID uint) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	err := r.db.Where("organization_id = ? AND user_id = ?", organizationID, userID).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// FindMembers 組織に所属する医師・スタッフの一覧（所属した順）
+func (r *organizationRepository) FindMembers(organizationID uint) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	err := r.db.Where("organization_id = ?", organizationID).
+		Preload("User.DoctorProfile").
+		Order("created_at ASC, user_id ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// RemoveMember 組織からの所属の解除（委任したロールも取り消す、所属していない場合はfalse）
+func (r *organizationRepository) RemoveMember(organizationID, userID uint) (bool, error) {
+	removed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ? AND user_id = ?", organizationID, userID).Delete(&models.OrganizationRoleGrant{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("organization_id = ? AND user_id = ?", organizationID, userID).Delete(&models.OrganizationMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed = result.RowsAffected > 0
+		return nil
+	})
+	return removed, err
+}
+
+// FindMemberIDs 組織に所属する利用者のID（roleを指定した場合はそのロールの利用者のみ）
+func (r *organizationRepository) FindMemberIDs(organizationID uint, role string) ([]uint, error) {
+	query := r.db.Model(&models.OrganizationMember{}).Where("organization_id = ?", organizationID)
+	if role != "" {
+		query = query.Where("user_id IN (?)", r.db.Model(&models.User{}).Select("id").Where("role = ?", role))
+	}
+
+	var userIDs []uint
+	err := query.Order("user_id ASC").Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// FindRoles 組織でスタッフに委任されたロール
+func (r *organizationRepository) FindRoles(organizationID, userID uint) ([]string, error) {
+	var roles []string
+	err := r.db.Model(&models.OrganizationRoleGrant{}).
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		Order("role ASC").
+		Pluck("role", &roles).Error
+	return roles, err
+}
+
+// FindRolesByOrganization 組織で委任されたすべてのロール
+func (r *organizationRepository) FindRolesByOrganization(organizationID uint) ([]models.OrganizationRoleGrant, error) {
+	var grants []models.OrganizationRoleGrant
+	err := r.db.Where("organization_id = ?", organizationID).Order("user_id ASC, role ASC").Find(&grants).Error
+	return grants, err
+}
+
+// ReplaceRoles スタッフに委任するロールの置き換え（引き続き委任するロールは付与した日時と付与者を変えない）
+func (r *organizationRepository) ReplaceRoles(organizationID, userID uint, roles []string, grantedByID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("organization_id = ? AND user_id = ?", organizationID, userID)
+		if len(roles) > 0 {
+			query = query.Where("role NOT IN ?", roles)
+		}
+		if err := query.Delete(&models.OrganizationRoleGrant{}).Error; err != nil {
+			return err
+		}
+		if len(roles) == 0 {
+			return nil
+		}
+
+		grants := make([]models.OrganizationRoleGrant, 0, len(roles))
+		for _, role := range roles {
+			grants = append(grants, models.OrganizationRoleGrant{
+				OrganizationID: organizationID,
+				UserID:         userID,
+				Role:           role,
+				GrantedByID:    grantedByID,
+			})
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&grants).Error
+	})
+}
@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type PatientDocumentRepository interface {
+	Create(document *models.PatientDocument) error
+	FindByID(id uint) (*models.PatientDocument, error)
+	FindByPatientID(patientID uint) ([]models.PatientDocument, error)
+	FindByIDsAndPatient(ids []uint, patientID uint) ([]models.PatientDocument, error)
+	Delete(id uint) error
+	CreateGrants(grants []models.AppointmentDocumentGrant) error
+	FindGrantsByAppointmentID(appointmentID uint) ([]models.AppointmentDocumentGrant, error)
+	HasGrantForDoctor(documentID, doctorID uint) (bool, error)
+	DeleteGrant(appointmentID, documentID uint) (bool, error)
+	DeleteGrantsByDocumentID(documentID uint) error
+	FindOCRProcessable(staleBefore time.Time, limit int) ([]models.PatientDocument, error)
+	ClaimOCR(id uint, updatedAt time.Time) (bool, error)
+	CompleteOCR(id uint, text, detectedDocType, provider string, values []models.PatientDocumentValue, processedAt time.Time) error
+	UpdateOCRStatus(id uint, status, reason string) error
+	SearchForDoctor(doctorID uint, filter DocumentSearchFilter, limit, offset int) ([]models.PatientDocument, error)
+}
+
+// DocumentSearchFilter 医師に共有された診療記録の検索条件
+type DocumentSearchFilter struct {
+	Query     string // タイトル・認識したテキスト・抽出した値に含まれる語句
+	PatientID uint
+	DocType   string // 患者が選んだ種類または推定した種類
+}
+
+type patientDocumentRepository struct {
+	db *gorm.DB
+}
+
+func NewPatientDocumentRepository(db *gorm.DB) PatientDocumentRepository {
+	return &patientDocumentRepository{
+		db: db,
+	}
+}
+
+// Create 診療記録の登録
+func (r *patientDocumentRepository) Create(document *models.PatientDocument) error {
+	return r.db.Create(document).Error
+}
+
+// FindByID IDで診療記録を取得
+func (r *patientDocumentRepository) FindByID(id uint) (*models.PatientDocument, error) {
+	var document models.PatientDocument
+	if err := r.db.First(&document, id).Error; err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// FindByPatientID 患者の診療記録一覧を取得（新しい順）
+func (r *patientDocumentRepository) FindByPatientID(patientID uint) ([]models.PatientDocument, error) {
+	var documents []models.PatientDocument
+	err := r.db.Preload("Values", orderDocumentValues).
+		Where("patient_id = ?", patientID).
+		Order("recorded_at DESC NULLS LAST, created_at DESC").
+		Find(&documents).Error
+	return documents, err
+}
+
+// FindByIDsAndPatient 指定した患者が所有する診療記録のみを取得
+func (r *patientDocumentRepository) FindByIDsAndPatient(ids []uint, patientID uint) ([]models.PatientDocument, error) {
+	var documents []models.PatientDocument
+	err := r.db.Where("id IN ? AND patient_id = ?", ids, patientID).Find(&documents).Error
+	return documents, err
+}
+
+// Delete 診療記録の削除
+func (r *patientDocumentRepository) Delete(id uint) error {
+	return r.db.Delete(&models.PatientDocument{}, id).Error
+}
+
+// CreateGrants 予約への共有の作成（共有済みのものは無視）
+func (r *patientDocumentRepository) CreateGrants(grants []models.AppointmentDocumentGrant) error {
+	if len(grants) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Omit("Document").Create(&grants).Error
+}
+
+// FindGrantsByAppointmentID 予約に共有された診療記録を取得
+func (r *patientDocumentRepository) FindGrantsByAppointmentID(appointmentID uint) ([]models.AppointmentDocumentGrant, error) {
+	var grants []models.AppointmentDocumentGrant
+	err := r.db.Preload("Document").Preload("Document.Values", orderDocumentValues).
+		Where("appointment_id = ?", appointmentID).
+		Order("created_at ASC").
+		Find(&grants).Error
+	return grants, err
+}
+
+// HasGrantForDoctor 医師に共有されている診療記録かどうか
+func (r *patientDocumentRepository) HasGrantForDoctor(documentID, doctorID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.AppointmentDocumentGrant{}).
+		Where("document_id = ? AND doctor_id = ?", documentID, doctorID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// DeleteGrant 予約への共有の取り消し
+func (r *patientDocumentRepository) DeleteGrant(appointmentID, documentID uint) (bool, error) {
+	result := r.db.Where("appointment_id = ? AND document_id = ?", appointmentID, documentID).
+		Delete(&models.AppointmentDocumentGrant{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteGrantsByDocumentID 診療記録のすべての共有を取り消す
+func (r *patientDocumentRepository) DeleteGrantsByDocumentID(documentID uint) error {
+	return r.db.Where("document_id = ?", documentID).Delete(&models.AppointmentDocumentGrant{}).Error
+}
+
+// FindOCRProcessable 文字認識待ち、または処理中のまま止まった診療記録を古い順に取得
+func (r *patientDocumentRepository) FindOCRProcessable(staleBefore time.Time, limit int) ([]models.PatientDocument, error) {
+	var documents []models.PatientDocument
+	err := r.db.Where("ocr_status = ? OR (ocr_status = ? AND updated_at < ?)", "pending", "processing", staleBefore).
+		Order("created_at").
+		Limit(limit).
+		Find(&documents).Error
+	return documents, err
+}
+
+// ClaimOCR 文字認識の開始（取得時から更新されていない場合のみ、複数インスタンスでの重複処理を防ぐ）
+func (r *patientDocumentRepository) ClaimOCR(id uint, updatedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.PatientDocument{}).
+		Where("id = ? AND updated_at = ? AND ocr_status IN ?", id, updatedAt, []string{"pending", "processing"}).
+		Updates(map[string]interface{}{"ocr_status": "processing", "ocr_attempts": gorm.Expr("ocr_attempts + 1")})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CompleteOCR 認識したテキストと抽出した値を保存して完了にする（再処理の場合は以前の値を置き換える）
+func (r *patientDocumentRepository) CompleteOCR(id uint, text, detectedDocType, provider string, values []models.PatientDocumentValue, processedAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("document_id = ?", id).Delete(&models.PatientDocumentValue{}).Error; err != nil {
+			return err
+		}
+		for i := range values {
+			values[i].DocumentID = id
+		}
+		if len(values) > 0 {
+			if err := tx.Create(&values).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.PatientDocument{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"ocr_status":        "completed",
+			"ocr_error":         "",
+			"ocr_provider":      provider,
+			"extracted_text":    text,
+			"detected_doc_type": detectedDocType,
+			"processed_at":      processedAt,
+		}).Error
+	})
+}
+
+// UpdateOCRStatus 文字認識の状況の更新（失敗時の再試行待ち・失敗の確定）
+func (r *patientDocumentRepository) UpdateOCRStatus(id uint, status, reason string) error {
+	return r.db.Model(&models.PatientDocument{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"ocr_status": status,
+		"ocr_error":  reason,
+	}).Error
+}
+
+// SearchForDoctor 医師に共有された診療記録の検索（検査日・作成日の新しい順）
+func (r *patientDocumentRepository) SearchForDoctor(doctorID uint, filter DocumentSearchFilter, limit, offset int) ([]models.PatientDocument, error) {
+	query := r.db.Preload("Values", orderDocumentValues).
+		Where("id IN (?)", r.db.Model(&models.AppointmentDocumentGrant{}).Select("document_id").Where("doctor_id = ?", doctorID))
+	if filter.PatientID != 0 {
+		query = query.Where("patient_id = ?", filter.PatientID)
+	}
+	if filter.DocType != "" {
+		query = query.Where("doc_type = ? OR detected_doc_type = ?", filter.DocType, filter.DocType)
+	}
+	if filter.Query != "" {
+		like := "%" + escapeLike(filter.Query) + "%"
+		matchingValues := r.db.Model(&models.PatientDocumentValue{}).Select("document_id").Where("name ILIKE ? OR value ILIKE ?", like, like)
+		query = query.Where("title ILIKE ? OR extracted_text ILIKE ? OR id IN (?)", like, like, matchingValues)
+	}
+
+	var documents []models.PatientDocument
+	err := query.Order("recorded_at DESC NULLS LAST, created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&documents).Error
+	return documents, err
+}
+
+// orderDocumentValues 抽出した値を検査項目・日付の順、抽出順に並べる
+func orderDocumentValues(db *gorm.DB) *gorm.DB {
+	return db.Order("kind DESC, id ASC")
+}
@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+// PatientMergeCounts 統合時に付け替えた件数
+type PatientMergeCounts struct {
+	Appointments         int64 `json:"appointments"`
+	Prescriptions        int64 `json:"prescriptions"`
+	Messages             int64 `json:"messages"`
+	Dependents           int64 `json:"dependents"`
+	TriageAssessments    int64 `json:"triage_assessments"`
+	Complaints           int64 `json:"complaints"`
+	Escalations          int64 `json:"escalations"`
+	Notifications        int64 `json:"notifications"`
+	LegalAcceptances     int64 `json:"legal_acceptances"`
+	Tasks                int64 `json:"tasks"`
+	Problems             int64 `json:"problems"`
+	PROMs                int64 `json:"proms"`
+	MedicalRecords       int64 `json:"medical_records"`
+	Transfers            int64 `json:"transfers"`
+	Invoices             int64 `json:"invoices"`
+	Documents            int64 `json:"documents"`
+	DocumentGrants       int64 `json:"document_grants"`
+	CorrectionRequests   int64 `json:"correction_requests"`
+	SlotSubscriptions    int64 `json:"slot_subscriptions"`
+	Subscriptions        int64 `json:"subscriptions"`
+	SubscriptionPayments int64 `json:"subscription_payments"`
+}
+
+type PatientMergeRepository interface {
+	FindPatientProfiles() ([]models.PatientProfile, error)
+	Merge(survivor *models.PatientProfile, duplicateID uint) (*PatientMergeCounts, error)
+}
+
+type patientMergeRepository struct {
+	db *gorm.DB
+}
+
+func NewPatientMergeRepository(db *gorm.DB) PatientMergeRepository {
+	return &patientMergeRepository{
+		db: db,
+	}
+}
+
+// FindPatientProfiles 全患者のプロフィールを取得（重複検出用）
+func (r *patientMergeRepository) FindPatientProfiles() ([]models.PatientProfile, error) {
+	var profiles []models.PatientProfile
+	err := r.db.Preload("User").
+		Joins("JOIN users ON users.id = patient_profiles.user_id AND users.deleted_at IS NULL").
+		Order("patient_profiles.user_id ASC").
+		Find(&profiles).Error
+	return profiles, err
+}
+
+// Merge 重複アカウントのデータを存続アカウントへ付け替え、重複アカウントを削除する（単一トランザクション）
+// 処方箋は予約に紐付くため、予約の付け替えで存続アカウントへ移る
+func (r *patientMergeRepository) Merge(survivor *models.PatientProfile, duplicateID uint) (*PatientMergeCounts, error) {
+	counts := &PatientMergeCounts{}
+	survivorID := survivor.UserID
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Prescription{}).
+			Where("appointment_id IN (?)", tx.Model(&models.Appointment{}).Select("id").Where("patient_id = ?", duplicateID)).
+			Count(&counts.Prescriptions).Error; err != nil {
+			return err
+		}
+
+		// 問題リストは存続アカウントで有効になっていない病名のみ引き継ぐ
+		if err := tx.Exec(`
+			DELETE FROM problem_list_entries d
+			WHERE d.patient_id = ? AND d.status = 'active' AND EXISTS (
+				SELECT 1 FROM problem_list_entries s
+				WHERE s.patient_id = ? AND s.status = 'active' AND s.code = d.code
+				AND COALESCE(s.dependent_id, 0) = COALESCE(d.dependent_id, 0)
+			)`, duplicateID, survivorID).Error; err != nil {
+			return err
+		}
+
+		updates := []struct {
+			model  interface{}
+			column string
+			count  *int64
+		}{
+			{&models.Appointment{}, "patient_id", &counts.Appointments},
+			{&models.Message{}, "sender_user_id", &counts.Messages},
+			{&models.Dependent{}, "guardian_id", &counts.Dependents},
+			{&models.TriageAssessment{}, "patient_id", &counts.TriageAssessments},
+			{&models.Complaint{}, "complainant_id", &counts.Complaints},
+			{&models.Escalation{}, "raised_by_user_id", &counts.Escalations},
+			{&models.Notification{}, "user_id", &counts.Notifications},
+			{&models.AppointmentTask{}, "assignee_id", &counts.Tasks},
+			{&models.ProblemListEntry{}, "patient_id", &counts.Problems},
+			{&models.PROMAssignment{}, "patient_id", &counts.PROMs},
+			{&models.MedicalRecord{}, "patient_id", &counts.MedicalRecords},
+			{&models.AppointmentTransfer{}, "patient_id", &counts.Transfers},
+			{&models.Invoice{}, "patient_id", &counts.Invoices},
+			{&models.PatientDocument{}, "patient_id", &counts.Documents},
+			{&models.AppointmentDocumentGrant{}, "patient_id", &counts.DocumentGrants},
+			{&models.CorrectionRequest{}, "patient_id", &counts.CorrectionRequests},
+			{&models.SubscriptionPayment{}, "patient_id", &counts.SubscriptionPayments},
+		}
+		for _, u := range updates {
+			result := tx.Model(u.model).Unscoped().Where(u.column+" = ?", duplicateID).Update(u.column, survivorID)
+			if result.Error != nil {
+				return result.Error
+			}
+			*u.count = result.RowsAffected
+		}
+
+		if err := tx.Model(&models.AppointmentTask{}).Where("created_by_id = ?", duplicateID).Update("created_by_id", survivorID).Error; err != nil {
+			return err
+		}
+
+		// 同意記録は存続アカウントが未同意の文書のみ引き継ぐ
+		if err := tx.Where("user_id = ? AND document_id IN (?)", duplicateID,
+			tx.Model(&models.LegalAcceptance{}).Select("document_id").Where("user_id = ?", survivorID)).
+			Delete(&models.LegalAcceptance{}).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.LegalAcceptance{}).Where("user_id = ?", duplicateID).Update("user_id", survivorID)
+		if result.Error != nil {
+			return result.Error
+		}
+		counts.LegalAcceptances = result.RowsAffected
+
+		// 空き枠の通知の登録は存続アカウントが登録していない医師のみ引き継ぐ
+		if err := tx.Where("patient_id = ? AND doctor_id IN (?)", duplicateID,
+			tx.Model(&models.SlotSubscription{}).Select("doctor_id").Where("patient_id = ?", survivorID)).
+			Delete(&models.SlotSubscription{}).Error; err != nil {
+			return err
+		}
+		result = tx.Model(&models.SlotSubscription{}).Where("patient_id = ?", duplicateID).Update("patient_id", survivorID)
+		if result.Error != nil {
+			return result.Error
+		}
+		counts.SlotSubscriptions = result.RowsAffected
+
+		// 有料プランの契約は期限の遅い方を存続アカウントの契約とする
+		if err := tx.Model(&models.PatientSubscription{}).Where("patient_id = ?", duplicateID).Count(&counts.Subscriptions).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+			UPDATE patient_subscriptions s SET active_until = d.active_until, updated_at = ?
+			FROM patient_subscriptions d
+			WHERE s.patient_id = ? AND d.patient_id = ? AND d.active_until > s.active_until`,
+			time.Now(), survivorID, duplicateID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("patient_id = ? AND EXISTS (?)", duplicateID,
+			tx.Model(&models.PatientSubscription{}).Select("1").Where("patient_id = ?", survivorID)).
+			Delete(&models.PatientSubscription{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PatientSubscription{}).Where("patient_id = ?", duplicateID).Update("patient_id", survivorID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Omit("User").Save(survivor).Error; err != nil {
+			return err
+		}
+
+		// 通知設定は存続アカウントのものを使用する
+		if err := tx.Where("user_id = ?", duplicateID).Delete(&models.NotificationPreference{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", duplicateID).Delete(&models.PatientProfile{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.User{}, duplicateID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+func TestMergeMovesPatientData(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPatientMergeRepository(db)
+	doctor := createTestUser(t, db, "doctor")
+	survivor := createTestPatient(t, db, "存続 患者")
+	duplicate := createTestPatient(t, db, "重複 患者")
+
+	appointment := &models.Appointment{PatientID: duplicate.UserID, DoctorID: doctor.ID, Status: "completed"}
+	mustCreate(t, db, appointment)
+	mustCreate(t, db, &models.Invoice{AppointmentID: appointment.ID, PatientID: duplicate.UserID, DoctorID: doctor.ID, Amount: 1000, Currency: "jpy"})
+	document := &models.PatientDocument{PatientID: duplicate.UserID, Title: "血液検査", DocType: "lab_result", FilePath: "documents/test.pdf", FileName: "test.pdf", ContentType: "application/pdf", FileSize: 1}
+	mustCreate(t, db, document)
+	mustCreate(t, db, &models.AppointmentDocumentGrant{AppointmentID: appointment.ID, DocumentID: document.ID, PatientID: duplicate.UserID, DoctorID: doctor.ID})
+	record := &models.MedicalRecord{AppointmentID: appointment.ID, PatientID: duplicate.UserID, DoctorID: doctor.ID}
+	mustCreate(t, db, record)
+	mustCreate(t, db, &models.CorrectionRequest{PatientID: duplicate.UserID, DoctorID: doctor.ID, AppointmentID: appointment.ID, MedicalRecordID: record.ID, Field: "allergies", Description: "アレルギーの記載漏れ"})
+
+	otherDoctor := createTestUser(t, db, "doctor")
+	mustCreate(t, db, &models.SlotSubscription{PatientID: survivor.UserID, DoctorID: doctor.ID, UnsubscribeToken: "survivor-" + survivor.Name})
+	mustCreate(t, db, &models.SlotSubscription{PatientID: duplicate.UserID, DoctorID: doctor.ID, UnsubscribeToken: "duplicate-" + duplicate.Name})
+	mustCreate(t, db, &models.SlotSubscription{PatientID: duplicate.UserID, DoctorID: otherDoctor.ID, UnsubscribeToken: "duplicate-other-" + duplicate.Name})
+
+	activeUntil := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second)
+	mustCreate(t, db, &models.PatientSubscription{PatientID: survivor.UserID, Plan: models.PlanPlus, ActiveUntil: time.Now().Add(24 * time.Hour)})
+	mustCreate(t, db, &models.PatientSubscription{PatientID: duplicate.UserID, Plan: models.PlanPlus, ActiveUntil: activeUntil})
+	mustCreate(t, db, &models.SubscriptionPayment{PatientID: duplicate.UserID, Plan: models.PlanPlus, Provider: "stripe", ProviderIntentID: "pi_merge_" + duplicate.Name, ClientSecret: "secret", Amount: 980, Currency: "jpy", Status: "succeeded"})
+
+	counts, err := repo.Merge(survivor, duplicate.UserID)
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if counts.Appointments != 1 || counts.Invoices != 1 {
+		t.Errorf("Merge() counts = %+v, want 1 appointment and 1 invoice", counts)
+	}
+	// 同じ医師の空き枠の通知の登録は存続アカウントの1件にまとめる
+	if counts.SlotSubscriptions != 1 {
+		t.Errorf("Merge() moved %d slot subscriptions, want 1", counts.SlotSubscriptions)
+	}
+	if n := countOwned(t, db, &models.SlotSubscription{}, "patient_id", survivor.UserID); n != 2 {
+		t.Errorf("survivor has %d slot subscriptions, want 2", n)
+	}
+	// 有料プランの契約は存続アカウントの1件にまとめ、期限の遅い方を残す
+	var subscriptions []models.PatientSubscription
+	if err := db.Where("patient_id = ?", survivor.UserID).Find(&subscriptions).Error; err != nil {
+		t.Fatalf("failed to load subscriptions: %v", err)
+	}
+	if len(subscriptions) != 1 || !subscriptions[0].ActiveUntil.Equal(activeUntil) {
+		t.Errorf("survivor subscriptions = %+v, want one active until %v", subscriptions, activeUntil)
+	}
+
+	// 統合後に重複アカウントに残ったデータは存続アカウントから参照できない
+	owned := []struct {
+		table  string
+		model  interface{}
+		column string
+	}{
+		{"appointments", &models.Appointment{}, "patient_id"},
+		{"invoices", &models.Invoice{}, "patient_id"},
+		{"patient_documents", &models.PatientDocument{}, "patient_id"},
+		{"appointment_document_grants", &models.AppointmentDocumentGrant{}, "patient_id"},
+		{"medical_records", &models.MedicalRecord{}, "patient_id"},
+		{"correction_requests", &models.CorrectionRequest{}, "patient_id"},
+		{"slot_subscriptions", &models.SlotSubscription{}, "patient_id"},
+		{"patient_subscriptions", &models.PatientSubscription{}, "patient_id"},
+		{"subscription_payments", &models.SubscriptionPayment{}, "patient_id"},
+	}
+	for _, o := range owned {
+		if n := countOwned(t, db, o.model, o.column, duplicate.UserID); n != 0 {
+			t.Errorf("%s: %d rows left on the merged duplicate", o.table, n)
+		}
+		if n := countOwned(t, db, o.model, o.column, survivor.UserID); n == 0 {
+			t.Errorf("%s: no rows moved to the survivor", o.table)
+		}
+	}
+
+	var remaining int64
+	if err := db.Model(&models.User{}).Where("id = ?", duplicate.UserID).Count(&remaining).Error; err != nil {
+		t.Fatalf("failed to count users: %v", err)
+	}
+	if remaining != 0 {
+		t.Error("duplicate user was not deleted")
+	}
+}
+
+// createTestPatient テスト用の患者（ユーザーとプロフィール）の作成
+func createTestPatient(t *testing.T, db *gorm.DB, name string) *models.PatientProfile {
+	t.Helper()
+	user := createTestUser(t, db, "patient")
+	profile := &models.PatientProfile{UserID: user.ID, Name: name}
+	mustCreate(t, db, profile)
+	return profile
+}
+
+func mustCreate(t *testing.T, db *gorm.DB, value interface{}) {
+	t.Helper()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatalf("failed to create %T: %v", value, err)
+	}
+}
+
+// countOwned 指定したユーザーの行数（削除済みを含む）
+func countOwned(t *testing.T, db *gorm.DB, model interface{}, column string, userID uint) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(model).Unscoped().Where(column+" = ?", userID).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count %T: %v", model, err)
+	}
+	return count
+}
@@ -1,6 +1,9 @@
 package repositories
 
 import (
+	"errors"
+	"time"
+
 	"gorm.io/gorm"
 	"online_medical_consultation_app/backend/internal/models"
 )
@@ -8,10 +11,17 @@ import (
 type PrescriptionRepository interface {
 	Create(prescription *models.Prescription) error
 	FindByID(id uint) (*models.Prescription, error)
-	FindByAppointmentID(appointmentID uint) ([]models.Prescription, error)
+	FindPageByAppointmentID(appointmentID uint, limit, offset int) ([]models.Prescription, int64, error)
 	Update(prescription *models.Prescription) error
 	Delete(id uint) error
 	LoadRelations(prescription *models.Prescription) error
+	FindByPatientSince(patientID uint, since time.Time) ([]models.Prescription, error)
+	Acknowledge(prescriptionID uint, acknowledgedAt time.Time) (bool, error)
+	FindUnacknowledgedByPatient(patientID uint) ([]models.Prescription, error)
+	FindAckReminderDue(notifiedBefore time.Time, maxReminders int) ([]models.Prescription, error)
+	MarkAckReminded(prescriptionID uint, notifiedAt time.Time) error
+	AssignVerificationCode(prescriptionID uint, code string) (bool, error)
+	FindByVerificationCode(code string) (*models.Prescription, error)
 }
 
 type prescriptionRepository struct {
@@ -39,11 +49,18 @@ func (r *prescriptionRepository) FindByID(id uint) (*models.Prescription, error)
 	return &prescription, nil
 }
 
-// FindByAppointmentID 予約IDで処方一覧を取得
-func (r *prescriptionRepository) FindByAppointmentID(appointmentID uint) ([]models.Prescription, error) {
+// FindPageByAppointmentID 予約IDで処方一覧（新しい順）の指定範囲と総件数を取得
+func (r *prescriptionRepository) FindPageByAppointmentID(appointmentID uint, limit, offset int) ([]models.Prescription, int64, error) {
+	query := r.db.Model(&models.Prescription{}).Where("appointment_id = ?", appointmentID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
 	var prescriptions []models.Prescription
-	err := r.db.Where("appointment_id = ?", appointmentID).Order("created_at DESC").Find(&prescriptions).Error
-	return prescriptions, err
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&prescriptions).Error
+	return prescriptions, total, err
 }
 
 // FindByDoctorID 医師IDで処方一覧を取得
@@ -86,3 +103,73 @@ func (r *prescriptionRepository) FindByDateRange(doctorID uint, startDate, endDa
 		doctorID, startDate, endDate).Order("created_at DESC").Find(&prescriptions).Error
 	return prescriptions, err
 }
+
+// FindByPatientSince 指定日時以降に発行された患者の処方を取得（処方医を含む）
+func (r *prescriptionRepository) FindByPatientSince(patientID uint, since time.Time) ([]models.Prescription, error) {
+	var prescriptions []models.Prescription
+	err := r.db.Preload("CreatedByDoctor.DoctorProfile").
+		Joins("JOIN appointments ON prescriptions.appointment_id = appointments.id").
+		Where("appointments.patient_id = ? AND prescriptions.created_at >= ?", patientID, since).
+		Order("prescriptions.created_at DESC").
+		Find(&prescriptions).Error
+	return prescriptions, err
+}
+
+// Acknowledge 患者の確認を記録する（確認済みの場合はfalse）
+func (r *prescriptionRepository) Acknowledge(prescriptionID uint, acknowledgedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.Prescription{}).
+		Where("id = ? AND acknowledged_at IS NULL", prescriptionID).
+		Update("acknowledged_at", acknowledgedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindUnacknowledgedByPatient 患者が未確認の処方を取得（処方医を含む）
+func (r *prescriptionRepository) FindUnacknowledgedByPatient(patientID uint) ([]models.Prescription, error) {
+	var prescriptions []models.Prescription
+	err := r.db.Preload("Appointment").Preload("CreatedByDoctor.DoctorProfile").
+		Joins("JOIN appointments ON prescriptions.appointment_id = appointments.id").
+		Where("appointments.patient_id = ? AND prescriptions.acknowledged_at IS NULL", patientID).
+		Order("prescriptions.created_at ASC").
+		Find(&prescriptions).Error
+	return prescriptions, err
+}
+
+// FindAckReminderDue 最後の通知から指定時刻を過ぎても未確認で、再通知の上限に達していない処方を取得
+func (r *prescriptionRepository) FindAckReminderDue(notifiedBefore time.Time, maxReminders int) ([]models.Prescription, error) {
+	var prescriptions []models.Prescription
+	err := r.db.Preload("Appointment").
+		Where("acknowledged_at IS NULL AND last_notified_at < ? AND ack_reminder_count < ?", notifiedBefore, maxReminders).
+		Order("last_notified_at ASC").
+		Find(&prescriptions).Error
+	return prescriptions, err
+}
+
+// MarkAckReminded 再通知の日時と回数を記録する
+func (r *prescriptionRepository) MarkAckReminded(prescriptionID uint, notifiedAt time.Time) error {
+	return r.db.Model(&models.Prescription{}).
+		Where("id = ?", prescriptionID).
+		Updates(map[string]interface{}{
+			"last_notified_at":   notifiedAt,
+			"ack_reminder_count": gorm.Expr("ack_reminder_count + 1"),
+		}).Error
+}
+
+// AssignVerificationCode 照合コードの設定（未設定の処方のみ、設定済みの場合はfalse）
+func (r *prescriptionRepository) AssignVerificationCode(prescriptionID uint, code string) (bool, error) {
+	result := r.db.Model(&models.Prescription{}).
+		Where("id = ? AND verification_code IS NULL", prescriptionID).
+		Update("verification_code", code)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindByVerificationCode 照合コードで処方を取得（取り消し済みの処方を含む、該当なしはnil）
+func (r *prescriptionRepository) FindByVerificationCode(code string) (*models.Prescription, error) {
+	var prescription models.Prescription
+	if err := r.db.Unscoped().Where("verification_code = ?", code).First(&prescription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &prescription, nil
+}
@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type PROMRepository interface {
+	Create(assignment *models.PROMAssignment) error
+	FindByID(id uint) (*models.PROMAssignment, error)
+	FindByAppointmentID(appointmentID uint) ([]models.PROMAssignment, error)
+	FindByPatientID(patientID uint, status string, limit int) ([]models.PROMAssignment, error)
+	ExistsPending(patientID uint, dependentID *uint, instrument string) (bool, error)
+	FindCompleted(patientID uint, dependentID *uint, instrument string) ([]models.PROMAssignment, error)
+	FindLatestCompleted(patientID uint, dependentID *uint, instrument string) (*models.PROMAssignment, error)
+	Complete(assignment *models.PROMAssignment) (bool, error)
+	Cancel(id uint) (bool, error)
+}
+
+type promRepository struct {
+	db *gorm.DB
+}
+
+func NewPROMRepository(db *gorm.DB) PROMRepository {
+	return &promRepository{
+		db: db,
+	}
+}
+
+func (r *promRepository) Create(assignment *models.PROMAssignment) error {
+	return r.db.Create(assignment).Error
+}
+
+func (r *promRepository) FindByID(id uint) (*models.PROMAssignment, error) {
+	var assignment models.PROMAssignment
+	if err := r.db.First(&assignment, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// FindByAppointmentID 予約で割り当てた質問票（新しい順）
+func (r *promRepository) FindByAppointmentID(appointmentID uint) ([]models.PROMAssignment, error) {
+	var assignments []models.PROMAssignment
+	err := r.db.Where("appointment_id = ?", appointmentID).
+		Order("created_at DESC, id DESC").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+// FindByPatientID 患者（家族の分を含む）に割り当てた質問票（未回答は期限の早い順、それ以外は新しい順）
+func (r *promRepository) FindByPatientID(patientID uint, status string, limit int) ([]models.PROMAssignment, error) {
+	query := r.db.Where("patient_id = ?", patientID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var assignments []models.PROMAssignment
+	err := query.Order("CASE WHEN status = 'pending' THEN 0 ELSE 1 END, due_at ASC NULLS LAST, created_at DESC").
+		Limit(limit).
+		Find(&assignments).Error
+	return assignments, err
+}
+
+// ExistsPending 同じ患者（家族）に未回答の同じ質問票があるか
+func (r *promRepository) ExistsPending(patientID uint, dependentID *uint, instrument string) (bool, error) {
+	var count int64
+	err := r.forSubject(patientID, dependentID).
+		Model(&models.PROMAssignment{}).
+		Where("instrument = ? AND status = ?", instrument, "pending").
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindCompleted 患者（家族）の回答済みの質問票（回答日時の古い順、点数の推移の表示用）
+// instrumentが空の場合はすべての質問票
+func (r *promRepository) FindCompleted(patientID uint, dependentID *uint, instrument string) ([]models.PROMAssignment, error) {
+	query := r.forSubject(patientID, dependentID).Where("status = ?", "completed")
+	if instrument != "" {
+		query = query.Where("instrument = ?", instrument)
+	}
+
+	var assignments []models.PROMAssignment
+	err := query.Order("completed_at ASC, id ASC").Find(&assignments).Error
+	return assignments, err
+}
+
+// FindLatestCompleted 患者（家族）の同じ質問票の直近の回答（未回答の場合はnil）
+func (r *promRepository) FindLatestCompleted(patientID uint, dependentID *uint, instrument string) (*models.PROMAssignment, error) {
+	var assignment models.PROMAssignment
+	err := r.forSubject(patientID, dependentID).
+		Where("instrument = ? AND status = ?", instrument, "completed").
+		Order("completed_at DESC, id DESC").
+		First(&assignment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// Complete 回答と採点結果の保存（未回答のもののみ、回答済み・取り消し済みの場合はfalse）
+func (r *promRepository) Complete(assignment *models.PROMAssignment) (bool, error) {
+	result := r.db.Model(&models.PROMAssignment{}).
+		Where("id = ? AND status = ?", assignment.ID, "pending").
+		Updates(map[string]interface{}{
+			"status":        "completed",
+			"answers_json":  assignment.AnswersJSON,
+			"score":         assignment.Score,
+			"severity":      assignment.Severity,
+			"alert_reasons": assignment.AlertReasons,
+			"completed_at":  assignment.CompletedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Cancel 割り当ての取り消し（未回答のもののみ）
+func (r *promRepository) Cancel(id uint) (bool, error) {
+	result := r.db.Model(&models.PROMAssignment{}).
+		Where("id = ? AND status = ?", id, "pending").
+		Update("status", "cancelled")
+	return result.RowsAffected > 0, result.Error
+}
+
+// forSubject 患者本人または家族の条件
+func (r *promRepository) forSubject(patientID uint, dependentID *uint) *gorm.DB {
+	query := r.db.Where("patient_id = ?", patientID)
+	if dependentID != nil {
+		return query.Where("dependent_id = ?", *dependentID)
+	}
+	return query.Where("dependent_id IS NULL")
+}
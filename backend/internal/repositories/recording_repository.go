@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type RecordingRepository interface {
+	Create(recording *models.Recording) error
+	FindByID(id uint) (*models.Recording, error)
+	FindBySession(videoSessionID uint) ([]models.Recording, error)
+	Stop(id uint, stoppedByID *uint, reason string, at time.Time) (bool, error)
+	StopInProgressBySession(videoSessionID uint, reason string, at time.Time) ([]models.Recording, error)
+	MarkAvailable(recording *models.Recording) (bool, error)
+}
+
+type recordingRepository struct {
+	db *gorm.DB
+}
+
+func NewRecordingRepository(db *gorm.DB) RecordingRepository {
+	return &recordingRepository{
+		db: db,
+	}
+}
+
+func (r *recordingRepository) Create(recording *models.Recording) error {
+	return r.db.Create(recording).Error
+}
+
+// FindByID 録画の取得（ない場合はnil）
+func (r *recordingRepository) FindByID(id uint) (*models.Recording, error) {
+	var recording models.Recording
+	err := r.db.First(&recording, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &recording, nil
+}
+
+// FindBySession セッションの録画の一覧（開始順）
+func (r *recordingRepository) FindBySession(videoSessionID uint) ([]models.Recording, error) {
+	var recordings []models.Recording
+	err := r.db.Where("video_session_id = ?", videoSessionID).
+		Order("started_at ASC, id ASC").
+		Find(&recordings).Error
+	return recordings, err
+}
+
+// Stop 録画中の録画の停止（既に停止済みの場合はfalse）
+func (r *recordingRepository) Stop(id uint, stoppedByID *uint, reason string, at time.Time) (bool, error) {
+	result := r.db.Model(&models.Recording{}).
+		Where("id = ? AND status = ?", id, models.RecordingInProgress).
+		Updates(map[string]interface{}{
+			"status":        models.RecordingStopped,
+			"stopped_by_id": stoppedByID,
+			"stopped_at":    at,
+			"stop_reason":   reason,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// StopInProgressBySession セッションの録画中の録画の停止（同意の撤回・セッションの終了時、停止した録画を返す）
+func (r *recordingRepository) StopInProgressBySession(videoSessionID uint, reason string, at time.Time) ([]models.Recording, error) {
+	var recordings []models.Recording
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_session_id = ? AND status = ?", videoSessionID, models.RecordingInProgress).
+			Find(&recordings).Error; err != nil {
+			return err
+		}
+		if len(recordings) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(recordings))
+		for i := range recordings {
+			ids[i] = recordings[i].ID
+			recordings[i].Status = models.RecordingStopped
+			recordings[i].StoppedAt = &at
+			recordings[i].StopReason = reason
+		}
+		return tx.Model(&models.Recording{}).
+			Where("id IN ? AND status = ?", ids, models.RecordingInProgress).
+			Updates(map[string]interface{}{
+				"status":      models.RecordingStopped,
+				"stopped_at":  at,
+				"stop_reason": reason,
+			}).Error
+	})
+	return recordings, err
+}
+
+// MarkAvailable 停止済みの録画へのファイルの保存の記録（ファイルを保存済みの場合はfalse）
+func (r *recordingRepository) MarkAvailable(recording *models.Recording) (bool, error) {
+	result := r.db.Model(&models.Recording{}).
+		Where("id = ? AND status = ?", recording.ID, models.RecordingStopped).
+		Updates(map[string]interface{}{
+			"status":       models.RecordingAvailable,
+			"storage":      recording.Storage,
+			"object_key":   recording.ObjectKey,
+			"content_type": recording.ContentType,
+			"size_bytes":   recording.SizeBytes,
+			"checksum":     recording.Checksum,
+			"uploaded_at":  recording.UploadedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type ReportSubscriptionRepository interface {
+	Create(subscription *models.ReportSubscription) error
+	FindByID(id uint) (*models.ReportSubscription, error)
+	FindByUser(userID uint) ([]models.ReportSubscription, error)
+	Update(subscription *models.ReportSubscription) error
+	Delete(id uint) error
+	FindDue(now time.Time, limit int) ([]models.ReportSubscription, error)
+	ClaimRun(id uint, scheduledAt, nextRunAt time.Time) (bool, error)
+	RecordDelivery(id uint, sentAt *time.Time, lastError string) error
+}
+
+type reportSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewReportSubscriptionRepository(db *gorm.DB) ReportSubscriptionRepository {
+	return &reportSubscriptionRepository{
+		db: db,
+	}
+}
+
+func (r *reportSubscriptionRepository) Create(subscription *models.ReportSubscription) error {
+	return r.db.Create(subscription).Error
+}
+
+// FindByID 配信の登録の取得（ない場合はnil）
+func (r *reportSubscriptionRepository) FindByID(id uint) (*models.ReportSubscription, error) {
+	var subscription models.ReportSubscription
+	err := r.db.First(&subscription, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// FindByUser 利用者の配信の登録の一覧（登録順）
+func (r *reportSubscriptionRepository) FindByUser(userID uint) ([]models.ReportSubscription, error) {
+	var subscriptions []models.ReportSubscription
+	err := r.db.Where("user_id = ?", userID).Order("created_at ASC, id ASC").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *reportSubscriptionRepository) Update(subscription *models.ReportSubscription) error {
+	return r.db.Save(subscription).Error
+}
+
+func (r *reportSubscriptionRepository) Delete(id uint) error {
+	return r.db.Delete(&models.ReportSubscription{}, id).Error
+}
+
+// FindDue 配信日時を過ぎた有効な登録を取得
+func (r *reportSubscriptionRepository) FindDue(now time.Time, limit int) ([]models.ReportSubscription, error) {
+	var subscriptions []models.ReportSubscription
+	err := r.db.Where("active = ? AND next_run_at <= ?", true, now).
+		Order("next_run_at ASC").
+		Limit(limit).
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// ClaimRun 次回の配信日時へ進める（他のジョブが先に処理した場合はfalse）
+func (r *reportSubscriptionRepository) ClaimRun(id uint, scheduledAt, nextRunAt time.Time) (bool, error) {
+	result := r.db.Model(&models.ReportSubscription{}).
+		Where("id = ? AND next_run_at = ?", id, scheduledAt).
+		Update("next_run_at", nextRunAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+// RecordDelivery 配信の結果の記録（失敗した場合は sentAt をnilにして前回の配信日時を残す）
+func (r *reportSubscriptionRepository) RecordDelivery(id uint, sentAt *time.Time, lastError string) error {
+	updates := map[string]interface{}{"last_error": lastError}
+	if sentAt != nil {
+		updates["last_sent_at"] = *sentAt
+	}
+	return r.db.Model(&models.ReportSubscription{}).Where("id = ?", id).Updates(updates).Error
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type SavedFilterRepository interface {
+	Create(filter *models.SavedFilter) error
+	FindByID(id uint) (*models.SavedFilter, error)
+	FindByUser(userID uint, scope string) ([]models.SavedFilter, error)
+	CountByUser(userID uint, scope string) (int64, error)
+	Update(filter *models.SavedFilter) error
+	Delete(id uint) error
+}
+
+type savedFilterRepository struct {
+	db *gorm.DB
+}
+
+func NewSavedFilterRepository(db *gorm.DB) SavedFilterRepository {
+	return &savedFilterRepository{
+		db: db,
+	}
+}
+
+func (r *savedFilterRepository) Create(filter *models.SavedFilter) error {
+	return r.db.Create(filter).Error
+}
+
+// FindByID 保存した検索条件の取得（ない場合はnil）
+func (r *savedFilterRepository) FindByID(id uint) (*models.SavedFilter, error) {
+	var filter models.SavedFilter
+	err := r.db.First(&filter, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &filter, nil
+}
+
+// FindByUser 利用者の保存した検索条件の一覧（scope が空の場合はすべての対象、名前順）
+func (r *savedFilterRepository) FindByUser(userID uint, scope string) ([]models.SavedFilter, error) {
+	var filters []models.SavedFilter
+	query := r.db.Where("user_id = ?", userID)
+	if scope != "" {
+		query = query.Where("scope = ?", scope)
+	}
+	err := query.Order("name ASC, id ASC").Find(&filters).Error
+	return filters, err
+}
+
+// CountByUser 利用者の対象ごとの保存した検索条件の件数
+func (r *savedFilterRepository) CountByUser(userID uint, scope string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.SavedFilter{}).Where("user_id = ? AND scope = ?", userID, scope).Count(&count).Error
+	return count, err
+}
+
+func (r *savedFilterRepository) Update(filter *models.SavedFilter) error {
+	return r.db.Save(filter).Error
+}
+
+func (r *savedFilterRepository) Delete(id uint) error {
+	return r.db.Delete(&models.SavedFilter{}, id).Error
+}
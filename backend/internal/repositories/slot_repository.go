@@ -9,10 +9,18 @@ import (
 type SlotRepository interface {
 	Create(slot *models.AvailabilitySlot) error
 	FindByID(id uint) (*models.AvailabilitySlot, error)
-	FindByDoctorID(doctorID uint) ([]models.AvailabilitySlot, error)
+	FindPageByDoctorID(doctorID uint, endedAfter time.Time, limit, offset int) ([]models.AvailabilitySlot, int64, error)
+	FindOverlapping(doctorID uint, start, end time.Time) ([]models.AvailabilitySlot, error)
 	FindAvailableByDoctorIDAndDate(doctorID uint, startDate, endDate time.Time) ([]models.AvailabilitySlot, error)
 	Update(slot *models.AvailabilitySlot) error
 	Delete(id uint) error
+	BlockInRange(doctorID uint, start, end time.Time) (int64, error)
+	FindInRangeWithBookings(start, end time.Time, doctorID uint) ([]models.AvailabilitySlot, error)
+	FindInRangeWithBookingStatus(start, end time.Time, doctorID uint) ([]models.AvailabilitySlot, error)
+	FindStaleOpen(doctorID uint, endedBefore time.Time) ([]models.AvailabilitySlot, error)
+	Restore(id uint) (bool, error)
+	BlockIfOpen(id uint) (bool, error)
+	Release(id uint) (bool, error)
 }
 
 type slotRepository struct {
@@ -37,12 +45,30 @@ func (r *slotRepository) FindByID(id uint) (*models.AvailabilitySlot, error) {
 	return &slot, nil
 }
 
-func (r *slotRepository) FindByDoctorID(doctorID uint) ([]models.AvailabilitySlot, error) {
-	var slots []models.AvailabilitySlot
-	if err := r.db.Where("doctor_id = ?", doctorID).Find(&slots).Error; err != nil {
-		return nil, err
+// FindPageByDoctorID 医師の診療枠（開始時刻順）の指定範囲と総件数（endedAfterを指定した場合はそれ以降に終了する枠のみ）
+func (r *slotRepository) FindPageByDoctorID(doctorID uint, endedAfter time.Time, limit, offset int) ([]models.AvailabilitySlot, int64, error) {
+	query := r.db.Model(&models.AvailabilitySlot{}).Where("doctor_id = ?", doctorID)
+	if !endedAfter.IsZero() {
+		query = query.Where("end_time > ?", endedAfter)
 	}
-	return slots, nil
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var slots []models.AvailabilitySlot
+	err := query.Order("start_time ASC, id ASC").Limit(limit).Offset(offset).Find(&slots).Error
+	return slots, total, err
+}
+
+// FindOverlapping 指定期間と重なる医師の診療枠（状態を問わない）
+func (r *slotRepository) FindOverlapping(doctorID uint, start, end time.Time) ([]models.AvailabilitySlot, error) {
+	var slots []models.AvailabilitySlot
+	err := r.db.Where("doctor_id = ? AND start_time < ? AND end_time > ?", doctorID, end, start).
+		Order("start_time ASC").
+		Find(&slots).Error
+	return slots, err
 }
 
 func (r *slotRepository) FindAvailableByDoctorIDAndDate(doctorID uint, startDate, endDate time.Time) ([]models.AvailabilitySlot, error) {
@@ -61,3 +87,84 @@ func (r *slotRepository) Update(slot *models.AvailabilitySlot) error {
 func (r *slotRepository) Delete(id uint) error {
 	return r.db.Delete(&models.AvailabilitySlot{}, id).Error
 }
+
+// BlockInRange 指定期間と重なる医師の公開中の診療枠を停止する
+func (r *slotRepository) BlockInRange(doctorID uint, start, end time.Time) (int64, error) {
+	result := r.db.Model(&models.AvailabilitySlot{}).
+		Where("doctor_id = ? AND status = ? AND start_time < ? AND end_time > ?", doctorID, "open", end, start).
+		Update("status", "blocked")
+	return result.RowsAffected, result.Error
+}
+
+// FindInRangeWithBookings 指定期間に開始する診療枠を、キャンセルされていない予約とあわせて取得
+// doctorIDが0の場合は全医師が対象
+func (r *slotRepository) FindInRangeWithBookings(start, end time.Time, doctorID uint) ([]models.AvailabilitySlot, error) {
+	query := r.db.Preload("Appointment", "status <> ?", "cancelled").
+		Preload("Appointment.Patient.PatientProfile").
+		Preload("Appointment.Dependent").
+		Where("start_time >= ? AND start_time < ?", start, end)
+	if doctorID != 0 {
+		query = query.Where("doctor_id = ?", doctorID)
+	}
+
+	var slots []models.AvailabilitySlot
+	err := query.Order("doctor_id ASC, start_time ASC").Find(&slots).Error
+	return slots, err
+}
+
+// FindInRangeWithBookingStatus 指定期間に開始する診療枠を、キャンセルされていない予約の有無とあわせて取得
+// doctorIDが0の場合はデモアカウントを除く全医師が対象
+func (r *slotRepository) FindInRangeWithBookingStatus(start, end time.Time, doctorID uint) ([]models.AvailabilitySlot, error) {
+	query := r.db.Preload("Appointment", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "slot_id", "status").Where("status <> ?", "cancelled")
+	}).Where("start_time >= ? AND start_time < ?", start, end)
+	if doctorID != 0 {
+		query = query.Where("doctor_id = ?", doctorID)
+	} else {
+		query = query.Where("doctor_id IN (?)", r.db.Model(&models.User{}).Select("id").Where("is_demo = ?", false))
+	}
+
+	var slots []models.AvailabilitySlot
+	err := query.Order("doctor_id ASC, start_time ASC").Find(&slots).Error
+	return slots, err
+}
+
+// FindStaleOpen 終了時刻を過ぎても公開中のまま、キャンセルされていない予約のない医師の診療枠を取得
+func (r *slotRepository) FindStaleOpen(doctorID uint, endedBefore time.Time) ([]models.AvailabilitySlot, error) {
+	var slots []models.AvailabilitySlot
+	err := r.db.Where("doctor_id = ? AND status = ? AND end_time < ?", doctorID, "open", endedBefore).
+		Where("NOT EXISTS (?)", r.db.Model(&models.Appointment{}).Select("1").
+			Where("appointments.slot_id = availability_slots.id AND appointments.status <> ?", "cancelled")).
+		Order("start_time ASC").
+		Find(&slots).Error
+	return slots, err
+}
+
+// Restore 削除済みの診療枠の復元（削除されていない場合はfalse）
+func (r *slotRepository) Restore(id uint) (bool, error) {
+	result := r.db.Unscoped().Model(&models.AvailabilitySlot{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	return result.RowsAffected > 0, result.Error
+}
+
+// BlockIfOpen 公開中の診療枠の停止（公開中でない場合はfalse）
+func (r *slotRepository) BlockIfOpen(id uint) (bool, error) {
+	result := r.db.Model(&models.AvailabilitySlot{}).
+		Where("id = ? AND status = ?", id, "open").
+		Update("status", "blocked")
+	return result.RowsAffected > 0, result.Error
+}
+
+// Release 予約をキャンセルした診療枠を公開中に戻す（医師の休暇と重なる枠は停止中にする）
+// 予約済みでない場合や、キャンセルされていない予約が残っている場合はfalse
+func (r *slotRepository) Release(id uint) (bool, error) {
+	timeOff := r.db.Model(&models.DoctorTimeOff{}).Select("1").
+		Where("doctor_time_offs.doctor_id = availability_slots.doctor_id AND doctor_time_offs.start_time < availability_slots.end_time AND doctor_time_offs.end_time > availability_slots.start_time")
+	result := r.db.Model(&models.AvailabilitySlot{}).
+		Where("id = ? AND status = ?", id, "booked").
+		Where("NOT EXISTS (?)", r.db.Model(&models.Appointment{}).Select("1").
+			Where("appointments.slot_id = availability_slots.id AND appointments.status <> ?", "cancelled")).
+		Update("status", gorm.Expr("CASE WHEN EXISTS (?) THEN 'blocked' ELSE 'open' END", timeOff))
+	return result.RowsAffected > 0, result.Error
+}
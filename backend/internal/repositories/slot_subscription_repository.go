@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+// SlotOpeningDue 通知の対象となる登録と、前回の通知以降に公開された空き枠
+type SlotOpeningDue struct {
+	SubscriptionID uint
+	NewSlots       int64
+	FirstStart     time.Time
+}
+
+type SlotSubscriptionRepository interface {
+	Create(subscription *models.SlotSubscription) error
+	FindByPatientAndDoctor(patientID, doctorID uint) (*models.SlotSubscription, error)
+	FindByPatientID(patientID uint) ([]models.SlotSubscription, error)
+	FindByIDs(ids []uint) ([]models.SlotSubscription, error)
+	DeleteForPatient(id, patientID uint) (bool, error)
+	DeleteByToken(token string) (*models.SlotSubscription, error)
+	FindDue(now, settledBefore, notifiedBefore time.Time, limit int) ([]SlotOpeningDue, error)
+	MarkNotified(id uint, notifiedBefore, through time.Time) (bool, error)
+}
+
+type slotSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewSlotSubscriptionRepository(db *gorm.DB) SlotSubscriptionRepository {
+	return &slotSubscriptionRepository{
+		db: db,
+	}
+}
+
+func (r *slotSubscriptionRepository) Create(subscription *models.SlotSubscription) error {
+	return r.db.Create(subscription).Error
+}
+
+// FindByPatientAndDoctor 患者の医師への登録を取得（ない場合はnil）
+func (r *slotSubscriptionRepository) FindByPatientAndDoctor(patientID, doctorID uint) (*models.SlotSubscription, error) {
+	var subscription models.SlotSubscription
+	err := r.db.Where("patient_id = ? AND doctor_id = ?", patientID, doctorID).First(&subscription).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *slotSubscriptionRepository) FindByPatientID(patientID uint) ([]models.SlotSubscription, error) {
+	var subscriptions []models.SlotSubscription
+	err := r.db.Preload("Doctor.DoctorProfile").
+		Where("patient_id = ?", patientID).
+		Order("created_at DESC").
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *slotSubscriptionRepository) FindByIDs(ids []uint) ([]models.SlotSubscription, error) {
+	var subscriptions []models.SlotSubscription
+	if len(ids) == 0 {
+		return subscriptions, nil
+	}
+	err := r.db.Preload("Doctor.DoctorProfile").Where("id IN ?", ids).Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// DeleteForPatient 患者本人による登録の解除（本人の登録でない場合はfalse）
+func (r *slotSubscriptionRepository) DeleteForPatient(id, patientID uint) (bool, error) {
+	result := r.db.Where("id = ? AND patient_id = ?", id, patientID).Delete(&models.SlotSubscription{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteByToken 登録解除リンクによる解除（該当する登録がない場合はnil）
+func (r *slotSubscriptionRepository) DeleteByToken(token string) (*models.SlotSubscription, error) {
+	var subscription models.SlotSubscription
+	err := r.db.Where("unsubscribe_token = ?", token).First(&subscription).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.Delete(&subscription).Error; err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// FindDue 通知済みの時刻（初回は登録）以降に公開された予約のない今後の空き枠がある登録を取得
+// settledBefore以前に作成された枠のみ数え（続けて作成中の枠はまとめて通知する）、通知済みの時刻がnotifiedBefore以前の登録のみ対象（通知の最短間隔）
+func (r *slotSubscriptionRepository) FindDue(now, settledBefore, notifiedBefore time.Time, limit int) ([]SlotOpeningDue, error) {
+	var due []SlotOpeningDue
+	err := r.db.Table("slot_subscriptions").
+		Select("slot_subscriptions.id AS subscription_id, COUNT(availability_slots.id) AS new_slots, MIN(availability_slots.start_time) AS first_start").
+		Joins("JOIN availability_slots ON availability_slots.doctor_id = slot_subscriptions.doctor_id AND availability_slots.deleted_at IS NULL").
+		Where("availability_slots.status = ? AND availability_slots.start_time > ?", "open", now).
+		Where("availability_slots.created_at > COALESCE(slot_subscriptions.notified_through, slot_subscriptions.created_at) AND availability_slots.created_at <= ?", settledBefore).
+		Where("slot_subscriptions.notified_through IS NULL OR slot_subscriptions.notified_through <= ?", notifiedBefore).
+		Where("NOT EXISTS (?)", r.db.Model(&models.Appointment{}).Select("1").
+			Where("appointments.slot_id = availability_slots.id AND appointments.status <> ?", "cancelled")).
+		Group("slot_subscriptions.id").
+		Order("slot_subscriptions.id ASC").
+		Limit(limit).
+		Scan(&due).Error
+	return due, err
+}
+
+// MarkNotified throughまでに作成された枠を通知済みとして記録する（他の処理が先に通知した場合はfalse）
+func (r *slotSubscriptionRepository) MarkNotified(id uint, notifiedBefore, through time.Time) (bool, error) {
+	result := r.db.Model(&models.SlotSubscription{}).
+		Where("id = ? AND (notified_through IS NULL OR notified_through <= ?)", id, notifiedBefore).
+		Update("notified_through", through)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type SlotTemplateRepository interface {
+	Create(template *models.SlotTemplate) error
+	FindByDoctorID(doctorID uint) ([]models.SlotTemplate, error)
+	FindAll() ([]models.SlotTemplate, error)
+	DeleteForDoctor(id, doctorID uint) (bool, error)
+	MarkMaterialized(id uint, previous *time.Time, through time.Time) (bool, error)
+}
+
+type slotTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewSlotTemplateRepository(db *gorm.DB) SlotTemplateRepository {
+	return &slotTemplateRepository{
+		db: db,
+	}
+}
+
+func (r *slotTemplateRepository) Create(template *models.SlotTemplate) error {
+	return r.db.Create(template).Error
+}
+
+// FindByDoctorID 医師のひな形を作成順に取得
+func (r *slotTemplateRepository) FindByDoctorID(doctorID uint) ([]models.SlotTemplate, error) {
+	var templates []models.SlotTemplate
+	err := r.db.Where("doctor_id = ?", doctorID).Order("id ASC").Find(&templates).Error
+	return templates, err
+}
+
+// FindAll 診療枠の作成対象となる全医師のひな形（退会した医師を除く）
+func (r *slotTemplateRepository) FindAll() ([]models.SlotTemplate, error) {
+	var templates []models.SlotTemplate
+	err := r.db.Where("doctor_id IN (?)", r.db.Model(&models.User{}).Select("id").Where("role = ? AND deactivated_at IS NULL", "doctor")).
+		Order("id ASC").
+		Find(&templates).Error
+	return templates, err
+}
+
+// DeleteForDoctor 医師本人のひな形の削除（該当しない場合はfalse）
+func (r *slotTemplateRepository) DeleteForDoctor(id, doctorID uint) (bool, error) {
+	result := r.db.Where("id = ? AND doctor_id = ?", id, doctorID).Delete(&models.SlotTemplate{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkMaterialized 診療枠を作成した期間の記録（他のインスタンスが先に記録した場合はfalse）
+func (r *slotTemplateRepository) MarkMaterialized(id uint, previous *time.Time, through time.Time) (bool, error) {
+	query := r.db.Model(&models.SlotTemplate{}).Where("id = ?", id)
+	if previous == nil {
+		query = query.Where("materialized_through IS NULL")
+	} else {
+		query = query.Where("materialized_through = ?", *previous)
+	}
+	result := query.Update("materialized_through", through)
+	return result.RowsAffected > 0, result.Error
+}
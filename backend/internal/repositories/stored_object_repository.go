@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type StoredObjectRepository interface {
+	AddReference(storage, checksum string) (*models.StoredObject, error)
+	Create(object *models.StoredObject) (bool, error)
+	ReleaseReference(storage, objectKey string) (bool, error)
+}
+
+type storedObjectRepository struct {
+	db *gorm.DB
+}
+
+func NewStoredObjectRepository(db *gorm.DB) StoredObjectRepository {
+	return &storedObjectRepository{
+		db: db,
+	}
+}
+
+// AddReference 同じ内容の保存済みのファイルへの参照の追加（ない場合はnil）
+func (r *storedObjectRepository) AddReference(storage, checksum string) (*models.StoredObject, error) {
+	var objects []models.StoredObject
+	err := r.db.Model(&objects).
+		Clauses(clause.Returning{}).
+		Where("storage = ? AND checksum = ?", storage, checksum).
+		Update("ref_count", gorm.Expr("ref_count + 1")).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, nil
+	}
+	return &objects[0], nil
+}
+
+// Create 保存したファイルの記録（同じ内容のファイルが同時に記録された場合はfalse）
+func (r *storedObjectRepository) Create(object *models.StoredObject) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "storage"}, {Name: "checksum"}},
+		DoNothing: true,
+	}).Create(object)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ReleaseReference ファイルへの参照の解除（参照がなくなった、または記録がないファイルの場合はtrue）
+// 参照がなくなった記録は削除し、ファイルの削除は呼び出し側で行う
+func (r *storedObjectRepository) ReleaseReference(storage, objectKey string) (bool, error) {
+	unreferenced := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var object models.StoredObject
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("storage = ? AND object_key = ?", storage, objectKey).
+			First(&object).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 重複を除く前に保存したファイルは参照元が1つのみ
+			unreferenced = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if object.RefCount > 1 {
+			return tx.Model(&object).Update("ref_count", gorm.Expr("ref_count - 1")).Error
+		}
+		unreferenced = true
+		return tx.Delete(&object).Error
+	})
+	return unreferenced, err
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type SubscriptionRepository interface {
+	FindByPatient(patientID uint) (*models.PatientSubscription, error)
+	CreatePayment(payment *models.SubscriptionPayment) error
+	FindPaymentByIntentID(intentID string) (*models.SubscriptionPayment, error)
+	FindOpenPayment(patientID uint) (*models.SubscriptionPayment, error)
+	CountPayments(patientID uint) (int64, error)
+	UpdatePaymentStatus(id uint, status, failureMessage string) (bool, error)
+	ExtendForPayment(payment *models.SubscriptionPayment, period time.Duration, paidAt time.Time) (*models.PatientSubscription, bool, error)
+}
+
+type subscriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewSubscriptionRepository(db *gorm.DB) SubscriptionRepository {
+	return &subscriptionRepository{
+		db: db,
+	}
+}
+
+// FindByPatient 患者の有料プランの契約（契約していない場合はnil）
+func (r *subscriptionRepository) FindByPatient(patientID uint) (*models.PatientSubscription, error) {
+	var subscription models.PatientSubscription
+	err := r.db.Where("patient_id = ?", patientID).First(&subscription).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *subscriptionRepository) CreatePayment(payment *models.SubscriptionPayment) error {
+	return r.db.Create(payment).Error
+}
+
+// FindPaymentByIntentID PaymentIntent の支払いの取得（ない場合はnil）
+func (r *subscriptionRepository) FindPaymentByIntentID(intentID string) (*models.SubscriptionPayment, error) {
+	var payment models.SubscriptionPayment
+	err := r.db.Where("provider_intent_id = ?", intentID).First(&payment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// FindOpenPayment 患者の支払い待ちの最新の支払い（ない場合はnil）
+func (r *subscriptionRepository) FindOpenPayment(patientID uint) (*models.SubscriptionPayment, error) {
+	var payment models.SubscriptionPayment
+	err := r.db.Where("patient_id = ? AND status = ?", patientID, "requires_payment").
+		Order("created_at DESC, id DESC").
+		First(&payment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// CountPayments 患者の有料プランの支払いの件数（重複作成を防ぐキーに使う）
+func (r *subscriptionRepository) CountPayments(patientID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.SubscriptionPayment{}).Where("patient_id = ?", patientID).Count(&count).Error
+	return count, err
+}
+
+// UpdatePaymentStatus 支払いの状態の更新
+// Webhookは順不同・重複して届くため、完了した支払いと同じ状態への更新は行わずfalseを返す
+func (r *subscriptionRepository) UpdatePaymentStatus(id uint, status, failureMessage string) (bool, error) {
+	result := r.db.Model(&models.SubscriptionPayment{}).
+		Where("id = ? AND status <> ? AND status <> ?", id, "succeeded", status).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"failure_message": failureMessage,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ExtendForPayment 支払いの完了による有料プランの利用期間の延長（期限内の場合は期限から、期限切れの場合は支払った日時から延長する）
+// 延長は支払いごとに1回のみで、延長済みの支払いの場合は現在の契約とfalseを返す
+func (r *subscriptionRepository) ExtendForPayment(payment *models.SubscriptionPayment, period time.Duration, paidAt time.Time) (*models.PatientSubscription, bool, error) {
+	var subscription models.PatientSubscription
+	extended := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.SubscriptionPayment{}).
+			Where("id = ? AND paid_at IS NULL", payment.ID).
+			Update("paid_at", paidAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		extended = result.RowsAffected > 0
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("patient_id = ?", payment.PatientID).
+			First(&subscription).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if !extended {
+			return nil
+		}
+
+		start := paidAt
+		if subscription.ActiveUntil.After(start) {
+			start = subscription.ActiveUntil
+		}
+		subscription.PatientID = payment.PatientID
+		subscription.Plan = payment.Plan
+		subscription.ActiveUntil = start.Add(period)
+		return tx.Save(&subscription).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if subscription.ID == 0 {
+		return nil, false, nil
+	}
+	return &subscription, extended, nil
+}
@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type TagRepository interface {
+	Create(tag *models.Tag) error
+	FindByID(id uint) (*models.Tag, error)
+	FindByDoctor(doctorID uint) ([]models.Tag, error)
+	FindByDoctorAndName(doctorID uint, name string) (*models.Tag, error)
+	Update(tag *models.Tag) error
+	Delete(id uint) error
+	SetAppointmentTags(doctorID, appointmentID uint, tagIDs []uint) error
+	FindByAppointments(doctorID uint, appointmentIDs []uint) (map[uint][]models.Tag, error)
+	CreateView(view *models.AppointmentSavedView) error
+	FindViewByID(id uint) (*models.AppointmentSavedView, error)
+	FindViewsByDoctor(doctorID uint) ([]models.AppointmentSavedView, error)
+	DeleteView(id uint) error
+}
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{
+		db: db,
+	}
+}
+
+func (r *tagRepository) Create(tag *models.Tag) error {
+	return r.db.Create(tag).Error
+}
+
+// FindByID タグの取得（ない場合はnil）
+func (r *tagRepository) FindByID(id uint) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.First(&tag, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// FindByDoctor 医師のタグの一覧（名前順）
+func (r *tagRepository) FindByDoctor(doctorID uint) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.Where("doctor_id = ?", doctorID).Order("name ASC").Find(&tags).Error
+	return tags, err
+}
+
+// FindByDoctorAndName 医師の同名のタグの取得（ない場合はnil）
+func (r *tagRepository) FindByDoctorAndName(doctorID uint, name string) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.Where("doctor_id = ? AND name = ?", doctorID, name).First(&tag).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *tagRepository) Update(tag *models.Tag) error {
+	return r.db.Save(tag).Error
+}
+
+// Delete タグの削除（予約への付与も削除する）
+func (r *tagRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", id).Delete(&models.AppointmentTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Tag{}, id).Error
+	})
+}
+
+// SetAppointmentTags 予約に付けた医師のタグの置き換え（タグの所有者の確認は呼び出し側で行い、他の医師のタグは残す）
+func (r *tagRepository) SetAppointmentTags(doctorID, appointmentID uint, tagIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		remove := tx.Where("appointment_id = ? AND tag_id IN (?)", appointmentID,
+			tx.Model(&models.Tag{}).Select("id").Where("doctor_id = ?", doctorID))
+		if len(tagIDs) > 0 {
+			remove = remove.Where("tag_id NOT IN ?", tagIDs)
+		}
+		if err := remove.Delete(&models.AppointmentTag{}).Error; err != nil {
+			return err
+		}
+
+		if len(tagIDs) == 0 {
+			return nil
+		}
+		links := make([]models.AppointmentTag, len(tagIDs))
+		for i, tagID := range tagIDs {
+			links[i] = models.AppointmentTag{AppointmentID: appointmentID, TagID: tagID}
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error
+	})
+}
+
+// FindByAppointments 予約に付けた医師のタグ（予約IDごと、名前順）
+func (r *tagRepository) FindByAppointments(doctorID uint, appointmentIDs []uint) (map[uint][]models.Tag, error) {
+	result := make(map[uint][]models.Tag)
+	if len(appointmentIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		AppointmentID uint
+		models.Tag
+	}
+	err := r.db.Table("appointment_tags").
+		Select("appointment_tags.appointment_id, tags.*").
+		Joins("JOIN tags ON tags.id = appointment_tags.tag_id").
+		Where("appointment_tags.appointment_id IN ? AND tags.doctor_id = ?", appointmentIDs, doctorID).
+		Order("tags.name ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.AppointmentID] = append(result[row.AppointmentID], row.Tag)
+	}
+	return result, nil
+}
+
+func (r *tagRepository) CreateView(view *models.AppointmentSavedView) error {
+	return r.db.Create(view).Error
+}
+
+// FindViewByID 保存した絞り込み条件の取得（ない場合はnil）
+func (r *tagRepository) FindViewByID(id uint) (*models.AppointmentSavedView, error) {
+	var view models.AppointmentSavedView
+	err := r.db.First(&view, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// FindViewsByDoctor 医師の保存した絞り込み条件の一覧（作成順）
+func (r *tagRepository) FindViewsByDoctor(doctorID uint) ([]models.AppointmentSavedView, error) {
+	var views []models.AppointmentSavedView
+	err := r.db.Where("doctor_id = ?", doctorID).Order("created_at ASC, id ASC").Find(&views).Error
+	return views, err
+}
+
+func (r *tagRepository) DeleteView(id uint) error {
+	return r.db.Delete(&models.AppointmentSavedView{}, id).Error
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type TaskRepository interface {
+	Create(task *models.AppointmentTask) error
+	FindByID(id uint) (*models.AppointmentTask, error)
+	FindByAppointmentID(appointmentID uint) ([]models.AppointmentTask, error)
+	FindOpenByAssigneeID(assigneeID uint, limit int) ([]models.AppointmentTask, error)
+	FindDueReminders(now time.Time, limit int) ([]models.AppointmentTask, error)
+	MarkReminded(id uint, remindedAt time.Time) (bool, error)
+	Update(task *models.AppointmentTask) error
+	Delete(id uint) error
+}
+
+type taskRepository struct {
+	db *gorm.DB
+}
+
+func NewTaskRepository(db *gorm.DB) TaskRepository {
+	return &taskRepository{
+		db: db,
+	}
+}
+
+// Create タスクの作成
+func (r *taskRepository) Create(task *models.AppointmentTask) error {
+	return r.db.Omit("Appointment").Create(task).Error
+}
+
+// FindByID IDでタスクを取得
+func (r *taskRepository) FindByID(id uint) (*models.AppointmentTask, error) {
+	var task models.AppointmentTask
+	if err := r.db.First(&task, id).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// FindByAppointmentID 予約のタスク一覧を取得（未完了・期限の早い順）
+func (r *taskRepository) FindByAppointmentID(appointmentID uint) ([]models.AppointmentTask, error) {
+	var tasks []models.AppointmentTask
+	err := r.db.Where("appointment_id = ?", appointmentID).
+		Order("status ASC, due_at ASC NULLS LAST, created_at ASC").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// FindOpenByAssigneeID 担当者の未完了タスクを取得（期限の早い順）
+func (r *taskRepository) FindOpenByAssigneeID(assigneeID uint, limit int) ([]models.AppointmentTask, error) {
+	var tasks []models.AppointmentTask
+	err := r.db.Where("assignee_id = ? AND status = ?", assigneeID, "open").
+		Order("due_at ASC NULLS LAST, created_at ASC").
+		Limit(limit).
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// FindDueReminders リマインド時刻を過ぎた未通知・未完了のタスクを取得
+func (r *taskRepository) FindDueReminders(now time.Time, limit int) ([]models.AppointmentTask, error) {
+	var tasks []models.AppointmentTask
+	err := r.db.Where("status = ? AND remind_at <= ? AND reminded_at IS NULL", "open", now).
+		Order("remind_at ASC").
+		Limit(limit).
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// MarkReminded リマインド済みにする（他のジョブが先に処理した場合はfalse）
+func (r *taskRepository) MarkReminded(id uint, remindedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.AppointmentTask{}).
+		Where("id = ? AND reminded_at IS NULL", id).
+		Update("reminded_at", remindedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Update タスクの更新
+func (r *taskRepository) Update(task *models.AppointmentTask) error {
+	return r.db.Omit("Appointment").Save(task).Error
+}
+
+// Delete タスクの削除
+func (r *taskRepository) Delete(id uint) error {
+	return r.db.Delete(&models.AppointmentTask{}, id).Error
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"online_medical_consultation_app/backend/internal/database"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+// openTestDB テスト用のデータベース（TEST_DATABASE_URL のPostgreSQL、設定していない場合はスキップする）
+// マイグレーションを適用したうえでトランザクションを開始し、テストの終了時にロールバックする
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(url), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatalf("failed to begin transaction: %v", tx.Error)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+// createTestUser テスト用のユーザーの作成
+func createTestUser(t *testing.T, db *gorm.DB, role string) *models.User {
+	t.Helper()
+	user := &models.User{
+		Email:        fmt.Sprintf("%s-%d@test.example.com", role, time.Now().UnixNano()),
+		PasswordHash: "x",
+		Role:         role,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("failed to create %s: %v", role, err)
+	}
+	return user
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type TimeOffRepository interface {
+	Create(timeOff *models.DoctorTimeOff) error
+	FindByID(id uint) (*models.DoctorTimeOff, error)
+	FindUpcomingByDoctorID(doctorID uint, since time.Time) ([]models.DoctorTimeOff, error)
+	Delete(id uint) error
+}
+
+type timeOffRepository struct {
+	db *gorm.DB
+}
+
+func NewTimeOffRepository(db *gorm.DB) TimeOffRepository {
+	return &timeOffRepository{
+		db: db,
+	}
+}
+
+// Create 休診期間の登録
+func (r *timeOffRepository) Create(timeOff *models.DoctorTimeOff) error {
+	return r.db.Create(timeOff).Error
+}
+
+// FindByID IDで休診期間を取得
+func (r *timeOffRepository) FindByID(id uint) (*models.DoctorTimeOff, error) {
+	var timeOff models.DoctorTimeOff
+	if err := r.db.First(&timeOff, id).Error; err != nil {
+		return nil, err
+	}
+	return &timeOff, nil
+}
+
+// FindUpcomingByDoctorID 指定時刻以降に終了する医師の休診期間を取得
+func (r *timeOffRepository) FindUpcomingByDoctorID(doctorID uint, since time.Time) ([]models.DoctorTimeOff, error) {
+	var timeOffs []models.DoctorTimeOff
+	err := r.db.Where("doctor_id = ? AND end_time > ?", doctorID, since).Order("start_time ASC").Find(&timeOffs).Error
+	return timeOffs, err
+}
+
+// Delete 休診期間の削除
+func (r *timeOffRepository) Delete(id uint) error {
+	return r.db.Delete(&models.DoctorTimeOff{}, id).Error
+}
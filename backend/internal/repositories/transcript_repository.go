@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type TranscriptRepository interface {
+	Create(transcript *models.Transcript) error
+	Update(transcript *models.Transcript) error
+	FindByVideoSessionID(videoSessionID uint) (*models.Transcript, error)
+	FindWithSegments(videoSessionID uint, query string) (*models.Transcript, error)
+	FindProcessable(staleBefore time.Time, limit int) ([]models.Transcript, error)
+	CountQueued() (int64, error)
+	Claim(id uint, updatedAt time.Time) (bool, error)
+	Complete(id uint, segments []models.TranscriptSegment, provider string, completedAt time.Time) error
+	SearchSegments(userID uint, query string, limit, offset int) ([]TranscriptSearchHit, error)
+}
+
+// TranscriptSearchHit 文字起こしの検索結果（一致した区間と予約）
+type TranscriptSearchHit struct {
+	AppointmentID  uint      `json:"appointment_id"`
+	VideoSessionID uint      `json:"video_session_id"`
+	TranscriptID   uint      `json:"transcript_id"`
+	SegmentID      uint      `json:"segment_id"`
+	StartMs        int64     `json:"start_ms"`
+	EndMs          int64     `json:"end_ms"`
+	Text           string    `json:"text"`
+	RecordedAt     time.Time `json:"recorded_at"`
+}
+
+type transcriptRepository struct {
+	db *gorm.DB
+}
+
+func NewTranscriptRepository(db *gorm.DB) TranscriptRepository {
+	return &transcriptRepository{
+		db: db,
+	}
+}
+
+func (r *transcriptRepository) Create(transcript *models.Transcript) error {
+	return r.db.Omit("Segments").Create(transcript).Error
+}
+
+func (r *transcriptRepository) Update(transcript *models.Transcript) error {
+	return r.db.Omit("Segments").Save(transcript).Error
+}
+
+// FindByVideoSessionID セッションの文字起こしを取得（区間は含まない）
+func (r *transcriptRepository) FindByVideoSessionID(videoSessionID uint) (*models.Transcript, error) {
+	var transcript models.Transcript
+	err := r.db.Where("video_session_id = ?", videoSessionID).First(&transcript).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transcript, nil
+}
+
+// FindWithSegments セッションの文字起こしを区間付きで取得（queryを指定した場合は一致する区間のみ）
+func (r *transcriptRepository) FindWithSegments(videoSessionID uint, query string) (*models.Transcript, error) {
+	var transcript models.Transcript
+	err := r.db.Preload("Segments", func(db *gorm.DB) *gorm.DB {
+		if query != "" {
+			db = db.Where("text ILIKE ?", "%"+escapeLike(query)+"%")
+		}
+		return db.Order("seq")
+	}).Where("video_session_id = ?", videoSessionID).First(&transcript).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transcript, nil
+}
+
+// FindProcessable 文字起こし待ち、または処理中のまま止まった文字起こしを古い順に取得
+func (r *transcriptRepository) FindProcessable(staleBefore time.Time, limit int) ([]models.Transcript, error) {
+	var transcripts []models.Transcript
+	err := r.db.Where("status = ? OR (status = ? AND updated_at < ?)", "pending", "processing", staleBefore).
+		Order("created_at").
+		Limit(limit).
+		Find(&transcripts).Error
+	return transcripts, err
+}
+
+// CountQueued 文字起こしの処理待ち・処理中の件数
+func (r *transcriptRepository) CountQueued() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Transcript{}).Where("status IN ?", []string{"pending", "processing"}).Count(&count).Error
+	return count, err
+}
+
+// Claim 処理の開始（取得時から更新されていない場合のみ、複数インスタンスでの重複処理を防ぐ）
+func (r *transcriptRepository) Claim(id uint, updatedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.Transcript{}).
+		Where("id = ? AND updated_at = ? AND status IN ?", id, updatedAt, []string{"pending", "processing"}).
+		Updates(map[string]interface{}{"status": "processing", "attempts": gorm.Expr("attempts + 1")})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Complete 区間を保存して完了にする（再処理の場合は以前の区間を置き換える）
+func (r *transcriptRepository) Complete(id uint, segments []models.TranscriptSegment, provider string, completedAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("transcript_id = ?", id).Delete(&models.TranscriptSegment{}).Error; err != nil {
+			return err
+		}
+		for i := range segments {
+			segments[i].TranscriptID = id
+		}
+		if len(segments) > 0 {
+			if err := tx.CreateInBatches(segments, 500).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Transcript{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"status":       "completed",
+			"provider":     provider,
+			"audio_path":   "",
+			"last_error":   "",
+			"completed_at": completedAt,
+		}).Error
+	})
+}
+
+// SearchSegments 参加した予約（患者・医師・通訳者）の文字起こしから語句を含む区間を新しい順に検索
+func (r *transcriptRepository) SearchSegments(userID uint, query string, limit, offset int) ([]TranscriptSearchHit, error) {
+	var hits []TranscriptSearchHit
+	err := r.db.Table("transcript_segments").
+		Select("transcripts.appointment_id, transcripts.video_session_id, transcripts.id AS transcript_id, transcript_segments.id AS segment_id, transcript_segments.start_ms, transcript_segments.end_ms, transcript_segments.text, transcripts.created_at AS recorded_at").
+		Joins("JOIN transcripts ON transcripts.id = transcript_segments.transcript_id AND transcripts.status = ?", "completed").
+		Joins("JOIN appointments ON appointments.id = transcripts.appointment_id AND appointments.deleted_at IS NULL").
+		Where("appointments.patient_id = ? OR appointments.doctor_id = ? OR appointments.interpreter_id = ?", userID, userID, userID).
+		Where("transcript_segments.text ILIKE ?", "%"+escapeLike(query)+"%").
+		Order("transcripts.created_at DESC, transcript_segments.seq").
+		Limit(limit).
+		Offset(offset).
+		Scan(&hits).Error
+	return hits, err
+}
+
+// escapeLike LIKE検索のワイルドカード（% _ \）を文字として扱う
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
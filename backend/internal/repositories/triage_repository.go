@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type TriageRepository interface {
+	Create(assessment *models.TriageAssessment) error
+	FindByID(id uint) (*models.TriageAssessment, error)
+	FindByPatientID(patientID uint, limit, offset int) ([]models.TriageAssessment, error)
+	FindByAppointmentID(appointmentID uint) (*models.TriageAssessment, error)
+	AttachToAppointment(id, appointmentID uint) error
+}
+
+type triageRepository struct {
+	db *gorm.DB
+}
+
+func NewTriageRepository(db *gorm.DB) TriageRepository {
+	return &triageRepository{
+		db: db,
+	}
+}
+
+// Create 問診結果の作成
+func (r *triageRepository) Create(assessment *models.TriageAssessment) error {
+	return r.db.Create(assessment).Error
+}
+
+// FindByID IDで問診結果を取得
+func (r *triageRepository) FindByID(id uint) (*models.TriageAssessment, error) {
+	var assessment models.TriageAssessment
+	if err := r.db.First(&assessment, id).Error; err != nil {
+		return nil, err
+	}
+	return &assessment, nil
+}
+
+// FindByPatientID 患者の問診結果一覧を取得
+func (r *triageRepository) FindByPatientID(patientID uint, limit, offset int) ([]models.TriageAssessment, error) {
+	var assessments []models.TriageAssessment
+	err := r.db.Where("patient_id = ?", patientID).Order("created_at DESC").Limit(limit).Offset(offset).Find(&assessments).Error
+	return assessments, err
+}
+
+// FindByAppointmentID 予約に紐付いた問診結果を取得（未紐付けの場合はnil）
+func (r *triageRepository) FindByAppointmentID(appointmentID uint) (*models.TriageAssessment, error) {
+	var assessments []models.TriageAssessment
+	if err := r.db.Where("appointment_id = ?", appointmentID).Limit(1).Find(&assessments).Error; err != nil {
+		return nil, err
+	}
+	if len(assessments) == 0 {
+		return nil, nil
+	}
+	return &assessments[0], nil
+}
+
+// AttachToAppointment 問診結果を予約に紐付ける（未紐付けの場合のみ）
+func (r *triageRepository) AttachToAppointment(id, appointmentID uint) error {
+	result := r.db.Model(&models.TriageAssessment{}).
+		Where("id = ? AND appointment_id IS NULL", id).
+		Update("appointment_id", appointmentID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -1,21 +1,46 @@
 package repositories
 
 import (
+	"fmt"
+	"time"
+
 	"online_medical_consultation_app/backend/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
 	Create(user *models.User) error
 	FindByID(id uint) (*models.User, error)
 	FindByEmail(email string) (*models.User, error)
-	FindDoctors() ([]models.DoctorProfile, error)
+	UpdateEmail(userID uint, email string) error
+	SetDeactivated(userID uint, deactivatedAt *time.Time) error
+	SetChatSuspendedUntil(userID uint, until *time.Time) error
+	FindByIDs(ids []uint) ([]models.User, error)
+	TouchLastSeen(userIDs []uint, at time.Time) error
+	SetHidePresence(userID uint, hide bool) error
+	SetBreakGlassAuthorized(userID uint, authorized bool) error
+	RecordFailedLogin(userID uint, at, resetBefore time.Time) (int, error)
+	SetLockedUntil(userID uint, until time.Time) error
+	ResetFailedLogins(userID uint) error
+	FindDeactivatedBefore(before time.Time, limit int) ([]models.User, error)
+	Anonymize(userID uint, anonymizedAt time.Time) error
+	FindDoctors(language string) ([]models.DoctorProfile, error)
+	FindDoctorPage(language string, isDemo bool, limit, offset int) ([]models.DoctorProfile, int64, error)
+	FindByRole(role string) ([]models.User, error)
 	CreatePatientProfile(profile *models.PatientProfile) error
 	CreateDoctorProfile(profile *models.DoctorProfile) error
 	FindPatientProfileByUserID(userID uint) (*models.PatientProfile, error)
 	FindDoctorProfileByUserID(userID uint) (*models.DoctorProfile, error)
 	UpdatePatientProfile(profile *models.PatientProfile) error
 	UpdateDoctorProfile(profile *models.DoctorProfile) error
+	CreateInterpreterProfile(profile *models.InterpreterProfile) error
+	FindInterpreterProfileByUserID(userID uint) (*models.InterpreterProfile, error)
+	UpdateInterpreterProfile(profile *models.InterpreterProfile) error
+	UpdateDoctorPresence(userID uint, acceptsInstant bool, lastSeenAt time.Time) error
+	FindOnlineDoctors(seenSince time.Time, language string) ([]models.DoctorProfile, error)
+	ClaimDoctorForInstant(userID uint, seenSince time.Time) (bool, error)
+	SetDoctorAcceptsInstant(userID uint, acceptsInstant bool) error
 }
 
 type userRepository struct {
@@ -48,14 +73,192 @@ func (r *userRepository) FindByEmail(email string) (*models.User, error) {
 	return &user, nil
 }
 
-func (r *userRepository) FindDoctors() ([]models.DoctorProfile, error) {
+// UpdateEmail ログイン用メールアドレスの変更
+func (r *userRepository) UpdateEmail(userID uint, email string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("email", email).Error
+}
+
+// SetDeactivated 退会日時の設定（nilで再開）
+func (r *userRepository) SetDeactivated(userID uint, deactivatedAt *time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("deactivated_at", deactivatedAt).Error
+}
+
+// SetChatSuspendedUntil チャット送信の停止期限の設定（nilで解除）
+func (r *userRepository) SetChatSuspendedUntil(userID uint, until *time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("chat_suspended_until", until).Error
+}
+
+func (r *userRepository) FindByIDs(ids []uint) ([]models.User, error) {
+	var users []models.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	if err := r.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// TouchLastSeen 最終アクティビティの更新（より新しい時刻の場合のみ、更新日時は変更しない）
+func (r *userRepository) TouchLastSeen(userIDs []uint, at time.Time) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.User{}).
+		Where("id IN ? AND (last_seen_at IS NULL OR last_seen_at < ?)", userIDs, at).
+		UpdateColumn("last_seen_at", at).Error
+}
+
+func (r *userRepository) SetHidePresence(userID uint, hide bool) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("hide_presence", hide).Error
+}
+
+func (r *userRepository) SetBreakGlassAuthorized(userID uint, authorized bool) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("break_glass_authorized", authorized).Error
+}
+
+// RecordFailedLogin ログインの失敗の記録（前回の失敗が resetBefore より前の場合は1回目として数え直す）
+func (r *userRepository) RecordFailedLogin(userID uint, at, resetBefore time.Time) (int, error) {
+	var users []models.User
+	err := r.db.Model(&users).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "failed_login_count"}}}).
+		Where("id = ?", userID).
+		UpdateColumns(map[string]interface{}{
+			"failed_login_count":   gorm.Expr("CASE WHEN last_failed_login_at IS NULL OR last_failed_login_at < ? THEN 1 ELSE failed_login_count + 1 END", resetBefore),
+			"last_failed_login_at": at,
+		}).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(users) == 0 {
+		return 0, fmt.Errorf("user %d not found", userID)
+	}
+	return users[0].FailedLoginCount, nil
+}
+
+// SetLockedUntil ログインのロックの期限の設定
+func (r *userRepository) SetLockedUntil(userID uint, until time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).UpdateColumn("locked_until", until).Error
+}
+
+// ResetFailedLogins ログインの失敗回数とロックの解除（失敗していない場合は更新しない）
+func (r *userRepository) ResetFailedLogins(userID uint) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ? AND (failed_login_count > 0 OR locked_until IS NOT NULL)", userID).
+		UpdateColumns(map[string]interface{}{
+			"failed_login_count":   0,
+			"last_failed_login_at": nil,
+			"locked_until":         nil,
+		}).Error
+}
+
+// FindDeactivatedBefore 指定時刻より前に退会し、まだ匿名化していないユーザーを取得
+func (r *userRepository) FindDeactivatedBefore(before time.Time, limit int) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("deactivated_at < ? AND anonymized_at IS NULL", before).
+		Order("deactivated_at ASC").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// Anonymize 退会ユーザーの個人情報を消去する（診療記録は保存義務のため残す）
+func (r *userRepository) Anonymize(userID uint, anonymizedAt time.Time) error {
+	const anonymizedName = "退会済みユーザー"
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"email":         fmt.Sprintf("deleted-%d@deleted.invalid", userID),
+			"password_hash": "!", // どのパスワードとも一致しない
+			"anonymized_at": anonymizedAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.PatientProfile{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"name":               anonymizedName,
+			"birthdate":          nil,
+			"phone":              "",
+			"address":            "",
+			"insurance_provider": "",
+			"insurance_number":   "",
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.DoctorProfile{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"name":            anonymizedName,
+			"bio":             "",
+			"accepts_instant": false,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.InterpreterProfile{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"name": anonymizedName,
+			"bio":  "",
+		}).Error; err != nil {
+			return err
+		}
+
+		// 家族（被扶養者）の氏名・生年月日・医師向けメモも消去する（削除済みの家族を含む）
+		if err := tx.Unscoped().Model(&models.Dependent{}).Where("guardian_id = ?", userID).Updates(map[string]interface{}{
+			"name":      anonymizedName,
+			"birthdate": nil,
+			"gender":    "",
+			"notes":     "",
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", userID).Delete(&models.ContactChangeRequest{}).Error
+	})
+}
+
+// doctorLanguageCondition 医師の診療言語（カンマ区切り）に指定言語が含まれる条件
+const doctorLanguageCondition = "',' || doctor_profiles.languages || ',' LIKE ?"
+
+// FindDoctors 医師一覧を取得（退会済みの医師は除く、言語指定時はその言語で診療できる医師のみ）
+func (r *userRepository) FindDoctors(language string) ([]models.DoctorProfile, error) {
+	query := r.db.Preload("User").
+		Joins("JOIN users ON users.id = doctor_profiles.user_id AND users.deactivated_at IS NULL")
+	if language != "" {
+		query = query.Where(doctorLanguageCondition, "%,"+language+",%")
+	}
+
 	var doctors []models.DoctorProfile
-	if err := r.db.Preload("User").Find(&doctors).Error; err != nil {
+	if err := query.Find(&doctors).Error; err != nil {
 		return nil, err
 	}
 	return doctors, nil
 }
 
+// FindDoctorPage 医師一覧の指定範囲と総件数（FindDoctors の条件に加え、デモ・実アカウントの別で絞り込む）
+func (r *userRepository) FindDoctorPage(language string, isDemo bool, limit, offset int) ([]models.DoctorProfile, int64, error) {
+	query := r.db.Model(&models.DoctorProfile{}).
+		Joins("JOIN users ON users.id = doctor_profiles.user_id AND users.deactivated_at IS NULL AND users.is_demo = ?", isDemo)
+	if language != "" {
+		query = query.Where(doctorLanguageCondition, "%,"+language+",%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var doctors []models.DoctorProfile
+	err := query.Preload("User").Order("doctor_profiles.id ASC").Limit(limit).Offset(offset).Find(&doctors).Error
+	return doctors, total, err
+}
+
+func (r *userRepository) FindByRole(role string) ([]models.User, error) {
+	var users []models.User
+	if err := r.db.Where("role = ?", role).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 func (r *userRepository) CreatePatientProfile(profile *models.PatientProfile) error {
 	return r.db.Create(profile).Error
 }
@@ -87,3 +290,52 @@ func (r *userRepository) UpdatePatientProfile(profile *models.PatientProfile) er
 func (r *userRepository) UpdateDoctorProfile(profile *models.DoctorProfile) error {
 	return r.db.Save(profile).Error
 }
+
+func (r *userRepository) CreateInterpreterProfile(profile *models.InterpreterProfile) error {
+	return r.db.Create(profile).Error
+}
+
+func (r *userRepository) FindInterpreterProfileByUserID(userID uint) (*models.InterpreterProfile, error) {
+	var profile models.InterpreterProfile
+	if err := r.db.Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *userRepository) UpdateInterpreterProfile(profile *models.InterpreterProfile) error {
+	return r.db.Save(profile).Error
+}
+
+// UpdateDoctorPresence 医師のオンライン状態と最終ハートビートの更新
+func (r *userRepository) UpdateDoctorPresence(userID uint, acceptsInstant bool, lastSeenAt time.Time) error {
+	return r.db.Model(&models.DoctorProfile{}).Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"accepts_instant": acceptsInstant, "last_seen_at": lastSeenAt}).Error
+}
+
+// FindOnlineDoctors 即時診療を受付中で、指定時刻以降にハートビートのある医師を取得
+func (r *userRepository) FindOnlineDoctors(seenSince time.Time, language string) ([]models.DoctorProfile, error) {
+	query := r.db.Preload("User").
+		Joins("JOIN users ON users.id = doctor_profiles.user_id AND users.deactivated_at IS NULL").
+		Where("accepts_instant = ? AND last_seen_at >= ?", true, seenSince)
+	if language != "" {
+		query = query.Where(doctorLanguageCondition, "%,"+language+",%")
+	}
+
+	var doctors []models.DoctorProfile
+	err := query.Order("last_seen_at DESC").Find(&doctors).Error
+	return doctors, err
+}
+
+// ClaimDoctorForInstant 即時診療の受付を締め切って医師を確保する（同時予約の防止）
+func (r *userRepository) ClaimDoctorForInstant(userID uint, seenSince time.Time) (bool, error) {
+	result := r.db.Model(&models.DoctorProfile{}).
+		Where("user_id = ? AND accepts_instant = ? AND last_seen_at >= ?", userID, true, seenSince).
+		Update("accepts_instant", false)
+	return result.RowsAffected == 1, result.Error
+}
+
+// SetDoctorAcceptsInstant 即時診療の受付状態のみを更新
+func (r *userRepository) SetDoctorAcceptsInstant(userID uint, acceptsInstant bool) error {
+	return r.db.Model(&models.DoctorProfile{}).Where("user_id = ?", userID).Update("accepts_instant", acceptsInstant).Error
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+type VideoPresenceRepository interface {
+	Create(presence *models.VideoPresence) error
+	Delete(id uint) (bool, error)
+	FindBySession(sessionID uint) ([]models.VideoPresence, error)
+	Touch(ids []uint, at time.Time) error
+	DeleteBySession(sessionID uint) (int64, error)
+	DeleteStale(seenBefore time.Time) ([]models.VideoPresence, error)
+}
+
+type videoPresenceRepository struct {
+	db *gorm.DB
+}
+
+func NewVideoPresenceRepository(db *gorm.DB) VideoPresenceRepository {
+	return &videoPresenceRepository{
+		db: db,
+	}
+}
+
+// Create シグナリングへの接続の記録
+func (r *videoPresenceRepository) Create(presence *models.VideoPresence) error {
+	return r.db.Create(presence).Error
+}
+
+// Delete 切断した接続の削除（定期ジョブで削除済みの場合はfalse）
+func (r *videoPresenceRepository) Delete(id uint) (bool, error) {
+	result := r.db.Where("id = ?", id).Delete(&models.VideoPresence{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindBySession セッションに接続中の参加者を接続順に取得
+func (r *videoPresenceRepository) FindBySession(sessionID uint) ([]models.VideoPresence, error) {
+	var presences []models.VideoPresence
+	err := r.db.Where("video_session_id = ?", sessionID).Order("connected_at ASC").Find(&presences).Error
+	return presences, err
+}
+
+// Touch このインスタンスに接続中の接続の最終確認日時の更新
+func (r *videoPresenceRepository) Touch(ids []uint, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.VideoPresence{}).Where("id IN ?", ids).Update("last_seen_at", at).Error
+}
+
+// DeleteBySession セッションの接続の削除
+func (r *videoPresenceRepository) DeleteBySession(sessionID uint) (int64, error) {
+	result := r.db.Where("video_session_id = ?", sessionID).Delete(&models.VideoPresence{})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteStale 指定時刻より前から更新がない接続と、終了したセッションの接続の削除（削除した接続を返す）
+func (r *videoPresenceRepository) DeleteStale(seenBefore time.Time) ([]models.VideoPresence, error) {
+	var presences []models.VideoPresence
+	err := r.db.Clauses(clause.Returning{}).
+		Where("last_seen_at < ? OR video_session_id IN (?)", seenBefore,
+			r.db.Model(&models.VideoSession{}).Select("id").Where("ended_at IS NOT NULL")).
+		Delete(&presences).Error
+	return presences, err
+}
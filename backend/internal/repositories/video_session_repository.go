@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"online_medical_consultation_app/backend/internal/models"
 )
 
@@ -11,6 +12,7 @@ type VideoSessionRepository interface {
 	Create(session *models.VideoSession) error
 	FindByID(id uint) (*models.VideoSession, error)
 	FindByAppointmentID(appointmentID uint) ([]models.VideoSession, error)
+	CountByAppointment(appointmentID uint) (int64, error)
 	Update(session *models.VideoSession) error
 	Delete(id uint) error
 	LoadRelations(session *models.VideoSession) error
@@ -18,6 +20,16 @@ type VideoSessionRepository interface {
 	FindByRoomID(roomID string) (*models.VideoSession, error)
 	UpdateStartedAt(sessionID uint, startedAt *time.Time) error
 	UpdateEndedAt(sessionID uint, endedAt *time.Time) error
+	UpdateRecordingConsent(sessionID uint, consent string, at time.Time) error
+	UpdateDoctorRecordingConsent(sessionID uint, consent string, at time.Time) error
+	SaveParticipant(participant *models.VideoParticipant) error
+	FindParticipant(sessionID, userID uint) (*models.VideoParticipant, error)
+	FindParticipants(sessionID uint) ([]models.VideoParticipant, error)
+	RespondParticipant(sessionID, userID uint, state string, respondedAt time.Time) (bool, error)
+	FindRingingBefore(before time.Time) ([]models.VideoParticipant, error)
+	MarkRingingMissed(sessionID uint, respondedAt time.Time) ([]models.VideoParticipant, error)
+	SetPendingOffer(sessionID, fromUserID uint, offer string, at time.Time) error
+	ClearPendingOffer(sessionID, fromUserID uint) (bool, error)
 }
 
 type videoSessionRepository struct {
@@ -52,6 +64,13 @@ func (r *videoSessionRepository) FindByAppointmentID(appointmentID uint) ([]mode
 	return videoSessions, err
 }
 
+// CountByAppointment 予約で作成したビデオセッションの数
+func (r *videoSessionRepository) CountByAppointment(appointmentID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.VideoSession{}).Where("appointment_id = ?", appointmentID).Count(&count).Error
+	return count, err
+}
+
 // FindActiveByAppointment 予約IDでアクティブなビデオセッションを取得
 func (r *videoSessionRepository) FindActiveByAppointment(appointmentID uint) (*models.VideoSession, error) {
 	var videoSession models.VideoSession
@@ -88,6 +107,18 @@ func (r *videoSessionRepository) UpdateEndedAt(sessionID uint, endedAt *time.Tim
 	return r.db.Model(&models.VideoSession{}).Where("id = ?", sessionID).Update("ended_at", endedAt).Error
 }
 
+// UpdateRecordingConsent 録音・文字起こしへの同意状態の更新
+func (r *videoSessionRepository) UpdateRecordingConsent(sessionID uint, consent string, at time.Time) error {
+	return r.db.Model(&models.VideoSession{}).Where("id = ?", sessionID).
+		Updates(map[string]interface{}{"recording_consent": consent, "recording_consent_at": at}).Error
+}
+
+// UpdateDoctorRecordingConsent 録画への医師の同意状態の更新
+func (r *videoSessionRepository) UpdateDoctorRecordingConsent(sessionID uint, consent string, at time.Time) error {
+	return r.db.Model(&models.VideoSession{}).Where("id = ?", sessionID).
+		Updates(map[string]interface{}{"doctor_recording_consent": consent, "doctor_recording_consent_at": at}).Error
+}
+
 // Delete ビデオセッションの削除
 func (r *videoSessionRepository) Delete(id uint) error {
 	return r.db.Delete(&models.VideoSession{}, id).Error
@@ -170,3 +201,67 @@ func (r *videoSessionRepository) FindSessionsByUser(userID uint, limit, offset i
 		Find(&videoSessions).Error
 	return videoSessions, err
 }
+
+// SaveParticipant 呼び出しの登録（同じセッション・ユーザーへの再呼び出しは呼び出し中に戻す）
+func (r *videoSessionRepository) SaveParticipant(participant *models.VideoParticipant) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "video_session_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"caller_id", "state", "rang_at", "responded_at", "updated_at"}),
+	}).Create(participant).Error
+}
+
+// FindParticipant セッション・ユーザーで呼び出しを取得
+func (r *videoSessionRepository) FindParticipant(sessionID, userID uint) (*models.VideoParticipant, error) {
+	var participant models.VideoParticipant
+	err := r.db.Where("video_session_id = ? AND user_id = ?", sessionID, userID).First(&participant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &participant, nil
+}
+
+// FindParticipants セッションの呼び出し一覧を取得
+func (r *videoSessionRepository) FindParticipants(sessionID uint) ([]models.VideoParticipant, error) {
+	var participants []models.VideoParticipant
+	err := r.db.Where("video_session_id = ?", sessionID).Order("rang_at").Find(&participants).Error
+	return participants, err
+}
+
+// RespondParticipant 呼び出し中の場合のみ応答状態を更新する（応答済み・時間切れの場合はfalse）
+func (r *videoSessionRepository) RespondParticipant(sessionID, userID uint, state string, respondedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.VideoParticipant{}).
+		Where("video_session_id = ? AND user_id = ? AND state = ?", sessionID, userID, models.CallRinging).
+		Updates(map[string]interface{}{"state": state, "responded_at": respondedAt})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindRingingBefore 指定時刻より前から呼び出し中のままの呼び出しを取得
+func (r *videoSessionRepository) FindRingingBefore(before time.Time) ([]models.VideoParticipant, error) {
+	var participants []models.VideoParticipant
+	err := r.db.Where("state = ? AND rang_at < ?", models.CallRinging, before).Find(&participants).Error
+	return participants, err
+}
+
+// MarkRingingMissed セッションの呼び出し中の呼び出しを不在にする（更新した呼び出しを返す）
+func (r *videoSessionRepository) MarkRingingMissed(sessionID uint, respondedAt time.Time) ([]models.VideoParticipant, error) {
+	var participants []models.VideoParticipant
+	err := r.db.Model(&participants).
+		Clauses(clause.Returning{}).
+		Where("video_session_id = ? AND state = ?", sessionID, models.CallRinging).
+		Updates(map[string]interface{}{"state": models.CallMissed, "responded_at": respondedAt}).Error
+	return participants, err
+}
+
+// SetPendingOffer 応答待ちのSDPオファーの記録（再交渉では前のオファーを置き換える）
+func (r *videoSessionRepository) SetPendingOffer(sessionID, fromUserID uint, offer string, at time.Time) error {
+	return r.db.Model(&models.VideoSession{}).Where("id = ?", sessionID).
+		Updates(map[string]interface{}{"pending_offer": offer, "pending_offer_from_id": fromUserID, "pending_offer_at": at}).Error
+}
+
+// ClearPendingOffer 送信者の応答待ちのSDPオファーの消去（他の参加者のオファーに置き換わっていた場合はfalse）
+func (r *videoSessionRepository) ClearPendingOffer(sessionID, fromUserID uint) (bool, error) {
+	result := r.db.Model(&models.VideoSession{}).
+		Where("id = ? AND pending_offer_from_id = ?", sessionID, fromUserID).
+		Updates(map[string]interface{}{"pending_offer": "", "pending_offer_from_id": nil, "pending_offer_at": nil})
+	return result.RowsAffected > 0, result.Error
+}
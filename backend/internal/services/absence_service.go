@@ -0,0 +1,271 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/realtime"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 自動辞退の理由
+const (
+	CancelReasonDoctorNoResponse = "doctor_no_response"
+	CancelReasonDoctorTimeOff    = "doctor_time_off"
+)
+
+// 代替候補の提示件数と検索期間
+const (
+	alternativeDoctorLimit = 3
+	alternativeSlotLimit   = 3
+	alternativeSearchDays  = 14
+)
+
+type DoctorAbsenceService struct {
+	appointmentRepo     repositories.AppointmentRepository
+	slotRepo            repositories.SlotRepository
+	timeOffRepo         repositories.TimeOffRepository
+	userRepo            repositories.UserRepository
+	interpreterRepo     repositories.InterpreterRepository
+	notificationService *NotificationService
+	auditService        *AuditService
+	hub                 *realtime.Hub
+	responseTimeout     time.Duration
+}
+
+type CreateTimeOffRequest struct {
+	StartTime time.Time `json:"start_time" binding:"required"`
+	EndTime   time.Time `json:"end_time" binding:"required"`
+	Reason    string    `json:"reason"`
+}
+
+// CreateTimeOffResult 休診登録の結果
+type CreateTimeOffResult struct {
+	TimeOff              *models.DoctorTimeOff `json:"time_off"`
+	BlockedSlots         int64                 `json:"blocked_slots"`
+	DeclinedAppointments int                   `json:"declined_appointments"`
+}
+
+// AlternativeDoctor 辞退された予約の代替候補
+type AlternativeDoctor struct {
+	DoctorID  uint                      `json:"doctor_id"`
+	Name      string                    `json:"name"`
+	Specialty string                    `json:"specialty"`
+	Slots     []models.AvailabilitySlot `json:"slots"`
+}
+
+func NewDoctorAbsenceService(appointmentRepo repositories.AppointmentRepository, slotRepo repositories.SlotRepository, timeOffRepo repositories.TimeOffRepository, userRepo repositories.UserRepository, interpreterRepo repositories.InterpreterRepository, notificationService *NotificationService, auditService *AuditService, hub *realtime.Hub, responseTimeout time.Duration) *DoctorAbsenceService {
+	return &DoctorAbsenceService{
+		appointmentRepo:     appointmentRepo,
+		slotRepo:            slotRepo,
+		timeOffRepo:         timeOffRepo,
+		userRepo:            userRepo,
+		interpreterRepo:     interpreterRepo,
+		notificationService: notificationService,
+		auditService:        auditService,
+		hub:                 hub,
+		responseTimeout:     responseTimeout,
+	}
+}
+
+// CreateTimeOff 休診期間の登録（期間内の診療枠を停止し、保留中の予約を辞退する）
+func (s *DoctorAbsenceService) CreateTimeOff(doctorID uint, req CreateTimeOffRequest) (*CreateTimeOffResult, error) {
+	if !req.EndTime.After(req.StartTime) {
+		return nil, errors.New("end time must be after start time")
+	}
+	if req.EndTime.Before(time.Now()) {
+		return nil, errors.New("time off must end in the future")
+	}
+
+	timeOff := &models.DoctorTimeOff{
+		DoctorID:  doctorID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Reason:    req.Reason,
+	}
+	if err := s.timeOffRepo.Create(timeOff); err != nil {
+		return nil, err
+	}
+
+	blocked, err := s.slotRepo.BlockInRange(doctorID, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := s.appointmentRepo.FindPendingByDoctorInRange(doctorID, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	declined := 0
+	for i := range pending {
+		if s.declineAppointment(&pending[i], CancelReasonDoctorTimeOff) {
+			declined++
+		}
+	}
+
+	s.auditService.LogUserAction(doctorID, "time_off_created", "doctor_time_off", fmt.Sprintf("%d", timeOff.ID), map[string]interface{}{
+		"blocked_slots":         blocked,
+		"declined_appointments": declined,
+	})
+
+	return &CreateTimeOffResult{
+		TimeOff:              timeOff,
+		BlockedSlots:         blocked,
+		DeclinedAppointments: declined,
+	}, nil
+}
+
+// GetTimeOffs 今後の休診期間一覧の取得
+func (s *DoctorAbsenceService) GetTimeOffs(doctorID uint) ([]models.DoctorTimeOff, error) {
+	return s.timeOffRepo.FindUpcomingByDoctorID(doctorID, time.Now())
+}
+
+// DeleteTimeOff 休診期間の削除（停止した診療枠は医師が個別に再開する）
+func (s *DoctorAbsenceService) DeleteTimeOff(doctorID, timeOffID uint) error {
+	timeOff, err := s.timeOffRepo.FindByID(timeOffID)
+	if err != nil || timeOff.DoctorID != doctorID {
+		return errors.New("time off not found")
+	}
+	return s.timeOffRepo.Delete(timeOffID)
+}
+
+// GetAlternatives 辞退された予約の代替候補の取得（予約した患者のみ）
+func (s *DoctorAbsenceService) GetAlternatives(appointmentID, patientID uint) ([]AlternativeDoctor, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.PatientID != patientID {
+		return nil, errors.New("unauthorized to view alternatives for this appointment")
+	}
+	if appointment.Status != "cancelled" || appointment.CancelReason == "" {
+		return nil, errors.New("appointment was not declined")
+	}
+
+	return s.suggestAlternatives(appointment.DoctorID)
+}
+
+// RunAutoDeclineJob 定期ジョブ：期限内に医師が応答しなかった保留中の予約を辞退する
+func (s *DoctorAbsenceService) RunAutoDeclineJob() error {
+	pending, err := s.appointmentRepo.FindPendingCreatedBefore(time.Now().Add(-s.responseTimeout))
+	if err != nil {
+		return err
+	}
+
+	for i := range pending {
+		// 即時診療は医師が受付中、非同期相談は回答期限（SLA）で管理するため対象外
+		if pending[i].IsInstant || pending[i].IsAsync {
+			continue
+		}
+		s.declineAppointment(&pending[i], CancelReasonDoctorNoResponse)
+	}
+	return nil
+}
+
+// declineAppointment 予約を辞退し、通訳枠を解放して患者へ代替候補を通知する
+func (s *DoctorAbsenceService) declineAppointment(appointment *models.Appointment, reason string) bool {
+	declined, err := s.appointmentRepo.DeclinePending(appointment.ID, reason)
+	if err != nil {
+		log.Printf("Warning: Failed to decline appointment %d: %v", appointment.ID, err)
+		return false
+	}
+	if !declined {
+		// 医師が先に応答した
+		return false
+	}
+	appointment.Status = "cancelled"
+	appointment.CancelReason = reason
+	publishAppointmentStatus(s.hub, appointment)
+
+	// 休暇と重なる枠は停止中に戻る
+	if appointment.SlotID != nil {
+		if _, err := s.slotRepo.Release(*appointment.SlotID); err != nil {
+			log.Printf("Warning: Failed to release slot %d: %v", *appointment.SlotID, err)
+		}
+	}
+	if appointment.InterpreterID != nil {
+		if err := s.interpreterRepo.ReleaseByAppointmentID(appointment.ID); err != nil {
+			log.Printf("Warning: Failed to release interpreter slot for appointment %d: %v", appointment.ID, err)
+		}
+	}
+
+	alternatives, err := s.suggestAlternatives(appointment.DoctorID)
+	if err != nil {
+		log.Printf("Warning: Failed to find alternatives for appointment %d: %v", appointment.ID, err)
+	}
+
+	title := "医師が応答しなかったため予約リクエストが取り消されました"
+	if reason == CancelReasonDoctorTimeOff {
+		title = "医師が休診のため予約リクエストが取り消されました"
+	}
+	if _, err := s.notificationService.Notify(appointment.PatientID, NotificationMessage{
+		Type:     "appointment_declined",
+		Title:    title,
+		Body:     "他の医師・日時での予約をご検討ください",
+		Priority: "high",
+		Data: map[string]interface{}{
+			"appointment_id": appointment.ID,
+			"reason":         reason,
+			"alternatives":   alternatives,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify patient %d of declined appointment %d: %v", appointment.PatientID, appointment.ID, err)
+	}
+
+	s.auditService.LogSystemAction("appointment_auto_declined", "appointment", fmt.Sprintf("%d", appointment.ID), map[string]interface{}{
+		"doctor_id": appointment.DoctorID,
+		"reason":    reason,
+	})
+
+	return true
+}
+
+// suggestAlternatives 同じ診療科で空き枠のある他の医師を提示する
+func (s *DoctorAbsenceService) suggestAlternatives(doctorID uint) ([]AlternativeDoctor, error) {
+	specialty := ""
+	if profile, err := s.userRepo.FindDoctorProfileByUserID(doctorID); err == nil {
+		specialty = profile.Specialty
+	}
+
+	doctors, err := s.userRepo.FindDoctors("")
+	if err != nil {
+		return nil, err
+	}
+	doctors = visibleDoctors(s.userRepo, doctorID, doctors)
+
+	now := time.Now()
+	until := now.AddDate(0, 0, alternativeSearchDays)
+	alternatives := []AlternativeDoctor{}
+	for _, doctor := range doctors {
+		if doctor.UserID == doctorID || (specialty != "" && doctor.Specialty != specialty) {
+			continue
+		}
+
+		slots, err := s.slotRepo.FindAvailableByDoctorIDAndDate(doctor.UserID, now, until)
+		if err != nil {
+			return nil, err
+		}
+		if len(slots) == 0 {
+			continue
+		}
+		sort.Slice(slots, func(i, j int) bool { return slots[i].StartTime.Before(slots[j].StartTime) })
+		if len(slots) > alternativeSlotLimit {
+			slots = slots[:alternativeSlotLimit]
+		}
+
+		alternatives = append(alternatives, AlternativeDoctor{
+			DoctorID:  doctor.UserID,
+			Name:      doctor.Name,
+			Specialty: doctor.Specialty,
+			Slots:     slots,
+		})
+		if len(alternatives) >= alternativeDoctorLimit {
+			break
+		}
+	}
+	return alternatives, nil
+}
@@ -0,0 +1,518 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 連絡先変更の有効期限と試行回数
+const (
+	emailChangeTTL     = 24 * time.Hour
+	phoneOTPTTL        = 10 * time.Minute
+	phoneOTPMaxAttempt = 5
+)
+
+// 1回の匿名化ジョブで処理するユーザー数の上限
+const anonymizationBatchSize = 100
+
+type AccountService struct {
+	contactChangeRepo   repositories.ContactChangeRepository
+	userRepo            repositories.UserRepository
+	appointmentService  *AppointmentService
+	notificationService *NotificationService
+	auditService        *AuditService
+	sender              ContactSender
+	appBaseURL          string
+	reactivationWindow  time.Duration
+}
+
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type ChangePhoneRequest struct {
+	Phone string `json:"phone" binding:"required"`
+}
+
+type VerifyPhoneRequest struct {
+	OTP string `json:"otp" binding:"required"`
+}
+
+type ContactChangeTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type DeactivateAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+	Reason   string `json:"reason"`
+}
+
+type ReactivateAccountRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// DeactivationResult 退会処理の結果
+type DeactivationResult struct {
+	DeactivatedAt         time.Time `json:"deactivated_at"`
+	ReactivationDeadline  time.Time `json:"reactivation_deadline"`
+	CancelledAppointments int       `json:"cancelled_appointments"`
+}
+
+func NewAccountService(contactChangeRepo repositories.ContactChangeRepository, userRepo repositories.UserRepository, appointmentService *AppointmentService, notificationService *NotificationService, auditService *AuditService, sender ContactSender, appBaseURL string, reactivationWindow time.Duration) *AccountService {
+	return &AccountService{
+		contactChangeRepo:   contactChangeRepo,
+		userRepo:            userRepo,
+		appointmentService:  appointmentService,
+		notificationService: notificationService,
+		auditService:        auditService,
+		sender:              sender,
+		appBaseURL:          strings.TrimRight(appBaseURL, "/"),
+		reactivationWindow:  reactivationWindow,
+	}
+}
+
+// RequestEmailChange メールアドレス変更の申請（新旧両方のアドレスで確認が必要）
+func (s *AccountService) RequestEmailChange(userID uint, req ChangeEmailRequest) (*models.ContactChangeRequest, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+	if user.IsDemo {
+		return nil, errors.New("contact details of demo accounts cannot be changed")
+	}
+
+	// 本人確認のため現在のパスワードを要求する
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, errors.New("invalid password")
+	}
+
+	newEmail := strings.TrimSpace(req.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return nil, errors.New("new email must be different from the current email")
+	}
+	if existing, err := s.userRepo.FindByEmail(newEmail); err == nil && existing != nil {
+		return nil, errors.New("email already in use")
+	}
+	if IsDemoEmail(newEmail) {
+		return nil, errors.New("this email domain is reserved")
+	}
+
+	newToken, err := generateContactToken()
+	if err != nil {
+		return nil, err
+	}
+	oldToken, err := generateContactToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// 以前の申請は無効にする
+	if err := s.contactChangeRepo.CancelPendingByUser(userID, models.ContactChangeEmail); err != nil {
+		return nil, err
+	}
+
+	request := &models.ContactChangeRequest{
+		UserID:       userID,
+		Kind:         models.ContactChangeEmail,
+		OldValue:     user.Email,
+		NewValue:     newEmail,
+		NewTokenHash: hashContactSecret(newToken),
+		OldTokenHash: hashContactSecret(oldToken),
+		Status:       "pending",
+		ExpiresAt:    time.Now().Add(emailChangeTTL),
+	}
+	if err := s.contactChangeRepo.Create(request); err != nil {
+		return nil, err
+	}
+
+	if err := s.sender.SendEmail(newEmail, "メールアドレス変更の確認",
+		fmt.Sprintf("メールアドレスの変更を完了するには、次のリンクを開いてください。\n%s\n有効期限: %s",
+			s.confirmEmailURL(newToken), request.ExpiresAt.Format("2006-01-02 15:04"))); err != nil {
+		return nil, fmt.Errorf("failed to send verification email: %v", err)
+	}
+	if err := s.sender.SendEmail(user.Email, "メールアドレス変更の申請がありました",
+		fmt.Sprintf("ログイン用メールアドレスを %s に変更する申請がありました。\n変更を承認する場合は次のリンクを開いてください。\n%s\n心当たりがない場合は次のリンクから取り消してください。\n%s",
+			newEmail, s.confirmEmailURL(oldToken), s.cancelEmailURL(oldToken))); err != nil {
+		return nil, fmt.Errorf("failed to send verification email: %v", err)
+	}
+
+	s.auditService.LogUserAction(userID, "email_change_requested", "user", fmt.Sprintf("%d", userID), map[string]interface{}{
+		"request_id": request.ID,
+	})
+
+	return request, nil
+}
+
+// ConfirmEmailChange 確認リンクによる承認（新旧両方の承認が揃った時点で変更を反映）
+func (s *AccountService) ConfirmEmailChange(token string) (*models.ContactChangeRequest, error) {
+	request, err := s.findPendingByToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	hash := hashContactSecret(token)
+	if hash == request.NewTokenHash {
+		request.NewConfirmedAt = &now
+	} else {
+		request.OldConfirmedAt = &now
+	}
+
+	if request.NewConfirmedAt == nil || request.OldConfirmedAt == nil {
+		if err := s.contactChangeRepo.Update(request); err != nil {
+			return nil, err
+		}
+		return request, nil
+	}
+
+	// 確認待ちの間に他のユーザーが同じアドレスを登録していないか再確認
+	if existing, err := s.userRepo.FindByEmail(request.NewValue); err == nil && existing != nil && existing.ID != request.UserID {
+		request.Status = "cancelled"
+		s.contactChangeRepo.Update(request)
+		return nil, errors.New("email already in use")
+	}
+
+	if err := s.userRepo.UpdateEmail(request.UserID, request.NewValue); err != nil {
+		return nil, errors.New("failed to update email")
+	}
+
+	request.Status = "completed"
+	request.CompletedAt = &now
+	if err := s.contactChangeRepo.Update(request); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(request.UserID, "email_changed", "user", fmt.Sprintf("%d", request.UserID), map[string]interface{}{
+		"request_id": request.ID,
+	})
+
+	// 旧アドレスへ変更完了を知らせる（乗っ取りに気付けるように）
+	if err := s.sender.SendEmail(request.OldValue, "メールアドレスが変更されました",
+		fmt.Sprintf("ログイン用メールアドレスが %s に変更されました。心当たりがない場合はサポートまでご連絡ください。", request.NewValue)); err != nil {
+		log.Printf("Warning: Failed to notify old email for user %d: %v", request.UserID, err)
+	}
+	if _, err := s.notificationService.Notify(request.UserID, NotificationMessage{
+		Type:     "email_changed",
+		Title:    "メールアドレスが変更されました",
+		Body:     fmt.Sprintf("ログイン用メールアドレスが %s に変更されました。", request.NewValue),
+		Priority: "high",
+	}); err != nil {
+		log.Printf("Warning: Failed to notify user %d of email change: %v", request.UserID, err)
+	}
+
+	return request, nil
+}
+
+// CancelEmailChange 旧アドレスに送ったリンクによる申請の取り消し
+func (s *AccountService) CancelEmailChange(token string) error {
+	request, err := s.findPendingByToken(token)
+	if err != nil {
+		return err
+	}
+	if hashContactSecret(token) != request.OldTokenHash {
+		return errors.New("invalid or expired token")
+	}
+
+	request.Status = "cancelled"
+	if err := s.contactChangeRepo.Update(request); err != nil {
+		return err
+	}
+
+	s.auditService.LogUserAction(request.UserID, "email_change_cancelled", "user", fmt.Sprintf("%d", request.UserID), map[string]interface{}{
+		"request_id": request.ID,
+	})
+	return nil
+}
+
+// RequestPhoneChange 電話番号変更の申請（新しい番号へSMSで確認コードを送信）
+func (s *AccountService) RequestPhoneChange(userID uint, req ChangePhoneRequest) (*models.ContactChangeRequest, error) {
+	profile, err := s.userRepo.FindPatientProfileByUserID(userID)
+	if err != nil || profile == nil {
+		return nil, errors.New("phone number is only available for patients")
+	}
+	if user, err := s.userRepo.FindByID(userID); err == nil && user.IsDemo {
+		return nil, errors.New("contact details of demo accounts cannot be changed")
+	}
+
+	phone := strings.TrimSpace(req.Phone)
+	if !phonePattern.MatchString(phone) {
+		return nil, errors.New("invalid phone number")
+	}
+	if phone == profile.Phone {
+		return nil, errors.New("new phone number must be different from the current one")
+	}
+
+	otp, err := generateOTP()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.contactChangeRepo.CancelPendingByUser(userID, models.ContactChangePhone); err != nil {
+		return nil, err
+	}
+
+	request := &models.ContactChangeRequest{
+		UserID:       userID,
+		Kind:         models.ContactChangePhone,
+		OldValue:     profile.Phone,
+		NewValue:     phone,
+		NewTokenHash: hashContactSecret(otp),
+		Status:       "pending",
+		ExpiresAt:    time.Now().Add(phoneOTPTTL),
+	}
+	if err := s.contactChangeRepo.Create(request); err != nil {
+		return nil, err
+	}
+
+	if err := s.sender.SendSMS(phone, fmt.Sprintf("確認コード: %s（%d分間有効）", otp, int(phoneOTPTTL.Minutes()))); err != nil {
+		return nil, fmt.Errorf("failed to send verification code: %v", err)
+	}
+
+	s.auditService.LogUserAction(userID, "phone_change_requested", "patient_profile", fmt.Sprintf("%d", userID), map[string]interface{}{
+		"request_id": request.ID,
+	})
+
+	return request, nil
+}
+
+// VerifyPhoneChange 確認コードの検証と電話番号の変更
+func (s *AccountService) VerifyPhoneChange(userID uint, req VerifyPhoneRequest) (*models.PatientProfile, error) {
+	request, err := s.contactChangeRepo.FindPendingByUser(userID, models.ContactChangePhone)
+	if err != nil || request == nil {
+		return nil, errors.New("no pending phone change")
+	}
+
+	if time.Now().After(request.ExpiresAt) {
+		request.Status = "expired"
+		s.contactChangeRepo.Update(request)
+		return nil, errors.New("verification code expired")
+	}
+
+	hash := hashContactSecret(strings.TrimSpace(req.OTP))
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(request.NewTokenHash)) != 1 {
+		request.Attempts++
+		if request.Attempts >= phoneOTPMaxAttempt {
+			request.Status = "expired"
+		}
+		s.contactChangeRepo.Update(request)
+		return nil, errors.New("invalid verification code")
+	}
+
+	profile, err := s.userRepo.FindPatientProfileByUserID(userID)
+	if err != nil || profile == nil {
+		return nil, errors.New("profile not found")
+	}
+
+	profile.Phone = request.NewValue
+	if err := s.userRepo.UpdatePatientProfile(profile); err != nil {
+		return nil, errors.New("failed to update phone number")
+	}
+
+	now := time.Now()
+	request.NewConfirmedAt = &now
+	request.Status = "completed"
+	request.CompletedAt = &now
+	if err := s.contactChangeRepo.Update(request); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(userID, "phone_changed", "patient_profile", fmt.Sprintf("%d", userID), map[string]interface{}{
+		"request_id": request.ID,
+	})
+
+	// 旧番号へ変更完了を知らせる
+	if request.OldValue != "" {
+		if err := s.sender.SendSMS(request.OldValue, "ご登録の電話番号が変更されました。心当たりがない場合はサポートまでご連絡ください。"); err != nil {
+			log.Printf("Warning: Failed to notify old phone for user %d: %v", userID, err)
+		}
+	}
+	if _, err := s.notificationService.Notify(userID, NotificationMessage{
+		Type:     "phone_changed",
+		Title:    "電話番号が変更されました",
+		Body:     "ご登録の電話番号が変更されました。",
+		Priority: "high",
+	}); err != nil {
+		log.Printf("Warning: Failed to notify user %d of phone change: %v", userID, err)
+	}
+
+	return profile, nil
+}
+
+// DeactivateAccount 本人によるアカウントの退会
+// 未完了の予約を取り消し、医師は検索結果から除外する。再開可能期間の経過後に匿名化する
+func (s *AccountService) DeactivateAccount(userID uint, req DeactivateAccountRequest) (*DeactivationResult, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+	if user.DeactivatedAt != nil {
+		return nil, errors.New("account is already deactivated")
+	}
+	if user.IsDemo {
+		return nil, errors.New("demo accounts cannot be deactivated")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, errors.New("invalid password")
+	}
+
+	now := time.Now()
+	if err := s.userRepo.SetDeactivated(userID, &now); err != nil {
+		return nil, errors.New("failed to deactivate account")
+	}
+
+	// 即時診療の受付を停止する
+	if user.Role == "doctor" {
+		if err := s.userRepo.SetDoctorAcceptsInstant(userID, false); err != nil {
+			log.Printf("Warning: Failed to stop instant consultations for doctor %d: %v", userID, err)
+		}
+	}
+
+	cancelled, err := s.appointmentService.CancelForDeactivatedUser(userID)
+	if err != nil {
+		log.Printf("Warning: Failed to cancel appointments for deactivated user %d: %v", userID, err)
+	}
+
+	s.contactChangeRepo.CancelPendingByUser(userID, models.ContactChangeEmail)
+	s.contactChangeRepo.CancelPendingByUser(userID, models.ContactChangePhone)
+
+	result := &DeactivationResult{
+		DeactivatedAt:         now,
+		ReactivationDeadline:  now.Add(s.reactivationWindow),
+		CancelledAppointments: cancelled,
+	}
+
+	s.auditService.LogUserAction(userID, "account_deactivated", "user", fmt.Sprintf("%d", userID), map[string]interface{}{
+		"reason":                 req.Reason,
+		"cancelled_appointments": cancelled,
+		"reactivation_deadline":  result.ReactivationDeadline,
+	})
+
+	if err := s.sender.SendEmail(user.Email, "退会手続きが完了しました",
+		fmt.Sprintf("アカウントの退会手続きが完了しました。%s までは再度ログイン画面から利用を再開できます。期限を過ぎると個人情報は消去されます。",
+			result.ReactivationDeadline.Format("2006-01-02"))); err != nil {
+		log.Printf("Warning: Failed to send deactivation email to user %d: %v", userID, err)
+	}
+
+	return result, nil
+}
+
+// ReactivateAccount 再開可能期間内の退会済みアカウントの利用再開
+func (s *AccountService) ReactivateAccount(req ReactivateAccountRequest) error {
+	user, err := s.userRepo.FindByEmail(strings.TrimSpace(req.Email))
+	if err != nil || user == nil {
+		return errors.New("invalid credentials")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return errors.New("invalid credentials")
+	}
+
+	if user.DeactivatedAt == nil {
+		return errors.New("account is not deactivated")
+	}
+	if user.AnonymizedAt != nil || time.Since(*user.DeactivatedAt) > s.reactivationWindow {
+		return errors.New("reactivation period has expired")
+	}
+
+	if err := s.userRepo.SetDeactivated(user.ID, nil); err != nil {
+		return errors.New("failed to reactivate account")
+	}
+
+	s.auditService.LogUserAction(user.ID, "account_reactivated", "user", fmt.Sprintf("%d", user.ID), nil)
+	return nil
+}
+
+// IsAccountActive 退会していないアカウントかどうか（発行済みトークンの無効化に使用）
+func (s *AccountService) IsAccountActive(userID uint) (bool, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return user.DeactivatedAt == nil, nil
+}
+
+// RunAnonymizationJob 再開可能期間を過ぎた退会ユーザーの個人情報を消去する（定期実行）
+func (s *AccountService) RunAnonymizationJob() error {
+	users, err := s.userRepo.FindDeactivatedBefore(time.Now().Add(-s.reactivationWindow), anonymizationBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if err := s.userRepo.Anonymize(user.ID, time.Now()); err != nil {
+			log.Printf("Warning: Failed to anonymize user %d: %v", user.ID, err)
+			continue
+		}
+		s.auditService.LogSystemAction("account_anonymized", "user", fmt.Sprintf("%d", user.ID), map[string]interface{}{
+			"deactivated_at": user.DeactivatedAt,
+		})
+	}
+
+	return nil
+}
+
+// findPendingByToken トークンに一致する有効なメールアドレス変更申請を取得
+func (s *AccountService) findPendingByToken(token string) (*models.ContactChangeRequest, error) {
+	request, err := s.contactChangeRepo.FindPendingByTokenHash(hashContactSecret(token))
+	if err != nil || request == nil || request.Kind != models.ContactChangeEmail {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	if time.Now().After(request.ExpiresAt) {
+		request.Status = "expired"
+		s.contactChangeRepo.Update(request)
+		return nil, errors.New("invalid or expired token")
+	}
+
+	return request, nil
+}
+
+func (s *AccountService) confirmEmailURL(token string) string {
+	return fmt.Sprintf("%s/account/email-change/confirm?token=%s", s.appBaseURL, token)
+}
+
+func (s *AccountService) cancelEmailURL(token string) string {
+	return fmt.Sprintf("%s/account/email-change/cancel?token=%s", s.appBaseURL, token)
+}
+
+// generateContactToken 確認リンク用のランダムなトークンを生成
+func generateContactToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// generateOTP 6桁の確認コードを生成
+func generateOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashContactSecret トークン・確認コードは平文で保存せずハッシュで照合する
+func hashContactSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 最低バージョンの設定を再読み込みするまでの間隔（リクエストごとにデータベースを参照しないため）
+const appVersionPolicyCacheTTL = time.Minute
+
+// バージョンの区切りの数の上限（1.2.3.4 まで）
+const appVersionMaxParts = 4
+
+// AppVersionService クライアントのアプリのバージョンの確認
+// 最低バージョンより古いアプリからのリクエストにはアップデートを求める
+type AppVersionService struct {
+	policyRepo   repositories.AppVersionPolicyRepository
+	userRepo     repositories.UserRepository
+	auditService *AuditService
+	defaults     models.AppVersionPolicy
+
+	mu       sync.Mutex
+	cached   *models.AppVersionPolicy
+	cachedAt time.Time
+}
+
+// AppVersionStatus アプリのバージョンの確認結果
+type AppVersionStatus struct {
+	Platform        string `json:"platform"`
+	Version         string `json:"version"`
+	MinimumVersion  string `json:"minimum_version,omitempty"`
+	UpgradeRequired bool   `json:"upgrade_required"`
+	UpgradeURL      string `json:"upgrade_url,omitempty"`
+	Message         string `json:"message,omitempty"`
+}
+
+// UpdateAppVersionPolicyRequest 最低バージョンの部分更新（nilの項目は変更しない、空文字で制限を解除）
+type UpdateAppVersionPolicyRequest struct {
+	MinIOSVersion     *string `json:"min_ios_version"`
+	MinAndroidVersion *string `json:"min_android_version"`
+	MinWebVersion     *string `json:"min_web_version"`
+	IOSUpgradeURL     *string `json:"ios_upgrade_url"`
+	AndroidUpgradeURL *string `json:"android_upgrade_url"`
+	UpgradeMessage    *string `json:"upgrade_message"`
+}
+
+func NewAppVersionService(policyRepo repositories.AppVersionPolicyRepository, userRepo repositories.UserRepository, auditService *AuditService, defaults models.AppVersionPolicy) *AppVersionService {
+	return &AppVersionService{
+		policyRepo:   policyRepo,
+		userRepo:     userRepo,
+		auditService: auditService,
+		defaults:     defaults,
+	}
+}
+
+// GetPolicy 現在の最低バージョンの取得（未設定の場合は初期値）
+func (s *AppVersionService) GetPolicy() (*models.AppVersionPolicy, error) {
+	versionPolicy, err := s.policyRepo.Find()
+	if err != nil {
+		return nil, err
+	}
+	if versionPolicy == nil {
+		defaults := s.defaults
+		return &defaults, nil
+	}
+	return versionPolicy, nil
+}
+
+// UpdatePolicy 最低バージョンの更新（管理者のみ）
+func (s *AppVersionService) UpdatePolicy(adminID uint, req UpdateAppVersionPolicyRequest) (*models.AppVersionPolicy, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	versionPolicy, err := s.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	if req.MinIOSVersion != nil {
+		versionPolicy.MinIOSVersion = strings.TrimSpace(*req.MinIOSVersion)
+	}
+	if req.MinAndroidVersion != nil {
+		versionPolicy.MinAndroidVersion = strings.TrimSpace(*req.MinAndroidVersion)
+	}
+	if req.MinWebVersion != nil {
+		versionPolicy.MinWebVersion = strings.TrimSpace(*req.MinWebVersion)
+	}
+	if req.IOSUpgradeURL != nil {
+		versionPolicy.IOSUpgradeURL = strings.TrimSpace(*req.IOSUpgradeURL)
+	}
+	if req.AndroidUpgradeURL != nil {
+		versionPolicy.AndroidUpgradeURL = strings.TrimSpace(*req.AndroidUpgradeURL)
+	}
+	if req.UpgradeMessage != nil {
+		versionPolicy.UpgradeMessage = strings.TrimSpace(*req.UpgradeMessage)
+	}
+
+	if err := ValidateAppVersionPolicy(versionPolicy); err != nil {
+		return nil, err
+	}
+
+	versionPolicy.UpdatedByID = &adminID
+	if err := s.policyRepo.Save(versionPolicy); err != nil {
+		return nil, err
+	}
+
+	// このインスタンスでは直ちに反映する（他のインスタンスは再読み込みの間隔の経過後に反映）
+	s.mu.Lock()
+	s.cached = versionPolicy
+	s.cachedAt = time.Now()
+	s.mu.Unlock()
+
+	s.auditService.LogUserAction(adminID, "app_version_policy_updated", "app_version_policy", "1", versionPolicy)
+
+	return versionPolicy, nil
+}
+
+// CheckVersion アプリのバージョンが最低バージョン以上かどうかの確認
+func (s *AppVersionService) CheckVersion(platform, version string) (*AppVersionStatus, error) {
+	platform = strings.ToLower(strings.TrimSpace(platform))
+	version = strings.TrimSpace(version)
+	if !isAppPlatform(platform) {
+		return nil, errors.New("platform must be ios, android or web")
+	}
+	current, err := parseAppVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	return s.checkVersion(platform, version, current)
+}
+
+// AppUpgradeRequired ミドルウェアからのバージョンの確認（middleware.AppVersionChecker の実装）
+// プラットフォーム・バージョンを判定できないリクエストは制限しない
+func (s *AppVersionService) AppUpgradeRequired(platform, version string) (interface{}, bool, error) {
+	platform = strings.ToLower(strings.TrimSpace(platform))
+	version = strings.TrimSpace(version)
+	current, err := parseAppVersion(version)
+	if err != nil || !isAppPlatform(platform) {
+		return nil, false, nil
+	}
+
+	status, err := s.checkVersion(platform, version, current)
+	if err != nil {
+		return nil, false, err
+	}
+	return status, status.UpgradeRequired, nil
+}
+
+// checkVersion 解析済みのバージョンと最低バージョンの比較
+func (s *AppVersionService) checkVersion(platform, version string, current []int) (*AppVersionStatus, error) {
+	versionPolicy, err := s.cachedPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &AppVersionStatus{Platform: platform, Version: version}
+	switch platform {
+	case "ios":
+		status.MinimumVersion = versionPolicy.MinIOSVersion
+		status.UpgradeURL = versionPolicy.IOSUpgradeURL
+	case "android":
+		status.MinimumVersion = versionPolicy.MinAndroidVersion
+		status.UpgradeURL = versionPolicy.AndroidUpgradeURL
+	case "web":
+		status.MinimumVersion = versionPolicy.MinWebVersion
+	}
+	if status.MinimumVersion == "" {
+		return status, nil
+	}
+
+	minimum, err := parseAppVersion(status.MinimumVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid minimum %s version", platform)
+	}
+	if compareAppVersions(current, minimum) < 0 {
+		status.UpgradeRequired = true
+		status.Message = versionPolicy.UpgradeMessage
+	}
+	return status, nil
+}
+
+// cachedPolicy 再読み込みの間隔内であれば前回読み込んだ最低バージョンを返す
+func (s *AppVersionService) cachedPolicy() (*models.AppVersionPolicy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached != nil && time.Since(s.cachedAt) < appVersionPolicyCacheTTL {
+		return s.cached, nil
+	}
+
+	versionPolicy, err := s.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+	s.cached = versionPolicy
+	s.cachedAt = time.Now()
+	return versionPolicy, nil
+}
+
+func (s *AppVersionService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
+
+// ValidateAppVersionPolicy 最低バージョンの設定の検証
+func ValidateAppVersionPolicy(versionPolicy *models.AppVersionPolicy) error {
+	for name, version := range map[string]string{
+		"min_ios_version":     versionPolicy.MinIOSVersion,
+		"min_android_version": versionPolicy.MinAndroidVersion,
+		"min_web_version":     versionPolicy.MinWebVersion,
+	} {
+		if version == "" {
+			continue
+		}
+		if _, err := parseAppVersion(version); err != nil {
+			return fmt.Errorf("%s must be a version such as 1.2.3", name)
+		}
+	}
+	for name, upgradeURL := range map[string]string{
+		"ios_upgrade_url":     versionPolicy.IOSUpgradeURL,
+		"android_upgrade_url": versionPolicy.AndroidUpgradeURL,
+	} {
+		if upgradeURL == "" {
+			continue
+		}
+		parsed, err := url.Parse(upgradeURL)
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return fmt.Errorf("%s must be an absolute https URL", name)
+		}
+	}
+	if len([]rune(versionPolicy.UpgradeMessage)) > 500 {
+		return errors.New("upgrade_message must be at most 500 characters")
+	}
+	return nil
+}
+
+func isAppPlatform(platform string) bool {
+	return platform == "ios" || platform == "android" || platform == "web"
+}
+
+// parseAppVersion 「1.2.3」形式のバージョンの解析（「-beta」等の後ろの部分は比較に使わない）
+func parseAppVersion(version string) ([]int, error) {
+	if i := strings.IndexAny(version, "-+ "); i >= 0 {
+		version = version[:i]
+	}
+	parts := strings.Split(version, ".")
+	if version == "" || len(parts) > appVersionMaxParts {
+		return nil, errors.New("invalid app version")
+	}
+
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, errors.New("invalid app version")
+		}
+		numbers[i] = n
+	}
+	return numbers, nil
+}
+
+// compareAppVersions バージョンの比較（足りない区切りは0とみなす）
+func compareAppVersions(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -2,25 +2,79 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"time"
 
 	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/realtime"
 	"online_medical_consultation_app/backend/internal/repositories"
 )
 
+// 即時診療の予約の日時（受付から）の長さ（医師の他の予約との重複の確認に使う）
+const instantConsultationDuration = 30 * time.Minute
+
 type AppointmentService struct {
 	appointmentRepo repositories.AppointmentRepository
 	slotRepo       repositories.SlotRepository
 	userRepo       repositories.UserRepository
+	dependentRepo  repositories.DependentRepository
+	triageRepo     repositories.TriageRepository
+	interpreterRepo repositories.InterpreterRepository
+	messageRepo    repositories.MessageRepository
+	notificationService *NotificationService
+	onboardingService *OnboardingService
+	documentService *PatientDocumentService
+	bookingPolicyService *BookingPolicyService
+	locationService *LocationService
+	visitSummaryService *VisitSummaryService
+	paymentService *PaymentService
+	tagService     *TagService
+	auditService   *AuditService
+	hub            *realtime.Hub
+	asyncResponseSLA time.Duration
+	completionGrace time.Duration
+	intakeReminderLead time.Duration
+	reminderLead   time.Duration
 }
 
 type CreateAppointmentRequest struct {
 	PatientID uint      `json:"patient_id"`
 	DoctorID  uint      `json:"doctor_id" binding:"required"`
-	SlotID    *uint     `json:"slot_id"`
+	SlotID    *uint     `json:"slot_id" binding:"required"` // 予約する診療枠（日時は枠の開始・終了時刻）
+	DependentID *uint   `json:"dependent_id"` // 家族（被扶養者）の代理予約
+	TriageID  *uint     `json:"triage_id"`    // 予約前の問診結果
+	InterpreterLanguage string `json:"interpreter_language"` // 通訳を依頼する言語コード
+	ConsultationLanguage string `json:"consultation_language"` // 希望する診療言語（医師が対応しない場合は通訳を自動で依頼）
+	DocumentIDs []uint  `json:"document_ids"` // 担当医師に共有する過去の診療記録
+	VisitType string    `json:"visit_type" binding:"omitempty,oneof=online in_person"` // 省略時はオンライン診療
+	LocationID *uint    `json:"location_id"` // 対面診療で受診する所在地（医師が対面診療を行う所在地に限る）
 	Notes     string    `json:"notes"`
-	StartTime time.Time `json:"start_time" binding:"required"`
-	EndTime   time.Time `json:"end_time" binding:"required"`
+	StartTime time.Time `json:"start_time"` // 指定する場合は診療枠の時刻と一致すること（予約の日時は診療枠の時刻で記録する）
+	EndTime   time.Time `json:"end_time"`
+}
+
+type CreateInstantAppointmentRequest struct {
+	PatientID   uint   `json:"patient_id"`
+	DoctorID    uint   `json:"doctor_id" binding:"required"`
+	DependentID *uint  `json:"dependent_id"`
+	TriageID    *uint  `json:"triage_id"`
+	ConsultationLanguage string `json:"consultation_language"` // 即時診療は通訳を手配できないため医師の対応言語に限る
+	Notes       string `json:"notes"`
+}
+
+type CreateAsyncAppointmentRequest struct {
+	PatientID   uint   `json:"patient_id"`
+	DoctorID    uint   `json:"doctor_id" binding:"required"`
+	DependentID *uint  `json:"dependent_id"`
+	TriageID    *uint  `json:"triage_id"`
+	ConsultationLanguage string `json:"consultation_language"` // 非同期相談は通訳を手配できないため医師の対応言語に限る
+	DocumentIDs []uint `json:"document_ids"` // 担当医師に共有する過去の診療記録
+	Question    string `json:"question" binding:"required"`
+}
+
+type AttachTriageRequest struct {
+	TriageID uint `json:"triage_id" binding:"required"`
 }
 
 type UpdateAppointmentStatusRequest struct {
@@ -30,46 +84,141 @@ type UpdateAppointmentStatusRequest struct {
 	Notes         string `json:"notes"`
 }
 
-func NewAppointmentService(appointmentRepo repositories.AppointmentRepository, slotRepo repositories.SlotRepository, userRepo repositories.UserRepository) *AppointmentService {
+type ReportDelayRequest struct {
+	AppointmentID uint   `json:"appointment_id"`
+	DoctorID      uint   `json:"doctor_id"`
+	DelayMinutes  int    `json:"delay_minutes" binding:"required,min=1,max=180"` // 予定の開始時刻からの遅れ（分）
+	Message       string `json:"message" binding:"max=500"`
+}
+
+// AppointmentConflictError 患者の既存の予約と時間が重なる場合のエラー
+type AppointmentConflictError struct {
+	Appointment models.Appointment
+}
+
+func (e *AppointmentConflictError) Error() string {
+	return fmt.Sprintf("appointment conflicts with your existing appointment %d", e.Appointment.ID)
+}
+
+func NewAppointmentService(appointmentRepo repositories.AppointmentRepository, slotRepo repositories.SlotRepository, userRepo repositories.UserRepository, dependentRepo repositories.DependentRepository, triageRepo repositories.TriageRepository, interpreterRepo repositories.InterpreterRepository, messageRepo repositories.MessageRepository, notificationService *NotificationService, onboardingService *OnboardingService, documentService *PatientDocumentService, bookingPolicyService *BookingPolicyService, locationService *LocationService, visitSummaryService *VisitSummaryService, paymentService *PaymentService, tagService *TagService, auditService *AuditService, hub *realtime.Hub, asyncResponseSLA, completionGrace, intakeReminderLead, reminderLead time.Duration) *AppointmentService {
 	return &AppointmentService{
 		appointmentRepo: appointmentRepo,
 		slotRepo:       slotRepo,
 		userRepo:       userRepo,
+		dependentRepo:  dependentRepo,
+		triageRepo:     triageRepo,
+		interpreterRepo: interpreterRepo,
+		messageRepo:    messageRepo,
+		notificationService: notificationService,
+		onboardingService: onboardingService,
+		documentService: documentService,
+		bookingPolicyService: bookingPolicyService,
+		locationService: locationService,
+		visitSummaryService: visitSummaryService,
+		paymentService: paymentService,
+		tagService:     tagService,
+		auditService:   auditService,
+		hub:            hub,
+		asyncResponseSLA: asyncResponseSLA,
+		completionGrace: completionGrace,
+		intakeReminderLead: intakeReminderLead,
+		reminderLead:   reminderLead,
 	}
 }
 
 // CreateAppointment 予約の作成
 func (s *AppointmentService) CreateAppointment(req CreateAppointmentRequest) (*models.Appointment, error) {
-	// 医師の存在確認
-	doctor, err := s.userRepo.FindByID(req.DoctorID)
-	if err != nil || doctor == nil || doctor.Role != "doctor" {
-		return nil, errors.New("doctor not found")
+	assessment, err := s.validateBooking(req.PatientID, req.DoctorID, req.DependentID, req.TriageID)
+	if err != nil {
+		return nil, err
 	}
 
-	// 患者の存在確認
-	patient, err := s.userRepo.FindByID(req.PatientID)
-	if err != nil || patient == nil || patient.Role != "patient" {
-		return nil, errors.New("patient not found")
+	// 診療枠の確認（予約の日時は枠の時刻とする）
+	if req.SlotID == nil {
+		return nil, errors.New("slot_id is required")
+	}
+	slot, err := s.slotRepo.FindByID(*req.SlotID)
+	if err != nil || slot.DoctorID != req.DoctorID {
+		return nil, errors.New("slot not found")
 	}
+	if slot.Status != "open" {
+		return nil, errors.New("time slot is already booked")
+	}
+	if (!req.StartTime.IsZero() && !req.StartTime.Equal(slot.StartTime)) || (!req.EndTime.IsZero() && !req.EndTime.Equal(slot.EndTime)) {
+		return nil, errors.New("start and end time must match the slot")
+	}
+	req.StartTime, req.EndTime = slot.StartTime, slot.EndTime
 
 	// 時間の妥当性チェック
 	if req.StartTime.Before(time.Now()) {
 		return nil, errors.New("start time cannot be in the past")
 	}
 
-	if req.EndTime.Before(req.StartTime) {
-		return nil, errors.New("end time must be after start time")
+	// 最短受付時間・受付期間・受付時間の確認
+	if err := s.bookingPolicyService.CheckBookable(req.StartTime, req.EndTime); err != nil {
+		return nil, err
+	}
+
+	if err := s.documentService.CheckOwnership(req.PatientID, req.DocumentIDs); err != nil {
+		return nil, err
+	}
+
+	// 対面診療は医師が対面診療を行う所在地を指定する
+	visitType, location, err := s.resolveVisitLocation(req.DoctorID, req.VisitType, req.LocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 医師が事前の問診を求める場合は提出期限を設定する（期限を過ぎてからの予約は問診の同時提出が必要）
+	intakeDueAt, err := s.intakeDueAt(req.DoctorID, req.StartTime)
+	if err != nil {
+		return nil, err
+	}
+	if intakeDueAt != nil && assessment == nil && !time.Now().Before(*intakeDueAt) {
+		return nil, errors.New("this doctor requires a triage assessment before the appointment; submit one with the booking")
+	}
+
+	// 患者自身の他の予約との重複チェック（家族分の予約も保護者の予定として扱う）
+	patientAppointments, err := s.appointmentRepo.FindPatientOverlapping(req.PatientID, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	if len(patientAppointments) > 0 {
+		return nil, &AppointmentConflictError{Appointment: patientAppointments[0]}
+	}
+
+	// 医師の他の予約（重なる別の枠の予約・即時診療）との重複チェック
+	busy, err := s.doctorBusy(req.DoctorID, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	if busy {
+		return nil, errors.New("time slot is already booked")
 	}
 
-	// 既存の予約との重複チェック
-	existingAppointments, err := s.appointmentRepo.FindByDoctorAndTimeRange(req.DoctorID, req.StartTime, req.EndTime)
+	// 医師が希望の診療言語に対応していない場合は、その言語の通訳を依頼する
+	consultationLanguage, doctorSpeaks, err := s.resolveConsultationLanguage(req.DoctorID, req.ConsultationLanguage)
 	if err != nil {
 		return nil, err
 	}
+	interpreterLanguage := normalizeLanguageCode(req.InterpreterLanguage)
+	if interpreterLanguage == "" && !doctorSpeaks {
+		interpreterLanguage = consultationLanguage
+	}
 
-	for _, existing := range existingAppointments {
-		if existing.Status != "cancelled" {
-			return nil, errors.New("time slot is already booked")
+	// 通訳の依頼がある場合は対応可能な通訳者がいるか事前に確認
+	// デモアカウントの予約では実在の通訳者を手配しない
+	var interpreterSlots []models.InterpreterSlot
+	if interpreterLanguage != "" {
+		if patient, err := s.userRepo.FindByID(req.PatientID); err == nil && patient.IsDemo {
+			return nil, errors.New("interpreters are not available for demo accounts")
+		}
+		interpreterSlots, err = s.interpreterRepo.FindAvailableSlots(interpreterLanguage, req.StartTime, req.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		if len(interpreterSlots) == 0 {
+			return nil, errors.New("no interpreter available for the requested language and time")
 		}
 	}
 
@@ -78,14 +227,278 @@ func (s *AppointmentService) CreateAppointment(req CreateAppointmentRequest) (*m
 		PatientID: req.PatientID,
 		DoctorID:  req.DoctorID,
 		SlotID:    req.SlotID,
+		DependentID: req.DependentID,
 		Status:    "pending",
 		Notes:     req.Notes,
+		IsUrgent:  assessment != nil && (assessment.HasRedFlag || assessment.Urgency == "urgent" || assessment.Urgency == "emergency"),
+		InterpreterLanguage: interpreterLanguage,
+		ConsultationLanguage: consultationLanguage,
+		IntakeDueAt: intakeDueAt,
+		VisitType:   visitType,
+		Location:    location,
+	}
+	if location != nil {
+		appointment.LocationID = &location.ID
+	}
+	if intakeDueAt != nil && assessment != nil {
+		now := time.Now()
+		appointment.IntakeCompletedAt = &now
+	}
+
+	// 診療枠をロックして予約済みにする（同じ枠への同時の予約は一方のみ成功する）
+	booked, err := s.appointmentRepo.BookSlot(appointment)
+	if err != nil {
+		return nil, err
+	}
+	if !booked {
+		return nil, errors.New("time slot is already booked")
+	}
+
+	if interpreterLanguage != "" {
+		if err := s.assignInterpreter(appointment, interpreterSlots); err != nil {
+			// 通訳者を確保できなかった予約は残さない
+			if delErr := s.appointmentRepo.Delete(appointment.ID); delErr != nil {
+				log.Printf("Warning: Failed to remove appointment %d without interpreter: %v", appointment.ID, delErr)
+			} else {
+				s.releaseSlot(appointment.SlotID)
+			}
+			return nil, err
+		}
+	}
+
+	if assessment != nil {
+		if err := s.attachTriage(appointment, assessment); err != nil {
+			return nil, err
+		}
+	}
+
+	s.shareDocuments(appointment, req.DocumentIDs)
+	publishAppointmentStatus(s.hub, appointment)
+	s.notifyAppointment(appointment.DoctorID, appointment, "appointment_created", "新しい予約が入りました")
+	s.notifyAppointment(appointment.PatientID, appointment, "appointment_created", "予約を受け付けました")
+
+	// 関連データの読み込み
+	if err := s.appointmentRepo.LoadRelations(appointment); err != nil {
+		return nil, err
+	}
+
+	return appointment, nil
+}
+
+// CreateInstantAppointment 即時診療の予約（オンラインの医師と枠を選ばずに直ちに開始）
+func (s *AppointmentService) CreateInstantAppointment(req CreateInstantAppointmentRequest) (*models.Appointment, error) {
+	assessment, err := s.validateBooking(req.PatientID, req.DoctorID, req.DependentID, req.TriageID)
+	if err != nil {
+		return nil, err
+	}
+
+	consultationLanguage, doctorSpeaks, err := s.resolveConsultationLanguage(req.DoctorID, req.ConsultationLanguage)
+	if err != nil {
+		return nil, err
+	}
+	if !doctorSpeaks {
+		return nil, errors.New("doctor does not support the requested consultation language")
+	}
+
+	// 即時診療も受付時間内に限る
+	now := time.Now()
+	if err := s.bookingPolicyService.CheckBusinessHours(now); err != nil {
+		return nil, err
+	}
+
+	// 予約の日時は受付から即時診療の長さとし、医師の他の予約と重なる場合は受け付けない
+	scheduledStart, scheduledEnd := now, now.Add(instantConsultationDuration)
+	busy, err := s.doctorBusy(req.DoctorID, scheduledStart, scheduledEnd)
+	if err != nil {
+		return nil, err
+	}
+	if busy {
+		return nil, errors.New("doctor is not available for instant consultation")
+	}
+
+	// 医師を確保（確保と同時に即時診療の受付を締め切る）
+	claimed, err := s.userRepo.ClaimDoctorForInstant(req.DoctorID, time.Now().Add(-doctorPresenceTimeout))
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, errors.New("doctor is not available for instant consultation")
+	}
+
+	appointment := &models.Appointment{
+		PatientID:   req.PatientID,
+		DoctorID:    req.DoctorID,
+		DependentID: req.DependentID,
+		Status:      "confirmed",
+		Notes:       req.Notes,
+		IsInstant:   true,
+		ScheduledStart: &scheduledStart,
+		ScheduledEnd:   &scheduledEnd,
+		ConsultationLanguage: consultationLanguage,
+		IsUrgent:    assessment != nil && (assessment.HasRedFlag || assessment.Urgency == "urgent" || assessment.Urgency == "emergency"),
+	}
+
+	// 医師をロックして作成する（確認の後に重なる予約が入った場合は受け付けない）
+	booked, err := s.appointmentRepo.BookInstant(appointment)
+	if err != nil {
+		s.reopenInstant(req.DoctorID)
+		return nil, err
+	}
+	if !booked {
+		s.reopenInstant(req.DoctorID)
+		return nil, errors.New("doctor is not available for instant consultation")
+	}
+
+	if assessment != nil {
+		if err := s.attachTriage(appointment, assessment); err != nil {
+			return nil, err
+		}
+	}
+
+	// 医師へ即時通知
+	if _, err := s.notificationService.Notify(req.DoctorID, NotificationMessage{
+		Type:     "instant_consultation",
+		Title:    "即時診療の依頼があります",
+		Body:     req.Notes,
+		Priority: "high",
+		Data:     map[string]interface{}{"appointment_id": appointment.ID},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify doctor %d of instant consultation: %v", req.DoctorID, err)
+	}
+
+	s.auditService.LogUserAction(req.PatientID, "instant_booked", "appointment", fmt.Sprintf("%d", appointment.ID), map[string]interface{}{
+		"doctor_id": req.DoctorID,
+	})
+	publishAppointmentStatus(s.hub, appointment)
+
+	// 即時診療は受付と同時に確定する
+	s.paymentService.IssueInvoice(appointment)
+
+	// 関連データの読み込み
+	if err := s.appointmentRepo.LoadRelations(appointment); err != nil {
+		return nil, err
+	}
+
+	return appointment, nil
+}
+
+// CreateAsyncAppointment 非同期（チャット）相談の作成
+// 相談内容は最初のチャットメッセージとして登録し、添付ファイルはチャットで追加する
+// ステータスは pending（回答待ち）→ confirmed（医師が回答）→ completed（医師が終了）と進む
+func (s *AppointmentService) CreateAsyncAppointment(req CreateAsyncAppointmentRequest) (*models.Appointment, error) {
+	assessment, err := s.validateBooking(req.PatientID, req.DoctorID, req.DependentID, req.TriageID)
+	if err != nil {
+		return nil, err
+	}
+
+	consultationLanguage, doctorSpeaks, err := s.resolveConsultationLanguage(req.DoctorID, req.ConsultationLanguage)
+	if err != nil {
+		return nil, err
+	}
+	if !doctorSpeaks {
+		return nil, errors.New("doctor does not support the requested consultation language")
+	}
+
+	if err := s.documentService.CheckOwnership(req.PatientID, req.DocumentIDs); err != nil {
+		return nil, err
+	}
+
+	dueAt := time.Now().Add(s.asyncResponseSLA)
+	appointment := &models.Appointment{
+		PatientID:     req.PatientID,
+		DoctorID:      req.DoctorID,
+		DependentID:   req.DependentID,
+		Status:        "pending",
+		IsAsync:       true,
+		ConsultationLanguage: consultationLanguage,
+		ResponseDueAt: &dueAt,
+		IsUrgent:      assessment != nil && (assessment.HasRedFlag || assessment.Urgency == "urgent" || assessment.Urgency == "emergency"),
 	}
 
 	if err := s.appointmentRepo.Create(appointment); err != nil {
 		return nil, err
 	}
 
+	question := &models.Message{
+		AppointmentID: appointment.ID,
+		SenderUserID:  req.PatientID,
+		Channel:       models.MessageChannelPatient,
+		Body:          req.Question,
+	}
+	if err := s.messageRepo.Create(question); err != nil {
+		if delErr := s.appointmentRepo.Delete(appointment.ID); delErr != nil {
+			log.Printf("Warning: Failed to remove async appointment %d without question: %v", appointment.ID, delErr)
+		}
+		return nil, err
+	}
+
+	if assessment != nil {
+		if err := s.attachTriage(appointment, assessment); err != nil {
+			return nil, err
+		}
+	}
+
+	s.shareDocuments(appointment, req.DocumentIDs)
+
+	if _, err := s.notificationService.Notify(req.DoctorID, NotificationMessage{
+		Type:  "async_consultation_requested",
+		Title: "チャット相談が届きました",
+		Body:  req.Question,
+		Data: map[string]interface{}{
+			"appointment_id":  appointment.ID,
+			"response_due_at": dueAt,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify doctor %d of async consultation: %v", req.DoctorID, err)
+	}
+
+	s.auditService.LogUserAction(req.PatientID, "async_booked", "appointment", fmt.Sprintf("%d", appointment.ID), map[string]interface{}{
+		"doctor_id": req.DoctorID,
+	})
+	publishAppointmentStatus(s.hub, appointment)
+
+	// 関連データの読み込み
+	if err := s.appointmentRepo.LoadRelations(appointment); err != nil {
+		return nil, err
+	}
+
+	return appointment, nil
+}
+
+// CloseAsyncConsultation 非同期相談の終了（担当医師のみ、回答後に完了となる）
+func (s *AppointmentService) CloseAsyncConsultation(appointmentID, doctorID uint) (*models.Appointment, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+
+	if appointment.DoctorID != doctorID {
+		return nil, errors.New("unauthorized to close this consultation")
+	}
+	if !appointment.IsAsync {
+		return nil, errors.New("appointment is not an async consultation")
+	}
+	if appointment.Status != "confirmed" {
+		return nil, errors.New("consultation must be answered before it can be closed")
+	}
+
+	now := time.Now()
+	appointment.Status = "completed"
+	appointment.CompletedAt = &now
+	if err := s.appointmentRepo.Update(appointment); err != nil {
+		return nil, err
+	}
+	publishAppointmentStatus(s.hub, appointment)
+	s.visitSummaryService.SendSummaryEmail(appointment.ID)
+
+	if _, err := s.notificationService.Notify(appointment.PatientID, NotificationMessage{
+		Type:  "async_consultation_closed",
+		Title: "チャット相談が終了しました",
+		Data:  map[string]interface{}{"appointment_id": appointment.ID},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify patient %d of closed consultation: %v", appointment.PatientID, err)
+	}
+
 	// 関連データの読み込み
 	if err := s.appointmentRepo.LoadRelations(appointment); err != nil {
 		return nil, err
@@ -94,9 +507,9 @@ func (s *AppointmentService) CreateAppointment(req CreateAppointmentRequest) (*m
 	return appointment, nil
 }
 
-// GetPatientAppointments 患者の予約一覧取得
-func (s *AppointmentService) GetPatientAppointments(patientID uint) ([]models.Appointment, error) {
-	appointments, err := s.appointmentRepo.FindByPatientID(patientID)
+// GetDoctorAsyncQueue 医師の未完了の非同期相談一覧（回答待ちを期限順に先頭）
+func (s *AppointmentService) GetDoctorAsyncQueue(doctorID uint) ([]models.Appointment, error) {
+	appointments, err := s.appointmentRepo.FindOpenAsyncByDoctor(doctorID)
 	if err != nil {
 		return nil, err
 	}
@@ -111,21 +524,568 @@ func (s *AppointmentService) GetPatientAppointments(patientID uint) ([]models.Ap
 	return appointments, nil
 }
 
-// GetDoctorAppointments 医師の予約一覧取得
-func (s *AppointmentService) GetDoctorAppointments(doctorID uint) ([]models.Appointment, error) {
-	appointments, err := s.appointmentRepo.FindByDoctorID(doctorID)
+// RunAsyncSLAJob 定期ジョブ：回答期限を過ぎた非同期相談を記録し、医師と管理者へ通知
+func (s *AppointmentService) RunAsyncSLAJob() error {
+	now := time.Now()
+	overdue, err := s.appointmentRepo.FindAsyncOverdue(now)
+	if err != nil {
+		return err
+	}
+	if len(overdue) == 0 {
+		return nil
+	}
+
+	var adminIDs []uint
+	admins, err := s.userRepo.FindByRole("admin")
+	if err != nil {
+		log.Printf("Warning: Failed to find admins for async SLA breach: %v", err)
+	}
+	for _, admin := range admins {
+		adminIDs = append(adminIDs, admin.ID)
+	}
+
+	for _, appointment := range overdue {
+		marked, err := s.appointmentRepo.MarkSLABreached(appointment.ID, now)
+		if err != nil || !marked {
+			continue
+		}
+
+		msg := NotificationMessage{
+			Type:     "async_sla_breached",
+			Title:    "チャット相談の回答期限を過ぎています",
+			Priority: "high",
+			Data: map[string]interface{}{
+				"appointment_id":  appointment.ID,
+				"doctor_id":       appointment.DoctorID,
+				"response_due_at": appointment.ResponseDueAt,
+			},
+		}
+		if _, err := s.notificationService.Notify(appointment.DoctorID, msg); err != nil {
+			log.Printf("Warning: Failed to notify doctor %d of SLA breach: %v", appointment.DoctorID, err)
+		}
+		s.notificationService.NotifyMany(adminIDs, msg)
+
+		s.auditService.LogSystemAction("async_sla_breached", "appointment", fmt.Sprintf("%d", appointment.ID), map[string]interface{}{
+			"doctor_id": appointment.DoctorID,
+		})
+	}
+	return nil
+}
+
+// RunAutoCompleteJob 定期ジョブ：ビデオ通話または診療枠の終了から猶予時間を過ぎた確定済みの予約を完了にし、
+// 医師へ診療メモ・処方の入力を促す
+func (s *AppointmentService) RunAutoCompleteJob() error {
+	appointments, err := s.appointmentRepo.FindCompletable(time.Now().Add(-s.completionGrace))
+	if err != nil {
+		return err
+	}
+
+	for _, appointment := range appointments {
+		completedAt := time.Now()
+		completed, err := s.appointmentRepo.MarkCompleted(appointment.ID, completedAt)
+		if err != nil {
+			log.Printf("Warning: Failed to auto-complete appointment %d: %v", appointment.ID, err)
+			continue
+		}
+		if !completed {
+			continue
+		}
+		appointment.Status = "completed"
+		appointment.CompletedAt = &completedAt
+		publishAppointmentStatus(s.hub, &appointment)
+
+		if appointment.IsInstant {
+			s.reopenInstant(appointment.DoctorID)
+		}
+		s.visitSummaryService.SendSummaryEmail(appointment.ID)
+
+		if _, err := s.notificationService.Notify(appointment.DoctorID, NotificationMessage{
+			Type:  "appointment_auto_completed",
+			Title: "診療を完了にしました",
+			Body:  "診療メモや処方の入力が必要な場合は登録してください",
+			Data:  map[string]interface{}{"appointment_id": appointment.ID},
+		}); err != nil {
+			log.Printf("Warning: Failed to notify doctor %d of auto-completed appointment: %v", appointment.DoctorID, err)
+		}
+
+		s.auditService.LogSystemAction("appointment_auto_completed", "appointment", fmt.Sprintf("%d", appointment.ID), map[string]interface{}{
+			"doctor_id":  appointment.DoctorID,
+			"patient_id": appointment.PatientID,
+		})
+	}
+	return nil
+}
+
+// AttachTriage 予約後の問診結果の提出（予約した患者のみ、問診の提出期限がある場合は完了を記録）
+func (s *AppointmentService) AttachTriage(appointmentID, patientID, triageID uint) (*models.Appointment, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil || appointment.PatientID != patientID {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.Status != "pending" && appointment.Status != "confirmed" {
+		return nil, errors.New("triage can only be submitted for upcoming appointments")
+	}
+	if existing, err := s.triageRepo.FindByAppointmentID(appointmentID); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, errors.New("triage assessment is already submitted for this appointment")
+	}
+
+	// 問診の完了には予約に必須のプロフィール項目の入力も含む
+	if err := s.onboardingService.CheckBookingAllowed(patientID); err != nil {
+		return nil, err
+	}
+
+	assessment, err := s.triageRepo.FindByID(triageID)
+	if err != nil || assessment == nil || assessment.PatientID != patientID {
+		return nil, errors.New("triage assessment not found")
+	}
+	if assessment.AppointmentID != nil {
+		return nil, errors.New("triage assessment is already attached to an appointment")
+	}
+	if !sameDependent(assessment.DependentID, appointment.DependentID) {
+		return nil, errors.New("triage assessment does not match the patient of this appointment")
+	}
+
+	if err := s.attachTriage(appointment, assessment); err != nil {
+		return nil, err
+	}
+	if appointment.IntakeDueAt != nil {
+		now := time.Now()
+		if err := s.appointmentRepo.MarkIntakeCompleted(appointment.ID, now); err != nil {
+			return nil, err
+		}
+		appointment.IntakeCompletedAt = &now
+	}
+
+	if err := s.appointmentRepo.LoadRelations(appointment); err != nil {
+		return nil, err
+	}
+	return appointment, nil
+}
+
+// RunReminderJob 定期ジョブ：診療開始が近づいた確定済みの予約を参加者へリマインドする（端末へのプッシュ通知を含む）
+func (s *AppointmentService) RunReminderJob() error {
+	now := time.Now()
+	appointments, err := s.appointmentRepo.FindReminderDue(now, now.Add(s.reminderLead))
+	if err != nil {
+		return err
+	}
+
+	location, _ := s.bookingPolicyService.Location()
+	for _, appointment := range appointments {
+		sent, err := s.appointmentRepo.MarkReminderSent(appointment.ID, now)
+		if err != nil || !sent {
+			continue
+		}
+		start := appointment.Slot.StartTime
+		if location != nil {
+			start = start.In(location)
+		}
+		data := map[string]interface{}{
+			"appointment_id": appointment.ID,
+			"start_time":     appointment.Slot.StartTime,
+		}
+		body := fmt.Sprintf("予約日時: %s", start.Format("2006年1月2日 15:04"))
+		body = s.withVisitLocation(body, data, &appointment)
+		s.notificationService.NotifyMany(appointment.ParticipantIDs(), NotificationMessage{
+			Type:     "appointment_reminder",
+			Title:    "まもなく診療の開始時刻です",
+			Body:     body,
+			Priority: "high",
+			Data:     data,
+		})
+	}
+	return nil
+}
+
+// RunIntakeDeadlineJob 定期ジョブ：問診の提出期限が近い患者へのリマインドと、
+// 期限を過ぎても未提出の予約の自動キャンセル（決済は未導入のため返金の処理はない）
+func (s *AppointmentService) RunIntakeDeadlineJob() error {
+	now := time.Now()
+
+	reminders, err := s.appointmentRepo.FindIntakeReminderDue(now.Add(s.intakeReminderLead))
+	if err != nil {
+		return err
+	}
+	for _, appointment := range reminders {
+		if !appointment.IntakeDueAt.After(now) {
+			// 期限切れはキャンセルで知らせる
+			continue
+		}
+		reminded, err := s.appointmentRepo.MarkIntakeReminded(appointment.ID, now)
+		if err != nil || !reminded {
+			continue
+		}
+		if _, err := s.notificationService.Notify(appointment.PatientID, NotificationMessage{
+			Type:     "intake_reminder",
+			Title:    "予約前の問診を提出してください",
+			Body:     "期限までに問診が提出されない場合、予約は自動でキャンセルされます",
+			Priority: "high",
+			Data: map[string]interface{}{
+				"appointment_id": appointment.ID,
+				"intake_due_at":  appointment.IntakeDueAt,
+			},
+		}); err != nil {
+			log.Printf("Warning: Failed to remind patient %d of intake: %v", appointment.PatientID, err)
+		}
+	}
+
+	overdue, err := s.appointmentRepo.FindIntakeOverdue(now)
+	if err != nil {
+		return err
+	}
+	for _, appointment := range overdue {
+		cancelled, err := s.appointmentRepo.CancelIntakeIncomplete(appointment.ID)
+		if err != nil {
+			log.Printf("Warning: Failed to cancel appointment %d with incomplete intake: %v", appointment.ID, err)
+			continue
+		}
+		if !cancelled {
+			continue
+		}
+		appointment.Status = "cancelled"
+		appointment.CancelReason = "intake_incomplete"
+		publishAppointmentStatus(s.hub, &appointment)
+
+		s.releaseSlot(appointment.SlotID)
+		if appointment.InterpreterID != nil {
+			s.releaseInterpreter(appointment.ID)
+		}
+
+		data := map[string]interface{}{
+			"appointment_id": appointment.ID,
+			"reason":         "intake_incomplete",
+		}
+		if _, err := s.notificationService.Notify(appointment.PatientID, NotificationMessage{
+			Type:     "appointment_cancelled",
+			Title:    "予約がキャンセルされました",
+			Body:     "期限までに問診が提出されなかったため、予約はキャンセルされました",
+			Priority: "high",
+			Data:     data,
+		}); err != nil {
+			log.Printf("Warning: Failed to notify patient %d of intake cancellation: %v", appointment.PatientID, err)
+		}
+		if _, err := s.notificationService.Notify(appointment.DoctorID, NotificationMessage{
+			Type:  "appointment_cancelled",
+			Title: "問診の未提出により予約がキャンセルされました",
+			Data:  data,
+		}); err != nil {
+			log.Printf("Warning: Failed to notify doctor %d of intake cancellation: %v", appointment.DoctorID, err)
+		}
+
+		s.auditService.LogSystemAction("appointment_intake_cancelled", "appointment", fmt.Sprintf("%d", appointment.ID), map[string]interface{}{
+			"doctor_id":     appointment.DoctorID,
+			"patient_id":    appointment.PatientID,
+			"intake_due_at": appointment.IntakeDueAt,
+		})
+	}
+	return nil
+}
+
+// intakeDueAt 医師の設定による問診の提出期限（求めない場合はnil）
+func (s *AppointmentService) intakeDueAt(doctorID uint, startTime time.Time) (*time.Time, error) {
+	profile, err := s.userRepo.FindDoctorProfileByUserID(doctorID)
+	if err != nil || profile == nil {
+		return nil, errors.New("doctor not found")
+	}
+	if profile.IntakeLeadHours <= 0 {
+		return nil, nil
+	}
+	dueAt := startTime.Add(-time.Duration(profile.IntakeLeadHours) * time.Hour)
+	return &dueAt, nil
+}
+
+// doctorBusy 予約した日時が指定期間と重なる医師の未完了（保留中・確定済み）の予約があるかどうか
+func (s *AppointmentService) doctorBusy(doctorID uint, start, end time.Time) (bool, error) {
+	appointments, err := s.appointmentRepo.FindByDoctorAndTimeRange(doctorID, start, end)
 	if err != nil {
+		return false, err
+	}
+	for _, appointment := range appointments {
+		if appointment.Status == "pending" || appointment.Status == "confirmed" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// reopenInstant 即時診療の終了後に医師の受付を再開する
+func (s *AppointmentService) reopenInstant(doctorID uint) {
+	if err := s.userRepo.SetDoctorAcceptsInstant(doctorID, true); err != nil {
+		log.Printf("Warning: Failed to reopen instant consultations for doctor %d: %v", doctorID, err)
+	}
+}
+
+// resolveConsultationLanguage 希望する診療言語の正規化と、医師がその言語で診療できるかの確認
+// 言語の指定がない場合は医師の言語で診療するものとして扱う
+func (s *AppointmentService) resolveConsultationLanguage(doctorID uint, language string) (string, bool, error) {
+	language = normalizeLanguageCode(language)
+	if language == "" {
+		return "", true, nil
+	}
+
+	profile, err := s.userRepo.FindDoctorProfileByUserID(doctorID)
+	if err != nil || profile == nil {
+		return "", false, errors.New("doctor not found")
+	}
+
+	return language, HasLanguageCode(profile.Languages, language), nil
+}
+
+// validateBooking 予約当事者（医師・患者・家族）と問診結果の確認
+func (s *AppointmentService) validateBooking(patientID, doctorID uint, dependentID, triageID *uint) (*models.TriageAssessment, error) {
+	// 医師の存在確認
+	doctor, err := s.userRepo.FindByID(doctorID)
+	if err != nil || doctor == nil || doctor.Role != "doctor" || doctor.DeactivatedAt != nil {
+		return nil, errors.New("doctor not found")
+	}
+
+	// 患者の存在確認
+	patient, err := s.userRepo.FindByID(patientID)
+	if err != nil || patient == nil || patient.Role != "patient" {
+		return nil, errors.New("patient not found")
+	}
+
+	// デモアカウントと実アカウントの間では予約できない
+	if patient.IsDemo != doctor.IsDemo {
+		return nil, errors.New("doctor not found")
+	}
+
+	// 予約に必須のプロフィール項目の確認（クリニックのポリシーによる）
+	if err := s.onboardingService.CheckBookingAllowed(patientID); err != nil {
 		return nil, err
 	}
 
+	// 家族の代理予約の場合は保護者であることを確認
+	if dependentID != nil {
+		dependent, err := s.dependentRepo.FindByID(*dependentID)
+		if err != nil || dependent == nil {
+			return nil, errors.New("dependent not found")
+		}
+		if dependent.GuardianID != patientID {
+			return nil, errors.New("unauthorized to book on behalf of this dependent")
+		}
+	}
+
+	// 問診結果の確認（本人の未使用の問診のみ紐付け可能）
+	var assessment *models.TriageAssessment
+	if triageID != nil {
+		assessment, err = s.triageRepo.FindByID(*triageID)
+		if err != nil || assessment == nil || assessment.PatientID != patientID {
+			return nil, errors.New("triage assessment not found")
+		}
+		if assessment.AppointmentID != nil {
+			return nil, errors.New("triage assessment is already attached to an appointment")
+		}
+		if !sameDependent(assessment.DependentID, dependentID) {
+			return nil, errors.New("triage assessment does not match the patient of this appointment")
+		}
+	}
+
+	return assessment, nil
+}
+
+// assignInterpreter 候補の枠から通訳者を割り当て、通訳者へ通知する
+func (s *AppointmentService) assignInterpreter(appointment *models.Appointment, candidates []models.InterpreterSlot) error {
+	for _, slot := range candidates {
+		// 他の予約と同時に割り当てられた場合は次の候補を試す
+		if err := s.interpreterRepo.AssignSlot(slot.ID, appointment.ID); err != nil {
+			continue
+		}
+
+		interpreterID := slot.InterpreterID
+		appointment.InterpreterID = &interpreterID
+		if err := s.appointmentRepo.Update(appointment); err != nil {
+			s.releaseInterpreter(appointment.ID)
+			return err
+		}
+
+		if _, err := s.notificationService.Notify(interpreterID, NotificationMessage{
+			Type:  "interpreter_assigned",
+			Title: "通訳の依頼が割り当てられました",
+			Data: map[string]interface{}{
+				"appointment_id": appointment.ID,
+				"language":       appointment.InterpreterLanguage,
+			},
+		}); err != nil {
+			log.Printf("Warning: Failed to notify interpreter %d: %v", interpreterID, err)
+		}
+		return nil
+	}
+
+	return errors.New("no interpreter available for the requested language and time")
+}
+
+// shareDocuments 予約時に選択された診療記録を担当医師へ共有する（所有者の確認は予約前に済ませる）
+func (s *AppointmentService) shareDocuments(appointment *models.Appointment, documentIDs []uint) {
+	if len(documentIDs) == 0 {
+		return
+	}
+	if _, err := s.documentService.ShareWithAppointment(appointment.ID, appointment.PatientID, documentIDs); err != nil {
+		log.Printf("Warning: Failed to share documents with appointment %d: %v", appointment.ID, err)
+	}
+}
+
+// releaseInterpreter 予約に割り当てた通訳者の枠を解放する
+func (s *AppointmentService) releaseInterpreter(appointmentID uint) {
+	if err := s.interpreterRepo.ReleaseByAppointmentID(appointmentID); err != nil {
+		log.Printf("Warning: Failed to release interpreter slot for appointment %d: %v", appointmentID, err)
+	}
+}
+
+// notifyAppointment 予約の作成・確定・キャンセルの通知（予約日時を本文とし、通知設定に応じてメールでも送る）
+func (s *AppointmentService) notifyAppointment(userID uint, appointment *models.Appointment, notificationType, title string) {
+	body := ""
+	if appointment.SlotID != nil {
+		if slot, err := s.slotRepo.FindByID(*appointment.SlotID); err == nil && slot != nil {
+			start := slot.StartTime
+			if location, err := s.bookingPolicyService.Location(); err == nil {
+				start = start.In(location)
+			}
+			body = fmt.Sprintf("予約日時: %s", start.Format("2006年1月2日 15:04"))
+		}
+	}
+	data := map[string]interface{}{
+		"appointment_id": appointment.ID,
+		"status":         appointment.Status,
+	}
+	body = s.withVisitLocation(body, data, appointment)
+
+	if _, err := s.notificationService.Notify(userID, NotificationMessage{
+		Type:  notificationType,
+		Title: title,
+		Body:  body,
+		Data:  data,
+	}); err != nil {
+		log.Printf("Warning: Failed to notify user %d of appointment %d: %v", userID, appointment.ID, err)
+	}
+}
+
+// resolveVisitLocation 予約の診療の形式と所在地の確認（対面診療は所在地が必須、オンライン診療は指定不可）
+func (s *AppointmentService) resolveVisitLocation(doctorID uint, visitType string, locationID *uint) (string, *models.ClinicLocation, error) {
+	if visitType == "" {
+		visitType = models.VisitOnline
+	}
+	if visitType != models.VisitInPerson {
+		if locationID != nil {
+			return "", nil, errors.New("location_id can only be specified for in-person visits")
+		}
+		return visitType, nil, nil
+	}
+	if locationID == nil {
+		return "", nil, errors.New("location_id is required for in-person visits")
+	}
+	location, err := s.locationService.CheckPractice(doctorID, *locationID)
+	if err != nil {
+		return "", nil, err
+	}
+	return visitType, location, nil
+}
+
+// withVisitLocation 対面診療の予約の通知への所在地の追記（経路案内のURLは通知のデータに含める）
+func (s *AppointmentService) withVisitLocation(body string, data map[string]interface{}, appointment *models.Appointment) string {
+	location := s.locationService.locationOf(appointment)
+	if location == nil {
+		return body
+	}
+	data["visit_type"] = appointment.VisitType
+	data["location_id"] = location.ID
+	data["directions_url"] = location.DirectionsURL
+	line := "場所: " + locationLabel(location)
+	if body == "" {
+		return line
+	}
+	return body + "\n" + line
+}
+
+// releaseSlot キャンセルした予約の診療枠を再び予約できるようにする
+func (s *AppointmentService) releaseSlot(slotID *uint) {
+	if slotID == nil {
+		return
+	}
+	if _, err := s.slotRepo.Release(*slotID); err != nil {
+		log.Printf("Warning: Failed to release slot %d: %v", *slotID, err)
+	}
+}
+
+// attachTriage 問診結果を予約に紐付け、レッドフラグがあれば医師へ即時通知する
+func (s *AppointmentService) attachTriage(appointment *models.Appointment, assessment *models.TriageAssessment) error {
+	if err := s.triageRepo.AttachToAppointment(assessment.ID, appointment.ID); err != nil {
+		return errors.New("triage assessment is already attached to an appointment")
+	}
+
+	if !assessment.HasRedFlag {
+		return nil
+	}
+
+	if _, err := s.notificationService.Notify(appointment.DoctorID, NotificationMessage{
+		Type:     "triage_red_flag",
+		Title:    "要注意の問診回答がある予約",
+		Body:     assessment.Summary,
+		Priority: "high",
+		Data: map[string]interface{}{
+			"appointment_id": appointment.ID,
+			"triage_id":      assessment.ID,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify doctor %d of triage red flag: %v", appointment.DoctorID, err)
+	}
+
+	s.auditService.LogUserAction(appointment.PatientID, "triage_red_flag", "appointment", fmt.Sprintf("%d", appointment.ID), map[string]interface{}{
+		"triage_id": assessment.ID,
+		"doctor_id": appointment.DoctorID,
+	})
+	return nil
+}
+
+func sameDependent(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// GetPatientAppointments 患者の予約一覧取得
+func (s *AppointmentService) GetPatientAppointments(patientID uint, limit, offset int) ([]models.Appointment, int64, error) {
+	appointments, total, err := s.appointmentRepo.FindPageByPatientID(patientID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	// 関連データの読み込み
 	for i := range appointments {
 		if err := s.appointmentRepo.LoadRelations(&appointments[i]); err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 	}
 
-	return appointments, nil
+	return appointments, total, nil
+}
+
+// GetDoctorAppointments 医師の予約一覧取得（ステータス・タグ・保存した絞り込み条件で絞り込む）
+func (s *AppointmentService) GetDoctorAppointments(doctorID uint, query DoctorAppointmentQuery, limit, offset int) ([]models.Appointment, int64, error) {
+	filter, err := s.tagService.AppointmentFilter(doctorID, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	appointments, total, err := s.appointmentRepo.FindPageByDoctorID(doctorID, filter, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 関連データの読み込み
+	for i := range appointments {
+		if err := s.appointmentRepo.LoadRelations(&appointments[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+	if err := s.tagService.AttachTags(doctorID, appointments); err != nil {
+		return nil, 0, err
+	}
+
+	return appointments, total, nil
 }
 
 // UpdateAppointmentStatus 予約ステータスの更新
@@ -141,8 +1101,19 @@ func (s *AppointmentService) UpdateAppointmentStatus(req UpdateAppointmentStatus
 		return nil, errors.New("unauthorized to update this appointment")
 	}
 
+	// 非同期相談のステータスは回答・終了操作で進める
+	if appointment.IsAsync && (req.Status == "pending" || req.Status == "confirmed") {
+		return nil, errors.New("async consultation status is updated by responding or closing")
+	}
+
 	// ステータスの更新
+	previousStatus := appointment.Status
+	wasCompleted := appointment.Status == "completed"
 	appointment.Status = req.Status
+	if appointment.Status == "completed" && !wasCompleted {
+		now := time.Now()
+		appointment.CompletedAt = &now
+	}
 	if req.Notes != "" {
 		appointment.Notes = req.Notes
 	}
@@ -150,6 +1121,112 @@ func (s *AppointmentService) UpdateAppointmentStatus(req UpdateAppointmentStatus
 	if err := s.appointmentRepo.Update(appointment); err != nil {
 		return nil, err
 	}
+	publishAppointmentStatus(s.hub, appointment)
+
+	if appointment.Status != previousStatus {
+		switch appointment.Status {
+		case "confirmed":
+			s.notifyAppointment(appointment.PatientID, appointment, "appointment_confirmed", "予約が確定しました")
+			s.paymentService.IssueInvoice(appointment)
+		case "cancelled":
+			s.notifyAppointment(appointment.PatientID, appointment, "appointment_cancelled", "予約がキャンセルされました")
+		}
+	}
+
+	// 完了時は患者へ診療サマリーを送る
+	if appointment.Status == "completed" && !wasCompleted {
+		s.visitSummaryService.SendSummaryEmail(appointment.ID)
+	}
+
+	if appointment.Status == "cancelled" {
+		s.releaseSlot(appointment.SlotID)
+		if appointment.InterpreterID != nil {
+			s.releaseInterpreter(appointment.ID)
+		}
+	}
+	if appointment.IsInstant && (appointment.Status == "completed" || appointment.Status == "cancelled") {
+		s.reopenInstant(appointment.DoctorID)
+	}
+
+	// 関連データの読み込み
+	if err := s.appointmentRepo.LoadRelations(appointment); err != nil {
+		return nil, err
+	}
+
+	return appointment, nil
+}
+
+// ReportDelay 医師の遅れの連絡（開始見込み時刻を更新し、患者へ通知する）
+// 遅れは予定の開始時刻（枠のない即時診療は予約日時）からの分数で、連絡し直した場合は最新の値で上書きする
+func (s *AppointmentService) ReportDelay(req ReportDelayRequest) (*models.Appointment, error) {
+	appointment, err := s.appointmentRepo.FindByID(req.AppointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+
+	if appointment.DoctorID != req.DoctorID {
+		return nil, errors.New("unauthorized to update this appointment")
+	}
+	if appointment.IsAsync {
+		return nil, errors.New("async consultation has no start time")
+	}
+	if appointment.Status != "confirmed" {
+		return nil, errors.New("only confirmed appointments can be delayed")
+	}
+
+	scheduledAt := appointment.CreatedAt
+	if appointment.SlotID != nil {
+		slot, err := s.slotRepo.FindByID(*appointment.SlotID)
+		if err != nil {
+			return nil, errors.New("slot not found")
+		}
+		scheduledAt = slot.StartTime
+	}
+	now := time.Now()
+	estimatedStartAt := scheduledAt.Add(time.Duration(req.DelayMinutes) * time.Minute)
+	if !estimatedStartAt.After(now) {
+		return nil, errors.New("estimated start time has already passed")
+	}
+
+	recorded, err := s.appointmentRepo.RecordDelay(appointment.ID, req.DelayMinutes, estimatedStartAt, now)
+	if err != nil {
+		return nil, err
+	}
+	if !recorded {
+		return nil, errors.New("only confirmed appointments can be delayed")
+	}
+	appointment.DelayMinutes = req.DelayMinutes
+	appointment.DelayReportedAt = &now
+	appointment.EstimatedStartAt = &estimatedStartAt
+
+	data := map[string]interface{}{
+		"appointment_id":     appointment.ID,
+		"delay_minutes":      req.DelayMinutes,
+		"estimated_start_at": estimatedStartAt,
+		"message":            req.Message,
+	}
+
+	body := fmt.Sprintf("開始が%d分ほど遅れる見込みです", req.DelayMinutes)
+	if location, err := s.bookingPolicyService.Location(); err == nil {
+		body = fmt.Sprintf("開始が%d分ほど遅れ、%s頃になる見込みです", req.DelayMinutes, estimatedStartAt.In(location).Format("15:04"))
+	}
+	if req.Message != "" {
+		body += "\n" + req.Message
+	}
+	if _, err := s.notificationService.Notify(appointment.PatientID, NotificationMessage{
+		Type:     "appointment_delayed",
+		Title:    "医師の診療開始が遅れています",
+		Body:     body,
+		Priority: "high",
+		Data:     data,
+	}); err != nil {
+		log.Printf("Warning: Failed to notify patient %d of delayed appointment %d: %v", appointment.PatientID, appointment.ID, err)
+	}
+
+	// 待合室で開始見込み時刻を表示している参加者へ送る
+	if err := s.hub.Publish(appointment.ParticipantIDs(), AppointmentDelayedEvent, data); err != nil {
+		log.Printf("Warning: Failed to publish delay of appointment %d: %v", appointment.ID, err)
+	}
 
 	// 関連データの読み込み
 	if err := s.appointmentRepo.LoadRelations(appointment); err != nil {
@@ -179,7 +1256,153 @@ func (s *AppointmentService) CancelAppointment(appointmentID, userID uint) error
 
 	// ステータスの更新
 	appointment.Status = "cancelled"
-	return s.appointmentRepo.Update(appointment)
+	if err := s.appointmentRepo.Update(appointment); err != nil {
+		return err
+	}
+	publishAppointmentStatus(s.hub, appointment)
+
+	s.releaseSlot(appointment.SlotID)
+	if appointment.InterpreterID != nil {
+		s.releaseInterpreter(appointment.ID)
+	}
+	if appointment.IsInstant {
+		s.reopenInstant(appointment.DoctorID)
+	}
+
+	// キャンセルした本人以外の参加者へ通知する
+	counterpartID := appointment.PatientID
+	if userID == appointment.PatientID {
+		counterpartID = appointment.DoctorID
+	}
+	s.notifyAppointment(counterpartID, appointment, "appointment_cancelled", "予約がキャンセルされました")
+	return nil
+}
+
+// CancelForScheduleConflict 医師の予定の不整合（診療枠がない・重複した予約）の解消のための予約の取り消し
+// 患者へ通知する
+func (s *AppointmentService) CancelForScheduleConflict(appointment *models.Appointment) error {
+	if appointment.Status == "completed" || appointment.Status == "cancelled" {
+		return errors.New("appointment cannot be cancelled")
+	}
+
+	appointment.Status = "cancelled"
+	appointment.CancelReason = "schedule_conflict"
+	if err := s.appointmentRepo.Update(appointment); err != nil {
+		return err
+	}
+	publishAppointmentStatus(s.hub, appointment)
+
+	s.releaseSlot(appointment.SlotID)
+	if appointment.InterpreterID != nil {
+		s.releaseInterpreter(appointment.ID)
+	}
+
+	if _, err := s.notificationService.Notify(appointment.PatientID, NotificationMessage{
+		Type:     "appointment_cancelled",
+		Title:    "予約がキャンセルされました",
+		Body:     "医師の予定の調整により予約はキャンセルされました。お手数ですが再度ご予約ください",
+		Priority: "high",
+		Data: map[string]interface{}{
+			"appointment_id": appointment.ID,
+			"reason":         appointment.CancelReason,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify patient %d of cancellation: %v", appointment.PatientID, err)
+	}
+	return nil
+}
+
+// CancelByStaff 組織で予約の管理を委任されたスタッフによる予約のキャンセル
+// 患者・医師・通訳者のすべてへ通知する
+func (s *AppointmentService) CancelByStaff(appointment *models.Appointment) error {
+	if appointment.Status == "completed" || appointment.Status == "cancelled" {
+		return errors.New("appointment cannot be cancelled")
+	}
+
+	appointment.Status = "cancelled"
+	appointment.CancelReason = "staff_cancelled"
+	if err := s.appointmentRepo.Update(appointment); err != nil {
+		return err
+	}
+	publishAppointmentStatus(s.hub, appointment)
+
+	s.releaseSlot(appointment.SlotID)
+	if appointment.InterpreterID != nil {
+		s.releaseInterpreter(appointment.ID)
+	}
+	if appointment.IsInstant {
+		s.reopenInstant(appointment.DoctorID)
+	}
+
+	for _, userID := range appointment.ParticipantIDs() {
+		s.notifyAppointment(userID, appointment, "appointment_cancelled", "予約がキャンセルされました")
+	}
+	return nil
+}
+
+// CancelForDeactivatedUser 退会したユーザーの未完了の予約を取り消し、相手方へ通知する
+// 通訳者として割り当てられている予約は通訳者の割り当てのみ解除する
+func (s *AppointmentService) CancelForDeactivatedUser(userID uint) (int, error) {
+	appointments, err := s.appointmentRepo.FindOpenByParticipant(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	for i := range appointments {
+		appointment := &appointments[i]
+
+		if appointment.PatientID != userID && appointment.DoctorID != userID {
+			appointment.InterpreterID = nil
+			if err := s.appointmentRepo.Update(appointment); err != nil {
+				log.Printf("Warning: Failed to unassign interpreter from appointment %d: %v", appointment.ID, err)
+				continue
+			}
+			s.releaseInterpreter(appointment.ID)
+			s.notificationService.NotifyMany([]uint{appointment.PatientID, appointment.DoctorID}, NotificationMessage{
+				Type:  "interpreter_unassigned",
+				Title: "通訳者の割り当てが解除されました",
+				Body:  "担当の通訳者が利用を終了したため、通訳者の割り当てが解除されました",
+				Data:  map[string]interface{}{"appointment_id": appointment.ID},
+			})
+			continue
+		}
+
+		appointment.Status = "cancelled"
+		appointment.CancelReason = "account_deactivated"
+		if err := s.appointmentRepo.Update(appointment); err != nil {
+			log.Printf("Warning: Failed to cancel appointment %d: %v", appointment.ID, err)
+			continue
+		}
+		cancelled++
+		publishAppointmentStatus(s.hub, appointment)
+
+		s.releaseSlot(appointment.SlotID)
+		if appointment.InterpreterID != nil {
+			s.releaseInterpreter(appointment.ID)
+		}
+
+		counterpartID := appointment.DoctorID
+		if appointment.DoctorID == userID {
+			counterpartID = appointment.PatientID
+		} else if appointment.IsInstant {
+			s.reopenInstant(appointment.DoctorID)
+		}
+		if _, err := s.notificationService.Notify(counterpartID, NotificationMessage{
+			Type:     "appointment_cancelled",
+			Title:    "予約がキャンセルされました",
+			Body:     "相手方のアカウントが利用停止されたため、予約はキャンセルされました",
+			Priority: "high",
+			Data: map[string]interface{}{
+				"appointment_id": appointment.ID,
+				"reason":         appointment.CancelReason,
+			},
+		}); err != nil {
+			log.Printf("Warning: Failed to notify user %d of cancellation: %v", counterpartID, err)
+		}
+	}
+
+	return cancelled, nil
 }
 
 // GetAppointmentDetails 予約詳細の取得
@@ -190,8 +1413,8 @@ func (s *AppointmentService) GetAppointmentDetails(appointmentID, userID uint) (
 		return nil, errors.New("appointment not found")
 	}
 
-	// 権限確認（患者または医師のみ）
-	if appointment.PatientID != userID && appointment.DoctorID != userID {
+	// 権限確認（患者・医師・通訳者のみ）
+	if !appointment.IsParticipant(userID) {
 		return nil, errors.New("unauthorized to view this appointment")
 	}
 
@@ -200,5 +1423,13 @@ func (s *AppointmentService) GetAppointmentDetails(appointmentID, userID uint) (
 		return nil, err
 	}
 
+	// 共有された診療記録は担当医師と患者本人のみに表示する
+	if appointment.PatientID != userID && appointment.DoctorID != userID {
+		appointment.SharedDocuments = nil
+	}
+
+	// PHI閲覧ログの記録
+	s.auditService.LogPHIAccess(userID, appointment.PatientID, "appointment", fmt.Sprintf("%d", appointment.ID), nil)
+
 	return appointment, nil
 }
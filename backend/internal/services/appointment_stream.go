@@ -0,0 +1,29 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/realtime"
+)
+
+// 予約の状態の変化を参加者へ送るイベント（/ws/appointments で受信する）
+const (
+	AppointmentStreamTopic  = "appointment."
+	AppointmentStatusEvent  = "appointment.status"  // 予約の作成・確定・キャンセル・完了
+	AppointmentDelayedEvent = "appointment.delayed" // 医師の遅れ
+)
+
+// publishAppointmentStatus 予約のステータスを参加者（患者・医師・通訳者）の接続中の端末へ送る
+// 一覧の再取得のきっかけとするための通知で、届かなくても予約の処理は続ける
+func publishAppointmentStatus(hub *realtime.Hub, appointment *models.Appointment) {
+	if err := hub.Publish(appointment.ParticipantIDs(), AppointmentStatusEvent, map[string]interface{}{
+		"appointment_id": appointment.ID,
+		"status":         appointment.Status,
+		"cancel_reason":  appointment.CancelReason,
+		"changed_at":     time.Now(),
+	}); err != nil {
+		log.Printf("Warning: Failed to publish status of appointment %d: %v", appointment.ID, err)
+	}
+}
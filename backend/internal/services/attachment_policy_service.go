@@ -0,0 +1,249 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 添付ファイルの制限を再読み込みするまでの間隔（アップロードごとにデータベースを参照しないため）
+const attachmentPolicyCacheTTL = time.Minute
+
+// 管理者が設定できる添付ファイルのサイズの上限
+const attachmentMaxSizeLimit = 1 << 30 // 1GB
+
+// 初期値で添付できる形式
+var defaultAttachmentTypes = []string{"image/jpeg", "image/png", "image/gif", "application/pdf"}
+
+// 添付ファイルを拒否した理由のコード
+const (
+	AttachmentCodeTypeNotAllowed = "attachment_type_not_allowed"
+	AttachmentCodeTooLarge       = "attachment_too_large"
+)
+
+// AttachmentRejectedError ロールの制限により添付ファイルを拒否した
+type AttachmentRejectedError struct {
+	Code         string   `json:"code"`
+	ContentType  string   `json:"content_type,omitempty"`
+	SizeBytes    int64    `json:"size_bytes,omitempty"`
+	MaxSizeBytes int64    `json:"max_size_bytes"`
+	AllowedTypes []string `json:"allowed_types"`
+}
+
+func (e *AttachmentRejectedError) Error() string {
+	if e.Code == AttachmentCodeTooLarge {
+		return fmt.Sprintf("attachment exceeds the maximum size of %d bytes", e.MaxSizeBytes)
+	}
+	return fmt.Sprintf("attachment type %q is not allowed", e.ContentType)
+}
+
+// AttachmentPolicyService ロールごとのチャットの添付ファイルの形式・サイズの制限
+type AttachmentPolicyService struct {
+	policyRepo      repositories.AttachmentPolicyRepository
+	userRepo        repositories.UserRepository
+	auditService    *AuditService
+	defaultMaxBytes int64
+
+	mu       sync.Mutex
+	cached   map[string]*models.AttachmentPolicy
+	cachedAt time.Time
+}
+
+// UpdateAttachmentPolicyRequest ロールの添付ファイルの制限の部分更新（nilの項目は変更しない）
+type UpdateAttachmentPolicyRequest struct {
+	MaxSizeBytes *int64    `json:"max_size_bytes"`
+	AllowedTypes *[]string `json:"allowed_types"`
+}
+
+func NewAttachmentPolicyService(policyRepo repositories.AttachmentPolicyRepository, userRepo repositories.UserRepository, auditService *AuditService, defaultMaxBytes int64) *AttachmentPolicyService {
+	return &AttachmentPolicyService{
+		policyRepo:      policyRepo,
+		userRepo:        userRepo,
+		auditService:    auditService,
+		defaultMaxBytes: defaultMaxBytes,
+	}
+}
+
+// GetPolicies ロールごとの添付ファイルの制限の一覧（管理者のみ、未設定のロールは初期値）
+func (s *AttachmentPolicyService) GetPolicies(adminID uint) ([]models.AttachmentPolicy, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	saved, err := s.policyRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	byRole := make(map[string]models.AttachmentPolicy, len(saved))
+	for _, attachmentPolicy := range saved {
+		byRole[attachmentPolicy.Role] = attachmentPolicy
+	}
+
+	roles := []string{policy.RolePatient, policy.RoleDoctor, policy.RoleInterpreter, policy.RoleAdmin}
+	policies := make([]models.AttachmentPolicy, 0, len(roles))
+	for _, role := range roles {
+		if attachmentPolicy, ok := byRole[role]; ok {
+			policies = append(policies, attachmentPolicy)
+		} else {
+			policies = append(policies, s.defaultPolicy(role))
+		}
+	}
+	return policies, nil
+}
+
+// GetPolicyForUser 利用者のロールの添付ファイルの制限（アップロード前の表示用）
+func (s *AttachmentPolicyService) GetPolicyForUser(userID uint) (*models.AttachmentPolicy, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return s.policyFor(user.Role)
+}
+
+// UpdatePolicy ロールの添付ファイルの制限の更新（管理者のみ）
+func (s *AttachmentPolicyService) UpdatePolicy(adminID uint, role string, req UpdateAttachmentPolicyRequest) (*models.AttachmentPolicy, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	if !policy.Allows(role, policy.RolePatient, policy.RoleDoctor, policy.RoleInterpreter, policy.RoleAdmin) {
+		return nil, errors.New("invalid role")
+	}
+
+	attachmentPolicy, err := s.policyRepo.FindByRole(role)
+	if err != nil {
+		return nil, err
+	}
+	if attachmentPolicy == nil {
+		defaults := s.defaultPolicy(role)
+		attachmentPolicy = &defaults
+	}
+
+	if req.MaxSizeBytes != nil {
+		if *req.MaxSizeBytes <= 0 || *req.MaxSizeBytes > attachmentMaxSizeLimit {
+			return nil, fmt.Errorf("invalid max_size_bytes: must be between 1 and %d", attachmentMaxSizeLimit)
+		}
+		attachmentPolicy.MaxSizeBytes = *req.MaxSizeBytes
+	}
+	if req.AllowedTypes != nil {
+		types, err := normalizeAttachmentTypes(*req.AllowedTypes)
+		if err != nil {
+			return nil, err
+		}
+		attachmentPolicy.AllowedTypes = strings.Join(types, ",")
+	}
+
+	attachmentPolicy.UpdatedByID = &adminID
+	if err := s.policyRepo.Save(attachmentPolicy); err != nil {
+		return nil, err
+	}
+
+	// このインスタンスでは直ちに反映する（他のインスタンスは再読み込みの間隔の経過後に反映）
+	s.mu.Lock()
+	s.cached = nil
+	s.mu.Unlock()
+
+	s.auditService.LogUserAction(adminID, "attachment_policy_updated", "attachment_policy", role, attachmentPolicy)
+
+	return attachmentPolicy, nil
+}
+
+// CheckUpload ロールの制限による添付ファイルの形式・サイズの確認（拒否した場合は *AttachmentRejectedError）
+func (s *AttachmentPolicyService) CheckUpload(role, contentType string, size int64) error {
+	attachmentPolicy, err := s.policyFor(role)
+	if err != nil {
+		return err
+	}
+
+	allowed := splitAttachmentTypes(attachmentPolicy.AllowedTypes)
+	rejected := &AttachmentRejectedError{
+		ContentType:  contentType,
+		SizeBytes:    size,
+		MaxSizeBytes: attachmentPolicy.MaxSizeBytes,
+		AllowedTypes: allowed,
+	}
+	if !slices.Contains(allowed, contentType) {
+		rejected.Code = AttachmentCodeTypeNotAllowed
+		return rejected
+	}
+	if size > attachmentPolicy.MaxSizeBytes {
+		rejected.Code = AttachmentCodeTooLarge
+		return rejected
+	}
+	return nil
+}
+
+// policyFor ロールの添付ファイルの制限（再読み込みの間隔内であれば前回読み込んだ設定を使う）
+func (s *AttachmentPolicyService) policyFor(role string) (*models.AttachmentPolicy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached == nil || time.Since(s.cachedAt) >= attachmentPolicyCacheTTL {
+		saved, err := s.policyRepo.FindAll()
+		if err != nil {
+			return nil, err
+		}
+		s.cached = make(map[string]*models.AttachmentPolicy, len(saved))
+		for i := range saved {
+			s.cached[saved[i].Role] = &saved[i]
+		}
+		s.cachedAt = time.Now()
+	}
+
+	if attachmentPolicy, ok := s.cached[role]; ok {
+		copied := *attachmentPolicy
+		return &copied, nil
+	}
+	defaults := s.defaultPolicy(role)
+	return &defaults, nil
+}
+
+func (s *AttachmentPolicyService) defaultPolicy(role string) models.AttachmentPolicy {
+	return models.AttachmentPolicy{
+		Role:         role,
+		MaxSizeBytes: s.defaultMaxBytes,
+		AllowedTypes: strings.Join(defaultAttachmentTypes, ","),
+	}
+}
+
+func (s *AttachmentPolicyService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
+
+// normalizeAttachmentTypes 添付できる形式の確認（チャットで扱える形式のみ、重複を除き並べ替える）
+func normalizeAttachmentTypes(types []string) ([]string, error) {
+	seen := make(map[string]bool, len(types))
+	var result []string
+	for _, contentType := range types {
+		contentType = strings.ToLower(strings.TrimSpace(contentType))
+		if _, ok := chatAttachmentContentTypes[contentType]; !ok {
+			return nil, fmt.Errorf("invalid attachment type: %s", contentType)
+		}
+		if !seen[contentType] {
+			seen[contentType] = true
+			result = append(result, contentType)
+		}
+	}
+	if len(result) == 0 {
+		return nil, errors.New("invalid allowed_types: at least one type is required")
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func splitAttachmentTypes(value string) []string {
+	var types []string
+	for _, contentType := range strings.Split(value, ",") {
+		if contentType = strings.TrimSpace(contentType); contentType != "" {
+			types = append(types, contentType)
+		}
+	}
+	return types
+}
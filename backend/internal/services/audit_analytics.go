@@ -0,0 +1,167 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+// 仮名化のソルトの長さ（バイト）
+const auditPseudonymSaltBytes = 32
+
+// auditAnalyticsHeaders 分析用エクスポートのCSV/XLSX形式のヘッダー
+var auditAnalyticsHeaders = []string{"ID", "User", "Role", "Patient", "Action", "Entity", "Entity ID", "Severity", "Timestamp", "Salt Version"}
+
+// AuditAnalyticsRecord 分析用エクスポートの監査ログ（利用者IDは仮名に置き換え、メタデータは含めない）
+// 同じソルトの版の間は同じ利用者に同じ仮名を割り当てるため、利用状況の集計・突き合わせに使える
+type AuditAnalyticsRecord struct {
+	ID          uint      `json:"id"`
+	User        string    `json:"user,omitempty"`
+	Role        string    `json:"role,omitempty"`
+	Patient     string    `json:"patient,omitempty"`
+	Action      string    `json:"action"`
+	Entity      string    `json:"entity"`
+	EntityID    string    `json:"entity_id"`
+	Severity    string    `json:"severity"`
+	At          time.Time `json:"at"`
+	SaltVersion uint      `json:"salt_version"`
+}
+
+// auditPseudonymizer 1回のエクスポートでの利用者IDの仮名化
+type auditPseudonymizer struct {
+	service     *AuditService
+	key         []byte
+	saltVersion uint
+	roles       map[uint]string // 利用者のロール（エクスポート中のみ保持）
+}
+
+// newPseudonymizer 最新のソルトによる仮名化（ソルトが未作成の場合は作成する）
+func (s *AuditService) newPseudonymizer() (*auditPseudonymizer, error) {
+	salt, err := s.auditRepo.FindCurrentPseudonymSalt()
+	if err != nil {
+		return nil, err
+	}
+	if salt == nil {
+		if salt, err = s.rotatePseudonymSalt(); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := hex.DecodeString(salt.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pseudonym salt %d", salt.ID)
+	}
+	return &auditPseudonymizer{
+		service:     s,
+		key:         key,
+		saltVersion: salt.ID,
+		roles:       make(map[uint]string),
+	}, nil
+}
+
+// RunPseudonymSaltRotationJob 切り替え間隔を過ぎた仮名化のソルトを新しいソルトに切り替える
+func (s *AuditService) RunPseudonymSaltRotationJob() error {
+	salt, err := s.auditRepo.FindCurrentPseudonymSalt()
+	if err != nil {
+		return err
+	}
+	if salt != nil && time.Since(salt.CreatedAt) < s.pseudonymSaltRotation {
+		return nil
+	}
+	_, err = s.rotatePseudonymSalt()
+	return err
+}
+
+// rotatePseudonymSalt 新しいソルトの作成と古いソルトの削除
+func (s *AuditService) rotatePseudonymSalt() (*models.AuditPseudonymSalt, error) {
+	buf := make([]byte, auditPseudonymSaltBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	salt := &models.AuditPseudonymSalt{Salt: hex.EncodeToString(buf)}
+	if err := s.auditRepo.CreatePseudonymSalt(salt); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.auditRepo.DeletePseudonymSaltsBefore(salt.ID); err != nil {
+		log.Printf("Warning: Failed to delete old pseudonym salts: %v", err)
+	}
+	s.LogSystemAction("audit_pseudonym_salt_rotated", "audit_pseudonym_salt", fmt.Sprintf("%d", salt.ID), nil)
+	return salt, nil
+}
+
+// pseudonym 利用者IDの仮名（HMAC-SHA256の先頭16文字）
+func (p *auditPseudonymizer) pseudonym(userID *uint) string {
+	if userID == nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, p.key)
+	fmt.Fprintf(mac, "user:%d", *userID)
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// role 利用者のロール（取得できない場合は空）
+func (p *auditPseudonymizer) role(userID *uint) string {
+	if userID == nil {
+		return ""
+	}
+	if role, ok := p.roles[*userID]; ok {
+		return role
+	}
+	role := ""
+	if user, err := p.service.userRepo.FindByID(*userID); err == nil && user != nil {
+		role = user.Role
+	}
+	p.roles[*userID] = role
+	return role
+}
+
+// record 監査ログの分析用の記録
+func (p *auditPseudonymizer) record(auditLog *models.AuditLog) AuditAnalyticsRecord {
+	entityID := auditLog.EntityID
+	// 利用者を対象とする記録は対象のIDも仮名にする
+	if auditLog.Entity == "user" || auditLog.Entity == "patient" {
+		entityID = ""
+		if id, err := strconv.ParseUint(auditLog.EntityID, 10, 32); err == nil {
+			userID := uint(id)
+			entityID = p.pseudonym(&userID)
+		}
+	}
+
+	return AuditAnalyticsRecord{
+		ID:          auditLog.ID,
+		User:        p.pseudonym(auditLog.UserID),
+		Role:        p.role(auditLog.UserID),
+		Patient:     p.pseudonym(auditLog.PatientID),
+		Action:      auditLog.Action,
+		Entity:      auditLog.Entity,
+		EntityID:    entityID,
+		Severity:    auditLog.Severity,
+		At:          auditLog.At,
+		SaltVersion: p.saltVersion,
+	}
+}
+
+// row CSV/XLSX用の1行分のデータ
+func (p *auditPseudonymizer) row(auditLog *models.AuditLog) []string {
+	record := p.record(auditLog)
+	return []string{
+		fmt.Sprintf("%d", record.ID),
+		record.User,
+		record.Role,
+		record.Patient,
+		record.Action,
+		record.Entity,
+		record.EntityID,
+		record.Severity,
+		record.At.Format(time.RFC3339),
+		fmt.Sprintf("%d", record.SaltVersion),
+	}
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 1アーカイブファイルあたりの最大件数
+const auditArchiveBatchSize = 10000
+
+type AuditArchiveService struct {
+	archiveRepo   repositories.AuditArchiveRepository
+	userRepo      repositories.UserRepository
+	archiveDir    string
+	retentionDays int
+	rehydrateDays int
+}
+
+type AuditArchiveSearchRequest struct {
+	UserID    *uint  `form:"user_id"`
+	PatientID *uint  `form:"patient_id"`
+	Entity    string `form:"entity"`
+	EntityID  string `form:"entity_id"`
+	Action    string `form:"action"`
+	StartDate string `form:"start_date"`
+	EndDate   string `form:"end_date"`
+	Limit     int    `form:"limit"`
+	Offset    int    `form:"offset"`
+}
+
+func NewAuditArchiveService(archiveRepo repositories.AuditArchiveRepository, userRepo repositories.UserRepository, archiveDir string, retentionDays, rehydrateDays int) *AuditArchiveService {
+	// アーカイブディレクトリの作成
+	if err := os.MkdirAll(archiveDir, 0750); err != nil {
+		fmt.Printf("Warning: Failed to create audit archive directory: %v\n", err)
+	}
+
+	return &AuditArchiveService{
+		archiveRepo:   archiveRepo,
+		userRepo:      userRepo,
+		archiveDir:    archiveDir,
+		retentionDays: retentionDays,
+		rehydrateDays: rehydrateDays,
+	}
+}
+
+// RunArchiveJob 定期ジョブ：期限切れ復元の後片付けと、保存期間を過ぎたログのアーカイブ
+func (s *AuditArchiveService) RunArchiveJob() error {
+	if err := s.purgeExpiredRehydrations(); err != nil {
+		return err
+	}
+
+	total, err := s.ArchiveExpiredLogs()
+	if err != nil {
+		return err
+	}
+	if total > 0 {
+		log.Printf("Archived %d audit logs", total)
+	}
+	return nil
+}
+
+// ArchiveExpiredLogs 保存期間を過ぎた監査ログを圧縮ファイルへ移動する
+func (s *AuditArchiveService) ArchiveExpiredLogs() (int, error) {
+	cutoff := time.Now().AddDate(0, 0, -s.retentionDays)
+	total := 0
+
+	for {
+		logs, err := s.archiveRepo.FindArchivableLogs(cutoff, auditArchiveBatchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(logs) == 0 {
+			return total, nil
+		}
+
+		if err := s.archiveBatch(logs); err != nil {
+			return total, err
+		}
+		total += len(logs)
+
+		if len(logs) < auditArchiveBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// archiveBatch 1バッチ分のログをNDJSON(gzip)として書き出し、索引を登録する
+func (s *AuditArchiveService) archiveBatch(logs []models.AuditLog) error {
+	fromAt, toAt := logs[0].At, logs[0].At
+	for _, l := range logs {
+		if l.At.Before(fromAt) {
+			fromAt = l.At
+		}
+		if l.At.After(toAt) {
+			toAt = l.At
+		}
+	}
+
+	objectKey := fmt.Sprintf("%s/audit_logs_%d_%d.ndjson.gz", fromAt.Format("2006/01"), logs[0].ID, logs[len(logs)-1].ID)
+	path := filepath.Join(s.archiveDir, filepath.FromSlash(objectKey))
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create archive directory: %v", err)
+	}
+
+	size, checksum, err := writeNDJSONGzip(path, logs)
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	archive := &models.AuditArchive{
+		ObjectKey:   objectKey,
+		Format:      "ndjson.gz",
+		FromAt:      fromAt,
+		ToAt:        toAt,
+		RecordCount: len(logs),
+		SizeBytes:   size,
+		Checksum:    checksum,
+		Status:      "archived",
+	}
+
+	if err := s.archiveRepo.CreateArchive(archive, logs); err != nil {
+		// DB登録に失敗した場合はファイルも削除して整合性を保つ
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// writeNDJSONGzip ログをNDJSON(gzip)形式で書き込み、サイズとSHA-256を返す
+func writeNDJSONGzip(path string, logs []models.AuditLog) (int64, string, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create archive file: %v", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(file, hash)}
+	gz := gzip.NewWriter(counter)
+	encoder := json.NewEncoder(gz)
+
+	for i := range logs {
+		logs[i].User = nil
+		if err := encoder.Encode(&logs[i]); err != nil {
+			return 0, "", fmt.Errorf("failed to encode audit log: %v", err)
+		}
+	}
+
+	if err := gz.Close(); err != nil {
+		return 0, "", err
+	}
+	if err := file.Sync(); err != nil {
+		return 0, "", err
+	}
+
+	return counter.n, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// GetArchives アーカイブ一覧の取得（管理者用）
+func (s *AuditArchiveService) GetArchives(limit, offset int, userID uint) ([]models.AuditArchive, error) {
+	if err := s.requireAdmin(userID); err != nil {
+		return nil, err
+	}
+	return s.archiveRepo.FindAll(limit, offset)
+}
+
+// SearchArchivedLogs アーカイブ索引の検索（管理者用）
+func (s *AuditArchiveService) SearchArchivedLogs(req AuditArchiveSearchRequest, userID uint) ([]models.AuditArchiveEntry, error) {
+	if err := s.requireAdmin(userID); err != nil {
+		return nil, err
+	}
+
+	filter := repositories.AuditArchiveEntryFilter{
+		UserID:    req.UserID,
+		PatientID: req.PatientID,
+		Entity:    req.Entity,
+		EntityID:  req.EntityID,
+		Action:    req.Action,
+	}
+	if req.StartDate != "" {
+		from, err := time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			return nil, errors.New("invalid start date format")
+		}
+		filter.From = &from
+	}
+	if req.EndDate != "" {
+		to, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			return nil, errors.New("invalid end date format")
+		}
+		to = to.Add(24*time.Hour - time.Nanosecond)
+		filter.To = &to
+	}
+
+	return s.archiveRepo.SearchEntries(filter, req.Limit, req.Offset)
+}
+
+// RehydrateArchive アーカイブを一時的に監査ログテーブルへ復元する（調査用）
+func (s *AuditArchiveService) RehydrateArchive(archiveID, userID uint) (*models.AuditArchive, error) {
+	if err := s.requireAdmin(userID); err != nil {
+		return nil, err
+	}
+
+	archive, err := s.archiveRepo.FindByID(archiveID)
+	if err != nil || archive == nil {
+		return nil, errors.New("archive not found")
+	}
+
+	logs, err := s.readArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	until := now.AddDate(0, 0, s.rehydrateDays)
+	archive.Status = "rehydrated"
+	archive.RehydratedAt = &now
+	archive.RehydratedUntil = &until
+
+	if err := s.archiveRepo.RestoreLogs(archive, logs); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// readArchive アーカイブファイルを読み込み、チェックサムを検証する
+func (s *AuditArchiveService) readArchive(archive *models.AuditArchive) ([]models.AuditLog, error) {
+	path := filepath.Join(s.archiveDir, filepath.FromSlash(archive.ObjectKey))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive file: %v", err)
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != archive.Checksum {
+		return nil, errors.New("archive checksum mismatch")
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %v", err)
+	}
+	defer gz.Close()
+
+	logs := make([]models.AuditLog, 0, archive.RecordCount)
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var l models.AuditLog
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil {
+			return nil, fmt.Errorf("failed to decode archived log: %v", err)
+		}
+		logs = append(logs, l)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// purgeExpiredRehydrations 復元期限を過ぎたログを監査ログテーブルから取り除く
+func (s *AuditArchiveService) purgeExpiredRehydrations() error {
+	archives, err := s.archiveRepo.FindExpiredRehydrations(time.Now())
+	if err != nil {
+		return err
+	}
+	for i := range archives {
+		if err := s.archiveRepo.PurgeRehydratedLogs(&archives[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requireAdmin 管理者権限の確認
+func (s *AuditArchiveService) requireAdmin(userID uint) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return errors.New("user not found")
+	}
+	if user.Role != "admin" {
+		return errors.New("insufficient permissions")
+	}
+	return nil
+}
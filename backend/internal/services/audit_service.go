@@ -5,32 +5,53 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strings"
+	"io"
 	"time"
 
+	"gorm.io/gorm"
+	"online_medical_consultation_app/backend/internal/alerting"
+	"online_medical_consultation_app/backend/internal/export"
 	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
 	"online_medical_consultation_app/backend/internal/repositories"
 )
 
 type AuditService struct {
 	auditRepo repositories.AuditRepository
 	userRepo  repositories.UserRepository
+
+	// 書き込みの失敗（監査ログの欠落）の通知
+	alerter       *alerting.Alerter
+	dropThreshold int
+	dropWindow    time.Duration
+
+	// 分析用エクスポートの仮名化のソルトの切り替え間隔
+	pseudonymSaltRotation time.Duration
 }
 
 type AuditLogFilter struct {
 	Entity    string `json:"entity"`
 	EntityID  string `json:"entity_id"`
 	Action    string `json:"action"`
+	Severity  string `json:"severity"` // info | high
 	StartDate string `json:"start_date"`
 	EndDate   string `json:"end_date"`
 	Limit     int    `json:"limit"`
 	Offset    int    `json:"offset"`
+
+	// キーセットページネーション（指定時はOffsetより優先）
+	Cursor       string `json:"cursor"`
+	IncludeTotal bool   `json:"include_total"`
 }
 
-func NewAuditService(auditRepo repositories.AuditRepository, userRepo repositories.UserRepository) *AuditService {
+func NewAuditService(auditRepo repositories.AuditRepository, userRepo repositories.UserRepository, alerter *alerting.Alerter, dropThreshold int, dropWindow time.Duration, pseudonymSaltRotation time.Duration) *AuditService {
 	return &AuditService{
-		auditRepo: auditRepo,
-		userRepo:  userRepo,
+		auditRepo:             auditRepo,
+		userRepo:              userRepo,
+		alerter:               alerter,
+		dropThreshold:         dropThreshold,
+		dropWindow:            dropWindow,
+		pseudonymSaltRotation: pseudonymSaltRotation,
 	}
 }
 
@@ -56,11 +77,21 @@ func (s *AuditService) CreateAuditLog(userID *uint, action, entity, entityID str
 		At:       time.Now(),
 	}
 
-	return s.auditRepo.Create(auditLog)
+	return s.create(auditLog)
+}
+
+// create 監査ログの書き込み（失敗は欠落として一定期間内の件数を監視する）
+func (s *AuditService) create(auditLog *models.AuditLog) error {
+	if err := s.auditRepo.Create(auditLog); err != nil {
+		s.alerter.Count(alerting.EventAuditWriteDropped, alerting.EventAuditWriteDropped, s.dropThreshold, s.dropWindow,
+			"Audit log writes are being dropped", map[string]interface{}{"action": auditLog.Action, "error": err.Error()})
+		return err
+	}
+	return nil
 }
 
 // GetAuditLogs 監査ログ一覧の取得
-func (s *AuditService) GetAuditLogs(filter AuditLogFilter, userID uint) ([]models.AuditLog, error) {
+func (s *AuditService) GetAuditLogs(filter AuditLogFilter, userID uint) (*repositories.AuditLogPage, error) {
 	// 管理者権限のチェック（簡易版）
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil || user == nil {
@@ -68,47 +99,21 @@ func (s *AuditService) GetAuditLogs(filter AuditLogFilter, userID uint) ([]model
 	}
 
 	// 管理者のみアクセス可能（実際の実装ではより詳細な権限チェックが必要）
-	if user.Role != "admin" {
+	if !policy.IsAdmin(user) {
 		return nil, errors.New("insufficient permissions")
 	}
 
 	// フィルタの適用
-	query := s.auditRepo.GetDB()
-	
-	if filter.Entity != "" {
-		query = query.Where("entity = ?", filter.Entity)
-	}
-	if filter.EntityID != "" {
-		query = query.Where("entity_id = ?", filter.EntityID)
-	}
-	if filter.Action != "" {
-		query = query.Where("action = ?", filter.Action)
-	}
-	if filter.StartDate != "" {
-		query = query.Where("DATE(at) >= ?", filter.StartDate)
-	}
-	if filter.EndDate != "" {
-		query = query.Where("DATE(at) <= ?", filter.EndDate)
-	}
-
-	// 監査ログの取得
-	logs, err := s.auditRepo.FindWithFilter(query, filter.Limit, filter.Offset)
+	query, err := s.applyFilter(s.auditRepo.GetDB(), filter)
 	if err != nil {
 		return nil, err
 	}
 
-	// 関連データの読み込み
-	for i := range logs {
-		if err := s.auditRepo.LoadRelations(&logs[i]); err != nil {
-			return nil, err
-		}
-	}
-
-	return logs, nil
+	return s.findPage(query, filter)
 }
 
 // GetUserAuditLogs 特定ユーザーの監査ログ取得
-func (s *AuditService) GetUserAuditLogs(targetUserID uint, limit, offset int, userID uint) ([]models.AuditLog, error) {
+func (s *AuditService) GetUserAuditLogs(targetUserID uint, filter AuditLogFilter, userID uint) (*repositories.AuditLogPage, error) {
 	// 権限チェック
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil || user == nil {
@@ -116,28 +121,16 @@ func (s *AuditService) GetUserAuditLogs(targetUserID uint, limit, offset int, us
 	}
 
 	// 自分自身のログまたは管理者のみアクセス可能
-	if userID != targetUserID && user.Role != "admin" {
+	if userID != targetUserID && !policy.IsAdmin(user) {
 		return nil, errors.New("insufficient permissions")
 	}
 
-	// ユーザーの監査ログを取得
-	logs, err := s.auditRepo.FindByUserID(targetUserID, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-
-	// 関連データの読み込み
-	for i := range logs {
-		if err := s.auditRepo.LoadRelations(&logs[i]); err != nil {
-			return nil, err
-		}
-	}
-
-	return logs, nil
+	query := s.auditRepo.GetDB().Where("user_id = ?", targetUserID)
+	return s.findPage(query, filter)
 }
 
 // GetEntityAuditLogs 特定エンティティの監査ログ取得
-func (s *AuditService) GetEntityAuditLogs(entity, entityID string, limit, offset int, userID uint) ([]models.AuditLog, error) {
+func (s *AuditService) GetEntityAuditLogs(entity, entityID string, filter AuditLogFilter, userID uint) (*repositories.AuditLogPage, error) {
 	// 権限チェック
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil || user == nil {
@@ -145,100 +138,256 @@ func (s *AuditService) GetEntityAuditLogs(entity, entityID string, limit, offset
 	}
 
 	// 管理者のみアクセス可能
-	if user.Role != "admin" {
+	if !policy.IsAdmin(user) {
 		return nil, errors.New("insufficient permissions")
 	}
 
-	// エンティティの監査ログを取得
-	logs, err := s.auditRepo.FindByEntity(entity, entityID, limit, offset)
+	query := s.auditRepo.GetDB().Where("entity = ? AND entity_id = ?", entity, entityID)
+	return s.findPage(query, filter)
+}
+
+// GetMembersAuditLogs 組織に所属するメンバーの操作の監査ログ（組織の監査担当用、権限の確認は呼び出し側で行う）
+func (s *AuditService) GetMembersAuditLogs(userIDs []uint, filter AuditLogFilter) (*repositories.AuditLogPage, error) {
+	query, err := s.applyFilter(s.auditRepo.GetDB().Where("user_id IN ?", userIDs), filter)
 	if err != nil {
 		return nil, err
 	}
+	return s.findPage(query, filter)
+}
 
-	// 関連データの読み込み
-	for i := range logs {
-		if err := s.auditRepo.LoadRelations(&logs[i]); err != nil {
+// findPage カーソルの解釈とページ取得
+func (s *AuditService) findPage(query *gorm.DB, filter AuditLogFilter) (*repositories.AuditLogPage, error) {
+	var cursor *repositories.AuditCursor
+	if filter.Cursor != "" {
+		decoded, err := repositories.DecodeAuditCursor(filter.Cursor)
+		if err != nil {
 			return nil, err
 		}
+		cursor = decoded
 	}
 
-	return logs, nil
+	return s.auditRepo.FindPage(query, cursor, filter.Limit, filter.Offset, filter.IncludeTotal)
+}
+
+// AuditExport ストリーミング形式の監査ログエクスポート
+type AuditExport struct {
+	Filename    string
+	ContentType string
+
+	service *AuditService
+	filter  AuditLogFilter
+	format  string
+
+	// 分析用エクスポートの場合のみ（利用者IDを仮名に置き換え、メタデータを含めない）
+	pseudonymizer *auditPseudonymizer
 }
 
-// ExportAuditLogs 監査ログのエクスポート
-func (s *AuditService) ExportAuditLogs(filter AuditLogFilter, format string, userID uint) ([]byte, string, error) {
+// 監査ログのエクスポートの種類
+const (
+	AuditExportModeFull          = "full"          // 監査ログをそのまま出力する
+	AuditExportModePseudonymized = "pseudonymized" // 分析用に利用者IDを仮名化して出力する
+)
+
+// auditExportContentTypes 対応しているエクスポート形式
+var auditExportContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"json":   "application/json",
+	"ndjson": "application/x-ndjson",
+	"xlsx":   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// auditExportHeaders CSV/XLSX形式のヘッダー
+var auditExportHeaders = []string{"ID", "User ID", "Action", "Entity", "Entity ID", "Meta Data", "Timestamp", "Created At"}
+
+// IsSupportedAuditExportFormat エクスポート形式の確認
+func IsSupportedAuditExportFormat(format string) bool {
+	_, ok := auditExportContentTypes[format]
+	return ok
+}
+
+// ExportAuditLogs 監査ログのエクスポート準備（権限確認とフィルタの検証）
+// 実際の書き込みは AuditExport.Stream でストリーミングして行う
+func (s *AuditService) ExportAuditLogs(filter AuditLogFilter, format, mode string, userID uint) (*AuditExport, error) {
 	// 管理者権限のチェック
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil || user == nil {
-		return nil, "", errors.New("user not found")
+		return nil, errors.New("user not found")
 	}
 
-	if user.Role != "admin" {
-		return nil, "", errors.New("insufficient permissions")
+	if !policy.IsAdmin(user) {
+		return nil, errors.New("insufficient permissions")
 	}
 
-	// 監査ログの取得
-	logs, err := s.GetAuditLogs(filter, userID)
-	if err != nil {
-		return nil, "", err
+	contentType, ok := auditExportContentTypes[format]
+	if !ok {
+		return nil, errors.New("unsupported export format")
 	}
 
-	// ファイル名の生成
-	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("audit_logs_%s.%s", timestamp, format)
+	// フィルタを事前に検証しておく（ヘッダー送信後はエラーを返せないため）
+	if _, err := s.applyFilter(s.auditRepo.GetDB(), filter); err != nil {
+		return nil, err
+	}
 
-	var data []byte
-	if format == "csv" {
-		data, err = s.exportToCSV(logs)
-	} else if format == "json" {
-		data, err = json.MarshalIndent(logs, "", "  ")
-	} else {
-		return nil, "", errors.New("unsupported export format")
+	auditExport := &AuditExport{
+		ContentType: contentType,
+		service:     s,
+		filter:      filter,
+		format:      format,
 	}
 
-	if err != nil {
-		return nil, "", err
+	// ファイル名の生成
+	timestamp := time.Now().Format("20060102_150405")
+	switch mode {
+	case "", AuditExportModeFull:
+		auditExport.Filename = fmt.Sprintf("audit_logs_%s.%s", timestamp, format)
+	case AuditExportModePseudonymized:
+		if auditExport.pseudonymizer, err = s.newPseudonymizer(); err != nil {
+			return nil, err
+		}
+		auditExport.Filename = fmt.Sprintf("audit_analytics_%s.%s", timestamp, format)
+		s.LogUserAction(userID, "audit_logs_exported", "audit_log", "", map[string]interface{}{
+			"mode":         mode,
+			"salt_version": auditExport.pseudonymizer.saltVersion,
+		})
+	default:
+		return nil, errors.New("unsupported export mode")
 	}
 
-	return data, filename, nil
+	return auditExport, nil
 }
 
-// exportToCSV CSV形式でのエクスポート
-func (s *AuditService) exportToCSV(logs []models.AuditLog) ([]byte, error) {
-	var buffer strings.Builder
-	writer := csv.NewWriter(&buffer)
+// Stream 監査ログを1件ずつ読み込みながら書き出す
+func (e *AuditExport) Stream(w io.Writer) error {
+	query, err := e.service.applyFilter(e.service.auditRepo.GetDB(), e.filter)
+	if err != nil {
+		return err
+	}
+
+	stream := func(fn func(*models.AuditLog) error) error {
+		return e.service.auditRepo.StreamWithFilter(query, e.filter.Limit, e.filter.Offset, fn)
+	}
 
-	// ヘッダーの書き込み
-	headers := []string{"ID", "User ID", "Action", "Entity", "Entity ID", "Meta Data", "Timestamp", "Created At"}
-	if err := writer.Write(headers); err != nil {
-		return nil, err
+	headers := auditExportHeaders
+	row := auditLogRow
+	record := func(log *models.AuditLog) interface{} { return log }
+	if e.pseudonymizer != nil {
+		headers = auditAnalyticsHeaders
+		row = e.pseudonymizer.row
+		record = func(log *models.AuditLog) interface{} { return e.pseudonymizer.record(log) }
 	}
 
-	// データの書き込み
-	for _, log := range logs {
-		userID := ""
-		if log.UserID != nil {
-			userID = fmt.Sprintf("%d", *log.UserID)
+	switch e.format {
+	case "csv":
+		writer := csv.NewWriter(w)
+		if err := writer.Write(headers); err != nil {
+			return err
 		}
+		if err := stream(func(log *models.AuditLog) error {
+			return writer.Write(row(log))
+		}); err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+
+	case "ndjson":
+		encoder := json.NewEncoder(w)
+		return stream(func(log *models.AuditLog) error {
+			return encoder.Encode(record(log))
+		})
+
+	case "json":
+		// 配列全体をメモリに載せずに書き出す
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+		first := true
+		if err := stream(func(log *models.AuditLog) error {
+			data, err := json.Marshal(record(log))
+			if err != nil {
+				return err
+			}
+			if !first {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+			_, err = w.Write(data)
+			return err
+		}); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "]")
+		return err
 
-		row := []string{
-			fmt.Sprintf("%d", log.ID),
-			userID,
-			log.Action,
-			log.Entity,
-			log.EntityID,
-			log.MetaJSON,
-			log.At.Format(time.RFC3339),
-			log.CreatedAt.Format(time.RFC3339),
+	case "xlsx":
+		writer, err := export.NewXLSXWriter(w, "audit_logs")
+		if err != nil {
+			return err
 		}
+		if err := writer.WriteRow(headers); err != nil {
+			return err
+		}
+		if err := stream(func(log *models.AuditLog) error {
+			return writer.WriteRow(row(log))
+		}); err != nil {
+			return err
+		}
+		return writer.Close()
+	}
 
-		if err := writer.Write(row); err != nil {
-			return nil, err
+	return errors.New("unsupported export format")
+}
+
+// applyFilter 監査ログの検索条件をクエリに適用する
+func (s *AuditService) applyFilter(query *gorm.DB, filter AuditLogFilter) (*gorm.DB, error) {
+	if filter.Entity != "" {
+		query = query.Where("entity = ?", filter.Entity)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Severity != "" {
+		query = query.Where("severity = ?", filter.Severity)
+	}
+	if filter.StartDate != "" {
+		startDate, err := time.Parse("2006-01-02", filter.StartDate)
+		if err != nil {
+			return nil, errors.New("invalid start date format")
+		}
+		query = query.Where("at >= ?", startDate)
+	}
+	if filter.EndDate != "" {
+		endDate, err := time.Parse("2006-01-02", filter.EndDate)
+		if err != nil {
+			return nil, errors.New("invalid end date format")
 		}
+		query = query.Where("at < ?", endDate.AddDate(0, 0, 1))
+	}
+	return query, nil
+}
+
+// auditLogRow CSV/XLSX用の1行分のデータ
+func auditLogRow(log *models.AuditLog) []string {
+	userID := ""
+	if log.UserID != nil {
+		userID = fmt.Sprintf("%d", *log.UserID)
 	}
 
-	writer.Flush()
-	return []byte(buffer.String()), nil
+	return []string{
+		fmt.Sprintf("%d", log.ID),
+		userID,
+		log.Action,
+		log.Entity,
+		log.EntityID,
+		log.MetaJSON,
+		log.At.Format(time.RFC3339),
+		log.CreatedAt.Format(time.RFC3339),
+	}
 }
 
 // LogUserAction ユーザーアクションのログ記録（ヘルパー関数）
@@ -252,6 +401,85 @@ func (s *AuditService) LogUserAction(userID uint, action, entity, entityID strin
 	}()
 }
 
+// LogPHIAccess 患者データ（PHI）の閲覧ログ記録（ヘルパー関数）
+// 本人による閲覧は開示対象ではないため記録しない
+func (s *AuditService) LogPHIAccess(viewerID, patientID uint, entity, entityID string, meta interface{}) {
+	if viewerID == patientID {
+		return
+	}
+
+	go func() {
+		var metaJSON string
+		if meta != nil {
+			if metaBytes, err := json.Marshal(meta); err == nil {
+				metaJSON = string(metaBytes)
+			}
+		}
+
+		auditLog := &models.AuditLog{
+			UserID:    &viewerID,
+			PatientID: &patientID,
+			Action:    "view",
+			Entity:    entity,
+			EntityID:  entityID,
+			MetaJSON:  metaJSON,
+			At:        time.Now(),
+		}
+		if err := s.create(auditLog); err != nil {
+			fmt.Printf("Warning: Failed to create PHI access log: %v\n", err)
+		}
+	}()
+}
+
+// LogBreakGlass 緊急時アクセスの記録（重要度high、患者本人の閲覧履歴にも表示される）
+// 記録できない場合はアクセスを許可しないため、同期的に書き込んでエラーを返す
+func (s *AuditService) LogBreakGlass(userID, patientID uint, action, entity, entityID string, meta interface{}) error {
+	return s.logPatientDataAccess(userID, patientID, action, entity, entityID, "high", meta)
+}
+
+// LogRecordingAccess 診療の録画へのアクセスの記録（患者本人のアクセスも含めて全て記録する）
+// 記録できない場合はアクセスを許可しないため、同期的に書き込んでエラーを返す
+func (s *AuditService) LogRecordingAccess(userID, patientID uint, action, entity, entityID string, meta interface{}) error {
+	return s.logPatientDataAccess(userID, patientID, action, entity, entityID, "info", meta)
+}
+
+// logPatientDataAccess 患者データへのアクセスの同期的な記録
+func (s *AuditService) logPatientDataAccess(userID, patientID uint, action, entity, entityID, severity string, meta interface{}) error {
+	var metaJSON string
+	if meta != nil {
+		metaBytes, err := json.Marshal(meta)
+		if err != nil {
+			return fmt.Errorf("failed to marshal meta data: %v", err)
+		}
+		metaJSON = string(metaBytes)
+	}
+
+	return s.create(&models.AuditLog{
+		UserID:    &userID,
+		PatientID: &patientID,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		MetaJSON:  metaJSON,
+		Severity:  severity,
+		At:        time.Now(),
+	})
+}
+
+// GetPatientAccessLog 患者本人向けの閲覧履歴（アクセス開示）の取得
+func (s *AuditService) GetPatientAccessLog(patientID uint, limit, offset int) ([]models.AuditLog, error) {
+	user, err := s.userRepo.FindByID(patientID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	if user.Role != "patient" {
+		return nil, errors.New("only patients can view their access log")
+	}
+
+	return s.auditRepo.FindAccessByPatientID(patientID, limit, offset)
+}
+
 // LogSystemAction システムアクションのログ記録（ヘルパー関数）
 func (s *AuditService) LogSystemAction(action, entity, entityID string, meta interface{}) {
 	// 非同期でログを記録（エラーは無視）
@@ -1,26 +1,64 @@
 package services
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
 	"online_medical_consultation_app/backend/internal/repositories"
 )
 
 type AuthService struct {
-	userRepo  repositories.UserRepository
-	jwtSecret string
+	userRepo     repositories.UserRepository
+	auditService *AuditService
+	jwtSecret    string
+	session      SessionConfig
+	lockout      LockoutConfig
+
+	// 登録されていないメールアドレスへのログインの失敗（全インスタンスで共有する）
+	loginAttemptRepo repositories.LoginAttemptRepository
+}
+
+// SessionConfig ログインのセッションの設定
+// アクセストークンは短い有効期限で発行し、有効期限が近づいた利用中のリクエストで更新したトークンを返す（スライディング方式）
+// 更新後のトークンもログイン時刻（auth_time）を引き継ぎ、ログインから最大有効期間を超えて延長しない
+type SessionConfig struct {
+	AccessTokenTTL time.Duration
+	RefreshWindow  time.Duration // 0の場合は更新しない（有効期限で再ログインが必要）
+	MaxLifetime    time.Duration
+}
+
+// LockoutConfig ログインの総当たり対策の設定
+// 連続して MaxFailures 回失敗するとロックし、ロック後も失敗が続くたびにロックの時間を倍にする（MaxDuration まで）
+type LockoutConfig struct {
+	MaxFailures  int
+	BaseDuration time.Duration
+	MaxDuration  time.Duration // 前回の失敗からこの時間が経過すると失敗回数を数え直す
 }
 
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
-	Role     string `json:"role" binding:"required,oneof=patient doctor"`
+	Role     string `json:"role" binding:"required,oneof=patient doctor staff"` // staffは組織の管理者がロールを委任するまで操作できない（通訳者は管理者が作成する）
 	Name     string `json:"name" binding:"required"`
+	Languages []string `json:"languages"` // 通訳者の対応言語・医師の診療言語コード
+}
+
+// ProvisionInterpreterRequest 管理者による通訳者アカウントの作成
+type ProvisionInterpreterRequest struct {
+	Email     string   `json:"email" binding:"required,email"`
+	Password  string   `json:"password" binding:"required,min=6"`
+	Name      string   `json:"name" binding:"required"`
+	Languages []string `json:"languages" binding:"required,min=1"`
 }
 
 type LoginRequest struct {
@@ -28,8 +66,21 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// ErrAccountDeactivated 退会済みのアカウントでのログイン
+var ErrAccountDeactivated = errors.New("account is deactivated")
+
+// AccountLockedError ログインの失敗が続いたためロック中のアカウントでのログイン
+type AccountLockedError struct {
+	LockedUntil time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return "account is temporarily locked due to repeated failed logins"
+}
+
 type LoginResponse struct {
 	AccessToken string      `json:"access_token"`
+	ExpiresAt   time.Time   `json:"expires_at"`
 	User       models.User `json:"user"`
 }
 
@@ -38,17 +89,50 @@ type ProfileRequest struct {
 	Birthdate *time.Time `json:"birthdate,omitempty"`
 	Phone     *string    `json:"phone,omitempty"`
 	Address   *string    `json:"address,omitempty"`
+	Allergies *string    `json:"allergies,omitempty"`
+	InsuranceProvider *string `json:"insurance_provider,omitempty"`
+	InsuranceNumber   *string `json:"insurance_number,omitempty"`
 	Specialty *string    `json:"specialty,omitempty"`
 	Bio       *string    `json:"bio,omitempty"`
 }
 
-func NewAuthService(userRepo repositories.UserRepository, jwtSecret string) *AuthService {
+func NewAuthService(userRepo repositories.UserRepository, loginAttemptRepo repositories.LoginAttemptRepository, auditService *AuditService, jwtSecret string, session SessionConfig, lockout LockoutConfig) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
+		userRepo:     userRepo,
+		auditService: auditService,
+		jwtSecret:    jwtSecret,
+		session:      session,
+		lockout:      lockout,
+
+		loginAttemptRepo: loginAttemptRepo,
 	}
 }
 
+// ValidateLockoutConfig ログインの総当たり対策の設定の検証
+func ValidateLockoutConfig(cfg *LockoutConfig) error {
+	if cfg.MaxFailures <= 0 {
+		return errors.New("login max failures must be positive")
+	}
+	if cfg.BaseDuration <= 0 || cfg.MaxDuration < cfg.BaseDuration {
+		return errors.New("login lockout duration must be positive and not exceed the maximum")
+	}
+	return nil
+}
+
+// ValidateSessionConfig ログインのセッションの設定の検証
+func ValidateSessionConfig(cfg *SessionConfig) error {
+	if cfg.AccessTokenTTL <= 0 {
+		return errors.New("access token TTL must be positive")
+	}
+	if cfg.MaxLifetime < cfg.AccessTokenTTL {
+		return errors.New("session max lifetime must not be shorter than the access token TTL")
+	}
+	if cfg.RefreshWindow < 0 || cfg.RefreshWindow >= cfg.AccessTokenTTL {
+		return errors.New("session refresh window must be between 0 and the access token TTL")
+	}
+	return nil
+}
+
 // Register ユーザー登録
 func (s *AuthService) Register(req RegisterRequest) (*models.User, error) {
 	// 既存ユーザーのチェック
@@ -58,12 +142,23 @@ func (s *AuthService) Register(req RegisterRequest) (*models.User, error) {
 	}
 	// エラーがnilでない場合（ユーザーが見つからない場合）は正常
 
+	// デモアカウント用のドメインは登録できない
+	if IsDemoEmail(req.Email) {
+		return nil, errors.New("this email domain is reserved")
+	}
+
 	// パスワードのハッシュ化
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
 	}
 
+	// 通訳者は対応言語が必須
+	languages := JoinLanguageCodes(req.Languages)
+	if req.Role == "interpreter" && languages == "" {
+		return nil, errors.New("at least one language is required for interpreters")
+	}
+
 	// ユーザーの作成
 	user := &models.User{
 		Email:        req.Email,
@@ -90,10 +185,20 @@ func (s *AuthService) Register(req RegisterRequest) (*models.User, error) {
 			Name:          req.Name,
 			Specialty:     "一般診療", // デフォルト値
 			LicenseNumber: "D" + fmt.Sprintf("%06d", user.ID), // 仮のライセンス番号
+			Languages:     languages, // 未指定の場合は既定値（ja）
 		}
 		if err := s.userRepo.CreateDoctorProfile(profile); err != nil {
 			return nil, err
 		}
+	} else if req.Role == "interpreter" {
+		profile := &models.InterpreterProfile{
+			UserID:    user.ID,
+			Name:      req.Name,
+			Languages: languages,
+		}
+		if err := s.userRepo.CreateInterpreterProfile(profile); err != nil {
+			return nil, err
+		}
 	}
 
 	return user, nil
@@ -101,45 +206,254 @@ func (s *AuthService) Register(req RegisterRequest) (*models.User, error) {
 
 // Login ユーザーログイン
 func (s *AuthService) Login(req LoginRequest) (*LoginResponse, error) {
-	// ユーザーの検索
+	// ユーザーの検索（登録されていないメールアドレスも登録済みのアカウントと同じ応答にする）
+	now := time.Now()
 	user, err := s.userRepo.FindByEmail(req.Email)
-	if err != nil {
-		return nil, errors.New("invalid credentials")
+	if err != nil || user == nil {
+		return nil, s.recordUnknownLogin(req.Email, now)
 	}
 
-	// ユーザーがnilの場合のチェック
-	if user == nil {
-		return nil, errors.New("invalid credentials")
+	// ロック中はパスワードを検証しない（正しいパスワードかどうかを推測させない）
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		return nil, &AccountLockedError{LockedUntil: *user.LockedUntil}
 	}
 
 	// パスワードの検証
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		if locked := s.recordFailedLogin(user.ID, now); locked != nil {
+			return nil, locked
+		}
 		return nil, errors.New("invalid credentials")
 	}
 
+	// 失敗回数はログインに成功した時点で数え直す
+	if user.FailedLoginCount > 0 || user.LockedUntil != nil {
+		if err := s.userRepo.ResetFailedLogins(user.ID); err != nil {
+			log.Printf("Warning: Failed to reset failed logins of user %d: %v", user.ID, err)
+		}
+		user.FailedLoginCount = 0
+		user.LastFailedLoginAt = nil
+		user.LockedUntil = nil
+	}
+
+	// 退会済みのアカウントは再開手続きが必要
+	if user.DeactivatedAt != nil {
+		return nil, ErrAccountDeactivated
+	}
+
 	// JWTトークンの生成
-	token, err := s.generateJWT(user.ID, user.Role)
+	token, expiresAt, err := s.generateJWT(user.ID, user.Role, time.Now())
 	if err != nil {
 		return nil, err
 	}
 
 	return &LoginResponse{
 		AccessToken: token,
+		ExpiresAt:   expiresAt,
 		User:        *user,
 	}, nil
 }
 
-// generateJWT JWTトークンを生成
-func (s *AuthService) generateJWT(userID uint, role string) (string, error) {
+// recordFailedLogin ログインの失敗の記録と、失敗回数が上限に達した場合のロック（ロックした場合はそのエラー）
+func (s *AuthService) recordFailedLogin(userID uint, now time.Time) *AccountLockedError {
+	failures, err := s.userRepo.RecordFailedLogin(userID, now, now.Add(-s.lockout.MaxDuration))
+	if err != nil {
+		log.Printf("Warning: Failed to record failed login of user %d: %v", userID, err)
+		return nil
+	}
+	s.auditService.LogSystemAction("login_failed", "user", fmt.Sprintf("%d", userID), map[string]interface{}{
+		"failed_attempts": failures,
+	})
+	if failures < s.lockout.MaxFailures {
+		return nil
+	}
+
+	lockedUntil := now.Add(lockoutDuration(failures-s.lockout.MaxFailures, s.lockout.BaseDuration, s.lockout.MaxDuration))
+	if err := s.userRepo.SetLockedUntil(userID, lockedUntil); err != nil {
+		log.Printf("Warning: Failed to lock user %d: %v", userID, err)
+		return nil
+	}
+	s.auditService.LogSystemAction("account_locked", "user", fmt.Sprintf("%d", userID), map[string]interface{}{
+		"failed_attempts": failures,
+		"locked_until":    lockedUntil,
+	})
+	return &AccountLockedError{LockedUntil: lockedUntil}
+}
+
+// recordUnknownLogin 登録されていないメールアドレスへのログインの失敗の記録
+// 登録済みのアカウントと同じ回数・時間でロックし、応答（429かどうか）や応答時間からメールアドレスが登録済みかどうかを判別させない
+func (s *AuthService) recordUnknownLogin(email string, now time.Time) error {
+	// 登録済みのアカウントのパスワードの検証と同じだけ時間をかける
+	_ = bcrypt.CompareHashAndPassword(unknownLoginHash(), []byte(email))
+
+	emailHash := unknownLoginKey(email)
+	attempt, err := s.loginAttemptRepo.FindUnknown(emailHash)
+	if err != nil {
+		log.Printf("Warning: Failed to load failed logins of an unknown email: %v", err)
+	}
+	if attempt != nil && attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil) {
+		return &AccountLockedError{LockedUntil: *attempt.LockedUntil}
+	}
+
+	failures, err := s.loginAttemptRepo.RecordUnknownFailure(emailHash, now, now.Add(-s.lockout.MaxDuration))
+	if err != nil {
+		log.Printf("Warning: Failed to record failed login of an unknown email: %v", err)
+		return errors.New("invalid credentials")
+	}
+	if failures < s.lockout.MaxFailures {
+		return errors.New("invalid credentials")
+	}
+
+	lockedUntil := now.Add(lockoutDuration(failures-s.lockout.MaxFailures, s.lockout.BaseDuration, s.lockout.MaxDuration))
+	if err := s.loginAttemptRepo.SetUnknownLockedUntil(emailHash, lockedUntil); err != nil {
+		log.Printf("Warning: Failed to lock an unknown email: %v", err)
+		return errors.New("invalid credentials")
+	}
+	return &AccountLockedError{LockedUntil: lockedUntil}
+}
+
+// unknownLoginKey 登録されていないメールアドレスの記録のキー（入力されたメールアドレスをそのまま保存しない）
+func unknownLoginKey(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
+
+// RunUnknownLoginCleanupJob 失敗回数を数え直す時間が経過し、ロック中でもない登録されていないメールアドレスの記録の削除
+func (s *AuthService) RunUnknownLoginCleanupJob() error {
+	now := time.Now()
+	deleted, err := s.loginAttemptRepo.DeleteStaleUnknown(now.Add(-s.lockout.MaxDuration), now)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d stale failed logins of unknown emails", deleted)
+	}
+	return nil
+}
+
+var (
+	unknownLoginHashOnce  sync.Once
+	unknownLoginHashValue []byte
+)
+
+// unknownLoginHash 登録されていないメールアドレスでのパスワードの検証に使うハッシュ（どのパスワードとも一致させない）
+func unknownLoginHash() []byte {
+	unknownLoginHashOnce.Do(func() {
+		hash, err := bcrypt.GenerateFromPassword([]byte("unknown-login-placeholder"), bcrypt.DefaultCost)
+		if err != nil {
+			log.Printf("Warning: Failed to generate placeholder password hash: %v", err)
+		}
+		unknownLoginHashValue = hash
+	})
+	return unknownLoginHashValue
+}
+
+// lockoutDuration ロックの時間（上限に達してからの失敗ごとに倍にする）
+func lockoutDuration(extraFailures int, base, max time.Duration) time.Duration {
+	duration := base
+	for i := 0; i < extraFailures && duration < max; i++ {
+		duration *= 2
+	}
+	if duration > max {
+		return max
+	}
+	return duration
+}
+
+// UnlockAccount ログインのロックと失敗回数の解除（管理者のみ）
+func (s *AuthService) UnlockAccount(adminID, userID uint) (*models.User, error) {
+	admin, err := s.userRepo.FindByID(adminID)
+	if err != nil || !policy.IsAdmin(admin) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	if err := s.userRepo.ResetFailedLogins(user.ID); err != nil {
+		return nil, err
+	}
+	s.auditService.LogUserAction(adminID, "account_unlocked", "user", fmt.Sprintf("%d", user.ID), map[string]interface{}{
+		"failed_attempts": user.FailedLoginCount,
+		"locked_until":    user.LockedUntil,
+	})
+
+	user.FailedLoginCount = 0
+	user.LastFailedLoginAt = nil
+	user.LockedUntil = nil
+	return user, nil
+}
+
+// ProvisionInterpreter 通訳者アカウントの作成（管理者のみ・通訳者は自己登録できない）
+func (s *AuthService) ProvisionInterpreter(adminID uint, req ProvisionInterpreterRequest) (*models.User, error) {
+	admin, err := s.userRepo.FindByID(adminID)
+	if err != nil || !policy.IsAdmin(admin) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	user, err := s.Register(RegisterRequest{
+		Email:     req.Email,
+		Password:  req.Password,
+		Role:      "interpreter",
+		Name:      req.Name,
+		Languages: req.Languages,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.auditService.LogUserAction(adminID, "interpreter_provisioned", "user", fmt.Sprintf("%d", user.ID), map[string]interface{}{
+		"email":     user.Email,
+		"languages": JoinLanguageCodes(req.Languages),
+	})
+	return user, nil
+}
+
+// IsSessionActive ログインからの最大有効期間内かどうか
+func (s *AuthService) IsSessionActive(sessionStart time.Time) bool {
+	return time.Now().Before(sessionStart.Add(s.session.MaxLifetime))
+}
+
+// RefreshSession 有効期限が近づいたアクセストークンの更新（利用中のリクエストごとに呼ばれる）
+// 更新が不要な場合・最大有効期間により延長できない場合は空のトークンを返す
+func (s *AuthService) RefreshSession(userID uint, role string, sessionStart, expiresAt time.Time) (string, time.Time, error) {
+	if !s.IsSessionActive(sessionStart) || s.session.RefreshWindow <= 0 || time.Until(expiresAt) > s.session.RefreshWindow {
+		return "", time.Time{}, nil
+	}
+
+	token, newExpiresAt, err := s.generateJWT(userID, role, sessionStart)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	// 既に最大有効期間の終わりまで発行済みの場合は延長しない
+	if !newExpiresAt.After(expiresAt) {
+		return "", time.Time{}, nil
+	}
+	return token, newExpiresAt, nil
+}
+
+// generateJWT JWTトークンを生成（有効期限はログインからの最大有効期間まで）
+func (s *AuthService) generateJWT(userID uint, role string, sessionStart time.Time) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.session.AccessTokenTTL)
+	if sessionEnd := sessionStart.Add(s.session.MaxLifetime); expiresAt.After(sessionEnd) {
+		expiresAt = sessionEnd
+	}
+
 	claims := jwt.MapClaims{
-		"user_id": userID,
-		"role":    role,
-		"exp":     time.Now().Add(15 * time.Minute).Unix(),
-		"iat":     time.Now().Unix(),
+		"user_id":   userID,
+		"role":      role,
+		"exp":       expiresAt.Unix(),
+		"iat":       now.Unix(),
+		"auth_time": sessionStart.Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	signed, err := token.SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, time.Unix(expiresAt.Unix(), 0), nil
 }
 
 // ValidateToken JWTトークンの検証
@@ -197,6 +511,15 @@ func (s *AuthService) UpdateProfile(userID uint, req ProfileRequest) error {
 		if req.Address != nil {
 			profile.Address = *req.Address
 		}
+		if req.Allergies != nil {
+			profile.Allergies = req.Allergies
+		}
+		if req.InsuranceProvider != nil {
+			profile.InsuranceProvider = *req.InsuranceProvider
+		}
+		if req.InsuranceNumber != nil {
+			profile.InsuranceNumber = *req.InsuranceNumber
+		}
 
 		return s.userRepo.UpdatePatientProfile(profile)
 	} else if user.Role == "doctor" {
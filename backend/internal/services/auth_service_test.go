@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// fakeUserRepository ログインで使う操作のみをメモリ上で行う
+type fakeUserRepository struct {
+	repositories.UserRepository
+	users map[string]*models.User
+}
+
+func (r *fakeUserRepository) FindByEmail(email string) (*models.User, error) {
+	user, ok := r.users[email]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepository) findByID(userID uint) *models.User {
+	for _, user := range r.users {
+		if user.ID == userID {
+			return user
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserRepository) RecordFailedLogin(userID uint, at, resetBefore time.Time) (int, error) {
+	user := r.findByID(userID)
+	if user == nil {
+		return 0, fmt.Errorf("user %d not found", userID)
+	}
+	if user.LastFailedLoginAt == nil || user.LastFailedLoginAt.Before(resetBefore) {
+		user.FailedLoginCount = 1
+	} else {
+		user.FailedLoginCount++
+	}
+	user.LastFailedLoginAt = &at
+	return user.FailedLoginCount, nil
+}
+
+func (r *fakeUserRepository) SetLockedUntil(userID uint, until time.Time) error {
+	r.findByID(userID).LockedUntil = &until
+	return nil
+}
+
+// fakeLoginAttemptRepository 登録されていないメールアドレスの失敗をメモリ上に記録する
+type fakeLoginAttemptRepository struct {
+	attempts map[string]*models.UnknownLoginAttempt
+}
+
+func (r *fakeLoginAttemptRepository) FindUnknown(emailHash string) (*models.UnknownLoginAttempt, error) {
+	attempt, ok := r.attempts[emailHash]
+	if !ok {
+		return nil, nil
+	}
+	copied := *attempt
+	return &copied, nil
+}
+
+func (r *fakeLoginAttemptRepository) RecordUnknownFailure(emailHash string, at, resetBefore time.Time) (int, error) {
+	attempt, ok := r.attempts[emailHash]
+	if !ok || attempt.LastFailedLoginAt.Before(resetBefore) {
+		r.attempts[emailHash] = &models.UnknownLoginAttempt{EmailHash: emailHash, FailedLoginCount: 1, LastFailedLoginAt: at}
+		if ok {
+			r.attempts[emailHash].LockedUntil = attempt.LockedUntil
+		}
+		return 1, nil
+	}
+	attempt.FailedLoginCount++
+	attempt.LastFailedLoginAt = at
+	return attempt.FailedLoginCount, nil
+}
+
+func (r *fakeLoginAttemptRepository) SetUnknownLockedUntil(emailHash string, until time.Time) error {
+	r.attempts[emailHash].LockedUntil = &until
+	return nil
+}
+
+func (r *fakeLoginAttemptRepository) DeleteStaleUnknown(lastFailedBefore, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+type fakeAuditRepository struct {
+	repositories.AuditRepository
+}
+
+func (fakeAuditRepository) Create(log *models.AuditLog) error { return nil }
+
+// describeLoginError 利用者から見えるログインの失敗の応答（ロックの期限は残り時間で比べる）
+func describeLoginError(err error) string {
+	var locked *AccountLockedError
+	if errors.As(err, &locked) {
+		return fmt.Sprintf("%s (locked for %s)", err.Error(), time.Until(locked.LockedUntil).Round(10*time.Second))
+	}
+	if err == nil {
+		return "<nil>"
+	}
+	return err.Error()
+}
+
+func TestLoginRespondsAlikeForUnknownAndKnownEmails(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct-password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	users := &fakeUserRepository{users: map[string]*models.User{
+		"known@example.com": {ID: 1, Email: "known@example.com", PasswordHash: string(hash), Role: "patient"},
+	}}
+	lockout := LockoutConfig{MaxFailures: 3, BaseDuration: time.Minute, MaxDuration: time.Hour}
+	service := NewAuthService(users, &fakeLoginAttemptRepository{attempts: map[string]*models.UnknownLoginAttempt{}},
+		NewAuditService(fakeAuditRepository{}, users, nil, 0, 0, 0), "test-secret",
+		SessionConfig{AccessTokenTTL: time.Hour, MaxLifetime: time.Hour}, lockout)
+
+	// 上限に達するまでの失敗・ロックした時点・ロック中の試行のいずれも同じ応答にする
+	for attempt := 1; attempt <= lockout.MaxFailures+1; attempt++ {
+		_, knownErr := service.Login(LoginRequest{Email: "known@example.com", Password: "wrong-password"})
+		_, unknownErr := service.Login(LoginRequest{Email: "unknown@example.com", Password: "wrong-password"})
+		if knownErr == nil {
+			t.Fatalf("attempt %d: Login(known) succeeded with a wrong password", attempt)
+		}
+		known, unknown := describeLoginError(knownErr), describeLoginError(unknownErr)
+		if known != unknown {
+			t.Errorf("attempt %d: Login(unknown) = %q, want the same response as Login(known) = %q", attempt, unknown, known)
+		}
+	}
+}
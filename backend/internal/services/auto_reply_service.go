@@ -0,0 +1,224 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/realtime"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 自動返信の初期設定（医師が設定するまで無効）
+const (
+	defaultAutoReplyMessage  = "ただいま診療時間外のため、医師からの返信は{{next_available}}以降になります。急を要する場合は救急外来を受診するか、119番に連絡してください。"
+	defaultAutoReplyStart    = "09:00"
+	defaultAutoReplyEnd      = "18:00"
+	defaultAutoReplyWeekdays = "1,2,3,4,5"
+	maxAutoReplyMessageRunes = 1000
+)
+
+type AutoReplyService struct {
+	autoReplyRepo        repositories.AutoReplyRepository
+	messageRepo          repositories.MessageRepository
+	userRepo             repositories.UserRepository
+	bookingPolicyService *BookingPolicyService
+	hub                  *realtime.Hub
+}
+
+// UpdateAutoReplyRequest 自動返信の設定の部分更新（nilの項目は変更しない）
+type UpdateAutoReplyRequest struct {
+	Enabled        *bool   `json:"enabled"`
+	Message        *string `json:"message"`
+	ActiveStart    *string `json:"active_start"`
+	ActiveEnd      *string `json:"active_end"`
+	ActiveWeekdays []int   `json:"active_weekdays"`
+}
+
+// offHours 医師の対応時間外であることと、その前後の対応時間の境界
+type offHours struct {
+	autoReply *models.DoctorAutoReply
+	since     time.Time // 直前の対応時間の終了
+	until     time.Time // 次の対応時間の開始（通知を遅らせる日時）
+}
+
+func NewAutoReplyService(autoReplyRepo repositories.AutoReplyRepository, messageRepo repositories.MessageRepository, userRepo repositories.UserRepository, bookingPolicyService *BookingPolicyService, hub *realtime.Hub) *AutoReplyService {
+	return &AutoReplyService{
+		autoReplyRepo:        autoReplyRepo,
+		messageRepo:          messageRepo,
+		userRepo:             userRepo,
+		bookingPolicyService: bookingPolicyService,
+		hub:                  hub,
+	}
+}
+
+// GetAutoReply 自動返信の設定を取得（未設定の場合は無効の初期設定）
+func (s *AutoReplyService) GetAutoReply(doctorID uint) (*models.DoctorAutoReply, error) {
+	autoReply, err := s.autoReplyRepo.FindByDoctorID(doctorID)
+	if err != nil {
+		return nil, err
+	}
+	if autoReply == nil {
+		autoReply = &models.DoctorAutoReply{
+			DoctorID:       doctorID,
+			Message:        defaultAutoReplyMessage,
+			ActiveStart:    defaultAutoReplyStart,
+			ActiveEnd:      defaultAutoReplyEnd,
+			ActiveWeekdays: defaultAutoReplyWeekdays,
+		}
+	}
+	return autoReply, nil
+}
+
+// UpdateAutoReply 自動返信の設定の変更（医師のみ）
+func (s *AutoReplyService) UpdateAutoReply(doctorID uint, req UpdateAutoReplyRequest) (*models.DoctorAutoReply, error) {
+	doctor, err := s.userRepo.FindByID(doctorID)
+	if err != nil || doctor == nil || doctor.Role != "doctor" {
+		return nil, errors.New("only doctors can configure auto-reply")
+	}
+
+	autoReply, err := s.GetAutoReply(doctorID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Enabled != nil {
+		autoReply.Enabled = *req.Enabled
+	}
+	if req.Message != nil {
+		autoReply.Message = strings.TrimSpace(*req.Message)
+	}
+	if req.ActiveStart != nil {
+		autoReply.ActiveStart = strings.TrimSpace(*req.ActiveStart)
+	}
+	if req.ActiveEnd != nil {
+		autoReply.ActiveEnd = strings.TrimSpace(*req.ActiveEnd)
+	}
+	if req.ActiveWeekdays != nil {
+		days := make([]string, 0, len(req.ActiveWeekdays))
+		for _, day := range req.ActiveWeekdays {
+			days = append(days, strconv.Itoa(day))
+		}
+		autoReply.ActiveWeekdays = strings.Join(days, ",")
+	}
+
+	if err := ValidateAutoReply(autoReply); err != nil {
+		return nil, err
+	}
+	if err := s.autoReplyRepo.Save(autoReply); err != nil {
+		return nil, err
+	}
+	return autoReply, nil
+}
+
+// ValidateAutoReply 自動返信の設定の値の検証
+func ValidateAutoReply(autoReply *models.DoctorAutoReply) error {
+	length := len([]rune(autoReply.Message))
+	if length == 0 {
+		return errors.New("message is required")
+	}
+	if length > maxAutoReplyMessageRunes {
+		return errors.New("message must be at most 1000 characters")
+	}
+
+	start, err := parseClock(autoReply.ActiveStart)
+	if err != nil {
+		return errors.New("invalid active_start (expected HH:MM)")
+	}
+	end, err := parseClock(autoReply.ActiveEnd)
+	if err != nil {
+		return errors.New("invalid active_end (expected HH:MM)")
+	}
+	if start >= end {
+		return errors.New("active_start must be before active_end")
+	}
+
+	weekdays, err := parseWeekdays(autoReply.ActiveWeekdays)
+	if err != nil || len(weekdays) == 0 {
+		return errors.New("active_weekdays must contain at least one day between 0 (Sunday) and 6 (Saturday)")
+	}
+	return nil
+}
+
+// checkOffHours 指定日時が医師の対応時間外か（自動返信が無効の場合はnil）
+func (s *AutoReplyService) checkOffHours(doctorID uint, at time.Time) (*offHours, error) {
+	autoReply, err := s.autoReplyRepo.FindByDoctorID(doctorID)
+	if err != nil || autoReply == nil || !autoReply.Enabled {
+		return nil, err
+	}
+	location, err := s.bookingPolicyService.Location()
+	if err != nil {
+		return nil, err
+	}
+	start, err := parseClock(autoReply.ActiveStart)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(autoReply.ActiveEnd)
+	if err != nil {
+		return nil, err
+	}
+	weekdays, err := parseWeekdays(autoReply.ActiveWeekdays)
+	if err != nil || len(weekdays) == 0 {
+		return nil, errors.New("invalid auto-reply weekdays")
+	}
+
+	local := at.In(location)
+	today := startOfDay(at, location)
+	clock := local.Hour()*60 + local.Minute()
+	if weekdays[local.Weekday()] && clock >= start && clock < end {
+		return nil, nil
+	}
+
+	off := &offHours{autoReply: autoReply}
+	// 直前の対応時間の終了（1週間前まで遡る）
+	off.since = today.AddDate(0, 0, -7)
+	for d := 0; d <= 7; d++ {
+		day := today.AddDate(0, 0, -d)
+		if boundary := day.Add(time.Duration(end) * time.Minute); weekdays[day.Weekday()] && !boundary.After(local) {
+			off.since = boundary
+			break
+		}
+	}
+	// 次の対応時間の開始
+	for d := 0; d <= 7; d++ {
+		day := today.AddDate(0, 0, d)
+		if boundary := day.Add(time.Duration(start) * time.Minute); weekdays[day.Weekday()] && boundary.After(local) {
+			off.until = boundary
+			break
+		}
+	}
+	return off, nil
+}
+
+// replyOffHours 対応時間外の自動返信（同じ時間外の間は予約ごとに1回のみ）
+func (s *AutoReplyService) replyOffHours(appointment *models.Appointment, off *offHours) {
+	replied, err := s.messageRepo.HasAutoReplySince(appointment.ID, off.since)
+	if err != nil {
+		log.Printf("Warning: Failed to check auto-reply of appointment %d: %v", appointment.ID, err)
+		return
+	}
+	if replied {
+		return
+	}
+
+	body := strings.ReplaceAll(off.autoReply.Message, "{{next_available}}", off.until.Format("1月2日 15:04"))
+	reply := &models.Message{
+		AppointmentID: appointment.ID,
+		SenderUserID:  appointment.DoctorID,
+		Body:          body,
+		IsAutoReply:   true,
+	}
+	if err := s.messageRepo.Create(reply); err != nil {
+		log.Printf("Warning: Failed to send auto-reply to appointment %d: %v", appointment.ID, err)
+		return
+	}
+	if err := s.messageRepo.LoadRelations(reply); err != nil {
+		log.Printf("Warning: Failed to load auto-reply %d: %v", reply.ID, err)
+	}
+	if err := publishChatMessage(s.hub, appointment, reply); err != nil {
+		log.Printf("Warning: Failed to publish auto-reply %d: %v", reply.ID, err)
+	}
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/backup"
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// BackupService データベースのバックアップの実行と履歴の管理（リストアは cmd/backup で行う）
+type BackupService struct {
+	backupRepo   repositories.BackupRepository
+	userRepo     repositories.UserRepository
+	runner       *backup.Runner
+	auditService *AuditService
+	timeout      time.Duration
+	keep         int // 保存する成功済みのバックアップの数（0: すべて保存）
+
+	mu      sync.Mutex
+	running bool
+}
+
+func NewBackupService(backupRepo repositories.BackupRepository, userRepo repositories.UserRepository, runner *backup.Runner, auditService *AuditService, timeout time.Duration, keep int) *BackupService {
+	return &BackupService{
+		backupRepo:   backupRepo,
+		userRepo:     userRepo,
+		runner:       runner,
+		auditService: auditService,
+		timeout:      timeout,
+		keep:         keep,
+	}
+}
+
+// StartBackup 管理者によるバックアップの開始（完了を待たずに実行記録を返す）
+func (s *BackupService) StartBackup(adminID uint) (*models.BackupRun, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	run, err := s.begin("manual", &adminID)
+	if err != nil {
+		return nil, err
+	}
+	go s.execute(*run)
+
+	s.auditService.LogUserAction(adminID, "backup_started", "backup_run", fmt.Sprintf("%d", run.ID), nil)
+	return run, nil
+}
+
+// RunBackupJob 定期ジョブ：バックアップの作成（完了まで待つ）
+func (s *BackupService) RunBackupJob() error {
+	run, err := s.begin("scheduled", nil)
+	if err != nil {
+		return err
+	}
+	return s.execute(*run)
+}
+
+// GetBackups バックアップの実行履歴（管理者のみ）
+func (s *BackupService) GetBackups(adminID uint, limit, offset int) ([]models.BackupRun, int64, error) {
+	if !s.isAdmin(adminID) {
+		return nil, 0, errors.New("unauthorized: admin access required")
+	}
+	return s.backupRepo.FindAll(limit, offset)
+}
+
+// GetBackup バックアップの実行状況（管理者のみ）
+func (s *BackupService) GetBackup(adminID, runID uint) (*models.BackupRun, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	run, err := s.backupRepo.FindByID(runID)
+	if err != nil || run == nil {
+		return nil, errors.New("backup not found")
+	}
+	return run, nil
+}
+
+// begin 実行記録の作成（同時に実行できるバックアップは1件のみ）
+func (s *BackupService) begin(source string, triggeredByID *uint) (*models.BackupRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return nil, errors.New("a backup is already running")
+	}
+
+	now := time.Now()
+	// タイムアウトを過ぎても終了していない記録は中断したものとして扱う
+	if s.timeout > 0 {
+		if failed, err := s.backupRepo.FailStale(now.Add(-s.timeout-time.Minute), "interrupted"); err != nil {
+			return nil, err
+		} else if failed > 0 {
+			log.Printf("Marked %d interrupted backups as failed", failed)
+		}
+	}
+	running, err := s.backupRepo.CountRunning()
+	if err != nil {
+		return nil, err
+	}
+	if running > 0 {
+		return nil, errors.New("a backup is already running")
+	}
+
+	run := &models.BackupRun{
+		Status:        "running",
+		Source:        source,
+		TriggeredByID: triggeredByID,
+		ObjectKey:     backup.ObjectKeyFor(now),
+		StartedAt:     now,
+	}
+	if err := s.backupRepo.Create(run); err != nil {
+		return nil, err
+	}
+	s.running = true
+	return run, nil
+}
+
+// execute pg_dumpの実行と結果の記録
+func (s *BackupService) execute(run models.BackupRun) error {
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	result, dumpErr := s.runner.Dump(context.Background(), run.ObjectKey)
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	if dumpErr != nil {
+		run.Status = "failed"
+		run.Error = dumpErr.Error()
+	} else {
+		run.Status = "succeeded"
+		run.ObjectKey = result.ObjectKey
+		run.SizeBytes = result.SizeBytes
+		run.Checksum = result.Checksum
+	}
+	if err := s.backupRepo.Update(&run); err != nil {
+		log.Printf("Warning: Failed to record backup %d: %v", run.ID, err)
+	}
+
+	meta := map[string]interface{}{
+		"source":     run.Source,
+		"object_key": run.ObjectKey,
+		"seconds":    int(finishedAt.Sub(run.StartedAt).Seconds()),
+	}
+	if dumpErr != nil {
+		log.Printf("Backup %d failed: %v", run.ID, dumpErr)
+		meta["error"] = run.Error
+		s.auditService.LogSystemAction("backup_failed", "backup_run", fmt.Sprintf("%d", run.ID), meta)
+		return dumpErr
+	}
+
+	log.Printf("Backup %d completed: %s (%d bytes)", run.ID, run.ObjectKey, run.SizeBytes)
+	meta["size_bytes"] = run.SizeBytes
+	s.auditService.LogSystemAction("backup_completed", "backup_run", fmt.Sprintf("%d", run.ID), meta)
+	s.prune()
+	return nil
+}
+
+// prune 保存数を超えた古いバックアップファイルの削除（記録は期限切れとして残す）
+func (s *BackupService) prune() {
+	if s.keep <= 0 {
+		return
+	}
+	runs, err := s.backupRepo.FindSucceededBeyond(s.keep)
+	if err != nil {
+		log.Printf("Warning: Failed to find old backups: %v", err)
+		return
+	}
+	for i := range runs {
+		run := &runs[i]
+		if err := s.runner.Remove(run.ObjectKey); err != nil {
+			log.Printf("Warning: Failed to remove backup file %s: %v", run.ObjectKey, err)
+			continue
+		}
+		run.Status = "expired"
+		if err := s.backupRepo.Update(run); err != nil {
+			log.Printf("Warning: Failed to mark backup %d as expired: %v", run.ID, err)
+		}
+	}
+}
+
+func (s *BackupService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 受付時間の終了に指定できる日付の終わり
+const endOfDayClock = 24 * 60
+
+type BookingPolicyService struct {
+	policyRepo   repositories.BookingPolicyRepository
+	userRepo     repositories.UserRepository
+	auditService *AuditService
+	defaults     models.BookingPolicy
+}
+
+// UpdateBookingPolicyRequest 予約受付ルールの部分更新（nilの項目は変更しない）
+type UpdateBookingPolicyRequest struct {
+	MinNoticeMinutes *int    `json:"min_notice_minutes"`
+	MaxAdvanceDays   *int    `json:"max_advance_days"`
+	OpenTime         *string `json:"open_time"`
+	CloseTime        *string `json:"close_time"`
+	OpenWeekdays     []int   `json:"open_weekdays"`
+	Timezone         *string `json:"timezone"`
+}
+
+func NewBookingPolicyService(policyRepo repositories.BookingPolicyRepository, userRepo repositories.UserRepository, auditService *AuditService, defaults models.BookingPolicy) *BookingPolicyService {
+	return &BookingPolicyService{
+		policyRepo:   policyRepo,
+		userRepo:     userRepo,
+		auditService: auditService,
+		defaults:     defaults,
+	}
+}
+
+// GetPolicy 現在の予約受付ルールの取得（未設定の場合は初期値）
+func (s *BookingPolicyService) GetPolicy() (*models.BookingPolicy, error) {
+	policy, err := s.policyRepo.Find()
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		defaults := s.defaults
+		return &defaults, nil
+	}
+	return policy, nil
+}
+
+// Location 予約受付ルールのタイムゾーンの取得
+func (s *BookingPolicyService) Location() (*time.Location, error) {
+	policy, err := s.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(policy.Timezone)
+	if err != nil {
+		return nil, errors.New("invalid booking timezone")
+	}
+	return location, nil
+}
+
+// UpdatePolicy 予約受付ルールの更新（管理者のみ）
+func (s *BookingPolicyService) UpdatePolicy(adminID uint, req UpdateBookingPolicyRequest) (*models.BookingPolicy, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	policy, err := s.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	if req.MinNoticeMinutes != nil {
+		policy.MinNoticeMinutes = *req.MinNoticeMinutes
+	}
+	if req.MaxAdvanceDays != nil {
+		policy.MaxAdvanceDays = *req.MaxAdvanceDays
+	}
+	if req.OpenTime != nil {
+		policy.OpenTime = strings.TrimSpace(*req.OpenTime)
+	}
+	if req.CloseTime != nil {
+		policy.CloseTime = strings.TrimSpace(*req.CloseTime)
+	}
+	if req.OpenWeekdays != nil {
+		weekdays := make([]string, 0, len(req.OpenWeekdays))
+		for _, day := range req.OpenWeekdays {
+			weekdays = append(weekdays, strconv.Itoa(day))
+		}
+		policy.OpenWeekdays = strings.Join(weekdays, ",")
+	}
+	if req.Timezone != nil {
+		policy.Timezone = strings.TrimSpace(*req.Timezone)
+	}
+
+	if err := ValidateBookingPolicy(policy); err != nil {
+		return nil, err
+	}
+
+	policy.UpdatedByID = &adminID
+	if err := s.policyRepo.Save(policy); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(adminID, "booking_policy_updated", "booking_policy", "1", policy)
+
+	return policy, nil
+}
+
+// CheckBookable 予約の受付可否（最短受付時間・受付期間・受付時間）の確認
+func (s *BookingPolicyService) CheckBookable(startTime, endTime time.Time) error {
+	policy, err := s.GetPolicy()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if startTime.Before(now.Add(time.Duration(policy.MinNoticeMinutes) * time.Minute)) {
+		return fmt.Errorf("appointments must be booked at least %d minutes in advance", policy.MinNoticeMinutes)
+	}
+	return checkWithinPolicy(policy, startTime, endTime)
+}
+
+// CheckSlot 診療枠が受付期間・受付時間内にあるかの確認（最短受付時間は予約時に確認する）
+func (s *BookingPolicyService) CheckSlot(startTime, endTime time.Time) error {
+	policy, err := s.GetPolicy()
+	if err != nil {
+		return err
+	}
+	return checkWithinPolicy(policy, startTime, endTime)
+}
+
+// CheckBusinessHours 指定時刻が受付時間内かの確認（即時診療用）
+func (s *BookingPolicyService) CheckBusinessHours(at time.Time) error {
+	policy, err := s.GetPolicy()
+	if err != nil {
+		return err
+	}
+	return checkBusinessHours(policy, at, at)
+}
+
+func (s *BookingPolicyService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
+
+// ValidateBookingPolicy 予約受付ルールの値の検証
+func ValidateBookingPolicy(policy *models.BookingPolicy) error {
+	if policy.MinNoticeMinutes < 0 {
+		return errors.New("min_notice_minutes must not be negative")
+	}
+	if policy.MaxAdvanceDays < 1 {
+		return errors.New("max_advance_days must be at least 1")
+	}
+
+	open, err := parseClock(policy.OpenTime)
+	if err != nil {
+		return errors.New("invalid open_time (expected HH:MM)")
+	}
+	closeClock, err := parseClock(policy.CloseTime)
+	if err != nil {
+		return errors.New("invalid close_time (expected HH:MM)")
+	}
+	if open >= closeClock {
+		return errors.New("open_time must be before close_time")
+	}
+
+	weekdays, err := parseWeekdays(policy.OpenWeekdays)
+	if err != nil || len(weekdays) == 0 {
+		return errors.New("open_weekdays must contain at least one day between 0 (Sunday) and 6 (Saturday)")
+	}
+
+	if _, err := time.LoadLocation(policy.Timezone); err != nil {
+		return errors.New("invalid timezone")
+	}
+	return nil
+}
+
+// checkWithinPolicy 受付期間・受付時間の確認
+func checkWithinPolicy(policy *models.BookingPolicy, startTime, endTime time.Time) error {
+	if startTime.After(time.Now().AddDate(0, 0, policy.MaxAdvanceDays)) {
+		return fmt.Errorf("appointments can only be booked up to %d days in advance", policy.MaxAdvanceDays)
+	}
+	return checkBusinessHours(policy, startTime, endTime)
+}
+
+// checkBusinessHours 開始から終了までが受付曜日・受付時間内に収まっているかの確認
+func checkBusinessHours(policy *models.BookingPolicy, startTime, endTime time.Time) error {
+	location, err := time.LoadLocation(policy.Timezone)
+	if err != nil {
+		return errors.New("invalid booking timezone")
+	}
+	open, err := parseClock(policy.OpenTime)
+	if err != nil {
+		return errors.New("invalid booking open time")
+	}
+	closeClock, err := parseClock(policy.CloseTime)
+	if err != nil {
+		return errors.New("invalid booking close time")
+	}
+	weekdays, err := parseWeekdays(policy.OpenWeekdays)
+	if err != nil {
+		return errors.New("invalid booking weekdays")
+	}
+
+	start := startTime.In(location)
+	end := endTime.In(location)
+	startClock := start.Hour()*60 + start.Minute()
+	endClock := end.Hour()*60 + end.Minute()
+
+	// 終了が翌日0:00ちょうどの場合は当日の24:00として扱う
+	dayStart := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, location)
+	if end.Equal(dayStart.AddDate(0, 0, 1)) {
+		endClock = endOfDayClock
+	} else if end.YearDay() != start.YearDay() || end.Year() != start.Year() {
+		return errors.New("appointments must start and end on the same day")
+	}
+
+	if !weekdays[start.Weekday()] {
+		return errors.New("appointments are not accepted on this day of the week")
+	}
+	if startClock < open || endClock > closeClock {
+		return fmt.Errorf("appointments are only accepted between %s and %s", policy.OpenTime, policy.CloseTime)
+	}
+	return nil
+}
+
+// parseClock HH:MM を0時からの分数に変換（24:00まで許可）
+func parseClock(value string) (int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return 0, errors.New("invalid clock")
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, err
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, err
+	}
+	if hour < 0 || minute < 0 || minute > 59 || hour*60+minute > endOfDayClock {
+		return 0, errors.New("invalid clock")
+	}
+	return hour*60 + minute, nil
+}
+
+// parseWeekdays カンマ区切りの曜日番号を集合に変換
+func parseWeekdays(value string) (map[time.Weekday]bool, error) {
+	weekdays := make(map[time.Weekday]bool)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		day, err := strconv.Atoi(item)
+		if err != nil || day < 0 || day > 6 {
+			return nil, errors.New("invalid weekday")
+		}
+		weekdays[time.Weekday(day)] = true
+	}
+	return weekdays, nil
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"online_medical_consultation_app/backend/internal/export"
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+var brandColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+type BrandingService struct {
+	brandingRepo repositories.ClinicBrandingRepository
+	userRepo     repositories.UserRepository
+	auditService *AuditService
+	defaults     models.ClinicBranding
+}
+
+// UpdateBrandingRequest クリニックの表記の部分更新（nilの項目は変更しない、空文字で削除）
+type UpdateBrandingRequest struct {
+	ClinicName   *string `json:"clinic_name"`
+	LogoURL      *string `json:"logo_url"`
+	PrimaryColor *string `json:"primary_color"`
+	SupportEmail *string `json:"support_email"`
+	SupportPhone *string `json:"support_phone"`
+	EmailFooter  *string `json:"email_footer"`
+}
+
+func NewBrandingService(brandingRepo repositories.ClinicBrandingRepository, userRepo repositories.UserRepository, auditService *AuditService, defaults models.ClinicBranding) *BrandingService {
+	return &BrandingService{
+		brandingRepo: brandingRepo,
+		userRepo:     userRepo,
+		auditService: auditService,
+		defaults:     defaults,
+	}
+}
+
+// GetBranding 現在のクリニックの表記の取得（未設定の場合は初期値）
+func (s *BrandingService) GetBranding() (*models.ClinicBranding, error) {
+	branding, err := s.brandingRepo.Find()
+	if err != nil {
+		return nil, err
+	}
+	if branding == nil {
+		defaults := s.defaults
+		return &defaults, nil
+	}
+	return branding, nil
+}
+
+// UpdateBranding クリニックの表記の更新（管理者のみ）
+func (s *BrandingService) UpdateBranding(adminID uint, req UpdateBrandingRequest) (*models.ClinicBranding, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	branding, err := s.GetBranding()
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ClinicName != nil {
+		branding.ClinicName = strings.TrimSpace(*req.ClinicName)
+	}
+	if req.LogoURL != nil {
+		branding.LogoURL = strings.TrimSpace(*req.LogoURL)
+	}
+	if req.PrimaryColor != nil {
+		branding.PrimaryColor = strings.ToUpper(strings.TrimSpace(*req.PrimaryColor))
+	}
+	if req.SupportEmail != nil {
+		branding.SupportEmail = strings.TrimSpace(*req.SupportEmail)
+	}
+	if req.SupportPhone != nil {
+		branding.SupportPhone = strings.TrimSpace(*req.SupportPhone)
+	}
+	if req.EmailFooter != nil {
+		branding.EmailFooter = strings.TrimSpace(*req.EmailFooter)
+	}
+
+	if err := ValidateBranding(branding); err != nil {
+		return nil, err
+	}
+
+	branding.UpdatedByID = &adminID
+	if err := s.brandingRepo.Save(branding); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(adminID, "branding_updated", "clinic_branding", "1", branding)
+
+	return branding, nil
+}
+
+// NewPDFDocument クリニック名（ブランドカラー）と問い合わせ先を各ページに表示するPDF文書の作成
+// 表記を取得できない場合も文書の作成は妨げない
+func (s *BrandingService) NewPDFDocument() *export.PDFDocument {
+	branding, err := s.GetBranding()
+	if err != nil {
+		log.Printf("Warning: Failed to load clinic branding for PDF: %v", err)
+		return export.NewPDFDocument()
+	}
+	return export.NewBrandedPDFDocument(export.PDFBranding{
+		Header: branding.ClinicName,
+		Color:  branding.PrimaryColor,
+		Footer: strings.Join(supportContactLines(branding), "\n"),
+	})
+}
+
+// EmailFooter メール本文の末尾に付けるフッター（クリニック名・問い合わせ先・設定したフッター）
+func (s *BrandingService) EmailFooter() string {
+	branding, err := s.GetBranding()
+	if err != nil {
+		log.Printf("Warning: Failed to load clinic branding for email: %v", err)
+		return ""
+	}
+
+	var lines []string
+	if branding.ClinicName != "" {
+		lines = append(lines, branding.ClinicName)
+	}
+	lines = append(lines, supportContactLines(branding)...)
+	if branding.EmailFooter != "" {
+		lines = append(lines, branding.EmailFooter)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "--\n" + strings.Join(lines, "\n")
+}
+
+func (s *BrandingService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
+
+// supportContactLines 問い合わせ先の表記
+func supportContactLines(branding *models.ClinicBranding) []string {
+	var lines []string
+	if branding.SupportEmail != "" {
+		lines = append(lines, fmt.Sprintf("お問い合わせ: %s", branding.SupportEmail))
+	}
+	if branding.SupportPhone != "" {
+		lines = append(lines, fmt.Sprintf("電話: %s", branding.SupportPhone))
+	}
+	return lines
+}
+
+// ValidateBranding クリニックの表記の値の検証
+func ValidateBranding(branding *models.ClinicBranding) error {
+	if branding.ClinicName == "" {
+		return errors.New("clinic_name is required")
+	}
+	if len([]rune(branding.ClinicName)) > 100 {
+		return errors.New("clinic_name must be at most 100 characters")
+	}
+	if branding.LogoURL != "" {
+		logoURL, err := url.Parse(branding.LogoURL)
+		if err != nil || (logoURL.Scheme != "https" && logoURL.Scheme != "http") || logoURL.Host == "" {
+			return errors.New("logo_url must be an absolute http(s) URL")
+		}
+	}
+	if !brandColorPattern.MatchString(branding.PrimaryColor) {
+		return errors.New("primary_color must be a hex color (#RRGGBB)")
+	}
+	if branding.SupportEmail != "" {
+		if address, err := mail.ParseAddress(branding.SupportEmail); err != nil || address.Address != branding.SupportEmail {
+			return errors.New("invalid support_email")
+		}
+	}
+	if len([]rune(branding.SupportPhone)) > 30 {
+		return errors.New("support_phone must be at most 30 characters")
+	}
+	if len([]rune(branding.EmailFooter)) > 1000 {
+		return errors.New("email_footer must be at most 1000 characters")
+	}
+	return nil
+}
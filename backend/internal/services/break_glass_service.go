@@ -0,0 +1,263 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+type BreakGlassService struct {
+	breakGlassRepo      repositories.BreakGlassRepository
+	userRepo            repositories.UserRepository
+	appointmentRepo     repositories.AppointmentRepository
+	prescriptionRepo    repositories.PrescriptionRepository
+	clinicalCodingRepo  repositories.ClinicalCodingRepository
+	patientDocumentRepo repositories.PatientDocumentRepository
+	notificationService *NotificationService
+	auditService        *AuditService
+	duration            time.Duration
+}
+
+type OpenBreakGlassRequest struct {
+	PatientID     uint   `json:"patient_id" binding:"required"`
+	Justification string `json:"justification" binding:"required,min=20,max=2000"`
+}
+
+type SetBreakGlassAuthorizationRequest struct {
+	Authorized *bool `json:"authorized" binding:"required"`
+}
+
+// EmergencyRecord 緊急時アクセスで閲覧する患者の診療記録
+type EmergencyRecord struct {
+	Access        *models.BreakGlassAccess  `json:"access"`
+	Profile       *models.PatientProfile    `json:"profile"`
+	Appointments  []models.Appointment      `json:"appointments"`
+	Prescriptions []models.Prescription     `json:"prescriptions"`
+	Problems      []models.ProblemListEntry `json:"problems"`
+	Documents     []models.PatientDocument  `json:"documents"`
+}
+
+func NewBreakGlassService(breakGlassRepo repositories.BreakGlassRepository, userRepo repositories.UserRepository, appointmentRepo repositories.AppointmentRepository, prescriptionRepo repositories.PrescriptionRepository, clinicalCodingRepo repositories.ClinicalCodingRepository, patientDocumentRepo repositories.PatientDocumentRepository, notificationService *NotificationService, auditService *AuditService, duration time.Duration) *BreakGlassService {
+	return &BreakGlassService{
+		breakGlassRepo:      breakGlassRepo,
+		userRepo:            userRepo,
+		appointmentRepo:     appointmentRepo,
+		prescriptionRepo:    prescriptionRepo,
+		clinicalCodingRepo:  clinicalCodingRepo,
+		patientDocumentRepo: patientDocumentRepo,
+		notificationService: notificationService,
+		auditService:        auditService,
+		duration:            duration,
+	}
+}
+
+// Open 緊急時アクセスの開始（許可された医師・管理者のみ、理由の記録が必須）
+// 重要度highの監査ログを記録できない場合は開始しない。患者本人と管理者へ通知する
+func (s *BreakGlassService) Open(userID uint, req OpenBreakGlassRequest) (*models.BreakGlassAccess, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user == nil || user.DeactivatedAt != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Role != "admin" && !(user.Role == "doctor" && user.BreakGlassAuthorized) {
+		return nil, errors.New("unauthorized: break-glass access is not permitted")
+	}
+	if req.PatientID == userID {
+		return nil, errors.New("cannot open break-glass access to your own record")
+	}
+
+	patient, err := s.userRepo.FindByID(req.PatientID)
+	if err != nil || patient == nil || patient.Role != "patient" || patient.AnonymizedAt != nil {
+		return nil, errors.New("patient not found")
+	}
+	// デモアカウントと実アカウントの間ではアクセスできない
+	if patient.IsDemo != user.IsDemo {
+		return nil, errors.New("patient not found")
+	}
+
+	justification := strings.TrimSpace(req.Justification)
+	if len([]rune(justification)) < 20 {
+		return nil, errors.New("justification must be at least 20 characters")
+	}
+
+	now := time.Now()
+	access := &models.BreakGlassAccess{
+		UserID:        userID,
+		PatientID:     req.PatientID,
+		Justification: justification,
+		ExpiresAt:     now.Add(s.duration),
+	}
+	if err := s.breakGlassRepo.Create(access); err != nil {
+		return nil, fmt.Errorf("failed to open break-glass access: %v", err)
+	}
+
+	if err := s.auditService.LogBreakGlass(userID, req.PatientID, "break_glass", "patient", strconv.FormatUint(uint64(req.PatientID), 10), map[string]interface{}{
+		"access_id":     access.ID,
+		"justification": justification,
+		"expires_at":    access.ExpiresAt,
+	}); err != nil {
+		// 記録のないアクセスは許可しない
+		if _, endErr := s.breakGlassRepo.End(access.ID, time.Now()); endErr != nil {
+			log.Printf("Warning: Failed to end unaudited break-glass access %d: %v", access.ID, endErr)
+		}
+		return nil, fmt.Errorf("failed to record break-glass access: %v", err)
+	}
+
+	if _, err := s.notificationService.Notify(req.PatientID, NotificationMessage{
+		Type:     "break_glass_opened",
+		Title:    "緊急時アクセスによりあなたの診療記録が閲覧されます",
+		Body:     fmt.Sprintf("理由: %s", justification),
+		Priority: "high",
+		Data: map[string]interface{}{
+			"access_id":  access.ID,
+			"user_id":    userID,
+			"role":       user.Role,
+			"expires_at": access.ExpiresAt,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify patient %d of break-glass access %d: %v", req.PatientID, access.ID, err)
+	}
+
+	admins, err := s.userRepo.FindByRole("admin")
+	if err != nil {
+		log.Printf("Warning: Failed to find admins for break-glass access %d: %v", access.ID, err)
+	}
+	var adminIDs []uint
+	for _, admin := range admins {
+		if admin.ID != userID {
+			adminIDs = append(adminIDs, admin.ID)
+		}
+	}
+	s.notificationService.NotifyMany(adminIDs, NotificationMessage{
+		Type:     "break_glass_opened",
+		Title:    "緊急時アクセスが開始されました",
+		Body:     fmt.Sprintf("理由: %s", justification),
+		Priority: "high",
+		Data: map[string]interface{}{
+			"access_id":  access.ID,
+			"user_id":    userID,
+			"patient_id": req.PatientID,
+			"expires_at": access.ExpiresAt,
+		},
+	})
+
+	return access, nil
+}
+
+// GetEmergencyRecord 緊急時アクセスによる患者の診療記録の閲覧（有効なアクセスが必要）
+// 閲覧のたびに重要度highの監査ログを記録し（記録できない場合は閲覧させない）、患者本人へ通知する
+func (s *BreakGlassService) GetEmergencyRecord(userID, patientID uint) (*EmergencyRecord, error) {
+	access, err := s.breakGlassRepo.FindActive(userID, patientID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if access == nil {
+		return nil, errors.New("unauthorized: no active break-glass access for this patient")
+	}
+
+	record := &EmergencyRecord{Access: access}
+	if record.Profile, err = s.userRepo.FindPatientProfileByUserID(patientID); err != nil {
+		return nil, errors.New("patient profile not found")
+	}
+	if record.Appointments, err = s.appointmentRepo.FindByPatientID(patientID); err != nil {
+		return nil, err
+	}
+	if record.Prescriptions, err = s.prescriptionRepo.FindByPatientSince(patientID, time.Time{}); err != nil {
+		return nil, err
+	}
+	if record.Problems, err = s.clinicalCodingRepo.FindProblems(patientID, nil); err != nil {
+		return nil, err
+	}
+	if record.Documents, err = s.patientDocumentRepo.FindByPatientID(patientID); err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.LogBreakGlass(userID, patientID, "view", "patient_record", strconv.FormatUint(uint64(patientID), 10), map[string]interface{}{
+		"access_id":     access.ID,
+		"break_glass":   true,
+		"appointments":  len(record.Appointments),
+		"prescriptions": len(record.Prescriptions),
+		"documents":     len(record.Documents),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to record break-glass access: %v", err)
+	}
+
+	if _, err := s.notificationService.Notify(patientID, NotificationMessage{
+		Type:     "break_glass_viewed",
+		Title:    "緊急時アクセスによりあなたの診療記録が閲覧されました",
+		Body:     fmt.Sprintf("理由: %s", access.Justification),
+		Priority: "high",
+		Data: map[string]interface{}{
+			"access_id": access.ID,
+			"user_id":   userID,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify patient %d of break-glass view %d: %v", patientID, access.ID, err)
+	}
+
+	return record, nil
+}
+
+// End 緊急時アクセスの期限前の終了（開始した本人または管理者）
+func (s *BreakGlassService) End(userID, accessID uint) error {
+	access, err := s.breakGlassRepo.FindByID(accessID)
+	if err != nil {
+		return errors.New("break-glass access not found")
+	}
+	if access.UserID != userID && !s.isAdmin(userID) {
+		return errors.New("break-glass access not found")
+	}
+
+	ended, err := s.breakGlassRepo.End(accessID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ended {
+		return errors.New("break-glass access has already ended")
+	}
+
+	s.auditService.LogUserAction(userID, "end", "break_glass_access", strconv.FormatUint(uint64(accessID), 10), map[string]interface{}{
+		"patient_id": access.PatientID,
+	})
+	return nil
+}
+
+// GetAccesses 緊急時アクセスの履歴（管理者用、patientIDが0の場合は全患者）
+func (s *BreakGlassService) GetAccesses(adminID, patientID uint, limit, offset int) ([]models.BreakGlassAccess, int64, error) {
+	if !s.isAdmin(adminID) {
+		return nil, 0, errors.New("unauthorized: admin access required")
+	}
+	return s.breakGlassRepo.FindAll(patientID, limit, offset)
+}
+
+// SetAuthorization 医師への緊急時アクセスの許可・取り消し（管理者用）
+func (s *BreakGlassService) SetAuthorization(adminID, doctorID uint, authorized bool) error {
+	if !s.isAdmin(adminID) {
+		return errors.New("unauthorized: admin access required")
+	}
+
+	doctor, err := s.userRepo.FindByID(doctorID)
+	if err != nil || doctor == nil || doctor.Role != "doctor" {
+		return errors.New("doctor not found")
+	}
+
+	if err := s.userRepo.SetBreakGlassAuthorized(doctorID, authorized); err != nil {
+		return err
+	}
+
+	s.auditService.LogUserAction(adminID, "update", "break_glass_authorization", strconv.FormatUint(uint64(doctorID), 10), map[string]interface{}{
+		"authorized": authorized,
+	})
+	return nil
+}
+
+func (s *BreakGlassService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
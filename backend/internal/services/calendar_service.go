@@ -0,0 +1,125 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/ics"
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// カレンダーの予定を作成したアプリの識別子
+const calendarProdID = "-//Online Medical Consultation//Appointments//JA"
+
+// CalendarService 予約をカレンダーアプリに取り込むための iCalendar（.ics）の出力
+type CalendarService struct {
+	appointmentRepo repositories.AppointmentRepository
+	userRepo        repositories.UserRepository
+	locationService *LocationService
+	auditService    *AuditService
+	appBaseURL      string
+	reminderLead    time.Duration
+}
+
+func NewCalendarService(appointmentRepo repositories.AppointmentRepository, userRepo repositories.UserRepository, locationService *LocationService, auditService *AuditService, appBaseURL string, reminderLead time.Duration) *CalendarService {
+	return &CalendarService{
+		appointmentRepo: appointmentRepo,
+		userRepo:        userRepo,
+		locationService: locationService,
+		auditService:    auditService,
+		appBaseURL:      strings.TrimRight(appBaseURL, "/"),
+		reminderLead:    reminderLead,
+	}
+}
+
+// ExportAppointment 予約の予定の出力（予約の参加者のみ、対面診療は所在地と経路案内を含める）
+func (s *CalendarService) ExportAppointment(userID, appointmentID uint) ([]byte, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if !appointment.IsParticipant(userID) {
+		return nil, errors.New("unauthorized to view this appointment")
+	}
+	if appointment.ScheduledStart == nil || appointment.ScheduledEnd == nil {
+		return nil, errors.New("appointment has no scheduled time")
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	event := ics.Event{
+		UID: fmt.Sprintf("appointment-%d@%s", appointment.ID, s.calendarDomain()),
+		// 予約の更新のたびに増える値（取り込み済みの予定を再度取り込むと更新される）
+		Sequence: int(appointment.UpdatedAt.Unix() - appointment.CreatedAt.Unix()),
+		Start:    *appointment.ScheduledStart,
+		End:      *appointment.ScheduledEnd,
+		Status:   calendarStatus(appointment.Status),
+		Alarm:    s.reminderLead,
+	}
+	// 予約の詳細は予定に含めず、アプリの予約の画面を案内する
+	appointmentURL := fmt.Sprintf("%s/%s/appointments/%d", s.appBaseURL, user.Role, appointment.ID)
+	if location := s.locationService.locationOf(appointment); location != nil {
+		event.Summary = "診療予約（対面）"
+		event.Location = locationLabel(location)
+		event.Latitude = location.Latitude
+		event.Longitude = location.Longitude
+		event.URL = location.DirectionsURL
+		event.Description = locationDescription(location, appointmentURL)
+	} else {
+		event.Summary = "診療予約（オンライン）"
+		event.URL = appointmentURL
+		event.Description = "開始時刻になりましたらアプリから診療に参加してください。\n" + appointmentURL
+	}
+
+	var buf bytes.Buffer
+	if err := ics.Write(&buf, calendarProdID, event); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogPHIAccess(userID, appointment.PatientID, "appointment", fmt.Sprintf("%d", appointment.ID), map[string]interface{}{
+		"export": "ics",
+	})
+	return buf.Bytes(), nil
+}
+
+// calendarDomain 予定のUIDに使うドメイン（アプリのURLのホスト名）
+func (s *CalendarService) calendarDomain() string {
+	parsed, err := url.Parse(s.appBaseURL)
+	if err != nil || parsed.Hostname() == "" {
+		return "localhost"
+	}
+	return parsed.Hostname()
+}
+
+// calendarStatus 予約の状態に対応する予定の状態
+func calendarStatus(status string) string {
+	switch status {
+	case "pending":
+		return "TENTATIVE"
+	case "cancelled":
+		return "CANCELLED"
+	default:
+		return "CONFIRMED"
+	}
+}
+
+// locationDescription 対面診療の予定の説明（電話番号・アクセス・経路案内）
+func locationDescription(location *models.ClinicLocation, appointmentURL string) string {
+	var description strings.Builder
+	fmt.Fprintf(&description, "%s\n", locationLabel(location))
+	if location.Phone != "" {
+		fmt.Fprintf(&description, "電話: %s\n", location.Phone)
+	}
+	if location.AccessNotes != "" {
+		fmt.Fprintf(&description, "アクセス: %s\n", location.AccessNotes)
+	}
+	fmt.Fprintf(&description, "経路案内: %s\n予約の詳細: %s", location.DirectionsURL, appointmentURL)
+	return description.String()
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 受け入れ数のシミュレーションの既定値
+const (
+	capacityDefaultWeeks        = 12
+	capacityDefaultMaxOverbook  = 5
+	capacityDefaultOverflowRisk = 0.1
+	capacityMinAttendable       = 20 // 無断キャンセル率の算出にこの件数未満の予約しかない場合は超過予約を推奨しない
+)
+
+type CapacityService struct {
+	appointmentRepo      repositories.AppointmentRepository
+	slotRepo             repositories.SlotRepository
+	userRepo             repositories.UserRepository
+	bookingPolicyService *BookingPolicyService
+}
+
+// CapacitySimulationRequest 受け入れ数のシミュレーションの条件
+type CapacitySimulationRequest struct {
+	DoctorID uint `form:"doctor_id"`                              // 0の場合はデモアカウントを除く全医師
+	Weeks    int  `form:"weeks" binding:"omitempty,min=1,max=53"` // 無断キャンセル率・診療枠の実績の集計期間（直近の週数）
+	// SlotsPerDay 1日に診察できる枠数（未指定の場合は医師ごとに過去に公開した枠数の1日平均）
+	SlotsPerDay int `form:"slots_per_day" binding:"omitempty,min=1,max=200"`
+	// MaxOverbook シミュレーションする超過予約数の上限（0〜この値の各シナリオを計算する）
+	MaxOverbook int `form:"max_overbook" binding:"omitempty,min=1,max=50"`
+	// MaxOverflowRisk 許容する、来院した患者が診察できる枠数を超える確率
+	MaxOverflowRisk float64 `form:"max_overflow_risk" binding:"omitempty,gt=0,lt=1"`
+}
+
+// CapacityScenario 1日あたりの予約の受け入れ数のシナリオ
+// 予約ごとに過去の無断キャンセル率で独立に来院しないと仮定した、来院数（二項分布）の期待値・確率
+type CapacityScenario struct {
+	Overbook          int     `json:"overbook"`            // 診察できる枠数を超えて受け付ける予約数
+	Bookings          int     `json:"bookings"`            // 受け付ける予約数（枠数 + 超過予約数）
+	ExpectedAttended  float64 `json:"expected_attended"`   // 来院数の期待値
+	ExpectedIdleSlots float64 `json:"expected_idle_slots"` // 無断キャンセルで空く枠数の期待値
+	ExpectedOverflow  float64 `json:"expected_overflow"`   // 枠数を超えて来院する患者数の期待値
+	OverflowRisk      float64 `json:"overflow_risk"`       // 来院数が枠数を超える確率
+	Utilization       float64 `json:"utilization"`         // 枠の稼働率の期待値
+}
+
+// CapacityPlan 医師ごとの受け入れ数のシミュレーションと推奨
+type CapacityPlan struct {
+	DoctorID   uint    `json:"doctor_id"`
+	DoctorName string  `json:"doctor_name"`
+	Attendable int     `json:"attendable"` // 無断キャンセル率の算出に使った予約数
+	NoShows    int     `json:"no_shows"`
+	NoShowRate float64 `json:"no_show_rate"`
+	// LowConfidence 実績の予約が少なく、無断キャンセル率の信頼性が低い（超過予約を推奨しない）
+	LowConfidence          bool               `json:"low_confidence"`
+	HistoricalSlotsPerDay  float64            `json:"historical_slots_per_day"`  // 過去に公開した枠数の1日平均（枠を公開した日）
+	HistoricalBookedPerDay float64            `json:"historical_booked_per_day"` // 過去に予約された枠数の1日平均
+	SlotsPerDay            int                `json:"slots_per_day"`             // シミュレーションに使った1日に診察できる枠数
+	Scenarios              []CapacityScenario `json:"scenarios"`
+	RecommendedOverbook    int                `json:"recommended_overbook"`
+	RecommendedBookings    int                `json:"recommended_bookings"` // 1日に受け付ける予約数の推奨
+}
+
+// CapacitySimulation 受け入れ数のシミュレーションの結果
+type CapacitySimulation struct {
+	From            time.Time      `json:"from"`
+	To              time.Time      `json:"to"`
+	MaxOverflowRisk float64        `json:"max_overflow_risk"`
+	Plans           []CapacityPlan `json:"plans"`
+}
+
+func NewCapacityService(appointmentRepo repositories.AppointmentRepository, slotRepo repositories.SlotRepository, userRepo repositories.UserRepository, bookingPolicyService *BookingPolicyService) *CapacityService {
+	return &CapacityService{
+		appointmentRepo:      appointmentRepo,
+		slotRepo:             slotRepo,
+		userRepo:             userRepo,
+		bookingPolicyService: bookingPolicyService,
+	}
+}
+
+// SimulateCapacity 医師ごとの過去の無断キャンセル率から、1日に受け付ける予約数のシナリオを計算し、
+// 来院数が枠数を超える確率が許容範囲内で最も多い予約数を推奨する（管理者のみ）
+// 無断キャンセルの定義・集計は医師の実績（PerformanceService）と同じ
+func (s *CapacityService) SimulateCapacity(adminID uint, req CapacitySimulationRequest) (*CapacitySimulation, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	if req.Weeks == 0 {
+		req.Weeks = capacityDefaultWeeks
+	}
+	if req.MaxOverbook == 0 {
+		req.MaxOverbook = capacityDefaultMaxOverbook
+	}
+	if req.MaxOverflowRisk == 0 {
+		req.MaxOverflowRisk = capacityDefaultOverflowRisk
+	}
+
+	location, err := s.bookingPolicyService.Location()
+	if err != nil {
+		return nil, err
+	}
+	local := time.Now().In(location)
+	end := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	start := end.AddDate(0, 0, -7*req.Weeks)
+
+	appointments, err := s.appointmentRepo.FindPerformance(start, end, req.DoctorID)
+	if err != nil {
+		return nil, err
+	}
+	slots, err := s.slotRepo.FindInRangeWithBookingStatus(start, end, req.DoctorID)
+	if err != nil {
+		return nil, err
+	}
+	names, err := s.doctorNames()
+	if err != nil {
+		return nil, err
+	}
+
+	// 医師ごとの無断キャンセルの実績
+	now := time.Now()
+	metrics := make(map[uint]*PerformanceMetrics)
+	for _, appointment := range appointments {
+		m, ok := metrics[appointment.DoctorID]
+		if !ok {
+			m = &PerformanceMetrics{}
+			metrics[appointment.DoctorID] = m
+		}
+		m.add(appointment, now)
+	}
+
+	// 医師ごとの公開した枠数・予約された枠数（枠を公開した日の1日平均）
+	type slotCounts struct {
+		offered, booked int
+		days            map[string]bool
+	}
+	counts := make(map[uint]*slotCounts)
+	for _, slot := range slots {
+		if slot.Status == "blocked" && slot.Appointment == nil {
+			continue
+		}
+		c, ok := counts[slot.DoctorID]
+		if !ok {
+			c = &slotCounts{days: make(map[string]bool)}
+			counts[slot.DoctorID] = c
+		}
+		c.offered++
+		if slot.Appointment != nil {
+			c.booked++
+		}
+		c.days[slot.StartTime.In(location).Format("2006-01-02")] = true
+	}
+
+	var doctorIDs []uint
+	for id := range metrics {
+		doctorIDs = append(doctorIDs, id)
+	}
+	for id := range counts {
+		if _, ok := metrics[id]; !ok {
+			doctorIDs = append(doctorIDs, id)
+		}
+	}
+	sort.Slice(doctorIDs, func(i, j int) bool { return doctorIDs[i] < doctorIDs[j] })
+
+	simulation := &CapacitySimulation{From: start, To: end, MaxOverflowRisk: req.MaxOverflowRisk, Plans: []CapacityPlan{}}
+	for _, doctorID := range doctorIDs {
+		plan := CapacityPlan{DoctorID: doctorID, DoctorName: names[doctorID]}
+		if m, ok := metrics[doctorID]; ok {
+			m.finish()
+			plan.Attendable = m.attendable
+			plan.NoShows = m.NoShows
+			plan.NoShowRate = m.NoShowRate
+		}
+		plan.LowConfidence = plan.Attendable < capacityMinAttendable
+		if c, ok := counts[doctorID]; ok && len(c.days) > 0 {
+			plan.HistoricalSlotsPerDay = roundHours(float64(c.offered) / float64(len(c.days)))
+			plan.HistoricalBookedPerDay = roundHours(float64(c.booked) / float64(len(c.days)))
+		}
+
+		plan.SlotsPerDay = req.SlotsPerDay
+		if plan.SlotsPerDay == 0 {
+			plan.SlotsPerDay = int(math.Round(plan.HistoricalSlotsPerDay))
+		}
+		// 枠を公開した実績がなく、枠数の指定もない医師は計算できない
+		if plan.SlotsPerDay == 0 {
+			continue
+		}
+
+		noShowRate := 0.0
+		if plan.Attendable > 0 {
+			noShowRate = float64(plan.NoShows) / float64(plan.Attendable)
+		}
+		plan.Scenarios = make([]CapacityScenario, 0, req.MaxOverbook+1)
+		for overbook := 0; overbook <= req.MaxOverbook; overbook++ {
+			scenario := simulateBookings(plan.SlotsPerDay, overbook, noShowRate)
+			plan.Scenarios = append(plan.Scenarios, scenario)
+			if !plan.LowConfidence && scenario.OverflowRisk <= req.MaxOverflowRisk {
+				plan.RecommendedOverbook = overbook
+			}
+		}
+		plan.RecommendedBookings = plan.SlotsPerDay + plan.RecommendedOverbook
+		simulation.Plans = append(simulation.Plans, plan)
+	}
+
+	if req.DoctorID != 0 && len(simulation.Plans) == 0 {
+		return nil, errors.New("no slot history for this doctor; specify slots_per_day")
+	}
+	return simulation, nil
+}
+
+func (s *CapacityService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
+
+func (s *CapacityService) doctorNames() (map[uint]string, error) {
+	doctors, err := s.userRepo.FindDoctors("")
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(doctors))
+	for _, doctor := range doctors {
+		names[doctor.UserID] = doctor.Name
+	}
+	return names, nil
+}
+
+// simulateBookings 枠数slotsに対してslots+overbook件の予約を受け付けた場合の来院数の分布を計算する
+// 来院数は予約数・来院率（1 - 無断キャンセル率）の二項分布
+func simulateBookings(slots, overbook int, noShowRate float64) CapacityScenario {
+	bookings := slots + overbook
+	scenario := CapacityScenario{Overbook: overbook, Bookings: bookings}
+
+	var seen float64
+	for attended, p := range binomialPMF(bookings, 1-noShowRate) {
+		scenario.ExpectedAttended += float64(attended) * p
+		if attended < slots {
+			scenario.ExpectedIdleSlots += float64(slots-attended) * p
+			seen += float64(attended) * p
+		} else {
+			seen += float64(slots) * p
+		}
+		if attended > slots {
+			scenario.ExpectedOverflow += float64(attended-slots) * p
+			scenario.OverflowRisk += p
+		}
+	}
+
+	scenario.ExpectedAttended = roundHours(scenario.ExpectedAttended)
+	scenario.ExpectedIdleSlots = roundHours(scenario.ExpectedIdleSlots)
+	scenario.ExpectedOverflow = roundHours(scenario.ExpectedOverflow)
+	scenario.OverflowRisk = math.Round(scenario.OverflowRisk*1000) / 1000
+	scenario.Utilization = roundHours(seen / float64(slots))
+	return scenario
+}
+
+// binomialPMF 試行回数n・成功確率pの二項分布の確率（添字が成功回数）
+func binomialPMF(n int, p float64) []float64 {
+	pmf := make([]float64, n+1)
+	switch {
+	case p <= 0:
+		pmf[0] = 1
+		return pmf
+	case p >= 1:
+		pmf[n] = 1
+		return pmf
+	}
+
+	logP, logQ := math.Log(p), math.Log(1-p)
+	lgN, _ := math.Lgamma(float64(n + 1))
+	for k := 0; k <= n; k++ {
+		lgK, _ := math.Lgamma(float64(k + 1))
+		lgNK, _ := math.Lgamma(float64(n - k + 1))
+		pmf[k] = math.Exp(lgN - lgK - lgNK + float64(k)*logP + float64(n-k)*logQ)
+	}
+	return pmf
+}
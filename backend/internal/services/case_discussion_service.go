@@ -0,0 +1,342 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+type CaseDiscussionService struct {
+	caseDiscussionRepo  repositories.CaseDiscussionRepository
+	messageRepo         repositories.MessageRepository
+	appointmentRepo     repositories.AppointmentRepository
+	userRepo            repositories.UserRepository
+	notificationService *NotificationService
+	auditService        *AuditService
+}
+
+type OpenCaseDiscussionRequest struct {
+	ConsultantDoctorID uint   `json:"consultant_doctor_id" binding:"required"`
+	Subject            string `json:"subject" binding:"required"`
+}
+
+type CaseDiscussionConsentRequest struct {
+	Approve *bool `json:"approve" binding:"required"`
+}
+
+func NewCaseDiscussionService(caseDiscussionRepo repositories.CaseDiscussionRepository, messageRepo repositories.MessageRepository, appointmentRepo repositories.AppointmentRepository, userRepo repositories.UserRepository, notificationService *NotificationService, auditService *AuditService) *CaseDiscussionService {
+	return &CaseDiscussionService{
+		caseDiscussionRepo:  caseDiscussionRepo,
+		messageRepo:         messageRepo,
+		appointmentRepo:     appointmentRepo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		auditService:        auditService,
+	}
+}
+
+// OpenCaseDiscussion 症例相談の開始（担当医師のみ、患者の同意待ちで作成）
+func (s *CaseDiscussionService) OpenCaseDiscussion(appointmentID, doctorID uint, req OpenCaseDiscussionRequest) (*models.CaseDiscussion, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+
+	if appointment.DoctorID != doctorID {
+		return nil, errors.New("only the assigned doctor can open a case discussion")
+	}
+	if appointment.Status == "cancelled" {
+		return nil, errors.New("appointment is cancelled")
+	}
+	if req.ConsultantDoctorID == doctorID {
+		return nil, errors.New("cannot open a case discussion with yourself")
+	}
+
+	consultant, err := s.userRepo.FindByID(req.ConsultantDoctorID)
+	if err != nil || consultant == nil || consultant.Role != "doctor" {
+		return nil, errors.New("consultant doctor not found")
+	}
+
+	existing, err := s.caseDiscussionRepo.FindOpenByAppointmentAndConsultant(appointmentID, req.ConsultantDoctorID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("a case discussion with this doctor is already open")
+	}
+
+	discussion := &models.CaseDiscussion{
+		AppointmentID:      appointmentID,
+		RequestingDoctorID: doctorID,
+		ConsultantDoctorID: req.ConsultantDoctorID,
+		Subject:            req.Subject,
+		Status:             "pending_consent",
+	}
+	if err := s.caseDiscussionRepo.Create(discussion); err != nil {
+		return nil, err
+	}
+
+	// 患者へ同意を依頼
+	if _, err := s.notificationService.Notify(appointment.PatientID, NotificationMessage{
+		Type:  "case_discussion_consent_requested",
+		Title: "他の医師への相談について同意をお願いします",
+		Body:  req.Subject,
+		Data:  caseDiscussionNotificationData(discussion),
+	}); err != nil {
+		log.Printf("Warning: Failed to notify patient %d of case discussion %d: %v", appointment.PatientID, discussion.ID, err)
+	}
+
+	s.auditService.LogUserAction(doctorID, "case_discussion_opened", "case_discussion", fmt.Sprintf("%d", discussion.ID), map[string]interface{}{
+		"appointment_id":       appointmentID,
+		"consultant_doctor_id": req.ConsultantDoctorID,
+	})
+
+	return s.caseDiscussionRepo.FindByID(discussion.ID)
+}
+
+// RespondConsent 症例相談への同意・拒否（予約した患者のみ）
+func (s *CaseDiscussionService) RespondConsent(appointmentID, discussionID, patientID uint, approve bool) (*models.CaseDiscussion, error) {
+	discussion, appointment, err := s.getDiscussion(appointmentID, discussionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if appointment.PatientID != patientID {
+		return nil, errors.New("only the patient can respond to consent")
+	}
+	if discussion.Status != "pending_consent" {
+		return nil, errors.New("consent has already been given or declined")
+	}
+
+	now := time.Now()
+	action := "case_discussion_declined"
+	title := "患者が症例相談への同意を拒否しました"
+	if approve {
+		discussion.Status = "active"
+		discussion.ConsentedAt = &now
+		action = "case_discussion_consented"
+		title = "患者が症例相談に同意しました"
+	} else {
+		discussion.Status = "declined"
+		discussion.ClosedAt = &now
+	}
+	if err := s.caseDiscussionRepo.Update(discussion); err != nil {
+		return nil, err
+	}
+
+	// 相談元・相談先の医師へ通知（相談先は同意された場合のみ）
+	recipients := []uint{discussion.RequestingDoctorID}
+	if approve {
+		recipients = append(recipients, discussion.ConsultantDoctorID)
+	}
+	s.notificationService.NotifyMany(recipients, NotificationMessage{
+		Type:  action,
+		Title: title,
+		Body:  discussion.Subject,
+		Data:  caseDiscussionNotificationData(discussion),
+	})
+
+	s.auditService.LogUserAction(patientID, action, "case_discussion", fmt.Sprintf("%d", discussion.ID), map[string]interface{}{
+		"appointment_id": appointmentID,
+	})
+
+	return discussion, nil
+}
+
+// CloseCaseDiscussion 症例相談の終了（参加医師のみ）
+func (s *CaseDiscussionService) CloseCaseDiscussion(appointmentID, discussionID, doctorID uint) (*models.CaseDiscussion, error) {
+	discussion, _, err := s.getDiscussion(appointmentID, discussionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !discussion.IsMember(doctorID) {
+		return nil, errors.New("unauthorized to close this case discussion")
+	}
+	if discussion.Status == "closed" || discussion.Status == "declined" {
+		return nil, errors.New("case discussion is already closed")
+	}
+
+	now := time.Now()
+	discussion.Status = "closed"
+	discussion.ClosedAt = &now
+	if err := s.caseDiscussionRepo.Update(discussion); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(doctorID, "case_discussion_closed", "case_discussion", fmt.Sprintf("%d", discussion.ID), map[string]interface{}{
+		"appointment_id": appointmentID,
+	})
+
+	return discussion, nil
+}
+
+// GetCaseDiscussions 予約の症例相談一覧の取得
+// 患者と担当医師は全件、相談先の医師は自分が参加するスレッドのみ閲覧できる
+func (s *CaseDiscussionService) GetCaseDiscussions(appointmentID, userID uint) ([]models.CaseDiscussion, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+
+	discussions, err := s.caseDiscussionRepo.FindByAppointmentID(appointmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if appointment.PatientID == userID || appointment.DoctorID == userID {
+		return discussions, nil
+	}
+
+	visible := make([]models.CaseDiscussion, 0, len(discussions))
+	for _, discussion := range discussions {
+		if discussion.IsMember(userID) {
+			visible = append(visible, discussion)
+		}
+	}
+	if len(visible) == 0 {
+		return nil, errors.New("unauthorized to view case discussions for this appointment")
+	}
+	return visible, nil
+}
+
+// GetDoctorCaseDiscussions 医師が参加する症例相談一覧の取得
+func (s *CaseDiscussionService) GetDoctorCaseDiscussions(doctorID uint, status string) ([]models.CaseDiscussion, error) {
+	return s.caseDiscussionRepo.FindByDoctorID(doctorID, status)
+}
+
+// SendMessage 症例相談スレッドへのメッセージ送信（参加医師のみ、同意済みのスレッドに限る）
+func (s *CaseDiscussionService) SendMessage(discussionID, senderID uint, req SendMessageRequest) (*models.Message, error) {
+	discussion, err := s.getDiscussionForMember(discussionID, senderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if discussion.Status != "active" {
+		return nil, errors.New("case discussion is not active")
+	}
+
+	message := &models.Message{
+		AppointmentID:    discussion.AppointmentID,
+		SenderUserID:     senderID,
+		Channel:          models.MessageChannelCaseDiscussion,
+		CaseDiscussionID: &discussion.ID,
+		Body:             req.Body,
+		AttachmentURL:    req.AttachmentURL,
+	}
+	if err := s.messageRepo.Create(message); err != nil {
+		return nil, err
+	}
+
+	// 相手の医師へ通知
+	recipientID := discussion.ConsultantDoctorID
+	if senderID == discussion.ConsultantDoctorID {
+		recipientID = discussion.RequestingDoctorID
+	}
+	if _, err := s.notificationService.Notify(recipientID, NotificationMessage{
+		Type:  "case_discussion_message",
+		Title: "症例相談に新しいメッセージがあります",
+		Body:  discussion.Subject,
+		Data:  caseDiscussionNotificationData(discussion),
+	}); err != nil {
+		log.Printf("Warning: Failed to notify doctor %d of case discussion message: %v", recipientID, err)
+	}
+
+	if err := s.messageRepo.LoadRelations(message); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// GetMessages 症例相談スレッドのメッセージ一覧の取得（参加医師のみ）
+func (s *CaseDiscussionService) GetMessages(discussionID, userID uint, limit, offset int) ([]models.Message, error) {
+	discussion, err := s.getDiscussionForMember(discussionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 同意前のスレッドにはメッセージが存在しない
+	if discussion.Status == "pending_consent" || discussion.Status == "declined" {
+		return []models.Message{}, nil
+	}
+
+	messages, err := s.messageRepo.FindByCaseDiscussionID(discussionID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range messages {
+		if err := s.messageRepo.LoadRelations(&messages[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	// PHI閲覧ログの記録
+	appointment, err := s.appointmentRepo.FindByID(discussion.AppointmentID)
+	if err == nil && appointment != nil {
+		s.auditService.LogPHIAccess(userID, appointment.PatientID, "case_discussion_message", fmt.Sprintf("%d", discussionID), map[string]interface{}{
+			"appointment_id": discussion.AppointmentID,
+			"count":          len(messages),
+		})
+	}
+
+	return messages, nil
+}
+
+// MarkMessagesAsRead 症例相談スレッドのメッセージを既読にする
+func (s *CaseDiscussionService) MarkMessagesAsRead(discussionID, userID uint) error {
+	if _, err := s.getDiscussionForMember(discussionID, userID); err != nil {
+		return err
+	}
+	return s.messageRepo.MarkCaseDiscussionAsRead(discussionID, userID)
+}
+
+// GetUnreadCount 症例相談スレッドの未読メッセージ数の取得
+func (s *CaseDiscussionService) GetUnreadCount(discussionID, userID uint) (int, error) {
+	if _, err := s.getDiscussionForMember(discussionID, userID); err != nil {
+		return 0, err
+	}
+	return s.messageRepo.GetCaseDiscussionUnreadCount(discussionID, userID)
+}
+
+// getDiscussion 予約に属する症例相談スレッドを取得する
+func (s *CaseDiscussionService) getDiscussion(appointmentID, discussionID uint) (*models.CaseDiscussion, *models.Appointment, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, nil, errors.New("appointment not found")
+	}
+
+	discussion, err := s.caseDiscussionRepo.FindByID(discussionID)
+	if err != nil || discussion.AppointmentID != appointmentID {
+		return nil, nil, errors.New("case discussion not found")
+	}
+
+	return discussion, appointment, nil
+}
+
+// getDiscussionForMember 参加医師であることを確認して症例相談スレッドを取得する
+// 患者・通訳者はスレッドの内容を閲覧できない
+func (s *CaseDiscussionService) getDiscussionForMember(discussionID, userID uint) (*models.CaseDiscussion, error) {
+	discussion, err := s.caseDiscussionRepo.FindByID(discussionID)
+	if err != nil {
+		return nil, errors.New("case discussion not found")
+	}
+
+	if !discussion.IsMember(userID) {
+		return nil, errors.New("unauthorized to access this case discussion")
+	}
+
+	return discussion, nil
+}
+
+func caseDiscussionNotificationData(discussion *models.CaseDiscussion) map[string]interface{} {
+	return map[string]interface{}{
+		"case_discussion_id": discussion.ID,
+		"appointment_id":     discussion.AppointmentID,
+	}
+}
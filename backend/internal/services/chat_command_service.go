@@ -0,0 +1,269 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/realtime"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 診療チャットのコマンド（医師が「/コマンド 引数」の形式で入力する）
+const (
+	ChatCommandPrescribe        = "prescribe"         // /prescribe 薬名 | 用量 | 用法 | 期間 [| 指示]（; 区切りで複数）
+	ChatCommandScheduleFollowUp = "schedule-followup" // /schedule-followup 14d|2w|2026-01-31 [メモ]
+	ChatCommandShareSummary     = "share-summary"     // /share-summary
+)
+
+// 再診の目安として指定できる期間の上限
+const followUpMaxDays = 365
+
+// ChatCommandService 診療チャットのコマンドの実行
+// 医師がチャットから処方・再診の目安の登録・サマリーの共有を行い、結果をシステムメッセージとして投稿する
+type ChatCommandService struct {
+	appointmentRepo      repositories.AppointmentRepository
+	messageRepo          repositories.MessageRepository
+	prescriptionService  *PrescriptionService
+	taskService          *TaskService
+	visitSummaryService  *VisitSummaryService
+	bookingPolicyService *BookingPolicyService
+	pushDispatcher       *PushDispatcher
+	hub                  *realtime.Hub
+	auditService         *AuditService
+}
+
+// chatCommandResult コマンドで投稿するシステムメッセージ
+type chatCommandResult struct {
+	event string
+	body  string
+	data  interface{}
+}
+
+func NewChatCommandService(appointmentRepo repositories.AppointmentRepository, messageRepo repositories.MessageRepository, prescriptionService *PrescriptionService, taskService *TaskService, visitSummaryService *VisitSummaryService, bookingPolicyService *BookingPolicyService, pushDispatcher *PushDispatcher, hub *realtime.Hub, auditService *AuditService) *ChatCommandService {
+	return &ChatCommandService{
+		appointmentRepo:      appointmentRepo,
+		messageRepo:          messageRepo,
+		prescriptionService:  prescriptionService,
+		taskService:          taskService,
+		visitSummaryService:  visitSummaryService,
+		bookingPolicyService: bookingPolicyService,
+		pushDispatcher:       pushDispatcher,
+		hub:                  hub,
+		auditService:         auditService,
+	}
+}
+
+// IsChatCommand メッセージがコマンドかどうか
+func IsChatCommand(body string) bool {
+	return strings.HasPrefix(strings.TrimSpace(body), "/")
+}
+
+// Execute コマンドの実行（予約の担当医師のみ）と結果のシステムメッセージの投稿
+func (s *ChatCommandService) Execute(appointmentID, doctorID uint, body string) (*models.Message, error) {
+	name, args, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(body), "/"), " ")
+	args = strings.TrimSpace(args)
+
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.DoctorID != doctorID {
+		return nil, errors.New("only the appointment doctor can use chat commands")
+	}
+	if appointment.Status == "cancelled" || (appointment.IsAsync && appointment.Status == "completed") {
+		return nil, errors.New("consultation is closed")
+	}
+
+	var result *chatCommandResult
+	switch name {
+	case ChatCommandPrescribe:
+		result, err = s.prescribe(appointment, args)
+	case ChatCommandScheduleFollowUp:
+		result, err = s.scheduleFollowUp(appointment, args)
+	case ChatCommandShareSummary:
+		result, err = s.shareSummary(appointment)
+	default:
+		return nil, fmt.Errorf("unknown chat command: /%s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dataJSON, err := json.Marshal(result.data)
+	if err != nil {
+		return nil, err
+	}
+	message := &models.Message{
+		AppointmentID:  appointment.ID,
+		SenderUserID:   doctorID,
+		Body:           result.body,
+		SystemEvent:    result.event,
+		SystemDataJSON: string(dataJSON),
+	}
+	if err := s.messageRepo.Create(message); err != nil {
+		return nil, err
+	}
+	if err := s.messageRepo.LoadRelations(message); err != nil {
+		return nil, err
+	}
+
+	if err := publishChatMessage(s.hub, appointment, message); err != nil {
+		log.Printf("Warning: Failed to publish message %d: %v", message.ID, err)
+	}
+	go s.pushDispatcher.PushChatMessage(appointment, message)
+
+	s.auditService.LogUserAction(doctorID, "chat_command", "appointment", fmt.Sprintf("%d", appointment.ID), map[string]interface{}{
+		"command":    name,
+		"message_id": message.ID,
+	})
+
+	return message, nil
+}
+
+// prescribe 処方の発行（項目は「薬名 | 用量 | 用法 | 期間 | 指示」、複数の場合は ; で区切る）
+func (s *ChatCommandService) prescribe(appointment *models.Appointment, args string) (*chatCommandResult, error) {
+	usage := errors.New("usage: /prescribe <medication> | <dosage> | <frequency> | <duration> [| <instructions>]")
+
+	var items []PrescriptionItem
+	for _, entry := range strings.Split(args, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		fields := strings.Split(entry, "|")
+		if len(fields) < 4 || len(fields) > 5 {
+			return nil, usage
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if fields[0] == "" || fields[1] == "" || fields[2] == "" || fields[3] == "" {
+			return nil, usage
+		}
+		item := PrescriptionItem{
+			MedicationName: fields[0],
+			Dosage:         fields[1],
+			Frequency:      fields[2],
+			Duration:       fields[3],
+		}
+		if len(fields) == 5 {
+			item.Instructions = fields[4]
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil, usage
+	}
+
+	prescription, err := s.prescriptionService.CreatePrescription(CreatePrescriptionRequest{
+		AppointmentID:     appointment.ID,
+		Items:             items,
+		CreatedByDoctorID: appointment.DoctorID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body := "処方を発行しました"
+	for _, item := range items {
+		line := fmt.Sprintf("・%s %s %s %s", item.MedicationName, item.Dosage, item.Frequency, item.Duration)
+		if item.Instructions != "" {
+			line += "（" + item.Instructions + "）"
+		}
+		body += "\n" + line
+	}
+
+	return &chatCommandResult{
+		event: "prescription_issued",
+		body:  body,
+		data: map[string]interface{}{
+			"prescription_id": prescription.ID,
+			"items":           items,
+		},
+	}, nil
+}
+
+// scheduleFollowUp 再診の目安の登録（患者のタスクとして登録し、期限の前にリマインドする）
+// 期間は「14d」（日）・「2w」（週）または日付（予約受付ルールのタイムゾーン）で指定する
+func (s *ChatCommandService) scheduleFollowUp(appointment *models.Appointment, args string) (*chatCommandResult, error) {
+	when, note, _ := strings.Cut(args, " ")
+	if when == "" {
+		return nil, errors.New("usage: /schedule-followup <days>d|<weeks>w|<YYYY-MM-DD> [note]")
+	}
+
+	location, err := s.bookingPolicyService.Location()
+	if err != nil {
+		location = time.Local
+	}
+	now := time.Now().In(location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+
+	var dueDate time.Time
+	switch {
+	case strings.HasSuffix(when, "d") || strings.HasSuffix(when, "w"):
+		n, err := strconv.Atoi(when[:len(when)-1])
+		if err != nil || n <= 0 {
+			return nil, errors.New("invalid follow-up period")
+		}
+		if strings.HasSuffix(when, "w") {
+			n *= 7
+		}
+		dueDate = today.AddDate(0, 0, n)
+	default:
+		if dueDate, err = time.ParseInLocation("2006-01-02", when, location); err != nil {
+			return nil, errors.New("invalid follow-up period")
+		}
+	}
+	if !dueDate.After(today) {
+		return nil, errors.New("follow-up date must be in the future")
+	}
+	if dueDate.After(today.AddDate(0, 0, followUpMaxDays)) {
+		return nil, fmt.Errorf("follow-up date must be within %d days", followUpMaxDays)
+	}
+
+	// 期限はその日の終わり（リマインドは既定どおり期限の24時間前）
+	dueAt := dueDate.AddDate(0, 0, 1).Add(-time.Minute)
+	task, err := s.taskService.CreateTask(appointment.ID, appointment.DoctorID, CreateTaskRequest{
+		Title:       "再診",
+		Description: strings.TrimSpace(note),
+		Assignee:    "patient",
+		DueAt:       &dueAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body := "再診の目安: " + dueDate.Format("2006年1月2日")
+	if task.Description != "" {
+		body += "\n" + task.Description
+	}
+
+	return &chatCommandResult{
+		event: "followup_scheduled",
+		body:  body,
+		data: map[string]interface{}{
+			"task_id":  task.ID,
+			"due_date": dueDate.Format("2006-01-02"),
+			"note":     task.Description,
+		},
+	}, nil
+}
+
+// shareSummary 診療サマリーの共有
+func (s *ChatCommandService) shareSummary(appointment *models.Appointment) (*chatCommandResult, error) {
+	summary, text, err := s.visitSummaryService.ShareSummary(appointment.ID, appointment.DoctorID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &chatCommandResult{
+		event: "summary_shared",
+		body:  "診療サマリー\n" + text,
+		data:  summary,
+	}, nil
+}
@@ -1,23 +1,68 @@
 package services
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"online_medical_consultation_app/backend/internal/contentfilter"
+	"online_medical_consultation_app/backend/internal/imaging"
 	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/realtime"
 	"online_medical_consultation_app/backend/internal/repositories"
+	"online_medical_consultation_app/backend/internal/storage"
+	"online_medical_consultation_app/backend/internal/views"
 )
 
+// 添付ファイルのメッセージに記録するURL（ダウンロード時に参加者かどうかを確認する）
+const chatAttachmentURLFormat = "/api/v1/appointments/%d/chat/attachments/%d"
+
+// 添付ファイルとして扱える形式と保存するファイルの拡張子
+// ロールごとに実際に添付できる形式は AttachmentPolicyService で管理者が設定する
+var chatAttachmentContentTypes = map[string]string{
+	"image/jpeg":        ".jpg",
+	"image/png":         ".png",
+	"image/gif":         ".gif",
+	"application/pdf":   ".pdf",
+	"application/dicom": ".dcm",
+	"video/mp4":         ".mp4",
+	"video/quicktime":   ".mov",
+	"video/webm":        ".webm",
+}
+
 type ChatService struct {
-	messageRepo      repositories.MessageRepository
-	appointmentRepo  repositories.AppointmentRepository
-	userRepo         repositories.UserRepository
-	uploadPath       string
+	messageRepo             repositories.MessageRepository
+	attachmentRepo          repositories.AttachmentRepository
+	appointmentRepo         repositories.AppointmentRepository
+	userRepo                repositories.UserRepository
+	videoSessionRepo        repositories.VideoSessionRepository
+	coverageRepo            repositories.CoverageRepository
+	contentFilter           *contentfilter.Pipeline
+	autoReplyService        *AutoReplyService
+	usageQuotaService       *UsageQuotaService
+	pushDispatcher          *PushDispatcher
+	hub                     *realtime.Hub
+	auditService            *AuditService
+	attachmentPolicyService *AttachmentPolicyService
+	attachmentObjects       *StoredObjectService
+	attachmentStore         storage.Store
+	attachmentURLTTL        time.Duration
+}
+
+// AttachmentFile ダウンロードする添付ファイル（呼び出し側で Body を閉じる、Size が不明な場合は-1）
+type AttachmentFile struct {
+	Filename    string
+	ContentType string
+	Size        int64
+	Body        io.ReadCloser
 }
 
 type SendMessageRequest struct {
@@ -25,58 +70,158 @@ type SendMessageRequest struct {
 	SenderUserID   uint   `json:"sender_user_id"`
 	Body           string `json:"body" binding:"required"`
 	AttachmentURL  *string `json:"attachment_url,omitempty"`
+	VideoSessionID *uint   `json:"-"`
 }
 
-func NewChatService(messageRepo repositories.MessageRepository, appointmentRepo repositories.AppointmentRepository, userRepo repositories.UserRepository) *ChatService {
-	uploadPath := os.Getenv("UPLOAD_PATH")
-	if uploadPath == "" {
-		uploadPath = "./uploads"
-	}
+// MessageBlockedError 内容フィルタにより送信を拒否した
+type MessageBlockedError struct {
+	Hits []contentfilter.Hit
+}
 
-	// アップロードディレクトリの作成
-	if err := os.MkdirAll(uploadPath, 0755); err != nil {
-		fmt.Printf("Warning: Failed to create upload directory: %v\n", err)
+func (e *MessageBlockedError) Error() string {
+	rules := make([]string, 0, len(e.Hits))
+	for _, hit := range e.Hits {
+		if hit.Action == contentfilter.ActionBlock {
+			rules = append(rules, hit.Rule)
+		}
 	}
+	return "message blocked by content filter: " + strings.Join(rules, ", ")
+}
 
+func NewChatService(messageRepo repositories.MessageRepository, attachmentRepo repositories.AttachmentRepository, appointmentRepo repositories.AppointmentRepository, userRepo repositories.UserRepository, videoSessionRepo repositories.VideoSessionRepository, coverageRepo repositories.CoverageRepository, contentFilter *contentfilter.Pipeline, autoReplyService *AutoReplyService, usageQuotaService *UsageQuotaService, pushDispatcher *PushDispatcher, hub *realtime.Hub, auditService *AuditService, attachmentPolicyService *AttachmentPolicyService, attachmentObjects *StoredObjectService, attachmentStore storage.Store, attachmentURLTTL time.Duration) *ChatService {
 	return &ChatService{
-		messageRepo:     messageRepo,
-		appointmentRepo: appointmentRepo,
-		userRepo:        userRepo,
-		uploadPath:      uploadPath,
+		messageRepo:             messageRepo,
+		attachmentRepo:          attachmentRepo,
+		appointmentRepo:         appointmentRepo,
+		userRepo:                userRepo,
+		videoSessionRepo:        videoSessionRepo,
+		coverageRepo:            coverageRepo,
+		contentFilter:           contentFilter,
+		autoReplyService:        autoReplyService,
+		usageQuotaService:       usageQuotaService,
+		pushDispatcher:          pushDispatcher,
+		hub:                     hub,
+		auditService:            auditService,
+		attachmentPolicyService: attachmentPolicyService,
+		attachmentObjects:       attachmentObjects,
+		attachmentStore:         attachmentStore,
+		attachmentURLTTL:        attachmentURLTTL,
 	}
 }
 
 // SendMessage メッセージの送信
-func (s *ChatService) SendMessage(req SendMessageRequest) (*models.Message, error) {
+// 内容フィルタで警告対象となった場合は送信したうえで一致したルールを返す
+func (s *ChatService) SendMessage(req SendMessageRequest) (*models.Message, []contentfilter.Hit, error) {
 	// 予約の存在確認
 	appointment, err := s.appointmentRepo.FindByID(req.AppointmentID)
 	if err != nil || appointment == nil {
-		return nil, errors.New("appointment not found")
+		return nil, nil, errors.New("appointment not found")
+	}
+
+	// 送信者の権限確認（患者・医師・通訳者、代診期間中の代診医のみ）
+	if !s.canAccessChat(appointment, req.SenderUserID) {
+		return nil, nil, errors.New("unauthorized to send message to this appointment")
+	}
+
+	// モデレーションでチャット送信を停止されている場合は送信できない
+	sender, err := s.userRepo.FindByID(req.SenderUserID)
+	if err != nil || sender == nil {
+		return nil, nil, errors.New("user not found")
+	}
+	if sender.ChatSuspendedUntil != nil && sender.ChatSuspendedUntil.After(time.Now()) {
+		return nil, nil, fmt.Errorf("chat is suspended until %s", sender.ChatSuspendedUntil.Format(time.RFC3339))
+	}
+
+	// 終了した非同期相談には送信できない
+	if appointment.IsAsync && (appointment.Status == "completed" || appointment.Status == "cancelled") {
+		return nil, nil, errors.New("consultation is closed")
+	}
+
+	// 診療の完了後は患者のプランの期間のみ送信できる
+	if err := s.usageQuotaService.CheckChat(appointment, req.SenderUserID); err != nil {
+		return nil, nil, err
+	}
+
+	// 添付は送信者がこの予約にアップロードしたファイルのみ（他の予約・他のユーザーのファイルを参照させない）
+	var attachmentID *uint
+	if req.AttachmentURL != nil {
+		attachment, err := s.attachmentFromURL(appointment.ID, *req.AttachmentURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		if attachment == nil || attachment.UploadedByID != req.SenderUserID {
+			return nil, nil, errors.New("invalid attachment")
+		}
+		attachmentID = &attachment.ID
+	}
+
+	// 内容フィルタ（一致したルールはコンプライアンス確認用に監査ログへ記録し、本文は記録しない）
+	result := s.contentFilter.Check(req.Body)
+	if len(result.Hits) > 0 {
+		s.auditService.LogUserAction(req.SenderUserID, "chat_filter_hit", "appointment", fmt.Sprintf("%d", appointment.ID), map[string]interface{}{
+			"rules":   result.Rules(),
+			"hits":    result.Hits,
+			"blocked": result.Blocked,
+		})
+	}
+	if result.Blocked {
+		return nil, nil, &MessageBlockedError{Hits: result.Hits}
 	}
 
-	// 送信者の権限確認（患者または医師のみ）
-	if appointment.PatientID != req.SenderUserID && appointment.DoctorID != req.SenderUserID {
-		return nil, errors.New("unauthorized to send message to this appointment")
+	// 医師の対応時間外に患者から届いたメッセージは自動返信し、医師への通知を次の対応時間の開始まで遅らせる
+	var off *offHours
+	if req.SenderUserID == appointment.PatientID && req.VideoSessionID == nil {
+		if off, err = s.autoReplyService.checkOffHours(appointment.DoctorID, time.Now()); err != nil {
+			fmt.Printf("Warning: Failed to check off-hours of doctor %d: %v\n", appointment.DoctorID, err)
+			off = nil
+		}
 	}
 
 	// メッセージの作成
 	message := &models.Message{
-		AppointmentID: req.AppointmentID,
-		SenderUserID:  req.SenderUserID,
-		Body:          req.Body,
-		AttachmentURL: req.AttachmentURL,
+		AppointmentID:  req.AppointmentID,
+		SenderUserID:   req.SenderUserID,
+		Body:           req.Body,
+		AttachmentURL:  req.AttachmentURL,
+		AttachmentID:   attachmentID,
+		VideoSessionID: req.VideoSessionID,
+	}
+	if off != nil {
+		message.NotifyAfter = &off.until
 	}
 
 	if err := s.messageRepo.Create(message); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	// 非同期相談は医師の最初の回答で対応中になる
+	if appointment.IsAsync && appointment.Status == "pending" && req.SenderUserID == appointment.DoctorID {
+		if responded, err := s.appointmentRepo.MarkAsyncResponded(appointment.ID, message.CreatedAt); err != nil {
+			fmt.Printf("Warning: Failed to mark async consultation %d as responded: %v\n", appointment.ID, err)
+		} else if responded {
+			appointment.Status = "confirmed"
+			publishAppointmentStatus(s.hub, appointment)
+		}
 	}
 
 	// 関連データの読み込み
 	if err := s.messageRepo.LoadRelations(message); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	// 接続中の参加者へ配信（送信者の他の端末を含む）
+	if err := publishChatMessage(s.hub, appointment, message); err != nil {
+		fmt.Printf("Warning: Failed to publish message %d: %v\n", message.ID, err)
 	}
 
-	return message, nil
+	// 送信者以外の参加者の端末へのプッシュ通知（対応時間外のメッセージは遅らせる）
+	go s.pushDispatcher.PushChatMessage(appointment, message)
+
+	if off != nil {
+		s.autoReplyService.replyOffHours(appointment, off)
+	}
+
+	return message, result.Hits, nil
 }
 
 // GetMessages メッセージ一覧の取得
@@ -87,8 +232,8 @@ func (s *ChatService) GetMessages(appointmentID, userID uint, limit, offset int)
 		return nil, errors.New("appointment not found")
 	}
 
-	// 権限確認（患者または医師のみ）
-	if appointment.PatientID != userID && appointment.DoctorID != userID {
+	// 権限確認（患者・医師・通訳者、代診期間中の代診医のみ）
+	if !s.canAccessChat(appointment, userID) {
 		return nil, errors.New("unauthorized to view messages for this appointment")
 	}
 
@@ -105,53 +250,251 @@ func (s *ChatService) GetMessages(appointmentID, userID uint, limit, offset int)
 		}
 	}
 
+	// PHI閲覧ログの記録
+	s.auditService.LogPHIAccess(userID, appointment.PatientID, "message", fmt.Sprintf("%d", appointmentID), map[string]interface{}{
+		"appointment_id": appointmentID,
+		"count":          len(messages),
+	})
+
 	return messages, nil
 }
 
-// UploadAttachment 添付ファイルのアップロード
-func (s *ChatService) UploadAttachment(file *multipart.FileHeader, appointmentID, userID uint) (string, error) {
+// UploadAttachment 添付ファイルのアップロード（メタデータを記録し、メッセージに添付するURLを返す）
+func (s *ChatService) UploadAttachment(file *multipart.FileHeader, appointmentID, userID uint) (*models.Attachment, string, error) {
 	// 予約の存在確認
 	appointment, err := s.appointmentRepo.FindByID(appointmentID)
 	if err != nil || appointment == nil {
-		return "", errors.New("appointment not found")
+		return nil, "", errors.New("appointment not found")
 	}
 
-	// 権限確認（患者または医師のみ）
-	if appointment.PatientID != userID && appointment.DoctorID != userID {
-		return "", errors.New("unauthorized to upload attachment for this appointment")
+	// 権限確認（患者・医師・通訳者、代診期間中の代診医のみ）
+	if !s.canAccessChat(appointment, userID) {
+		return nil, "", errors.New("unauthorized to upload attachment for this appointment")
 	}
 
-	// ファイル名の生成（重複回避）
-	timestamp := time.Now().Unix()
-	filename := fmt.Sprintf("%d_%d_%s", appointmentID, timestamp, filepath.Base(file.Filename))
-	filePath := filepath.Join(s.uploadPath, filename)
+	if appointment.IsAsync && (appointment.Status == "completed" || appointment.Status == "cancelled") {
+		return nil, "", errors.New("consultation is closed")
+	}
+	if err := s.usageQuotaService.CheckChat(appointment, userID); err != nil {
+		return nil, "", err
+	}
 
-	// ディレクトリの作成
-	uploadDir := filepath.Dir(filePath)
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create directory: %v", err)
+	// ロールごとの添付できる形式・サイズの確認
+	uploader, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, "", errors.New("user not found")
+	}
+	contentType := file.Header.Get("Content-Type")
+	if err := s.attachmentPolicyService.CheckUpload(uploader.Role, contentType, file.Size); err != nil {
+		return nil, "", err
+	}
+	ext, ok := chatAttachmentContentTypes[contentType]
+	if !ok {
+		return nil, "", errors.New("unsupported attachment type")
 	}
 
 	src, err := file.Open()
 	if err != nil {
-		return "", fmt.Errorf("failed to open file: %v", err)
+		return nil, "", fmt.Errorf("failed to open file: %v", err)
 	}
 	defer src.Close()
 
-	dst, err := os.Create(filePath)
+	attachment := &models.Attachment{
+		AppointmentID: appointmentID,
+		UploadedByID:  userID,
+		Filename:      filepath.Base(file.Filename),
+		ContentType:   contentType,
+		SizeBytes:     file.Size,
+		Storage:       s.attachmentStore.Name(),
+	}
+
+	// 画像は位置情報などのメタデータを除いた版のみ保存し、プレビューの表示に使う寸法とBlurhashを記録する
+	var body io.ReadSeeker = src
+	if imaging.Supported(contentType) {
+		data, err := io.ReadAll(src)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read file: %v", err)
+		}
+		processed, err := imaging.Process(data, contentType)
+		if err != nil {
+			return nil, "", err
+		}
+		body = bytes.NewReader(processed.Data)
+		attachment.SizeBytes = int64(len(processed.Data))
+		attachment.Width = &processed.Width
+		attachment.Height = &processed.Height
+		attachment.Blurhash = processed.Blurhash
+	}
+
+	// 同じ内容のファイルは保存済みのファイルを参照する（拡張子は形式から決め、ファイル名はメタデータにのみ記録する）
+	object, err := s.attachmentObjects.Put(context.Background(), body, attachment.SizeBytes, contentType, ext)
+	if err != nil {
+		return nil, "", err
+	}
+	attachment.ObjectKey = object.ObjectKey
+	attachment.Checksum = object.Checksum
+	if err := s.attachmentRepo.Create(attachment); err != nil {
+		if removeErr := s.attachmentObjects.Release(context.Background(), object.ObjectKey); removeErr != nil {
+			fmt.Printf("Warning: Failed to remove unrecorded attachment %s: %v\n", object.ObjectKey, removeErr)
+		}
+		return nil, "", err
+	}
+
+	return attachment, fmt.Sprintf(chatAttachmentURLFormat, appointmentID, attachment.ID), nil
+}
+
+// OpenAttachment 添付ファイルの取得（チャットに参加できるユーザーのみ）
+func (s *ChatService) OpenAttachment(appointmentID, attachmentID, userID uint) (*AttachmentFile, error) {
+	appointment, attachment, err := s.findAttachment(appointmentID, attachmentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := s.attachmentStore.Open(context.Background(), attachment.ObjectKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, errors.New("attachment file not found")
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to create file: %v", err)
+		return nil, err
 	}
-	defer dst.Close()
 
-	// ファイルのコピー
-	if _, err := io.Copy(dst, src); err != nil {
-		return "", fmt.Errorf("failed to copy file: %v", err)
+	s.logAttachmentAccess(userID, appointment, attachment)
+
+	return &AttachmentFile{
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Size:        attachment.SizeBytes,
+		Body:        body,
+	}, nil
+}
+
+// GetAttachmentURL 添付ファイルの署名付きURLの発行（チャットに参加できるユーザーのみ、有効期限付き）
+func (s *ChatService) GetAttachmentURL(appointmentID, attachmentID, userID uint) (string, time.Time, error) {
+	appointment, attachment, err := s.findAttachment(appointmentID, attachmentID, userID)
+	if err != nil {
+		return "", time.Time{}, err
 	}
 
-	// ファイルURLの生成
-	fileURL := fmt.Sprintf("/uploads/%s", filename)
-	return fileURL, nil
+	expiresAt := time.Now().Add(s.attachmentURLTTL)
+	signedURL, err := s.attachmentStore.PresignGet(attachment.ObjectKey, attachment.Filename, s.attachmentURLTTL)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	s.logAttachmentAccess(userID, appointment, attachment)
+
+	return signedURL, expiresAt, nil
+}
+
+// OpenSignedAttachment ローカルに保存した添付ファイルの署名付きURLでのダウンロード（S3の場合は直接S3からダウンロードする）
+func (s *ChatService) OpenSignedAttachment(key, filename, expires, signature string) (*AttachmentFile, error) {
+	local, ok := s.attachmentStore.(*storage.LocalStore)
+	if !ok || !(strings.HasPrefix(key, "objects/") || strings.HasPrefix(key, "chat/")) {
+		return nil, errors.New("attachment not found")
+	}
+	if err := local.VerifyPresigned(key, filename, expires, signature); err != nil {
+		return nil, err
+	}
+
+	body, err := local.Open(context.Background(), key)
+	if err != nil {
+		return nil, errors.New("attachment not found")
+	}
+
+	// 拡張子から判定した形式はアップロードを許可した形式のみ返す（それ以外はブラウザに解釈させない）
+	contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(key)))
+	if _, ok := chatAttachmentContentTypes[contentType]; !ok {
+		contentType = "application/octet-stream"
+	}
+	size := int64(-1)
+	if file, ok := body.(*os.File); ok {
+		if info, err := file.Stat(); err == nil {
+			size = info.Size()
+		}
+	}
+	if filename == "" {
+		filename = filepath.Base(key)
+	}
+	return &AttachmentFile{Filename: filename, ContentType: contentType, Size: size, Body: body}, nil
+}
+
+// ShareSessionFile ビデオ通話中に共有したファイルを診療チャットの添付として記録する
+// 通常の添付と同じアップロード・送信処理を使い、説明文が空の場合はファイル名を本文とする
+func (s *ChatService) ShareSessionFile(appointmentID, sessionID, userID uint, file *multipart.FileHeader, caption string) (*models.Message, []contentfilter.Hit, error) {
+	session, err := s.videoSessionRepo.FindByID(sessionID)
+	if err != nil || session == nil || session.AppointmentID != appointmentID {
+		return nil, nil, errors.New("video session not found")
+	}
+	if session.StartedAt == nil || session.EndedAt != nil {
+		return nil, nil, errors.New("files can only be shared during an active video session")
+	}
+
+	attachment, attachmentURL, err := s.UploadAttachment(file, appointmentID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body := strings.TrimSpace(caption)
+	if body == "" {
+		body = filepath.Base(file.Filename)
+	}
+	message, hits, err := s.SendMessage(SendMessageRequest{
+		AppointmentID:  appointmentID,
+		SenderUserID:   userID,
+		Body:           body,
+		AttachmentURL:  &attachmentURL,
+		VideoSessionID: &sessionID,
+	})
+	if err != nil {
+		// 記録されなかったファイルは残さない
+		if removeErr := s.RemoveAttachment(attachment); removeErr != nil {
+			fmt.Printf("Warning: Failed to remove unshared file %s: %v\n", attachmentURL, removeErr)
+		}
+		return nil, nil, err
+	}
+	return message, hits, nil
+}
+
+// RemoveAttachment 添付ファイルの削除（ファイル・記録が既にない場合は何もしない）
+// 同じ内容の他の添付ファイルが参照しているファイルは残す
+func (s *ChatService) RemoveAttachment(attachment *models.Attachment) error {
+	if err := s.attachmentObjects.Release(context.Background(), attachment.ObjectKey); err != nil {
+		return err
+	}
+	return s.attachmentRepo.Delete(attachment.ID)
+}
+
+// GetSessionFiles ビデオセッション中に共有されたファイルの一覧
+func (s *ChatService) GetSessionFiles(appointmentID, sessionID, userID uint) ([]models.Message, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if !appointment.IsParticipant(userID) {
+		return nil, errors.New("unauthorized to view files for this appointment")
+	}
+	session, err := s.videoSessionRepo.FindByID(sessionID)
+	if err != nil || session == nil || session.AppointmentID != appointmentID {
+		return nil, errors.New("video session not found")
+	}
+
+	messages, err := s.messageRepo.FindSharedFilesBySession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range messages {
+		if err := s.messageRepo.LoadRelations(&messages[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	// PHI閲覧ログの記録
+	s.auditService.LogPHIAccess(userID, appointment.PatientID, "message", fmt.Sprintf("%d", appointmentID), map[string]interface{}{
+		"video_session_id": sessionID,
+		"count":            len(messages),
+	})
+
+	return messages, nil
 }
 
 // MarkMessagesAsRead メッセージを既読にする
@@ -162,8 +505,8 @@ func (s *ChatService) MarkMessagesAsRead(appointmentID, userID uint) error {
 		return errors.New("appointment not found")
 	}
 
-	// 権限確認（患者または医師のみ）
-	if appointment.PatientID != userID && appointment.DoctorID != userID {
+	// 権限確認（患者・医師・通訳者、代診期間中の代診医のみ）
+	if !s.canAccessChat(appointment, userID) {
 		return errors.New("unauthorized to mark messages as read for this appointment")
 	}
 
@@ -179,11 +522,92 @@ func (s *ChatService) GetUnreadCount(appointmentID, userID uint) (int, error) {
 		return 0, errors.New("appointment not found")
 	}
 
-	// 権限確認（患者または医師のみ）
-	if appointment.PatientID != userID && appointment.DoctorID != userID {
+	// 権限確認（患者・医師・通訳者、代診期間中の代診医のみ）
+	if !s.canAccessChat(appointment, userID) {
 		return 0, errors.New("unauthorized to get unread count for this appointment")
 	}
 
 	// 未読メッセージ数の取得
 	return s.messageRepo.GetUnreadCount(appointmentID, userID)
 }
+
+// GetUnreadSummary 参加する全予約の未読メッセージ数（予約ごとと合計）の取得
+func (s *ChatService) GetUnreadSummary(userID uint) (*UnreadSummary, error) {
+	counts, err := s.messageRepo.GetUnreadCountsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := newUnreadSummary(counts)
+	return &summary, nil
+}
+
+// canAccessChat チャットに参加できるかどうか（予約の参加者、または未完了の予約の担当医師の代診期間中の代診医）
+func (s *ChatService) canAccessChat(appointment *models.Appointment, userID uint) bool {
+	if appointment.IsParticipant(userID) {
+		return true
+	}
+	if appointment.Status != "pending" && appointment.Status != "confirmed" {
+		return false
+	}
+	covering, err := s.coverageRepo.IsCovering(appointment.DoctorID, userID, time.Now())
+	if err != nil {
+		fmt.Printf("Warning: Failed to check coverage of doctor %d: %v\n", appointment.DoctorID, err)
+		return false
+	}
+	return covering
+}
+
+// findAttachment 予約の添付ファイルの取得（チャットに参加できるユーザーのみ、他の予約の添付ファイルは存在しないものとして扱う）
+func (s *ChatService) findAttachment(appointmentID, attachmentID, userID uint) (*models.Appointment, *models.Attachment, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, nil, errors.New("appointment not found")
+	}
+	if !s.canAccessChat(appointment, userID) {
+		return nil, nil, errors.New("unauthorized to view attachments for this appointment")
+	}
+	attachment, err := s.attachmentRepo.FindByID(attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if attachment == nil || attachment.AppointmentID != appointmentID {
+		return nil, nil, errors.New("attachment not found")
+	}
+	return appointment, attachment, nil
+}
+
+// attachmentFromURL メッセージに添付したURLの添付ファイル（この予約の添付ファイルのURLでない場合はnil）
+func (s *ChatService) attachmentFromURL(appointmentID uint, attachmentURL string) (*models.Attachment, error) {
+	var urlAppointmentID, attachmentID uint
+	if _, err := fmt.Sscanf(attachmentURL, chatAttachmentURLFormat, &urlAppointmentID, &attachmentID); err != nil {
+		return nil, nil
+	}
+	if urlAppointmentID != appointmentID || attachmentURL != fmt.Sprintf(chatAttachmentURLFormat, urlAppointmentID, attachmentID) {
+		return nil, nil
+	}
+	attachment, err := s.attachmentRepo.FindByID(attachmentID)
+	if err != nil || attachment == nil || attachment.AppointmentID != appointmentID {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// logAttachmentAccess 添付ファイルの閲覧の記録（PHI閲覧ログ）
+func (s *ChatService) logAttachmentAccess(userID uint, appointment *models.Appointment, attachment *models.Attachment) {
+	s.auditService.LogPHIAccess(userID, appointment.PatientID, "attachment", fmt.Sprintf("%d", attachment.ID), map[string]interface{}{
+		"appointment_id": appointment.ID,
+	})
+}
+
+// publishChatMessage メッセージを参加者の接続中の端末へ送る（送信者の情報は受け取る参加者のロールに応じて絞り込む）
+func publishChatMessage(hub *realtime.Hub, appointment *models.Appointment, message *models.Message) error {
+	var errs []error
+	for _, userID := range appointment.ParticipantIDs() {
+		view := views.Message(views.AppointmentParticipant(appointment, userID), message)
+		if err := hub.Publish([]uint{userID}, "chat.message", view); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/clinicalcoding"
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+const (
+	codingSuggestionLimit   = 10
+	codingSuggestionTimeout = 30 * time.Second
+)
+
+type ClinicalCodingService struct {
+	codingRepo      repositories.ClinicalCodingRepository
+	appointmentRepo repositories.AppointmentRepository
+	auditService    *AuditService
+	provider        clinicalcoding.Provider
+}
+
+type SuggestCodesRequest struct {
+	Note clinicalcoding.Note `json:"note"`
+}
+
+// ReviewCodingSuggestionsRequest 医師による候補の確認（確定した候補のみ問題リストに追加する）
+type ReviewCodingSuggestionsRequest struct {
+	ConfirmIDs []uint `json:"confirm_ids"`
+	RejectIDs  []uint `json:"reject_ids"`
+}
+
+// ReviewCodingSuggestionsResult 確認の結果
+type ReviewCodingSuggestionsResult struct {
+	Confirmed     int64                     `json:"confirmed"`
+	Rejected      int64                     `json:"rejected"`
+	AddedProblems []models.ProblemListEntry `json:"added_problems"`
+}
+
+func NewClinicalCodingService(codingRepo repositories.ClinicalCodingRepository, appointmentRepo repositories.AppointmentRepository, auditService *AuditService, provider clinicalcoding.Provider) *ClinicalCodingService {
+	return &ClinicalCodingService{
+		codingRepo:      codingRepo,
+		appointmentRepo: appointmentRepo,
+		auditService:    auditService,
+		provider:        provider,
+	}
+}
+
+// SuggestCodes 診療記録（SOAP）からICD-10コードの候補を提案する（担当医師のみ）
+// 記録の本文は保存せず、候補のみ確認待ちとして保存する
+func (s *ClinicalCodingService) SuggestCodes(appointmentID, doctorID uint, req SuggestCodesRequest) ([]models.CodingSuggestion, error) {
+	if s.provider == nil {
+		return nil, errors.New("clinical coding suggestions are not enabled")
+	}
+	if _, err := s.getAppointmentForDoctor(appointmentID, doctorID); err != nil {
+		return nil, err
+	}
+	if req.Note.Text() == "" {
+		return nil, errors.New("note text is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), codingSuggestionTimeout)
+	defer cancel()
+	results, err := s.provider.Suggest(ctx, req.Note, codingSuggestionLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coding suggestions: %v", err)
+	}
+
+	suggestions := make([]models.CodingSuggestion, 0, len(results))
+	for _, result := range results {
+		suggestions = append(suggestions, models.CodingSuggestion{
+			AppointmentID: appointmentID,
+			DoctorID:      doctorID,
+			Code:          result.Code,
+			Description:   result.Description,
+			Confidence:    result.Confidence,
+			Evidence:      result.Evidence,
+			Provider:      s.provider.Name(),
+			Status:        "pending",
+		})
+	}
+	if err := s.codingRepo.CreateSuggestions(suggestions); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(doctorID, "coding_suggested", "appointment", fmt.Sprintf("%d", appointmentID), map[string]interface{}{
+		"provider":    s.provider.Name(),
+		"suggestions": len(suggestions),
+	})
+
+	return suggestions, nil
+}
+
+// GetSuggestions 予約のコード候補一覧（担当医師のみ）
+func (s *ClinicalCodingService) GetSuggestions(appointmentID, doctorID uint) ([]models.CodingSuggestion, error) {
+	if _, err := s.getAppointmentForDoctor(appointmentID, doctorID); err != nil {
+		return nil, err
+	}
+	return s.codingRepo.FindSuggestionsByAppointmentID(appointmentID)
+}
+
+// ReviewSuggestions 候補の確定・却下（担当医師のみ、確定した候補を患者の問題リストへ追加）
+func (s *ClinicalCodingService) ReviewSuggestions(appointmentID, doctorID uint, req ReviewCodingSuggestionsRequest) (*ReviewCodingSuggestionsResult, error) {
+	appointment, err := s.getAppointmentForDoctor(appointmentID, doctorID)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.ConfirmIDs) == 0 && len(req.RejectIDs) == 0 {
+		return nil, errors.New("confirm_ids or reject_ids is required")
+	}
+	for _, id := range req.ConfirmIDs {
+		for _, rejectID := range req.RejectIDs {
+			if id == rejectID {
+				return nil, errors.New("a suggestion cannot be both confirmed and rejected")
+			}
+		}
+	}
+
+	result := &ReviewCodingSuggestionsResult{AddedProblems: []models.ProblemListEntry{}}
+	now := time.Now()
+
+	if len(req.ConfirmIDs) > 0 {
+		suggestions, err := s.codingRepo.FindSuggestionsByIDs(appointmentID, req.ConfirmIDs)
+		if err != nil {
+			return nil, err
+		}
+		var entries []models.ProblemListEntry
+		for _, suggestion := range suggestions {
+			if suggestion.Status != "pending" {
+				continue
+			}
+			suggestionID := suggestion.ID
+			entries = append(entries, models.ProblemListEntry{
+				PatientID:           appointment.PatientID,
+				DependentID:         appointment.DependentID,
+				Code:                suggestion.Code,
+				Description:         suggestion.Description,
+				Status:              "active",
+				SourceAppointmentID: &appointment.ID,
+				SuggestionID:        &suggestionID,
+				AddedByID:           doctorID,
+			})
+		}
+
+		confirmed, err := s.codingRepo.ReviewSuggestions(appointmentID, req.ConfirmIDs, "confirmed", now)
+		if err != nil {
+			return nil, err
+		}
+		result.Confirmed = confirmed
+
+		added, err := s.codingRepo.AddProblems(entries)
+		if err != nil {
+			return nil, err
+		}
+		if added != nil {
+			result.AddedProblems = added
+		}
+	}
+
+	if len(req.RejectIDs) > 0 {
+		rejected, err := s.codingRepo.ReviewSuggestions(appointmentID, req.RejectIDs, "rejected", now)
+		if err != nil {
+			return nil, err
+		}
+		result.Rejected = rejected
+	}
+
+	codes := make([]string, 0, len(result.AddedProblems))
+	for _, entry := range result.AddedProblems {
+		codes = append(codes, entry.Code)
+	}
+	s.auditService.LogUserAction(doctorID, "coding_reviewed", "appointment", fmt.Sprintf("%d", appointmentID), map[string]interface{}{
+		"confirmed":   result.Confirmed,
+		"rejected":    result.Rejected,
+		"added_codes": codes,
+	})
+
+	return result, nil
+}
+
+// GetProblemList 予約の患者（家族の予約の場合はその家族）の問題リスト（担当医師・患者本人のみ）
+func (s *ClinicalCodingService) GetProblemList(appointmentID, userID uint) ([]models.ProblemListEntry, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.PatientID != userID && appointment.DoctorID != userID {
+		return nil, errors.New("unauthorized to view the problem list for this appointment")
+	}
+
+	entries, err := s.codingRepo.FindProblems(appointment.PatientID, appointment.DependentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// PHI閲覧ログの記録
+	s.auditService.LogPHIAccess(userID, appointment.PatientID, "problem_list", fmt.Sprintf("%d", appointment.PatientID), map[string]interface{}{
+		"appointment_id": appointmentID,
+	})
+
+	return entries, nil
+}
+
+func (s *ClinicalCodingService) getAppointmentForDoctor(appointmentID, doctorID uint) (*models.Appointment, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.DoctorID != doctorID {
+		return nil, errors.New("only the assigned doctor can code this appointment")
+	}
+	if appointment.Status == "cancelled" {
+		return nil, errors.New("appointment is cancelled")
+	}
+	return appointment, nil
+}
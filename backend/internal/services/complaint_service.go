@@ -0,0 +1,460 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 苦情に自動添付する監査ログの最大件数
+const complaintEvidenceLimit = 500
+
+// 苦情ステータスの遷移（open → investigating → resolved）
+var complaintTransitions = map[string]string{
+	"open":          "investigating",
+	"investigating": "resolved",
+}
+
+type ComplaintService struct {
+	complaintRepo       repositories.ComplaintRepository
+	appointmentRepo     repositories.AppointmentRepository
+	messageRepo         repositories.MessageRepository
+	videoSessionRepo    repositories.VideoSessionRepository
+	auditRepo           repositories.AuditRepository
+	userRepo            repositories.UserRepository
+	notificationService *NotificationService
+	auditService        *AuditService
+}
+
+type FileComplaintRequest struct {
+	AppointmentID  *uint  `json:"appointment_id"`
+	VideoSessionID *uint  `json:"video_session_id"`
+	Category       string `json:"category" binding:"required,oneof=quality conduct technical billing privacy other"`
+	Subject        string `json:"subject" binding:"required"`
+	Description    string `json:"description" binding:"required"`
+}
+
+type AssignComplaintRequest struct {
+	AdminID uint `json:"admin_id" binding:"required"`
+}
+
+type UpdateComplaintStatusRequest struct {
+	Status     string `json:"status" binding:"required,oneof=investigating resolved"`
+	Resolution string `json:"resolution"`
+}
+
+// ViewChatTranscriptRequest 苦情の審査のための診療チャットの閲覧（緊急時アクセスと同様に理由の記録が必須）
+type ViewChatTranscriptRequest struct {
+	Justification string `json:"justification" binding:"required,min=20,max=2000"`
+}
+
+// DisputeTranscript 苦情の審査で閲覧する診療チャット（閲覧専用、閲覧ごとの透かしを付ける）
+type DisputeTranscript struct {
+	ComplaintID   uint                `json:"complaint_id"`
+	AppointmentID uint                `json:"appointment_id"`
+	ReadOnly      bool                `json:"read_only"`
+	WatermarkID   string              `json:"watermark_id"` // 監査ログと照合するための閲覧ごとの識別子
+	Watermark     string              `json:"watermark"`
+	ViewedAt      time.Time           `json:"viewed_at"`
+	Messages      []TranscriptMessage `json:"messages"`
+}
+
+// TranscriptMessage 閲覧専用のチャットのメッセージ（削除済みのものは削除日時を付ける）
+type TranscriptMessage struct {
+	ID            uint       `json:"id"`
+	SenderUserID  uint       `json:"sender_user_id"`
+	SenderRole    string     `json:"sender_role"`
+	Body          string     `json:"body"`
+	AttachmentURL *string    `json:"attachment_url,omitempty"`
+	SentAt        time.Time  `json:"sent_at"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+	Watermark     string     `json:"watermark"`
+}
+
+func NewComplaintService(complaintRepo repositories.ComplaintRepository, appointmentRepo repositories.AppointmentRepository, messageRepo repositories.MessageRepository, videoSessionRepo repositories.VideoSessionRepository, auditRepo repositories.AuditRepository, userRepo repositories.UserRepository, notificationService *NotificationService, auditService *AuditService) *ComplaintService {
+	return &ComplaintService{
+		complaintRepo:       complaintRepo,
+		appointmentRepo:     appointmentRepo,
+		messageRepo:         messageRepo,
+		videoSessionRepo:    videoSessionRepo,
+		auditRepo:           auditRepo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		auditService:        auditService,
+	}
+}
+
+// FileComplaint 苦情の申し立て（患者用）
+func (s *ComplaintService) FileComplaint(userID uint, req FileComplaintRequest) (*models.Complaint, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user == nil || user.Role != "patient" {
+		return nil, errors.New("only patients can file complaints")
+	}
+
+	if req.VideoSessionID != nil && req.AppointmentID == nil {
+		return nil, errors.New("appointment is required when specifying a video session")
+	}
+
+	complaint := &models.Complaint{
+		ComplainantID:  userID,
+		AppointmentID:  req.AppointmentID,
+		VideoSessionID: req.VideoSessionID,
+		Category:       req.Category,
+		Subject:        req.Subject,
+		Description:    req.Description,
+		Status:         "open",
+	}
+
+	var evidence []models.ComplaintEvidence
+	if req.AppointmentID != nil {
+		appointment, err := s.appointmentRepo.FindByID(*req.AppointmentID)
+		if err != nil || appointment == nil {
+			return nil, errors.New("appointment not found")
+		}
+		if appointment.PatientID != userID {
+			return nil, errors.New("unauthorized to file a complaint for this appointment")
+		}
+
+		evidence, err = s.collectEvidence(appointment.ID, req.VideoSessionID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.complaintRepo.Create(complaint, evidence); err != nil {
+		return nil, err
+	}
+
+	// 管理者へ通知（デモアカウントからの申し立ては通知しない）
+	var admins []models.User
+	if !user.IsDemo {
+		admins, err = s.userRepo.FindByRole("admin")
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to find admins for complaint %d: %v", complaint.ID, err)
+	}
+	adminIDs := make([]uint, 0, len(admins))
+	for _, admin := range admins {
+		adminIDs = append(adminIDs, admin.ID)
+	}
+	s.notificationService.NotifyMany(adminIDs, NotificationMessage{
+		Type:  "complaint_filed",
+		Title: "新しい苦情が申し立てられました",
+		Body:  complaint.Subject,
+		Data:  map[string]interface{}{"complaint_id": complaint.ID},
+	})
+
+	s.auditService.LogUserAction(userID, "complaint_filed", "complaint", fmt.Sprintf("%d", complaint.ID), map[string]interface{}{
+		"appointment_id": complaint.AppointmentID,
+		"category":       complaint.Category,
+		"evidence_count": len(evidence),
+	})
+
+	return complaint, nil
+}
+
+// collectEvidence 予約に関連する監査ログとセッション記録を証跡として収集する
+func (s *ComplaintService) collectEvidence(appointmentID uint, videoSessionID *uint) ([]models.ComplaintEvidence, error) {
+	sessions, err := s.videoSessionRepo.FindByAppointmentID(appointmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if videoSessionID != nil {
+		found := false
+		for _, session := range sessions {
+			if session.ID == *videoSessionID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.New("video session not found")
+		}
+	}
+
+	evidence := make([]models.ComplaintEvidence, 0, len(sessions))
+	sessionIDs := make([]uint, 0, len(sessions))
+	for _, session := range sessions {
+		sessionIDs = append(sessionIDs, session.ID)
+		evidence = append(evidence, models.ComplaintEvidence{
+			Source:   "video_session",
+			SourceID: session.ID,
+			Action:   "session_record",
+			At:       session.CreatedAt,
+			DetailJSON: marshalEvidenceDetail(map[string]interface{}{
+				"room_id":    session.RoomID,
+				"started_at": session.StartedAt,
+				"ended_at":   session.EndedAt,
+			}),
+		})
+	}
+
+	auditLogs, err := s.auditRepo.FindByAppointment(appointmentID, sessionIDs, complaintEvidenceLimit)
+	if err != nil {
+		return nil, err
+	}
+	for _, auditLog := range auditLogs {
+		evidence = append(evidence, models.ComplaintEvidence{
+			Source:   "audit_log",
+			SourceID: auditLog.ID,
+			Action:   auditLog.Action,
+			At:       auditLog.At,
+			DetailJSON: marshalEvidenceDetail(map[string]interface{}{
+				"user_id":   auditLog.UserID,
+				"entity":    auditLog.Entity,
+				"entity_id": auditLog.EntityID,
+				"meta_json": auditLog.MetaJSON,
+			}),
+		})
+	}
+
+	return evidence, nil
+}
+
+func marshalEvidenceDetail(detail map[string]interface{}) string {
+	detailBytes, err := json.Marshal(detail)
+	if err != nil {
+		return ""
+	}
+	return string(detailBytes)
+}
+
+// GetMyComplaints 自分の苦情一覧の取得
+func (s *ComplaintService) GetMyComplaints(userID uint, limit, offset int) ([]models.Complaint, error) {
+	return s.complaintRepo.FindByComplainantID(userID, limit, offset)
+}
+
+// GetComplaint 苦情詳細の取得（申立者または管理者）
+func (s *ComplaintService) GetComplaint(complaintID, userID uint) (*models.Complaint, error) {
+	complaint, err := s.complaintRepo.FindByID(complaintID)
+	if err != nil || complaint == nil {
+		return nil, errors.New("complaint not found")
+	}
+
+	if s.isAdmin(userID) {
+		return complaint, nil
+	}
+
+	if complaint.ComplainantID != userID {
+		return nil, errors.New("unauthorized to view this complaint")
+	}
+
+	// 証跡には他者の操作記録が含まれるため、申立者には開示しない
+	complaint.Evidence = nil
+	return complaint, nil
+}
+
+// GetComplaints 苦情一覧の取得（管理者用）
+func (s *ComplaintService) GetComplaints(status string, assignedAdminID *uint, limit, offset int, userID uint) ([]models.Complaint, error) {
+	if !s.isAdmin(userID) {
+		return nil, errors.New("insufficient permissions")
+	}
+	return s.complaintRepo.FindAll(status, assignedAdminID, limit, offset)
+}
+
+// AssignComplaint 担当管理者の割り当て（管理者用）
+func (s *ComplaintService) AssignComplaint(complaintID uint, req AssignComplaintRequest, userID uint) (*models.Complaint, error) {
+	if !s.isAdmin(userID) {
+		return nil, errors.New("insufficient permissions")
+	}
+
+	if !s.isAdmin(req.AdminID) {
+		return nil, errors.New("assignee must be an admin")
+	}
+
+	complaint, err := s.complaintRepo.FindByID(complaintID)
+	if err != nil || complaint == nil {
+		return nil, errors.New("complaint not found")
+	}
+
+	if complaint.Status == "resolved" {
+		return nil, errors.New("complaint is already resolved")
+	}
+
+	complaint.AssignedAdminID = &req.AdminID
+	complaint.AssignedAdmin = nil
+	if err := s.complaintRepo.Update(complaint); err != nil {
+		return nil, err
+	}
+
+	if req.AdminID != userID {
+		if _, err := s.notificationService.Notify(req.AdminID, NotificationMessage{
+			Type:  "complaint_assigned",
+			Title: "苦情の担当に割り当てられました",
+			Body:  complaint.Subject,
+			Data:  map[string]interface{}{"complaint_id": complaint.ID},
+		}); err != nil {
+			log.Printf("Warning: Failed to notify admin %d of complaint assignment: %v", req.AdminID, err)
+		}
+	}
+
+	s.auditService.LogUserAction(userID, "complaint_assigned", "complaint", fmt.Sprintf("%d", complaint.ID), map[string]interface{}{
+		"assigned_admin_id": req.AdminID,
+	})
+
+	return complaint, nil
+}
+
+// UpdateComplaintStatus 苦情ステータスの更新（管理者用）
+func (s *ComplaintService) UpdateComplaintStatus(complaintID uint, req UpdateComplaintStatusRequest, userID uint) (*models.Complaint, error) {
+	if !s.isAdmin(userID) {
+		return nil, errors.New("insufficient permissions")
+	}
+
+	complaint, err := s.complaintRepo.FindByID(complaintID)
+	if err != nil || complaint == nil {
+		return nil, errors.New("complaint not found")
+	}
+
+	if complaintTransitions[complaint.Status] != req.Status {
+		return nil, fmt.Errorf("cannot change status from %s to %s", complaint.Status, req.Status)
+	}
+
+	previousStatus := complaint.Status
+	complaint.Status = req.Status
+
+	switch req.Status {
+	case "investigating":
+		// 未割り当ての場合は調査を開始した管理者を担当とする
+		if complaint.AssignedAdminID == nil {
+			complaint.AssignedAdminID = &userID
+			complaint.AssignedAdmin = nil
+		}
+	case "resolved":
+		if req.Resolution == "" {
+			return nil, errors.New("resolution is required to resolve a complaint")
+		}
+		now := time.Now()
+		complaint.Resolution = req.Resolution
+		complaint.ResolvedAt = &now
+	}
+
+	if err := s.complaintRepo.Update(complaint); err != nil {
+		return nil, err
+	}
+
+	// 申立者へ通知
+	if _, err := s.notificationService.Notify(complaint.ComplainantID, NotificationMessage{
+		Type:  "complaint_status_changed",
+		Title: "苦情の対応状況が更新されました",
+		Body:  complaint.Subject,
+		Data: map[string]interface{}{
+			"complaint_id": complaint.ID,
+			"status":       complaint.Status,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify complainant %d: %v", complaint.ComplainantID, err)
+	}
+
+	s.auditService.LogUserAction(userID, "complaint_status_changed", "complaint", fmt.Sprintf("%d", complaint.ID), map[string]interface{}{
+		"from": previousStatus,
+		"to":   complaint.Status,
+	})
+
+	return complaint, nil
+}
+
+// ViewChatTranscript 苦情の対象の予約の診療チャットを閲覧専用で取得する（管理者用、審査中の苦情のみ）
+// 担当管理者がいる場合は担当者のみ。閲覧ごとに透かしの識別子を発行して重要度highの監査ログに記録し、
+// 記録できない場合は閲覧させない
+func (s *ComplaintService) ViewChatTranscript(complaintID, userID uint, req ViewChatTranscriptRequest) (*DisputeTranscript, error) {
+	if !s.isAdmin(userID) {
+		return nil, errors.New("insufficient permissions")
+	}
+
+	complaint, err := s.complaintRepo.FindByID(complaintID)
+	if err != nil || complaint == nil {
+		return nil, errors.New("complaint not found")
+	}
+	if complaint.AppointmentID == nil {
+		return nil, errors.New("complaint does not reference an appointment")
+	}
+	if complaint.Status == "resolved" {
+		return nil, errors.New("complaint is already resolved")
+	}
+	if complaint.AssignedAdminID != nil && *complaint.AssignedAdminID != userID {
+		return nil, errors.New("complaint is assigned to another admin")
+	}
+
+	justification := strings.TrimSpace(req.Justification)
+	if len([]rune(justification)) < 20 {
+		return nil, errors.New("justification must be at least 20 characters")
+	}
+
+	appointment, err := s.appointmentRepo.FindByID(*complaint.AppointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	messages, err := s.messageRepo.FindTranscript(appointment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	watermarkID, err := generateWatermarkID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	watermark := fmt.Sprintf("閲覧専用 苦情#%d の審査のため管理者#%d が %s に閲覧（%s）複製・転送禁止",
+		complaint.ID, userID, now.Format(time.RFC3339), watermarkID)
+
+	if err := s.auditService.LogBreakGlass(userID, appointment.PatientID, "view", "chat_transcript", fmt.Sprintf("%d", appointment.ID), map[string]interface{}{
+		"complaint_id":  complaint.ID,
+		"justification": justification,
+		"watermark_id":  watermarkID,
+		"messages":      len(messages),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to record transcript access: %v", err)
+	}
+
+	transcript := &DisputeTranscript{
+		ComplaintID:   complaint.ID,
+		AppointmentID: appointment.ID,
+		ReadOnly:      true,
+		WatermarkID:   watermarkID,
+		Watermark:     watermark,
+		ViewedAt:      now,
+		Messages:      make([]TranscriptMessage, 0, len(messages)),
+	}
+	for _, message := range messages {
+		entry := TranscriptMessage{
+			ID:            message.ID,
+			SenderUserID:  message.SenderUserID,
+			SenderRole:    message.Sender.Role,
+			Body:          message.Body,
+			AttachmentURL: message.AttachmentURL,
+			SentAt:        message.CreatedAt,
+			Watermark:     watermarkID,
+		}
+		if message.DeletedAt.Valid {
+			deletedAt := message.DeletedAt.Time
+			entry.DeletedAt = &deletedAt
+		}
+		transcript.Messages = append(transcript.Messages, entry)
+	}
+	return transcript, nil
+}
+
+// generateWatermarkID 閲覧ごとの透かしの識別子
+func generateWatermarkID() (string, error) {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+func (s *ComplaintService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
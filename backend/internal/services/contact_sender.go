@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"online_medical_consultation_app/backend/internal/mail"
+)
+
+// ContactSender 宛先を直接指定するメール・SMSの送信（確認コード等、通知設定に依存しない連絡用）
+type ContactSender interface {
+	SendEmail(to, subject, body string) error
+	SendEmailWithAttachments(to, subject, body string, attachments []mail.Attachment) error
+	SendSMS(to, body string) error
+}
+
+// LogContactSender 送信内容をログに出力するだけの開発用実装
+type LogContactSender struct{}
+
+func NewLogContactSender() *LogContactSender {
+	return &LogContactSender{}
+}
+
+// SendEmail メール送信（ログ出力のみ）
+func (s *LogContactSender) SendEmail(to, subject, body string) error {
+	log.Printf("[email] to=%s subject=%q body=%q", to, subject, body)
+	return nil
+}
+
+// SendEmailWithAttachments 添付ファイル付きのメール送信（ログ出力のみ、添付ファイルは名前とサイズのみ出力する）
+func (s *LogContactSender) SendEmailWithAttachments(to, subject, body string, attachments []mail.Attachment) error {
+	files := make([]string, len(attachments))
+	for i, attachment := range attachments {
+		files[i] = fmt.Sprintf("%s (%d bytes)", attachment.Filename, len(attachment.Data))
+	}
+	log.Printf("[email] to=%s subject=%q body=%q attachments=%q", to, subject, body, files)
+	return nil
+}
+
+// SendSMS SMS送信（ログ出力のみ）
+func (s *LogContactSender) SendSMS(to, body string) error {
+	log.Printf("[sms] to=%s body=%q", to, body)
+	return nil
+}
+
+// MailContactSender メールを送信事業者（SMTP・SendGrid）から送信する（SMSは別の実装に任せる）
+type MailContactSender struct {
+	provider mail.Provider
+	sms      ContactSender
+}
+
+func NewMailContactSender(provider mail.Provider, sms ContactSender) *MailContactSender {
+	return &MailContactSender{
+		provider: provider,
+		sms:      sms,
+	}
+}
+
+// SendEmail メール送信
+func (s *MailContactSender) SendEmail(to, subject, body string) error {
+	return s.provider.Send(context.Background(), mail.Message{To: to, Subject: subject, Body: body})
+}
+
+// SendEmailWithAttachments 添付ファイル付きのメール送信
+func (s *MailContactSender) SendEmailWithAttachments(to, subject, body string, attachments []mail.Attachment) error {
+	return s.provider.Send(context.Background(), mail.Message{To: to, Subject: subject, Body: body, Attachments: attachments})
+}
+
+// SendSMS SMS送信
+func (s *MailContactSender) SendSMS(to, body string) error {
+	return s.sms.SendSMS(to, body)
+}
+
+// BrandedContactSender メールの末尾にクリニックのフッターを付けて送信する（SMSはそのまま送信）
+type BrandedContactSender struct {
+	sender          ContactSender
+	brandingService *BrandingService
+}
+
+func NewBrandedContactSender(sender ContactSender, brandingService *BrandingService) *BrandedContactSender {
+	return &BrandedContactSender{
+		sender:          sender,
+		brandingService: brandingService,
+	}
+}
+
+// SendEmail フッターを付けたメール送信
+func (s *BrandedContactSender) SendEmail(to, subject, body string) error {
+	return s.sender.SendEmail(to, subject, s.withFooter(body))
+}
+
+// SendEmailWithAttachments フッターを付けた添付ファイル付きのメール送信
+func (s *BrandedContactSender) SendEmailWithAttachments(to, subject, body string, attachments []mail.Attachment) error {
+	return s.sender.SendEmailWithAttachments(to, subject, s.withFooter(body), attachments)
+}
+
+func (s *BrandedContactSender) withFooter(body string) string {
+	if footer := s.brandingService.EmailFooter(); footer != "" {
+		body = strings.TrimRight(body, "\n") + "\n\n" + footer + "\n"
+	}
+	return body
+}
+
+// SendSMS SMS送信
+func (s *BrandedContactSender) SendSMS(to, body string) error {
+	return s.sender.SendSMS(to, body)
+}
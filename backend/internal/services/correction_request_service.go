@@ -0,0 +1,343 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 訂正の依頼の対象の項目・回答の表示名（通知に使用、通知には診療録の記載・依頼の内容を含めない）
+var correctionFieldLabels = map[string]string{
+	"diagnosis":     "診断",
+	"symptoms":      "症状",
+	"vitals":        "バイタル",
+	"allergies":     "アレルギー",
+	"visit_summary": "診療の要約",
+	"other":         "その他",
+}
+
+var correctionStatusLabels = map[string]string{
+	"accepted": "承認",
+	"rejected": "却下",
+}
+
+// CorrectionRequestService 患者による診療録の訂正の依頼
+// 診療録を記載した医師へ依頼し、医師が承認・却下する。承認した場合は訂正前の記載を依頼に残し、
+// 監査ログは correction_request と medical_record の両方から辿れるように依頼のIDを記録する
+type CorrectionRequestService struct {
+	requestRepo         repositories.CorrectionRequestRepository
+	recordRepo          repositories.MedicalRecordRepository
+	userRepo            repositories.UserRepository
+	notificationService *NotificationService
+	auditService        *AuditService
+}
+
+type CreateCorrectionRequestRequest struct {
+	AppointmentID uint   `json:"appointment_id" binding:"required"`
+	Field         string `json:"field" binding:"required,oneof=diagnosis symptoms vitals allergies visit_summary other"`
+	Description   string `json:"description" binding:"required,max=2000"`
+	ProposedValue string `json:"proposed_value" binding:"max=5000"`
+}
+
+// ResolveCorrectionRequestRequest 医師による訂正の依頼への回答
+// corrected_value は承認した場合に診療録へ反映する記載（diagnosis・symptoms・visit_summary のみ、
+// その他の項目は診療録の記載を更新してから承認する）
+type ResolveCorrectionRequestRequest struct {
+	Status         string  `json:"status" binding:"required,oneof=accepted rejected"`
+	Resolution     string  `json:"resolution" binding:"required,max=2000"`
+	CorrectedValue *string `json:"corrected_value" binding:"omitempty,max=10000"`
+}
+
+// AssignCorrectionRequestRequest 対応する医師の変更（記載した医師が対応できない場合）
+type AssignCorrectionRequestRequest struct {
+	DoctorID uint `json:"doctor_id" binding:"required"`
+}
+
+func NewCorrectionRequestService(requestRepo repositories.CorrectionRequestRepository, recordRepo repositories.MedicalRecordRepository, userRepo repositories.UserRepository, notificationService *NotificationService, auditService *AuditService) *CorrectionRequestService {
+	return &CorrectionRequestService{
+		requestRepo:         requestRepo,
+		recordRepo:          recordRepo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		auditService:        auditService,
+	}
+}
+
+// CreateRequest 診療録の訂正の依頼（患者用、自身・家族の診療録のみ、同じ項目への対応中の依頼は1件まで）
+func (s *CorrectionRequestService) CreateRequest(patientID uint, req CreateCorrectionRequestRequest) (*models.CorrectionRequest, error) {
+	user, err := s.userRepo.FindByID(patientID)
+	if err != nil || user == nil || user.Role != "patient" {
+		return nil, errors.New("only patients can request corrections")
+	}
+
+	record, err := s.recordRepo.FindByAppointmentID(req.AppointmentID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil || record.PatientID != patientID {
+		return nil, errors.New("medical record not found")
+	}
+
+	existing, err := s.requestRepo.FindOpen(record.ID, req.Field)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("a correction request for this field is already open")
+	}
+
+	request := &models.CorrectionRequest{
+		PatientID:       patientID,
+		DependentID:     record.DependentID,
+		DoctorID:        record.DoctorID,
+		AppointmentID:   record.AppointmentID,
+		MedicalRecordID: record.ID,
+		Field:           req.Field,
+		CurrentValue:    correctionFieldValue(record, req.Field),
+		Description:     strings.TrimSpace(req.Description),
+		ProposedValue:   strings.TrimSpace(req.ProposedValue),
+		Status:          "open",
+	}
+	if request.Description == "" {
+		return nil, errors.New("invalid description: must not be empty")
+	}
+	if err := s.requestRepo.Create(request); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(patientID, "correction_requested", "correction_request", fmt.Sprintf("%d", request.ID), map[string]interface{}{
+		"appointment_id":    request.AppointmentID,
+		"medical_record_id": request.MedicalRecordID,
+		"field":             request.Field,
+		"doctor_id":         request.DoctorID,
+	})
+
+	if !user.IsDemo {
+		s.notifyDoctor(request)
+	}
+
+	return s.requestRepo.FindByID(request.ID)
+}
+
+// GetRequests 訂正の依頼の一覧（患者は自身の依頼、医師は対応する依頼、管理者はすべて）
+func (s *CorrectionRequestService) GetRequests(userID uint, status string, limit, offset int) ([]models.CorrectionRequest, int64, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, 0, errors.New("user not found")
+	}
+
+	filter := repositories.CorrectionRequestFilter{Status: status}
+	switch {
+	case policy.IsAdmin(user):
+	case user.Role == "doctor":
+		filter.DoctorID = &userID
+	case user.Role == "patient":
+		filter.PatientID = &userID
+	default:
+		return nil, 0, errors.New("unauthorized to view correction requests")
+	}
+	return s.requestRepo.FindPage(filter, limit, offset)
+}
+
+// GetRequest 訂正の依頼の取得（依頼した患者・対応する医師・管理者のみ）
+func (s *CorrectionRequestService) GetRequest(userID, requestID uint) (*models.CorrectionRequest, error) {
+	request, err := s.requestRepo.FindByID(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, errors.New("correction request not found")
+	}
+	if request.PatientID != userID && request.DoctorID != userID && !s.isAdmin(userID) {
+		return nil, errors.New("unauthorized to view this correction request")
+	}
+
+	// 依頼には診療録の記載を含むため、PHI閲覧ログを記録する
+	s.auditService.LogPHIAccess(userID, request.PatientID, "correction_request", fmt.Sprintf("%d", request.ID), map[string]interface{}{
+		"medical_record_id": request.MedicalRecordID,
+	})
+	return request, nil
+}
+
+// ResolveRequest 訂正の依頼の承認・却下（対応する医師のみ、承認時は corrected_value を診療録に反映する）
+func (s *CorrectionRequestService) ResolveRequest(doctorID, requestID uint, req ResolveCorrectionRequestRequest) (*models.CorrectionRequest, error) {
+	request, err := s.requestRepo.FindByID(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, errors.New("correction request not found")
+	}
+	if request.DoctorID != doctorID {
+		return nil, errors.New("unauthorized to resolve this correction request")
+	}
+	if request.Status != "open" {
+		return nil, errors.New("correction request is not open")
+	}
+
+	resolution := strings.TrimSpace(req.Resolution)
+	if resolution == "" {
+		return nil, errors.New("invalid resolution: must not be empty")
+	}
+
+	if req.CorrectedValue != nil {
+		if req.Status != "accepted" {
+			return nil, errors.New("invalid corrected_value: only accepted requests can amend the medical record")
+		}
+		switch request.Field {
+		case "diagnosis", "symptoms", "visit_summary":
+		default:
+			return nil, errors.New("invalid corrected_value: only diagnosis, symptoms and visit_summary can be amended directly")
+		}
+
+		corrected := strings.TrimSpace(*req.CorrectedValue)
+		if err := s.recordRepo.AmendField(request.MedicalRecordID, request.Field, corrected); err != nil {
+			return nil, err
+		}
+		request.CorrectedValue = &corrected
+
+		s.auditService.LogUserAction(doctorID, "medical_record_amended", "medical_record", fmt.Sprintf("%d", request.MedicalRecordID), map[string]interface{}{
+			"appointment_id":        request.AppointmentID,
+			"patient_id":            request.PatientID,
+			"field":                 request.Field,
+			"correction_request_id": request.ID,
+		})
+	}
+
+	now := time.Now()
+	request.Status = req.Status
+	request.Resolution = resolution
+	request.ResolvedByID = &doctorID
+	request.ResolvedAt = &now
+	if err := s.requestRepo.Update(request); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(doctorID, "correction_"+req.Status, "correction_request", fmt.Sprintf("%d", request.ID), map[string]interface{}{
+		"medical_record_id": request.MedicalRecordID,
+		"field":             request.Field,
+		"amended":           request.CorrectedValue != nil,
+	})
+
+	if _, err := s.notificationService.Notify(request.PatientID, NotificationMessage{
+		Type:  "correction_resolved",
+		Title: "診療録の訂正の依頼に回答がありました",
+		Body:  fmt.Sprintf("%sの訂正の依頼が%sされました", correctionFieldLabels[request.Field], correctionStatusLabels[request.Status]),
+		Data: map[string]interface{}{
+			"correction_request_id": request.ID,
+			"appointment_id":        request.AppointmentID,
+			"status":                request.Status,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify patient %d of correction request %d: %v", request.PatientID, request.ID, err)
+	}
+
+	return request, nil
+}
+
+// WithdrawRequest 訂正の依頼の取り下げ（依頼した患者のみ、対応中の依頼）
+func (s *CorrectionRequestService) WithdrawRequest(patientID, requestID uint) (*models.CorrectionRequest, error) {
+	request, err := s.requestRepo.FindByID(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil || request.PatientID != patientID {
+		return nil, errors.New("correction request not found")
+	}
+	if request.Status != "open" {
+		return nil, errors.New("correction request is not open")
+	}
+
+	now := time.Now()
+	request.Status = "withdrawn"
+	request.ResolvedAt = &now
+	if err := s.requestRepo.Update(request); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(patientID, "correction_withdrawn", "correction_request", fmt.Sprintf("%d", request.ID), map[string]interface{}{
+		"medical_record_id": request.MedicalRecordID,
+	})
+	return request, nil
+}
+
+// AssignRequest 対応する医師の変更（管理者用、記載した医師が退職・休職した場合など）
+func (s *CorrectionRequestService) AssignRequest(adminID, requestID uint, req AssignCorrectionRequestRequest) (*models.CorrectionRequest, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	request, err := s.requestRepo.FindByID(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, errors.New("correction request not found")
+	}
+	if request.Status != "open" {
+		return nil, errors.New("correction request is not open")
+	}
+
+	doctor, err := s.userRepo.FindByID(req.DoctorID)
+	if err != nil || doctor == nil || doctor.Role != "doctor" || doctor.DeactivatedAt != nil {
+		return nil, errors.New("doctor not found")
+	}
+
+	previousDoctorID := request.DoctorID
+	request.DoctorID = doctor.ID
+	request.Doctor = *doctor
+	if err := s.requestRepo.Update(request); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(adminID, "correction_reassigned", "correction_request", fmt.Sprintf("%d", request.ID), map[string]interface{}{
+		"from_doctor_id": previousDoctorID,
+		"to_doctor_id":   doctor.ID,
+	})
+	s.notifyDoctor(request)
+
+	return request, nil
+}
+
+// notifyDoctor 対応する医師への訂正の依頼の通知
+func (s *CorrectionRequestService) notifyDoctor(request *models.CorrectionRequest) {
+	if _, err := s.notificationService.Notify(request.DoctorID, NotificationMessage{
+		Type:  "correction_requested",
+		Title: "診療録の訂正の依頼が届きました",
+		Body:  fmt.Sprintf("%sの訂正の依頼", correctionFieldLabels[request.Field]),
+		Data: map[string]interface{}{
+			"correction_request_id": request.ID,
+			"appointment_id":        request.AppointmentID,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify doctor %d of correction request %d: %v", request.DoctorID, request.ID, err)
+	}
+}
+
+func (s *CorrectionRequestService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
+
+// correctionFieldValue 依頼した時点の診療録の記載
+func correctionFieldValue(record *models.MedicalRecord, field string) string {
+	switch field {
+	case "diagnosis":
+		return record.Diagnosis
+	case "symptoms":
+		return record.Symptoms
+	case "vitals":
+		return record.VitalsJSON
+	case "allergies":
+		return record.AllergiesJSON
+	case "visit_summary":
+		return record.VisitSummary
+	}
+	return ""
+}
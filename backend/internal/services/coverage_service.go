@@ -0,0 +1,343 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/realtime"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// CoverageService 休診中の医師の代診
+// 代診期間中は代診医が休診する医師の担当患者のチャットに参加でき、
+// 期間内の確定済みの予約は患者の同意を得たうえで代診医へ引き継ぐ
+type CoverageService struct {
+	coverageRepo        repositories.CoverageRepository
+	appointmentRepo     repositories.AppointmentRepository
+	slotRepo            repositories.SlotRepository
+	userRepo            repositories.UserRepository
+	locationService     *LocationService
+	notificationService *NotificationService
+	auditService        *AuditService
+	hub                 *realtime.Hub
+}
+
+type CreateCoverageRequest struct {
+	CoveringDoctorID     uint      `json:"covering_doctor_id" binding:"required"`
+	StartTime            time.Time `json:"start_time" binding:"required"`
+	EndTime              time.Time `json:"end_time" binding:"required"`
+	Note                 string    `json:"note" binding:"max=1000"`
+	TransferAppointments bool      `json:"transfer_appointments"` // 期間内の確定済みの予約の引き継ぎを患者へ依頼する
+}
+
+type TransferConsentRequest struct {
+	Approve *bool `json:"approve" binding:"required"`
+}
+
+func NewCoverageService(coverageRepo repositories.CoverageRepository, appointmentRepo repositories.AppointmentRepository, slotRepo repositories.SlotRepository, userRepo repositories.UserRepository, locationService *LocationService, notificationService *NotificationService, auditService *AuditService, hub *realtime.Hub) *CoverageService {
+	return &CoverageService{
+		coverageRepo:        coverageRepo,
+		appointmentRepo:     appointmentRepo,
+		slotRepo:            slotRepo,
+		userRepo:            userRepo,
+		locationService:     locationService,
+		notificationService: notificationService,
+		auditService:        auditService,
+		hub:                 hub,
+	}
+}
+
+// CreateCoverage 代診医の指定（休診する医師用、指定があれば期間内の確定済みの予約の引き継ぎを患者へ依頼する）
+func (s *CoverageService) CreateCoverage(doctorID uint, req CreateCoverageRequest) (*models.DoctorCoverage, error) {
+	if !req.EndTime.After(req.StartTime) {
+		return nil, errors.New("end time must be after start time")
+	}
+	if req.EndTime.Before(time.Now()) {
+		return nil, errors.New("coverage must end in the future")
+	}
+	if req.CoveringDoctorID == doctorID {
+		return nil, errors.New("cannot assign yourself as the covering doctor")
+	}
+
+	doctor, err := s.userRepo.FindByID(doctorID)
+	if err != nil || doctor == nil || doctor.Role != "doctor" {
+		return nil, errors.New("only doctors can assign a covering doctor")
+	}
+	covering, err := s.userRepo.FindByID(req.CoveringDoctorID)
+	if err != nil || covering == nil || covering.Role != "doctor" || covering.DeactivatedAt != nil || covering.IsDemo != doctor.IsDemo {
+		return nil, errors.New("covering doctor not found")
+	}
+
+	overlapping, err := s.coverageRepo.FindOverlapping(doctorID, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	if len(overlapping) > 0 {
+		return nil, errors.New("a covering doctor is already assigned for this period")
+	}
+
+	coverage := &models.DoctorCoverage{
+		DoctorID:         doctorID,
+		CoveringDoctorID: req.CoveringDoctorID,
+		StartTime:        req.StartTime,
+		EndTime:          req.EndTime,
+		Status:           "active",
+		Note:             req.Note,
+	}
+	if err := s.coverageRepo.Create(coverage); err != nil {
+		return nil, err
+	}
+
+	requested := 0
+	if req.TransferAppointments {
+		confirmed, err := s.appointmentRepo.FindConfirmedByDoctorInRange(doctorID, req.StartTime, req.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		for i := range confirmed {
+			// 開始済みの予約は休診する医師がそのまま担当する
+			if confirmed[i].Slot == nil || !confirmed[i].Slot.StartTime.After(now) {
+				continue
+			}
+			// 代診医が対面診療を行わない所在地の予約は引き継がない
+			if !s.canSeeAt(req.CoveringDoctorID, &confirmed[i]) {
+				continue
+			}
+			if s.requestTransfer(coverage, &confirmed[i]) {
+				requested++
+			}
+		}
+	}
+
+	if _, err := s.notificationService.Notify(req.CoveringDoctorID, NotificationMessage{
+		Type:     "coverage_assigned",
+		Title:    "代診を依頼されました",
+		Body:     fmt.Sprintf("%s〜%s", req.StartTime.Format("2006/01/02 15:04"), req.EndTime.Format("2006/01/02 15:04")),
+		Priority: "high",
+		Data: map[string]interface{}{
+			"coverage_id":         coverage.ID,
+			"doctor_id":           doctorID,
+			"start_time":          req.StartTime,
+			"end_time":            req.EndTime,
+			"requested_transfers": requested,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify covering doctor %d of coverage %d: %v", req.CoveringDoctorID, coverage.ID, err)
+	}
+
+	s.auditService.LogUserAction(doctorID, "coverage_created", "doctor_coverage", fmt.Sprintf("%d", coverage.ID), map[string]interface{}{
+		"covering_doctor_id":  req.CoveringDoctorID,
+		"requested_transfers": requested,
+	})
+
+	return s.coverageRepo.FindByID(coverage.ID)
+}
+
+// GetCoverages 今後の代診の一覧（休診する・代診医を務める分）
+func (s *CoverageService) GetCoverages(doctorID uint) ([]models.DoctorCoverage, error) {
+	return s.coverageRepo.FindUpcomingByDoctor(doctorID, time.Now())
+}
+
+// CancelCoverage 代診の取り消し（休診する医師のみ、同意待ちの引き継ぎも取り消す。引き継ぎ済みの予約はそのまま）
+func (s *CoverageService) CancelCoverage(doctorID, coverageID uint) error {
+	coverage, err := s.coverageRepo.FindByID(coverageID)
+	if err != nil {
+		return err
+	}
+	if coverage == nil || coverage.DoctorID != doctorID {
+		return errors.New("coverage not found")
+	}
+
+	cancelled, err := s.coverageRepo.Cancel(coverage.ID)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return errors.New("coverage is already cancelled")
+	}
+
+	transfers, err := s.coverageRepo.CancelPendingTransfers(coverage.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to cancel pending transfers of coverage %d: %v", coverage.ID, err)
+	}
+
+	if _, err := s.notificationService.Notify(coverage.CoveringDoctorID, NotificationMessage{
+		Type:  "coverage_cancelled",
+		Title: "代診の依頼が取り消されました",
+		Data: map[string]interface{}{
+			"coverage_id": coverage.ID,
+			"doctor_id":   doctorID,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify covering doctor %d of cancelled coverage %d: %v", coverage.CoveringDoctorID, coverage.ID, err)
+	}
+
+	s.auditService.LogUserAction(doctorID, "coverage_cancelled", "doctor_coverage", fmt.Sprintf("%d", coverage.ID), map[string]interface{}{
+		"cancelled_transfers": transfers,
+	})
+	return nil
+}
+
+// GetMyTransfers 同意待ちの予約の引き継ぎの一覧（患者用）
+func (s *CoverageService) GetMyTransfers(patientID uint) ([]models.AppointmentTransfer, error) {
+	return s.coverageRepo.FindPendingTransfersByPatient(patientID)
+}
+
+// RespondTransfer 予約の引き継ぎへの同意・拒否（予約した患者のみ）
+// 同意した場合は代診医の予定を確認したうえで予約の担当医師を代診医に変更する
+func (s *CoverageService) RespondTransfer(transferID, patientID uint, approve bool) (*models.AppointmentTransfer, error) {
+	transfer, err := s.coverageRepo.FindTransferByID(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer == nil || transfer.PatientID != patientID {
+		return nil, errors.New("appointment transfer not found")
+	}
+	if transfer.Status != "pending" {
+		return nil, errors.New("appointment transfer has already been answered")
+	}
+
+	appointment, err := s.appointmentRepo.FindByID(transfer.AppointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+
+	now := time.Now()
+	status := "declined"
+	if approve {
+		if err := s.checkTransferable(transfer, appointment); err != nil {
+			return nil, err
+		}
+
+		// 担当医師の変更は予約が元の医師の確定済みのままの場合のみ行う
+		transferred, err := s.appointmentRepo.TransferDoctor(appointment.ID, transfer.FromDoctorID, transfer.ToDoctorID)
+		if err != nil {
+			return nil, err
+		}
+		if !transferred {
+			if _, err := s.coverageRepo.RespondTransfer(transfer.ID, "cancelled", now); err != nil {
+				log.Printf("Warning: Failed to cancel appointment transfer %d: %v", transfer.ID, err)
+			}
+			return nil, errors.New("appointment can no longer be transferred")
+		}
+		appointment.DoctorID = transfer.ToDoctorID
+		publishAppointmentStatus(s.hub, appointment)
+		status = "accepted"
+	}
+
+	responded, err := s.coverageRepo.RespondTransfer(transfer.ID, status, now)
+	if err != nil {
+		return nil, err
+	}
+	if !responded && !approve {
+		return nil, errors.New("appointment transfer has already been answered")
+	}
+	transfer.Status = status
+	transfer.RespondedAt = &now
+
+	// 元の医師・代診医へ通知（代診医は同意された場合のみ）
+	action := "appointment_transfer_" + status
+	title := "患者が予約の引き継ぎを断りました"
+	recipients := []uint{transfer.FromDoctorID}
+	if approve {
+		title = "患者が予約の引き継ぎに同意しました"
+		recipients = append(recipients, transfer.ToDoctorID)
+	}
+	s.notificationService.NotifyMany(recipients, NotificationMessage{
+		Type:  action,
+		Title: title,
+		Data:  appointmentTransferNotificationData(transfer),
+	})
+
+	s.auditService.LogUserAction(patientID, action, "appointment_transfer", fmt.Sprintf("%d", transfer.ID), map[string]interface{}{
+		"appointment_id": transfer.AppointmentID,
+		"from_doctor_id": transfer.FromDoctorID,
+		"to_doctor_id":   transfer.ToDoctorID,
+	})
+
+	return transfer, nil
+}
+
+// requestTransfer 予約の引き継ぎを作成し、患者へ同意を依頼する
+func (s *CoverageService) requestTransfer(coverage *models.DoctorCoverage, appointment *models.Appointment) bool {
+	transfer := &models.AppointmentTransfer{
+		CoverageID:    coverage.ID,
+		AppointmentID: appointment.ID,
+		FromDoctorID:  coverage.DoctorID,
+		ToDoctorID:    coverage.CoveringDoctorID,
+		PatientID:     appointment.PatientID,
+		Status:        "pending",
+	}
+	if err := s.coverageRepo.CreateTransfer(transfer); err != nil {
+		log.Printf("Warning: Failed to create transfer for appointment %d: %v", appointment.ID, err)
+		return false
+	}
+
+	if _, err := s.notificationService.Notify(appointment.PatientID, NotificationMessage{
+		Type:     "appointment_transfer_requested",
+		Title:    "担当医師の休診に伴う代診医への引き継ぎについて同意をお願いします",
+		Body:     "同意しない場合は元の医師の予約のままとなります",
+		Priority: "high",
+		Data:     appointmentTransferNotificationData(transfer),
+	}); err != nil {
+		log.Printf("Warning: Failed to notify patient %d of appointment transfer %d: %v", appointment.PatientID, transfer.ID, err)
+	}
+	return true
+}
+
+// checkTransferable 引き継ぎに同意できる状態か（代診が有効、予約が確定済みで未開始、代診医の予定が空いていて対面診療の所在地で診療を行う）
+func (s *CoverageService) checkTransferable(transfer *models.AppointmentTransfer, appointment *models.Appointment) error {
+	coverage, err := s.coverageRepo.FindByID(transfer.CoverageID)
+	if err != nil {
+		return err
+	}
+	if coverage == nil || coverage.Status != "active" {
+		return errors.New("coverage is no longer active")
+	}
+	if appointment.Status != "confirmed" || appointment.DoctorID != transfer.FromDoctorID || appointment.SlotID == nil {
+		return errors.New("appointment can no longer be transferred")
+	}
+
+	slot, err := s.slotRepo.FindByID(*appointment.SlotID)
+	if err != nil || slot == nil {
+		return errors.New("appointment can no longer be transferred")
+	}
+	if !slot.StartTime.After(time.Now()) {
+		return errors.New("appointment has already started")
+	}
+
+	conflicts, err := s.appointmentRepo.FindDoctorOverlapping(transfer.ToDoctorID, slot.StartTime, slot.EndTime)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return errors.New("covering doctor is no longer available at this time")
+	}
+	if !s.canSeeAt(transfer.ToDoctorID, appointment) {
+		return errors.New("covering doctor does not practice at this location")
+	}
+	return nil
+}
+
+// canSeeAt 代診医が予約の所在地で対面診療を行えるか（オンライン診療は常に可）
+func (s *CoverageService) canSeeAt(doctorID uint, appointment *models.Appointment) bool {
+	if appointment.LocationID == nil {
+		return true
+	}
+	_, err := s.locationService.CheckPractice(doctorID, *appointment.LocationID)
+	return err == nil
+}
+
+func appointmentTransferNotificationData(transfer *models.AppointmentTransfer) map[string]interface{} {
+	return map[string]interface{}{
+		"transfer_id":    transfer.ID,
+		"coverage_id":    transfer.CoverageID,
+		"appointment_id": transfer.AppointmentID,
+		"from_doctor_id": transfer.FromDoctorID,
+		"to_doctor_id":   transfer.ToDoctorID,
+	}
+}
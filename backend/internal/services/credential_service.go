@@ -0,0 +1,268 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 資格証明書類のファイル制限
+const credentialMaxFileSize = 10 * 1024 * 1024
+
+var credentialContentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+}
+
+type CredentialService struct {
+	credentialRepo      repositories.CredentialRepository
+	userRepo            repositories.UserRepository
+	notificationService *NotificationService
+	auditService        *AuditService
+	storageDir          string
+}
+
+type UploadCredentialRequest struct {
+	Title    string `form:"title" binding:"required"`
+	DocType  string `form:"doc_type" binding:"required,oneof=diploma certification license other"`
+	IsPublic bool   `form:"is_public"`
+}
+
+type UpdateCredentialVisibilityRequest struct {
+	IsPublic *bool `json:"is_public" binding:"required"`
+}
+
+type ReviewCredentialRequest struct {
+	Status string `json:"status" binding:"required,oneof=approved rejected"`
+	Note   string `json:"note"`
+}
+
+func NewCredentialService(credentialRepo repositories.CredentialRepository, userRepo repositories.UserRepository, notificationService *NotificationService, auditService *AuditService, uploadDir string) *CredentialService {
+	// 資格証明書類は公開アップロードとは別のディレクトリに保存する
+	storageDir := filepath.Join(uploadDir, "credentials")
+	if err := os.MkdirAll(storageDir, 0700); err != nil {
+		log.Printf("Warning: Failed to create credential directory: %v", err)
+	}
+
+	return &CredentialService{
+		credentialRepo:      credentialRepo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		auditService:        auditService,
+		storageDir:          storageDir,
+	}
+}
+
+// UploadCredential 資格証明書類のアップロード（医師のみ、審査待ちで登録）
+func (s *CredentialService) UploadCredential(doctorID uint, req UploadCredentialRequest, file *multipart.FileHeader) (*models.DoctorCredential, error) {
+	user, err := s.userRepo.FindByID(doctorID)
+	if err != nil || user == nil || user.Role != "doctor" {
+		return nil, errors.New("only doctors can upload credentials")
+	}
+
+	if file.Size > credentialMaxFileSize {
+		return nil, errors.New("file size must be less than 10MB")
+	}
+	contentType := file.Header.Get("Content-Type")
+	if !credentialContentTypes[contentType] {
+		return nil, errors.New("only PDF, JPEG and PNG files are allowed")
+	}
+
+	filename := fmt.Sprintf("%d_%d%s", doctorID, time.Now().UnixNano(), filepath.Ext(file.Filename))
+	path := filepath.Join(s.storageDir, filename)
+	if err := saveUploadedFile(file, path); err != nil {
+		return nil, err
+	}
+
+	credential := &models.DoctorCredential{
+		DoctorID:    doctorID,
+		Title:       req.Title,
+		DocType:     req.DocType,
+		FilePath:    path,
+		FileName:    filepath.Base(file.Filename),
+		ContentType: contentType,
+		FileSize:    file.Size,
+		Status:      "pending",
+		IsPublic:    req.IsPublic,
+	}
+	if err := s.credentialRepo.Create(credential); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	// 管理者へ審査を依頼
+	if admins, err := s.userRepo.FindByRole("admin"); err == nil {
+		adminIDs := make([]uint, 0, len(admins))
+		for _, admin := range admins {
+			adminIDs = append(adminIDs, admin.ID)
+		}
+		s.notificationService.NotifyMany(adminIDs, NotificationMessage{
+			Type:  "credential_submitted",
+			Title: "医師の資格証明書類の審査依頼があります",
+			Body:  credential.Title,
+			Data:  map[string]interface{}{"credential_id": credential.ID, "doctor_id": doctorID},
+		})
+	}
+
+	s.auditService.LogUserAction(doctorID, "credential_uploaded", "doctor_credential", fmt.Sprintf("%d", credential.ID), map[string]interface{}{
+		"doc_type": credential.DocType,
+	})
+
+	return credential, nil
+}
+
+// GetMyCredentials 自分の資格証明書類一覧（医師用、審査状況を含む）
+func (s *CredentialService) GetMyCredentials(doctorID uint) ([]models.DoctorCredential, error) {
+	return s.credentialRepo.FindByDoctorID(doctorID)
+}
+
+// GetDoctorCredentials 医師の資格証明書類一覧（管理者は全件、それ以外は承認済みの公開分のみ）
+func (s *CredentialService) GetDoctorCredentials(doctorID, viewerID uint) ([]models.DoctorCredential, error) {
+	if viewerID == doctorID || s.isAdmin(viewerID) {
+		return s.credentialRepo.FindByDoctorID(doctorID)
+	}
+	return s.credentialRepo.FindPublicByDoctorID(doctorID)
+}
+
+// UpdateVisibility 公開設定の変更（医師用）
+func (s *CredentialService) UpdateVisibility(credentialID, doctorID uint, isPublic bool) (*models.DoctorCredential, error) {
+	credential, err := s.credentialRepo.FindByID(credentialID)
+	if err != nil || credential.DoctorID != doctorID {
+		return nil, errors.New("credential not found")
+	}
+
+	credential.IsPublic = isPublic
+	if err := s.credentialRepo.Update(credential); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// DeleteCredential 資格証明書類の削除（医師用）
+func (s *CredentialService) DeleteCredential(credentialID, doctorID uint) error {
+	credential, err := s.credentialRepo.FindByID(credentialID)
+	if err != nil || credential.DoctorID != doctorID {
+		return errors.New("credential not found")
+	}
+
+	if err := s.credentialRepo.Delete(credentialID); err != nil {
+		return err
+	}
+	if err := os.Remove(credential.FilePath); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: Failed to remove credential file %s: %v", credential.FilePath, err)
+	}
+
+	s.auditService.LogUserAction(doctorID, "credential_deleted", "doctor_credential", fmt.Sprintf("%d", credentialID), nil)
+	return nil
+}
+
+// GetCredentialsForReview 審査対象の資格証明書類一覧（管理者のみ）
+func (s *CredentialService) GetCredentialsForReview(adminID uint, status string, limit, offset int) ([]models.DoctorCredential, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	return s.credentialRepo.FindByStatus(status, limit, offset)
+}
+
+// ReviewCredential 資格証明書類の承認・却下（管理者のみ）
+func (s *CredentialService) ReviewCredential(credentialID, adminID uint, req ReviewCredentialRequest) (*models.DoctorCredential, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	credential, err := s.credentialRepo.FindByID(credentialID)
+	if err != nil {
+		return nil, errors.New("credential not found")
+	}
+
+	now := time.Now()
+	credential.Status = req.Status
+	credential.ReviewNote = req.Note
+	credential.ReviewedByID = &adminID
+	credential.ReviewedAt = &now
+	if err := s.credentialRepo.Update(credential); err != nil {
+		return nil, err
+	}
+
+	title := "資格証明書類が承認されました"
+	if req.Status == "rejected" {
+		title = "資格証明書類が却下されました"
+	}
+	if _, err := s.notificationService.Notify(credential.DoctorID, NotificationMessage{
+		Type:  "credential_reviewed",
+		Title: title,
+		Body:  req.Note,
+		Data:  map[string]interface{}{"credential_id": credential.ID, "status": req.Status},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify doctor %d of credential review: %v", credential.DoctorID, err)
+	}
+
+	s.auditService.LogUserAction(adminID, "credential_"+req.Status, "doctor_credential", fmt.Sprintf("%d", credential.ID), map[string]interface{}{
+		"doctor_id": credential.DoctorID,
+	})
+
+	return credential, nil
+}
+
+// OpenCredentialFile 資格証明書類ファイルの取得（閲覧権限を確認）
+func (s *CredentialService) OpenCredentialFile(credentialID, viewerID uint) (*models.DoctorCredential, *os.File, error) {
+	credential, err := s.credentialRepo.FindByID(credentialID)
+	if err != nil {
+		return nil, nil, errors.New("credential not found")
+	}
+
+	public := credential.Status == "approved" && credential.IsPublic
+	if !public && credential.DoctorID != viewerID && !s.isAdmin(viewerID) {
+		// 非公開の書類は存在自体を明かさない
+		return nil, nil, errors.New("credential not found")
+	}
+
+	file, err := os.Open(credential.FilePath)
+	if err != nil {
+		return nil, nil, errors.New("credential file not found")
+	}
+
+	if s.isAdmin(viewerID) {
+		s.auditService.LogUserAction(viewerID, "credential_viewed", "doctor_credential", fmt.Sprintf("%d", credential.ID), map[string]interface{}{
+			"doctor_id": credential.DoctorID,
+		})
+	}
+
+	return credential, file, nil
+}
+
+func (s *CredentialService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
+
+// saveUploadedFile アップロードされたファイルを指定パスに保存する
+func saveUploadedFile(file *multipart.FileHeader, path string) error {
+	src, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open file: %v", err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %v", err)
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to copy file: %v", err)
+	}
+	return nil
+}
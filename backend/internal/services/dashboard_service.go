@@ -0,0 +1,96 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+	"online_medical_consultation_app/backend/internal/views"
+)
+
+// ホーム画面に表示する件数と期間
+const (
+	dashboardAppointmentLimit      = 10
+	dashboardTaskLimit             = 20
+	dashboardActivePrescriptionAge = 30 * 24 * time.Hour
+)
+
+type DashboardService struct {
+	appointmentRepo  repositories.AppointmentRepository
+	prescriptionRepo repositories.PrescriptionRepository
+	messageRepo      repositories.MessageRepository
+	taskRepo         repositories.TaskRepository
+	userRepo         repositories.UserRepository
+}
+
+// UnreadSummary 未読メッセージ数（合計と予約ごと）
+type UnreadSummary struct {
+	Total        int                                   `json:"total"`
+	Appointments []repositories.AppointmentUnreadCount `json:"appointments"`
+}
+
+// PatientDashboard 患者のホーム画面の集約データ
+type PatientDashboard struct {
+	UpcomingAppointments []*views.AppointmentView  `json:"upcoming_appointments"`
+	ActivePrescriptions  []*views.PrescriptionView `json:"active_prescriptions"`
+	UnreadMessages       UnreadSummary             `json:"unread_messages"`
+	PendingTasks         []models.AppointmentTask  `json:"pending_tasks"`
+}
+
+func NewDashboardService(appointmentRepo repositories.AppointmentRepository, prescriptionRepo repositories.PrescriptionRepository, messageRepo repositories.MessageRepository, taskRepo repositories.TaskRepository, userRepo repositories.UserRepository) *DashboardService {
+	return &DashboardService{
+		appointmentRepo:  appointmentRepo,
+		prescriptionRepo: prescriptionRepo,
+		messageRepo:      messageRepo,
+		taskRepo:         taskRepo,
+		userRepo:         userRepo,
+	}
+}
+
+// GetPatientDashboard 患者のホーム画面データの取得（項目ごとに1クエリで取得）
+func (s *DashboardService) GetPatientDashboard(patientID uint) (*PatientDashboard, error) {
+	user, err := s.userRepo.FindByID(patientID)
+	if err != nil || user == nil || user.Role != "patient" {
+		return nil, errors.New("patient not found")
+	}
+
+	appointments, err := s.appointmentRepo.FindActiveByPatientWithDoctor(patientID, dashboardAppointmentLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	prescriptions, err := s.prescriptionRepo.FindByPatientSince(patientID, time.Now().Add(-dashboardActivePrescriptionAge))
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := s.messageRepo.GetUnreadCountsByUser(patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.taskRepo.FindOpenByAssigneeID(patientID, dashboardTaskLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	viewer := views.Viewer{UserID: patientID, Role: user.Role}
+	return &PatientDashboard{
+		UpcomingAppointments: views.Appointments(viewer, appointments),
+		ActivePrescriptions:  views.Prescriptions(viewer, prescriptions),
+		UnreadMessages:       newUnreadSummary(unread),
+		PendingTasks:         tasks,
+	}, nil
+}
+
+func newUnreadSummary(counts []repositories.AppointmentUnreadCount) UnreadSummary {
+	summary := UnreadSummary{Appointments: counts}
+	if summary.Appointments == nil {
+		summary.Appointments = []repositories.AppointmentUnreadCount{}
+	}
+	for _, count := range counts {
+		summary.Total += count.UnreadCount
+	}
+	return summary
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// デモアカウントのメールアドレスのドメイン（実在しない予約済みドメイン、通常の登録には使用できない）
+const demoEmailDomain = "demo.invalid"
+
+// demoAccounts 営業デモ用のアカウント
+var demoAccounts = []struct {
+	Email string
+	Role  string
+	Name  string
+}{
+	{Email: "doctor@" + demoEmailDomain, Role: "doctor", Name: "デモ 医師"},
+	{Email: "patient1@" + demoEmailDomain, Role: "patient", Name: "デモ 患者A"},
+	{Email: "patient2@" + demoEmailDomain, Role: "patient", Name: "デモ 患者B"},
+}
+
+// デモ用の診療枠（予約受付ルールのタイムゾーンで毎日この時間帯に作成する）
+const (
+	demoSlotDays      = 7
+	demoSlotStartHour = 10
+	demoSlotCount     = 4
+	demoSlotLength    = 30 * time.Minute
+)
+
+// IsDemoEmail デモアカウント用のドメインのメールアドレスか
+func IsDemoEmail(email string) bool {
+	return strings.HasSuffix(strings.ToLower(strings.TrimSpace(email)), "@"+demoEmailDomain)
+}
+
+// DemoResetResult デモデータの初期化結果
+type DemoResetResult struct {
+	Accounts            int       `json:"accounts"`
+	PurgedAppointments  int64     `json:"purged_appointments"`
+	CreatedSlots        int       `json:"created_slots"`
+	CreatedAppointments int       `json:"created_appointments"`
+	ResetAt             time.Time `json:"reset_at"`
+}
+
+// DemoService 営業デモ用のアカウントとデータの管理
+// デモアカウントは実アカウントから分離し（予約・医師一覧・集計・外部への通知の対象外）、毎日初期化する
+type DemoService struct {
+	demoRepo               repositories.DemoRepository
+	userRepo               repositories.UserRepository
+	slotRepo               repositories.SlotRepository
+	appointmentRepo        repositories.AppointmentRepository
+	messageRepo            repositories.MessageRepository
+	prescriptionRepo       repositories.PrescriptionRepository
+	chatService            *ChatService
+	patientDocumentService *PatientDocumentService
+	bookingPolicyService   *BookingPolicyService
+	auditService           *AuditService
+	password               string
+	resetHour              int
+
+	mu        sync.Mutex
+	lastReset time.Time
+}
+
+func NewDemoService(demoRepo repositories.DemoRepository, userRepo repositories.UserRepository, slotRepo repositories.SlotRepository, appointmentRepo repositories.AppointmentRepository, messageRepo repositories.MessageRepository, prescriptionRepo repositories.PrescriptionRepository, chatService *ChatService, patientDocumentService *PatientDocumentService, bookingPolicyService *BookingPolicyService, auditService *AuditService, password string, resetHour int) *DemoService {
+	return &DemoService{
+		demoRepo:               demoRepo,
+		userRepo:               userRepo,
+		slotRepo:               slotRepo,
+		appointmentRepo:        appointmentRepo,
+		messageRepo:            messageRepo,
+		prescriptionRepo:       prescriptionRepo,
+		chatService:            chatService,
+		patientDocumentService: patientDocumentService,
+		bookingPolicyService:   bookingPolicyService,
+		auditService:           auditService,
+		password:               password,
+		resetHour:              resetHour,
+	}
+}
+
+// EnsureDemoAccounts デモアカウントの作成（起動時に呼び出す、既存のアカウントはパスワードのみ更新する）
+// 新たに作成した場合はサンプルデータも作成する
+func (s *DemoService) EnsureDemoAccounts() error {
+	if s.password == "" {
+		return errors.New("demo password is not configured")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(s.password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	created := 0
+	for _, account := range demoAccounts {
+		existing, err := s.userRepo.FindByEmail(account.Email)
+		if err == nil && existing != nil {
+			if !existing.IsDemo {
+				return fmt.Errorf("%s is registered as a non-demo account", account.Email)
+			}
+			if err := s.demoRepo.SetPasswordHash(existing.ID, string(hash)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		user := &models.User{
+			Email:        account.Email,
+			PasswordHash: string(hash),
+			Role:         account.Role,
+			IsDemo:       true,
+		}
+		if err := s.userRepo.Create(user); err != nil {
+			return err
+		}
+		if err := s.createProfile(user, account.Name); err != nil {
+			return err
+		}
+		log.Printf("Created demo account %s (%s)", account.Email, account.Role)
+		created++
+	}
+
+	if created > 0 {
+		if _, err := s.reset(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunResetJob 定期ジョブ：デモデータを1日1回、指定の時刻に初期化する
+func (s *DemoService) RunResetJob() error {
+	location, err := s.bookingPolicyService.Location()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().In(location)
+	if now.Hour() != s.resetHour {
+		return nil
+	}
+	s.mu.Lock()
+	last := s.lastReset
+	s.mu.Unlock()
+	if !last.IsZero() && last.In(location).Format("2006-01-02") == now.Format("2006-01-02") {
+		return nil
+	}
+
+	result, err := s.reset()
+	if err != nil {
+		return err
+	}
+	s.auditService.LogSystemAction("demo_reset", "demo", "", result)
+	return nil
+}
+
+// ResetNow デモデータの即時の初期化（管理者のみ）
+func (s *DemoService) ResetNow(adminID uint) (*DemoResetResult, error) {
+	user, err := s.userRepo.FindByID(adminID)
+	if err != nil || user == nil || user.Role != "admin" {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	result, err := s.reset()
+	if err != nil {
+		return nil, err
+	}
+	s.auditService.LogUserAction(adminID, "demo_reset", "demo", "", result)
+	return result, nil
+}
+
+// reset デモアカウントのデータを削除し、サンプルの診療枠と予約を作り直す
+func (s *DemoService) reset() (*DemoResetResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users, err := s.demoRepo.FindDemoUsers()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+
+	purged, err := s.demoRepo.Purge(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range purged.Files {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to remove demo file %s: %v", path, err)
+		}
+	}
+	for i := range purged.Documents {
+		if err := s.patientDocumentService.RemoveDocumentFile(&purged.Documents[i]); err != nil {
+			log.Printf("Warning: Failed to remove demo document %s: %v", purged.Documents[i].FilePath, err)
+		}
+	}
+	for i := range purged.Attachments {
+		if err := s.chatService.RemoveAttachment(&purged.Attachments[i]); err != nil {
+			log.Printf("Warning: Failed to remove demo attachment %s: %v", purged.Attachments[i].ObjectKey, err)
+		}
+	}
+
+	result := &DemoResetResult{Accounts: len(users), PurgedAppointments: purged.Appointments, ResetAt: time.Now()}
+	if err := s.seed(users, result); err != nil {
+		return nil, err
+	}
+	s.lastReset = result.ResetAt
+
+	log.Printf("Demo data reset: %d appointments purged, %d slots and %d appointments created",
+		result.PurgedAppointments, result.CreatedSlots, result.CreatedAppointments)
+	return result, nil
+}
+
+// seed サンプルデータの作成（医師ごとに今後の診療枠と、完了済み・予約済みの診療を1件ずつ）
+func (s *DemoService) seed(users []models.User, result *DemoResetResult) error {
+	location, err := s.bookingPolicyService.Location()
+	if err != nil {
+		return err
+	}
+
+	var doctors, patients []models.User
+	for _, user := range users {
+		switch user.Role {
+		case "doctor":
+			doctors = append(doctors, user)
+		case "patient":
+			patients = append(patients, user)
+		}
+	}
+
+	now := time.Now()
+	local := now.In(location)
+	today := time.Date(local.Year(), local.Month(), local.Day(), demoSlotStartHour, 0, 0, 0, location)
+	for _, doctor := range doctors {
+		// 前日の枠は完了済みの診療に使う
+		var slots []*models.AvailabilitySlot
+		for day := -1; day < demoSlotDays; day++ {
+			for i := 0; i < demoSlotCount; i++ {
+				start := today.AddDate(0, 0, day).Add(time.Duration(i) * demoSlotLength)
+				if day >= 0 && !start.After(now) {
+					continue
+				}
+				slot := &models.AvailabilitySlot{DoctorID: doctor.ID, StartTime: start, EndTime: start.Add(demoSlotLength), Status: "open"}
+				if err := s.slotRepo.Create(slot); err != nil {
+					return err
+				}
+				slots = append(slots, slot)
+				result.CreatedSlots++
+			}
+		}
+		if len(patients) == 0 {
+			continue
+		}
+
+		completed := &models.Appointment{PatientID: patients[0].ID, DoctorID: doctor.ID, SlotID: &slots[0].ID, Status: "completed", Notes: "デモ用の診療です"}
+		completed.SetSchedule(slots[0])
+		if err := s.appointmentRepo.Create(completed); err != nil {
+			return err
+		}
+		if err := s.markSlotBooked(slots[0]); err != nil {
+			return err
+		}
+		result.CreatedAppointments++
+		if err := s.seedConsultation(completed); err != nil {
+			return err
+		}
+
+		// 予約済みの診療は翌日以降の最初の枠に入れる
+		upcomingPatient := patients[len(patients)-1]
+		for _, slot := range slots[demoSlotCount:] {
+			if slot.StartTime.Before(today.AddDate(0, 0, 1)) {
+				continue
+			}
+			upcoming := &models.Appointment{PatientID: upcomingPatient.ID, DoctorID: doctor.ID, SlotID: &slot.ID, Status: "confirmed", Notes: "デモ用の予約です"}
+			upcoming.SetSchedule(slot)
+			if err := s.appointmentRepo.Create(upcoming); err != nil {
+				return err
+			}
+			if err := s.markSlotBooked(slot); err != nil {
+				return err
+			}
+			result.CreatedAppointments++
+			break
+		}
+	}
+	return nil
+}
+
+// markSlotBooked 予約を入れたデモの診療枠を予約済みにする
+func (s *DemoService) markSlotBooked(slot *models.AvailabilitySlot) error {
+	slot.Status = "booked"
+	return s.slotRepo.Update(slot)
+}
+
+// seedConsultation 完了済みの診療のチャットと処方箋
+func (s *DemoService) seedConsultation(appointment *models.Appointment) error {
+	messages := []models.Message{
+		{AppointmentID: appointment.ID, SenderUserID: appointment.PatientID, Body: "昨日から喉の痛みと微熱があります。"},
+		{AppointmentID: appointment.ID, SenderUserID: appointment.DoctorID, Body: "承知しました。ビデオ通話で詳しくお伺いします。"},
+	}
+	for i := range messages {
+		if err := s.messageRepo.Create(&messages[i]); err != nil {
+			return err
+		}
+	}
+
+	items, err := json.Marshal([]PrescriptionItem{{
+		MedicationName: "アセトアミノフェン錠200mg",
+		Dosage:         "1回2錠",
+		Frequency:      "発熱時",
+		Duration:       "5日分",
+		Instructions:   "4時間以上あけて服用してください",
+	}})
+	if err != nil {
+		return err
+	}
+	return s.prescriptionRepo.Create(&models.Prescription{
+		AppointmentID:     appointment.ID,
+		ItemsJSON:         string(items),
+		Notes:             "デモ用の処方箋です",
+		CreatedByDoctorID: appointment.DoctorID,
+	})
+}
+
+// createProfile デモアカウントのプロフィールの作成（患者は予約できるようオンボーディングを完了済みにする）
+func (s *DemoService) createProfile(user *models.User, name string) error {
+	switch user.Role {
+	case "doctor":
+		return s.userRepo.CreateDoctorProfile(&models.DoctorProfile{
+			UserID:        user.ID,
+			Name:          name,
+			Specialty:     "内科",
+			LicenseNumber: "DEMO",
+			Bio:           "デモ用の医師アカウントです。",
+			Languages:     "ja,en",
+		})
+	case "patient":
+		birthdate := time.Date(1985, 4, 1, 0, 0, 0, 0, time.UTC)
+		noAllergies := ""
+		now := time.Now()
+		return s.userRepo.CreatePatientProfile(&models.PatientProfile{
+			UserID:                user.ID,
+			Name:                  name,
+			Birthdate:             &birthdate,
+			Phone:                 "000-0000-0000",
+			Allergies:             &noAllergies,
+			InsuranceProvider:     "デモ健康保険組合",
+			InsuranceNumber:       "DEMO",
+			OnboardingStep:        OnboardingStepCompleted,
+			OnboardingCompletedAt: &now,
+		})
+	}
+	return nil
+}
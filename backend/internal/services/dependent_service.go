@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+type DependentService struct {
+	dependentRepo   repositories.DependentRepository
+	appointmentRepo repositories.AppointmentRepository
+	userRepo        repositories.UserRepository
+}
+
+type DependentRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Birthdate    string `json:"birthdate"` // YYYY-MM-DD
+	Relationship string `json:"relationship" binding:"required,oneof=child parent spouse other"`
+	Gender       string `json:"gender"`
+	Notes        string `json:"notes"`
+}
+
+func NewDependentService(dependentRepo repositories.DependentRepository, appointmentRepo repositories.AppointmentRepository, userRepo repositories.UserRepository) *DependentService {
+	return &DependentService{
+		dependentRepo:   dependentRepo,
+		appointmentRepo: appointmentRepo,
+		userRepo:        userRepo,
+	}
+}
+
+// CreateDependent 家族アカウントの登録
+func (s *DependentService) CreateDependent(guardianID uint, req DependentRequest) (*models.Dependent, error) {
+	// 保護者（患者）の存在確認
+	guardian, err := s.userRepo.FindByID(guardianID)
+	if err != nil || guardian == nil || guardian.Role != "patient" {
+		return nil, errors.New("only patients can manage dependents")
+	}
+
+	dependent := &models.Dependent{GuardianID: guardianID}
+	if err := applyDependentRequest(dependent, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.dependentRepo.Create(dependent); err != nil {
+		return nil, err
+	}
+
+	return dependent, nil
+}
+
+// GetDependents 家族アカウント一覧の取得
+func (s *DependentService) GetDependents(guardianID uint) ([]models.Dependent, error) {
+	return s.dependentRepo.FindByGuardianID(guardianID)
+}
+
+// UpdateDependent 家族アカウントの更新
+func (s *DependentService) UpdateDependent(dependentID, guardianID uint, req DependentRequest) (*models.Dependent, error) {
+	dependent, err := s.GetOwnedDependent(dependentID, guardianID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyDependentRequest(dependent, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.dependentRepo.Update(dependent); err != nil {
+		return nil, err
+	}
+
+	return dependent, nil
+}
+
+// DeleteDependent 家族アカウントの削除
+func (s *DependentService) DeleteDependent(dependentID, guardianID uint) error {
+	if _, err := s.GetOwnedDependent(dependentID, guardianID); err != nil {
+		return err
+	}
+
+	return s.dependentRepo.Delete(dependentID)
+}
+
+// GetDependentAppointments 家族アカウントの受診履歴の取得
+func (s *DependentService) GetDependentAppointments(dependentID, guardianID uint, limit, offset int) ([]models.Appointment, int64, error) {
+	if _, err := s.GetOwnedDependent(dependentID, guardianID); err != nil {
+		return nil, 0, err
+	}
+
+	appointments, total, err := s.appointmentRepo.FindPageByDependentID(dependentID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 関連データの読み込み
+	for i := range appointments {
+		if err := s.appointmentRepo.LoadRelations(&appointments[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return appointments, total, nil
+}
+
+// GetOwnedDependent 保護者が管理する家族アカウントの取得
+func (s *DependentService) GetOwnedDependent(dependentID, guardianID uint) (*models.Dependent, error) {
+	dependent, err := s.dependentRepo.FindByID(dependentID)
+	if err != nil || dependent == nil {
+		return nil, errors.New("dependent not found")
+	}
+
+	if dependent.GuardianID != guardianID {
+		return nil, errors.New("unauthorized to access this dependent")
+	}
+
+	return dependent, nil
+}
+
+// applyDependentRequest リクエスト内容の反映
+func applyDependentRequest(dependent *models.Dependent, req DependentRequest) error {
+	dependent.Name = req.Name
+	dependent.Relationship = req.Relationship
+	dependent.Gender = req.Gender
+	dependent.Notes = req.Notes
+	dependent.Birthdate = nil
+
+	if req.Birthdate != "" {
+		birthdate, err := time.Parse("2006-01-02", req.Birthdate)
+		if err != nil {
+			return errors.New("invalid birthdate format")
+		}
+		if birthdate.After(time.Now()) {
+			return errors.New("birthdate cannot be in the future")
+		}
+		dependent.Birthdate = &birthdate
+	}
+
+	return nil
+}
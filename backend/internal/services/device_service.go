@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"log"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+type DeviceService struct {
+	deviceRepo repositories.DeviceRepository
+	userRepo   repositories.UserRepository
+	sender     PushSender
+}
+
+type RegisterDeviceRequest struct {
+	Platform string `json:"platform" binding:"required,oneof=ios android web"`
+	Token    string `json:"token" binding:"required,max=4096"`
+}
+
+func NewDeviceService(deviceRepo repositories.DeviceRepository, userRepo repositories.UserRepository, sender PushSender) *DeviceService {
+	return &DeviceService{
+		deviceRepo: deviceRepo,
+		userRepo:   userRepo,
+		sender:     sender,
+	}
+}
+
+// RegisterDevice プッシュ通知を受け取る端末の登録（別の利用者が登録済みのトークンは登録し直す）
+func (s *DeviceService) RegisterDevice(userID uint, req RegisterDeviceRequest) (*models.DeviceToken, error) {
+	device := &models.DeviceToken{
+		UserID:   userID,
+		Platform: req.Platform,
+		Token:    req.Token,
+	}
+	if err := s.deviceRepo.Save(device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// GetDevices 登録済み端末の一覧
+func (s *DeviceService) GetDevices(userID uint) ([]models.DeviceToken, error) {
+	return s.deviceRepo.FindByUserID(userID)
+}
+
+// DeleteDevice 端末の登録解除（ログアウト時等）
+func (s *DeviceService) DeleteDevice(userID, deviceID uint) error {
+	deleted, err := s.deviceRepo.DeleteByUser(deviceID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("device not found")
+	}
+	return nil
+}
+
+// PushToUser 利用者の全端末へのプッシュ通知（届いた端末数を返す、無効なトークンは削除する）
+func (s *DeviceService) PushToUser(userID uint, msg PushMessage) int {
+	// デモアカウントには実際のプッシュ通知を送らない
+	if user, err := s.userRepo.FindByID(userID); err == nil && user != nil && user.IsDemo {
+		return 0
+	}
+
+	devices, err := s.deviceRepo.FindByUserID(userID)
+	if err != nil {
+		log.Printf("Warning: Failed to find devices of user %d: %v", userID, err)
+		return 0
+	}
+
+	sent := 0
+	for _, device := range devices {
+		if err := s.sender.Send(device, msg); err != nil {
+			if errors.Is(err, ErrInvalidPushToken) {
+				if err := s.deviceRepo.DeleteByToken(device.Token); err != nil {
+					log.Printf("Warning: Failed to delete invalid device %d: %v", device.ID, err)
+				}
+				continue
+			}
+			log.Printf("Warning: Failed to push %s to device %d: %v", msg.Type, device.ID, err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/qrcode"
+)
+
+// 処方箋のQRコードの幅（ポイント、約35mm）
+const prescriptionQRCodeWidth = 100.0
+
+// DocumentService 患者へ発行する文書（処方箋等）のPDFの作成
+type DocumentService struct {
+	brandingService      *BrandingService
+	bookingPolicyService *BookingPolicyService
+	appBaseURL           string
+}
+
+// PrescriptionDocument 処方箋に記載する内容
+type PrescriptionDocument struct {
+	PrescriptionID    uint
+	IssuedAt          time.Time
+	PatientName       string
+	PatientBirthdate  *time.Time
+	InsuranceProvider string
+	InsuranceNumber   string
+	DoctorName        string
+	DoctorSpecialty   string
+	LicenseNumber     string
+	Items             []PrescriptionItem
+	Notes             string
+	VerificationCode  string
+}
+
+func NewDocumentService(brandingService *BrandingService, bookingPolicyService *BookingPolicyService, appBaseURL string) *DocumentService {
+	return &DocumentService{
+		brandingService:      brandingService,
+		bookingPolicyService: bookingPolicyService,
+		appBaseURL:           strings.TrimRight(appBaseURL, "/"),
+	}
+}
+
+// RenderPrescription 処方箋のPDF（ファイル名とデータ）
+// 薬局等が内容を照合できるよう、照合ページのURLをQRコードで記載する（日時は予約受付ルールのタイムゾーンで表示）
+func (s *DocumentService) RenderPrescription(document *PrescriptionDocument) (string, []byte, error) {
+	location, err := s.bookingPolicyService.Location()
+	if err != nil {
+		location = time.Local
+	}
+	verificationURL := s.PrescriptionVerificationURL(document.VerificationCode)
+	modules, err := qrcode.Encode(verificationURL)
+	if err != nil {
+		return "", nil, err
+	}
+
+	doc := s.brandingService.NewPDFDocument()
+	doc.Heading("処方箋")
+	doc.Blank()
+	doc.Text(fmt.Sprintf("処方箋番号: %d", document.PrescriptionID))
+	doc.Text("交付日: " + document.IssuedAt.In(location).Format("2006年1月2日"))
+
+	doc.Blank()
+	doc.Text("■ 患者")
+	doc.Text("氏名: " + document.PatientName)
+	if document.PatientBirthdate != nil {
+		doc.Text("生年月日: " + document.PatientBirthdate.Format("2006年1月2日"))
+	}
+	if document.InsuranceProvider != "" || document.InsuranceNumber != "" {
+		doc.Text("保険: " + strings.TrimSpace(document.InsuranceProvider+" "+document.InsuranceNumber))
+	}
+
+	doc.Blank()
+	doc.Text("■ 処方")
+	for i, item := range document.Items {
+		doc.Text(fmt.Sprintf("%d. %s", i+1, item.MedicationName))
+		doc.Text(fmt.Sprintf("　　用量: %s　用法: %s　期間: %s", item.Dosage, item.Frequency, item.Duration))
+		if item.Instructions != "" {
+			doc.Text("　　指示: " + item.Instructions)
+		}
+	}
+	if document.Notes != "" {
+		doc.Blank()
+		doc.Text("■ 備考")
+		doc.Text(document.Notes)
+	}
+
+	doctor := document.DoctorName
+	if document.DoctorSpecialty != "" {
+		doctor += "（" + document.DoctorSpecialty + "）"
+	}
+	license := document.LicenseNumber
+	if license == "" {
+		license = "未登録"
+	}
+	doc.Blank()
+	doc.Text("■ 処方医")
+	doc.Text("氏名: " + doctor)
+	doc.Text("医籍登録番号: " + license)
+
+	doc.Blank()
+	doc.Text("■ 処方箋の照合")
+	doc.Text("この処方箋の内容は、QRコードまたは次のURLから照合できます。処方の変更・取り消し後は照合できません。")
+	doc.Text(verificationURL)
+	doc.QRCode(modules, prescriptionQRCodeWidth)
+	doc.Text("照合コード: " + document.VerificationCode)
+
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("prescription_%d.pdf", document.PrescriptionID), buf.Bytes(), nil
+}
+
+// PrescriptionVerificationURL 処方箋の照合ページのURL
+func (s *DocumentService) PrescriptionVerificationURL(code string) string {
+	return fmt.Sprintf("%s/prescriptions/verify/%s", s.appBaseURL, code)
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+	"online_medical_consultation_app/backend/internal/storage"
+)
+
+// 1回のジョブで削除する期限切れのダウンロードの件数
+const exportDownloadCleanupBatch = 100
+
+// 保存先から直接取得させる署名付きURLの有効期間（再取得のたびに発行し直す）
+const exportDownloadURLTTL = 15 * time.Minute
+
+// DownloadService 大きなエクスポートのダウンロード
+// 生成しながら送信するエクスポートを一時保存し、通信が途切れた場合はトークンで途中から再取得できるようにする。
+// 生成中は一時ファイルに書き込み、完了後に保存先（全インスタンスで共有する）にアップロードする。
+// 1つの接続がインスタンスの帯域を使い切らないよう、接続ごとの送信速度の上限を設ける
+type DownloadService struct {
+	downloadRepo   repositories.ExportDownloadRepository
+	store          storage.Store
+	spoolDir       string // 空の場合はOSの一時ディレクトリ
+	ttl            time.Duration
+	bandwidthLimit int64
+}
+
+// ExportSpool 生成中のエクスポートの一時保存先
+type ExportSpool struct {
+	Download *models.ExportDownload
+	Token    string
+	file     *os.File
+	size     int64
+}
+
+// ExportFile 一時保存したエクスポートの取得
+// URL がある場合は保存先から直接取得させる（Range指定は保存先が処理する）。ない場合は呼び出し側で Body を閉じる
+type ExportFile struct {
+	Download *models.ExportDownload
+	URL      string
+	Body     io.ReadCloser
+}
+
+func NewDownloadService(downloadRepo repositories.ExportDownloadRepository, store storage.Store, spoolDir string, ttl time.Duration, bandwidthLimit int64) *DownloadService {
+	if spoolDir != "" {
+		if err := os.MkdirAll(spoolDir, 0700); err != nil {
+			log.Printf("Warning: Failed to create export spool directory: %v", err)
+		}
+	}
+
+	return &DownloadService{
+		downloadRepo:   downloadRepo,
+		store:          store,
+		spoolDir:       spoolDir,
+		ttl:            ttl,
+		bandwidthLimit: bandwidthLimit,
+	}
+}
+
+// BandwidthLimit 接続ごとの送信速度の上限（バイト/秒、0は無制限）
+func (s *DownloadService) BandwidthLimit() int64 {
+	return s.bandwidthLimit
+}
+
+// Begin エクスポートの一時保存の開始（再取得用のトークンを発行する）
+func (s *DownloadService) Begin(userID uint, filename, contentType string) (*ExportSpool, error) {
+	token, err := generateContactToken()
+	if err != nil {
+		return nil, err
+	}
+	tokenHash := hashContactSecret(token)
+
+	// ファイル名はトークンから推測できないようハッシュの一部を使う
+	objectKey := fmt.Sprintf("%d_%s", userID, tokenHash[:32])
+	file, err := os.CreateTemp(s.spoolDir, "export-*")
+	if err != nil {
+		return nil, err
+	}
+
+	download := &models.ExportDownload{
+		UserID:      userID,
+		TokenHash:   tokenHash,
+		ObjectKey:   objectKey,
+		Filename:    filename,
+		ContentType: contentType,
+		ExpiresAt:   time.Now().Add(s.ttl),
+	}
+	if err := s.downloadRepo.Create(download); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	return &ExportSpool{Download: download, Token: token, file: file}, nil
+}
+
+// Write 一時保存先への書き込み
+func (spool *ExportSpool) Write(p []byte) (int, error) {
+	n, err := spool.file.Write(p)
+	spool.size += int64(n)
+	return n, err
+}
+
+// Complete 一時保存の完了（保存先にアップロードし、以降はトークンで再取得できる）
+func (s *DownloadService) Complete(spool *ExportSpool) error {
+	if err := s.upload(spool); err != nil {
+		s.Abort(spool)
+		return err
+	}
+	os.Remove(spool.file.Name())
+
+	completedAt := time.Now()
+	if err := s.downloadRepo.MarkCompleted(spool.Download.ID, spool.size, completedAt); err != nil {
+		s.Abort(spool)
+		return err
+	}
+	spool.Download.SizeBytes = spool.size
+	spool.Download.CompletedAt = &completedAt
+	return nil
+}
+
+// upload 一時ファイルの保存先へのアップロード
+func (s *DownloadService) upload(spool *ExportSpool) error {
+	if _, err := spool.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	err := s.store.Put(context.Background(), spool.Download.ObjectKey, spool.file, spool.size, spool.Download.ContentType)
+	if closeErr := spool.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Abort 生成に失敗したエクスポートの一時保存の破棄
+func (s *DownloadService) Abort(spool *ExportSpool) {
+	spool.file.Close()
+	if err := os.Remove(spool.file.Name()); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: Failed to remove export spool %s: %v", spool.Download.ObjectKey, err)
+	}
+	if err := s.store.Delete(context.Background(), spool.Download.ObjectKey); err != nil {
+		log.Printf("Warning: Failed to remove export download %s: %v", spool.Download.ObjectKey, err)
+	}
+	if err := s.downloadRepo.Delete(spool.Download.ID); err != nil {
+		log.Printf("Warning: Failed to delete export download %d: %v", spool.Download.ID, err)
+	}
+}
+
+// Open トークンによる一時保存したエクスポートの取得（発行したユーザーのみ）
+// 保存先が署名付きURLを発行できる場合は短い有効期間のURLを返す
+func (s *DownloadService) Open(token string, userID uint) (*ExportFile, error) {
+	download, err := s.downloadRepo.FindByTokenHash(hashContactSecret(token))
+	if err != nil {
+		return nil, err
+	}
+	if download == nil || download.UserID != userID || !time.Now().Before(download.ExpiresAt) {
+		return nil, errors.New("download not found")
+	}
+	if download.CompletedAt == nil {
+		return nil, errors.New("download is not ready")
+	}
+
+	expires := time.Until(download.ExpiresAt)
+	if expires > exportDownloadURLTTL {
+		expires = exportDownloadURLTTL
+	}
+	signedURL, err := s.store.PresignGet(download.ObjectKey, download.Filename, expires)
+	if err == nil {
+		return &ExportFile{Download: download, URL: signedURL}, nil
+	}
+	if !errors.Is(err, storage.ErrPresignNotConfigured) {
+		return nil, err
+	}
+
+	body, err := s.store.Open(context.Background(), download.ObjectKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, errors.New("download not found")
+		}
+		return nil, err
+	}
+	return &ExportFile{Download: download, Body: body}, nil
+}
+
+// RunCleanupJob 有効期限を過ぎたダウンロードの一時保存ファイルの削除（定期実行用）
+func (s *DownloadService) RunCleanupJob() error {
+	downloads, err := s.downloadRepo.FindExpired(time.Now(), exportDownloadCleanupBatch)
+	if err != nil {
+		return err
+	}
+
+	for _, download := range downloads {
+		if err := s.store.Delete(context.Background(), download.ObjectKey); err != nil {
+			log.Printf("Warning: Failed to remove export spool %s: %v", download.ObjectKey, err)
+			continue
+		}
+		if err := s.downloadRepo.Delete(download.ID); err != nil {
+			return err
+		}
+	}
+	if len(downloads) > 0 {
+		log.Printf("Deleted %d expired export downloads", len(downloads))
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/storage"
+)
+
+// fakeExportDownloadRepository ダウンロードの記録をメモリ上で管理する
+type fakeExportDownloadRepository struct {
+	downloads map[uint]*models.ExportDownload
+}
+
+func (r *fakeExportDownloadRepository) Create(download *models.ExportDownload) error {
+	download.ID = uint(len(r.downloads) + 1)
+	r.downloads[download.ID] = download
+	return nil
+}
+
+func (r *fakeExportDownloadRepository) FindByTokenHash(tokenHash string) (*models.ExportDownload, error) {
+	for _, download := range r.downloads {
+		if download.TokenHash == tokenHash {
+			copied := *download
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeExportDownloadRepository) MarkCompleted(id uint, sizeBytes int64, completedAt time.Time) error {
+	r.downloads[id].SizeBytes = sizeBytes
+	r.downloads[id].CompletedAt = &completedAt
+	return nil
+}
+
+func (r *fakeExportDownloadRepository) Delete(id uint) error {
+	delete(r.downloads, id)
+	return nil
+}
+
+func (r *fakeExportDownloadRepository) FindExpired(now time.Time, limit int) ([]models.ExportDownload, error) {
+	return nil, nil
+}
+
+func TestExportDownloadIsStoredInStorage(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewLocalStore(filepath.Join(dir, "exports"), "", "")
+	spoolDir := filepath.Join(dir, "spool")
+	service := NewDownloadService(&fakeExportDownloadRepository{downloads: map[uint]*models.ExportDownload{}}, store, spoolDir, time.Hour, 0)
+
+	spool, err := service.Begin(1, "export.csv", "text/csv")
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if _, err := spool.Write([]byte("id,name\n1,test\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := service.Complete(spool); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	// 一時ファイルは保存先へのアップロード後に削除する
+	if entries, err := os.ReadDir(spoolDir); err != nil || len(entries) != 0 {
+		t.Errorf("spool directory = %v, %v; want the temporary file removed", entries, err)
+	}
+
+	if _, err := service.Open(spool.Token, 2); err == nil || err.Error() != "download not found" {
+		t.Errorf("Open() by another user error = %v, want download not found", err)
+	}
+	exportFile, err := service.Open(spool.Token, 1)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer exportFile.Body.Close()
+	body, err := io.ReadAll(exportFile.Body)
+	if err != nil {
+		t.Fatalf("failed to read the export: %v", err)
+	}
+	if string(body) != "id,name\n1,test\n" || exportFile.Download.SizeBytes != int64(len(body)) {
+		t.Errorf("Open() = %q (%d bytes), want the spooled export", body, exportFile.Download.SizeBytes)
+	}
+}
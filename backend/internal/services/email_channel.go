@@ -0,0 +1,22 @@
+package services
+
+import (
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+// EmailChannel 予約・処方の通知をメールで配信するチャネル（ユーザーの通知設定に従う）
+type EmailChannel struct {
+	emailNotificationService *EmailNotificationService
+}
+
+func NewEmailChannel(emailNotificationService *EmailNotificationService) *EmailChannel {
+	return &EmailChannel{emailNotificationService: emailNotificationService}
+}
+
+func (c *EmailChannel) Name() string {
+	return "email"
+}
+
+func (c *EmailChannel) Send(user *models.User, notification *models.Notification) error {
+	return c.emailNotificationService.sendNotificationEmail(user, notification)
+}
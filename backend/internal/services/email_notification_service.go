@@ -0,0 +1,221 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// unreadMessageEmailWindow 未読のメッセージをメールで知らせる対象とする送信からの期間（それより古いものは知らせない）
+const unreadMessageEmailWindow = 24 * time.Hour
+
+// emailNotificationCategories メールでも送る通知の種類と通知設定の項目
+var emailNotificationCategories = map[string]string{
+	"appointment_created":       models.EmailNotificationAppointmentCreated,
+	"appointment_confirmed":     models.EmailNotificationAppointmentConfirmed,
+	"appointment_cancelled":     models.EmailNotificationAppointmentCancelled,
+	"appointment_declined":      models.EmailNotificationAppointmentCancelled,
+	"prescription_issued":       models.EmailNotificationPrescription,
+	"prescription_updated":      models.EmailNotificationPrescription,
+	"prescription_ack_reminder": models.EmailNotificationPrescription,
+}
+
+// UpdateNotificationPreferenceRequest メール通知の設定の変更（指定した項目のみ）
+type UpdateNotificationPreferenceRequest struct {
+	EmailEnabled         *bool `json:"email_enabled"`
+	AppointmentCreated   *bool `json:"appointment_created"`
+	AppointmentConfirmed *bool `json:"appointment_confirmed"`
+	AppointmentCancelled *bool `json:"appointment_cancelled"`
+	Prescription         *bool `json:"prescription"`
+	ChatMessage          *bool `json:"chat_message"`
+}
+
+type EmailNotificationService struct {
+	preferenceRepo repositories.NotificationPreferenceRepository
+	messageRepo    repositories.MessageRepository
+	userRepo       repositories.UserRepository
+	sender         ContactSender
+	appBaseURL     string
+	unreadDelay    time.Duration // 未読のままメールで知らせるまでの時間
+}
+
+func NewEmailNotificationService(preferenceRepo repositories.NotificationPreferenceRepository, messageRepo repositories.MessageRepository, userRepo repositories.UserRepository, sender ContactSender, appBaseURL string, unreadDelay time.Duration) *EmailNotificationService {
+	return &EmailNotificationService{
+		preferenceRepo: preferenceRepo,
+		messageRepo:    messageRepo,
+		userRepo:       userRepo,
+		sender:         sender,
+		appBaseURL:     appBaseURL,
+		unreadDelay:    unreadDelay,
+	}
+}
+
+// GetPreferences メール通知の設定を取得（未設定の場合はすべて受け取る設定）
+func (s *EmailNotificationService) GetPreferences(userID uint) (*models.NotificationPreference, error) {
+	preference, err := s.preferenceRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if preference == nil {
+		preference = defaultNotificationPreference(userID)
+	}
+	return preference, nil
+}
+
+// UpdatePreferences メール通知の設定の変更
+func (s *EmailNotificationService) UpdatePreferences(userID uint, req UpdateNotificationPreferenceRequest) (*models.NotificationPreference, error) {
+	preference, err := s.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []struct {
+		value  *bool
+		target *bool
+	}{
+		{req.EmailEnabled, &preference.EmailEnabled},
+		{req.AppointmentCreated, &preference.AppointmentCreated},
+		{req.AppointmentConfirmed, &preference.AppointmentConfirmed},
+		{req.AppointmentCancelled, &preference.AppointmentCancelled},
+		{req.Prescription, &preference.Prescription},
+		{req.ChatMessage, &preference.ChatMessage},
+	}
+	for _, field := range fields {
+		if field.value != nil {
+			*field.target = *field.value
+		}
+	}
+
+	if err := s.preferenceRepo.Save(preference); err != nil {
+		return nil, err
+	}
+	return preference, nil
+}
+
+func defaultNotificationPreference(userID uint) *models.NotificationPreference {
+	return &models.NotificationPreference{
+		UserID:               userID,
+		EmailEnabled:         true,
+		AppointmentCreated:   true,
+		AppointmentConfirmed: true,
+		AppointmentCancelled: true,
+		Prescription:         true,
+		ChatMessage:          true,
+	}
+}
+
+// allowsEmail ユーザーが指定した種類のメールを受け取るか
+// 退会済み・デモ用のアカウントには送らない
+func (s *EmailNotificationService) allowsEmail(user *models.User, category string) bool {
+	if user.Email == "" || user.IsDemo || user.DeactivatedAt != nil || user.AnonymizedAt != nil {
+		return false
+	}
+
+	preference, err := s.GetPreferences(user.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to load notification preferences of user %d: %v", user.ID, err)
+		return false
+	}
+	if !preference.EmailEnabled {
+		return false
+	}
+	switch category {
+	case models.EmailNotificationAppointmentCreated:
+		return preference.AppointmentCreated
+	case models.EmailNotificationAppointmentConfirmed:
+		return preference.AppointmentConfirmed
+	case models.EmailNotificationAppointmentCancelled:
+		return preference.AppointmentCancelled
+	case models.EmailNotificationPrescription:
+		return preference.Prescription
+	case models.EmailNotificationChatMessage:
+		return preference.ChatMessage
+	}
+	return false
+}
+
+// sendNotificationEmail アプリ内通知と同じ内容のメール（メールで送る種類の通知のみ）
+func (s *EmailNotificationService) sendNotificationEmail(user *models.User, notification *models.Notification) error {
+	category, ok := emailNotificationCategories[notification.Type]
+	if !ok || !s.allowsEmail(user, category) {
+		return nil
+	}
+
+	var body strings.Builder
+	if notification.Body != "" {
+		body.WriteString(notification.Body + "\n\n")
+	}
+	fmt.Fprintf(&body, "詳しくはアプリでご確認ください。\n%s\n", s.appBaseURL)
+	return s.sender.SendEmail(user.Email, notification.Title, body.String())
+}
+
+// RunUnreadMessageEmailJob 未読のまま一定時間が経過した診療チャットのメッセージを受信者へメールで知らせる
+// 受信者ごとに予約単位の件数をまとめて送り、メッセージの内容はメールに含めない
+func (s *EmailNotificationService) RunUnreadMessageEmailJob() error {
+	now := time.Now()
+	messages, err := s.messageRepo.FindUnreadForEmail(now.Add(-unreadMessageEmailWindow), now.Add(-s.unreadDelay))
+	if err != nil {
+		return err
+	}
+
+	unread := make(map[uint]map[uint][]uint) // 受信者 → 予約 → メッセージ
+	for _, message := range messages {
+		recipientID := message.Appointment.PatientID
+		if message.SenderUserID == message.Appointment.PatientID {
+			recipientID = message.Appointment.DoctorID
+		}
+		if unread[recipientID] == nil {
+			unread[recipientID] = make(map[uint][]uint)
+		}
+		unread[recipientID][message.AppointmentID] = append(unread[recipientID][message.AppointmentID], message.ID)
+	}
+
+	for recipientID, byAppointment := range unread {
+		var messageIDs []uint
+		for _, ids := range byAppointment {
+			messageIDs = append(messageIDs, ids...)
+		}
+
+		if err := s.sendUnreadMessageEmail(recipientID, byAppointment); err != nil {
+			// 次回の実行で再送する
+			log.Printf("Warning: Failed to email unread messages to user %d: %v", recipientID, err)
+			continue
+		}
+		if err := s.messageRepo.MarkEmailNotified(messageIDs, now); err != nil {
+			log.Printf("Warning: Failed to record unread message email to user %d: %v", recipientID, err)
+		}
+	}
+	return nil
+}
+
+func (s *EmailNotificationService) sendUnreadMessageEmail(recipientID uint, byAppointment map[uint][]uint) error {
+	user, err := s.userRepo.FindByID(recipientID)
+	if err != nil || user == nil {
+		// 削除済みのユーザーには送らない（送信済みとして記録する）
+		return nil
+	}
+	if !s.allowsEmail(user, models.EmailNotificationChatMessage) {
+		return nil
+	}
+
+	appointmentIDs := make([]uint, 0, len(byAppointment))
+	total := 0
+	for appointmentID, ids := range byAppointment {
+		appointmentIDs = append(appointmentIDs, appointmentID)
+		total += len(ids)
+	}
+	sort.Slice(appointmentIDs, func(i, j int) bool { return appointmentIDs[i] < appointmentIDs[j] })
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "診療チャットに未読のメッセージが%d件あります。\n", total)
+	for _, appointmentID := range appointmentIDs {
+		fmt.Fprintf(&body, "\n予約 #%d: %d件\n%s/%s/chat/%d\n", appointmentID, len(byAppointment[appointmentID]), s.appBaseURL, user.Role, appointmentID)
+	}
+	body.WriteString("\nメールでのお知らせが不要な場合は、アプリの通知設定から停止できます。\n")
+	return s.sender.SendEmail(user.Email, "【未読のメッセージ】診療チャットにメッセージが届いています", body.String())
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+type EscalationService struct {
+	escalationRepo      repositories.EscalationRepository
+	appointmentRepo     repositories.AppointmentRepository
+	userRepo            repositories.UserRepository
+	notificationService *NotificationService
+	auditService        *AuditService
+	timeout             time.Duration
+	onCallAdminIDs      []uint
+}
+
+type RaiseEscalationRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func NewEscalationService(escalationRepo repositories.EscalationRepository, appointmentRepo repositories.AppointmentRepository, userRepo repositories.UserRepository, notificationService *NotificationService, auditService *AuditService, timeout time.Duration, onCallAdminIDs []uint) *EscalationService {
+	return &EscalationService{
+		escalationRepo:      escalationRepo,
+		appointmentRepo:     appointmentRepo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		auditService:        auditService,
+		timeout:             timeout,
+		onCallAdminIDs:      onCallAdminIDs,
+	}
+}
+
+// RaiseEscalation 患者による緊急エスカレーションの発報
+func (s *EscalationService) RaiseEscalation(appointmentID, userID uint, req RaiseEscalationRequest) (*models.Escalation, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+
+	// 権限確認（予約した患者のみ）
+	if appointment.PatientID != userID {
+		return nil, errors.New("unauthorized to escalate this appointment")
+	}
+
+	if appointment.Status != "pending" && appointment.Status != "confirmed" {
+		return nil, errors.New("appointment is not active")
+	}
+
+	// 未解決のエスカレーションがある場合は重複して発報しない
+	active, err := s.escalationRepo.FindActiveByAppointmentID(appointmentID)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, errors.New("an escalation is already in progress for this appointment")
+	}
+
+	// 予約を緊急としてマーク
+	appointment.IsUrgent = true
+	if err := s.appointmentRepo.Update(appointment); err != nil {
+		return nil, err
+	}
+
+	escalation := &models.Escalation{
+		AppointmentID:  appointmentID,
+		RaisedByUserID: userID,
+		Reason:         req.Reason,
+		Status:         "open",
+	}
+	if err := s.escalationRepo.Create(escalation); err != nil {
+		return nil, err
+	}
+
+	// 担当医師へ即時通知（全チャネル）
+	if _, err := s.notificationService.Notify(appointment.DoctorID, NotificationMessage{
+		Type:     "escalation_raised",
+		Title:    "緊急対応の要請",
+		Body:     req.Reason,
+		Priority: "high",
+		Data:     escalationNotificationData(escalation),
+	}); err != nil {
+		log.Printf("Warning: Failed to notify doctor %d of escalation %d: %v", appointment.DoctorID, escalation.ID, err)
+	}
+
+	s.auditService.LogUserAction(userID, "escalation_raised", "escalation", fmt.Sprintf("%d", escalation.ID), map[string]interface{}{
+		"appointment_id": appointmentID,
+		"doctor_id":      appointment.DoctorID,
+	})
+
+	return escalation, nil
+}
+
+// AcknowledgeEscalation エスカレーションの確認（医師または管理者）
+func (s *EscalationService) AcknowledgeEscalation(appointmentID, escalationID, userID uint) (*models.Escalation, error) {
+	escalation, appointment, err := s.getEscalationForResponder(appointmentID, escalationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if escalation.Status != "open" && escalation.Status != "escalated" {
+		return nil, errors.New("escalation cannot be acknowledged")
+	}
+
+	now := time.Now()
+	escalation.Status = "acknowledged"
+	escalation.AcknowledgedByID = &userID
+	escalation.AcknowledgedAt = &now
+	if err := s.escalationRepo.Update(escalation); err != nil {
+		return nil, err
+	}
+
+	// 患者へ対応開始を通知
+	if _, err := s.notificationService.Notify(appointment.PatientID, NotificationMessage{
+		Type:     "escalation_acknowledged",
+		Title:    "緊急対応の要請が確認されました",
+		Priority: "high",
+		Data:     escalationNotificationData(escalation),
+	}); err != nil {
+		log.Printf("Warning: Failed to notify patient %d of escalation %d: %v", appointment.PatientID, escalation.ID, err)
+	}
+
+	s.auditService.LogUserAction(userID, "escalation_acknowledged", "escalation", fmt.Sprintf("%d", escalation.ID), map[string]interface{}{
+		"appointment_id": appointmentID,
+	})
+
+	return escalation, nil
+}
+
+// ResolveEscalation エスカレーションの解決（医師または管理者）
+func (s *EscalationService) ResolveEscalation(appointmentID, escalationID, userID uint) (*models.Escalation, error) {
+	escalation, _, err := s.getEscalationForResponder(appointmentID, escalationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if escalation.Status == "resolved" {
+		return nil, errors.New("escalation is already resolved")
+	}
+
+	now := time.Now()
+	escalation.Status = "resolved"
+	escalation.ResolvedAt = &now
+	if escalation.AcknowledgedByID == nil {
+		escalation.AcknowledgedByID = &userID
+		escalation.AcknowledgedAt = &now
+	}
+	if err := s.escalationRepo.Update(escalation); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(userID, "escalation_resolved", "escalation", fmt.Sprintf("%d", escalation.ID), map[string]interface{}{
+		"appointment_id": appointmentID,
+	})
+
+	return escalation, nil
+}
+
+// GetEscalations 予約のエスカレーション一覧の取得
+func (s *EscalationService) GetEscalations(appointmentID, userID uint) ([]models.Escalation, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+
+	if !appointment.IsParticipant(userID) && !s.isAdmin(userID) {
+		return nil, errors.New("unauthorized to view escalations for this appointment")
+	}
+
+	return s.escalationRepo.FindByAppointmentID(appointmentID)
+}
+
+// RunEscalationJob 定期ジョブ：期限内に確認されなかったエスカレーションを当番管理者へ通知
+func (s *EscalationService) RunEscalationJob() error {
+	escalations, err := s.escalationRepo.FindOpenCreatedBefore(time.Now().Add(-s.timeout))
+	if err != nil {
+		return err
+	}
+	if len(escalations) == 0 {
+		return nil
+	}
+
+	adminIDs, err := s.onCallAdmins()
+	if err != nil {
+		return err
+	}
+	if len(adminIDs) == 0 {
+		log.Printf("Warning: No on-call admins configured, %d escalations left unescalated", len(escalations))
+		return nil
+	}
+
+	for i := range escalations {
+		escalation := &escalations[i]
+
+		now := time.Now()
+		escalation.Status = "escalated"
+		escalation.EscalatedAt = &now
+		if err := s.escalationRepo.Update(escalation); err != nil {
+			return err
+		}
+
+		// デモアカウントの予約では実在の管理者を呼び出さない
+		if doctor, err := s.userRepo.FindByID(escalation.Appointment.DoctorID); err == nil && doctor.IsDemo {
+			continue
+		}
+
+		sent := s.notificationService.NotifyMany(adminIDs, NotificationMessage{
+			Type:     "escalation_unacknowledged",
+			Title:    "未確認の緊急対応要請",
+			Body:     escalation.Reason,
+			Priority: "high",
+			Data:     escalationNotificationData(escalation),
+		})
+
+		s.auditService.LogSystemAction("escalation_escalated", "escalation", fmt.Sprintf("%d", escalation.ID), map[string]interface{}{
+			"appointment_id": escalation.AppointmentID,
+			"doctor_id":      escalation.Appointment.DoctorID,
+			"admin_ids":      adminIDs,
+			"notified":       sent,
+		})
+	}
+
+	return nil
+}
+
+// onCallAdmins 通知先の管理者（設定がない場合は全管理者）
+func (s *EscalationService) onCallAdmins() ([]uint, error) {
+	if len(s.onCallAdminIDs) > 0 {
+		return s.onCallAdminIDs, nil
+	}
+
+	admins, err := s.userRepo.FindByRole("admin")
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(admins))
+	for _, admin := range admins {
+		ids = append(ids, admin.ID)
+	}
+	return ids, nil
+}
+
+// getEscalationForResponder 対応者（担当医師または管理者）としてエスカレーションを取得
+func (s *EscalationService) getEscalationForResponder(appointmentID, escalationID, userID uint) (*models.Escalation, *models.Appointment, error) {
+	escalation, err := s.escalationRepo.FindByID(escalationID)
+	if err != nil || escalation == nil || escalation.AppointmentID != appointmentID {
+		return nil, nil, errors.New("escalation not found")
+	}
+
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, nil, errors.New("appointment not found")
+	}
+
+	if appointment.DoctorID != userID && !s.isAdmin(userID) {
+		return nil, nil, errors.New("unauthorized to respond to this escalation")
+	}
+
+	return escalation, appointment, nil
+}
+
+func (s *EscalationService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
+
+func escalationNotificationData(escalation *models.Escalation) map[string]interface{} {
+	return map[string]interface{}{
+		"escalation_id":  escalation.ID,
+		"appointment_id": escalation.AppointmentID,
+	}
+}
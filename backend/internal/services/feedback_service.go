@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+const feedbackCommentMaxLength = 2000
+
+type FeedbackService struct {
+	feedbackRepo    repositories.FeedbackRepository
+	appointmentRepo repositories.AppointmentRepository
+	auditService    *AuditService
+}
+
+type SubmitFeedbackRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment string `json:"comment"`
+}
+
+func NewFeedbackService(feedbackRepo repositories.FeedbackRepository, appointmentRepo repositories.AppointmentRepository, auditService *AuditService) *FeedbackService {
+	return &FeedbackService{
+		feedbackRepo:    feedbackRepo,
+		appointmentRepo: appointmentRepo,
+		auditService:    auditService,
+	}
+}
+
+// SubmitFeedback 完了した診療の満足度の評価（予約した患者のみ、1予約につき1回）
+func (s *FeedbackService) SubmitFeedback(appointmentID, patientID uint, req SubmitFeedbackRequest) (*models.AppointmentFeedback, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil || appointment.PatientID != patientID {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.Status != "completed" {
+		return nil, errors.New("feedback can only be submitted for completed appointments")
+	}
+	comment := strings.TrimSpace(req.Comment)
+	if len([]rune(comment)) > feedbackCommentMaxLength {
+		return nil, fmt.Errorf("comment must be at most %d characters", feedbackCommentMaxLength)
+	}
+
+	if existing, err := s.feedbackRepo.FindByAppointmentID(appointmentID); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, errors.New("feedback is already submitted for this appointment")
+	}
+
+	feedback := &models.AppointmentFeedback{
+		AppointmentID: appointmentID,
+		DoctorID:      appointment.DoctorID,
+		Rating:        req.Rating,
+		Comment:       comment,
+	}
+	if err := s.feedbackRepo.Create(feedback); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(patientID, "feedback_submitted", "appointment", fmt.Sprintf("%d", appointmentID), map[string]interface{}{
+		"doctor_id": appointment.DoctorID,
+		"rating":    req.Rating,
+	})
+
+	return feedback, nil
+}
+
+// GetFeedback 予約の評価の取得（患者・担当医師のみ、未評価の場合はnil）
+func (s *FeedbackService) GetFeedback(appointmentID, userID uint) (*models.AppointmentFeedback, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.PatientID != userID && appointment.DoctorID != userID {
+		return nil, errors.New("unauthorized to view feedback for this appointment")
+	}
+	return s.feedbackRepo.FindByAppointmentID(appointmentID)
+}
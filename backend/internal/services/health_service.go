@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/storage"
+)
+
+// 各確認のタイムアウトと、未適用のマイグレーションの確認結果を使い回す期間
+const (
+	healthCheckTimeout      = 3 * time.Second
+	migrationCheckCacheTTL  = 30 * time.Second
+	healthStatusOK          = "ok"
+	healthStatusUnavailable = "unavailable"
+)
+
+// HealthCheck 個別の確認の結果
+type HealthCheck struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HealthReport 稼働状況の確認結果（確認の名前ごと）
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks"`
+}
+
+// Healthy すべての確認が成功したかどうか
+func (r *HealthReport) Healthy() bool {
+	return r.Status == healthStatusOK
+}
+
+// HealthService 死活監視（データベースへの接続）と準備状況（マイグレーション・アップロード先・オブジェクトストレージ）の確認
+type HealthService struct {
+	db                DatabasePinger
+	pendingMigrations func() ([]string, error)
+	uploadDir         string
+	stores            map[string]storage.Store
+
+	mu              sync.Mutex
+	migrationResult HealthCheck
+	migrationAt     time.Time
+}
+
+func NewHealthService(db DatabasePinger, pendingMigrations func() ([]string, error), uploadDir string, stores map[string]storage.Store) *HealthService {
+	return &HealthService{
+		db:                db,
+		pendingMigrations: pendingMigrations,
+		uploadDir:         uploadDir,
+		stores:            stores,
+	}
+}
+
+// Liveness 死活監視（データベースへの接続のみを確認する）
+func (s *HealthService) Liveness(ctx context.Context) *HealthReport {
+	report := &HealthReport{Status: healthStatusOK, Checks: make(map[string]HealthCheck)}
+	report.add("database", s.checkDatabase(ctx))
+	return report
+}
+
+// Readiness 準備状況（データベースへの接続・未適用のマイグレーション・アップロード先・オブジェクトストレージ）
+func (s *HealthService) Readiness(ctx context.Context) *HealthReport {
+	report := &HealthReport{Status: healthStatusOK, Checks: make(map[string]HealthCheck)}
+
+	dbErr := s.checkDatabase(ctx)
+	report.add("database", dbErr)
+	if dbErr != nil {
+		// データベースに接続できない場合はマイグレーションを確認できない
+		report.Checks["migrations"] = HealthCheck{Status: healthStatusUnavailable, Error: "database unavailable"}
+	} else {
+		report.Checks["migrations"] = s.checkMigrations()
+	}
+
+	report.add("upload_dir", storage.CheckWritableDir(s.uploadDir))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for name, store := range s.stores {
+		wg.Add(1)
+		go func(name string, store storage.Store) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			err := store.Check(checkCtx)
+			mu.Lock()
+			report.add("storage_"+name, err)
+			mu.Unlock()
+		}(name, store)
+	}
+	wg.Wait()
+
+	for _, check := range report.Checks {
+		if check.Status != healthStatusOK {
+			report.Status = healthStatusUnavailable
+		}
+	}
+	return report
+}
+
+func (s *HealthService) checkDatabase(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	return s.db.PingContext(ctx)
+}
+
+// checkMigrations 未適用のマイグレーションの確認（スキーマの照会は重いため一定期間は結果を使い回す）
+func (s *HealthService) checkMigrations() HealthCheck {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.migrationAt.IsZero() && time.Since(s.migrationAt) < migrationCheckCacheTTL {
+		return s.migrationResult
+	}
+
+	result := HealthCheck{Status: healthStatusOK}
+	pending, err := s.pendingMigrations()
+	switch {
+	case err != nil:
+		result = HealthCheck{Status: healthStatusUnavailable, Error: err.Error()}
+	case len(pending) > 0:
+		sort.Strings(pending)
+		result = HealthCheck{Status: healthStatusUnavailable, Error: fmt.Sprintf("pending migrations: %s", strings.Join(pending, ", "))}
+	}
+	s.migrationResult = result
+	s.migrationAt = time.Now()
+	return result
+}
+
+func (r *HealthReport) add(name string, err error) {
+	if err != nil {
+		r.Checks[name] = HealthCheck{Status: healthStatusUnavailable, Error: err.Error()}
+		r.Status = healthStatusUnavailable
+		return
+	}
+	r.Checks[name] = HealthCheck{Status: healthStatusOK}
+}
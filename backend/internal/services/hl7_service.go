@@ -0,0 +1,588 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/hl7"
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+const (
+	hl7ExportBatchSize = 100
+	// 書き込み中のトランザクションを取りこぼさないよう、直近の更新は次回の送信に回す
+	hl7ExportSettleDelay = 5 * time.Second
+)
+
+// hl7MessageTypes 連携先ごとに選択できるメッセージの種類（ADT: 患者・予約、ORM: 処方）
+var hl7MessageTypes = []string{"ADT", "ORM"}
+
+var hl7DirectoryPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// HL7Service HL7v2による医療機関への連携（FHIRに未対応の病院へ患者・予約・処方をMLLPまたはファイル配置で送信する）
+type HL7Service struct {
+	hl7Repo      repositories.HL7Repository
+	userRepo     repositories.UserRepository
+	auditService *AuditService
+	fileDropDir  string // ファイル配置による連携先のディレクトリの基点
+	timeout      time.Duration
+}
+
+func NewHL7Service(hl7Repo repositories.HL7Repository, userRepo repositories.UserRepository, auditService *AuditService, fileDropDir string, timeout time.Duration) *HL7Service {
+	return &HL7Service{
+		hl7Repo:      hl7Repo,
+		userRepo:     userRepo,
+		auditService: auditService,
+		fileDropDir:  fileDropDir,
+		timeout:      timeout,
+	}
+}
+
+// HL7DestinationRequest 連携先の登録・更新
+type HL7DestinationRequest struct {
+	Name                 string   `json:"name" binding:"required"`
+	Transport            string   `json:"transport" binding:"required,oneof=mllp file"`
+	Host                 string   `json:"host"`
+	Port                 int      `json:"port"`
+	UseTLS               bool     `json:"use_tls"`
+	Directory            string   `json:"directory"`
+	SendingApplication   string   `json:"sending_application" binding:"required"`
+	SendingFacility      string   `json:"sending_facility" binding:"required"`
+	ReceivingApplication string   `json:"receiving_application"`
+	ReceivingFacility    string   `json:"receiving_facility"`
+	ProcessingID         string   `json:"processing_id"` // P | T（省略時はP）
+	MessageTypes         []string `json:"message_types" binding:"required,min=1"`
+	Enabled              *bool    `json:"enabled"` // 省略時は有効
+	// SendFrom この日時以降に作成・更新されたものから送信する（登録時の省略は登録以降、更新時の指定は送信し直し）
+	SendFrom *time.Time `json:"send_from"`
+}
+
+// CreateDestination 連携先の登録（管理者のみ）
+func (s *HL7Service) CreateDestination(adminID uint, req HL7DestinationRequest) (*models.HL7Destination, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	destination := &models.HL7Destination{}
+	if err := applyHL7DestinationRequest(destination, req); err != nil {
+		return nil, err
+	}
+	from := time.Now()
+	if req.SendFrom != nil {
+		from = *req.SendFrom
+	}
+	resetHL7Cursors(destination, from)
+	if err := s.hl7Repo.CreateDestination(destination); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(adminID, "hl7_destination_created", "hl7_destination", fmt.Sprintf("%d", destination.ID), nil)
+	return destination, nil
+}
+
+// UpdateDestination 連携先の設定の更新（管理者のみ）
+func (s *HL7Service) UpdateDestination(adminID, destinationID uint, req HL7DestinationRequest) (*models.HL7Destination, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	destination, err := s.hl7Repo.FindDestinationByID(destinationID)
+	if err != nil {
+		return nil, err
+	}
+	if destination == nil {
+		return nil, errors.New("hl7 destination not found")
+	}
+	if err := applyHL7DestinationRequest(destination, req); err != nil {
+		return nil, err
+	}
+	if req.SendFrom != nil {
+		resetHL7Cursors(destination, *req.SendFrom)
+	}
+	if err := s.hl7Repo.UpdateDestination(destination, req.SendFrom != nil); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(adminID, "hl7_destination_updated", "hl7_destination", fmt.Sprintf("%d", destination.ID), map[string]interface{}{
+		"resend_from": req.SendFrom,
+	})
+	return destination, nil
+}
+
+// GetDestinations 連携先の一覧（管理者のみ）
+func (s *HL7Service) GetDestinations(adminID uint) ([]models.HL7Destination, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	return s.hl7Repo.FindDestinations()
+}
+
+// DeleteDestination 連携先の削除（管理者のみ、送信履歴は残す）
+func (s *HL7Service) DeleteDestination(adminID, destinationID uint) error {
+	if !s.isAdmin(adminID) {
+		return errors.New("unauthorized: admin access required")
+	}
+
+	deleted, err := s.hl7Repo.DeleteDestination(destinationID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("hl7 destination not found")
+	}
+
+	s.auditService.LogUserAction(adminID, "hl7_destination_deleted", "hl7_destination", fmt.Sprintf("%d", destinationID), nil)
+	return nil
+}
+
+// GetMessages 連携先への送信履歴（管理者のみ）
+func (s *HL7Service) GetMessages(adminID, destinationID uint, limit, offset int) ([]models.HL7Message, int64, error) {
+	if !s.isAdmin(adminID) {
+		return nil, 0, errors.New("unauthorized: admin access required")
+	}
+
+	destination, err := s.hl7Repo.FindDestinationByID(destinationID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if destination == nil {
+		return nil, 0, errors.New("hl7 destination not found")
+	}
+	return s.hl7Repo.FindMessages(destinationID, limit, offset)
+}
+
+// RunExportJob 定期ジョブ：有効な連携先ごとに、前回以降に作成・更新された患者・予約・処方を送信する
+// 受信側で順序が入れ替わらないよう1件ずつ送信し、失敗した場合はその連携先への送信を中断して次回に再送する
+func (s *HL7Service) RunExportJob() error {
+	destinations, err := s.hl7Repo.FindEnabledDestinations()
+	if err != nil {
+		return err
+	}
+
+	for i := range destinations {
+		destination := &destinations[i]
+		if err := s.export(destination); err != nil {
+			log.Printf("Warning: HL7 export to destination %d (%s) failed: %v", destination.ID, destination.Name, err)
+			if err := s.hl7Repo.RecordError(destination.ID, err.Error()); err != nil {
+				log.Printf("Warning: failed to record HL7 export error for destination %d: %v", destination.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *HL7Service) export(destination *models.HL7Destination) error {
+	var sender hl7.Sender
+	switch destination.Transport {
+	case "mllp":
+		sender = hl7.NewMLLPSender(destination.Host, destination.Port, destination.UseTLS, s.timeout)
+	case "file":
+		sender = hl7.NewFileDropSender(filepath.Join(s.fileDropDir, destination.Directory))
+	default:
+		return fmt.Errorf("unknown transport: %s", destination.Transport)
+	}
+
+	before := time.Now().Add(-hl7ExportSettleDelay)
+	types := strings.Split(destination.MessageTypes, ",")
+	if slices.Contains(types, "ADT") {
+		if err := s.exportPatients(destination, sender, before); err != nil {
+			return err
+		}
+		if err := s.exportAppointments(destination, sender, before); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(types, "ORM") {
+		if err := s.exportPrescriptions(destination, sender, before); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportPatients 患者の登録（A28）・患者情報の変更（A31）
+func (s *HL7Service) exportPatients(destination *models.HL7Destination, sender hl7.Sender, before time.Time) error {
+	for {
+		profiles, err := s.hl7Repo.FindChangedPatients(destination.PatientsSyncedAt, destination.PatientsSyncedID, before, hl7ExportBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, profile := range profiles {
+			err := s.deliver(destination, sender, repositories.HL7EntityPatients, profile.UserID, profile.UpdatedAt,
+				func(header hl7.Header, sent bool) (string, string, string, error) {
+					event := hl7.EventRegisterPerson
+					if sent {
+						event = hl7.EventUpdatePerson
+					}
+					message, err := hl7.BuildADT(header, event, hl7PatientFromProfile(profile.UserID, &profile), nil, nil)
+					if err != nil {
+						return "", "", "", err
+					}
+					return "ADT^" + event, message, hl7.Content(message), nil
+				})
+			if err != nil {
+				return err
+			}
+		}
+		if len(profiles) < hl7ExportBatchSize {
+			return nil
+		}
+	}
+}
+
+// exportAppointments 予約の確定（A05）・キャンセル（A38）・診療の終了（A03）
+// 確定前の予約は送信せず、送信済みの位置のみ進める
+func (s *HL7Service) exportAppointments(destination *models.HL7Destination, sender hl7.Sender, before time.Time) error {
+	for {
+		appointments, err := s.hl7Repo.FindChangedAppointments(destination.AppointmentsSyncedAt, destination.AppointmentsSyncedID, before, hl7ExportBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, appointment := range appointments {
+			err := s.deliver(destination, sender, repositories.HL7EntityAppointments, appointment.ID, appointment.UpdatedAt,
+				func(header hl7.Header, sent bool) (string, string, string, error) {
+					var event string
+					switch appointment.Status {
+					case "confirmed":
+						event = hl7.EventPreAdmit
+					case "completed":
+						event = hl7.EventEndVisit
+					case "cancelled":
+						// 送信していない予約のキャンセルは連携先に存在しないため送信しない
+						if !sent {
+							return "", "", "", nil
+						}
+						event = hl7.EventCancelPreAdmit
+					default:
+						return "", "", "", nil
+					}
+
+					patient, kin := hl7PatientForAppointment(&appointment)
+					message, err := hl7.BuildADT(header, event, patient, kin, hl7VisitForAppointment(&appointment))
+					if err != nil {
+						return "", "", "", err
+					}
+					return "ADT^" + event, message, event + "\r" + hl7.Content(message), nil
+				})
+			if err != nil {
+				return err
+			}
+		}
+		if len(appointments) < hl7ExportBatchSize {
+			return nil
+		}
+	}
+}
+
+// exportPrescriptions 処方の新規（NW）・変更（XO）・取り消し（CA）
+// 確認日時等の処方内容以外の更新では送信しない
+func (s *HL7Service) exportPrescriptions(destination *models.HL7Destination, sender hl7.Sender, before time.Time) error {
+	for {
+		prescriptions, err := s.hl7Repo.FindChangedPrescriptions(destination.PrescriptionsSyncedAt, destination.PrescriptionsSyncedID, before, hl7ExportBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, prescription := range prescriptions {
+			changedAt := prescription.UpdatedAt
+			if prescription.DeletedAt.Valid && prescription.DeletedAt.Time.After(changedAt) {
+				changedAt = prescription.DeletedAt.Time
+			}
+			err := s.deliver(destination, sender, repositories.HL7EntityPrescriptions, prescription.ID, changedAt,
+				func(header hl7.Header, sent bool) (string, string, string, error) {
+					control := hl7.OrderNew
+					switch {
+					case prescription.DeletedAt.Valid:
+						if !sent {
+							return "", "", "", nil
+						}
+						control = hl7.OrderCancel
+					case sent:
+						control = hl7.OrderChange
+					}
+
+					var items []PrescriptionItem
+					if err := json.Unmarshal([]byte(prescription.ItemsJSON), &items); err != nil {
+						return "", "", "", fmt.Errorf("invalid items of prescription %d: %v", prescription.ID, err)
+					}
+					order := hl7.Order{
+						Control:   control,
+						PlacerID:  fmt.Sprintf("RX%d", prescription.ID),
+						OrderedAt: prescription.CreatedAt,
+						Notes:     prescription.Notes,
+					}
+					order.DoctorID, order.DoctorFamily, order.DoctorGiven = hl7Doctor(&prescription.Appointment.Doctor)
+					for _, item := range items {
+						order.Items = append(order.Items, hl7.OrderItem{
+							Medication:   item.MedicationName,
+							Dosage:       item.Dosage,
+							Frequency:    item.Frequency,
+							Duration:     item.Duration,
+							Instructions: item.Instructions,
+						})
+					}
+
+					patient, kin := hl7PatientForAppointment(&prescription.Appointment)
+					message, err := hl7.BuildORM(header, patient, kin, hl7VisitForAppointment(&prescription.Appointment), order)
+					if err != nil {
+						return "", "", "", err
+					}
+					fingerprint := fmt.Sprintf("%t\r%s\r%s", prescription.DeletedAt.Valid, prescription.ItemsJSON, prescription.Notes)
+					return "ORM^O01", message, fingerprint, nil
+				})
+			if err != nil {
+				return err
+			}
+		}
+		if len(prescriptions) < hl7ExportBatchSize {
+			return nil
+		}
+	}
+}
+
+// hl7Builder メッセージの作成（sent: 同じ対象を送信済みか。メッセージが空の場合は送信しない）
+// fingerprintは前回の送信と同じ内容かどうかの判定に使い、一致する場合は送信しない
+type hl7Builder func(header hl7.Header, sent bool) (messageType, message, fingerprint string, err error)
+
+// deliver 1件の送信と記録（送信しない場合も送信済みの位置は進める）
+func (s *HL7Service) deliver(destination *models.HL7Destination, sender hl7.Sender, entity string, entityID uint, changedAt time.Time, build hl7Builder) error {
+	last, err := s.hl7Repo.FindLastMessage(destination.ID, entity, entityID)
+	if err != nil {
+		return err
+	}
+	controlID, err := generateHL7ControlID()
+	if err != nil {
+		return err
+	}
+
+	header := hl7.Header{
+		SendingApplication:   destination.SendingApplication,
+		SendingFacility:      destination.SendingFacility,
+		ReceivingApplication: destination.ReceivingApplication,
+		ReceivingFacility:    destination.ReceivingFacility,
+		ProcessingID:         destination.ProcessingID,
+		ControlID:            controlID,
+		At:                   time.Now(),
+	}
+	messageType, message, fingerprint, err := build(header, last != nil)
+	if err != nil {
+		return fmt.Errorf("%s %d: %v", entity, entityID, err)
+	}
+
+	hash := sha256.Sum256([]byte(fingerprint))
+	contentHash := hex.EncodeToString(hash[:])
+	if message == "" || (last != nil && last.ContentHash == contentHash) {
+		if err := s.hl7Repo.AdvanceCursor(destination.ID, entity, changedAt, entityID); err != nil {
+			return err
+		}
+		setHL7Cursor(destination, entity, changedAt, entityID)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	if err := sender.Send(ctx, controlID, message); err != nil {
+		return fmt.Errorf("%s %s %d: %v", messageType, entity, entityID, err)
+	}
+
+	record := &models.HL7Message{
+		DestinationID: destination.ID,
+		Entity:        entity,
+		EntityID:      entityID,
+		MessageType:   messageType,
+		ControlID:     controlID,
+		ContentHash:   contentHash,
+		CreatedAt:     header.At,
+	}
+	if err := s.hl7Repo.RecordSent(record, entity, changedAt); err != nil {
+		return err
+	}
+	setHL7Cursor(destination, entity, changedAt, entityID)
+	return nil
+}
+
+func setHL7Cursor(destination *models.HL7Destination, entity string, at time.Time, entityID uint) {
+	switch entity {
+	case repositories.HL7EntityPatients:
+		destination.PatientsSyncedAt, destination.PatientsSyncedID = at, entityID
+	case repositories.HL7EntityAppointments:
+		destination.AppointmentsSyncedAt, destination.AppointmentsSyncedID = at, entityID
+	case repositories.HL7EntityPrescriptions:
+		destination.PrescriptionsSyncedAt, destination.PrescriptionsSyncedID = at, entityID
+	}
+}
+
+func resetHL7Cursors(destination *models.HL7Destination, from time.Time) {
+	for _, entity := range []string{repositories.HL7EntityPatients, repositories.HL7EntityAppointments, repositories.HL7EntityPrescriptions} {
+		setHL7Cursor(destination, entity, from, 0)
+	}
+}
+
+// applyHL7DestinationRequest 連携先の設定の検証と反映
+func applyHL7DestinationRequest(destination *models.HL7Destination, req HL7DestinationRequest) error {
+	switch req.Transport {
+	case "mllp":
+		if strings.TrimSpace(req.Host) == "" || req.Port < 1 || req.Port > 65535 {
+			return errors.New("host and a valid port are required for mllp")
+		}
+		req.Directory = ""
+	case "file":
+		if !hl7DirectoryPattern.MatchString(req.Directory) {
+			return errors.New("directory must consist of letters, digits, '-' and '_'")
+		}
+		req.Host, req.Port, req.UseTLS = "", 0, false
+	default:
+		return errors.New("transport must be mllp or file")
+	}
+
+	processingID := req.ProcessingID
+	if processingID == "" {
+		processingID = "P"
+	}
+	if processingID != "P" && processingID != "T" {
+		return errors.New("processing_id must be P or T")
+	}
+
+	var types []string
+	for _, messageType := range req.MessageTypes {
+		messageType = strings.ToUpper(strings.TrimSpace(messageType))
+		if !slices.Contains(hl7MessageTypes, messageType) {
+			return fmt.Errorf("unsupported message type: %s", messageType)
+		}
+		if !slices.Contains(types, messageType) {
+			types = append(types, messageType)
+		}
+	}
+
+	destination.Name = strings.TrimSpace(req.Name)
+	destination.Transport = req.Transport
+	destination.Host = strings.TrimSpace(req.Host)
+	destination.Port = req.Port
+	destination.UseTLS = req.UseTLS
+	destination.Directory = req.Directory
+	destination.SendingApplication = strings.TrimSpace(req.SendingApplication)
+	destination.SendingFacility = strings.TrimSpace(req.SendingFacility)
+	destination.ReceivingApplication = strings.TrimSpace(req.ReceivingApplication)
+	destination.ReceivingFacility = strings.TrimSpace(req.ReceivingFacility)
+	destination.ProcessingID = processingID
+	destination.MessageTypes = strings.Join(types, ",")
+	destination.Enabled = req.Enabled == nil || *req.Enabled
+	return nil
+}
+
+// hl7PatientFromProfile 患者の送信内容（患者番号はユーザーID）
+func hl7PatientFromProfile(userID uint, profile *models.PatientProfile) hl7.Patient {
+	patient := hl7.Patient{ID: fmt.Sprintf("%d", userID), Sex: "U"}
+	if profile != nil {
+		patient.FamilyName, patient.GivenName = hl7.SplitName(profile.Name)
+		patient.Birthdate = profile.Birthdate
+		patient.Phone = profile.Phone
+		patient.Address = profile.Address
+	}
+	return patient
+}
+
+// hl7PatientForAppointment 予約の患者（家族の代理予約の場合は家族を患者、予約者を保護者として送信する）
+// 家族の患者番号はアカウントの患者と重ならないよう D を付ける
+func hl7PatientForAppointment(appointment *models.Appointment) (hl7.Patient, *hl7.NextOfKin) {
+	account := hl7PatientFromProfile(appointment.PatientID, appointment.Patient.PatientProfile)
+	if appointment.Dependent == nil {
+		return account, nil
+	}
+
+	dependent := appointment.Dependent
+	patient := hl7.Patient{
+		ID:        fmt.Sprintf("D%d", dependent.ID),
+		Birthdate: dependent.Birthdate,
+		Sex:       hl7Sex(dependent.Gender),
+		Phone:     account.Phone,
+		Address:   account.Address,
+	}
+	patient.FamilyName, patient.GivenName = hl7.SplitName(dependent.Name)
+	kin := &hl7.NextOfKin{
+		FamilyName:   account.FamilyName,
+		GivenName:    account.GivenName,
+		Relationship: hl7GuardianRelationship(dependent.Relationship),
+		Phone:        account.Phone,
+	}
+	return patient, kin
+}
+
+// hl7VisitForAppointment 予約の診療（枠を選ばない即時・非同期の診療は予約の作成日時を開始とする）
+func hl7VisitForAppointment(appointment *models.Appointment) *hl7.Visit {
+	visit := &hl7.Visit{
+		ID:    fmt.Sprintf("A%d", appointment.ID),
+		Start: appointment.CreatedAt,
+	}
+	visit.DoctorID, visit.DoctorFamily, visit.DoctorGiven = hl7Doctor(&appointment.Doctor)
+	if appointment.Slot != nil {
+		visit.Start = appointment.Slot.StartTime
+		if appointment.Status == "completed" {
+			end := appointment.Slot.EndTime
+			visit.End = &end
+		}
+	}
+	return visit
+}
+
+func hl7Doctor(doctor *models.User) (id, family, given string) {
+	id = fmt.Sprintf("%d", doctor.ID)
+	if doctor.DoctorProfile != nil {
+		family, given = hl7.SplitName(doctor.DoctorProfile.Name)
+	}
+	return id, family, given
+}
+
+// hl7Sex 性別（自由入力）のHL7の値への変換
+func hl7Sex(gender string) string {
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "":
+		return "U"
+	case "m", "male", "男", "男性":
+		return "M"
+	case "f", "female", "女", "女性":
+		return "F"
+	default:
+		return "O"
+	}
+}
+
+// hl7GuardianRelationship 家族の続柄（予約者から見た家族）から、患者から見た保護者の続柄への変換
+func hl7GuardianRelationship(relationship string) string {
+	switch relationship {
+	case "child":
+		return "PAR"
+	case "parent":
+		return "CHD"
+	case "spouse":
+		return "SPO"
+	default:
+		return "OTH"
+	}
+}
+
+// generateHL7ControlID メッセージの制御番号（MSH-10、20文字以内）
+func generateHL7ControlID() (string, error) {
+	bytes := make([]byte, 10)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+func (s *HL7Service) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+type InterpreterService struct {
+	interpreterRepo repositories.InterpreterRepository
+	appointmentRepo repositories.AppointmentRepository
+	userRepo        repositories.UserRepository
+}
+
+type InterpreterProfileRequest struct {
+	Name      *string  `json:"name"`
+	Languages []string `json:"languages"`
+	Bio       *string  `json:"bio"`
+}
+
+type CreateInterpreterSlotRequest struct {
+	StartTime string `json:"start_time" binding:"required"`
+	EndTime   string `json:"end_time" binding:"required"`
+}
+
+func NewInterpreterService(interpreterRepo repositories.InterpreterRepository, appointmentRepo repositories.AppointmentRepository, userRepo repositories.UserRepository) *InterpreterService {
+	return &InterpreterService{
+		interpreterRepo: interpreterRepo,
+		appointmentRepo: appointmentRepo,
+		userRepo:        userRepo,
+	}
+}
+
+// GetInterpreters 通訳者一覧の取得（言語で絞り込み可能）
+func (s *InterpreterService) GetInterpreters(language string) ([]models.InterpreterProfile, error) {
+	return s.interpreterRepo.FindProfiles(normalizeLanguageCode(language))
+}
+
+// GetProfile 通訳者プロフィールの取得
+func (s *InterpreterService) GetProfile(interpreterID uint) (*models.InterpreterProfile, error) {
+	if err := s.requireInterpreter(interpreterID); err != nil {
+		return nil, err
+	}
+
+	profile, err := s.userRepo.FindInterpreterProfileByUserID(interpreterID)
+	if err != nil || profile == nil {
+		return nil, errors.New("profile not found")
+	}
+	return profile, nil
+}
+
+// UpdateProfile 通訳者プロフィールの更新
+func (s *InterpreterService) UpdateProfile(interpreterID uint, req InterpreterProfileRequest) (*models.InterpreterProfile, error) {
+	profile, err := s.GetProfile(interpreterID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		profile.Name = *req.Name
+	}
+	if req.Languages != nil {
+		languages := JoinLanguageCodes(req.Languages)
+		if languages == "" {
+			return nil, errors.New("at least one language is required")
+		}
+		profile.Languages = languages
+	}
+	if req.Bio != nil {
+		profile.Bio = *req.Bio
+	}
+
+	if err := s.userRepo.UpdateInterpreterProfile(profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// CreateSlot 対応可能枠の作成
+func (s *InterpreterService) CreateSlot(interpreterID uint, req CreateInterpreterSlotRequest) (*models.InterpreterSlot, error) {
+	if err := s.requireInterpreter(interpreterID); err != nil {
+		return nil, err
+	}
+
+	startTime, err := time.Parse(time.RFC3339, req.StartTime)
+	if err != nil {
+		return nil, errors.New("invalid start time format")
+	}
+
+	endTime, err := time.Parse(time.RFC3339, req.EndTime)
+	if err != nil {
+		return nil, errors.New("invalid end time format")
+	}
+
+	if startTime.Before(time.Now()) {
+		return nil, errors.New("start time cannot be in the past")
+	}
+
+	if !startTime.Before(endTime) {
+		return nil, errors.New("start time must be before end time")
+	}
+
+	slot := &models.InterpreterSlot{
+		InterpreterID: interpreterID,
+		StartTime:     startTime,
+		EndTime:       endTime,
+	}
+	if err := s.interpreterRepo.CreateSlot(slot); err != nil {
+		return nil, err
+	}
+	return slot, nil
+}
+
+// GetSlots 対応可能枠一覧の取得
+func (s *InterpreterService) GetSlots(interpreterID uint) ([]models.InterpreterSlot, error) {
+	if err := s.requireInterpreter(interpreterID); err != nil {
+		return nil, err
+	}
+	return s.interpreterRepo.FindSlotsByInterpreterID(interpreterID)
+}
+
+// DeleteSlot 対応可能枠の削除（未割り当ての枠のみ）
+func (s *InterpreterService) DeleteSlot(slotID, interpreterID uint) error {
+	slot, err := s.interpreterRepo.FindSlotByID(slotID)
+	if err != nil || slot == nil {
+		return errors.New("slot not found")
+	}
+
+	if slot.InterpreterID != interpreterID {
+		return errors.New("unauthorized to delete this slot")
+	}
+
+	if slot.AppointmentID != nil {
+		return errors.New("cannot delete slot assigned to an appointment")
+	}
+
+	return s.interpreterRepo.DeleteSlot(slotID)
+}
+
+// GetAssignedAppointments 割り当てられた予約一覧の取得
+func (s *InterpreterService) GetAssignedAppointments(interpreterID uint, limit, offset int) ([]models.Appointment, int64, error) {
+	if err := s.requireInterpreter(interpreterID); err != nil {
+		return nil, 0, err
+	}
+	return s.appointmentRepo.FindPageByInterpreterID(interpreterID, limit, offset)
+}
+
+func (s *InterpreterService) requireInterpreter(userID uint) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return errors.New("user not found")
+	}
+	if user.Role != "interpreter" {
+		return errors.New("only interpreters can perform this action")
+	}
+	return nil
+}
+
+// normalizeLanguageCode 言語コードの正規化（小文字化・前後の空白除去）
+func normalizeLanguageCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+// HasLanguageCode カンマ区切りの言語コードに指定言語が含まれるか
+func HasLanguageCode(languages, code string) bool {
+	code = normalizeLanguageCode(code)
+	for _, l := range strings.Split(languages, ",") {
+		if l == code {
+			return true
+		}
+	}
+	return false
+}
+
+// JoinLanguageCodes 言語コードを正規化し、重複を除いてカンマ区切りにする
+func JoinLanguageCodes(codes []string) string {
+	seen := make(map[string]bool, len(codes))
+	normalized := make([]string, 0, len(codes))
+	for _, code := range codes {
+		code = normalizeLanguageCode(code)
+		if code == "" || strings.Contains(code, ",") || seen[code] {
+			continue
+		}
+		seen[code] = true
+		normalized = append(normalized, code)
+	}
+	return strings.Join(normalized, ",")
+}
@@ -0,0 +1,378 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 勘定科目（医師・患者ごとの勘定はコードの末尾にユーザーIDを付ける）
+const (
+	ledgerPaymentClearing = "assets:payment_clearing"       // 決済代行サービスが預かっている入金
+	ledgerDoctorPayable   = "liabilities:doctor_payable:%d" // 医師への未払いの診療費
+	ledgerPatientWallet   = "liabilities:patient_wallet:%d" // 患者のウォレットの残高（次回以降の支払いに充当する）
+	ledgerWalletCredits   = "expenses:wallet_credits"       // 患者へのウォレットの付与（お詫び・キャンペーン等）
+)
+
+// LedgerService 全ての入出金を記録する複式簿記の元帳
+// 支払い・返金・ウォレットの付与・医師への支払いは全て借方・貸方の合計が一致する仕訳として記帳し、
+// 記帳のたびに不変条件を確認するため、元帳から作成した財務の集計は常に整合する
+type LedgerService struct {
+	ledgerRepo   repositories.LedgerRepository
+	invoiceRepo  repositories.InvoiceRepository
+	userRepo     repositories.UserRepository
+	auditService *AuditService
+	currency     string
+}
+
+// RecordRefundRequest 返金の記録（返金は管理者が決済代行サービスで行い、その結果を記録する）
+type RecordRefundRequest struct {
+	InvoiceID         uint   `json:"invoice_id" binding:"required"`
+	Amount            int64  `json:"amount" binding:"required"`
+	ProviderReference string `json:"provider_reference" binding:"required"` // 決済代行サービスの返金のID
+	Reason            string `json:"reason"`
+}
+
+// RecordWalletCreditRequest 患者のウォレットへの付与
+type RecordWalletCreditRequest struct {
+	PatientID uint   `json:"patient_id" binding:"required"`
+	Amount    int64  `json:"amount" binding:"required"`
+	Currency  string `json:"currency"`                     // 省略時は決済通貨
+	Reference string `json:"reference" binding:"required"` // 二重の付与を防ぐ管理者の指定するキー
+	Reason    string `json:"reason"`
+}
+
+// RecordPayoutRequest 医師への支払いの記録（振込は管理者が行い、その結果を記録する）
+type RecordPayoutRequest struct {
+	DoctorID          uint   `json:"doctor_id" binding:"required"`
+	Amount            int64  `json:"amount" binding:"required"`
+	Currency          string `json:"currency"`                              // 省略時は決済通貨
+	ProviderReference string `json:"provider_reference" binding:"required"` // 振込のID
+}
+
+// LedgerReport 元帳の整合性の確認結果
+type LedgerReport struct {
+	TrialBalance       []repositories.LedgerTrialBalance `json:"trial_balance"`
+	UnbalancedEntries  []uint                            `json:"unbalanced_entries"`
+	MismatchedAccounts []uint                            `json:"mismatched_accounts"`
+	Consistent         bool                              `json:"consistent"`
+	CheckedAt          time.Time                         `json:"checked_at"`
+}
+
+func NewLedgerService(ledgerRepo repositories.LedgerRepository, invoiceRepo repositories.InvoiceRepository, userRepo repositories.UserRepository, auditService *AuditService, currency string) *LedgerService {
+	return &LedgerService{
+		ledgerRepo:   ledgerRepo,
+		invoiceRepo:  invoiceRepo,
+		userRepo:     userRepo,
+		auditService: auditService,
+		currency:     strings.ToLower(currency),
+	}
+}
+
+// RecordPayment 完了した支払いの記帳（Webhookの再送で呼ばれても1回のみ記帳する）
+// 請求を支払った支払いは医師への未払い、支払い済みの請求への二重の支払い・無効の請求への支払いは患者のウォレットに計上する
+func (s *LedgerService) RecordPayment(invoice *models.Invoice, payment *models.Payment) error {
+	paid, err := s.ledgerRepo.FindEntryByReference(fmt.Sprintf("invoice:%d", invoice.ID))
+	if err != nil {
+		return err
+	}
+	if paid == nil && invoice.Status == "paid" {
+		entry, err := s.paymentEntry(fmt.Sprintf("invoice:%d", invoice.ID), invoice, payment, fmt.Sprintf(ledgerDoctorPayable, invoice.DoctorID), models.LedgerLiability, invoice.DoctorID)
+		if err != nil {
+			return err
+		}
+		posted, err := s.ledgerRepo.Post(entry)
+		if err != nil || posted {
+			return err
+		}
+		// 同時に記帳された場合は記帳済みの仕訳で判断する
+		if paid, err = s.ledgerRepo.FindEntryByReference(fmt.Sprintf("invoice:%d", invoice.ID)); err != nil || paid == nil {
+			return err
+		}
+	}
+	if paid != nil && paid.PaymentID != nil && *paid.PaymentID == payment.ID {
+		return nil
+	}
+
+	entry, err := s.paymentEntry(fmt.Sprintf("payment:%d", payment.ID), invoice, payment, fmt.Sprintf(ledgerPatientWallet, invoice.PatientID), models.LedgerLiability, invoice.PatientID)
+	if err != nil {
+		return err
+	}
+	entry.Description = "請求の支払いに充当しなかった支払い（患者のウォレットに計上）"
+	_, err = s.ledgerRepo.Post(entry)
+	return err
+}
+
+// RecordRefund 返金の記帳（支払い済みの請求のみ、返金の合計は請求額と完了した支払いの合計まで、医師への未払いから差し引く）
+// 医師への未払いは負にできない勘定のため、医師への支払い済みで未払いの残高を超える返金は記帳時に拒否する
+func (s *LedgerService) RecordRefund(adminID uint, req RecordRefundRequest) (*models.JournalEntry, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	if req.Amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	invoice, err := s.invoiceRepo.FindByID(req.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice == nil {
+		return nil, errors.New("invoice not found")
+	}
+	if invoice.Status != "paid" {
+		return nil, errors.New("invoice is not paid")
+	}
+
+	payable, err := s.account(fmt.Sprintf(ledgerDoctorPayable, invoice.DoctorID), invoice.Currency, models.LedgerLiability, &invoice.DoctorID)
+	if err != nil {
+		return nil, err
+	}
+	clearing, err := s.account(ledgerPaymentClearing, invoice.Currency, models.LedgerAsset, nil)
+	if err != nil {
+		return nil, err
+	}
+	refunded, err := s.ledgerRepo.SumLinesByInvoice(invoice.ID, models.JournalRefund, payable.ID)
+	if err != nil {
+		return nil, err
+	}
+	// 確認待ちの支払いは返金できる金額に含めない
+	refundable := invoice.Amount
+	if paid := invoice.PaidAmount(); paid < refundable {
+		refundable = paid
+	}
+	if refunded+req.Amount > refundable {
+		return nil, fmt.Errorf("refund exceeds the refundable amount of %d", refundable-refunded)
+	}
+
+	entry := &models.JournalEntry{
+		Reference:   "refund:" + strings.TrimSpace(req.ProviderReference),
+		Kind:        models.JournalRefund,
+		Currency:    invoice.Currency,
+		Description: strings.TrimSpace(req.Reason),
+		InvoiceID:   &invoice.ID,
+		CreatedByID: &adminID,
+		PostedAt:    time.Now(),
+		Lines: []models.JournalLine{
+			{AccountID: payable.ID, Debit: req.Amount},
+			{AccountID: clearing.ID, Credit: req.Amount},
+		},
+	}
+	return s.postByAdmin(adminID, entry, "ledger_refund_recorded")
+}
+
+// RecordWalletCredit 患者のウォレットへの付与の記帳
+func (s *LedgerService) RecordWalletCredit(adminID uint, req RecordWalletCreditRequest) (*models.JournalEntry, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	if req.Amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	patient, err := s.userRepo.FindByID(req.PatientID)
+	if err != nil || patient == nil || patient.Role != policy.RolePatient {
+		return nil, errors.New("patient not found")
+	}
+	currency := s.entryCurrency(req.Currency)
+
+	wallet, err := s.account(fmt.Sprintf(ledgerPatientWallet, patient.ID), currency, models.LedgerLiability, &patient.ID)
+	if err != nil {
+		return nil, err
+	}
+	expense, err := s.account(ledgerWalletCredits, currency, models.LedgerExpense, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.JournalEntry{
+		Reference:   "wallet_credit:" + strings.TrimSpace(req.Reference),
+		Kind:        models.JournalWalletCredit,
+		Currency:    currency,
+		Description: strings.TrimSpace(req.Reason),
+		CreatedByID: &adminID,
+		PostedAt:    time.Now(),
+		Lines: []models.JournalLine{
+			{AccountID: expense.ID, Debit: req.Amount},
+			{AccountID: wallet.ID, Credit: req.Amount},
+		},
+	}
+	return s.postByAdmin(adminID, entry, "ledger_wallet_credit_recorded")
+}
+
+// RecordPayout 医師への支払いの記帳（医師への未払いの残高まで、負にできない勘定として記帳時に確認する）
+func (s *LedgerService) RecordPayout(adminID uint, req RecordPayoutRequest) (*models.JournalEntry, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	if req.Amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	doctor, err := s.userRepo.FindByID(req.DoctorID)
+	if err != nil || doctor == nil || doctor.Role != policy.RoleDoctor {
+		return nil, errors.New("doctor not found")
+	}
+	currency := s.entryCurrency(req.Currency)
+
+	payable, err := s.account(fmt.Sprintf(ledgerDoctorPayable, doctor.ID), currency, models.LedgerLiability, &doctor.ID)
+	if err != nil {
+		return nil, err
+	}
+	clearing, err := s.account(ledgerPaymentClearing, currency, models.LedgerAsset, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.JournalEntry{
+		Reference:   "payout:" + strings.TrimSpace(req.ProviderReference),
+		Kind:        models.JournalPayout,
+		Currency:    currency,
+		Description: fmt.Sprintf("医師への支払い（%d）", doctor.ID),
+		CreatedByID: &adminID,
+		PostedAt:    time.Now(),
+		Lines: []models.JournalLine{
+			{AccountID: payable.ID, Debit: req.Amount},
+			{AccountID: clearing.ID, Credit: req.Amount},
+		},
+	}
+	return s.postByAdmin(adminID, entry, "ledger_payout_recorded")
+}
+
+// GetAccounts 勘定の一覧（管理者用、?type= で種類を指定）
+func (s *LedgerService) GetAccounts(adminID uint, accountType string) ([]models.LedgerAccount, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	if accountType != "" && !isLedgerAccountType(accountType) {
+		return nil, errors.New("invalid account type")
+	}
+	return s.ledgerRepo.FindAccounts(accountType, nil)
+}
+
+// GetEntries 仕訳の一覧（管理者用、種類・勘定で絞り込み）
+func (s *LedgerService) GetEntries(adminID uint, kind string, accountID *uint, limit, offset int) ([]models.JournalEntry, int64, error) {
+	if !s.isAdmin(adminID) {
+		return nil, 0, errors.New("unauthorized: admin access required")
+	}
+	if kind != "" && kind != models.JournalPayment && kind != models.JournalRefund && kind != models.JournalWalletCredit && kind != models.JournalPayout {
+		return nil, 0, errors.New("invalid journal entry kind")
+	}
+	return s.ledgerRepo.FindEntries(kind, accountID, limit, offset)
+}
+
+// GetReport 元帳の整合性の確認（通貨ごとの借方・貸方の合計、不均衡な仕訳、残高が明細と一致しない勘定）
+func (s *LedgerService) GetReport(adminID uint) (*LedgerReport, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	trialBalance, err := s.ledgerRepo.TrialBalance()
+	if err != nil {
+		return nil, err
+	}
+	unbalanced, err := s.ledgerRepo.FindUnbalancedEntryIDs()
+	if err != nil {
+		return nil, err
+	}
+	mismatched, err := s.ledgerRepo.FindMismatchedAccountIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &LedgerReport{
+		TrialBalance:       trialBalance,
+		UnbalancedEntries:  unbalanced,
+		MismatchedAccounts: mismatched,
+		Consistent:         len(unbalanced) == 0 && len(mismatched) == 0,
+		CheckedAt:          time.Now(),
+	}
+	for _, row := range trialBalance {
+		if row.Debits != row.Credits {
+			report.Consistent = false
+		}
+	}
+	return report, nil
+}
+
+// paymentEntry 支払いの仕訳（決済代行サービスの預かりを借方、指定した勘定を貸方に計上する）
+func (s *LedgerService) paymentEntry(reference string, invoice *models.Invoice, payment *models.Payment, creditCode, creditType string, ownerID uint) (*models.JournalEntry, error) {
+	clearing, err := s.account(ledgerPaymentClearing, payment.Currency, models.LedgerAsset, nil)
+	if err != nil {
+		return nil, err
+	}
+	credit, err := s.account(creditCode, payment.Currency, creditType, &ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.JournalEntry{
+		Reference:   reference,
+		Kind:        models.JournalPayment,
+		Currency:    payment.Currency,
+		Description: invoice.Description,
+		InvoiceID:   &invoice.ID,
+		PaymentID:   &payment.ID,
+		PostedAt:    time.Now(),
+		Lines: []models.JournalLine{
+			{AccountID: clearing.ID, Debit: payment.Amount},
+			{AccountID: credit.ID, Credit: payment.Amount},
+		},
+	}, nil
+}
+
+// postByAdmin 管理者の記録した仕訳の記帳と監査ログの記録（同じ参照の仕訳は記帳済みとして拒否する）
+func (s *LedgerService) postByAdmin(adminID uint, entry *models.JournalEntry, action string) (*models.JournalEntry, error) {
+	if entry.Reference == "" || strings.HasSuffix(entry.Reference, ":") {
+		return nil, errors.New("reference is required")
+	}
+	posted, err := s.ledgerRepo.Post(entry)
+	if errors.Is(err, repositories.ErrInsufficientBalance) {
+		return nil, errors.New("insufficient balance")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !posted {
+		return nil, errors.New("journal entry has already been recorded")
+	}
+
+	s.auditService.LogUserAction(adminID, action, "journal_entry", fmt.Sprintf("%d", entry.ID), map[string]interface{}{
+		"reference": entry.Reference,
+		"currency":  entry.Currency,
+		"amount":    entry.Lines[0].Debit,
+	})
+	return entry, nil
+}
+
+// account 勘定の取得（初めて使う場合は作成する、負債・資産の勘定は負にできない）
+func (s *LedgerService) account(code, currency, accountType string, ownerID *uint) (*models.LedgerAccount, error) {
+	return s.ledgerRepo.FindOrCreateAccount(&models.LedgerAccount{
+		Code:        code,
+		Currency:    currency,
+		Type:        accountType,
+		OwnerID:     ownerID,
+		NonNegative: accountType == models.LedgerAsset || accountType == models.LedgerLiability,
+	})
+}
+
+func (s *LedgerService) entryCurrency(currency string) string {
+	if currency = strings.ToLower(strings.TrimSpace(currency)); currency != "" {
+		return currency
+	}
+	return s.currency
+}
+
+func (s *LedgerService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
+
+func isLedgerAccountType(accountType string) bool {
+	switch accountType {
+	case models.LedgerAsset, models.LedgerLiability, models.LedgerEquity, models.LedgerRevenue, models.LedgerExpense:
+		return true
+	}
+	return false
+}
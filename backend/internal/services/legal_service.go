@@ -0,0 +1,216 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 公開中の文書のキャッシュ有効期間（公開予約の反映もこの間隔で行われる）
+const legalDocumentCacheTTL = time.Minute
+
+type LegalService struct {
+	legalRepo    repositories.LegalRepository
+	userRepo     repositories.UserRepository
+	auditService *AuditService
+
+	mu        sync.RWMutex
+	current   []models.LegalDocument
+	expiresAt time.Time
+}
+
+type CreateLegalDocumentRequest struct {
+	DocType     string     `json:"doc_type" binding:"required,oneof=terms privacy"`
+	Version     string     `json:"version" binding:"required"`
+	Title       string     `json:"title" binding:"required"`
+	Content     string     `json:"content" binding:"required"`
+	PublishedAt *time.Time `json:"published_at"` // 指定時は公開予約（過去の日時は即時公開）
+}
+
+type AcceptLegalDocumentsRequest struct {
+	DocumentIDs []uint `json:"document_ids" binding:"required,min=1"`
+}
+
+func NewLegalService(legalRepo repositories.LegalRepository, userRepo repositories.UserRepository, auditService *AuditService) *LegalService {
+	return &LegalService{
+		legalRepo:    legalRepo,
+		userRepo:     userRepo,
+		auditService: auditService,
+	}
+}
+
+// GetCurrentDocuments 現在公開中の文書（種別ごとの最新版）の取得
+func (s *LegalService) GetCurrentDocuments() ([]models.LegalDocument, error) {
+	s.mu.RLock()
+	if time.Now().Before(s.expiresAt) {
+		current := s.current
+		s.mu.RUnlock()
+		return current, nil
+	}
+	s.mu.RUnlock()
+
+	documents, err := s.legalRepo.FindCurrentDocuments()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.current = documents
+	s.expiresAt = time.Now().Add(legalDocumentCacheTTL)
+	s.mu.Unlock()
+
+	return documents, nil
+}
+
+// GetPendingDocuments ユーザーが未同意の公開中文書の取得
+func (s *LegalService) GetPendingDocuments(userID uint) ([]models.LegalDocument, error) {
+	current, err := s.GetCurrentDocuments()
+	if err != nil || len(current) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uint, len(current))
+	for i, document := range current {
+		ids[i] = document.ID
+	}
+
+	acceptedIDs, err := s.legalRepo.FindAcceptedDocumentIDs(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	accepted := make(map[uint]bool, len(acceptedIDs))
+	for _, id := range acceptedIDs {
+		accepted[id] = true
+	}
+
+	var pending []models.LegalDocument
+	for _, document := range current {
+		if !accepted[document.ID] {
+			pending = append(pending, document)
+		}
+	}
+	return pending, nil
+}
+
+// PendingLegalDocuments 未同意の文書の取得（同意確認ミドルウェア用）
+func (s *LegalService) PendingLegalDocuments(userID uint) (interface{}, bool, error) {
+	pending, err := s.GetPendingDocuments(userID)
+	if err != nil {
+		return nil, false, err
+	}
+	return pending, len(pending) > 0, nil
+}
+
+// AcceptDocuments 文書への同意の記録
+func (s *LegalService) AcceptDocuments(userID uint, req AcceptLegalDocumentsRequest, clientIP, userAgent string) ([]models.LegalDocument, error) {
+	now := time.Now()
+	for _, documentID := range req.DocumentIDs {
+		document, err := s.legalRepo.FindDocumentByID(documentID)
+		if err != nil || document == nil {
+			return nil, errors.New("document not found")
+		}
+		if document.PublishedAt == nil || document.PublishedAt.After(now) {
+			return nil, errors.New("document is not published")
+		}
+
+		acceptance := &models.LegalAcceptance{
+			UserID:     userID,
+			DocumentID: documentID,
+			AcceptedAt: now,
+			ClientIP:   clientIP,
+			UserAgent:  userAgent,
+		}
+		if err := s.legalRepo.CreateAcceptance(acceptance); err != nil {
+			return nil, err
+		}
+
+		s.auditService.LogUserAction(userID, "accept", "legal_document", fmt.Sprintf("%d", documentID), map[string]interface{}{
+			"doc_type": document.DocType,
+			"version":  document.Version,
+		})
+	}
+
+	return s.GetPendingDocuments(userID)
+}
+
+// GetAcceptances 同意履歴の取得
+func (s *LegalService) GetAcceptances(userID uint) ([]models.LegalAcceptance, error) {
+	return s.legalRepo.FindAcceptancesByUserID(userID)
+}
+
+// GetDocuments 文書一覧の取得（管理者用、未公開の文書を含む）
+func (s *LegalService) GetDocuments(docType string, userID uint) ([]models.LegalDocument, error) {
+	if err := s.requireAdmin(userID); err != nil {
+		return nil, err
+	}
+	return s.legalRepo.FindDocuments(docType)
+}
+
+// CreateDocument 新しいバージョンの文書の作成（管理者用）
+func (s *LegalService) CreateDocument(req CreateLegalDocumentRequest, userID uint) (*models.LegalDocument, error) {
+	if err := s.requireAdmin(userID); err != nil {
+		return nil, err
+	}
+
+	document := &models.LegalDocument{
+		DocType:     req.DocType,
+		Version:     req.Version,
+		Title:       req.Title,
+		Content:     req.Content,
+		PublishedAt: req.PublishedAt,
+	}
+	if err := s.legalRepo.CreateDocument(document); err != nil {
+		return nil, err
+	}
+
+	if document.PublishedAt != nil {
+		s.invalidateCache()
+	}
+	return document, nil
+}
+
+// PublishDocument 文書の即時公開（管理者用）
+// 公開後は全ユーザーに再同意が求められる
+func (s *LegalService) PublishDocument(documentID, userID uint) (*models.LegalDocument, error) {
+	if err := s.requireAdmin(userID); err != nil {
+		return nil, err
+	}
+
+	document, err := s.legalRepo.FindDocumentByID(documentID)
+	if err != nil || document == nil {
+		return nil, errors.New("document not found")
+	}
+	if document.PublishedAt != nil && !document.PublishedAt.After(time.Now()) {
+		return nil, errors.New("document is already published")
+	}
+
+	now := time.Now()
+	if err := s.legalRepo.PublishDocument(documentID, now); err != nil {
+		return nil, err
+	}
+	document.PublishedAt = &now
+	s.invalidateCache()
+
+	return document, nil
+}
+
+func (s *LegalService) invalidateCache() {
+	s.mu.Lock()
+	s.expiresAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func (s *LegalService) requireAdmin(userID uint) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return errors.New("user not found")
+	}
+	if user.Role != "admin" {
+		return errors.New("insufficient permissions")
+	}
+	return nil
+}
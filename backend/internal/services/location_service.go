@@ -0,0 +1,247 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 地図アプリでの経路案内のURL（Google Maps のURLはWeb・iOS・Androidのいずれでも開ける）
+const directionsBaseURL = "https://www.google.com/maps/dir/?api=1&destination="
+
+// LocationService 対面診療を行うクリニックの所在地と、所在地ごとの医師の管理
+type LocationService struct {
+	locationRepo repositories.LocationRepository
+	userRepo     repositories.UserRepository
+	auditService *AuditService
+}
+
+// SaveLocationRequest 所在地の登録・更新
+type SaveLocationRequest struct {
+	Name        string   `json:"name" binding:"required,max=200"`
+	PostalCode  string   `json:"postal_code" binding:"max=20"`
+	Address     string   `json:"address" binding:"required,max=500"`
+	Phone       string   `json:"phone" binding:"max=50"`
+	Latitude    *float64 `json:"latitude" binding:"omitempty,gte=-90,lte=90"`
+	Longitude   *float64 `json:"longitude" binding:"omitempty,gte=-180,lte=180"`
+	AccessNotes string   `json:"access_notes" binding:"max=2000"`
+	Active      *bool    `json:"active"` // 省略時は有効
+}
+
+// SetLocationDoctorsRequest 所在地で対面診療を行う医師（指定した医師で置き換える）
+type SetLocationDoctorsRequest struct {
+	DoctorIDs []uint `json:"doctor_ids" binding:"max=500"`
+}
+
+func NewLocationService(locationRepo repositories.LocationRepository, userRepo repositories.UserRepository, auditService *AuditService) *LocationService {
+	return &LocationService{
+		locationRepo: locationRepo,
+		userRepo:     userRepo,
+		auditService: auditService,
+	}
+}
+
+// GetLocations 予約に指定できる所在地の一覧（doctorIDが0以外の場合はその医師が対面診療を行う所在地のみ）
+func (s *LocationService) GetLocations(doctorID uint) ([]models.ClinicLocation, error) {
+	return s.locationRepo.FindAll(true, doctorID)
+}
+
+// GetAllLocations 停止中を含む所在地の一覧（管理者のみ）
+func (s *LocationService) GetAllLocations(adminID uint) ([]models.ClinicLocation, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	return s.locationRepo.FindAll(false, 0)
+}
+
+// CreateLocation 所在地の登録（管理者のみ）
+func (s *LocationService) CreateLocation(adminID uint, req SaveLocationRequest) (*models.ClinicLocation, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	location := &models.ClinicLocation{}
+	if err := applyLocationRequest(location, req); err != nil {
+		return nil, err
+	}
+	if err := s.locationRepo.Create(location); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(adminID, "clinic_location_created", "clinic_location", fmt.Sprintf("%d", location.ID), map[string]interface{}{
+		"name": location.Name,
+	})
+	return location, nil
+}
+
+// UpdateLocation 所在地の更新（管理者のみ、停止しても既存の予約の所在地は変えない）
+func (s *LocationService) UpdateLocation(adminID, locationID uint, req SaveLocationRequest) (*models.ClinicLocation, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	location, err := s.locationRepo.FindByID(locationID)
+	if err != nil {
+		return nil, err
+	}
+	if location == nil {
+		return nil, errors.New("location not found")
+	}
+
+	if err := applyLocationRequest(location, req); err != nil {
+		return nil, err
+	}
+	if err := s.locationRepo.Update(location); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(adminID, "clinic_location_updated", "clinic_location", fmt.Sprintf("%d", location.ID), map[string]interface{}{
+		"name":   location.Name,
+		"active": location.Active,
+	})
+	return location, nil
+}
+
+// GetLocationDoctors 所在地で対面診療を行う医師のID（管理者のみ）
+func (s *LocationService) GetLocationDoctors(adminID, locationID uint) ([]uint, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	location, err := s.locationRepo.FindByID(locationID)
+	if err != nil {
+		return nil, err
+	}
+	if location == nil {
+		return nil, errors.New("location not found")
+	}
+	return s.locationRepo.FindDoctorIDs(location.ID)
+}
+
+// SetLocationDoctors 所在地で対面診療を行う医師の設定（管理者のみ）
+func (s *LocationService) SetLocationDoctors(adminID, locationID uint, req SetLocationDoctorsRequest) ([]uint, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	location, err := s.locationRepo.FindByID(locationID)
+	if err != nil {
+		return nil, err
+	}
+	if location == nil {
+		return nil, errors.New("location not found")
+	}
+
+	doctorIDs := make([]uint, 0, len(req.DoctorIDs))
+	seen := make(map[uint]bool, len(req.DoctorIDs))
+	for _, doctorID := range req.DoctorIDs {
+		if seen[doctorID] {
+			continue
+		}
+		seen[doctorID] = true
+		doctorIDs = append(doctorIDs, doctorID)
+	}
+	doctors, err := s.userRepo.FindByIDs(doctorIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(doctors) != len(doctorIDs) {
+		return nil, errors.New("doctor not found")
+	}
+	for _, doctor := range doctors {
+		if doctor.Role != "doctor" {
+			return nil, fmt.Errorf("invalid doctor_ids: user %d is not a doctor", doctor.ID)
+		}
+	}
+
+	if err := s.locationRepo.ReplaceDoctors(location.ID, doctorIDs); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(adminID, "clinic_location_doctors_updated", "clinic_location", fmt.Sprintf("%d", location.ID), map[string]interface{}{
+		"doctor_ids": doctorIDs,
+	})
+	return doctorIDs, nil
+}
+
+// CheckPractice 予約に指定する所在地の確認（有効な所在地で、医師がそこで対面診療を行うこと）
+func (s *LocationService) CheckPractice(doctorID, locationID uint) (*models.ClinicLocation, error) {
+	location, err := s.locationRepo.FindByID(locationID)
+	if err != nil {
+		return nil, err
+	}
+	if location == nil || !location.Active {
+		return nil, errors.New("location not found")
+	}
+
+	practices, err := s.locationRepo.IsDoctorAt(doctorID, location.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !practices {
+		return nil, errors.New("doctor does not practice at this location")
+	}
+	return location, nil
+}
+
+// locationOf 対面診療の予約の所在地（読み込まれていない場合は取得し、オンライン診療はnil）
+func (s *LocationService) locationOf(appointment *models.Appointment) *models.ClinicLocation {
+	if appointment.LocationID == nil {
+		return nil
+	}
+	if appointment.Location != nil {
+		return appointment.Location
+	}
+	location, err := s.locationRepo.FindByID(*appointment.LocationID)
+	if err != nil {
+		return nil
+	}
+	return location
+}
+
+func (s *LocationService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
+
+// applyLocationRequest 所在地への入力の反映（経路案内のURLは所在地から生成する）
+func applyLocationRequest(location *models.ClinicLocation, req SaveLocationRequest) error {
+	name := strings.TrimSpace(req.Name)
+	address := strings.TrimSpace(req.Address)
+	if name == "" || address == "" {
+		return errors.New("invalid location: name and address are required")
+	}
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		return errors.New("invalid location: latitude and longitude must be specified together")
+	}
+
+	location.Name = name
+	location.PostalCode = strings.TrimSpace(req.PostalCode)
+	location.Address = address
+	location.Phone = strings.TrimSpace(req.Phone)
+	location.Latitude = req.Latitude
+	location.Longitude = req.Longitude
+	location.AccessNotes = strings.TrimSpace(req.AccessNotes)
+	location.Active = req.Active == nil || *req.Active
+	location.DirectionsURL = directionsURL(location)
+	return nil
+}
+
+// directionsURL 所在地への経路案内のURL（緯度・経度がある場合はそれを優先し、ない場合は住所で検索する）
+func directionsURL(location *models.ClinicLocation) string {
+	if location.Latitude != nil && location.Longitude != nil {
+		return directionsBaseURL + url.QueryEscape(fmt.Sprintf("%.6f,%.6f", *location.Latitude, *location.Longitude))
+	}
+	destination := location.Address
+	if location.PostalCode != "" {
+		destination = "〒" + location.PostalCode + " " + destination
+	}
+	return directionsBaseURL + url.QueryEscape(destination)
+}
+
+// locationLabel 通知・カレンダーに記載する所在地（名称と住所）
+func locationLabel(location *models.ClinicLocation) string {
+	return fmt.Sprintf("%s（%s）", location.Name, location.Address)
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+type MedicalRecordService struct {
+	recordRepo      repositories.MedicalRecordRepository
+	appointmentRepo repositories.AppointmentRepository
+	dependentRepo   repositories.DependentRepository
+	auditService    *AuditService
+}
+
+// MedicalRecordVitals バイタルサイン（未測定の項目は省略）
+type MedicalRecordVitals struct {
+	BodyTemperature  *float64 `json:"body_temperature,omitempty" binding:"omitempty,gte=30,lte=45"`   // 体温（℃）
+	SystolicBP       *int     `json:"systolic_bp,omitempty" binding:"omitempty,gte=40,lte=300"`       // 収縮期血圧（mmHg）
+	DiastolicBP      *int     `json:"diastolic_bp,omitempty" binding:"omitempty,gte=20,lte=200"`      // 拡張期血圧（mmHg）
+	HeartRate        *int     `json:"heart_rate,omitempty" binding:"omitempty,gte=20,lte=300"`        // 脈拍（回/分）
+	RespiratoryRate  *int     `json:"respiratory_rate,omitempty" binding:"omitempty,gte=4,lte=80"`    // 呼吸数（回/分）
+	OxygenSaturation *int     `json:"oxygen_saturation,omitempty" binding:"omitempty,gte=50,lte=100"` // SpO2（%）
+	HeightCm         *float64 `json:"height_cm,omitempty" binding:"omitempty,gt=0,lte=300"`
+	WeightKg         *float64 `json:"weight_kg,omitempty" binding:"omitempty,gt=0,lte=500"`
+}
+
+// SaveMedicalRecordRequest 診療録の記載（予約の診療録を丸ごと置き換える）
+type SaveMedicalRecordRequest struct {
+	Diagnosis    string              `json:"diagnosis" binding:"max=5000"`
+	Symptoms     string              `json:"symptoms" binding:"max=5000"`
+	Vitals       MedicalRecordVitals `json:"vitals"`
+	Allergies    []string            `json:"allergies" binding:"max=50,dive,max=200"`
+	VisitSummary string              `json:"visit_summary" binding:"max=10000"`
+}
+
+func NewMedicalRecordService(recordRepo repositories.MedicalRecordRepository, appointmentRepo repositories.AppointmentRepository, dependentRepo repositories.DependentRepository, auditService *AuditService) *MedicalRecordService {
+	return &MedicalRecordService{
+		recordRepo:      recordRepo,
+		appointmentRepo: appointmentRepo,
+		dependentRepo:   dependentRepo,
+		auditService:    auditService,
+	}
+}
+
+// SaveRecord 予約の診療録の記載・更新（担当医師のみ、確定済み・完了の予約）
+func (s *MedicalRecordService) SaveRecord(appointmentID, doctorID uint, req SaveMedicalRecordRequest) (*models.MedicalRecord, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.DoctorID != doctorID {
+		return nil, errors.New("unauthorized to write the medical record for this appointment")
+	}
+	if appointment.Status != "confirmed" && appointment.Status != "completed" {
+		return nil, errors.New("medical records can only be written for confirmed or completed appointments")
+	}
+
+	vitalsJSON, err := json.Marshal(req.Vitals)
+	if err != nil {
+		return nil, errors.New("invalid vitals format")
+	}
+	allergiesJSON, err := json.Marshal(normalizeAllergies(req.Allergies))
+	if err != nil {
+		return nil, errors.New("invalid allergies format")
+	}
+
+	record := &models.MedicalRecord{
+		AppointmentID: appointment.ID,
+		PatientID:     appointment.PatientID,
+		DependentID:   appointment.DependentID,
+		DoctorID:      doctorID,
+		Diagnosis:     strings.TrimSpace(req.Diagnosis),
+		Symptoms:      strings.TrimSpace(req.Symptoms),
+		VitalsJSON:    string(vitalsJSON),
+		AllergiesJSON: string(allergiesJSON),
+		VisitSummary:  strings.TrimSpace(req.VisitSummary),
+	}
+	if err := s.recordRepo.Upsert(record); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.recordRepo.FindByAppointmentID(appointment.ID)
+	if err != nil || saved == nil {
+		return nil, errors.New("failed to load medical record")
+	}
+
+	s.auditService.LogUserAction(doctorID, "medical_record_saved", "medical_record", fmt.Sprintf("%d", saved.ID), map[string]interface{}{
+		"appointment_id": appointment.ID,
+		"patient_id":     appointment.PatientID,
+	})
+
+	return saved, nil
+}
+
+// GetRecord 予約の診療録の取得（担当医師・予約した患者のみ）
+func (s *MedicalRecordService) GetRecord(appointmentID, userID uint) (*models.MedicalRecord, error) {
+	appointment, err := s.getAppointmentForViewer(appointmentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := s.recordRepo.FindByAppointmentID(appointment.ID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, errors.New("medical record not found")
+	}
+
+	// PHI閲覧ログの記録
+	s.auditService.LogPHIAccess(userID, appointment.PatientID, "medical_record", fmt.Sprintf("%d", record.ID), map[string]interface{}{
+		"appointment_id": appointment.ID,
+	})
+
+	return record, nil
+}
+
+// GetAppointmentHistory 予約の患者（家族の予約の場合はその家族）の過去の診療録（担当医師・予約した患者のみ）
+func (s *MedicalRecordService) GetAppointmentHistory(appointmentID, userID uint, limit, offset int) ([]models.MedicalRecord, int64, error) {
+	appointment, err := s.getAppointmentForViewer(appointmentID, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	records, total, err := s.recordRepo.FindBySubject(appointment.PatientID, appointment.DependentID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// PHI閲覧ログの記録
+	s.auditService.LogPHIAccess(userID, appointment.PatientID, "medical_record", fmt.Sprintf("%d", appointment.PatientID), map[string]interface{}{
+		"appointment_id": appointment.ID,
+		"count":          len(records),
+	})
+
+	return records, total, nil
+}
+
+// GetMyRecords 患者本人（dependentID指定時は家族）の診療録の履歴
+func (s *MedicalRecordService) GetMyRecords(patientID uint, dependentID *uint, limit, offset int) ([]models.MedicalRecord, int64, error) {
+	if dependentID != nil {
+		dependent, err := s.dependentRepo.FindByID(*dependentID)
+		if err != nil || dependent == nil || dependent.GuardianID != patientID {
+			return nil, 0, errors.New("dependent not found")
+		}
+	}
+	return s.recordRepo.FindBySubject(patientID, dependentID, limit, offset)
+}
+
+func (s *MedicalRecordService) getAppointmentForViewer(appointmentID, userID uint) (*models.Appointment, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.PatientID != userID && appointment.DoctorID != userID {
+		return nil, errors.New("unauthorized to view the medical record for this appointment")
+	}
+	return appointment, nil
+}
+
+// normalizeAllergies アレルギーの一覧の前後の空白・空の項目・重複を除く
+func normalizeAllergies(allergies []string) []string {
+	result := make([]string, 0, len(allergies))
+	seen := make(map[string]bool, len(allergies))
+	for _, allergy := range allergies {
+		allergy = strings.TrimSpace(allergy)
+		if allergy == "" || seen[allergy] {
+			continue
+		}
+		seen[allergy] = true
+		result = append(result, allergy)
+	}
+	return result
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// チャット送信の停止期間（日数）
+const (
+	moderationDefaultSuspendDays = 7
+	moderationMaxSuspendDays     = 90
+)
+
+type ModerationService struct {
+	flagRepo            repositories.MessageFlagRepository
+	messageRepo         repositories.MessageRepository
+	appointmentRepo     repositories.AppointmentRepository
+	userRepo            repositories.UserRepository
+	notificationService *NotificationService
+	auditService        *AuditService
+}
+
+type FlagMessageRequest struct {
+	Reason  string `json:"reason" binding:"required,oneof=harassment spam other"`
+	Comment string `json:"comment"`
+}
+
+type ResolveFlagRequest struct {
+	Action      string `json:"action" binding:"required,oneof=dismiss warn suspend"`
+	Note        string `json:"note"`
+	SuspendDays int    `json:"suspend_days"` // suspendの場合のみ（未指定は7日）
+}
+
+func NewModerationService(flagRepo repositories.MessageFlagRepository, messageRepo repositories.MessageRepository, appointmentRepo repositories.AppointmentRepository, userRepo repositories.UserRepository, notificationService *NotificationService, auditService *AuditService) *ModerationService {
+	return &ModerationService{
+		flagRepo:            flagRepo,
+		messageRepo:         messageRepo,
+		appointmentRepo:     appointmentRepo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		auditService:        auditService,
+	}
+}
+
+// FlagMessage 診療チャットのメッセージを通報する（予約の参加者のみ、自分のメッセージは不可）
+func (s *ModerationService) FlagMessage(userID, appointmentID, messageID uint, req FlagMessageRequest) (*models.MessageFlag, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if !appointment.IsParticipant(userID) {
+		return nil, errors.New("unauthorized to flag messages in this appointment")
+	}
+
+	message, err := s.messageRepo.FindByID(messageID)
+	if err != nil || message == nil || message.AppointmentID != appointmentID || message.Channel != models.MessageChannelPatient {
+		return nil, errors.New("message not found")
+	}
+	if message.SenderUserID == userID {
+		return nil, errors.New("cannot flag your own message")
+	}
+
+	flag := &models.MessageFlag{
+		MessageID:     message.ID,
+		ReporterID:    userID,
+		AppointmentID: appointmentID,
+		Reason:        req.Reason,
+		Comment:       req.Comment,
+		Status:        "open",
+	}
+	created, err := s.flagRepo.Create(flag)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, errors.New("message already flagged")
+	}
+
+	s.auditService.LogUserAction(userID, "message_flagged", "message", fmt.Sprintf("%d", message.ID), map[string]interface{}{
+		"flag_id":        flag.ID,
+		"appointment_id": appointmentID,
+		"reason":         req.Reason,
+		"sender_id":      message.SenderUserID,
+	})
+
+	// 管理者へモデレーション待ちを通知
+	var adminIDs []uint
+	admins, err := s.userRepo.FindByRole("admin")
+	if err != nil {
+		log.Printf("Warning: Failed to find admins for message flag: %v", err)
+	}
+	for _, admin := range admins {
+		adminIDs = append(adminIDs, admin.ID)
+	}
+	s.notificationService.NotifyMany(adminIDs, NotificationMessage{
+		Type:  "message_flagged",
+		Title: "チャットメッセージが通報されました",
+		Data:  map[string]interface{}{"flag_id": flag.ID, "reason": req.Reason},
+	})
+
+	return flag, nil
+}
+
+// GetFlags モデレーション待ちの通報一覧（管理者のみ）
+func (s *ModerationService) GetFlags(adminID uint, status string, limit, offset int) ([]models.MessageFlag, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	flags, err := s.flagRepo.FindAll(status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	// 通報されたメッセージの閲覧を予約ごとにPHI閲覧ログへ記録
+	logged := make(map[uint]bool)
+	for _, flag := range flags {
+		if logged[flag.AppointmentID] {
+			continue
+		}
+		logged[flag.AppointmentID] = true
+		if appointment, err := s.appointmentRepo.FindByID(flag.AppointmentID); err == nil && appointment != nil {
+			s.auditService.LogPHIAccess(adminID, appointment.PatientID, "message_flag", fmt.Sprintf("%d", flag.ID), map[string]interface{}{
+				"appointment_id": flag.AppointmentID,
+			})
+		}
+	}
+
+	return flags, nil
+}
+
+// GetFlag 通報の詳細（管理者のみ）
+func (s *ModerationService) GetFlag(adminID, flagID uint) (*models.MessageFlag, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	flag, err := s.flagRepo.FindByID(flagID)
+	if err != nil || flag == nil {
+		return nil, errors.New("flag not found")
+	}
+
+	if appointment, err := s.appointmentRepo.FindByID(flag.AppointmentID); err == nil && appointment != nil {
+		s.auditService.LogPHIAccess(adminID, appointment.PatientID, "message_flag", fmt.Sprintf("%d", flag.ID), nil)
+	}
+
+	return flag, nil
+}
+
+// ResolveFlag 通報への対応（却下・警告・チャット送信の停止）
+// 同じメッセージへの未対応の通報もまとめて解決する
+func (s *ModerationService) ResolveFlag(adminID, flagID uint, req ResolveFlagRequest) (*models.MessageFlag, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	flag, err := s.flagRepo.FindByID(flagID)
+	if err != nil || flag == nil {
+		return nil, errors.New("flag not found")
+	}
+	if flag.Status != "open" {
+		return nil, errors.New("flag is already resolved")
+	}
+
+	senderID := flag.Message.SenderUserID
+	meta := map[string]interface{}{
+		"flag_id":    flag.ID,
+		"message_id": flag.MessageID,
+		"sender_id":  senderID,
+		"note":       req.Note,
+	}
+
+	switch req.Action {
+	case models.ModerationWarn:
+		if _, err := s.notificationService.Notify(senderID, NotificationMessage{
+			Type:     "moderation_warning",
+			Title:    "チャットでの発言について警告があります",
+			Body:     "利用規約に反するメッセージが確認されました。繰り返される場合はチャットの利用を停止します",
+			Priority: "high",
+			Data:     map[string]interface{}{"appointment_id": flag.AppointmentID},
+		}); err != nil {
+			log.Printf("Warning: Failed to send moderation warning to user %d: %v", senderID, err)
+		}
+
+	case models.ModerationSuspend:
+		days := req.SuspendDays
+		if days == 0 {
+			days = moderationDefaultSuspendDays
+		}
+		if days < 1 || days > moderationMaxSuspendDays {
+			return nil, fmt.Errorf("suspend_days must be between 1 and %d", moderationMaxSuspendDays)
+		}
+		until := time.Now().AddDate(0, 0, days)
+		if err := s.userRepo.SetChatSuspendedUntil(senderID, &until); err != nil {
+			return nil, err
+		}
+		meta["suspended_until"] = until
+
+		if _, err := s.notificationService.Notify(senderID, NotificationMessage{
+			Type:     "chat_suspended",
+			Title:    "チャットの利用を停止しました",
+			Body:     fmt.Sprintf("%sまでメッセージを送信できません", until.Format("2006年1月2日 15:04")),
+			Priority: "high",
+			Data:     map[string]interface{}{"suspended_until": until},
+		}); err != nil {
+			log.Printf("Warning: Failed to notify user %d of chat suspension: %v", senderID, err)
+		}
+	}
+
+	resolved, err := s.flagRepo.ResolveOpenByMessage(flag.MessageID, req.Action, req.Note, adminID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	meta["resolved_flags"] = resolved
+
+	s.auditService.LogUserAction(adminID, "moderation_"+req.Action, "message", fmt.Sprintf("%d", flag.MessageID), meta)
+
+	return s.flagRepo.FindByID(flag.ID)
+}
+
+func (s *ModerationService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// NotificationChannel アプリ内通知以外の配信チャネル（メール・プッシュ等）
+type NotificationChannel interface {
+	Name() string
+	Send(user *models.User, notification *models.Notification) error
+}
+
+// NotificationMessage 通知内容
+type NotificationMessage struct {
+	Type     string
+	Title    string
+	Body     string
+	Priority string // normal | high
+	Data     interface{}
+}
+
+type NotificationService struct {
+	notificationRepo repositories.NotificationRepository
+	userRepo         repositories.UserRepository
+	channels         []NotificationChannel
+}
+
+func NewNotificationService(notificationRepo repositories.NotificationRepository, userRepo repositories.UserRepository) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+	}
+}
+
+// RegisterChannel 配信チャネルの追加
+func (s *NotificationService) RegisterChannel(channel NotificationChannel) {
+	s.channels = append(s.channels, channel)
+}
+
+// Notify ユーザーへの通知（アプリ内通知を保存し、各チャネルへ非同期配信）
+func (s *NotificationService) Notify(userID uint, msg NotificationMessage) (*models.Notification, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	priority := msg.Priority
+	if priority == "" {
+		priority = "normal"
+	}
+
+	var dataJSON string
+	if msg.Data != nil {
+		dataBytes, err := json.Marshal(msg.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal notification data: %v", err)
+		}
+		dataJSON = string(dataBytes)
+	}
+
+	notification := &models.Notification{
+		UserID:   userID,
+		Type:     msg.Type,
+		Title:    msg.Title,
+		Body:     msg.Body,
+		Priority: priority,
+		DataJSON: dataJSON,
+	}
+
+	if err := s.notificationRepo.Create(notification); err != nil {
+		return nil, err
+	}
+
+	// 外部チャネルへの配信（失敗しても通知自体は成功とする）
+	for _, channel := range s.channels {
+		go func(channel NotificationChannel) {
+			if err := channel.Send(user, notification); err != nil {
+				log.Printf("Warning: Failed to deliver notification %d via %s: %v", notification.ID, channel.Name(), err)
+			}
+		}(channel)
+	}
+
+	return notification, nil
+}
+
+// NotifyMany 複数ユーザーへの通知（個別の失敗はログに記録して続行）
+func (s *NotificationService) NotifyMany(userIDs []uint, msg NotificationMessage) int {
+	sent := 0
+	for _, userID := range userIDs {
+		if _, err := s.Notify(userID, msg); err != nil {
+			log.Printf("Warning: Failed to notify user %d: %v", userID, err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
+
+// GetNotifications 通知一覧の取得
+func (s *NotificationService) GetNotifications(userID uint, unreadOnly bool, limit, offset int) ([]models.Notification, int64, error) {
+	notifications, err := s.notificationRepo.FindByUserID(userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	unreadCount, err := s.notificationRepo.CountUnread(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, unreadCount, nil
+}
+
+// MarkAsRead 通知を既読にする
+func (s *NotificationService) MarkAsRead(notificationID, userID uint) error {
+	notification, err := s.notificationRepo.FindByID(notificationID)
+	if err != nil || notification == nil {
+		return errors.New("notification not found")
+	}
+
+	if notification.UserID != userID {
+		return errors.New("unauthorized to update this notification")
+	}
+
+	return s.notificationRepo.MarkAsRead(notificationID)
+}
+
+// MarkAllAsRead すべての通知を既読にする
+func (s *NotificationService) MarkAllAsRead(userID uint) error {
+	return s.notificationRepo.MarkAllAsRead(userID)
+}
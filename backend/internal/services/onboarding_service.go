@@ -0,0 +1,313 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 患者プロフィールの項目
+const (
+	ProfileFieldBirthdate = "birthdate"
+	ProfileFieldPhone     = "phone"
+	ProfileFieldAllergies = "allergies"
+	ProfileFieldInsurance = "insurance"
+)
+
+// OnboardingStepCompleted オンボーディング完了後のステップ名
+const OnboardingStepCompleted = "completed"
+
+// profileFieldCheckers 項目ごとの入力済み判定
+var profileFieldCheckers = map[string]func(p *models.PatientProfile) bool{
+	ProfileFieldBirthdate: func(p *models.PatientProfile) bool { return p.Birthdate != nil },
+	ProfileFieldPhone:     func(p *models.PatientProfile) bool { return strings.TrimSpace(p.Phone) != "" },
+	ProfileFieldAllergies: func(p *models.PatientProfile) bool { return p.Allergies != nil },
+	ProfileFieldInsurance: func(p *models.PatientProfile) bool {
+		return strings.TrimSpace(p.InsuranceProvider) != "" && strings.TrimSpace(p.InsuranceNumber) != ""
+	},
+}
+
+// profileFields 完成度の計算対象（表示順）
+var profileFields = []string{ProfileFieldBirthdate, ProfileFieldPhone, ProfileFieldAllergies, ProfileFieldInsurance}
+
+// onboardingSteps オンボーディングの手順（上から順に進む）
+var onboardingSteps = []struct {
+	Name   string
+	Fields []string
+}{
+	{Name: "basic_info", Fields: []string{ProfileFieldBirthdate, ProfileFieldPhone}},
+	{Name: "allergies", Fields: []string{ProfileFieldAllergies}},
+	{Name: "insurance", Fields: []string{ProfileFieldInsurance}},
+}
+
+type OnboardingService struct {
+	userRepo       repositories.UserRepository
+	auditService   *AuditService
+	requiredFields map[string]bool
+}
+
+// ProfileCompleteness プロフィールの完成度
+type ProfileCompleteness struct {
+	Percent         int      `json:"percent"`
+	Missing         []string `json:"missing"`
+	MissingRequired []string `json:"missing_required"`
+	CanBook         bool     `json:"can_book"`
+}
+
+// OnboardingStepState オンボーディングの各ステップの状態
+type OnboardingStepState struct {
+	Name      string   `json:"name"`
+	Fields    []string `json:"fields"`
+	Required  bool     `json:"required"` // 予約に必須の項目を含む（スキップ不可）
+	Completed bool     `json:"completed"`
+}
+
+// OnboardingState オンボーディングの進行状態
+type OnboardingState struct {
+	CurrentStep  string                `json:"current_step"`
+	Steps        []OnboardingStepState `json:"steps"`
+	Completed    bool                  `json:"completed"`
+	Completeness ProfileCompleteness   `json:"completeness"`
+}
+
+// OnboardingStepRequest オンボーディングのステップで送信する項目
+type OnboardingStepRequest struct {
+	Birthdate         *time.Time `json:"birthdate"`
+	Phone             *string    `json:"phone"`
+	Address           *string    `json:"address"`
+	Allergies         *string    `json:"allergies"` // 空文字はアレルギーなし
+	InsuranceProvider *string    `json:"insurance_provider"`
+	InsuranceNumber   *string    `json:"insurance_number"`
+}
+
+func NewOnboardingService(userRepo repositories.UserRepository, auditService *AuditService, requiredFields []string) *OnboardingService {
+	required := make(map[string]bool, len(requiredFields))
+	for _, field := range requiredFields {
+		if _, ok := profileFieldCheckers[field]; !ok {
+			log.Printf("Warning: Unknown required patient profile field %q ignored", field)
+			continue
+		}
+		required[field] = true
+	}
+
+	return &OnboardingService{
+		userRepo:       userRepo,
+		auditService:   auditService,
+		requiredFields: required,
+	}
+}
+
+// GetCompleteness プロフィール完成度の取得
+func (s *OnboardingService) GetCompleteness(patientID uint) (*ProfileCompleteness, error) {
+	profile, err := s.userRepo.FindPatientProfileByUserID(patientID)
+	if err != nil {
+		return nil, errors.New("patient profile not found")
+	}
+
+	completeness := s.completeness(profile)
+	return &completeness, nil
+}
+
+// GetOnboarding オンボーディングの進行状態の取得
+func (s *OnboardingService) GetOnboarding(patientID uint) (*OnboardingState, error) {
+	profile, err := s.userRepo.FindPatientProfileByUserID(patientID)
+	if err != nil {
+		return nil, errors.New("patient profile not found")
+	}
+
+	return s.state(profile), nil
+}
+
+// SubmitStep 現在のステップの項目を保存して次のステップへ進む
+// 完了済みのステップは再送信で修正できるが、先のステップには進めない
+func (s *OnboardingService) SubmitStep(patientID uint, step string, req OnboardingStepRequest) (*OnboardingState, error) {
+	profile, err := s.userRepo.FindPatientProfileByUserID(patientID)
+	if err != nil {
+		return nil, errors.New("patient profile not found")
+	}
+
+	index, err := s.checkStep(profile, step)
+	if err != nil {
+		return nil, err
+	}
+
+	// 登録済みの番号の変更はSMS認証（/auth/me/phone）を経由させる
+	if req.Phone != nil && profile.Phone != "" && strings.TrimSpace(*req.Phone) != profile.Phone {
+		return nil, errors.New("registered phone number must be changed via phone verification")
+	}
+
+	applyOnboardingFields(profile, req)
+	for _, field := range onboardingSteps[index].Fields {
+		if !profileFieldCheckers[field](profile) {
+			return nil, fmt.Errorf("field required: %s", field)
+		}
+	}
+
+	if onboardingSteps[index].Name == profile.OnboardingStep {
+		s.advance(profile, index)
+	}
+	if err := s.userRepo.UpdatePatientProfile(profile); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(patientID, "onboarding_step_submitted", "patient_profile", fmt.Sprintf("%d", patientID), map[string]interface{}{
+		"step": step,
+	})
+
+	return s.state(profile), nil
+}
+
+// SkipStep 現在のステップをスキップする（予約に必須の項目を含むステップは不可）
+func (s *OnboardingService) SkipStep(patientID uint, step string) (*OnboardingState, error) {
+	profile, err := s.userRepo.FindPatientProfileByUserID(patientID)
+	if err != nil {
+		return nil, errors.New("patient profile not found")
+	}
+
+	index, err := s.checkStep(profile, step)
+	if err != nil {
+		return nil, err
+	}
+	if onboardingSteps[index].Name != profile.OnboardingStep {
+		return nil, errors.New("only the current step can be skipped")
+	}
+	if s.stepRequired(index) {
+		return nil, errors.New("this step is required before booking and cannot be skipped")
+	}
+
+	s.advance(profile, index)
+	if err := s.userRepo.UpdatePatientProfile(profile); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(patientID, "onboarding_step_skipped", "patient_profile", fmt.Sprintf("%d", patientID), map[string]interface{}{
+		"step": step,
+	})
+
+	return s.state(profile), nil
+}
+
+// CheckBookingAllowed 予約に必須のプロフィール項目が入力済みか確認する
+func (s *OnboardingService) CheckBookingAllowed(patientID uint) error {
+	profile, err := s.userRepo.FindPatientProfileByUserID(patientID)
+	if err != nil {
+		return errors.New("patient profile not found")
+	}
+
+	completeness := s.completeness(profile)
+	if !completeness.CanBook {
+		return fmt.Errorf("profile incomplete, required before booking: %s", strings.Join(completeness.MissingRequired, ", "))
+	}
+	return nil
+}
+
+// checkStep 送信されたステップが現在または完了済みのステップか確認し、その位置を返す
+func (s *OnboardingService) checkStep(profile *models.PatientProfile, step string) (int, error) {
+	index := onboardingStepIndex(step)
+	if index < 0 {
+		return 0, errors.New("unknown onboarding step")
+	}
+
+	current := onboardingStepIndex(profile.OnboardingStep)
+	if current >= 0 && index > current {
+		return 0, errors.New("complete previous onboarding steps first")
+	}
+	return index, nil
+}
+
+// advance 次のステップへ進める
+func (s *OnboardingService) advance(profile *models.PatientProfile, index int) {
+	if index+1 < len(onboardingSteps) {
+		profile.OnboardingStep = onboardingSteps[index+1].Name
+		return
+	}
+	now := time.Now()
+	profile.OnboardingStep = OnboardingStepCompleted
+	profile.OnboardingCompletedAt = &now
+}
+
+func (s *OnboardingService) stepRequired(index int) bool {
+	for _, field := range onboardingSteps[index].Fields {
+		if s.requiredFields[field] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *OnboardingService) completeness(profile *models.PatientProfile) ProfileCompleteness {
+	result := ProfileCompleteness{Missing: []string{}, MissingRequired: []string{}}
+	filled := 0
+	for _, field := range profileFields {
+		if profileFieldCheckers[field](profile) {
+			filled++
+			continue
+		}
+		result.Missing = append(result.Missing, field)
+		if s.requiredFields[field] {
+			result.MissingRequired = append(result.MissingRequired, field)
+		}
+	}
+
+	result.Percent = filled * 100 / len(profileFields)
+	result.CanBook = len(result.MissingRequired) == 0
+	return result
+}
+
+func (s *OnboardingService) state(profile *models.PatientProfile) *OnboardingState {
+	current := onboardingStepIndex(profile.OnboardingStep)
+	completed := profile.OnboardingStep == OnboardingStepCompleted
+
+	steps := make([]OnboardingStepState, len(onboardingSteps))
+	for i, step := range onboardingSteps {
+		steps[i] = OnboardingStepState{
+			Name:      step.Name,
+			Fields:    step.Fields,
+			Required:  s.stepRequired(i),
+			Completed: completed || (current >= 0 && i < current),
+		}
+	}
+
+	return &OnboardingState{
+		CurrentStep:  profile.OnboardingStep,
+		Steps:        steps,
+		Completed:    completed,
+		Completeness: s.completeness(profile),
+	}
+}
+
+func onboardingStepIndex(step string) int {
+	for i, s := range onboardingSteps {
+		if s.Name == step {
+			return i
+		}
+	}
+	return -1
+}
+
+func applyOnboardingFields(profile *models.PatientProfile, req OnboardingStepRequest) {
+	if req.Birthdate != nil {
+		profile.Birthdate = req.Birthdate
+	}
+	if req.Phone != nil {
+		profile.Phone = strings.TrimSpace(*req.Phone)
+	}
+	if req.Address != nil {
+		profile.Address = *req.Address
+	}
+	if req.Allergies != nil {
+		allergies := strings.TrimSpace(*req.Allergies)
+		profile.Allergies = &allergies
+	}
+	if req.InsuranceProvider != nil {
+		profile.InsuranceProvider = strings.TrimSpace(*req.InsuranceProvider)
+	}
+	if req.InsuranceNumber != nil {
+		profile.InsuranceNumber = strings.TrimSpace(*req.InsuranceNumber)
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/alerting"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// DatabasePinger データベースへの接続確認（*sql.DB）
+type DatabasePinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// OpsAlertThresholds 運用アラートの閾値
+type OpsAlertThresholds struct {
+	DatabaseFailures     int   // データベースの接続確認が連続して失敗した回数
+	JobFailures          int   // 定期ジョブが連続して失敗した回数
+	TranscriptionBacklog int64 // 文字起こしの処理待ちの件数
+}
+
+// OpsMonitorService 運用上の異常（データベースの接続断・ジョブの失敗・キューの滞留）の監視
+type OpsMonitorService struct {
+	db             DatabasePinger
+	transcriptRepo repositories.TranscriptRepository
+	alerter        *alerting.Alerter
+	thresholds     OpsAlertThresholds
+}
+
+func NewOpsMonitorService(db DatabasePinger, transcriptRepo repositories.TranscriptRepository, alerter *alerting.Alerter, thresholds OpsAlertThresholds) *OpsMonitorService {
+	return &OpsMonitorService{
+		db:             db,
+		transcriptRepo: transcriptRepo,
+		alerter:        alerter,
+		thresholds:     thresholds,
+	}
+}
+
+// RunHealthCheckJob 定期ジョブ：データベースの接続と処理待ちのキューの確認
+// 異常はアラートで通知するため、ジョブ自体は失敗として扱わない
+func (s *OpsMonitorService) RunHealthCheckJob() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.PingContext(ctx); err != nil {
+		log.Printf("Warning: Database health check failed: %v", err)
+		s.alerter.Failure(alerting.EventDatabaseUnreachable, alerting.EventDatabaseUnreachable, s.thresholds.DatabaseFailures,
+			"Database connection lost", map[string]interface{}{"error": err.Error()})
+		return nil
+	}
+	s.alerter.Success(alerting.EventDatabaseUnreachable, "Database connection restored")
+
+	key := alerting.EventJobBacklog + ":transcription"
+	queued, err := s.transcriptRepo.CountQueued()
+	if err != nil {
+		log.Printf("Warning: Failed to count queued transcriptions: %v", err)
+		return nil
+	}
+	if s.thresholds.TranscriptionBacklog > 0 && queued >= s.thresholds.TranscriptionBacklog {
+		s.alerter.Fire(alerting.Alert{
+			Key:      key,
+			Event:    alerting.EventJobBacklog,
+			Severity: alerting.SeverityWarning,
+			Summary:  fmt.Sprintf("Transcription queue backlog: %d recordings waiting", queued),
+			Details:  map[string]interface{}{"queued": queued, "threshold": s.thresholds.TranscriptionBacklog},
+		})
+	} else {
+		s.alerter.Resolve(key, "Transcription queue backlog cleared")
+	}
+	return nil
+}
+
+// JobFinished 定期ジョブの実行結果（連続した失敗が閾値に達したら通知する）
+func (s *OpsMonitorService) JobFinished(name string, err error) {
+	key := alerting.EventJobFailing + ":" + name
+	if err != nil {
+		s.alerter.Failure(key, alerting.EventJobFailing, s.thresholds.JobFailures,
+			fmt.Sprintf("Scheduled job %s is failing", name), map[string]interface{}{"job": name, "error": err.Error()})
+		return
+	}
+	s.alerter.Success(key, fmt.Sprintf("Scheduled job %s recovered", name))
+}
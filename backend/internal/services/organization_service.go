@@ -0,0 +1,390 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// OrganizationService 組織（医療機関）と、組織の管理者によるスタッフへのロールの委任
+//
+// 管理者は組織の作成と医師・組織の管理者の所属を管理し、組織の管理者はスタッフの所属と
+// スタッフに委任するロール（予約の管理・請求の閲覧・監査ログの閲覧）を管理する。
+// 委任されたロールで操作できるのは組織に所属する医師の予約・請求と、所属するメンバーの監査ログのみ。
+type OrganizationService struct {
+	organizationRepo    repositories.OrganizationRepository
+	userRepo            repositories.UserRepository
+	appointmentRepo     repositories.AppointmentRepository
+	invoiceRepo         repositories.InvoiceRepository
+	appointmentService  *AppointmentService
+	notificationService *NotificationService
+	auditService        *AuditService
+}
+
+// CreateOrganizationRequest 組織の作成
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required,max=200"`
+}
+
+// AddOrganizationMemberRequest 組織への医師・スタッフの所属の追加
+type AddOrganizationMemberRequest struct {
+	Email      string `json:"email" binding:"required,email"`
+	IsOrgAdmin bool   `json:"is_org_admin"` // 組織の管理者にする（管理者のみ指定できる）
+}
+
+// SetOrganizationRolesRequest スタッフに委任するロール（指定したロールで置き換える）
+type SetOrganizationRolesRequest struct {
+	Roles []string `json:"roles" binding:"max=3,dive,oneof=scheduler billing_clerk auditor"`
+}
+
+func NewOrganizationService(organizationRepo repositories.OrganizationRepository, userRepo repositories.UserRepository, appointmentRepo repositories.AppointmentRepository, invoiceRepo repositories.InvoiceRepository, appointmentService *AppointmentService, notificationService *NotificationService, auditService *AuditService) *OrganizationService {
+	return &OrganizationService{
+		organizationRepo:    organizationRepo,
+		userRepo:            userRepo,
+		appointmentRepo:     appointmentRepo,
+		invoiceRepo:         invoiceRepo,
+		appointmentService:  appointmentService,
+		notificationService: notificationService,
+		auditService:        auditService,
+	}
+}
+
+// CreateOrganization 組織の作成（管理者のみ）
+func (s *OrganizationService) CreateOrganization(adminID uint, req CreateOrganizationRequest) (*models.Organization, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("invalid organization: name is required")
+	}
+
+	organization := &models.Organization{Name: name}
+	if err := s.organizationRepo.Create(organization); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(adminID, "organization_created", "organization", fmt.Sprintf("%d", organization.ID), map[string]interface{}{
+		"name": organization.Name,
+	})
+	return organization, nil
+}
+
+// GetOrganizations 組織の一覧（管理者はすべて、それ以外は所属する組織）
+func (s *OrganizationService) GetOrganizations(userID uint) ([]models.Organization, error) {
+	if s.isAdmin(userID) {
+		return s.organizationRepo.FindAll()
+	}
+	return s.organizationRepo.FindByMember(userID)
+}
+
+// GetMembers 組織に所属する医師・スタッフと委任されたロールの一覧（管理者・組織の管理者）
+func (s *OrganizationService) GetMembers(userID, organizationID uint) ([]models.OrganizationMember, error) {
+	if err := s.authorize(userID, organizationID, policy.OrgPermManage); err != nil {
+		return nil, err
+	}
+
+	members, err := s.organizationRepo.FindMembers(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	grants, err := s.organizationRepo.FindRolesByOrganization(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[uint][]string, len(grants))
+	for _, grant := range grants {
+		roles[grant.UserID] = append(roles[grant.UserID], grant.Role)
+	}
+	for i := range members {
+		members[i].Roles = roles[members[i].UserID]
+		if members[i].Roles == nil {
+			members[i].Roles = []string{}
+		}
+	}
+	return members, nil
+}
+
+// AddMember 組織への所属の追加
+// 管理者は医師・スタッフを追加して組織の管理者を指定でき、組織の管理者はスタッフのみ追加できる
+func (s *OrganizationService) AddMember(actorID, organizationID uint, req AddOrganizationMemberRequest) (*models.OrganizationMember, error) {
+	if err := s.authorize(actorID, organizationID, policy.OrgPermManage); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByEmail(strings.TrimSpace(req.Email))
+	if err != nil || user == nil || user.DeactivatedAt != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Role != policy.RoleDoctor && user.Role != policy.RoleStaff {
+		return nil, errors.New("invalid member: only doctors and staff can belong to an organization")
+	}
+
+	existing, err := s.organizationRepo.FindMember(organizationID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.isAdmin(actorID) {
+		if user.Role != policy.RoleStaff || req.IsOrgAdmin {
+			return nil, errors.New("unauthorized: only administrators can add doctors and organization admins")
+		}
+		if existing != nil {
+			return nil, errors.New("user is already a member of this organization")
+		}
+	}
+
+	member := &models.OrganizationMember{
+		OrganizationID: organizationID,
+		UserID:         user.ID,
+		IsOrgAdmin:     req.IsOrgAdmin,
+	}
+	if err := s.organizationRepo.SaveMember(member); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(actorID, "organization_member_added", "organization", fmt.Sprintf("%d", organizationID), map[string]interface{}{
+		"user_id":      user.ID,
+		"is_org_admin": member.IsOrgAdmin,
+		"updated":      existing != nil,
+	})
+	return s.organizationRepo.FindMember(organizationID, user.ID)
+}
+
+// RemoveMember 組織からの所属の解除（委任したロールも取り消す、組織の管理者はスタッフのみ解除できる）
+func (s *OrganizationService) RemoveMember(actorID, organizationID, userID uint) error {
+	if err := s.authorize(actorID, organizationID, policy.OrgPermManage); err != nil {
+		return err
+	}
+
+	member, err := s.organizationRepo.FindMember(organizationID, userID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return errors.New("member not found")
+	}
+	if !s.isAdmin(actorID) {
+		user, err := s.userRepo.FindByID(userID)
+		if err != nil {
+			return err
+		}
+		if user.Role != policy.RoleStaff || member.IsOrgAdmin {
+			return errors.New("unauthorized: only administrators can remove doctors and organization admins")
+		}
+	}
+
+	removed, err := s.organizationRepo.RemoveMember(organizationID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.New("member not found")
+	}
+
+	s.auditService.LogUserAction(actorID, "organization_member_removed", "organization", fmt.Sprintf("%d", organizationID), map[string]interface{}{
+		"user_id": userID,
+	})
+	return nil
+}
+
+// SetMemberRoles スタッフに委任するロールの設定（管理者・組織の管理者、組織の管理者は自身のロールを変更できない）
+func (s *OrganizationService) SetMemberRoles(actorID, organizationID, userID uint, req SetOrganizationRolesRequest) ([]string, error) {
+	if err := s.authorize(actorID, organizationID, policy.OrgPermManage); err != nil {
+		return nil, err
+	}
+	if actorID == userID && !s.isAdmin(actorID) {
+		return nil, errors.New("unauthorized: cannot change your own roles")
+	}
+
+	member, err := s.organizationRepo.FindMember(organizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, errors.New("member not found")
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != policy.RoleStaff {
+		return nil, errors.New("invalid member: roles can only be granted to staff users")
+	}
+
+	roles := make([]string, 0, len(req.Roles))
+	for _, role := range policy.OrgRoles {
+		if policy.Allows(role, req.Roles...) {
+			roles = append(roles, role)
+		}
+	}
+
+	previous, err := s.organizationRepo.FindRoles(organizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.organizationRepo.ReplaceRoles(organizationID, userID, roles, actorID); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(actorID, "organization_roles_updated", "organization", fmt.Sprintf("%d", organizationID), map[string]interface{}{
+		"user_id":  userID,
+		"previous": previous,
+		"roles":    roles,
+	})
+	if _, err := s.notificationService.Notify(userID, NotificationMessage{
+		Type:  "organization_roles_updated",
+		Title: "組織で委任されたロールが変更されました",
+		Data: map[string]interface{}{
+			"organization_id": organizationID,
+			"roles":           roles,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify user %d of organization roles: %v", userID, err)
+	}
+	return roles, nil
+}
+
+// HasOrgPermission 組織内の操作の権限があるか（管理者は常に許可、組織の管理者は所属とロールの管理のみ、
+// スタッフは委任されたロールが許可する操作のみ）
+func (s *OrganizationService) HasOrgPermission(userID, organizationID uint, permission string) (bool, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return false, nil
+	}
+	if policy.IsAdmin(user) {
+		return true, nil
+	}
+
+	member, err := s.organizationRepo.FindMember(organizationID, userID)
+	if err != nil {
+		return false, err
+	}
+	if member == nil {
+		return false, nil
+	}
+	if permission == policy.OrgPermManage {
+		return member.IsOrgAdmin, nil
+	}
+	if user.Role != policy.RoleStaff {
+		return false, nil
+	}
+
+	roles, err := s.organizationRepo.FindRoles(organizationID, userID)
+	if err != nil {
+		return false, err
+	}
+	return policy.OrgAllows(roles, permission), nil
+}
+
+// GetAppointments 組織に所属する医師の予約の一覧（予約の管理を委任されたスタッフ用、診療の内容は含めない）
+func (s *OrganizationService) GetAppointments(userID, organizationID uint, status string, limit, offset int) ([]models.Appointment, int64, error) {
+	if err := s.authorize(userID, organizationID, policy.OrgPermAppointments); err != nil {
+		return nil, 0, err
+	}
+	if status != "" && status != "pending" && status != "confirmed" && status != "cancelled" && status != "completed" {
+		return nil, 0, errors.New("invalid appointment status")
+	}
+
+	doctorIDs, err := s.organizationRepo.FindMemberIDs(organizationID, policy.RoleDoctor)
+	if err != nil {
+		return nil, 0, err
+	}
+	appointments, total, err := s.appointmentRepo.FindPageByDoctorIDs(doctorIDs, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range appointments {
+		appointments[i].Notes = ""
+	}
+	return appointments, total, nil
+}
+
+// CancelAppointment 組織に所属する医師の予約のキャンセル（予約の管理を委任されたスタッフ用）
+func (s *OrganizationService) CancelAppointment(userID, organizationID, appointmentID uint) (*models.Appointment, error) {
+	if err := s.authorize(userID, organizationID, policy.OrgPermAppointments); err != nil {
+		return nil, err
+	}
+
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	member, err := s.organizationRepo.FindMember(organizationID, appointment.DoctorID)
+	if err != nil {
+		return nil, err
+	}
+	// 組織に所属しない医師の予約は存在しないものとして扱う
+	if member == nil {
+		return nil, errors.New("appointment not found")
+	}
+
+	if err := s.appointmentService.CancelByStaff(appointment); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(userID, "appointment_cancelled_by_staff", "appointment", fmt.Sprintf("%d", appointment.ID), map[string]interface{}{
+		"organization_id": organizationID,
+		"doctor_id":       appointment.DoctorID,
+	})
+	appointment.Notes = ""
+	return appointment, nil
+}
+
+// GetInvoices 組織に所属する医師の予約の請求の一覧（請求の閲覧を委任されたスタッフ用）
+func (s *OrganizationService) GetInvoices(userID, organizationID uint, status string, limit, offset int) ([]models.Invoice, int64, error) {
+	if err := s.authorize(userID, organizationID, policy.OrgPermBilling); err != nil {
+		return nil, 0, err
+	}
+	if status != "" && status != "open" && status != "paid" && status != "void" {
+		return nil, 0, errors.New("invalid invoice status")
+	}
+
+	doctorIDs, err := s.organizationRepo.FindMemberIDs(organizationID, policy.RoleDoctor)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.invoiceRepo.FindByDoctors(doctorIDs, status, limit, offset)
+}
+
+// GetAuditLogs 組織に所属するメンバーの操作の監査ログ（監査ログの閲覧を委任されたスタッフ用）
+func (s *OrganizationService) GetAuditLogs(userID, organizationID uint, filter AuditLogFilter) (*repositories.AuditLogPage, error) {
+	if err := s.authorize(userID, organizationID, policy.OrgPermAudit); err != nil {
+		return nil, err
+	}
+
+	memberIDs, err := s.organizationRepo.FindMemberIDs(organizationID, "")
+	if err != nil {
+		return nil, err
+	}
+	return s.auditService.GetMembersAuditLogs(memberIDs, filter)
+}
+
+// authorize 組織の存在と組織内の操作の権限の確認
+func (s *OrganizationService) authorize(userID, organizationID uint, permission string) error {
+	organization, err := s.organizationRepo.FindByID(organizationID)
+	if err != nil {
+		return err
+	}
+	if organization == nil {
+		return errors.New("organization not found")
+	}
+
+	allowed, err := s.HasOrgPermission(userID, organizationID, permission)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.New("unauthorized: organization permission required")
+	}
+	return nil
+}
+
+func (s *OrganizationService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
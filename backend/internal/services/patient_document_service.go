@@ -0,0 +1,411 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/ocr"
+	"online_medical_consultation_app/backend/internal/repositories"
+	"online_medical_consultation_app/backend/internal/storage"
+)
+
+// 診療記録のファイル制限
+const patientDocumentMaxFileSize = 20 * 1024 * 1024
+
+var patientDocumentContentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+}
+
+const (
+	documentOCRMaxAttempts = 3
+	documentOCRBatchSize   = 5
+	// 処理中のまま更新がない文字認識は中断されたものとして再処理する
+	documentOCRStaleAfter = 15 * time.Minute
+)
+
+type PatientDocumentService struct {
+	documentRepo    repositories.PatientDocumentRepository
+	appointmentRepo repositories.AppointmentRepository
+	userRepo        repositories.UserRepository
+	auditService    *AuditService
+	ocrProvider     ocr.Provider
+	storageDir      string
+	objects         *StoredObjectService
+}
+
+type UploadPatientDocumentRequest struct {
+	Title      string `form:"title" binding:"required"`
+	DocType    string `form:"doc_type" binding:"required,oneof=lab_result imaging referral prescription other"`
+	RecordedAt string `form:"recorded_at"` // YYYY-MM-DD
+}
+
+type ShareDocumentsRequest struct {
+	DocumentIDs []uint `json:"document_ids" binding:"required,min=1"`
+}
+
+// SearchDocumentsRequest 医師に共有された診療記録の検索条件
+type SearchDocumentsRequest struct {
+	Query     string `form:"q"`
+	PatientID uint   `form:"patient_id"`
+	DocType   string `form:"doc_type" binding:"omitempty,oneof=lab_result imaging referral prescription other"`
+}
+
+func NewPatientDocumentService(documentRepo repositories.PatientDocumentRepository, appointmentRepo repositories.AppointmentRepository, userRepo repositories.UserRepository, storedObjectRepo repositories.StoredObjectRepository, auditService *AuditService, ocrProvider ocr.Provider, uploadDir string) *PatientDocumentService {
+	// 診療記録は公開アップロードとは別のディレクトリに保存する
+	storageDir := filepath.Join(uploadDir, "patient_documents")
+	if err := os.MkdirAll(storageDir, 0700); err != nil {
+		log.Printf("Warning: Failed to create patient document directory: %v", err)
+	}
+
+	// 文字認識はファイルのパスを使うため、診療記録は常にローカルに保存する
+	objects := NewStoredObjectService(storedObjectRepo, StoredObjectsPatientDocuments, storage.NewLocalStore(storageDir, "", ""))
+
+	return &PatientDocumentService{
+		documentRepo:    documentRepo,
+		appointmentRepo: appointmentRepo,
+		userRepo:        userRepo,
+		auditService:    auditService,
+		ocrProvider:     ocrProvider,
+		storageDir:      storageDir,
+		objects:         objects,
+	}
+}
+
+// UploadDocument 診療記録のアップロード（患者のみ）
+func (s *PatientDocumentService) UploadDocument(patientID uint, req UploadPatientDocumentRequest, file *multipart.FileHeader) (*models.PatientDocument, error) {
+	user, err := s.userRepo.FindByID(patientID)
+	if err != nil || user == nil || user.Role != "patient" {
+		return nil, errors.New("only patients can upload documents")
+	}
+
+	var recordedAt *time.Time
+	if req.RecordedAt != "" {
+		date, err := time.Parse("2006-01-02", req.RecordedAt)
+		if err != nil {
+			return nil, errors.New("invalid recorded_at format (expected YYYY-MM-DD)")
+		}
+		recordedAt = &date
+	}
+
+	if file.Size > patientDocumentMaxFileSize {
+		return nil, errors.New("file size must be less than 20MB")
+	}
+	contentType := file.Header.Get("Content-Type")
+	if !patientDocumentContentTypes[contentType] {
+		return nil, errors.New("only PDF, JPEG and PNG files are allowed")
+	}
+
+	// 同じ内容の診療記録は保存済みのファイルを参照する
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer src.Close()
+	object, err := s.objects.Put(context.Background(), src, file.Size, contentType, strings.ToLower(filepath.Ext(file.Filename)))
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(s.storageDir, filepath.FromSlash(object.ObjectKey))
+
+	document := &models.PatientDocument{
+		PatientID:   patientID,
+		Title:       req.Title,
+		DocType:     req.DocType,
+		RecordedAt:  recordedAt,
+		FilePath:    path,
+		Checksum:    object.Checksum,
+		FileName:    filepath.Base(file.Filename),
+		ContentType: contentType,
+		FileSize:    file.Size,
+		OCRStatus:   "skipped",
+	}
+	// 文字認識・分類は定期ジョブで行う
+	if s.ocrProvider != nil {
+		document.OCRStatus = "pending"
+	}
+	if err := s.documentRepo.Create(document); err != nil {
+		if removeErr := s.objects.Release(context.Background(), object.ObjectKey); removeErr != nil {
+			log.Printf("Warning: Failed to remove unrecorded document %s: %v", path, removeErr)
+		}
+		return nil, err
+	}
+
+	return document, nil
+}
+
+// RemoveDocumentFile 削除した診療記録のファイルの削除（同じ内容の他の診療記録が参照しているファイルは残す）
+func (s *PatientDocumentService) RemoveDocumentFile(document *models.PatientDocument) error {
+	if document.Checksum == "" {
+		// 重複を除く前に保存したファイル
+		if err := os.Remove(document.FilePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	key, err := filepath.Rel(s.storageDir, document.FilePath)
+	if err != nil {
+		return err
+	}
+	return s.objects.Release(context.Background(), filepath.ToSlash(key))
+}
+
+// GetMyDocuments 自分の診療記録一覧
+func (s *PatientDocumentService) GetMyDocuments(patientID uint) ([]models.PatientDocument, error) {
+	return s.documentRepo.FindByPatientID(patientID)
+}
+
+// DeleteDocument 診療記録の削除（すべての予約への共有も取り消す）
+func (s *PatientDocumentService) DeleteDocument(documentID, patientID uint) error {
+	document, err := s.documentRepo.FindByID(documentID)
+	if err != nil || document.PatientID != patientID {
+		return errors.New("document not found")
+	}
+
+	if err := s.documentRepo.DeleteGrantsByDocumentID(documentID); err != nil {
+		return err
+	}
+	return s.documentRepo.Delete(documentID)
+}
+
+// CheckOwnership 共有しようとしている診療記録がすべて患者本人のものか確認
+func (s *PatientDocumentService) CheckOwnership(patientID uint, documentIDs []uint) error {
+	ids := uniqueIDs(documentIDs)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	documents, err := s.documentRepo.FindByIDsAndPatient(ids, patientID)
+	if err != nil {
+		return err
+	}
+	if len(documents) != len(ids) {
+		return errors.New("document not found")
+	}
+	return nil
+}
+
+// ShareWithAppointment 診療記録を予約の担当医師に共有する（予約した患者のみ）
+func (s *PatientDocumentService) ShareWithAppointment(appointmentID, patientID uint, documentIDs []uint) ([]models.AppointmentDocumentGrant, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil || appointment.PatientID != patientID {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.Status == "cancelled" || appointment.Status == "completed" {
+		return nil, errors.New("documents cannot be shared with a closed appointment")
+	}
+
+	if err := s.CheckOwnership(patientID, documentIDs); err != nil {
+		return nil, err
+	}
+
+	ids := uniqueIDs(documentIDs)
+	grants := make([]models.AppointmentDocumentGrant, 0, len(ids))
+	for _, id := range ids {
+		grants = append(grants, models.AppointmentDocumentGrant{
+			AppointmentID: appointmentID,
+			DocumentID:    id,
+			PatientID:     patientID,
+			DoctorID:      appointment.DoctorID,
+		})
+	}
+	if err := s.documentRepo.CreateGrants(grants); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(patientID, "documents_shared", "appointment", fmt.Sprintf("%d", appointmentID), map[string]interface{}{
+		"document_ids": ids,
+		"doctor_id":    appointment.DoctorID,
+	})
+
+	return s.documentRepo.FindGrantsByAppointmentID(appointmentID)
+}
+
+// RevokeShare 予約への共有の取り消し（予約した患者のみ）
+func (s *PatientDocumentService) RevokeShare(appointmentID, documentID, patientID uint) error {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil || appointment.PatientID != patientID {
+		return errors.New("appointment not found")
+	}
+
+	deleted, err := s.documentRepo.DeleteGrant(appointmentID, documentID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("document is not shared with this appointment")
+	}
+
+	s.auditService.LogUserAction(patientID, "document_share_revoked", "appointment", fmt.Sprintf("%d", appointmentID), map[string]interface{}{
+		"document_id": documentID,
+	})
+	return nil
+}
+
+// GetSharedDocuments 予約に共有された診療記録の一覧（患者または担当医師のみ）
+func (s *PatientDocumentService) GetSharedDocuments(appointmentID, userID uint) ([]models.AppointmentDocumentGrant, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.PatientID != userID && appointment.DoctorID != userID {
+		return nil, errors.New("unauthorized to view documents for this appointment")
+	}
+
+	grants, err := s.documentRepo.FindGrantsByAppointmentID(appointmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogPHIAccess(userID, appointment.PatientID, "appointment_documents", fmt.Sprintf("%d", appointmentID), map[string]interface{}{
+		"appointment_id": appointmentID,
+		"count":          len(grants),
+	})
+
+	return grants, nil
+}
+
+// OpenDocumentFile 診療記録ファイルを開く（本人、または共有を受けた医師のみ）
+func (s *PatientDocumentService) OpenDocumentFile(documentID, viewerID uint) (*models.PatientDocument, *os.File, error) {
+	document, err := s.documentRepo.FindByID(documentID)
+	if err != nil {
+		return nil, nil, errors.New("document not found")
+	}
+
+	if document.PatientID != viewerID {
+		granted, err := s.documentRepo.HasGrantForDoctor(documentID, viewerID)
+		if err != nil || !granted {
+			// 共有されていない記録は存在自体を明かさない
+			return nil, nil, errors.New("document not found")
+		}
+	}
+
+	file, err := os.Open(document.FilePath)
+	if err != nil {
+		return nil, nil, errors.New("document file not found")
+	}
+
+	s.auditService.LogPHIAccess(viewerID, document.PatientID, "patient_document", fmt.Sprintf("%d", document.ID), nil)
+
+	return document, file, nil
+}
+
+// SearchDocuments 医師に共有された診療記録の検索（タイトル・認識したテキスト・抽出した検査項目や日付）
+func (s *PatientDocumentService) SearchDocuments(doctorID uint, req SearchDocumentsRequest, limit, offset int) ([]models.PatientDocument, error) {
+	documents, err := s.documentRepo.SearchForDoctor(doctorID, repositories.DocumentSearchFilter{
+		Query:     strings.TrimSpace(req.Query),
+		PatientID: req.PatientID,
+		DocType:   req.DocType,
+	}, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	// PHI閲覧ログの記録（患者ごと）
+	counts := make(map[uint]int)
+	var patientIDs []uint
+	for _, document := range documents {
+		if counts[document.PatientID] == 0 {
+			patientIDs = append(patientIDs, document.PatientID)
+		}
+		counts[document.PatientID]++
+	}
+	for _, patientID := range patientIDs {
+		s.auditService.LogPHIAccess(doctorID, patientID, "patient_document_search", "", map[string]interface{}{
+			"query": req.Query,
+			"count": counts[patientID],
+		})
+	}
+
+	return documents, nil
+}
+
+// RunOCRJob 文字認識待ちの診療記録を処理し、種類の推定と検査項目・日付の抽出を行う
+// 失敗した場合は上限回数まで次回以降に再試行する
+func (s *PatientDocumentService) RunOCRJob() error {
+	if s.ocrProvider == nil {
+		return nil
+	}
+
+	documents, err := s.documentRepo.FindOCRProcessable(time.Now().Add(-documentOCRStaleAfter), documentOCRBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range documents {
+		document := &documents[i]
+		claimed, err := s.documentRepo.ClaimOCR(document.ID, document.UpdatedAt)
+		if err != nil {
+			log.Printf("Warning: Failed to claim document %d for OCR: %v", document.ID, err)
+			continue
+		}
+		if !claimed {
+			// 他のインスタンスが処理中
+			continue
+		}
+		document.OCRAttempts++
+		s.recognize(document)
+	}
+	return nil
+}
+
+// recognize 1件の文字認識と抽出
+func (s *PatientDocumentService) recognize(document *models.PatientDocument) {
+	text, err := s.ocrProvider.Recognize(context.Background(), document.FilePath, document.ContentType)
+	if err != nil {
+		log.Printf("Warning: OCR of document %d failed (attempt %d): %v", document.ID, document.OCRAttempts, err)
+		status := "pending"
+		if document.OCRAttempts >= documentOCRMaxAttempts {
+			status = "failed"
+		}
+		if err := s.documentRepo.UpdateOCRStatus(document.ID, status, err.Error()); err != nil {
+			log.Printf("Warning: Failed to update OCR status of document %d: %v", document.ID, err)
+		}
+		return
+	}
+
+	analysis := ocr.Analyze(text)
+	values := make([]models.PatientDocumentValue, 0, len(analysis.Values))
+	for _, value := range analysis.Values {
+		values = append(values, models.PatientDocumentValue{
+			Kind:  value.Kind,
+			Name:  value.Name,
+			Value: value.Value,
+			Unit:  value.Unit,
+			Flag:  value.Flag,
+			Date:  value.Date,
+		})
+	}
+	if err := s.documentRepo.CompleteOCR(document.ID, text, analysis.DocType, s.ocrProvider.Name(), values, time.Now()); err != nil {
+		log.Printf("Warning: Failed to save OCR result of document %d: %v", document.ID, err)
+		return
+	}
+
+	s.auditService.LogSystemAction("document_ocr_completed", "patient_document", fmt.Sprintf("%d", document.ID), map[string]interface{}{
+		"detected_doc_type": analysis.DocType,
+		"values":            len(values),
+	})
+}
+
+// uniqueIDs 重複を除いたID一覧（順序は維持）
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
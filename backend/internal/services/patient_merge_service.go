@@ -0,0 +1,250 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 重複と判定する一致条件（氏名・生年月日・電話番号のうち2項目の一致）
+const (
+	DuplicateReasonNameBirthdate  = "name_birthdate"
+	DuplicateReasonNamePhone      = "name_phone"
+	DuplicateReasonPhoneBirthdate = "phone_birthdate"
+)
+
+type PatientMergeService struct {
+	patientMergeRepo repositories.PatientMergeRepository
+	userRepo         repositories.UserRepository
+	auditService     *AuditService
+}
+
+// DuplicatePatientGroup 重複の可能性がある患者アカウントのグループ
+type DuplicatePatientGroup struct {
+	Reasons  []string                `json:"reasons"`
+	Patients []models.PatientProfile `json:"patients"`
+}
+
+type MergePatientsRequest struct {
+	SurvivorID  uint `json:"survivor_id" binding:"required"`
+	DuplicateID uint `json:"duplicate_id" binding:"required"`
+}
+
+// MergePatientsResult 統合結果
+type MergePatientsResult struct {
+	SurvivorID  uint                             `json:"survivor_id"`
+	DuplicateID uint                             `json:"duplicate_id"`
+	Counts      *repositories.PatientMergeCounts `json:"counts"`
+}
+
+func NewPatientMergeService(patientMergeRepo repositories.PatientMergeRepository, userRepo repositories.UserRepository, auditService *AuditService) *PatientMergeService {
+	return &PatientMergeService{
+		patientMergeRepo: patientMergeRepo,
+		userRepo:         userRepo,
+		auditService:     auditService,
+	}
+}
+
+// FindDuplicates 重複の可能性がある患者アカウントの検出（管理者のみ）
+func (s *PatientMergeService) FindDuplicates(adminID uint) ([]DuplicatePatientGroup, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	profiles, err := s.patientMergeRepo.FindPatientProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	// 一致条件ごとのキーで突き合わせ、一致したアカウント同士を同じグループにまとめる
+	parent := make([]int, len(profiles))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	reasons := make(map[int]map[string]bool)
+	seen := make(map[string]int)
+	for i, profile := range profiles {
+		for reason, key := range duplicateKeys(&profile) {
+			first, ok := seen[reason+"|"+key]
+			if !ok {
+				seen[reason+"|"+key] = i
+				continue
+			}
+			a, b := find(first), find(i)
+			if a != b {
+				parent[b] = a
+				for r := range reasons[b] {
+					addReason(reasons, a, r)
+				}
+				delete(reasons, b)
+			}
+			addReason(reasons, a, reason)
+		}
+	}
+
+	members := make(map[int][]models.PatientProfile)
+	var roots []int
+	for i, profile := range profiles {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], profile)
+	}
+
+	groups := []DuplicatePatientGroup{}
+	for _, root := range roots {
+		if len(members[root]) < 2 {
+			continue
+		}
+		group := DuplicatePatientGroup{Patients: members[root]}
+		for reason := range reasons[root] {
+			group.Reasons = append(group.Reasons, reason)
+		}
+		sort.Strings(group.Reasons)
+		groups = append(groups, group)
+	}
+
+	s.auditService.LogUserAction(adminID, "duplicate_patients_searched", "patient", "", map[string]interface{}{
+		"groups": len(groups),
+	})
+
+	return groups, nil
+}
+
+// MergePatients 重複アカウントを存続アカウントへ統合（管理者のみ）
+func (s *PatientMergeService) MergePatients(adminID uint, req MergePatientsRequest) (*MergePatientsResult, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	if req.SurvivorID == req.DuplicateID {
+		return nil, errors.New("cannot merge a patient into itself")
+	}
+
+	survivor, err := s.findPatientProfile(req.SurvivorID)
+	if err != nil {
+		return nil, err
+	}
+	duplicate, err := s.findPatientProfile(req.DuplicateID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 存続アカウントの未入力項目は重複アカウントの値で補う
+	fillMissingProfileFields(survivor, duplicate)
+
+	counts, err := s.patientMergeRepo.Merge(survivor, req.DuplicateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge patients: %v", err)
+	}
+
+	s.auditService.LogUserAction(adminID, "patients_merged", "patient", fmt.Sprintf("%d", req.SurvivorID), map[string]interface{}{
+		"survivor_id":  req.SurvivorID,
+		"duplicate_id": req.DuplicateID,
+		"counts":       counts,
+	})
+
+	return &MergePatientsResult{
+		SurvivorID:  req.SurvivorID,
+		DuplicateID: req.DuplicateID,
+		Counts:      counts,
+	}, nil
+}
+
+func (s *PatientMergeService) findPatientProfile(userID uint) (*models.PatientProfile, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user == nil || user.Role != "patient" {
+		return nil, fmt.Errorf("patient %d not found", userID)
+	}
+	profile, err := s.userRepo.FindPatientProfileByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("patient profile %d not found", userID)
+	}
+	return profile, nil
+}
+
+func (s *PatientMergeService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
+
+// duplicateKeys 一致条件ごとの比較キー（項目が欠けている条件は含めない）
+func duplicateKeys(profile *models.PatientProfile) map[string]string {
+	name := normalizeName(profile.Name)
+	phone := normalizePhone(profile.Phone)
+	birthdate := ""
+	if profile.Birthdate != nil {
+		birthdate = profile.Birthdate.Format("2006-01-02")
+	}
+
+	keys := make(map[string]string)
+	if name != "" && birthdate != "" {
+		keys[DuplicateReasonNameBirthdate] = name + "|" + birthdate
+	}
+	if name != "" && phone != "" {
+		keys[DuplicateReasonNamePhone] = name + "|" + phone
+	}
+	if phone != "" && birthdate != "" {
+		keys[DuplicateReasonPhoneBirthdate] = phone + "|" + birthdate
+	}
+	return keys
+}
+
+func addReason(reasons map[int]map[string]bool, root int, reason string) {
+	if reasons[root] == nil {
+		reasons[root] = make(map[string]bool)
+	}
+	reasons[root][reason] = true
+}
+
+// normalizeName 空白（全角を含む）を除き小文字化する
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), ""))
+}
+
+// normalizePhone 数字以外を除く
+func normalizePhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phone)
+}
+
+func fillMissingProfileFields(survivor, duplicate *models.PatientProfile) {
+	if survivor.Birthdate == nil {
+		survivor.Birthdate = duplicate.Birthdate
+	}
+	if survivor.Phone == "" {
+		survivor.Phone = duplicate.Phone
+	}
+	if survivor.Address == "" {
+		survivor.Address = duplicate.Address
+	}
+	if survivor.Allergies == nil {
+		survivor.Allergies = duplicate.Allergies
+	}
+	if survivor.InsuranceProvider == "" && survivor.InsuranceNumber == "" {
+		survivor.InsuranceProvider = duplicate.InsuranceProvider
+		survivor.InsuranceNumber = duplicate.InsuranceNumber
+	}
+	if survivor.PricingRegion == "" {
+		survivor.PricingRegion = duplicate.PricingRegion
+	}
+}
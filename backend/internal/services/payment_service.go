@@ -0,0 +1,459 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/payments"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// PaymentService 診療費の請求と支払い
+// 予約の確定時に医師の診療費で請求を作成して決済代行サービスの PaymentIntent を用意し、支払いの結果はWebhookで反映する
+type PaymentService struct {
+	invoiceRepo         repositories.InvoiceRepository
+	appointmentRepo     repositories.AppointmentRepository
+	userRepo            repositories.UserRepository
+	notificationService *NotificationService
+	auditService        *AuditService
+	ledgerService       *LedgerService
+	subscriptionService *SubscriptionService
+	gateway             payments.Gateway // nilの場合はオンライン決済を行わない
+	currency            string
+}
+
+// PaymentCheckout 支払いの開始（フロントエンドで決済代行サービスの画面を表示するための情報）
+type PaymentCheckout struct {
+	Invoice        *models.Invoice `json:"invoice"`
+	Payment        *models.Payment `json:"payment"`
+	Provider       string          `json:"provider"`
+	ClientSecret   string          `json:"client_secret"`
+	PublishableKey string          `json:"publishable_key"`
+}
+
+// ResolveHeldPaymentRequest 確認待ちの支払いの判断（approve: true で承認、false で却下）
+type ResolveHeldPaymentRequest struct {
+	Approve *bool  `json:"approve" binding:"required"`
+	Note    string `json:"note"`
+}
+
+func NewPaymentService(invoiceRepo repositories.InvoiceRepository, appointmentRepo repositories.AppointmentRepository, userRepo repositories.UserRepository, notificationService *NotificationService, auditService *AuditService, ledgerService *LedgerService, subscriptionService *SubscriptionService, gateway payments.Gateway, currency string) *PaymentService {
+	return &PaymentService{
+		invoiceRepo:         invoiceRepo,
+		appointmentRepo:     appointmentRepo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		auditService:        auditService,
+		ledgerService:       ledgerService,
+		subscriptionService: subscriptionService,
+		gateway:             gateway,
+		currency:            strings.ToLower(currency),
+	}
+}
+
+// IssueInvoice 確定した予約の請求の作成（診療費を設定していない医師・請求済みの予約は何もしない）
+// 請求の作成と同時に PaymentIntent を用意し、失敗した場合は患者の支払いの開始時に作り直す
+func (s *PaymentService) IssueInvoice(appointment *models.Appointment) {
+	profile, err := s.userRepo.FindDoctorProfileByUserID(appointment.DoctorID)
+	if err != nil || profile == nil {
+		log.Printf("Warning: Failed to load doctor profile %d for invoice: %v", appointment.DoctorID, err)
+		return
+	}
+	if profile.ConsultationFee <= 0 {
+		return
+	}
+
+	invoice := &models.Invoice{
+		AppointmentID: appointment.ID,
+		PatientID:     appointment.PatientID,
+		DoctorID:      appointment.DoctorID,
+		Amount:        profile.ConsultationFee,
+		Currency:      s.currency,
+		Description:   fmt.Sprintf("診療費（%s）", profile.Name),
+		Status:        "open",
+	}
+	created, err := s.invoiceRepo.CreateIfAbsent(invoice)
+	if err != nil {
+		log.Printf("Warning: Failed to create invoice for appointment %d: %v", appointment.ID, err)
+		return
+	}
+	if !created {
+		return
+	}
+
+	if s.gateway != nil && !s.isDemoPatient(appointment.PatientID) {
+		if _, err := s.createPayment(invoice, 1); err != nil {
+			log.Printf("Warning: Failed to create payment intent for invoice %d: %v", invoice.ID, err)
+		}
+	}
+
+	if _, err := s.notificationService.Notify(appointment.PatientID, NotificationMessage{
+		Type:  "invoice_issued",
+		Title: "診療費のお支払いをお願いします",
+		Body:  fmt.Sprintf("%s %d %s", invoice.Description, invoice.Amount, strings.ToUpper(invoice.Currency)),
+		Data: map[string]interface{}{
+			"invoice_id":     invoice.ID,
+			"appointment_id": appointment.ID,
+			"amount":         invoice.Amount,
+			"currency":       invoice.Currency,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify patient %d of invoice %d: %v", appointment.PatientID, invoice.ID, err)
+	}
+
+	s.auditService.LogSystemAction("invoice_issued", "invoice", fmt.Sprintf("%d", invoice.ID), map[string]interface{}{
+		"appointment_id": appointment.ID,
+		"amount":         invoice.Amount,
+		"currency":       invoice.Currency,
+	})
+}
+
+// GetMyInvoices 自分の請求の一覧（患者用、statusを指定した場合はその状態のみ）
+func (s *PaymentService) GetMyInvoices(patientID uint, status string, limit, offset int) ([]models.Invoice, int64, error) {
+	if status != "" && status != "open" && status != "paid" && status != "void" {
+		return nil, 0, errors.New("invalid invoice status")
+	}
+	return s.invoiceRepo.FindByPatient(patientID, status, limit, offset)
+}
+
+// GetInvoice 請求の取得（請求先の患者・担当医師のみ）
+func (s *PaymentService) GetInvoice(invoiceID, userID uint) (*models.Invoice, error) {
+	invoice, err := s.invoiceRepo.FindByID(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice == nil || (invoice.PatientID != userID && invoice.DoctorID != userID) {
+		return nil, errors.New("invoice not found")
+	}
+	return invoice, nil
+}
+
+// PayInvoice 請求の支払いの開始（請求先の患者のみ、支払い待ちの PaymentIntent があれば再利用する）
+func (s *PaymentService) PayInvoice(invoiceID, patientID uint) (*PaymentCheckout, error) {
+	invoice, err := s.invoiceRepo.FindByID(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice == nil || invoice.PatientID != patientID {
+		return nil, errors.New("invoice not found")
+	}
+	if invoice.Status != "open" {
+		return nil, errors.New("invoice is not payable")
+	}
+	if invoice.Appointment != nil && invoice.Appointment.Status == "cancelled" {
+		return nil, errors.New("appointment is cancelled")
+	}
+	if s.gateway == nil || s.isDemoPatient(patientID) {
+		return nil, errors.New("online payment is not available")
+	}
+
+	payment, err := s.invoiceRepo.FindOpenPayment(invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		if payment, err = s.createPayment(invoice, len(invoice.Payments)+1); err != nil {
+			log.Printf("Warning: Failed to create payment intent for invoice %d: %v", invoice.ID, err)
+			return nil, errors.New("failed to start payment")
+		}
+	}
+
+	s.auditService.LogUserAction(patientID, "payment_started", "invoice", fmt.Sprintf("%d", invoice.ID), map[string]interface{}{
+		"payment_id": payment.ID,
+	})
+
+	return &PaymentCheckout{
+		Invoice:        invoice,
+		Payment:        payment,
+		Provider:       s.gateway.Name(),
+		ClientSecret:   payment.ClientSecret,
+		PublishableKey: s.gateway.PublishableKey(),
+	}, nil
+}
+
+// HandleWebhook 決済代行サービスからの支払いの結果の反映（署名を検証し、同じイベントの再送は無視する）
+func (s *PaymentService) HandleWebhook(payload []byte, signature string) error {
+	if s.gateway == nil {
+		return errors.New("online payment is not available")
+	}
+	event, err := s.gateway.ParseWebhook(payload, signature)
+	if err != nil {
+		return err
+	}
+	if event.IntentID == "" || event.Status == "" {
+		// 支払いの状態に関係しないイベント
+		return nil
+	}
+
+	payment, err := s.invoiceRepo.FindPaymentByIntentID(event.IntentID)
+	if err != nil {
+		return err
+	}
+	if payment == nil {
+		// 診療費でない支払いは有料プランの支払いとして反映する
+		handled, err := s.subscriptionService.HandlePaymentEvent(event)
+		if err != nil {
+			return err
+		}
+		if !handled {
+			log.Printf("Warning: Received %s for unknown payment intent %s", event.Type, event.IntentID)
+		}
+		return nil
+	}
+
+	// 金額・通貨が請求と一致しない支払いは請求・元帳に反映せず、管理者の確認待ちにする
+	if event.Status == payments.StatusSucceeded {
+		reason, err := s.paymentMismatch(payment, event)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			s.holdPayment(payment, event, reason)
+			return nil
+		}
+	}
+
+	updated, err := s.invoiceRepo.UpdatePaymentStatus(payment.ID, event.Status, event.FailureMessage)
+	if err != nil {
+		return err
+	}
+
+	switch event.Status {
+	case payments.StatusSucceeded:
+		// 請求の更新に失敗した後の再送でも反映されるよう、支払いが更新済みでも請求を確認する
+		return s.markInvoicePaid(payment, updated)
+	case payments.StatusFailed:
+		if updated {
+			s.notifyPaymentFailed(payment, event.FailureMessage)
+		}
+	}
+	return nil
+}
+
+// markInvoicePaid 支払いの完了による請求の支払い済みへの更新、元帳への記帳と患者・担当医師への通知
+// 記帳に失敗した場合はエラーを返し、Webhookの再送で記帳し直す（記帳は支払いごとに1回のみ）
+func (s *PaymentService) markInvoicePaid(payment *models.Payment, newlySucceeded bool) error {
+	paidAt := time.Now()
+	paid, err := s.invoiceRepo.MarkPaid(payment.InvoiceID, paidAt)
+	if err != nil {
+		return err
+	}
+
+	invoice, err := s.invoiceRepo.FindByID(payment.InvoiceID)
+	if err != nil {
+		return err
+	}
+	if invoice == nil {
+		log.Printf("Warning: Failed to load paid invoice %d", payment.InvoiceID)
+		return nil
+	}
+	if err := s.ledgerService.RecordPayment(invoice, payment); err != nil {
+		return fmt.Errorf("failed to record payment %d in ledger: %w", payment.ID, err)
+	}
+
+	if !paid {
+		if newlySucceeded {
+			// 支払い済みの請求への二重の支払い（患者のウォレットに計上し、返金は管理者が決済代行サービスで行う）
+			log.Printf("Warning: Payment %d succeeded for invoice %d which is not open", payment.ID, payment.InvoiceID)
+		}
+		return nil
+	}
+
+	data := map[string]interface{}{
+		"invoice_id":     invoice.ID,
+		"appointment_id": invoice.AppointmentID,
+		"amount":         invoice.Amount,
+		"currency":       invoice.Currency,
+	}
+	if _, err := s.notificationService.Notify(invoice.PatientID, NotificationMessage{
+		Type:  "invoice_paid",
+		Title: "診療費のお支払いが完了しました",
+		Data:  data,
+	}); err != nil {
+		log.Printf("Warning: Failed to notify patient %d of paid invoice %d: %v", invoice.PatientID, invoice.ID, err)
+	}
+	if _, err := s.notificationService.Notify(invoice.DoctorID, NotificationMessage{
+		Type:  "invoice_paid",
+		Title: "患者が診療費を支払いました",
+		Data:  data,
+	}); err != nil {
+		log.Printf("Warning: Failed to notify doctor %d of paid invoice %d: %v", invoice.DoctorID, invoice.ID, err)
+	}
+
+	s.auditService.LogSystemAction("invoice_paid", "invoice", fmt.Sprintf("%d", invoice.ID), map[string]interface{}{
+		"payment_id": payment.ID,
+		"amount":     payment.Amount,
+	})
+	return nil
+}
+
+// paymentMismatch 完了した支払いの金額・通貨と請求の照合（一致する場合は空）
+func (s *PaymentService) paymentMismatch(payment *models.Payment, event *payments.Event) (string, error) {
+	invoice, err := s.invoiceRepo.FindByID(payment.InvoiceID)
+	if err != nil {
+		return "", err
+	}
+	if invoice == nil {
+		return "invoice not found", nil
+	}
+	if event.Amount != invoice.Amount {
+		return fmt.Sprintf("amount mismatch: paid %d, invoiced %d", event.Amount, invoice.Amount), nil
+	}
+	if event.Currency != strings.ToLower(invoice.Currency) {
+		return fmt.Sprintf("currency mismatch: paid %q, invoiced %q", event.Currency, invoice.Currency), nil
+	}
+	return "", nil
+}
+
+// holdPayment 請求と一致しない支払いの確認待ちへの更新と管理者への通知（返金・再請求は管理者が判断する）
+func (s *PaymentService) holdPayment(payment *models.Payment, event *payments.Event, reason string) {
+	held, err := s.invoiceRepo.HoldPayment(payment.ID, reason)
+	if err != nil {
+		log.Printf("Warning: Failed to hold payment %d for review: %v", payment.ID, err)
+		return
+	}
+	if !held {
+		return
+	}
+	log.Printf("Warning: Payment %d for invoice %d held for review: %s", payment.ID, payment.InvoiceID, reason)
+
+	s.auditService.LogSystemAction("payment_held_for_review", "payment", fmt.Sprintf("%d", payment.ID), map[string]interface{}{
+		"invoice_id": payment.InvoiceID,
+		"amount":     event.Amount,
+		"currency":   event.Currency,
+		"reason":     reason,
+	})
+
+	admins, err := s.userRepo.FindByRole("admin")
+	if err != nil {
+		log.Printf("Warning: Failed to find admins for held payment %d: %v", payment.ID, err)
+	}
+	adminIDs := make([]uint, 0, len(admins))
+	for _, admin := range admins {
+		adminIDs = append(adminIDs, admin.ID)
+	}
+	s.notificationService.NotifyMany(adminIDs, NotificationMessage{
+		Type:     "payment_held_for_review",
+		Title:    "請求と一致しない支払いを確認待ちにしました",
+		Body:     reason,
+		Priority: "high",
+		Data: map[string]interface{}{
+			"payment_id": payment.ID,
+			"invoice_id": payment.InvoiceID,
+		},
+	})
+}
+
+// ResolveHeldPayment 確認待ちの支払いの管理者の判断（承認した場合は完了した支払いとして請求・元帳に反映する）
+// 却下した支払いは失敗として記録し、返金は管理者が決済代行サービスで行う
+func (s *PaymentService) ResolveHeldPayment(adminID, paymentID uint, req ResolveHeldPaymentRequest) (*models.Payment, error) {
+	approve := req.Approve != nil && *req.Approve
+	note := strings.TrimSpace(req.Note)
+	admin, err := s.userRepo.FindByID(adminID)
+	if err != nil || !policy.IsAdmin(admin) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	payment, err := s.invoiceRepo.FindPaymentByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, errors.New("payment not found")
+	}
+	if payment.Status != "requires_review" {
+		return nil, errors.New("payment is not held for review")
+	}
+
+	status, failureMessage, action := payments.StatusSucceeded, "", "payment_review_approved"
+	if !approve {
+		status, failureMessage, action = payments.StatusFailed, "rejected on review", "payment_review_rejected"
+		if note != "" {
+			failureMessage += ": " + note
+		}
+	}
+	resolved, err := s.invoiceRepo.ResolveHeldPayment(payment.ID, status, failureMessage)
+	if err != nil {
+		return nil, err
+	}
+	if !resolved {
+		return nil, errors.New("payment is not held for review")
+	}
+	payment.Status = status
+	payment.FailureMessage = failureMessage
+
+	s.auditService.LogUserAction(adminID, action, "payment", fmt.Sprintf("%d", payment.ID), map[string]interface{}{
+		"invoice_id":    payment.InvoiceID,
+		"review_reason": payment.ReviewReason,
+		"note":          note,
+	})
+
+	if approve {
+		if err := s.markInvoicePaid(payment, true); err != nil {
+			return nil, err
+		}
+	}
+	return payment, nil
+}
+
+// notifyPaymentFailed 支払いの失敗の患者への通知
+func (s *PaymentService) notifyPaymentFailed(payment *models.Payment, reason string) {
+	invoice, err := s.invoiceRepo.FindByID(payment.InvoiceID)
+	if err != nil || invoice == nil {
+		log.Printf("Warning: Failed to load invoice %d for failed payment: %v", payment.InvoiceID, err)
+		return
+	}
+	if _, err := s.notificationService.Notify(invoice.PatientID, NotificationMessage{
+		Type:     "payment_failed",
+		Title:    "診療費のお支払いができませんでした",
+		Body:     reason,
+		Priority: "high",
+		Data: map[string]interface{}{
+			"invoice_id":     invoice.ID,
+			"appointment_id": invoice.AppointmentID,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify patient %d of failed payment %d: %v", invoice.PatientID, payment.ID, err)
+	}
+}
+
+// createPayment 請求の PaymentIntent の作成（attemptは同じ請求での作成回数、重複作成を防ぐキーに使う）
+func (s *PaymentService) createPayment(invoice *models.Invoice, attempt int) (*models.Payment, error) {
+	intent, err := s.gateway.CreateIntent(context.Background(), payments.IntentParams{
+		Amount:      invoice.Amount,
+		Currency:    invoice.Currency,
+		Description: invoice.Description,
+		Metadata: map[string]string{
+			"invoice_id":     fmt.Sprintf("%d", invoice.ID),
+			"appointment_id": fmt.Sprintf("%d", invoice.AppointmentID),
+		},
+		IdempotencyKey: fmt.Sprintf("invoice-%d-%d", invoice.ID, attempt),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	payment := &models.Payment{
+		InvoiceID:        invoice.ID,
+		Provider:         s.gateway.Name(),
+		ProviderIntentID: intent.ID,
+		ClientSecret:     intent.ClientSecret,
+		Amount:           intent.Amount,
+		Currency:         intent.Currency,
+		Status:           intent.Status,
+	}
+	if err := s.invoiceRepo.CreatePayment(payment); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (s *PaymentService) isDemoPatient(patientID uint) bool {
+	patient, err := s.userRepo.FindByID(patientID)
+	return err == nil && patient != nil && patient.IsDemo
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 実績の集計単位と期間
+const (
+	performancePeriodWeek     = "week"
+	performancePeriodMonth    = "month"
+	performanceDefaultPeriods = 3
+	performanceMaxPeriods     = 53 // 週の場合は約1年、月の場合は4年余り
+)
+
+var performanceExportHeaders = []string{
+	"doctor_id", "doctor_name", "period_start", "appointments_handled", "cancelled",
+	"average_consultation_minutes", "satisfaction_score", "satisfaction_responses",
+	"prescriptions", "no_shows", "no_show_rate", "late_starts", "average_delay_minutes",
+}
+
+type PerformanceService struct {
+	appointmentRepo      repositories.AppointmentRepository
+	userRepo             repositories.UserRepository
+	bookingPolicyService *BookingPolicyService
+}
+
+// PerformanceMetrics 医師の実績
+// 診療時間は終了したビデオ通話の合計時間の予約あたりの平均
+// 無断キャンセル（no-show）は診療枠が終了してもビデオ通話が一度も開始されなかった確定済み・完了の予約で、
+// 割合は診療枠が終了した確定済み・完了の予約に対する比率
+// 遅れは医師が遅れを連絡した予約の件数と、その予約での連絡した遅れの平均
+type PerformanceMetrics struct {
+	AppointmentsHandled        int      `json:"appointments_handled"`
+	Cancelled                  int      `json:"cancelled"`
+	AverageConsultationMinutes float64  `json:"average_consultation_minutes"`
+	SatisfactionScore          *float64 `json:"satisfaction_score"` // 評価の平均（1〜5、評価がない場合はnull）
+	SatisfactionResponses      int      `json:"satisfaction_responses"`
+	Prescriptions              int64    `json:"prescriptions"`
+	NoShows                    int      `json:"no_shows"`
+	NoShowRate                 float64  `json:"no_show_rate"`
+	LateStarts                 int      `json:"late_starts"`
+	AverageDelayMinutes        float64  `json:"average_delay_minutes"`
+
+	videoSeconds  float64
+	videoSessions int
+	ratingTotal   int
+	attendable    int
+	delayMinutes  int
+}
+
+// PerformanceRow 医師ごと・期間ごとの実績
+type PerformanceRow struct {
+	DoctorID    uint   `json:"doctor_id"`
+	DoctorName  string `json:"doctor_name"`
+	PeriodStart string `json:"period_start"`
+	PerformanceMetrics
+}
+
+// PerformanceReport 期間内の医師の実績
+type PerformanceReport struct {
+	From   time.Time          `json:"from"`
+	To     time.Time          `json:"to"`
+	Period string             `json:"period"`
+	Rows   []PerformanceRow   `json:"rows"`
+	Totals PerformanceMetrics `json:"totals"`
+}
+
+// PerformanceExport 実績のCSV
+type PerformanceExport struct {
+	Filename string
+	Data     []byte
+}
+
+func NewPerformanceService(appointmentRepo repositories.AppointmentRepository, userRepo repositories.UserRepository, bookingPolicyService *BookingPolicyService) *PerformanceService {
+	return &PerformanceService{
+		appointmentRepo:      appointmentRepo,
+		userRepo:             userRepo,
+		bookingPolicyService: bookingPolicyService,
+	}
+}
+
+// GetPerformance 医師ごと・期間（週または月）ごとの実績の取得（管理者のみ、doctorID指定時はその医師）
+// 予約は診療枠の開始日時（枠のない即時・非同期相談は予約日時）の期間に数える
+func (s *PerformanceService) GetPerformance(adminID, doctorID uint, period, from, to string) (*PerformanceReport, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	if period == "" {
+		period = performancePeriodMonth
+	}
+	if period != performancePeriodWeek && period != performancePeriodMonth {
+		return nil, errors.New("period must be week or month")
+	}
+
+	location, err := s.bookingPolicyService.Location()
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := performanceRange(period, from, to, location)
+	if err != nil {
+		return nil, err
+	}
+
+	appointments, err := s.appointmentRepo.FindPerformance(start, end, doctorID)
+	if err != nil {
+		return nil, err
+	}
+	names, err := s.doctorNames()
+	if err != nil {
+		return nil, err
+	}
+
+	type rowKey struct {
+		doctorID    uint
+		periodStart time.Time
+	}
+	rows := make(map[rowKey]*PerformanceRow)
+	var order []rowKey
+	report := &PerformanceReport{From: start, To: end, Period: period, Rows: []PerformanceRow{}}
+	now := time.Now()
+
+	for _, appointment := range appointments {
+		key := rowKey{doctorID: appointment.DoctorID, periodStart: periodStart(appointment.ScheduledAt, period, location)}
+		row, ok := rows[key]
+		if !ok {
+			row = &PerformanceRow{DoctorID: appointment.DoctorID, DoctorName: names[appointment.DoctorID], PeriodStart: key.periodStart.Format("2006-01-02")}
+			rows[key] = row
+			order = append(order, key)
+		}
+		row.add(appointment, now)
+		report.Totals.add(appointment, now)
+	}
+
+	// 医師ID・期間の順（予約の取得順）で並べる
+	for _, key := range order {
+		row := rows[key]
+		row.finish()
+		report.Rows = append(report.Rows, *row)
+	}
+	report.Totals.finish()
+
+	return report, nil
+}
+
+// ExportPerformance 医師ごと・期間ごとの実績をCSVで出力
+func (s *PerformanceService) ExportPerformance(adminID, doctorID uint, period, from, to string) (*PerformanceExport, error) {
+	report, err := s.GetPerformance(adminID, doctorID, period, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(performanceExportHeaders); err != nil {
+		return nil, err
+	}
+	for _, row := range report.Rows {
+		score := ""
+		if row.SatisfactionScore != nil {
+			score = formatHours(*row.SatisfactionScore)
+		}
+		if err := writer.Write([]string{
+			strconv.FormatUint(uint64(row.DoctorID), 10),
+			row.DoctorName,
+			row.PeriodStart,
+			strconv.Itoa(row.AppointmentsHandled),
+			strconv.Itoa(row.Cancelled),
+			formatHours(row.AverageConsultationMinutes),
+			score,
+			strconv.Itoa(row.SatisfactionResponses),
+			strconv.FormatInt(row.Prescriptions, 10),
+			strconv.Itoa(row.NoShows),
+			formatHours(row.NoShowRate),
+			strconv.Itoa(row.LateStarts),
+			formatHours(row.AverageDelayMinutes),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+
+	return &PerformanceExport{
+		Filename: fmt.Sprintf("doctor_performance_%s_%s_%s.csv", report.Period, report.From.Format("20060102"), report.To.AddDate(0, 0, -1).Format("20060102")),
+		Data:     buf.Bytes(),
+	}, nil
+}
+
+func (s *PerformanceService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
+
+func (s *PerformanceService) doctorNames() (map[uint]string, error) {
+	doctors, err := s.userRepo.FindDoctors("")
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(doctors))
+	for _, doctor := range doctors {
+		names[doctor.UserID] = doctor.Name
+	}
+	return names, nil
+}
+
+// add 予約の実績を加算する
+func (m *PerformanceMetrics) add(appointment repositories.AppointmentPerformance, now time.Time) {
+	switch appointment.Status {
+	case "cancelled":
+		m.Cancelled++
+		return
+	case "completed":
+		m.AppointmentsHandled++
+	}
+
+	m.Prescriptions += appointment.Prescriptions
+	if appointment.VideoSeconds > 0 {
+		m.videoSeconds += appointment.VideoSeconds
+		m.videoSessions++
+	}
+	if appointment.Rating != nil {
+		m.ratingTotal += *appointment.Rating
+		m.SatisfactionResponses++
+	}
+	if appointment.DelayMinutes > 0 {
+		m.LateStarts++
+		m.delayMinutes += appointment.DelayMinutes
+	}
+
+	if appointment.Status != "pending" && !appointment.IsAsync && appointment.SlotEndTime != nil && appointment.SlotEndTime.Before(now) {
+		m.attendable++
+		if !appointment.VideoStarted {
+			m.NoShows++
+		}
+	}
+}
+
+// finish 平均・割合の計算
+func (m *PerformanceMetrics) finish() {
+	if m.videoSessions > 0 {
+		m.AverageConsultationMinutes = roundHours(m.videoSeconds / float64(m.videoSessions) / 60)
+	}
+	if m.SatisfactionResponses > 0 {
+		score := roundHours(float64(m.ratingTotal) / float64(m.SatisfactionResponses))
+		m.SatisfactionScore = &score
+	}
+	if m.attendable > 0 {
+		m.NoShowRate = roundHours(float64(m.NoShows) / float64(m.attendable))
+	}
+	if m.LateStarts > 0 {
+		m.AverageDelayMinutes = roundHours(float64(m.delayMinutes) / float64(m.LateStarts))
+	}
+}
+
+// performanceRange 集計期間（週・月単位に切り上げ、toは指定日を含む）
+// 未指定の場合は今週・今月を含む直近3期間
+func performanceRange(period, from, to string, location *time.Location) (time.Time, time.Time, error) {
+	end := nextPeriod(periodStart(time.Now(), period, location), period, 1)
+	if to != "" {
+		date, err := time.ParseInLocation("2006-01-02", to, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid to date (expected YYYY-MM-DD)")
+		}
+		end = nextPeriod(periodStart(date, period, location), period, 1)
+	}
+
+	start := nextPeriod(end, period, -performanceDefaultPeriods)
+	if from != "" {
+		date, err := time.ParseInLocation("2006-01-02", from, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid from date (expected YYYY-MM-DD)")
+		}
+		start = periodStart(date, period, location)
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, errors.New("from must not be after to")
+	}
+	if nextPeriod(start, period, performanceMaxPeriods).Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("range must be at most %d %ss", performanceMaxPeriods, period)
+	}
+	return start, end, nil
+}
+
+// periodStart 指定時刻を含む週（月曜日）・月（1日）の0時
+func periodStart(t time.Time, period string, location *time.Location) time.Time {
+	if period == performancePeriodWeek {
+		return weekStart(t, location)
+	}
+	local := t.In(location)
+	return time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, location)
+}
+
+// nextPeriod 期間の先頭からn期間後（負の場合は前）の先頭
+func nextPeriod(start time.Time, period string, n int) time.Time {
+	if period == performancePeriodWeek {
+		return start.AddDate(0, 0, 7*n)
+	}
+	return start.AddDate(0, n, 0)
+}
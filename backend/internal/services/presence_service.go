@@ -0,0 +1,174 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/realtime"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// ハートビートが途絶えてからオフライン扱いにするまでの時間
+const doctorPresenceTimeout = 3 * time.Minute
+
+// ユーザーのオンライン状態
+const (
+	// 最終アクティビティからオンライン扱いにする時間（接続中のユーザーは定期ジョブで更新する）
+	userPresenceTimeout = 3 * time.Minute
+	// API利用による最終アクティビティの書き込み間隔（同じユーザーの書き込みを間引く）
+	presenceTouchInterval = time.Minute
+)
+
+type PresenceService struct {
+	userRepo        repositories.UserRepository
+	appointmentRepo repositories.AppointmentRepository
+	hub             *realtime.Hub
+
+	mu      sync.Mutex
+	touched map[uint]time.Time
+}
+
+type UpdatePresenceRequest struct {
+	Online *bool `json:"online" binding:"required"`
+}
+
+type PresenceSettingsRequest struct {
+	HidePresence *bool `json:"hide_presence" binding:"required"`
+}
+
+// UserPresence 他のユーザーに表示するオンライン状態（非表示設定のユーザーはhiddenとし最終アクセスを含めない）
+type UserPresence struct {
+	UserID     uint       `json:"user_id"`
+	Status     string     `json:"status"` // online | offline | hidden
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+}
+
+// PresenceSettings 自身のオンライン状態と表示設定
+type PresenceSettings struct {
+	HidePresence bool       `json:"hide_presence"`
+	Status       string     `json:"status"`
+	LastSeenAt   *time.Time `json:"last_seen_at"`
+}
+
+func NewPresenceService(userRepo repositories.UserRepository, appointmentRepo repositories.AppointmentRepository, hub *realtime.Hub) *PresenceService {
+	return &PresenceService{
+		userRepo:        userRepo,
+		appointmentRepo: appointmentRepo,
+		hub:             hub,
+		touched:         make(map[uint]time.Time),
+	}
+}
+
+// UpdatePresence 医師のオンライン状態の更新（オンライン中は定期的に呼び出してハートビートとする）
+func (s *PresenceService) UpdatePresence(doctorID uint, online bool) (*models.DoctorProfile, error) {
+	user, err := s.userRepo.FindByID(doctorID)
+	if err != nil || user == nil || user.Role != "doctor" {
+		return nil, errors.New("doctor not found")
+	}
+
+	if err := s.userRepo.UpdateDoctorPresence(doctorID, online, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.FindDoctorProfileByUserID(doctorID)
+}
+
+// GetOnlineDoctors 即時診療を受付中の医師一覧の取得（言語指定時はその言語で診療できる医師のみ）
+func (s *PresenceService) GetOnlineDoctors(viewerID uint, language string) ([]models.DoctorProfile, error) {
+	doctors, err := s.userRepo.FindOnlineDoctors(time.Now().Add(-doctorPresenceTimeout), normalizeLanguageCode(language))
+	if err != nil {
+		return nil, err
+	}
+	return visibleDoctors(s.userRepo, viewerID, doctors), nil
+}
+
+// RecordActivity API利用による最終アクティビティの記録（一定間隔で間引く）
+func (s *PresenceService) RecordActivity(userID uint) {
+	now := time.Now()
+	s.mu.Lock()
+	if last, ok := s.touched[userID]; ok && now.Sub(last) < presenceTouchInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.touched[userID] = now
+	s.mu.Unlock()
+
+	s.touch([]uint{userID}, now)
+}
+
+// UserConnected WebSocket接続時の最終アクティビティの記録
+func (s *PresenceService) UserConnected(userID uint) {
+	s.touch([]uint{userID}, time.Now())
+}
+
+// UserDisconnected WebSocket切断時の最終アクティビティの記録
+func (s *PresenceService) UserDisconnected(userID uint) {
+	s.touch([]uint{userID}, time.Now())
+}
+
+// RunHeartbeatJob このインスタンスにWebSocket接続中のユーザーの最終アクティビティを更新する
+func (s *PresenceService) RunHeartbeatJob() error {
+	return s.userRepo.TouchLastSeen(s.hub.ConnectedUserIDs(), time.Now())
+}
+
+// GetPresenceSettings 自身のオンライン状態と表示設定の取得
+func (s *PresenceService) GetPresenceSettings(userID uint) (*PresenceSettings, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, errors.New("user not found")
+	}
+	presence := UserPresenceOf(userID, user)
+	return &PresenceSettings{HidePresence: user.HidePresence, Status: presence.Status, LastSeenAt: user.LastSeenAt}, nil
+}
+
+// UpdatePresenceSettings オンライン状態を他のユーザーに表示するかの設定
+func (s *PresenceService) UpdatePresenceSettings(userID uint, hide bool) (*PresenceSettings, error) {
+	if err := s.userRepo.SetHidePresence(userID, hide); err != nil {
+		return nil, err
+	}
+	return s.GetPresenceSettings(userID)
+}
+
+// GetAppointmentPresence 予約の参加者（患者・医師・通訳者）のオンライン状態（チャット画面用）
+func (s *PresenceService) GetAppointmentPresence(appointmentID, viewerID uint) ([]UserPresence, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if !appointment.IsParticipant(viewerID) {
+		return nil, errors.New("unauthorized to view presence for this appointment")
+	}
+
+	users, err := s.userRepo.FindByIDs(appointment.ParticipantIDs())
+	if err != nil {
+		return nil, err
+	}
+	presences := make([]UserPresence, 0, len(users))
+	for i := range users {
+		presences = append(presences, UserPresenceOf(viewerID, &users[i]))
+	}
+	return presences, nil
+}
+
+// UserPresenceOf 閲覧者に表示するユーザーのオンライン状態（本人には非表示設定でも表示する）
+func UserPresenceOf(viewerID uint, user *models.User) UserPresence {
+	presence := UserPresence{UserID: user.ID, Status: "offline"}
+	if user.HidePresence && user.ID != viewerID {
+		presence.Status = "hidden"
+		return presence
+	}
+	presence.LastSeenAt = user.LastSeenAt
+	if user.LastSeenAt != nil && time.Since(*user.LastSeenAt) < userPresenceTimeout {
+		presence.Status = "online"
+	}
+	return presence
+}
+
+func (s *PresenceService) touch(userIDs []uint, at time.Time) {
+	if err := s.userRepo.TouchLastSeen(userIDs, at); err != nil {
+		log.Printf("Warning: Failed to record last seen for users %v: %v", userIDs, err)
+	}
+}
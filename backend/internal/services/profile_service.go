@@ -0,0 +1,301 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"online_medical_consultation_app/backend/internal/drugpricing"
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// プロフィール項目の最大文字数
+const (
+	profileNameMaxLength    = 100
+	profileTextMaxLength    = 255
+	profileBioMaxLength     = 2000
+	profileAllergyMaxLength = 1000
+)
+
+// 問診の提出期限として設定できる最大時間（1週間）
+const intakeLeadHoursMax = 7 * 24
+
+// 医師が設定できる診療費の上限（決済通貨の最小単位）
+const consultationFeeMax = 1000000
+
+// 電話番号の形式（数字・ハイフン・括弧・空白、先頭の+のみ許可）
+var phonePattern = regexp.MustCompile(`^\+?[0-9()\- ]{7,20}$`)
+
+type ProfileService struct {
+	userRepo        repositories.UserRepository
+	brandingService *BrandingService
+}
+
+// DoctorProfileRequest 医師プロフィールの部分更新（nilの項目は変更しない）
+type DoctorProfileRequest struct {
+	Name            *string  `json:"name"`
+	Specialty       *string  `json:"specialty"`
+	LicenseNumber   *string  `json:"license_number"`
+	Bio             *string  `json:"bio"`
+	Languages       []string `json:"languages"`         // 診療可能な言語コード
+	IntakeLeadHours *int     `json:"intake_lead_hours"` // 診療開始の何時間前までに問診の完了を求めるか（0: 求めない）
+	ConsultationFee *int64   `json:"consultation_fee"`  // 診療費（決済通貨の最小単位、0: 請求しない）
+
+	// 旧フロントエンドとの互換用（license_number が優先）
+	LegacyLicenseNumber *string `json:"licenseNumber"`
+}
+
+// PatientProfileRequest 患者プロフィールの部分更新（nilの項目は変更しない）
+type PatientProfileRequest struct {
+	Name              *string    `json:"name"`
+	Birthdate         *time.Time `json:"birthdate"`
+	Phone             *string    `json:"phone"`
+	Address           *string    `json:"address"`
+	Allergies         *string    `json:"allergies"`
+	InsuranceProvider *string    `json:"insurance_provider"`
+	InsuranceNumber   *string    `json:"insurance_number"`
+	PricingRegion     *string    `json:"pricing_region"`
+}
+
+func NewProfileService(userRepo repositories.UserRepository, brandingService *BrandingService) *ProfileService {
+	return &ProfileService{
+		userRepo:        userRepo,
+		brandingService: brandingService,
+	}
+}
+
+// GetDoctorProfile 医師プロフィールの取得
+func (s *ProfileService) GetDoctorProfile(userID uint) (*models.DoctorProfile, error) {
+	profile, err := s.userRepo.FindDoctorProfileByUserID(userID)
+	if err != nil || profile == nil {
+		return nil, errors.New("profile not found")
+	}
+	return profile, nil
+}
+
+// UpdateDoctorProfile 医師プロフィールの更新
+func (s *ProfileService) UpdateDoctorProfile(userID uint, req DoctorProfileRequest) (*models.DoctorProfile, error) {
+	profile, err := s.GetDoctorProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.LicenseNumber == nil {
+		req.LicenseNumber = req.LegacyLicenseNumber
+	}
+
+	if req.Name != nil {
+		name, err := normalizeProfileName(*req.Name)
+		if err != nil {
+			return nil, err
+		}
+		profile.Name = name
+	}
+	if req.Specialty != nil {
+		specialty, err := normalizeProfileText("specialty", *req.Specialty, profileTextMaxLength)
+		if err != nil {
+			return nil, err
+		}
+		profile.Specialty = specialty
+	}
+	if req.LicenseNumber != nil {
+		licenseNumber, err := normalizeProfileText("license_number", *req.LicenseNumber, profileTextMaxLength)
+		if err != nil {
+			return nil, err
+		}
+		profile.LicenseNumber = licenseNumber
+	}
+	if req.Bio != nil {
+		bio, err := normalizeProfileText("bio", *req.Bio, profileBioMaxLength)
+		if err != nil {
+			return nil, err
+		}
+		profile.Bio = bio
+	}
+	if req.Languages != nil {
+		languages := JoinLanguageCodes(req.Languages)
+		if languages == "" {
+			return nil, errors.New("at least one language is required")
+		}
+		profile.Languages = languages
+	}
+	if req.IntakeLeadHours != nil {
+		if *req.IntakeLeadHours < 0 || *req.IntakeLeadHours > intakeLeadHoursMax {
+			return nil, errors.New("intake_lead_hours must be between 0 and 168")
+		}
+		profile.IntakeLeadHours = *req.IntakeLeadHours
+	}
+	if req.ConsultationFee != nil {
+		if *req.ConsultationFee < 0 || *req.ConsultationFee > consultationFeeMax {
+			return nil, errors.New("consultation_fee must be between 0 and 1000000")
+		}
+		profile.ConsultationFee = *req.ConsultationFee
+	}
+
+	if err := s.userRepo.UpdateDoctorProfile(profile); err != nil {
+		return nil, errors.New("failed to update profile")
+	}
+
+	return profile, nil
+}
+
+// DoctorListing 医師一覧の項目（プロフィールとオンライン状態）
+type DoctorListing struct {
+	models.DoctorProfile
+	Presence UserPresence `json:"presence"`
+}
+
+// DoctorDirectory 医師一覧とクリニックの表記
+type DoctorDirectory struct {
+	Doctors  []DoctorListing        `json:"doctors"`
+	Total    int64                  `json:"total"`
+	Branding *models.ClinicBranding `json:"branding"`
+}
+
+// ListDoctors 医師一覧の取得（言語指定時はその言語で診療できる医師のみ）
+func (s *ProfileService) ListDoctors(viewerID uint, language string, limit, offset int) (*DoctorDirectory, error) {
+	// 閲覧者と同じ種別（デモ・実アカウント）の医師のみ
+	isDemo := false
+	if viewer, err := s.userRepo.FindByID(viewerID); err == nil && viewer != nil {
+		isDemo = viewer.IsDemo
+	}
+	doctors, total, err := s.userRepo.FindDoctorPage(normalizeLanguageCode(language), isDemo, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	listings := make([]DoctorListing, len(doctors))
+	for i, doctor := range doctors {
+		listings[i] = DoctorListing{DoctorProfile: doctor, Presence: UserPresenceOf(viewerID, &doctor.User)}
+	}
+
+	branding, err := s.brandingService.GetBranding()
+	if err != nil {
+		return nil, err
+	}
+	return &DoctorDirectory{Doctors: listings, Total: total, Branding: branding}, nil
+}
+
+// visibleDoctors 閲覧者と同じ種別（デモ・実アカウント）の医師のみに絞り込む
+func visibleDoctors(userRepo repositories.UserRepository, viewerID uint, doctors []models.DoctorProfile) []models.DoctorProfile {
+	isDemo := false
+	if viewer, err := userRepo.FindByID(viewerID); err == nil && viewer != nil {
+		isDemo = viewer.IsDemo
+	}
+
+	visible := make([]models.DoctorProfile, 0, len(doctors))
+	for _, doctor := range doctors {
+		if doctor.User.IsDemo == isDemo {
+			visible = append(visible, doctor)
+		}
+	}
+	return visible
+}
+
+// GetPatientProfile 患者プロフィールの取得
+func (s *ProfileService) GetPatientProfile(userID uint) (*models.PatientProfile, error) {
+	profile, err := s.userRepo.FindPatientProfileByUserID(userID)
+	if err != nil || profile == nil {
+		return nil, errors.New("profile not found")
+	}
+	return profile, nil
+}
+
+// UpdatePatientProfile 患者プロフィールの更新
+func (s *ProfileService) UpdatePatientProfile(userID uint, req PatientProfileRequest) (*models.PatientProfile, error) {
+	profile, err := s.GetPatientProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name, err := normalizeProfileName(*req.Name)
+		if err != nil {
+			return nil, err
+		}
+		profile.Name = name
+	}
+	if req.Birthdate != nil {
+		if req.Birthdate.After(time.Now()) {
+			return nil, errors.New("birthdate must not be in the future")
+		}
+		profile.Birthdate = req.Birthdate
+	}
+	if req.Phone != nil {
+		phone := strings.TrimSpace(*req.Phone)
+		if phone != "" && !phonePattern.MatchString(phone) {
+			return nil, errors.New("invalid phone number")
+		}
+		// 登録済みの番号の変更はSMS認証（/auth/me/phone）を経由させる
+		if profile.Phone != "" && phone != profile.Phone {
+			return nil, errors.New("registered phone number must be changed via phone verification")
+		}
+		profile.Phone = phone
+	}
+	if req.Address != nil {
+		address, err := normalizeProfileText("address", *req.Address, profileTextMaxLength)
+		if err != nil {
+			return nil, err
+		}
+		profile.Address = address
+	}
+	if req.Allergies != nil {
+		allergies, err := normalizeProfileText("allergies", *req.Allergies, profileAllergyMaxLength)
+		if err != nil {
+			return nil, err
+		}
+		profile.Allergies = &allergies
+	}
+	if req.InsuranceProvider != nil {
+		provider, err := normalizeProfileText("insurance_provider", *req.InsuranceProvider, profileTextMaxLength)
+		if err != nil {
+			return nil, err
+		}
+		profile.InsuranceProvider = provider
+	}
+	if req.InsuranceNumber != nil {
+		number, err := normalizeProfileText("insurance_number", *req.InsuranceNumber, profileTextMaxLength)
+		if err != nil {
+			return nil, err
+		}
+		profile.InsuranceNumber = number
+	}
+	if req.PricingRegion != nil {
+		region := strings.TrimSpace(*req.PricingRegion)
+		if region != "" {
+			normalized, err := drugpricing.NormalizeRegion(region)
+			if err != nil {
+				return nil, errors.New("invalid pricing_region (expected a region code such as JP or JP-13)")
+			}
+			region = normalized
+		}
+		profile.PricingRegion = region
+	}
+
+	if err := s.userRepo.UpdatePatientProfile(profile); err != nil {
+		return nil, errors.New("failed to update profile")
+	}
+
+	return profile, nil
+}
+
+// normalizeProfileName 氏名の検証（必須項目のため空文字は不可）
+func normalizeProfileName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("name must not be empty")
+	}
+	return normalizeProfileText("name", name, profileNameMaxLength)
+}
+
+// normalizeProfileText 前後の空白を除去し、最大文字数を検証する
+func normalizeProfileText(field, value string, maxLength int) (string, error) {
+	value = strings.TrimSpace(value)
+	if utf8.RuneCountInString(value) > maxLength {
+		return "", errors.New(field + " is too long")
+	}
+	return value, nil
+}
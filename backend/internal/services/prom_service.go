@@ -0,0 +1,335 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/prom"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 患者の割り当て一覧の上限
+const promListLimit = 100
+
+// PROMService 患者報告アウトカム（PROM）の質問票の割り当て・回答・点数の推移
+type PROMService struct {
+	promRepo            repositories.PROMRepository
+	appointmentRepo     repositories.AppointmentRepository
+	notificationService *NotificationService
+	auditService        *AuditService
+}
+
+type AssignPROMRequest struct {
+	Instrument     string     `json:"instrument" binding:"required"`
+	AlertThreshold *int       `json:"alert_threshold"` // 省略時は質問票の既定の基準点
+	DueAt          *time.Time `json:"due_at"`
+}
+
+type SubmitPROMRequest struct {
+	Answers map[string]int `json:"answers" binding:"required"`
+}
+
+// PROMScorePoint 点数の推移の1回分
+type PROMScorePoint struct {
+	AssignmentID  uint      `json:"assignment_id"`
+	AppointmentID uint      `json:"appointment_id"`
+	Score         int       `json:"score"`
+	Severity      string    `json:"severity"`
+	Alerted       bool      `json:"alerted"`
+	CompletedAt   time.Time `json:"completed_at"`
+}
+
+// PROMTrend 質問票ごとの点数の推移
+type PROMTrend struct {
+	Instrument string           `json:"instrument"`
+	Name       string           `json:"name"`
+	MaxScore   int              `json:"max_score"`
+	Points     []PROMScorePoint `json:"points"`           // 回答日時の古い順
+	Change     *int             `json:"change,omitempty"` // 直近の回答の前回からの増減（2回以上回答した場合）
+}
+
+func NewPROMService(promRepo repositories.PROMRepository, appointmentRepo repositories.AppointmentRepository, notificationService *NotificationService, auditService *AuditService) *PROMService {
+	return &PROMService{
+		promRepo:            promRepo,
+		appointmentRepo:     appointmentRepo,
+		notificationService: notificationService,
+		auditService:        auditService,
+	}
+}
+
+// GetInstruments 割り当てられる質問票の一覧
+func (s *PROMService) GetInstruments() []prom.Instrument {
+	return prom.Instruments
+}
+
+// AssignPROM 質問票の割り当て（予約の担当医師のみ、家族の予約の場合は家族について回答を求める）
+func (s *PROMService) AssignPROM(appointmentID, doctorID uint, req AssignPROMRequest) (*models.PROMAssignment, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.DoctorID != doctorID {
+		return nil, errors.New("unauthorized: only the assigned doctor can assign questionnaires")
+	}
+	if appointment.Status != "confirmed" && appointment.Status != "completed" {
+		return nil, errors.New("questionnaires can only be assigned for confirmed or completed appointments")
+	}
+
+	instrument := prom.Find(req.Instrument)
+	if instrument == nil {
+		return nil, errors.New("unknown questionnaire: " + req.Instrument)
+	}
+	threshold := instrument.DefaultAlertThreshold
+	if req.AlertThreshold != nil {
+		if *req.AlertThreshold < 0 || *req.AlertThreshold > instrument.MaxScore() {
+			return nil, fmt.Errorf("alert_threshold must be between 0 and %d", instrument.MaxScore())
+		}
+		threshold = *req.AlertThreshold
+	}
+	if req.DueAt != nil && !req.DueAt.After(time.Now()) {
+		return nil, errors.New("due_at must be in the future")
+	}
+
+	// 未回答の同じ質問票がある場合は重ねて割り当てない
+	pending, err := s.promRepo.ExistsPending(appointment.PatientID, appointment.DependentID, instrument.Code)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, errors.New("this questionnaire is already pending for the patient")
+	}
+
+	assignment := &models.PROMAssignment{
+		AppointmentID:  appointment.ID,
+		PatientID:      appointment.PatientID,
+		DependentID:    appointment.DependentID,
+		DoctorID:       doctorID,
+		Instrument:     instrument.Code,
+		Status:         "pending",
+		AlertThreshold: threshold,
+		DueAt:          req.DueAt,
+	}
+	if err := s.promRepo.Create(assignment); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.notificationService.Notify(assignment.PatientID, NotificationMessage{
+		Type:  "prom_assigned",
+		Title: "質問票への回答のお願い",
+		Body:  instrument.Name,
+		Data: map[string]interface{}{
+			"assignment_id":  assignment.ID,
+			"appointment_id": assignment.AppointmentID,
+			"instrument":     assignment.Instrument,
+			"due_at":         assignment.DueAt,
+		},
+	}); err != nil {
+		log.Printf("Warning: failed to notify questionnaire %d to patient %d: %v", assignment.ID, assignment.PatientID, err)
+	}
+
+	s.auditService.LogUserAction(doctorID, "prom_assigned", "prom_assignment", fmt.Sprintf("%d", assignment.ID), map[string]interface{}{
+		"appointment_id": appointmentID,
+		"instrument":     assignment.Instrument,
+	})
+	return assignment, nil
+}
+
+// GetAppointmentPROMs 予約で割り当てた質問票（予約の参加者のみ）
+func (s *PROMService) GetAppointmentPROMs(appointmentID, userID uint) ([]models.PROMAssignment, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.PatientID != userID && appointment.DoctorID != userID {
+		return nil, errors.New("unauthorized to view questionnaires for this appointment")
+	}
+	return s.promRepo.FindByAppointmentID(appointmentID)
+}
+
+// GetPROMTrends 予約の患者（家族の予約の場合はその家族）の点数の推移（担当医師・患者本人のみ）
+// 他の医師が割り当てた回答を含め、質問票ごとにまとめて返す
+func (s *PROMService) GetPROMTrends(appointmentID, userID uint, instrument string) ([]PROMTrend, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.PatientID != userID && appointment.DoctorID != userID {
+		return nil, errors.New("unauthorized to view questionnaires for this appointment")
+	}
+	if instrument != "" && prom.Find(instrument) == nil {
+		return nil, errors.New("unknown questionnaire: " + instrument)
+	}
+
+	assignments, err := s.promRepo.FindCompleted(appointment.PatientID, appointment.DependentID, instrument)
+	if err != nil {
+		return nil, err
+	}
+
+	trends := []PROMTrend{}
+	for _, definition := range prom.Instruments {
+		trend := PROMTrend{
+			Instrument: definition.Code,
+			Name:       definition.Name,
+			MaxScore:   definition.MaxScore(),
+			Points:     []PROMScorePoint{},
+		}
+		for _, a := range assignments {
+			if a.Instrument != definition.Code || a.Score == nil || a.CompletedAt == nil {
+				continue
+			}
+			trend.Points = append(trend.Points, PROMScorePoint{
+				AssignmentID:  a.ID,
+				AppointmentID: a.AppointmentID,
+				Score:         *a.Score,
+				Severity:      a.Severity,
+				Alerted:       a.AlertReasons != "",
+				CompletedAt:   *a.CompletedAt,
+			})
+		}
+		if len(trend.Points) == 0 {
+			continue
+		}
+		if n := len(trend.Points); n >= 2 {
+			change := trend.Points[n-1].Score - trend.Points[n-2].Score
+			trend.Change = &change
+		}
+		trends = append(trends, trend)
+	}
+
+	// PHI閲覧ログの記録
+	s.auditService.LogPHIAccess(userID, appointment.PatientID, "prom_trend", fmt.Sprintf("%d", appointment.PatientID), map[string]interface{}{
+		"appointment_id": appointmentID,
+	})
+	return trends, nil
+}
+
+// GetMyPROMs 自分（家族の分を含む）に割り当てられた質問票（患者用）
+func (s *PROMService) GetMyPROMs(patientID uint, status string) ([]models.PROMAssignment, error) {
+	if status != "" && status != "pending" && status != "completed" && status != "cancelled" {
+		return nil, errors.New("invalid status")
+	}
+	return s.promRepo.FindByPatientID(patientID, status, promListLimit)
+}
+
+// SubmitPROM 質問票への回答（患者本人のみ）
+// 採点して保存し、基準点以上・前回からの悪化・注意を要する項目の回答があれば割り当てた医師へ通知する
+func (s *PROMService) SubmitPROM(assignmentID, patientID uint, req SubmitPROMRequest) (*models.PROMAssignment, error) {
+	assignment, err := s.promRepo.FindByID(assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment == nil || assignment.PatientID != patientID {
+		return nil, errors.New("questionnaire not found")
+	}
+	if assignment.Status != "pending" {
+		return nil, errors.New("questionnaire is not awaiting an answer")
+	}
+
+	instrument := prom.Find(assignment.Instrument)
+	if instrument == nil {
+		return nil, errors.New("unknown questionnaire: " + assignment.Instrument)
+	}
+	result, err := instrument.Score(req.Answers)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.promRepo.FindLatestCompleted(assignment.PatientID, assignment.DependentID, assignment.Instrument)
+	if err != nil {
+		return nil, err
+	}
+
+	reasons := result.Alerts
+	if result.Score >= assignment.AlertThreshold {
+		reasons = append(reasons, fmt.Sprintf("基準点（%d点）以上", assignment.AlertThreshold))
+	}
+	if previous != nil && previous.Score != nil && result.Score-*previous.Score >= instrument.WorseningDelta {
+		reasons = append(reasons, fmt.Sprintf("前回から%d点悪化", result.Score-*previous.Score))
+	}
+
+	answersJSON, err := json.Marshal(req.Answers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal answers: %v", err)
+	}
+	now := time.Now()
+	score := result.Score
+	assignment.AnswersJSON = string(answersJSON)
+	assignment.Score = &score
+	assignment.Severity = result.Severity
+	assignment.AlertReasons = strings.Join(reasons, "、")
+	assignment.CompletedAt = &now
+
+	completed, err := s.promRepo.Complete(assignment)
+	if err != nil {
+		return nil, err
+	}
+	if !completed {
+		return nil, errors.New("questionnaire is not awaiting an answer")
+	}
+	assignment.Status = "completed"
+
+	if len(reasons) > 0 {
+		s.notifyAlert(assignment, instrument, previous)
+	}
+
+	s.auditService.LogUserAction(patientID, "prom_completed", "prom_assignment", fmt.Sprintf("%d", assignment.ID), map[string]interface{}{
+		"instrument": assignment.Instrument,
+		"score":      score,
+		"alerted":    len(reasons) > 0,
+	})
+	return assignment, nil
+}
+
+// CancelPROM 未回答の割り当ての取り消し（割り当てた医師のみ）
+func (s *PROMService) CancelPROM(assignmentID, doctorID uint) error {
+	assignment, err := s.promRepo.FindByID(assignmentID)
+	if err != nil {
+		return err
+	}
+	if assignment == nil || assignment.DoctorID != doctorID {
+		return errors.New("questionnaire not found")
+	}
+
+	cancelled, err := s.promRepo.Cancel(assignmentID)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return errors.New("only pending questionnaires can be cancelled")
+	}
+
+	s.auditService.LogUserAction(doctorID, "prom_cancelled", "prom_assignment", fmt.Sprintf("%d", assignmentID), nil)
+	return nil
+}
+
+// notifyAlert 割り当てた医師への通知（点数と通知の理由）
+func (s *PROMService) notifyAlert(assignment *models.PROMAssignment, instrument *prom.Instrument, previous *models.PROMAssignment) {
+	band := instrument.SeverityFor(*assignment.Score)
+	data := map[string]interface{}{
+		"assignment_id":  assignment.ID,
+		"appointment_id": assignment.AppointmentID,
+		"patient_id":     assignment.PatientID,
+		"dependent_id":   assignment.DependentID,
+		"instrument":     assignment.Instrument,
+		"score":          *assignment.Score,
+		"severity":       assignment.Severity,
+	}
+	if previous != nil && previous.Score != nil {
+		data["previous_score"] = *previous.Score
+	}
+
+	if _, err := s.notificationService.Notify(assignment.DoctorID, NotificationMessage{
+		Type:     "prom_alert",
+		Title:    "質問票の回答に注意が必要です",
+		Body:     fmt.Sprintf("%s: %d/%d点（%s）。%s", instrument.Name, *assignment.Score, instrument.MaxScore(), band.Label, assignment.AlertReasons),
+		Priority: "high",
+		Data:     data,
+	}); err != nil {
+		log.Printf("Warning: failed to notify questionnaire alert %d to doctor %d: %v", assignment.ID, assignment.DoctorID, err)
+	}
+}
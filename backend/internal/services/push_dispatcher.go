@@ -0,0 +1,108 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// deferredPushWindow 通知を遅らせたメッセージをプッシュ通知する期間（遅らせた日時からこれより経過したものは送らない）
+const deferredPushWindow = 24 * time.Hour
+
+// pushNotificationTypes アプリ内通知のうちプッシュ通知でも送る種類
+var pushNotificationTypes = map[string]bool{
+	"appointment_reminder": true,
+}
+
+// PushDispatcher 端末へのプッシュ通知の配信（予約のリマインドと新しいチャットメッセージ）
+type PushDispatcher struct {
+	deviceService *DeviceService
+	messageRepo   repositories.MessageRepository
+}
+
+func NewPushDispatcher(deviceService *DeviceService, messageRepo repositories.MessageRepository) *PushDispatcher {
+	return &PushDispatcher{
+		deviceService: deviceService,
+		messageRepo:   messageRepo,
+	}
+}
+
+func (d *PushDispatcher) Name() string {
+	return "push"
+}
+
+// Send 通知チャネルとしての配信（プッシュ通知で送る種類のみ）
+func (d *PushDispatcher) Send(user *models.User, notification *models.Notification) error {
+	if !pushNotificationTypes[notification.Type] {
+		return nil
+	}
+
+	data := map[string]interface{}{}
+	if notification.DataJSON != "" {
+		if err := json.Unmarshal([]byte(notification.DataJSON), &data); err != nil {
+			return err
+		}
+	}
+	data["notification_id"] = notification.ID
+
+	d.deviceService.PushToUser(user.ID, PushMessage{
+		Type:     notification.Type,
+		Title:    notification.Title,
+		Body:     notification.Body,
+		Priority: notification.Priority,
+		Data:     data,
+	})
+	return nil
+}
+
+// PushChatMessage 診療チャットの新しいメッセージを送信者以外の参加者の端末へ知らせる（本文は含めない）
+// 医師の対応時間外に届いたメッセージは遅らせた日時に定期ジョブで送る
+func (d *PushDispatcher) PushChatMessage(appointment *models.Appointment, message *models.Message) {
+	if message.NotifyAfter != nil && message.NotifyAfter.After(time.Now()) {
+		return
+	}
+	d.pushChatMessage(appointment, message)
+}
+
+// RunDeferredChatPushJob 通知を遅らせたメッセージのうち、遅らせた日時を過ぎても未読のものをプッシュ通知する
+func (d *PushDispatcher) RunDeferredChatPushJob() error {
+	now := time.Now()
+	messages, err := d.messageRepo.FindDeferredForPush(now.Add(-deferredPushWindow), now)
+	if err != nil {
+		return err
+	}
+	for i := range messages {
+		message := &messages[i]
+		marked, err := d.messageRepo.MarkPushNotified(message.ID, now)
+		if err != nil {
+			log.Printf("Warning: Failed to mark message %d as pushed: %v", message.ID, err)
+			continue
+		}
+		if !marked {
+			continue
+		}
+		d.pushChatMessage(&message.Appointment, message)
+	}
+	return nil
+}
+
+func (d *PushDispatcher) pushChatMessage(appointment *models.Appointment, message *models.Message) {
+	for _, userID := range appointment.ParticipantIDs() {
+		if userID == message.SenderUserID {
+			continue
+		}
+		d.deviceService.PushToUser(userID, PushMessage{
+			Type:  "chat_message",
+			Title: "新しいメッセージ",
+			Body:  "診療チャットにメッセージが届いています",
+			TTL:   deferredPushWindow,
+			Data: map[string]interface{}{
+				"appointment_id": appointment.ID,
+				"message_id":     message.ID,
+			},
+		})
+	}
+}
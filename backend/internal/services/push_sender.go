@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/push"
+)
+
+// ErrInvalidPushToken 端末のトークンが無効（アプリの削除等）で、登録を削除すべき
+var ErrInvalidPushToken = errors.New("invalid push token")
+
+// PushMessage 端末へのプッシュ通知の内容
+type PushMessage struct {
+	Type     string
+	Title    string
+	Body     string
+	Priority string        // normal | high（着信等、端末のスリープ中でも即時に届ける）
+	TTL      time.Duration // 0の場合は配信事業者の既定
+	Data     map[string]interface{}
+}
+
+// PushSender 端末へのプッシュ通知の送信（APNs・FCM等）
+type PushSender interface {
+	Send(device models.DeviceToken, msg PushMessage) error
+}
+
+// LogPushSender 送信内容をログに出力するだけの開発用実装
+type LogPushSender struct{}
+
+func NewLogPushSender() *LogPushSender {
+	return &LogPushSender{}
+}
+
+// Send プッシュ通知の送信（ログ出力のみ）
+func (s *LogPushSender) Send(device models.DeviceToken, msg PushMessage) error {
+	log.Printf("[push] user=%d platform=%s type=%s priority=%s title=%q data=%v", device.UserID, device.Platform, msg.Type, msg.Priority, msg.Title, msg.Data)
+	return nil
+}
+
+// ProviderPushSender 端末のプラットフォームに応じた配信事業者からの送信（iOS: APNs / Android・Web: FCM）
+// 事業者が設定されていないプラットフォームはfallbackで送信する
+type ProviderPushSender struct {
+	fcm      push.Provider
+	apns     push.Provider
+	fallback PushSender
+}
+
+func NewProviderPushSender(fcm, apns push.Provider, fallback PushSender) *ProviderPushSender {
+	return &ProviderPushSender{
+		fcm:      fcm,
+		apns:     apns,
+		fallback: fallback,
+	}
+}
+
+// Send プッシュ通知の送信（無効なトークンはErrInvalidPushTokenを返す）
+func (s *ProviderPushSender) Send(device models.DeviceToken, msg PushMessage) error {
+	provider := s.fcm
+	if device.Platform == "ios" {
+		provider = s.apns
+	}
+	if provider == nil {
+		return s.fallback.Send(device, msg)
+	}
+
+	data := make(map[string]string, len(msg.Data)+1)
+	for key, value := range msg.Data {
+		if t, ok := value.(time.Time); ok {
+			data[key] = t.Format(time.RFC3339)
+			continue
+		}
+		data[key] = fmt.Sprint(value)
+	}
+	data["type"] = msg.Type
+
+	err := provider.Send(context.Background(), device.Token, push.Notification{
+		Title:    msg.Title,
+		Body:     msg.Body,
+		Priority: msg.Priority,
+		TTL:      msg.TTL,
+		Data:     data,
+	})
+	if errors.Is(err, push.ErrInvalidToken) {
+		return ErrInvalidPushToken
+	}
+	return err
+}
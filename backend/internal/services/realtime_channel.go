@@ -0,0 +1,23 @@
+package services
+
+import (
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/realtime"
+)
+
+// RealtimeChannel 接続中のクライアントへWebSocketで通知を配信するチャネル
+type RealtimeChannel struct {
+	hub *realtime.Hub
+}
+
+func NewRealtimeChannel(hub *realtime.Hub) *RealtimeChannel {
+	return &RealtimeChannel{hub: hub}
+}
+
+func (c *RealtimeChannel) Name() string {
+	return "websocket"
+}
+
+func (c *RealtimeChannel) Send(user *models.User, notification *models.Notification) error {
+	return c.hub.Publish([]uint{user.ID}, "notification", notification)
+}
@@ -0,0 +1,359 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"path/filepath"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/realtime"
+	"online_medical_consultation_app/backend/internal/repositories"
+	"online_medical_consultation_app/backend/internal/storage"
+)
+
+// 録画ファイルの制限
+const recordingArtifactMaxFileSize = 2 << 30 // 2GB
+
+// 録画ファイルの形式と保存するファイルの拡張子
+var recordingArtifactContentTypes = map[string]string{
+	"video/webm": ".webm",
+	"video/mp4":  ".mp4",
+	"audio/webm": ".webm",
+	"audio/mp4":  ".m4a",
+	"audio/ogg":  ".ogg",
+}
+
+// 録画の停止の理由
+const (
+	RecordingStopByParticipant     = "stopped"
+	RecordingStopConsentWithdrawn  = "consent_withdrawn"
+	RecordingStopVideoSessionEnded = "session_ended"
+)
+
+// RecordingService ビデオ診療の録画の管理
+// 録画は患者・医師の双方が同意したセッションでのみ開始でき、録画ファイルへのアクセスは全て監査ログに記録する
+type RecordingService struct {
+	recordingRepo    repositories.RecordingRepository
+	videoSessionRepo repositories.VideoSessionRepository
+	appointmentRepo  repositories.AppointmentRepository
+	auditService     *AuditService
+	hub              *realtime.Hub
+	store            storage.Store
+}
+
+// RecordingFile 録画ファイルのダウンロード（呼び出し側で Body を閉じる）
+type RecordingFile struct {
+	Recording *models.Recording
+	Filename  string
+	Body      io.ReadCloser
+}
+
+func NewRecordingService(recordingRepo repositories.RecordingRepository, videoSessionRepo repositories.VideoSessionRepository, appointmentRepo repositories.AppointmentRepository, auditService *AuditService, hub *realtime.Hub, store storage.Store) *RecordingService {
+	return &RecordingService{
+		recordingRepo:    recordingRepo,
+		videoSessionRepo: videoSessionRepo,
+		appointmentRepo:  appointmentRepo,
+		auditService:     auditService,
+		hub:              hub,
+		store:            store,
+	}
+}
+
+// StartRecording 録画の開始（患者・担当医師のみ、通話中で双方が同意したセッション、同時に1件まで）
+func (s *RecordingService) StartRecording(sessionID, userID uint) (*models.Recording, error) {
+	session, appointment, err := s.loadSession(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if session.StartedAt == nil || session.EndedAt != nil {
+		return nil, errors.New("video session is not in progress")
+	}
+	if session.RecordingConsent != "granted" || session.DoctorRecordingConsent != "granted" {
+		return nil, errors.New("both patient and doctor must consent to recording")
+	}
+
+	existing, err := s.recordingRepo.FindBySession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range existing {
+		if r.Status == models.RecordingInProgress {
+			return nil, errors.New("recording is already in progress")
+		}
+	}
+
+	recording := &models.Recording{
+		VideoSessionID: sessionID,
+		AppointmentID:  appointment.ID,
+		Status:         models.RecordingInProgress,
+		StartedByID:    userID,
+		StartedAt:      time.Now(),
+	}
+	// 同時に開始した場合は一意インデックスにより一方のみ作成される
+	if err := s.recordingRepo.Create(recording); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(userID, "recording_started", "recording", fmt.Sprintf("%d", recording.ID), map[string]interface{}{
+		"video_session_id": sessionID,
+		"appointment_id":   appointment.ID,
+	})
+	s.publish(appointment, "video.recording_started", recording)
+
+	return recording, nil
+}
+
+// StopRecording 録画の停止（患者・担当医師のどちらも停止できる）
+func (s *RecordingService) StopRecording(sessionID, recordingID, userID uint) (*models.Recording, error) {
+	_, appointment, err := s.loadSession(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	recording, err := s.findRecording(sessionID, recordingID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	stopped, err := s.recordingRepo.Stop(recording.ID, &userID, RecordingStopByParticipant, now)
+	if err != nil {
+		return nil, err
+	}
+	if !stopped {
+		return nil, errors.New("recording is not in progress")
+	}
+	recording.Status = models.RecordingStopped
+	recording.StoppedByID = &userID
+	recording.StoppedAt = &now
+	recording.StopReason = RecordingStopByParticipant
+
+	s.auditService.LogUserAction(userID, "recording_stopped", "recording", fmt.Sprintf("%d", recording.ID), map[string]interface{}{
+		"video_session_id": sessionID,
+		"appointment_id":   appointment.ID,
+	})
+	s.publish(appointment, "video.recording_stopped", recording)
+
+	return recording, nil
+}
+
+// StopInProgress セッションの録画中の録画の停止（同意の撤回・セッションの終了時、失敗しても呼び出し元の処理は妨げない）
+func (s *RecordingService) StopInProgress(session *models.VideoSession, appointment *models.Appointment, reason string) {
+	recordings, err := s.recordingRepo.StopInProgressBySession(session.ID, reason, time.Now())
+	if err != nil {
+		log.Printf("Warning: Failed to stop recordings of video session %d: %v", session.ID, err)
+		return
+	}
+	for i := range recordings {
+		s.auditService.LogSystemAction("recording_stopped", "recording", fmt.Sprintf("%d", recordings[i].ID), map[string]interface{}{
+			"video_session_id": session.ID,
+			"reason":           reason,
+		})
+		s.publish(appointment, "video.recording_stopped", &recordings[i])
+	}
+}
+
+// UploadRecording 録画ファイルのアップロード（録画を開始した参加者のみ、停止済みの録画に1回のみ）
+func (s *RecordingService) UploadRecording(sessionID, recordingID, userID uint, file *multipart.FileHeader) (*models.Recording, error) {
+	session, appointment, err := s.loadSession(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	recording, err := s.findRecording(sessionID, recordingID)
+	if err != nil {
+		return nil, err
+	}
+	if recording.StartedByID != userID {
+		return nil, errors.New("only the participant who started the recording can upload it")
+	}
+	switch recording.Status {
+	case models.RecordingInProgress:
+		return nil, errors.New("recording must be stopped before uploading")
+	case models.RecordingAvailable:
+		return nil, errors.New("recording has already been uploaded")
+	}
+	// アップロード前に同意が撤回された録画は保存しない
+	if session.RecordingConsent != "granted" || session.DoctorRecordingConsent != "granted" {
+		return nil, errors.New("consent to recording has been withdrawn")
+	}
+
+	if file.Size > recordingArtifactMaxFileSize {
+		return nil, errors.New("file size must be less than 2GB")
+	}
+	contentType, _, _ := mime.ParseMediaType(file.Header.Get("Content-Type"))
+	extension, ok := recordingArtifactContentTypes[contentType]
+	if !ok {
+		return nil, errors.New("only WebM, MP4 and Ogg recordings are allowed")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	// チェックサムを計算してから先頭に戻して保存する
+	hash := sha256.New()
+	if _, err := io.Copy(hash, src); err != nil {
+		return nil, err
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	objectKey := fmt.Sprintf("recordings/%d/%d/%d_%d%s", appointment.ID, sessionID, recording.ID, time.Now().UnixNano(), extension)
+	if err := s.store.Put(context.Background(), objectKey, src, file.Size, contentType); err != nil {
+		log.Printf("Warning: Failed to store recording %d: %v", recording.ID, err)
+		return nil, errors.New("failed to store recording")
+	}
+
+	now := time.Now()
+	recording.Storage = s.store.Name()
+	recording.ObjectKey = objectKey
+	recording.ContentType = contentType
+	recording.SizeBytes = file.Size
+	recording.Checksum = hex.EncodeToString(hash.Sum(nil))
+	recording.UploadedAt = &now
+	updated, err := s.recordingRepo.MarkAvailable(recording)
+	if err != nil || !updated {
+		if deleteErr := s.store.Delete(context.Background(), objectKey); deleteErr != nil {
+			log.Printf("Warning: Failed to delete recording file %s: %v", objectKey, deleteErr)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.New("recording has already been uploaded")
+	}
+	recording.Status = models.RecordingAvailable
+
+	s.auditService.LogUserAction(userID, "recording_uploaded", "recording", fmt.Sprintf("%d", recording.ID), map[string]interface{}{
+		"video_session_id": sessionID,
+		"appointment_id":   appointment.ID,
+		"storage":          recording.Storage,
+		"size_bytes":       recording.SizeBytes,
+	})
+	s.publish(appointment, "video.recording_available", recording)
+
+	return recording, nil
+}
+
+// GetRecordings セッションの録画の一覧（患者・担当医師のみ、閲覧を監査ログに記録する）
+func (s *RecordingService) GetRecordings(sessionID, userID uint) ([]models.Recording, error) {
+	_, appointment, err := s.loadSession(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	recordings, err := s.recordingRepo.FindBySession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.LogRecordingAccess(userID, appointment.PatientID, "recording_list_viewed", "video_session", fmt.Sprintf("%d", sessionID), map[string]interface{}{
+		"appointment_id": appointment.ID,
+		"count":          len(recordings),
+	}); err != nil {
+		log.Printf("Warning: Failed to record recording access for video session %d: %v", sessionID, err)
+		return nil, errors.New("recording access is temporarily unavailable")
+	}
+	return recordings, nil
+}
+
+// GetRecording 録画の取得（患者・担当医師のみ、閲覧を監査ログに記録する）
+func (s *RecordingService) GetRecording(sessionID, recordingID, userID uint) (*models.Recording, error) {
+	_, appointment, err := s.loadSession(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	recording, err := s.findRecording(sessionID, recordingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.LogRecordingAccess(userID, appointment.PatientID, "recording_viewed", "recording", fmt.Sprintf("%d", recording.ID), map[string]interface{}{
+		"video_session_id": sessionID,
+		"appointment_id":   appointment.ID,
+	}); err != nil {
+		log.Printf("Warning: Failed to record access to recording %d: %v", recording.ID, err)
+		return nil, errors.New("recording access is temporarily unavailable")
+	}
+	return recording, nil
+}
+
+// OpenRecording 録画ファイルのダウンロード（患者・担当医師のみ、記録できない場合はダウンロードさせない）
+func (s *RecordingService) OpenRecording(sessionID, recordingID, userID uint) (*RecordingFile, error) {
+	_, appointment, err := s.loadSession(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	recording, err := s.findRecording(sessionID, recordingID)
+	if err != nil {
+		return nil, err
+	}
+	if recording.Status != models.RecordingAvailable {
+		return nil, errors.New("recording file is not available")
+	}
+	if recording.Storage != s.store.Name() {
+		return nil, fmt.Errorf("recording is stored in %s storage", recording.Storage)
+	}
+
+	if err := s.auditService.LogRecordingAccess(userID, appointment.PatientID, "recording_downloaded", "recording", fmt.Sprintf("%d", recording.ID), map[string]interface{}{
+		"video_session_id": sessionID,
+		"appointment_id":   appointment.ID,
+	}); err != nil {
+		log.Printf("Warning: Failed to record access to recording %d: %v", recording.ID, err)
+		return nil, errors.New("recording access is temporarily unavailable")
+	}
+
+	body, err := s.store.Open(context.Background(), recording.ObjectKey)
+	if err != nil {
+		log.Printf("Warning: Failed to open recording %d: %v", recording.ID, err)
+		return nil, errors.New("recording file not found")
+	}
+	return &RecordingFile{
+		Recording: recording,
+		Filename:  fmt.Sprintf("recording_%d_%d%s", appointment.ID, recording.ID, filepath.Ext(recording.ObjectKey)),
+		Body:      body,
+	}, nil
+}
+
+// loadSession セッションと予約の取得（録画は患者・担当医師のみ扱える）
+func (s *RecordingService) loadSession(sessionID, userID uint) (*models.VideoSession, *models.Appointment, error) {
+	session, err := s.videoSessionRepo.FindByID(sessionID)
+	if err != nil || session == nil {
+		return nil, nil, errors.New("video session not found")
+	}
+	appointment, err := s.appointmentRepo.FindByID(session.AppointmentID)
+	if err != nil || appointment == nil {
+		return nil, nil, errors.New("appointment not found")
+	}
+	if appointment.PatientID != userID && appointment.DoctorID != userID {
+		return nil, nil, errors.New("unauthorized to access recordings of this session")
+	}
+	return session, appointment, nil
+}
+
+// findRecording セッションの録画の取得
+func (s *RecordingService) findRecording(sessionID, recordingID uint) (*models.Recording, error) {
+	recording, err := s.recordingRepo.FindByID(recordingID)
+	if err != nil {
+		return nil, err
+	}
+	if recording == nil || recording.VideoSessionID != sessionID {
+		return nil, errors.New("recording not found")
+	}
+	return recording, nil
+}
+
+// publish 録画の状態の変化の予約の参加者への配信
+func (s *RecordingService) publish(appointment *models.Appointment, event string, recording *models.Recording) {
+	if err := s.hub.Publish(appointment.ParticipantIDs(), event, recording); err != nil {
+		log.Printf("Warning: Failed to publish %s for recording %d: %v", event, recording.ID, err)
+	}
+}
@@ -0,0 +1,443 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/mail"
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 定期レポートの配信時刻（予約受付ルールのタイムゾーン）と1回のジョブで配信する件数
+const (
+	reportDeliveryHour      = 7
+	reportDeliveryBatchSize = 20
+)
+
+var reportTitles = map[string]string{
+	models.ReportWeeklyUtilization: "診療枠の稼働状況（週次）",
+	models.ReportMonthlyRevenue:    "入出金の集計（月次）",
+	models.ReportAuditSummary:      "監査ログの集計（週次）",
+}
+
+// ReportSubscriptionService 定期レポート（稼働状況・入出金・監査ログ）のメール配信
+// 登録した管理者のメールアドレスにのみ送る（任意の宛先へ集計を送れないようにする）
+type ReportSubscriptionService struct {
+	subscriptionRepo     repositories.ReportSubscriptionRepository
+	userRepo             repositories.UserRepository
+	ledgerRepo           repositories.LedgerRepository
+	auditRepo            repositories.AuditRepository
+	utilizationService   *UtilizationService
+	bookingPolicyService *BookingPolicyService
+	brandingService      *BrandingService
+	auditService         *AuditService
+	sender               ContactSender
+}
+
+type CreateReportSubscriptionRequest struct {
+	ReportType string `json:"report_type" binding:"required,oneof=weekly_utilization monthly_revenue audit_summary"`
+	Format     string `json:"format" binding:"omitempty,oneof=csv pdf"` // 省略時は csv
+}
+
+// UpdateReportSubscriptionRequest 配信の登録の部分更新（nilの項目は変更しない）
+type UpdateReportSubscriptionRequest struct {
+	Format *string `json:"format" binding:"omitempty,oneof=csv pdf"`
+	Active *bool   `json:"active"`
+}
+
+// reportTable 定期レポートの内容（CSV・PDFで共通の表）
+type reportTable struct {
+	Summary []string // メール本文とPDFの冒頭に載せる要約
+	Headers []string
+	Rows    [][]string
+}
+
+func NewReportSubscriptionService(subscriptionRepo repositories.ReportSubscriptionRepository, userRepo repositories.UserRepository, ledgerRepo repositories.LedgerRepository, auditRepo repositories.AuditRepository, utilizationService *UtilizationService, bookingPolicyService *BookingPolicyService, brandingService *BrandingService, auditService *AuditService, sender ContactSender) *ReportSubscriptionService {
+	return &ReportSubscriptionService{
+		subscriptionRepo:     subscriptionRepo,
+		userRepo:             userRepo,
+		ledgerRepo:           ledgerRepo,
+		auditRepo:            auditRepo,
+		utilizationService:   utilizationService,
+		bookingPolicyService: bookingPolicyService,
+		brandingService:      brandingService,
+		auditService:         auditService,
+		sender:               sender,
+	}
+}
+
+// GetSubscriptions 自分の配信の登録の一覧（管理者のみ）
+func (s *ReportSubscriptionService) GetSubscriptions(adminID uint) ([]models.ReportSubscription, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	return s.subscriptionRepo.FindByUser(adminID)
+}
+
+// CreateSubscription 定期レポートの配信の登録（同じレポートは1件のみ、初回は次の配信日時に送る）
+func (s *ReportSubscriptionService) CreateSubscription(adminID uint, req CreateReportSubscriptionRequest) (*models.ReportSubscription, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	if _, ok := reportTitles[req.ReportType]; !ok {
+		return nil, errors.New("invalid report_type")
+	}
+	format := req.Format
+	if format == "" {
+		format = "csv"
+	}
+
+	existing, err := s.subscriptionRepo.FindByUser(adminID)
+	if err != nil {
+		return nil, err
+	}
+	for _, subscription := range existing {
+		if subscription.ReportType == req.ReportType {
+			return nil, errors.New("already subscribed to this report")
+		}
+	}
+
+	location, err := s.bookingPolicyService.Location()
+	if err != nil {
+		return nil, err
+	}
+	subscription := &models.ReportSubscription{
+		UserID:     adminID,
+		ReportType: req.ReportType,
+		Format:     format,
+		Active:     true,
+		NextRunAt:  nextReportRun(req.ReportType, time.Now(), location),
+	}
+	if err := s.subscriptionRepo.Create(subscription); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(adminID, "report_subscription_created", "report_subscription", fmt.Sprintf("%d", subscription.ID), map[string]interface{}{
+		"report_type": subscription.ReportType,
+		"format":      subscription.Format,
+	})
+	return subscription, nil
+}
+
+// UpdateSubscription 配信の形式・停止の変更（再開した場合は次の配信日時から送る）
+func (s *ReportSubscriptionService) UpdateSubscription(adminID, subscriptionID uint, req UpdateReportSubscriptionRequest) (*models.ReportSubscription, error) {
+	subscription, err := s.getOwnSubscription(adminID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Format != nil {
+		if *req.Format != "csv" && *req.Format != "pdf" {
+			return nil, errors.New("invalid format")
+		}
+		subscription.Format = *req.Format
+	}
+	if req.Active != nil {
+		if *req.Active && !subscription.Active {
+			// 停止中に過ぎた配信日時の分はまとめて送らない
+			location, err := s.bookingPolicyService.Location()
+			if err != nil {
+				return nil, err
+			}
+			subscription.NextRunAt = nextReportRun(subscription.ReportType, time.Now(), location)
+		}
+		subscription.Active = *req.Active
+	}
+	if err := s.subscriptionRepo.Update(subscription); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(adminID, "report_subscription_updated", "report_subscription", fmt.Sprintf("%d", subscription.ID), map[string]interface{}{
+		"format": subscription.Format,
+		"active": subscription.Active,
+	})
+	return subscription, nil
+}
+
+// DeleteSubscription 配信の登録の削除
+func (s *ReportSubscriptionService) DeleteSubscription(adminID, subscriptionID uint) error {
+	subscription, err := s.getOwnSubscription(adminID, subscriptionID)
+	if err != nil {
+		return err
+	}
+	if err := s.subscriptionRepo.Delete(subscription.ID); err != nil {
+		return err
+	}
+
+	s.auditService.LogUserAction(adminID, "report_subscription_deleted", "report_subscription", fmt.Sprintf("%d", subscription.ID), map[string]interface{}{
+		"report_type": subscription.ReportType,
+	})
+	return nil
+}
+
+// SendNow 直近の集計期間のレポートを直ちに送る（配信内容の確認用、次回の配信日時は変えない）
+func (s *ReportSubscriptionService) SendNow(adminID, subscriptionID uint) (*models.ReportSubscription, error) {
+	subscription, err := s.getOwnSubscription(adminID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.deliver(subscription, now); err != nil {
+		return nil, fmt.Errorf("failed to send report: %v", err)
+	}
+	subscription.LastSentAt = &now
+	subscription.LastError = ""
+	if err := s.subscriptionRepo.RecordDelivery(subscription.ID, &now, ""); err != nil {
+		log.Printf("Warning: Failed to record delivery of report subscription %d: %v", subscription.ID, err)
+	}
+	return subscription, nil
+}
+
+// RunDeliveryJob 配信日時を過ぎたレポートの配信（定期ジョブ）
+// 配信日時を先に次回へ進めるため、複数のインスタンスで実行しても重複して送らない
+func (s *ReportSubscriptionService) RunDeliveryJob() error {
+	now := time.Now()
+	subscriptions, err := s.subscriptionRepo.FindDue(now, reportDeliveryBatchSize)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+	location, err := s.bookingPolicyService.Location()
+	if err != nil {
+		return err
+	}
+
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		scheduledAt := subscription.NextRunAt
+		claimed, err := s.subscriptionRepo.ClaimRun(subscription.ID, scheduledAt, nextReportRun(subscription.ReportType, now, location))
+		if err != nil {
+			log.Printf("Warning: Failed to claim report subscription %d: %v", subscription.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		// 集計期間は予定していた配信日時で決める（ジョブが遅れても対象の期間は変わらない）
+		if err := s.deliver(subscription, scheduledAt); err != nil {
+			log.Printf("Warning: Failed to deliver report subscription %d: %v", subscription.ID, err)
+			if err := s.subscriptionRepo.RecordDelivery(subscription.ID, nil, err.Error()); err != nil {
+				log.Printf("Warning: Failed to record delivery of report subscription %d: %v", subscription.ID, err)
+			}
+			continue
+		}
+		sentAt := time.Now()
+		if err := s.subscriptionRepo.RecordDelivery(subscription.ID, &sentAt, ""); err != nil {
+			log.Printf("Warning: Failed to record delivery of report subscription %d: %v", subscription.ID, err)
+		}
+	}
+	return nil
+}
+
+// deliver 配信日時の直前の集計期間のレポートの作成と送信
+func (s *ReportSubscriptionService) deliver(subscription *models.ReportSubscription, at time.Time) error {
+	// 管理者でなくなった・退会した利用者には送らない
+	user, err := s.userRepo.FindByID(subscription.UserID)
+	if err != nil || !policy.IsAdmin(user) || user.DeactivatedAt != nil {
+		return errors.New("subscriber is no longer an active admin")
+	}
+	location, err := s.bookingPolicyService.Location()
+	if err != nil {
+		return err
+	}
+
+	from, to := reportPeriod(subscription.ReportType, at, location)
+	table, err := s.buildReport(subscription.ReportType, user.ID, from, to)
+	if err != nil {
+		return err
+	}
+	attachment, err := s.renderReport(subscription, table, from, to)
+	if err != nil {
+		return err
+	}
+
+	title := reportTitles[subscription.ReportType]
+	period := fmt.Sprintf("%s〜%s", from.Format("2006年1月2日"), to.AddDate(0, 0, -1).Format("2006年1月2日"))
+	var body strings.Builder
+	fmt.Fprintf(&body, "%sを添付します。\n\n期間: %s\n", title, period)
+	for _, line := range table.Summary {
+		body.WriteString(line + "\n")
+	}
+	body.WriteString("\n配信の停止・形式の変更は管理画面の定期レポートの設定から行えます。\n")
+
+	subject := fmt.Sprintf("【定期レポート】%s %s", title, period)
+	if err := s.sender.SendEmailWithAttachments(user.Email, subject, body.String(), []mail.Attachment{*attachment}); err != nil {
+		return err
+	}
+
+	s.auditService.LogSystemAction("report_delivered", "report_subscription", fmt.Sprintf("%d", subscription.ID), map[string]interface{}{
+		"user_id":     subscription.UserID,
+		"report_type": subscription.ReportType,
+		"format":      subscription.Format,
+		"from":        from.Format("2006-01-02"),
+		"to":          to.AddDate(0, 0, -1).Format("2006-01-02"),
+	})
+	return nil
+}
+
+// buildReport レポートの集計（from を含み to を含まない期間）
+func (s *ReportSubscriptionService) buildReport(reportType string, adminID uint, from, to time.Time) (*reportTable, error) {
+	switch reportType {
+	case models.ReportWeeklyUtilization:
+		report, err := s.utilizationService.GetUtilization(adminID, 0, from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"))
+		if err != nil {
+			return nil, err
+		}
+		table := &reportTable{
+			Summary: []string{
+				fmt.Sprintf("稼働率: %.0f%%", report.Totals.UtilizationRate*100),
+				fmt.Sprintf("予約あり: %s時間 / 空き: %s時間 / 停止中: %s時間", formatHours(report.Totals.BookedHours), formatHours(report.Totals.OpenHours), formatHours(report.Totals.BlockedHours)),
+			},
+			Headers: utilizationExportHeaders,
+		}
+		for _, row := range report.Rows {
+			table.Rows = append(table.Rows, utilizationRecord(row))
+		}
+		return table, nil
+
+	case models.ReportMonthlyRevenue:
+		totals, err := s.ledgerRepo.SumEntriesByKind(from, to)
+		if err != nil {
+			return nil, err
+		}
+		table := &reportTable{Headers: []string{"currency", "kind", "entries", "amount"}}
+		net := make(map[string]int64)
+		var currencies []string
+		for _, total := range totals {
+			table.Rows = append(table.Rows, []string{total.Currency, total.Kind, strconv.FormatInt(total.Entries, 10), strconv.FormatInt(total.Amount, 10)})
+			if _, ok := net[total.Currency]; !ok {
+				currencies = append(currencies, total.Currency)
+				net[total.Currency] = 0
+			}
+			switch total.Kind {
+			case models.JournalPayment:
+				net[total.Currency] += total.Amount
+			case models.JournalRefund:
+				net[total.Currency] -= total.Amount
+			}
+		}
+		for _, currency := range currencies {
+			table.Summary = append(table.Summary, fmt.Sprintf("入金（返金を差し引いた額）: %d %s", net[currency], strings.ToUpper(currency)))
+		}
+		if len(currencies) == 0 {
+			table.Summary = append(table.Summary, "期間内の入出金はありません")
+		}
+		return table, nil
+
+	case models.ReportAuditSummary:
+		counts, err := s.auditRepo.CountByAction(from, to)
+		if err != nil {
+			return nil, err
+		}
+		table := &reportTable{Headers: []string{"action", "severity", "count"}}
+		var total, high int64
+		for _, count := range counts {
+			table.Rows = append(table.Rows, []string{count.Action, count.Severity, strconv.FormatInt(count.Count, 10)})
+			total += count.Count
+			if count.Severity == "high" {
+				high += count.Count
+			}
+		}
+		table.Summary = []string{
+			fmt.Sprintf("記録: %d件", total),
+			fmt.Sprintf("要確認（緊急時アクセス等）: %d件", high),
+		}
+		return table, nil
+	}
+	return nil, errors.New("invalid report_type")
+}
+
+// renderReport レポートのCSV・PDFの作成
+func (s *ReportSubscriptionService) renderReport(subscription *models.ReportSubscription, table *reportTable, from, to time.Time) (*mail.Attachment, error) {
+	filename := fmt.Sprintf("%s_%s_%s.%s", subscription.ReportType, from.Format("20060102"), to.AddDate(0, 0, -1).Format("20060102"), subscription.Format)
+
+	var buf bytes.Buffer
+	if subscription.Format == "pdf" {
+		doc := s.brandingService.NewPDFDocument()
+		doc.Heading(reportTitles[subscription.ReportType])
+		doc.Blank()
+		doc.Text(fmt.Sprintf("期間: %s〜%s", from.Format("2006年1月2日"), to.AddDate(0, 0, -1).Format("2006年1月2日")))
+		for _, line := range table.Summary {
+			doc.Text(line)
+		}
+		doc.Blank()
+		doc.Text(strings.Join(table.Headers, " / "))
+		for _, row := range table.Rows {
+			doc.Text(strings.Join(row, " / "))
+		}
+		if _, err := doc.WriteTo(&buf); err != nil {
+			return nil, err
+		}
+		return &mail.Attachment{Filename: filename, ContentType: "application/pdf", Data: buf.Bytes()}, nil
+	}
+
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(table.Headers); err != nil {
+		return nil, err
+	}
+	if err := writer.WriteAll(table.Rows); err != nil {
+		return nil, err
+	}
+	return &mail.Attachment{Filename: filename, ContentType: "text/csv", Data: buf.Bytes()}, nil
+}
+
+// getOwnSubscription 自分の配信の登録の取得（管理者のみ）
+func (s *ReportSubscriptionService) getOwnSubscription(adminID, subscriptionID uint) (*models.ReportSubscription, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+	subscription, err := s.subscriptionRepo.FindByID(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil || subscription.UserID != adminID {
+		return nil, errors.New("report subscription not found")
+	}
+	return subscription, nil
+}
+
+func (s *ReportSubscriptionService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
+
+// reportPeriod 配信日時の直前の集計期間（週次は前週の月曜日から、月次は前月の1日から、終わりは含まない）
+func reportPeriod(reportType string, at time.Time, location *time.Location) (time.Time, time.Time) {
+	if reportType == models.ReportMonthlyRevenue {
+		local := at.In(location)
+		end := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, location)
+		return end.AddDate(0, -1, 0), end
+	}
+	end := weekStart(at, location)
+	return end.AddDate(0, 0, -7), end
+}
+
+// nextReportRun after より後の配信日時（週次は月曜日、月次は1日の配信時刻）
+func nextReportRun(reportType string, after time.Time, location *time.Location) time.Time {
+	local := after.In(location)
+	if reportType == models.ReportMonthlyRevenue {
+		next := time.Date(local.Year(), local.Month(), 1, reportDeliveryHour, 0, 0, 0, location)
+		if !next.After(after) {
+			next = next.AddDate(0, 1, 0)
+		}
+		return next
+	}
+	monday := weekStart(after, location)
+	next := time.Date(monday.Year(), monday.Month(), monday.Day(), reportDeliveryHour, 0, 0, 0, location)
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 7)
+	}
+	return next
+}
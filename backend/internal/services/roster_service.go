@@ -0,0 +1,342 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 勤務表の表示期間
+const (
+	RosterSpanDay  = "day"
+	RosterSpanWeek = "week"
+)
+
+type RosterService struct {
+	slotRepo             repositories.SlotRepository
+	appointmentRepo      repositories.AppointmentRepository
+	userRepo             repositories.UserRepository
+	bookingPolicyService *BookingPolicyService
+	brandingService      *BrandingService
+	auditService         *AuditService
+}
+
+// 印刷用の週間予定表の曜日の表記
+var scheduleWeekdays = [...]string{"日", "月", "火", "水", "木", "金", "土"}
+
+// RosterBooking 勤務表に表示する予約（患者情報は氏名のみ）
+type RosterBooking struct {
+	AppointmentID       uint      `json:"appointment_id"`
+	Status              string    `json:"status"`
+	PatientID           uint      `json:"patient_id"`
+	PatientName         string    `json:"patient_name"`
+	IsUrgent            bool      `json:"is_urgent"`
+	IsInstant           bool      `json:"is_instant"`
+	InterpreterLanguage string    `json:"interpreter_language,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+// RosterSlot 勤務表の診療枠（booked: 予約あり / open: 空き / blocked: 停止中）
+type RosterSlot struct {
+	SlotID    uint           `json:"slot_id"`
+	StartTime time.Time      `json:"start_time"`
+	EndTime   time.Time      `json:"end_time"`
+	Status    string         `json:"status"`
+	Booking   *RosterBooking `json:"booking,omitempty"`
+}
+
+// DoctorRoster 医師ごとの診療枠と予約
+type DoctorRoster struct {
+	DoctorID        uint            `json:"doctor_id"`
+	Name            string          `json:"name"`
+	Specialty       string          `json:"specialty"`
+	Slots           []RosterSlot    `json:"slots"`
+	InstantBookings []RosterBooking `json:"instant_bookings"`
+	BookedCount     int             `json:"booked_count"`
+	OpenCount       int             `json:"open_count"`
+}
+
+// Roster 全医師の勤務表
+type Roster struct {
+	Span    string         `json:"span"`
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	Doctors []DoctorRoster `json:"doctors"`
+}
+
+func NewRosterService(slotRepo repositories.SlotRepository, appointmentRepo repositories.AppointmentRepository, userRepo repositories.UserRepository, bookingPolicyService *BookingPolicyService, brandingService *BrandingService, auditService *AuditService) *RosterService {
+	return &RosterService{
+		slotRepo:             slotRepo,
+		appointmentRepo:      appointmentRepo,
+		userRepo:             userRepo,
+		bookingPolicyService: bookingPolicyService,
+		brandingService:      brandingService,
+		auditService:         auditService,
+	}
+}
+
+// GetRoster 指定日（週の場合はその日から7日間）の全医師の診療枠と予約を取得（管理者のみ）
+// 日付の区切りは予約受付ルールのタイムゾーンに従う
+func (s *RosterService) GetRoster(adminID uint, date, span string) (*Roster, error) {
+	if !s.isAdmin(adminID) {
+		return nil, errors.New("unauthorized: admin access required")
+	}
+
+	location, err := s.bookingPolicyService.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	from := time.Now().In(location)
+	if date != "" {
+		from, err = time.ParseInLocation("2006-01-02", date, location)
+		if err != nil {
+			return nil, errors.New("invalid date format (expected YYYY-MM-DD)")
+		}
+	}
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, location)
+
+	var to time.Time
+	switch span {
+	case "", RosterSpanDay:
+		span = RosterSpanDay
+		to = from.AddDate(0, 0, 1)
+	case RosterSpanWeek:
+		to = from.AddDate(0, 0, 7)
+	default:
+		return nil, errors.New("span must be day or week")
+	}
+
+	doctors, err := s.userRepo.FindDoctors("")
+	if err != nil {
+		return nil, err
+	}
+	slots, err := s.slotRepo.FindInRangeWithBookings(from, to, 0)
+	if err != nil {
+		return nil, err
+	}
+	instants, err := s.appointmentRepo.FindInstantCreatedInRange(from, to, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	roster := &Roster{Span: span, From: from, To: to, Doctors: make([]DoctorRoster, 0, len(doctors))}
+	index := make(map[uint]int, len(doctors))
+	for _, doctor := range doctors {
+		index[doctor.UserID] = len(roster.Doctors)
+		roster.Doctors = append(roster.Doctors, DoctorRoster{
+			DoctorID:        doctor.UserID,
+			Name:            doctor.Name,
+			Specialty:       doctor.Specialty,
+			Slots:           []RosterSlot{},
+			InstantBookings: []RosterBooking{},
+		})
+	}
+
+	// 退会済みの医師の枠・予約は一覧に含めない
+	for _, slot := range slots {
+		i, ok := index[slot.DoctorID]
+		if !ok {
+			continue
+		}
+		entry := RosterSlot{SlotID: slot.ID, StartTime: slot.StartTime, EndTime: slot.EndTime, Status: slot.Status}
+		if slot.Appointment != nil {
+			booking := newRosterBooking(slot.Appointment)
+			entry.Status = "booked"
+			entry.Booking = &booking
+			roster.Doctors[i].BookedCount++
+		} else if slot.Status == "open" {
+			roster.Doctors[i].OpenCount++
+		}
+		roster.Doctors[i].Slots = append(roster.Doctors[i].Slots, entry)
+	}
+
+	for i := range instants {
+		j, ok := index[instants[i].DoctorID]
+		if !ok {
+			continue
+		}
+		roster.Doctors[j].InstantBookings = append(roster.Doctors[j].InstantBookings, newRosterBooking(&instants[i]))
+		roster.Doctors[j].BookedCount++
+	}
+
+	return roster, nil
+}
+
+// RenderDoctorSchedulePDF 医師の週間予定表のPDF（ファイル名とデータ、週は指定日を含む月曜日から7日間）
+// 患者の氏名は initialsOnly の場合イニシャルのみ表示する
+func (s *RosterService) RenderDoctorSchedulePDF(doctorID uint, week string, initialsOnly bool) (string, []byte, error) {
+	profile, err := s.userRepo.FindDoctorProfileByUserID(doctorID)
+	if err != nil || profile == nil {
+		return "", nil, errors.New("doctor profile not found")
+	}
+
+	location, err := s.bookingPolicyService.Location()
+	if err != nil {
+		return "", nil, err
+	}
+
+	day := time.Now().In(location)
+	if week != "" {
+		day, err = time.ParseInLocation("2006-01-02", week, location)
+		if err != nil {
+			return "", nil, errors.New("invalid week format (expected YYYY-MM-DD)")
+		}
+	}
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, location)
+	from = from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+	to := from.AddDate(0, 0, 7)
+
+	slots, err := s.slotRepo.FindInRangeWithBookings(from, to, doctorID)
+	if err != nil {
+		return "", nil, err
+	}
+	instants, err := s.appointmentRepo.FindInstantCreatedInRange(from, to, doctorID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// 日ごとの行（開始時刻順、即時診療は受付時刻で並べる）
+	type scheduleLine struct {
+		at   time.Time
+		text string
+	}
+	days := make([][]scheduleLine, 7)
+	dayIndex := func(t time.Time) int {
+		local := t.In(location)
+		return int(time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location).Sub(from).Hours() / 24)
+	}
+	bookings := 0
+	for _, slot := range slots {
+		i := dayIndex(slot.StartTime)
+		if i < 0 || i >= 7 {
+			continue
+		}
+		text := fmt.Sprintf("%s-%s  ", slot.StartTime.In(location).Format("15:04"), slot.EndTime.In(location).Format("15:04"))
+		switch {
+		case slot.Appointment != nil:
+			text += scheduleBookingText(newRosterBooking(slot.Appointment), initialsOnly)
+			bookings++
+		case slot.Status == "blocked":
+			text += "停止中"
+		default:
+			text += "空き"
+		}
+		days[i] = append(days[i], scheduleLine{at: slot.StartTime, text: text})
+	}
+	for i := range instants {
+		j := dayIndex(instants[i].CreatedAt)
+		if j < 0 || j >= 7 {
+			continue
+		}
+		text := instants[i].CreatedAt.In(location).Format("15:04") + "  " + scheduleBookingText(newRosterBooking(&instants[i]), initialsOnly)
+		days[j] = append(days[j], scheduleLine{at: instants[i].CreatedAt, text: text})
+		bookings++
+	}
+
+	doc := s.brandingService.NewPDFDocument()
+	doc.Heading("週間予定表")
+	doc.Blank()
+	doc.Text(fmt.Sprintf("医師: %s（%s）", profile.Name, profile.Specialty))
+	doc.Text(fmt.Sprintf("期間: %s〜%s", from.Format("2006年1月2日"), to.AddDate(0, 0, -1).Format("2006年1月2日")))
+	doc.Text(fmt.Sprintf("予約: %d件", bookings))
+	for i, lines := range days {
+		date := from.AddDate(0, 0, i)
+		doc.Blank()
+		doc.Text(fmt.Sprintf("■ %s（%s）", date.Format("1月2日"), scheduleWeekdays[date.Weekday()]))
+		if len(lines) == 0 {
+			doc.Text("予定なし")
+			continue
+		}
+		// 診療枠の後に受け付けた即時診療も時刻順に並べる
+		sort.SliceStable(lines, func(a, b int) bool { return lines[a].at.Before(lines[b].at) })
+		for _, line := range lines {
+			doc.Text(line.text)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		return "", nil, err
+	}
+
+	s.auditService.LogUserAction(doctorID, "export", "doctor_schedule", fmt.Sprintf("%d", doctorID), map[string]interface{}{
+		"week":          from.Format("2006-01-02"),
+		"bookings":      bookings,
+		"initials_only": initialsOnly,
+	})
+
+	return fmt.Sprintf("schedule_%s.pdf", from.Format("20060102")), buf.Bytes(), nil
+}
+
+func (s *RosterService) isAdmin(userID uint) bool {
+	user, err := s.userRepo.FindByID(userID)
+	return err == nil && policy.IsAdmin(user)
+}
+
+// newRosterBooking 予約から勤務表用の情報を抜き出す（家族の代理予約は受診者の氏名を表示）
+func newRosterBooking(appointment *models.Appointment) RosterBooking {
+	booking := RosterBooking{
+		AppointmentID:       appointment.ID,
+		Status:              appointment.Status,
+		PatientID:           appointment.PatientID,
+		IsUrgent:            appointment.IsUrgent,
+		IsInstant:           appointment.IsInstant,
+		InterpreterLanguage: appointment.InterpreterLanguage,
+		CreatedAt:           appointment.CreatedAt,
+	}
+	switch {
+	case appointment.Dependent != nil:
+		booking.PatientName = appointment.Dependent.Name
+	case appointment.Patient.PatientProfile != nil:
+		booking.PatientName = appointment.Patient.PatientProfile.Name
+	}
+	return booking
+}
+
+// scheduleBookingText 予定表に表示する予約（患者の氏名と診療の種類）
+func scheduleBookingText(booking RosterBooking, initialsOnly bool) string {
+	name := booking.PatientName
+	if initialsOnly {
+		name = patientInitials(name)
+	}
+	if name == "" {
+		name = "氏名未登録"
+	} else {
+		name += "様"
+	}
+
+	kinds := []string{"ビデオ診療"}
+	if booking.IsInstant {
+		kinds[0] = "即時診療"
+	}
+	if booking.IsUrgent {
+		kinds = append(kinds, "緊急")
+	}
+	if booking.InterpreterLanguage != "" {
+		kinds = append(kinds, "通訳: "+booking.InterpreterLanguage)
+	}
+	if booking.Status == "pending" {
+		kinds = append(kinds, "承認待ち")
+	}
+	return fmt.Sprintf("%s（%s）", name, strings.Join(kinds, "・"))
+}
+
+// patientInitials 氏名のイニシャル（空白で区切った各部分の先頭の文字、例: "山田 太郎" → "山.太."）
+func patientInitials(name string) string {
+	var initials strings.Builder
+	for _, part := range strings.FieldsFunc(name, unicode.IsSpace) {
+		r := []rune(part)[0]
+		initials.WriteRune(unicode.ToUpper(r))
+		initials.WriteString(".")
+	}
+	return initials.String()
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/policy"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 保存した検索条件の名前・値の最大文字数と、利用者・対象ごとの上限
+const (
+	savedFilterNameMaxLength  = 50
+	savedFilterValueMaxLength = 200
+	savedFiltersPerScope      = 50
+)
+
+// savedFilterScope 保存した検索条件の対象ごとの利用できるロールと検索パラメーター
+type savedFilterScope struct {
+	roles  []string
+	params map[string]bool
+}
+
+// 対象ごとの設定（パラメーターは一覧のクエリパラメーターと同じ名前）
+var savedFilterScopes = map[string]savedFilterScope{
+	models.SavedFilterAppointments: {
+		roles:  policy.DoctorSelf,
+		params: map[string]bool{"status": true, "tags": true, "match": true},
+	},
+	models.SavedFilterAuditLogs: {
+		roles: policy.Admin,
+		params: map[string]bool{
+			"entity": true, "entity_id": true, "action": true, "severity": true,
+			"start_date": true, "end_date": true,
+		},
+	},
+}
+
+type SavedFilterService struct {
+	savedFilterRepo repositories.SavedFilterRepository
+	userRepo        repositories.UserRepository
+}
+
+// SavedFilterRequest 検索条件の保存・更新
+type SavedFilterRequest struct {
+	Scope string          `json:"scope"` // 更新時は変更できない
+	Name  string          `json:"name" binding:"required"`
+	Query json.RawMessage `json:"query" binding:"required"` // パラメーター名と値（文字列）のJSONオブジェクト
+}
+
+func NewSavedFilterService(savedFilterRepo repositories.SavedFilterRepository, userRepo repositories.UserRepository) *SavedFilterService {
+	return &SavedFilterService{
+		savedFilterRepo: savedFilterRepo,
+		userRepo:        userRepo,
+	}
+}
+
+// GetFilters 保存した検索条件の一覧（scope が空の場合は利用できるすべての対象）
+func (s *SavedFilterService) GetFilters(userID uint, scope string) ([]models.SavedFilter, error) {
+	if scope != "" {
+		if _, err := s.scopeFor(userID, scope); err != nil {
+			return nil, err
+		}
+	}
+	return s.savedFilterRepo.FindByUser(userID, scope)
+}
+
+// CreateFilter 検索条件の保存
+func (s *SavedFilterService) CreateFilter(userID uint, req SavedFilterRequest) (*models.SavedFilter, error) {
+	scope, err := s.scopeFor(userID, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+	name, queryJSON, err := normalizeSavedFilter(scope, req)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.savedFilterRepo.CountByUser(userID, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+	if count >= savedFiltersPerScope {
+		return nil, errors.New("too many saved filters")
+	}
+
+	filter := &models.SavedFilter{UserID: userID, Scope: req.Scope, Name: name, QueryJSON: queryJSON}
+	if err := s.savedFilterRepo.Create(filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// UpdateFilter 保存した検索条件の名前・条件の変更
+func (s *SavedFilterService) UpdateFilter(userID, filterID uint, req SavedFilterRequest) (*models.SavedFilter, error) {
+	filter, err := s.findFilter(userID, filterID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Scope != "" && req.Scope != filter.Scope {
+		return nil, errors.New("invalid scope")
+	}
+	scope, err := s.scopeFor(userID, filter.Scope)
+	if err != nil {
+		return nil, err
+	}
+	name, queryJSON, err := normalizeSavedFilter(scope, req)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.Name = name
+	filter.QueryJSON = queryJSON
+	if err := s.savedFilterRepo.Update(filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// DeleteFilter 保存した検索条件の削除
+func (s *SavedFilterService) DeleteFilter(userID, filterID uint) error {
+	if _, err := s.findFilter(userID, filterID); err != nil {
+		return err
+	}
+	return s.savedFilterRepo.Delete(filterID)
+}
+
+// findFilter 利用者の保存した検索条件の取得（他の利用者の条件は存在しないものとして扱う）
+func (s *SavedFilterService) findFilter(userID, filterID uint) (*models.SavedFilter, error) {
+	filter, err := s.savedFilterRepo.FindByID(filterID)
+	if err != nil {
+		return nil, err
+	}
+	if filter == nil || filter.UserID != userID {
+		return nil, errors.New("saved filter not found")
+	}
+	return filter, nil
+}
+
+// scopeFor 対象の確認（利用者のロールで検索できない対象は保存できない）
+func (s *SavedFilterService) scopeFor(userID uint, name string) (savedFilterScope, error) {
+	scope, ok := savedFilterScopes[name]
+	if !ok {
+		return scope, errors.New("invalid scope")
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		return scope, errors.New("user not found")
+	}
+	if !policy.Allows(user.Role, scope.roles...) {
+		return scope, errors.New("unauthorized: scope is not available for this role")
+	}
+	return scope, nil
+}
+
+// normalizeSavedFilter 名前と検索条件の確認（対象のパラメーターのみ、値は文字列）
+func normalizeSavedFilter(scope savedFilterScope, req SavedFilterRequest) (string, string, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > savedFilterNameMaxLength {
+		return "", "", errors.New("invalid filter name")
+	}
+
+	var query map[string]string
+	if err := json.Unmarshal(req.Query, &query); err != nil || query == nil {
+		return "", "", errors.New("invalid query: must be an object of string values")
+	}
+	for key, value := range query {
+		if !scope.params[key] {
+			return "", "", errors.New("invalid query parameter: " + key)
+		}
+		if utf8.RuneCountInString(value) > savedFilterValueMaxLength {
+			return "", "", errors.New("invalid query value: " + key)
+		}
+		if value == "" {
+			delete(query, key)
+		}
+	}
+
+	// キー順に整形して保存する
+	normalized, err := json.Marshal(query)
+	if err != nil {
+		return "", "", err
+	}
+	return name, string(normalized), nil
+}
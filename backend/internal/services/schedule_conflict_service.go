@@ -0,0 +1,283 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 予定の不整合の種類
+const (
+	ScheduleIssueMissingSlot   = "missing_slot"         // 診療枠がない（削除済み・他の医師の枠）未完了の予約
+	ScheduleIssueOverlap       = "overlapping_bookings" // 時間が重なる確定済みの予約
+	ScheduleIssueStaleOpenSlot = "stale_open_slot"      // 終了時刻を過ぎても公開中のままの空き枠
+)
+
+// 不整合の解消方法
+const (
+	ScheduleFixCancelAppointment = "cancel_appointment"
+	ScheduleFixRestoreSlot       = "restore_slot"
+	ScheduleFixBlockSlot         = "block_slot"
+)
+
+type ScheduleConflictService struct {
+	appointmentRepo    repositories.AppointmentRepository
+	slotRepo           repositories.SlotRepository
+	userRepo           repositories.UserRepository
+	appointmentService *AppointmentService
+	auditService       *AuditService
+}
+
+// ScheduleFix 不整合の解消方法（レポートの項目をそのまま送信して実行する）
+type ScheduleFix struct {
+	Action        string `json:"action" binding:"required"`
+	AppointmentID *uint  `json:"appointment_id,omitempty"`
+	SlotID        *uint  `json:"slot_id,omitempty"`
+	Label         string `json:"label,omitempty"`
+}
+
+// ScheduleIssue 医師の予定の不整合
+type ScheduleIssue struct {
+	Type           string        `json:"type"`
+	Description    string        `json:"description"`
+	AppointmentIDs []uint        `json:"appointment_ids,omitempty"`
+	SlotIDs        []uint        `json:"slot_ids,omitempty"`
+	StartTime      *time.Time    `json:"start_time,omitempty"`
+	EndTime        *time.Time    `json:"end_time,omitempty"`
+	Fixes          []ScheduleFix `json:"fixes"`
+}
+
+// ScheduleConflictReport 医師の予定の不整合のレポート
+type ScheduleConflictReport struct {
+	DoctorID  uint            `json:"doctor_id"`
+	CheckedAt time.Time       `json:"checked_at"`
+	Issues    []ScheduleIssue `json:"issues"`
+}
+
+func NewScheduleConflictService(appointmentRepo repositories.AppointmentRepository, slotRepo repositories.SlotRepository, userRepo repositories.UserRepository, appointmentService *AppointmentService, auditService *AuditService) *ScheduleConflictService {
+	return &ScheduleConflictService{
+		appointmentRepo:    appointmentRepo,
+		slotRepo:           slotRepo,
+		userRepo:           userRepo,
+		appointmentService: appointmentService,
+		auditService:       auditService,
+	}
+}
+
+// GetReport 医師の予定の不整合の検出（医師本人または管理者）
+func (s *ScheduleConflictService) GetReport(userID, doctorID uint) (*ScheduleConflictReport, error) {
+	if err := s.authorize(userID, doctorID); err != nil {
+		return nil, err
+	}
+	return s.buildReport(doctorID)
+}
+
+// ApplyFix レポートで提示した解消方法の実行（医師本人または管理者）
+// 実行前に再度検出し、現在も提示される解消方法のみ実行する。実行後のレポートを返す
+func (s *ScheduleConflictService) ApplyFix(userID, doctorID uint, fix ScheduleFix) (*ScheduleConflictReport, error) {
+	if err := s.authorize(userID, doctorID); err != nil {
+		return nil, err
+	}
+
+	report, err := s.buildReport(doctorID)
+	if err != nil {
+		return nil, err
+	}
+	if !reportOffersFix(report, fix) {
+		return nil, errors.New("fix is no longer applicable")
+	}
+
+	switch fix.Action {
+	case ScheduleFixCancelAppointment:
+		appointment, err := s.appointmentRepo.FindByID(*fix.AppointmentID)
+		if err != nil || appointment == nil {
+			return nil, errors.New("appointment not found")
+		}
+		if err := s.appointmentService.CancelForScheduleConflict(appointment); err != nil {
+			return nil, err
+		}
+	case ScheduleFixRestoreSlot:
+		restored, err := s.slotRepo.Restore(*fix.SlotID)
+		if err != nil {
+			return nil, err
+		}
+		if !restored {
+			return nil, errors.New("fix is no longer applicable")
+		}
+	case ScheduleFixBlockSlot:
+		blocked, err := s.slotRepo.BlockIfOpen(*fix.SlotID)
+		if err != nil {
+			return nil, err
+		}
+		if !blocked {
+			return nil, errors.New("fix is no longer applicable")
+		}
+	}
+
+	fix.Label = ""
+	s.auditService.LogUserAction(userID, "schedule_fix", "doctor_schedule", strconv.FormatUint(uint64(doctorID), 10), fix)
+
+	return s.buildReport(doctorID)
+}
+
+func (s *ScheduleConflictService) buildReport(doctorID uint) (*ScheduleConflictReport, error) {
+	now := time.Now()
+	appointments, err := s.appointmentRepo.FindScheduledOpenByDoctor(doctorID)
+	if err != nil {
+		return nil, err
+	}
+	staleSlots, err := s.slotRepo.FindStaleOpen(doctorID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := []ScheduleIssue{}
+	var booked []models.Appointment
+	for _, appointment := range appointments {
+		slot := appointment.Slot
+		if slot == nil || slot.DeletedAt.Valid || slot.DoctorID != doctorID {
+			issues = append(issues, missingSlotIssue(appointment, doctorID))
+			continue
+		}
+		if appointment.Status == "confirmed" && slot.EndTime.After(now) {
+			booked = append(booked, appointment)
+		}
+	}
+	issues = append(issues, overlapIssues(booked)...)
+
+	for _, slot := range staleSlots {
+		slotID := slot.ID
+		start, end := slot.StartTime, slot.EndTime
+		issues = append(issues, ScheduleIssue{
+			Type:        ScheduleIssueStaleOpenSlot,
+			Description: "終了時刻を過ぎた空き枠が公開中のままです",
+			SlotIDs:     []uint{slot.ID},
+			StartTime:   &start,
+			EndTime:     &end,
+			Fixes: []ScheduleFix{
+				{Action: ScheduleFixBlockSlot, SlotID: &slotID, Label: "診療枠を停止する"},
+			},
+		})
+	}
+
+	return &ScheduleConflictReport{DoctorID: doctorID, CheckedAt: now, Issues: issues}, nil
+}
+
+// missingSlotIssue 診療枠がない予約（削除済みの自分の枠は復元できる）
+func missingSlotIssue(appointment models.Appointment, doctorID uint) ScheduleIssue {
+	appointmentID := appointment.ID
+	issue := ScheduleIssue{
+		Type:           ScheduleIssueMissingSlot,
+		Description:    "診療枠がない予約です",
+		AppointmentIDs: []uint{appointment.ID},
+	}
+	if slot := appointment.Slot; slot != nil {
+		start, end := slot.StartTime, slot.EndTime
+		issue.SlotIDs = []uint{slot.ID}
+		issue.StartTime, issue.EndTime = &start, &end
+		if slot.DoctorID != doctorID {
+			issue.Description = "他の医師の診療枠を参照している予約です"
+		} else {
+			issue.Description = "削除された診療枠の予約です"
+			slotID := slot.ID
+			issue.Fixes = append(issue.Fixes, ScheduleFix{Action: ScheduleFixRestoreSlot, SlotID: &slotID, Label: "診療枠を復元する"})
+		}
+	}
+	issue.Fixes = append(issue.Fixes, ScheduleFix{Action: ScheduleFixCancelAppointment, AppointmentID: &appointmentID, Label: "予約をキャンセルする"})
+	return issue
+}
+
+// overlapIssues 時間が重なる確定済みの予約をまとめ、最初に予約されたもの以外のキャンセルを提示する
+func overlapIssues(appointments []models.Appointment) []ScheduleIssue {
+	sort.SliceStable(appointments, func(i, j int) bool {
+		return appointments[i].Slot.StartTime.Before(appointments[j].Slot.StartTime)
+	})
+
+	var issues []ScheduleIssue
+	for i := 0; i < len(appointments); {
+		group := []models.Appointment{appointments[i]}
+		groupEnd := appointments[i].Slot.EndTime
+		j := i + 1
+		for ; j < len(appointments) && appointments[j].Slot.StartTime.Before(groupEnd); j++ {
+			group = append(group, appointments[j])
+			if appointments[j].Slot.EndTime.After(groupEnd) {
+				groupEnd = appointments[j].Slot.EndTime
+			}
+		}
+		i = j
+		if len(group) < 2 {
+			continue
+		}
+
+		// 先に予約されたものを残す
+		sort.SliceStable(group, func(a, b int) bool {
+			if group[a].CreatedAt.Equal(group[b].CreatedAt) {
+				return group[a].ID < group[b].ID
+			}
+			return group[a].CreatedAt.Before(group[b].CreatedAt)
+		})
+		start := group[0].Slot.StartTime
+		end := groupEnd
+		issue := ScheduleIssue{
+			Type:        ScheduleIssueOverlap,
+			Description: fmt.Sprintf("時間が重なる確定済みの予約が%d件あります", len(group)),
+			StartTime:   &start,
+			EndTime:     &end,
+			Fixes:       []ScheduleFix{},
+		}
+		for k, appointment := range group {
+			issue.AppointmentIDs = append(issue.AppointmentIDs, appointment.ID)
+			issue.SlotIDs = append(issue.SlotIDs, appointment.Slot.ID)
+			if k == 0 {
+				continue
+			}
+			appointmentID := appointment.ID
+			issue.Fixes = append(issue.Fixes, ScheduleFix{
+				Action:        ScheduleFixCancelAppointment,
+				AppointmentID: &appointmentID,
+				Label:         fmt.Sprintf("後から入った予約 #%d をキャンセルする", appointment.ID),
+			})
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// reportOffersFix レポートが指定の解消方法を提示しているか
+func reportOffersFix(report *ScheduleConflictReport, fix ScheduleFix) bool {
+	for _, issue := range report.Issues {
+		for _, offered := range issue.Fixes {
+			if offered.Action == fix.Action && sameID(offered.AppointmentID, fix.AppointmentID) && sameID(offered.SlotID, fix.SlotID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// authorize 医師本人または管理者のみ
+func (s *ScheduleConflictService) authorize(userID, doctorID uint) error {
+	if userID != doctorID {
+		user, err := s.userRepo.FindByID(userID)
+		if err != nil || user == nil || user.Role != "admin" {
+			return errors.New("unauthorized: admin access required")
+		}
+	}
+	doctor, err := s.userRepo.FindByID(doctorID)
+	if err != nil || doctor == nil || doctor.Role != "doctor" {
+		return errors.New("doctor not found")
+	}
+	return nil
+}
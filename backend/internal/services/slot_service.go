@@ -3,14 +3,26 @@ package services
 import (
 	"errors"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"online_medical_consultation_app/backend/internal/models"
 	"online_medical_consultation_app/backend/internal/repositories"
 )
 
+// 毎週繰り返す診療枠のひな形
+const (
+	defaultSlotTemplateWeeks  = 4  // 診療枠を作成する週数の初期値
+	maxSlotTemplatesPerDoctor = 20 // 医師ごとのひな形の上限
+)
+
 type SlotService struct {
-	slotRepo repositories.SlotRepository
+	slotRepo             repositories.SlotRepository
+	slotTemplateRepo     repositories.SlotTemplateRepository
+	timeOffRepo          repositories.TimeOffRepository
+	bookingPolicyService *BookingPolicyService
 }
 
 type CreateSlotRequest struct {
@@ -24,9 +36,21 @@ type UpdateSlotRequest struct {
 	Notes  string `json:"notes"`
 }
 
-func NewSlotService(slotRepo repositories.SlotRepository) *SlotService {
+// CreateSlotTemplateRequest 毎週繰り返す診療枠（例: 月・水の9:00〜12:00を20分ごと）
+type CreateSlotTemplateRequest struct {
+	Weekdays    []int  `json:"weekdays" binding:"required"`   // 0=日〜6=土
+	StartTime   string `json:"start_time" binding:"required"` // HH:MM
+	EndTime     string `json:"end_time" binding:"required"`   // HH:MM（24:00まで）
+	SlotMinutes int    `json:"slot_minutes" binding:"required"`
+	WeeksAhead  int    `json:"weeks_ahead"` // 何週先まで診療枠を作成するか（省略時は4週）
+}
+
+func NewSlotService(slotRepo repositories.SlotRepository, slotTemplateRepo repositories.SlotTemplateRepository, timeOffRepo repositories.TimeOffRepository, bookingPolicyService *BookingPolicyService) *SlotService {
 	return &SlotService{
-		slotRepo: slotRepo,
+		slotRepo:             slotRepo,
+		slotTemplateRepo:     slotTemplateRepo,
+		timeOffRepo:          timeOffRepo,
+		bookingPolicyService: bookingPolicyService,
 	}
 }
 
@@ -50,6 +74,20 @@ func (s *SlotService) CreateSlot(doctorID uint, req CreateSlotRequest) (*models.
 		return nil, errors.New("start time must be before end time")
 	}
 
+	// 受付期間・受付時間外の枠は作成できない
+	if err := s.bookingPolicyService.CheckSlot(startTime, endTime); err != nil {
+		return nil, err
+	}
+
+	// 重なる枠があると同じ時間に二重に予約されるため作成できない
+	overlapping, err := s.slotRepo.FindOverlapping(doctorID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	if len(overlapping) > 0 {
+		return nil, errors.New("slot overlaps an existing slot")
+	}
+
 	slot := &models.AvailabilitySlot{
 		DoctorID:  doctorID,
 		StartTime: startTime,
@@ -64,9 +102,13 @@ func (s *SlotService) CreateSlot(doctorID uint, req CreateSlotRequest) (*models.
 	return slot, nil
 }
 
-// GetSlotsByDoctorID 医師の診療枠一覧取得
-func (s *SlotService) GetSlotsByDoctorID(doctorID uint) ([]models.AvailabilitySlot, error) {
-	return s.slotRepo.FindByDoctorID(doctorID)
+// GetSlotsByDoctorID 医師の診療枠一覧取得（includePastがfalseの場合は終了していない枠のみ）
+func (s *SlotService) GetSlotsByDoctorID(doctorID uint, includePast bool, limit, offset int) ([]models.AvailabilitySlot, int64, error) {
+	var endedAfter time.Time
+	if !includePast {
+		endedAfter = time.Now()
+	}
+	return s.slotRepo.FindPageByDoctorID(doctorID, endedAfter, limit, offset)
 }
 
 // UpdateSlot 診療枠の更新
@@ -84,6 +126,10 @@ func (s *SlotService) UpdateSlot(slotID, doctorID uint, req UpdateSlotRequest) (
 		if req.Status != "open" && req.Status != "blocked" {
 			return nil, errors.New("invalid status")
 		}
+		// 予約済みの枠は予約のキャンセルで公開中に戻る
+		if slot.Status == "booked" {
+			return nil, errors.New("cannot change status of a booked slot")
+		}
 		slot.Status = req.Status
 	}
 
@@ -111,7 +157,7 @@ func (s *SlotService) DeleteSlot(slotID, doctorID uint) error {
 	}
 
 	// 予約が入っている診療枠は削除できない
-	if slot.Appointment != nil {
+	if slot.Status == "booked" || slot.Appointment != nil {
 		return errors.New("cannot delete slot with existing appointment")
 	}
 
@@ -135,11 +181,11 @@ func (s *SlotService) GetAvailableSlots(doctorID uint, date string) ([]models.Av
 		return nil, err
 	}
 
-	// 現在時刻より後の診療枠のみを返す
+	// 現在時刻より後で、予約受付ルールを満たす診療枠のみを返す
 	var availableSlots []models.AvailabilitySlot
 	now := time.Now()
 	for _, slot := range slots {
-		if slot.StartTime.After(now) && slot.Status == "open" {
+		if slot.StartTime.After(now) && slot.Status == "open" && s.bookingPolicyService.CheckBookable(slot.StartTime, slot.EndTime) == nil {
 			availableSlots = append(availableSlots, slot)
 		}
 	}
@@ -151,3 +197,241 @@ func (s *SlotService) GetAvailableSlots(doctorID uint, date string) ([]models.Av
 
 	return availableSlots, nil
 }
+
+// CreateSlotTemplate 毎週繰り返す診療枠のひな形の登録（登録時に対象期間の診療枠を作成する）
+// 戻り値の件数は作成した診療枠の数
+func (s *SlotService) CreateSlotTemplate(doctorID uint, req CreateSlotTemplateRequest) (*models.SlotTemplate, int, error) {
+	days := append([]int(nil), req.Weekdays...)
+	sort.Ints(days)
+	weekdays := make([]string, 0, len(days))
+	for i, day := range days {
+		if day < 0 || day > 6 {
+			return nil, 0, errors.New("weekdays must be between 0 (Sunday) and 6 (Saturday)")
+		}
+		if i > 0 && days[i-1] == day {
+			continue
+		}
+		weekdays = append(weekdays, strconv.Itoa(day))
+	}
+
+	template := &models.SlotTemplate{
+		DoctorID:    doctorID,
+		Weekdays:    strings.Join(weekdays, ","),
+		StartTime:   strings.TrimSpace(req.StartTime),
+		EndTime:     strings.TrimSpace(req.EndTime),
+		SlotMinutes: req.SlotMinutes,
+		WeeksAhead:  req.WeeksAhead,
+	}
+	if template.WeeksAhead == 0 {
+		template.WeeksAhead = defaultSlotTemplateWeeks
+	}
+	if err := ValidateSlotTemplate(template); err != nil {
+		return nil, 0, err
+	}
+
+	templates, err := s.slotTemplateRepo.FindByDoctorID(doctorID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(templates) >= maxSlotTemplatesPerDoctor {
+		return nil, 0, errors.New("too many slot templates")
+	}
+
+	if err := s.slotTemplateRepo.Create(template); err != nil {
+		return nil, 0, err
+	}
+
+	// 作成できなかった分は定期ジョブで再試行しない（次回以降の期間のみ作成する）
+	created, err := s.materializeTemplate(template, time.Now())
+	if err != nil {
+		log.Printf("Warning: Failed to create slots from template %d: %v", template.ID, err)
+	}
+	return template, created, nil
+}
+
+// GetSlotTemplates 医師のひな形一覧
+func (s *SlotService) GetSlotTemplates(doctorID uint) ([]models.SlotTemplate, error) {
+	return s.slotTemplateRepo.FindByDoctorID(doctorID)
+}
+
+// DeleteSlotTemplate ひな形の削除（作成済みの診療枠は残す）
+func (s *SlotService) DeleteSlotTemplate(templateID, doctorID uint) error {
+	deleted, err := s.slotTemplateRepo.DeleteForDoctor(templateID, doctorID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("slot template not found")
+	}
+	return nil
+}
+
+// RunSlotTemplateJob 定期ジョブ：ひな形から各医師の指定週数先までの診療枠を作成する
+func (s *SlotService) RunSlotTemplateJob() error {
+	templates, err := s.slotTemplateRepo.FindAll()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range templates {
+		created, err := s.materializeTemplate(&templates[i], now)
+		if err != nil {
+			log.Printf("Warning: Failed to create slots from template %d: %v", templates[i].ID, err)
+			continue
+		}
+		if created > 0 {
+			log.Printf("Created %d slots from template %d", created, templates[i].ID)
+		}
+	}
+	return nil
+}
+
+// materializeTemplate ひな形の未作成の期間（指定週数先・受付期間まで）の診療枠を作成する
+// 既存の診療枠・休診期間と重なる枠、受付時間外の枠は作成しない
+// 作成する期間は先に記録し、複数のインスタンスで同じ期間を重ねて作成しないようにする
+// 作成の途中で失敗した場合は作成を終えた日までに記録を戻し、残りの期間は次回のジョブで作成する
+func (s *SlotService) materializeTemplate(template *models.SlotTemplate, now time.Time) (int, error) {
+	policy, err := s.bookingPolicyService.GetPolicy()
+	if err != nil {
+		return 0, err
+	}
+	location, err := time.LoadLocation(policy.Timezone)
+	if err != nil {
+		return 0, errors.New("invalid booking timezone")
+	}
+	weekdays, err := parseWeekdays(template.Weekdays)
+	if err != nil {
+		return 0, err
+	}
+	startClock, err := parseClock(template.StartTime)
+	if err != nil {
+		return 0, err
+	}
+	endClock, err := parseClock(template.EndTime)
+	if err != nil {
+		return 0, err
+	}
+
+	from := startOfDay(now, location)
+	if template.MaterializedThrough != nil && template.MaterializedThrough.After(from) {
+		from = template.MaterializedThrough.In(location)
+	}
+	through := startOfDay(now, location).AddDate(0, 0, 7*template.WeeksAhead)
+	// 受付期間を超える日は受付期間が進んでから作成する
+	if limit := startOfDay(now.AddDate(0, 0, policy.MaxAdvanceDays), location); limit.Before(through) {
+		through = limit
+	}
+	if !from.Before(through) {
+		return 0, nil
+	}
+
+	claimed, err := s.slotTemplateRepo.MarkMaterialized(template.ID, template.MaterializedThrough, through)
+	if err != nil || !claimed {
+		return 0, err
+	}
+	template.MaterializedThrough = &through
+
+	created := 0
+	written := from
+	release := func(cause error) (int, error) {
+		if _, err := s.slotTemplateRepo.MarkMaterialized(template.ID, &through, written); err != nil {
+			log.Printf("Warning: Failed to release materialized period of template %d: %v", template.ID, err)
+		}
+		template.MaterializedThrough = &written
+		return created, cause
+	}
+
+	existing, err := s.slotRepo.FindOverlapping(template.DoctorID, from, through)
+	if err != nil {
+		return release(err)
+	}
+	timeOffs, err := s.timeOffRepo.FindUpcomingByDoctorID(template.DoctorID, from)
+	if err != nil {
+		return release(err)
+	}
+
+	for day := from; day.Before(through); day = day.AddDate(0, 0, 1) {
+		// 前日までの枠は作成済み（失敗した日の作成済みの枠は次回に既存の枠として除外する）
+		written = day
+		if !weekdays[day.Weekday()] {
+			continue
+		}
+		for clock := startClock; clock+template.SlotMinutes <= endClock; clock += template.SlotMinutes {
+			start := time.Date(day.Year(), day.Month(), day.Day(), 0, clock, 0, 0, location)
+			end := time.Date(day.Year(), day.Month(), day.Day(), 0, clock+template.SlotMinutes, 0, 0, location)
+			if !start.After(now) || checkWithinPolicy(policy, start, end) != nil {
+				continue
+			}
+			if overlapsSlots(existing, start, end) || overlapsTimeOffs(timeOffs, start, end) {
+				continue
+			}
+
+			slot := &models.AvailabilitySlot{
+				DoctorID:  template.DoctorID,
+				StartTime: start,
+				EndTime:   end,
+				Status:    "open",
+			}
+			if err := s.slotRepo.Create(slot); err != nil {
+				return release(err)
+			}
+			existing = append(existing, *slot)
+			created++
+		}
+	}
+	return created, nil
+}
+
+// ValidateSlotTemplate ひな形の値の検証
+func ValidateSlotTemplate(template *models.SlotTemplate) error {
+	weekdays, err := parseWeekdays(template.Weekdays)
+	if err != nil || len(weekdays) == 0 {
+		return errors.New("weekdays must contain at least one day between 0 (Sunday) and 6 (Saturday)")
+	}
+	startClock, err := parseClock(template.StartTime)
+	if err != nil {
+		return errors.New("invalid start_time (expected HH:MM)")
+	}
+	endClock, err := parseClock(template.EndTime)
+	if err != nil {
+		return errors.New("invalid end_time (expected HH:MM)")
+	}
+	if startClock >= endClock {
+		return errors.New("start_time must be before end_time")
+	}
+	if template.SlotMinutes < 5 || template.SlotMinutes > 240 {
+		return errors.New("slot_minutes must be between 5 and 240")
+	}
+	if endClock-startClock < template.SlotMinutes {
+		return errors.New("slot_minutes must fit between start_time and end_time")
+	}
+	if template.WeeksAhead < 1 || template.WeeksAhead > 12 {
+		return errors.New("weeks_ahead must be between 1 and 12")
+	}
+	return nil
+}
+
+// startOfDay 指定したタイムゾーンでのその日の0時
+func startOfDay(at time.Time, location *time.Location) time.Time {
+	local := at.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+}
+
+func overlapsSlots(slots []models.AvailabilitySlot, start, end time.Time) bool {
+	for _, slot := range slots {
+		if slot.StartTime.Before(end) && slot.EndTime.After(start) {
+			return true
+		}
+	}
+	return false
+}
+
+func overlapsTimeOffs(timeOffs []models.DoctorTimeOff, start, end time.Time) bool {
+	for _, timeOff := range timeOffs {
+		if timeOff.StartTime.Before(end) && timeOff.EndTime.After(start) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// slotOpeningSettle 医師が続けて作成している枠をまとめて通知するため、作成から通知までに待つ時間
+const slotOpeningSettle = 5 * time.Minute
+
+type SlotSubscriptionService struct {
+	subscriptionRepo     repositories.SlotSubscriptionRepository
+	userRepo             repositories.UserRepository
+	notificationService  *NotificationService
+	bookingPolicyService *BookingPolicyService
+	sender               ContactSender
+	appBaseURL           string
+	batchSize            int
+	cooldown             time.Duration
+}
+
+type SubscribeSlotsRequest struct {
+	DoctorID uint `json:"doctor_id" binding:"required"`
+}
+
+type UnsubscribeSlotsRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func NewSlotSubscriptionService(subscriptionRepo repositories.SlotSubscriptionRepository, userRepo repositories.UserRepository, notificationService *NotificationService, bookingPolicyService *BookingPolicyService, sender ContactSender, appBaseURL string, batchSize int, cooldown time.Duration) *SlotSubscriptionService {
+	return &SlotSubscriptionService{
+		subscriptionRepo:     subscriptionRepo,
+		userRepo:             userRepo,
+		notificationService:  notificationService,
+		bookingPolicyService: bookingPolicyService,
+		sender:               sender,
+		appBaseURL:           strings.TrimRight(appBaseURL, "/"),
+		batchSize:            batchSize,
+		cooldown:             cooldown,
+	}
+}
+
+// Subscribe 医師の診療枠の公開の通知を登録（患者のみ、登録済みの場合はその登録を返す）
+func (s *SlotSubscriptionService) Subscribe(patientID uint, req SubscribeSlotsRequest) (*models.SlotSubscription, error) {
+	patient, err := s.userRepo.FindByID(patientID)
+	if err != nil || patient == nil || patient.Role != "patient" {
+		return nil, errors.New("only patients can subscribe to slot openings")
+	}
+	doctor, err := s.userRepo.FindByID(req.DoctorID)
+	if err != nil || doctor == nil || doctor.Role != "doctor" || doctor.DeactivatedAt != nil {
+		return nil, errors.New("doctor not found")
+	}
+	// デモアカウントと実アカウントの間では登録できない
+	if patient.IsDemo != doctor.IsDemo {
+		return nil, errors.New("doctor not found")
+	}
+
+	existing, err := s.subscriptionRepo.FindByPatientAndDoctor(patientID, req.DoctorID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	token, err := generateContactToken()
+	if err != nil {
+		return nil, err
+	}
+	subscription := &models.SlotSubscription{
+		PatientID:        patientID,
+		DoctorID:         req.DoctorID,
+		UnsubscribeToken: token,
+	}
+	if err := s.subscriptionRepo.Create(subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// GetSubscriptions 患者の登録一覧
+func (s *SlotSubscriptionService) GetSubscriptions(patientID uint) ([]models.SlotSubscription, error) {
+	return s.subscriptionRepo.FindByPatientID(patientID)
+}
+
+// Unsubscribe 患者本人による登録の解除
+func (s *SlotSubscriptionService) Unsubscribe(subscriptionID, patientID uint) error {
+	deleted, err := s.subscriptionRepo.DeleteForPatient(subscriptionID, patientID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("subscription not found")
+	}
+	return nil
+}
+
+// UnsubscribeByToken 通知に記載した登録解除リンクによる解除（ログイン不要）
+func (s *SlotSubscriptionService) UnsubscribeByToken(token string) error {
+	subscription, err := s.subscriptionRepo.DeleteByToken(strings.TrimSpace(token))
+	if err != nil {
+		return err
+	}
+	if subscription == nil {
+		return errors.New("invalid or already used unsubscribe link")
+	}
+	return nil
+}
+
+// RunNotificationJob 新しく公開された空き枠を登録した患者へ通知する
+// 1回の実行で通知する登録数を上限までとし（残りは次回以降）、同じ登録へは最短間隔をあけて通知する
+func (s *SlotSubscriptionService) RunNotificationJob() error {
+	now := time.Now()
+	settledBefore := now.Add(-slotOpeningSettle)
+	notifiedBefore := now.Add(-s.cooldown)
+
+	due, err := s.subscriptionRepo.FindDue(now, settledBefore, notifiedBefore, s.batchSize)
+	if err != nil {
+		return err
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(due))
+	for i, d := range due {
+		ids[i] = d.SubscriptionID
+	}
+	subscriptions, err := s.subscriptionRepo.FindByIDs(ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uint]*models.SlotSubscription, len(subscriptions))
+	for i := range subscriptions {
+		byID[subscriptions[i].ID] = &subscriptions[i]
+	}
+
+	for _, d := range due {
+		subscription := byID[d.SubscriptionID]
+		if subscription == nil {
+			continue
+		}
+		marked, err := s.subscriptionRepo.MarkNotified(subscription.ID, notifiedBefore, settledBefore)
+		if err != nil {
+			log.Printf("Warning: Failed to mark slot subscription %d as notified: %v", subscription.ID, err)
+			continue
+		}
+		if !marked {
+			continue
+		}
+		s.notifyOpening(subscription, d)
+	}
+	return nil
+}
+
+// notifyOpening 患者へのアプリ内通知とメール（登録解除リンク付き）
+func (s *SlotSubscriptionService) notifyOpening(subscription *models.SlotSubscription, due repositories.SlotOpeningDue) {
+	patient, err := s.userRepo.FindByID(subscription.PatientID)
+	if err != nil || patient == nil || patient.DeactivatedAt != nil {
+		return
+	}
+
+	doctorName := "医師"
+	if profile := subscription.Doctor.DoctorProfile; profile != nil && profile.Name != "" {
+		doctorName = profile.Name + " 医師"
+	}
+	unsubscribeURL := fmt.Sprintf("%s/slot-subscriptions/unsubscribe?token=%s", s.appBaseURL, subscription.UnsubscribeToken)
+	bookingURL := fmt.Sprintf("%s/doctors/%d", s.appBaseURL, subscription.DoctorID)
+	title := fmt.Sprintf("%sの予約枠が公開されました", doctorName)
+	firstStart := due.FirstStart
+	if location, err := s.bookingPolicyService.Location(); err == nil {
+		firstStart = firstStart.In(location)
+	}
+	body := fmt.Sprintf("新しい予約枠が%d件公開されました（最も早い枠: %s）", due.NewSlots, firstStart.Format("2006年1月2日 15:04"))
+
+	if _, err := s.notificationService.Notify(patient.ID, NotificationMessage{
+		Type:  "slot_opening",
+		Title: title,
+		Body:  body,
+		Data: map[string]interface{}{
+			"doctor_id":       subscription.DoctorID,
+			"subscription_id": subscription.ID,
+			"new_slots":       due.NewSlots,
+			"first_start":     due.FirstStart,
+			"unsubscribe_url": unsubscribeURL,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify patient %d of slot opening: %v", patient.ID, err)
+	}
+
+	// デモアカウントには実際のメールを送らない
+	if patient.IsDemo {
+		return
+	}
+	email := fmt.Sprintf("%s\n\n予約は次のページからお申し込みください。\n%s\n\nこの医師の予約枠の通知が不要な場合は、次のリンクから登録を解除できます。\n%s\n", body, bookingURL, unsubscribeURL)
+	if err := s.sender.SendEmail(patient.Email, "【予約枠のお知らせ】"+title, email); err != nil {
+		log.Printf("Warning: Failed to email patient %d of slot opening: %v", patient.ID, err)
+	}
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+	"online_medical_consultation_app/backend/internal/storage"
+)
+
+// 重複を除いて保存するファイルの保存先の名前
+const (
+	StoredObjectsChatAttachments  = "chat_attachments"
+	StoredObjectsPatientDocuments = "patient_documents"
+)
+
+// StoredObjectService 内容の重複を除いたファイルの保存（同じ内容のファイルは保存済みのファイルへの参照を追加する）
+// 何度も送り直される書類の保存容量を抑えるため、SHA-256が一致するファイルは保存先ごとに1つだけ保存する
+type StoredObjectService struct {
+	objectRepo repositories.StoredObjectRepository
+	name       string
+	store      storage.Store
+}
+
+func NewStoredObjectService(objectRepo repositories.StoredObjectRepository, name string, store storage.Store) *StoredObjectService {
+	return &StoredObjectService{
+		objectRepo: objectRepo,
+		name:       name,
+		store:      store,
+	}
+}
+
+// Put ファイルの保存（同じ内容のファイルを保存済みの場合はそのファイルを参照する）
+// 内容のハッシュを求めてから保存するため、body は先頭に戻せる必要がある
+func (s *StoredObjectService) Put(ctx context.Context, body io.ReadSeeker, size int64, contentType, ext string) (*models.StoredObject, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, body); err != nil {
+		return nil, fmt.Errorf("failed to hash file: %v", err)
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+
+	existing, err := s.objectRepo.AddReference(s.name, checksum)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	// キーは内容ごとに一意にする（参照がなくなって削除したファイルと同じキーを再利用しない）
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("objects/%s/%s_%s%s", checksum[:2], checksum, hex.EncodeToString(suffix), ext)
+
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(ctx, key, body, size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store file: %v", err)
+	}
+
+	object := &models.StoredObject{
+		Storage:   s.name,
+		Checksum:  checksum,
+		ObjectKey: key,
+		SizeBytes: size,
+		RefCount:  1,
+	}
+	created, err := s.objectRepo.Create(object)
+	if err == nil && !created {
+		// 同じ内容のファイルが同時に保存された場合は先に記録されたファイルを参照する
+		object, err = s.objectRepo.AddReference(s.name, checksum)
+		if err == nil && object == nil {
+			err = fmt.Errorf("stored object %s disappeared", checksum)
+		}
+	}
+	if err != nil || !created {
+		s.deleteFile(ctx, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// Release ファイルへの参照の解除（参照がなくなった場合はファイルを削除する）
+// 重複を除く前に保存したファイルは参照元が1つのみのため、そのまま削除する
+func (s *StoredObjectService) Release(ctx context.Context, key string) error {
+	unreferenced, err := s.objectRepo.ReleaseReference(s.name, key)
+	if err != nil {
+		return err
+	}
+	if !unreferenced {
+		return nil
+	}
+	return s.store.Delete(ctx, key)
+}
+
+func (s *StoredObjectService) deleteFile(ctx context.Context, key string) {
+	if err := s.store.Delete(ctx, key); err != nil {
+		log.Printf("Warning: Failed to remove unrecorded file %s: %v", key, err)
+	}
+}
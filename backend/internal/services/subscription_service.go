@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/payments"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// SubscriptionService 患者の有料プラン（予約ごとのチャット・ビデオの利用上限の緩和）の購入
+// 支払いごとに利用期間を延長し、自動では更新しない
+type SubscriptionService struct {
+	subscriptionRepo    repositories.SubscriptionRepository
+	userRepo            repositories.UserRepository
+	usageQuotaService   *UsageQuotaService
+	notificationService *NotificationService
+	auditService        *AuditService
+	gateway             payments.Gateway // nilの場合は有料プランを提供しない
+	currency            string
+	price               int64 // 0の場合は有料プランを提供しない
+	period              time.Duration
+}
+
+// SubscriptionStatus 患者の現在のプランと利用上限
+type SubscriptionStatus struct {
+	Plan             string                      `json:"plan"`
+	ActiveUntil      *time.Time                  `json:"active_until,omitempty"`
+	Limits           UsageLimits                 `json:"limits"`
+	UpgradeAvailable bool                        `json:"upgrade_available"`
+	Plans            map[string]UsageLimits      `json:"plans"`
+	Price            int64                       `json:"price,omitempty"`
+	Currency         string                      `json:"currency,omitempty"`
+	PeriodDays       int                         `json:"period_days,omitempty"`
+	Subscription     *models.PatientSubscription `json:"subscription,omitempty"`
+}
+
+// SubscriptionCheckout 有料プランの支払いの開始（返したclient_secretでフロントエンドから支払う）
+type SubscriptionCheckout struct {
+	Payment        *models.SubscriptionPayment `json:"payment"`
+	Provider       string                      `json:"provider"`
+	ClientSecret   string                      `json:"client_secret"`
+	PublishableKey string                      `json:"publishable_key"`
+}
+
+func NewSubscriptionService(subscriptionRepo repositories.SubscriptionRepository, userRepo repositories.UserRepository, usageQuotaService *UsageQuotaService, notificationService *NotificationService, auditService *AuditService, gateway payments.Gateway, currency string, price int64, period time.Duration) *SubscriptionService {
+	return &SubscriptionService{
+		subscriptionRepo:    subscriptionRepo,
+		userRepo:            userRepo,
+		usageQuotaService:   usageQuotaService,
+		notificationService: notificationService,
+		auditService:        auditService,
+		gateway:             gateway,
+		currency:            strings.ToLower(currency),
+		price:               price,
+		period:              period,
+	}
+}
+
+// Available 有料プランを購入できるかどうか（料金と決済代行サービスを設定している場合のみ）
+func (s *SubscriptionService) Available() bool {
+	return s.gateway != nil && s.price > 0 && s.period > 0
+}
+
+// GetMySubscription 自分の現在のプランと利用上限（患者用）
+func (s *SubscriptionService) GetMySubscription(patientID uint) (*SubscriptionStatus, error) {
+	subscription, err := s.subscriptionRepo.FindByPatient(patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &SubscriptionStatus{
+		Plan: models.PlanFree,
+		Plans: map[string]UsageLimits{
+			models.PlanFree: s.usageQuotaService.PlanLimits(models.PlanFree),
+			models.PlanPlus: s.usageQuotaService.PlanLimits(models.PlanPlus),
+		},
+		Subscription: subscription,
+	}
+	if subscription != nil && subscription.IsActive(time.Now()) {
+		status.Plan = subscription.Plan
+		status.ActiveUntil = &subscription.ActiveUntil
+	}
+	status.Limits = s.usageQuotaService.PlanLimits(status.Plan)
+
+	// 契約中も期間を延長できる
+	if s.Available() && !s.isDemoPatient(patientID) {
+		status.UpgradeAvailable = true
+		status.Price = s.price
+		status.Currency = s.currency
+		status.PeriodDays = int(s.period / (24 * time.Hour))
+	}
+	return status, nil
+}
+
+// Subscribe 有料プランの支払いの開始（支払い待ちの PaymentIntent があれば再利用する、期限内の場合は期限から延長する）
+func (s *SubscriptionService) Subscribe(patientID uint) (*SubscriptionCheckout, error) {
+	if !s.Available() || s.isDemoPatient(patientID) {
+		return nil, errors.New("subscriptions are not available")
+	}
+
+	payment, err := s.subscriptionRepo.FindOpenPayment(patientID)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		if payment, err = s.createPayment(patientID); err != nil {
+			log.Printf("Warning: Failed to create subscription payment intent for patient %d: %v", patientID, err)
+			return nil, errors.New("failed to start payment")
+		}
+	}
+
+	s.auditService.LogUserAction(patientID, "subscription_payment_started", "subscription_payment", fmt.Sprintf("%d", payment.ID), map[string]interface{}{
+		"plan":   payment.Plan,
+		"amount": payment.Amount,
+	})
+
+	return &SubscriptionCheckout{
+		Payment:        payment,
+		Provider:       s.gateway.Name(),
+		ClientSecret:   payment.ClientSecret,
+		PublishableKey: s.gateway.PublishableKey(),
+	}, nil
+}
+
+// HandlePaymentEvent 有料プランの支払いの結果の反映（診療費の支払いでない PaymentIntent のWebhookで呼び出す）
+// 有料プランの支払いでない場合はfalse
+func (s *SubscriptionService) HandlePaymentEvent(event *payments.Event) (bool, error) {
+	payment, err := s.subscriptionRepo.FindPaymentByIntentID(event.IntentID)
+	if err != nil {
+		return false, err
+	}
+	if payment == nil {
+		return false, nil
+	}
+
+	updated, err := s.subscriptionRepo.UpdatePaymentStatus(payment.ID, event.Status, event.FailureMessage)
+	if err != nil {
+		return true, err
+	}
+
+	switch event.Status {
+	case payments.StatusSucceeded:
+		// 金額・通貨が作成した PaymentIntent と一致しない支払いでは延長せず、管理者が確認する
+		if event.Amount != payment.Amount || event.Currency != payment.Currency {
+			if updated {
+				log.Printf("Warning: Subscription payment %d held for review: paid %d %s, expected %d %s", payment.ID, event.Amount, event.Currency, payment.Amount, payment.Currency)
+				s.auditService.LogSystemAction("subscription_payment_held_for_review", "subscription_payment", fmt.Sprintf("%d", payment.ID), map[string]interface{}{
+					"patient_id": payment.PatientID,
+					"amount":     event.Amount,
+					"currency":   event.Currency,
+				})
+			}
+			return true, nil
+		}
+		// 延長に失敗した後の再送でも反映されるよう、支払いが更新済みでも延長を確認する
+		return true, s.activate(payment)
+	case payments.StatusFailed:
+		if updated {
+			if _, err := s.notificationService.Notify(payment.PatientID, NotificationMessage{
+				Type:     "subscription_payment_failed",
+				Title:    "有料プランのお支払いができませんでした",
+				Body:     event.FailureMessage,
+				Priority: "high",
+				Data:     map[string]interface{}{"subscription_payment_id": payment.ID},
+			}); err != nil {
+				log.Printf("Warning: Failed to notify patient %d of failed subscription payment %d: %v", payment.PatientID, payment.ID, err)
+			}
+		}
+	}
+	return true, nil
+}
+
+// activate 支払いの完了による有料プランの利用期間の延長と患者への通知（延長は支払いごとに1回のみ）
+func (s *SubscriptionService) activate(payment *models.SubscriptionPayment) error {
+	subscription, extended, err := s.subscriptionRepo.ExtendForPayment(payment, s.period, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to extend subscription for payment %d: %w", payment.ID, err)
+	}
+	if !extended || subscription == nil {
+		return nil
+	}
+
+	if _, err := s.notificationService.Notify(payment.PatientID, NotificationMessage{
+		Type:  "subscription_activated",
+		Title: "有料プランをご利用いただけます",
+		Body:  fmt.Sprintf("%s まで診療後のチャット・ビデオ診療の上限が緩和されます", subscription.ActiveUntil.Format("2006/01/02")),
+		Data: map[string]interface{}{
+			"plan":         subscription.Plan,
+			"active_until": subscription.ActiveUntil,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify patient %d of activated subscription: %v", payment.PatientID, err)
+	}
+
+	s.auditService.LogSystemAction("subscription_activated", "patient_subscription", fmt.Sprintf("%d", subscription.ID), map[string]interface{}{
+		"patient_id":   payment.PatientID,
+		"payment_id":   payment.ID,
+		"active_until": subscription.ActiveUntil,
+	})
+	return nil
+}
+
+// createPayment 有料プランの PaymentIntent の作成
+func (s *SubscriptionService) createPayment(patientID uint) (*models.SubscriptionPayment, error) {
+	count, err := s.subscriptionRepo.CountPayments(patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	intent, err := s.gateway.CreateIntent(context.Background(), payments.IntentParams{
+		Amount:      s.price,
+		Currency:    s.currency,
+		Description: "有料プラン（plus）",
+		Metadata: map[string]string{
+			"patient_id": fmt.Sprintf("%d", patientID),
+			"plan":       models.PlanPlus,
+		},
+		IdempotencyKey: fmt.Sprintf("subscription-%d-%d", patientID, count+1),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	payment := &models.SubscriptionPayment{
+		PatientID:        patientID,
+		Plan:             models.PlanPlus,
+		Provider:         s.gateway.Name(),
+		ProviderIntentID: intent.ID,
+		ClientSecret:     intent.ClientSecret,
+		Amount:           intent.Amount,
+		Currency:         intent.Currency,
+		Status:           intent.Status,
+	}
+	if err := s.subscriptionRepo.CreatePayment(payment); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (s *SubscriptionService) isDemoPatient(patientID uint) bool {
+	patient, err := s.userRepo.FindByID(patientID)
+	return err == nil && patient != nil && patient.IsDemo
+}
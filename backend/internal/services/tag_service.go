@@ -0,0 +1,345 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// タグ名の最大文字数と、一度に付与・絞り込みできるタグ数、医師ごとに保存できる絞り込み条件の上限
+const (
+	tagNameMaxLength    = 30
+	tagsPerFilterLimit  = 20
+	savedViewsPerDoctor = 20
+)
+
+var tagColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// 予約一覧で絞り込めるステータス
+var appointmentStatuses = map[string]bool{
+	"pending":   true,
+	"confirmed": true,
+	"cancelled": true,
+	"completed": true,
+}
+
+type TagService struct {
+	tagRepo         repositories.TagRepository
+	appointmentRepo repositories.AppointmentRepository
+}
+
+// TagRequest タグの作成・更新
+type TagRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Color string `json:"color"`
+}
+
+// SetAppointmentTagsRequest 予約に付けるタグ（空の場合はすべて外す）
+type SetAppointmentTagsRequest struct {
+	TagIDs []uint `json:"tag_ids"`
+}
+
+// SavedViewRequest 予約一覧の絞り込み条件の保存
+type SavedViewRequest struct {
+	Name     string `json:"name" binding:"required"`
+	TagIDs   []uint `json:"tag_ids"`
+	MatchAll bool   `json:"match_all"`
+	Status   string `json:"status"`
+}
+
+// DoctorAppointmentQuery 医師の予約一覧の絞り込み（ViewID を指定した場合は保存した条件を使う）
+type DoctorAppointmentQuery struct {
+	ViewID   *uint
+	Status   string
+	TagIDs   []uint
+	MatchAll bool
+}
+
+func NewTagService(tagRepo repositories.TagRepository, appointmentRepo repositories.AppointmentRepository) *TagService {
+	return &TagService{
+		tagRepo:         tagRepo,
+		appointmentRepo: appointmentRepo,
+	}
+}
+
+// GetTags 医師のタグの一覧
+func (s *TagService) GetTags(doctorID uint) ([]models.Tag, error) {
+	return s.tagRepo.FindByDoctor(doctorID)
+}
+
+// CreateTag タグの作成（同じ名前のタグは作成しない）
+func (s *TagService) CreateTag(doctorID uint, req TagRequest) (*models.Tag, error) {
+	name, color, err := normalizeTag(req)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.tagRepo.FindByDoctorAndName(doctorID, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("tag already exists")
+	}
+
+	tag := &models.Tag{DoctorID: doctorID, Name: name, Color: color}
+	if err := s.tagRepo.Create(tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// UpdateTag タグの名前・表示色の変更
+func (s *TagService) UpdateTag(doctorID, tagID uint, req TagRequest) (*models.Tag, error) {
+	tag, err := s.findTag(doctorID, tagID)
+	if err != nil {
+		return nil, err
+	}
+
+	name, color, err := normalizeTag(req)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.tagRepo.FindByDoctorAndName(doctorID, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.ID != tag.ID {
+		return nil, errors.New("tag already exists")
+	}
+
+	tag.Name = name
+	tag.Color = color
+	if err := s.tagRepo.Update(tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// DeleteTag タグの削除（予約から外し、保存した絞り込み条件からは絞り込み時に除く）
+func (s *TagService) DeleteTag(doctorID, tagID uint) error {
+	if _, err := s.findTag(doctorID, tagID); err != nil {
+		return err
+	}
+	return s.tagRepo.Delete(tagID)
+}
+
+// SetAppointmentTags 予約に付けるタグの置き換え（担当医師のみ、自分のタグのみ付けられる）
+func (s *TagService) SetAppointmentTags(doctorID, appointmentID uint, req SetAppointmentTagsRequest) ([]models.Tag, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.DoctorID != doctorID {
+		return nil, errors.New("unauthorized to tag this appointment")
+	}
+
+	tagIDs, err := s.ownTagIDs(doctorID, req.TagIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tagRepo.SetAppointmentTags(doctorID, appointmentID, tagIDs); err != nil {
+		return nil, err
+	}
+
+	tags, err := s.tagRepo.FindByAppointments(doctorID, []uint{appointmentID})
+	if err != nil {
+		return nil, err
+	}
+	return tags[appointmentID], nil
+}
+
+// GetViews 医師の保存した絞り込み条件の一覧
+func (s *TagService) GetViews(doctorID uint) ([]models.AppointmentSavedView, error) {
+	return s.tagRepo.FindViewsByDoctor(doctorID)
+}
+
+// CreateView 予約一覧の絞り込み条件の保存
+func (s *TagService) CreateView(doctorID uint, req SavedViewRequest) (*models.AppointmentSavedView, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > tagNameMaxLength {
+		return nil, errors.New("invalid view name")
+	}
+	if req.Status != "" && !appointmentStatuses[req.Status] {
+		return nil, errors.New("invalid status")
+	}
+	tagIDs, err := s.ownTagIDs(doctorID, req.TagIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	views, err := s.tagRepo.FindViewsByDoctor(doctorID)
+	if err != nil {
+		return nil, err
+	}
+	if len(views) >= savedViewsPerDoctor {
+		return nil, errors.New("too many saved views")
+	}
+
+	ids := make([]string, len(tagIDs))
+	for i, id := range tagIDs {
+		ids[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	view := &models.AppointmentSavedView{
+		DoctorID: doctorID,
+		Name:     name,
+		TagIDs:   strings.Join(ids, ","),
+		MatchAll: req.MatchAll,
+		Status:   req.Status,
+	}
+	if err := s.tagRepo.CreateView(view); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// DeleteView 保存した絞り込み条件の削除
+func (s *TagService) DeleteView(doctorID, viewID uint) error {
+	view, err := s.tagRepo.FindViewByID(viewID)
+	if err != nil {
+		return err
+	}
+	if view == nil || view.DoctorID != doctorID {
+		return errors.New("saved view not found")
+	}
+	return s.tagRepo.DeleteView(viewID)
+}
+
+// AppointmentFilter 医師の予約一覧の絞り込み条件の解決（保存した条件の削除済みのタグは除く）
+func (s *TagService) AppointmentFilter(doctorID uint, query DoctorAppointmentQuery) (repositories.DoctorAppointmentFilter, error) {
+	filter := repositories.DoctorAppointmentFilter{Status: query.Status, MatchAll: query.MatchAll}
+	requested := query.TagIDs
+
+	if query.ViewID != nil {
+		view, err := s.tagRepo.FindViewByID(*query.ViewID)
+		if err != nil {
+			return filter, err
+		}
+		if view == nil || view.DoctorID != doctorID {
+			return filter, errors.New("saved view not found")
+		}
+		filter = repositories.DoctorAppointmentFilter{Status: view.Status, MatchAll: view.MatchAll}
+		requested = nil
+		for _, value := range strings.Split(view.TagIDs, ",") {
+			if id, err := strconv.ParseUint(value, 10, 32); err == nil {
+				requested = append(requested, uint(id))
+			}
+		}
+		if len(requested) > 0 {
+			tags, err := s.tagRepo.FindByDoctor(doctorID)
+			if err != nil {
+				return filter, err
+			}
+			requested = existingTagIDs(requested, tags)
+			if len(requested) == 0 {
+				// 条件のタグがすべて削除された場合は該当なしとする
+				requested = []uint{0}
+			}
+		}
+		filter.TagIDs = requested
+		return filter, nil
+	}
+
+	if filter.Status != "" && !appointmentStatuses[filter.Status] {
+		return filter, errors.New("invalid status")
+	}
+	tagIDs, err := s.ownTagIDs(doctorID, requested)
+	if err != nil {
+		return filter, err
+	}
+	filter.TagIDs = tagIDs
+	return filter, nil
+}
+
+// AttachTags 予約一覧への医師のタグの読み込み
+func (s *TagService) AttachTags(doctorID uint, appointments []models.Appointment) error {
+	ids := make([]uint, len(appointments))
+	for i := range appointments {
+		ids[i] = appointments[i].ID
+	}
+	tags, err := s.tagRepo.FindByAppointments(doctorID, ids)
+	if err != nil {
+		return err
+	}
+	for i := range appointments {
+		appointments[i].Tags = tags[appointments[i].ID]
+	}
+	return nil
+}
+
+// findTag 医師のタグの取得（他の医師のタグは存在しないものとして扱う）
+func (s *TagService) findTag(doctorID, tagID uint) (*models.Tag, error) {
+	tag, err := s.tagRepo.FindByID(tagID)
+	if err != nil {
+		return nil, err
+	}
+	if tag == nil || tag.DoctorID != doctorID {
+		return nil, errors.New("tag not found")
+	}
+	return tag, nil
+}
+
+// ownTagIDs 指定したタグが医師のタグであることの確認（重複を除く）
+func (s *TagService) ownTagIDs(doctorID uint, tagIDs []uint) ([]uint, error) {
+	if len(tagIDs) == 0 {
+		return nil, nil
+	}
+	tags, err := s.tagRepo.FindByDoctor(doctorID)
+	if err != nil {
+		return nil, err
+	}
+	own := existingTagIDs(tagIDs, tags)
+	if len(own) != len(uniqueTagIDs(tagIDs)) {
+		return nil, errors.New("invalid tag")
+	}
+	if len(own) > tagsPerFilterLimit {
+		return nil, errors.New("too many tags")
+	}
+	return own, nil
+}
+
+// existingTagIDs 指定したタグのうち存在するもの（重複を除く）
+func existingTagIDs(tagIDs []uint, tags []models.Tag) []uint {
+	exists := make(map[uint]bool, len(tags))
+	for _, tag := range tags {
+		exists[tag.ID] = true
+	}
+	var result []uint
+	for _, id := range uniqueTagIDs(tagIDs) {
+		if exists[id] {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func uniqueTagIDs(tagIDs []uint) []uint {
+	seen := make(map[uint]bool, len(tagIDs))
+	var result []uint
+	for _, id := range tagIDs {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// normalizeTag タグ名・表示色の確認
+func normalizeTag(req TagRequest) (string, string, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > tagNameMaxLength {
+		return "", "", errors.New("invalid tag name")
+	}
+	color := strings.TrimSpace(req.Color)
+	if color != "" && !tagColorPattern.MatchString(color) {
+		return "", "", errors.New("invalid tag color")
+	}
+	return name, color, nil
+}
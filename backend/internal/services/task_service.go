@@ -0,0 +1,286 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 期限のみ指定されたタスクのリマインド時刻（期限の何時間前か）
+const taskDefaultReminderLead = 24 * time.Hour
+
+// 1回のジョブで送信するリマインドの上限
+const taskReminderBatchSize = 200
+
+type TaskService struct {
+	taskRepo            repositories.TaskRepository
+	appointmentRepo     repositories.AppointmentRepository
+	notificationService *NotificationService
+	auditService        *AuditService
+}
+
+type CreateTaskRequest struct {
+	Title       string     `json:"title" binding:"required"`
+	Description string     `json:"description"`
+	Assignee    string     `json:"assignee" binding:"required,oneof=patient doctor"`
+	DueAt       *time.Time `json:"due_at"`
+	RemindAt    *time.Time `json:"remind_at"`
+}
+
+type UpdateTaskRequest struct {
+	Title       *string    `json:"title"`
+	Description *string    `json:"description"`
+	Assignee    *string    `json:"assignee" binding:"omitempty,oneof=patient doctor"`
+	DueAt       *time.Time `json:"due_at"`
+	RemindAt    *time.Time `json:"remind_at"`
+}
+
+func NewTaskService(taskRepo repositories.TaskRepository, appointmentRepo repositories.AppointmentRepository, notificationService *NotificationService, auditService *AuditService) *TaskService {
+	return &TaskService{
+		taskRepo:            taskRepo,
+		appointmentRepo:     appointmentRepo,
+		notificationService: notificationService,
+		auditService:        auditService,
+	}
+}
+
+// CreateTask タスクの作成（予約の患者または医師）
+func (s *TaskService) CreateTask(appointmentID, userID uint, req CreateTaskRequest) (*models.AppointmentTask, error) {
+	appointment, err := s.getAppointmentForMember(appointmentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if appointment.Status == "cancelled" {
+		return nil, errors.New("appointment is cancelled")
+	}
+
+	task := &models.AppointmentTask{
+		AppointmentID: appointmentID,
+		CreatedByID:   userID,
+		AssigneeID:    assigneeID(appointment, req.Assignee),
+		Title:         req.Title,
+		Description:   req.Description,
+		Status:        "open",
+		DueAt:         req.DueAt,
+		RemindAt:      reminderTime(req.DueAt, req.RemindAt),
+	}
+	if err := s.taskRepo.Create(task); err != nil {
+		return nil, err
+	}
+
+	// 他の参加者に割り当てた場合は通知
+	if task.AssigneeID != userID {
+		s.notifyAssignee(task, "task_assigned", "新しいタスクが割り当てられました")
+	}
+
+	s.auditService.LogUserAction(userID, "task_created", "appointment_task", fmt.Sprintf("%d", task.ID), map[string]interface{}{
+		"appointment_id": appointmentID,
+		"assignee_id":    task.AssigneeID,
+	})
+
+	return task, nil
+}
+
+// GetTasks 予約のタスク一覧の取得
+func (s *TaskService) GetTasks(appointmentID, userID uint) ([]models.AppointmentTask, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+
+	// 権限確認（患者・医師・通訳者のみ）
+	if !appointment.IsParticipant(userID) {
+		return nil, errors.New("unauthorized to view tasks for this appointment")
+	}
+
+	return s.taskRepo.FindByAppointmentID(appointmentID)
+}
+
+// GetMyOpenTasks 自分に割り当てられた未完了タスクの取得
+func (s *TaskService) GetMyOpenTasks(userID uint, limit int) ([]models.AppointmentTask, error) {
+	return s.taskRepo.FindOpenByAssigneeID(userID, limit)
+}
+
+// UpdateTask タスクの更新（予約の患者または医師）
+func (s *TaskService) UpdateTask(appointmentID, taskID, userID uint, req UpdateTaskRequest) (*models.AppointmentTask, error) {
+	task, appointment, err := s.getTask(appointmentID, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	previousAssignee := task.AssigneeID
+	if req.Title != nil {
+		task.Title = *req.Title
+	}
+	if req.Description != nil {
+		task.Description = *req.Description
+	}
+	if req.Assignee != nil {
+		task.AssigneeID = assigneeID(appointment, *req.Assignee)
+	}
+	if req.DueAt != nil || req.RemindAt != nil {
+		if req.DueAt != nil {
+			task.DueAt = req.DueAt
+		}
+		task.RemindAt = reminderTime(task.DueAt, req.RemindAt)
+		task.RemindedAt = nil
+	}
+
+	if err := s.taskRepo.Update(task); err != nil {
+		return nil, err
+	}
+
+	if task.AssigneeID != previousAssignee && task.AssigneeID != userID {
+		s.notifyAssignee(task, "task_assigned", "新しいタスクが割り当てられました")
+	}
+
+	return task, nil
+}
+
+// CompleteTask タスクの完了
+func (s *TaskService) CompleteTask(appointmentID, taskID, userID uint) (*models.AppointmentTask, error) {
+	return s.setTaskStatus(appointmentID, taskID, userID, "done")
+}
+
+// ReopenTask 完了したタスクを未完了に戻す
+func (s *TaskService) ReopenTask(appointmentID, taskID, userID uint) (*models.AppointmentTask, error) {
+	return s.setTaskStatus(appointmentID, taskID, userID, "open")
+}
+
+// DeleteTask タスクの削除（作成者または医師）
+func (s *TaskService) DeleteTask(appointmentID, taskID, userID uint) error {
+	task, appointment, err := s.getTask(appointmentID, taskID, userID)
+	if err != nil {
+		return err
+	}
+
+	if task.CreatedByID != userID && appointment.DoctorID != userID {
+		return errors.New("unauthorized to delete this task")
+	}
+
+	if err := s.taskRepo.Delete(task.ID); err != nil {
+		return err
+	}
+
+	s.auditService.LogUserAction(userID, "task_deleted", "appointment_task", fmt.Sprintf("%d", task.ID), map[string]interface{}{
+		"appointment_id": appointmentID,
+	})
+	return nil
+}
+
+// RunReminderJob 定期ジョブ：リマインド時刻を過ぎたタスクを担当者へ通知
+func (s *TaskService) RunReminderJob() error {
+	now := time.Now()
+	tasks, err := s.taskRepo.FindDueReminders(now, taskReminderBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range tasks {
+		claimed, err := s.taskRepo.MarkReminded(tasks[i].ID, now)
+		if err != nil {
+			log.Printf("Warning: Failed to mark task %d as reminded: %v", tasks[i].ID, err)
+			continue
+		}
+		if claimed {
+			s.notifyAssignee(&tasks[i], "task_reminder", "タスクの期限が近づいています")
+		}
+	}
+	return nil
+}
+
+func (s *TaskService) setTaskStatus(appointmentID, taskID, userID uint, status string) (*models.AppointmentTask, error) {
+	task, appointment, err := s.getTask(appointmentID, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 担当者本人または医師のみ
+	if task.AssigneeID != userID && appointment.DoctorID != userID {
+		return nil, errors.New("unauthorized to update this task")
+	}
+	if task.Status == status {
+		return task, nil
+	}
+
+	task.Status = status
+	if status == "done" {
+		now := time.Now()
+		task.CompletedAt = &now
+	} else {
+		task.CompletedAt = nil
+	}
+	if err := s.taskRepo.Update(task); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(userID, "task_"+status, "appointment_task", fmt.Sprintf("%d", task.ID), map[string]interface{}{
+		"appointment_id": appointmentID,
+	})
+
+	return task, nil
+}
+
+// getAppointmentForMember 予約の患者または医師であることを確認する
+func (s *TaskService) getAppointmentForMember(appointmentID, userID uint) (*models.Appointment, error) {
+	appointment, err := s.appointmentRepo.FindByID(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+
+	if appointment.PatientID != userID && appointment.DoctorID != userID {
+		return nil, errors.New("unauthorized to manage tasks for this appointment")
+	}
+	return appointment, nil
+}
+
+func (s *TaskService) getTask(appointmentID, taskID, userID uint) (*models.AppointmentTask, *models.Appointment, error) {
+	appointment, err := s.getAppointmentForMember(appointmentID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	task, err := s.taskRepo.FindByID(taskID)
+	if err != nil || task.AppointmentID != appointmentID {
+		return nil, nil, errors.New("task not found")
+	}
+	return task, appointment, nil
+}
+
+func (s *TaskService) notifyAssignee(task *models.AppointmentTask, notificationType, title string) {
+	if _, err := s.notificationService.Notify(task.AssigneeID, NotificationMessage{
+		Type:  notificationType,
+		Title: title,
+		Body:  task.Title,
+		Data: map[string]interface{}{
+			"task_id":        task.ID,
+			"appointment_id": task.AppointmentID,
+			"due_at":         task.DueAt,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify user %d of task %d: %v", task.AssigneeID, task.ID, err)
+	}
+}
+
+func assigneeID(appointment *models.Appointment, assignee string) uint {
+	if assignee == "doctor" {
+		return appointment.DoctorID
+	}
+	return appointment.PatientID
+}
+
+// reminderTime リマインド時刻の決定（指定がなければ期限の24時間前）
+func reminderTime(dueAt, remindAt *time.Time) *time.Time {
+	if remindAt != nil {
+		return remindAt
+	}
+	if dueAt == nil {
+		return nil
+	}
+	t := dueAt.Add(-taskDefaultReminderLead)
+	return &t
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+	"online_medical_consultation_app/backend/internal/transcription"
+)
+
+// 録音ファイルの制限
+const recordingMaxFileSize = 100 * 1024 * 1024
+
+var recordingContentTypes = map[string]bool{
+	"audio/webm":  true,
+	"audio/ogg":   true,
+	"audio/mpeg":  true,
+	"audio/mp4":   true,
+	"audio/wav":   true,
+	"audio/x-wav": true,
+	"video/webm":  true,
+}
+
+const (
+	transcriptionMaxAttempts = 3
+	transcriptionBatchSize   = 5
+	// 処理中のまま更新がない文字起こしは中断されたものとして再処理する
+	transcriptionStaleAfter = 30 * time.Minute
+)
+
+type TranscriptionService struct {
+	transcriptRepo      repositories.TranscriptRepository
+	videoSessionRepo    repositories.VideoSessionRepository
+	appointmentRepo     repositories.AppointmentRepository
+	notificationService *NotificationService
+	auditService        *AuditService
+	brandingService     *BrandingService
+	provider            transcription.Provider
+	storageDir          string
+}
+
+// TranscriptExport 文字起こしのダウンロード
+type TranscriptExport struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+func NewTranscriptionService(transcriptRepo repositories.TranscriptRepository, videoSessionRepo repositories.VideoSessionRepository, appointmentRepo repositories.AppointmentRepository, notificationService *NotificationService, auditService *AuditService, brandingService *BrandingService, provider transcription.Provider, uploadDir string) *TranscriptionService {
+	// 録音は公開アップロードとは別のディレクトリに保存する
+	storageDir := filepath.Join(uploadDir, "recordings")
+	if err := os.MkdirAll(storageDir, 0700); err != nil {
+		log.Printf("Warning: Failed to create recording directory: %v", err)
+	}
+
+	return &TranscriptionService{
+		transcriptRepo:      transcriptRepo,
+		videoSessionRepo:    videoSessionRepo,
+		appointmentRepo:     appointmentRepo,
+		notificationService: notificationService,
+		auditService:        auditService,
+		brandingService:     brandingService,
+		provider:            provider,
+		storageDir:          storageDir,
+	}
+}
+
+// UploadRecording 診療の録音のアップロード（患者が同意したセッションのみ、文字起こし待ちで登録）
+// 失敗した文字起こしは録音を再アップロードすることでやり直せる
+func (s *TranscriptionService) UploadRecording(sessionID, userID uint, file *multipart.FileHeader) (*models.Transcript, error) {
+	if s.provider == nil {
+		return nil, errors.New("transcription is not enabled")
+	}
+
+	session, appointment, err := s.loadSession(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if session.RecordingConsent != "granted" {
+		return nil, errors.New("patient has not consented to recording")
+	}
+
+	if file.Size > recordingMaxFileSize {
+		return nil, errors.New("file size must be less than 100MB")
+	}
+	contentType, _, _ := mime.ParseMediaType(file.Header.Get("Content-Type"))
+	if !recordingContentTypes[contentType] {
+		return nil, errors.New("only WebM, Ogg, MP3, MP4 and WAV audio files are allowed")
+	}
+
+	existing, err := s.transcriptRepo.FindByVideoSessionID(sessionID)
+	if err == nil && existing != nil && existing.Status != "failed" {
+		return nil, errors.New("recording has already been uploaded for this session")
+	}
+
+	filename := fmt.Sprintf("%d_%d%s", sessionID, time.Now().UnixNano(), filepath.Ext(file.Filename))
+	path := filepath.Join(s.storageDir, filename)
+	if err := saveUploadedFile(file, path); err != nil {
+		return nil, err
+	}
+
+	language := appointment.ConsultationLanguage
+	if language == "" {
+		language = "ja"
+	}
+
+	transcript := existing
+	if transcript == nil {
+		transcript = &models.Transcript{
+			VideoSessionID: sessionID,
+			AppointmentID:  appointment.ID,
+		}
+	}
+	transcript.UploadedByID = userID
+	transcript.Status = "pending"
+	transcript.Language = language
+	transcript.AudioPath = path
+	transcript.Attempts = 0
+	transcript.LastError = ""
+
+	if transcript.ID == 0 {
+		err = s.transcriptRepo.Create(transcript)
+	} else {
+		err = s.transcriptRepo.Update(transcript)
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	s.auditService.LogUserAction(userID, "recording_uploaded", "transcript", fmt.Sprintf("%d", transcript.ID), map[string]interface{}{
+		"video_session_id": sessionID,
+		"appointment_id":   appointment.ID,
+	})
+
+	return transcript, nil
+}
+
+// GetTranscript セッションの文字起こしの取得（予約の参加者のみ、queryを指定した場合は一致する区間のみ）
+func (s *TranscriptionService) GetTranscript(sessionID, userID uint, query string) (*models.Transcript, error) {
+	_, appointment, err := s.loadSession(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	transcript, err := s.transcriptRepo.FindWithSegments(sessionID, strings.TrimSpace(query))
+	if err != nil || transcript == nil {
+		return nil, errors.New("transcript not found")
+	}
+
+	// PHI閲覧ログの記録
+	s.auditService.LogPHIAccess(userID, appointment.PatientID, "transcript", fmt.Sprintf("%d", transcript.ID), nil)
+
+	return transcript, nil
+}
+
+// SearchTranscripts 参加した診療の文字起こしの全文検索
+func (s *TranscriptionService) SearchTranscripts(userID uint, query string, limit, offset int) ([]repositories.TranscriptSearchHit, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("search query is required")
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.transcriptRepo.SearchSegments(userID, query, limit, offset)
+}
+
+// ExportTranscript 文字起こしのダウンロード（txt: タイムスタンプ付きテキスト / pdf）
+func (s *TranscriptionService) ExportTranscript(sessionID, userID uint, format string) (*TranscriptExport, error) {
+	if format != "txt" && format != "pdf" {
+		return nil, errors.New("format must be txt or pdf")
+	}
+
+	transcript, err := s.GetTranscript(sessionID, userID, "")
+	if err != nil {
+		return nil, err
+	}
+	if transcript.Status != "completed" {
+		return nil, errors.New("transcript is not ready")
+	}
+
+	title := fmt.Sprintf("ビデオ診療の文字起こし（予約 #%d）", transcript.AppointmentID)
+	lines := make([]string, 0, len(transcript.Segments))
+	for _, segment := range transcript.Segments {
+		line := fmt.Sprintf("[%s] %s", formatTranscriptTimestamp(segment.StartMs), segment.Text)
+		if segment.Speaker != "" {
+			line = fmt.Sprintf("[%s] %s: %s", formatTranscriptTimestamp(segment.StartMs), segment.Speaker, segment.Text)
+		}
+		lines = append(lines, line)
+	}
+
+	filename := fmt.Sprintf("transcript_%d", sessionID)
+	if format == "txt" {
+		body := title + "\n\n" + strings.Join(lines, "\n") + "\n"
+		return &TranscriptExport{Filename: filename + ".txt", ContentType: "text/plain; charset=utf-8", Data: []byte(body)}, nil
+	}
+
+	doc := s.brandingService.NewPDFDocument()
+	doc.Heading(title)
+	doc.Blank()
+	for _, line := range lines {
+		doc.Text(line)
+	}
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return &TranscriptExport{Filename: filename + ".pdf", ContentType: "application/pdf", Data: buf.Bytes()}, nil
+}
+
+// RunTranscriptionJob 文字起こし待ちの録音を処理する
+// 失敗した場合は上限回数まで次回以降に再試行し、完了後は録音を削除する
+func (s *TranscriptionService) RunTranscriptionJob() error {
+	if s.provider == nil {
+		return nil
+	}
+
+	transcripts, err := s.transcriptRepo.FindProcessable(time.Now().Add(-transcriptionStaleAfter), transcriptionBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range transcripts {
+		transcript := &transcripts[i]
+		claimed, err := s.transcriptRepo.Claim(transcript.ID, transcript.UpdatedAt)
+		if err != nil {
+			log.Printf("Warning: Failed to claim transcript %d: %v", transcript.ID, err)
+			continue
+		}
+		if !claimed {
+			// 他のインスタンスが処理中
+			continue
+		}
+		transcript.Attempts++
+		s.process(transcript)
+	}
+	return nil
+}
+
+// process 1件の文字起こし（同意が撤回された場合は録音を破棄する）
+func (s *TranscriptionService) process(transcript *models.Transcript) {
+	session, err := s.videoSessionRepo.FindByID(transcript.VideoSessionID)
+	if err != nil || session == nil || session.RecordingConsent != "granted" {
+		s.fail(transcript, "recording consent was withdrawn", true)
+		return
+	}
+
+	ctx := context.Background()
+	segments, err := s.provider.Transcribe(ctx, transcript.AudioPath, transcript.Language)
+	if err != nil {
+		log.Printf("Warning: Transcription of session %d failed (attempt %d): %v", transcript.VideoSessionID, transcript.Attempts, err)
+		s.fail(transcript, err.Error(), transcript.Attempts >= transcriptionMaxAttempts)
+		return
+	}
+
+	records := make([]models.TranscriptSegment, 0, len(segments))
+	for i, segment := range segments {
+		records = append(records, models.TranscriptSegment{
+			Seq:     i,
+			StartMs: segment.StartMs,
+			EndMs:   segment.EndMs,
+			Speaker: segment.Speaker,
+			Text:    segment.Text,
+		})
+	}
+	if err := s.transcriptRepo.Complete(transcript.ID, records, s.provider.Name(), time.Now()); err != nil {
+		log.Printf("Warning: Failed to save transcript %d: %v", transcript.ID, err)
+		return
+	}
+	removeRecording(transcript.AudioPath)
+
+	s.auditService.LogSystemAction("transcript_completed", "transcript", fmt.Sprintf("%d", transcript.ID), map[string]interface{}{
+		"video_session_id": transcript.VideoSessionID,
+		"segments":         len(records),
+	})
+	s.notifyDoctor(transcript, "transcript_ready", "ビデオ診療の文字起こしが完了しました")
+}
+
+// fail 失敗の記録（finalの場合は失敗として確定し、録音を破棄する）
+func (s *TranscriptionService) fail(transcript *models.Transcript, reason string, final bool) {
+	transcript.LastError = reason
+	transcript.Status = "pending"
+	if final {
+		transcript.Status = "failed"
+		removeRecording(transcript.AudioPath)
+		transcript.AudioPath = ""
+	}
+	if err := s.transcriptRepo.Update(transcript); err != nil {
+		log.Printf("Warning: Failed to update transcript %d: %v", transcript.ID, err)
+		return
+	}
+	if final {
+		s.notifyDoctor(transcript, "transcript_failed", "ビデオ診療の文字起こしに失敗しました")
+	}
+}
+
+func (s *TranscriptionService) notifyDoctor(transcript *models.Transcript, notificationType, title string) {
+	appointment, err := s.appointmentRepo.FindByID(transcript.AppointmentID)
+	if err != nil || appointment == nil {
+		return
+	}
+	if _, err := s.notificationService.Notify(appointment.DoctorID, NotificationMessage{
+		Type:  notificationType,
+		Title: title,
+		Data: map[string]interface{}{
+			"appointment_id":   appointment.ID,
+			"video_session_id": transcript.VideoSessionID,
+		},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify doctor of transcript %d: %v", transcript.ID, err)
+	}
+}
+
+// loadSession セッションと予約の取得（予約の参加者のみ）
+func (s *TranscriptionService) loadSession(sessionID, userID uint) (*models.VideoSession, *models.Appointment, error) {
+	session, err := s.videoSessionRepo.FindByID(sessionID)
+	if err != nil || session == nil {
+		return nil, nil, errors.New("video session not found")
+	}
+	appointment, err := s.appointmentRepo.FindByID(session.AppointmentID)
+	if err != nil || appointment == nil {
+		return nil, nil, errors.New("appointment not found")
+	}
+	if !appointment.IsParticipant(userID) {
+		return nil, nil, errors.New("unauthorized to access this video session")
+	}
+	return session, appointment, nil
+}
+
+func removeRecording(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: Failed to remove recording %s: %v", path, err)
+	}
+}
+
+// formatTranscriptTimestamp 録音の先頭からの経過時間（hh:mm:ss）
+func formatTranscriptTimestamp(ms int64) string {
+	seconds := ms / 1000
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+	"online_medical_consultation_app/backend/internal/triage"
+)
+
+type TriageService struct {
+	triageRepo    repositories.TriageRepository
+	userRepo      repositories.UserRepository
+	dependentRepo repositories.DependentRepository
+	auditService  *AuditService
+}
+
+type SubmitTriageRequest struct {
+	DependentID *uint                  `json:"dependent_id"`
+	Answers     map[string]interface{} `json:"answers" binding:"required"`
+}
+
+func NewTriageService(triageRepo repositories.TriageRepository, userRepo repositories.UserRepository, dependentRepo repositories.DependentRepository, auditService *AuditService) *TriageService {
+	return &TriageService{
+		triageRepo:    triageRepo,
+		userRepo:      userRepo,
+		dependentRepo: dependentRepo,
+		auditService:  auditService,
+	}
+}
+
+// GetQuestionnaire 問診票の取得
+func (s *TriageService) GetQuestionnaire() []triage.Question {
+	return triage.Questionnaire
+}
+
+// SubmitTriage 問診の回答を判定して保存する（患者用）
+func (s *TriageService) SubmitTriage(patientID uint, req SubmitTriageRequest) (*models.TriageAssessment, error) {
+	user, err := s.userRepo.FindByID(patientID)
+	if err != nil || user == nil || user.Role != "patient" {
+		return nil, errors.New("patient not found")
+	}
+
+	// 家族の代理回答の場合は保護者であることを確認
+	if req.DependentID != nil {
+		dependent, err := s.dependentRepo.FindByID(*req.DependentID)
+		if err != nil || dependent == nil || dependent.GuardianID != patientID {
+			return nil, errors.New("dependent not found")
+		}
+	}
+
+	result, err := triage.Evaluate(req.Answers)
+	if err != nil {
+		return nil, err
+	}
+
+	answersJSON, err := json.Marshal(req.Answers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal answers: %v", err)
+	}
+	redFlagsJSON, err := json.Marshal(result.RedFlags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal red flags: %v", err)
+	}
+
+	assessment := &models.TriageAssessment{
+		PatientID:          patientID,
+		DependentID:        req.DependentID,
+		AnswersJSON:        string(answersJSON),
+		Urgency:            result.Urgency,
+		SuggestedSpecialty: result.SuggestedSpecialty,
+		RedFlagsJSON:       string(redFlagsJSON),
+		HasRedFlag:         result.HasRedFlag(),
+		Summary:            result.Summary,
+	}
+
+	if err := s.triageRepo.Create(assessment); err != nil {
+		return nil, err
+	}
+
+	if assessment.HasRedFlag {
+		s.auditService.LogUserAction(patientID, "triage_red_flag", "triage_assessment", fmt.Sprintf("%d", assessment.ID), map[string]interface{}{
+			"red_flags": result.RedFlags,
+		})
+	}
+
+	return assessment, nil
+}
+
+// GetTriageAssessment 問診結果の取得（患者本人用）
+func (s *TriageService) GetTriageAssessment(assessmentID, patientID uint) (*models.TriageAssessment, error) {
+	assessment, err := s.triageRepo.FindByID(assessmentID)
+	if err != nil || assessment == nil || assessment.PatientID != patientID {
+		return nil, errors.New("triage assessment not found")
+	}
+	return assessment, nil
+}
+
+// GetTriageAssessments 問診結果一覧の取得（患者本人用）
+func (s *TriageService) GetTriageAssessments(patientID uint, limit, offset int) ([]models.TriageAssessment, error) {
+	return s.triageRepo.FindByPatientID(patientID, limit, offset)
+}
+
+// TriageAdvice 判定結果に応じた患者向けの案内
+func TriageAdvice(assessment *models.TriageAssessment) string {
+	switch assessment.Urgency {
+	case triage.UrgencyEmergency:
+		return "緊急の対応が必要な可能性があります。オンライン診療を待たずに、直ちに119番へ通報するか救急外来を受診してください。"
+	case triage.UrgencyUrgent:
+		return "本日中の受診をおすすめします。"
+	case triage.UrgencySoon:
+		return "数日以内の受診をおすすめします。"
+	}
+	return "通常のご予約で問題ありません。"
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 利用上限の種類
+const (
+	QuotaChatWindow    = "chat_window"    // 診療の完了後にチャットを送信できる期間
+	QuotaVideoSessions = "video_sessions" // 予約ごとのビデオセッションの数
+)
+
+// UsageLimits プランごとの予約あたりのチャット・ビデオの利用上限
+type UsageLimits struct {
+	ChatPostConsultationWindow time.Duration `json:"-"`
+	ChatPostConsultationHours  int           `json:"chat_post_consultation_hours"`
+	MaxVideoSessions           int           `json:"max_video_sessions"` // 0の場合は制限しない
+}
+
+func NewUsageLimits(chatPostConsultationWindow time.Duration, maxVideoSessions int) UsageLimits {
+	return UsageLimits{
+		ChatPostConsultationWindow: chatPostConsultationWindow,
+		ChatPostConsultationHours:  int(chatPostConsultationWindow / time.Hour),
+		MaxVideoSessions:           maxVideoSessions,
+	}
+}
+
+// QuotaExceededError 予約の患者のプランの利用上限を超えた
+// UpgradeAvailable は患者本人が有料プランに切り替えると上限が緩和される場合にtrue
+type QuotaExceededError struct {
+	Quota            string
+	Plan             string
+	Limit            int // chat_window は時間、video_sessions はセッション数
+	UpgradeAvailable bool
+}
+
+func (e *QuotaExceededError) Error() string {
+	switch e.Quota {
+	case QuotaChatWindow:
+		return fmt.Sprintf("quota exceeded: chat is closed %d hours after the consultation on the %s plan", e.Limit, e.Plan)
+	case QuotaVideoSessions:
+		return fmt.Sprintf("quota exceeded: at most %d video sessions per appointment on the %s plan", e.Limit, e.Plan)
+	default:
+		return "quota exceeded: " + e.Quota
+	}
+}
+
+// UsageQuotaService 予約ごとのチャット・ビデオの利用上限の確認
+// 上限は予約の患者のプラン（有料プランの契約の期限内かどうか）で決まり、医師・通訳者にも同じ上限を適用する
+type UsageQuotaService struct {
+	subscriptionRepo     repositories.SubscriptionRepository
+	videoSessionRepo     repositories.VideoSessionRepository
+	freeLimits           UsageLimits
+	plusLimits           UsageLimits
+	subscriptionsEnabled bool // 有料プランに切り替えられるかどうか（料金・決済代行サービスの設定による）
+}
+
+func NewUsageQuotaService(subscriptionRepo repositories.SubscriptionRepository, videoSessionRepo repositories.VideoSessionRepository, freeLimits, plusLimits UsageLimits, subscriptionsEnabled bool) *UsageQuotaService {
+	return &UsageQuotaService{
+		subscriptionRepo:     subscriptionRepo,
+		videoSessionRepo:     videoSessionRepo,
+		freeLimits:           freeLimits,
+		plusLimits:           plusLimits,
+		subscriptionsEnabled: subscriptionsEnabled,
+	}
+}
+
+// PlanLimits プランの利用上限
+func (s *UsageQuotaService) PlanLimits(plan string) UsageLimits {
+	if plan == models.PlanPlus {
+		return s.plusLimits
+	}
+	return s.freeLimits
+}
+
+// PatientPlan 患者の現在のプラン（期限切れの契約は無料のプランとして扱う）
+func (s *UsageQuotaService) PatientPlan(patientID uint) (string, error) {
+	subscription, err := s.subscriptionRepo.FindByPatient(patientID)
+	if err != nil {
+		return "", err
+	}
+	if subscription == nil || !subscription.IsActive(time.Now()) {
+		return models.PlanFree, nil
+	}
+	return subscription.Plan, nil
+}
+
+// CheckChat 診療の完了後のチャットの送信期間の確認（未完了の予約は制限しない）
+func (s *UsageQuotaService) CheckChat(appointment *models.Appointment, userID uint) error {
+	if appointment.Status != "completed" {
+		return nil
+	}
+	plan, err := s.PatientPlan(appointment.PatientID)
+	if err != nil {
+		return err
+	}
+	limits := s.PlanLimits(plan)
+	if consultationCompletedAt(appointment).Add(limits.ChatPostConsultationWindow).After(time.Now()) {
+		return nil
+	}
+	return s.exceeded(QuotaChatWindow, plan, limits.ChatPostConsultationHours, appointment, userID,
+		s.plusLimits.ChatPostConsultationWindow > limits.ChatPostConsultationWindow)
+}
+
+// CheckVideoSession 予約で新しいビデオセッションを作成できるかどうかの確認
+func (s *UsageQuotaService) CheckVideoSession(appointment *models.Appointment, userID uint) error {
+	plan, err := s.PatientPlan(appointment.PatientID)
+	if err != nil {
+		return err
+	}
+	limits := s.PlanLimits(plan)
+	if limits.MaxVideoSessions <= 0 {
+		return nil
+	}
+	count, err := s.videoSessionRepo.CountByAppointment(appointment.ID)
+	if err != nil {
+		return err
+	}
+	if count < int64(limits.MaxVideoSessions) {
+		return nil
+	}
+	return s.exceeded(QuotaVideoSessions, plan, limits.MaxVideoSessions, appointment, userID,
+		s.plusLimits.MaxVideoSessions <= 0 || s.plusLimits.MaxVideoSessions > limits.MaxVideoSessions)
+}
+
+// exceeded 利用上限の超過のエラー（有料プランへの切り替えは患者本人にのみ案内する）
+func (s *UsageQuotaService) exceeded(quota, plan string, limit int, appointment *models.Appointment, userID uint, plusRaisesLimit bool) error {
+	return &QuotaExceededError{
+		Quota:            quota,
+		Plan:             plan,
+		Limit:            limit,
+		UpgradeAvailable: s.subscriptionsEnabled && plan == models.PlanFree && plusRaisesLimit && appointment.PatientID == userID,
+	}
+}
+
+// consultationCompletedAt 診療の完了日時（完了日時を記録する前に完了した予約は予定の終了時刻・最終更新日時で代用する）
+func consultationCompletedAt(appointment *models.Appointment) time.Time {
+	if appointment.CompletedAt != nil {
+		return *appointment.CompletedAt
+	}
+	if appointment.ScheduledEnd != nil {
+		return *appointment.ScheduledEnd
+	}
+	return appointment.UpdatedAt
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+// 稼働率の集計期間
+const (
+	utilizationDefaultWeeks = 4
+	utilizationMaxWeeks     = 53
+)
+
+var utilizationExportHeaders = []string{"doctor_id", "doctor_name", "week_start", "open_hours", "booked_hours", "blocked_hours", "utilization_rate"}
+
+type UtilizationService struct {
+	slotRepo             repositories.SlotRepository
+	userRepo             repositories.UserRepository
+	bookingPolicyService *BookingPolicyService
+}
+
+// UtilizationHours 診療枠の時間数（予約あり・空き・停止中）と稼働率（予約あり / 公開した枠）
+type UtilizationHours struct {
+	OpenHours       float64 `json:"open_hours"`
+	BookedHours     float64 `json:"booked_hours"`
+	BlockedHours    float64 `json:"blocked_hours"`
+	UtilizationRate float64 `json:"utilization_rate"`
+}
+
+// UtilizationRow 医師ごと・週ごとの稼働状況
+type UtilizationRow struct {
+	DoctorID   uint   `json:"doctor_id"`
+	DoctorName string `json:"doctor_name"`
+	WeekStart  string `json:"week_start"`
+	UtilizationHours
+}
+
+// UtilizationReport 期間内の稼働状況
+type UtilizationReport struct {
+	From   time.Time        `json:"from"`
+	To     time.Time        `json:"to"`
+	Rows   []UtilizationRow `json:"rows"`
+	Totals UtilizationHours `json:"totals"`
+}
+
+// UtilizationWeek 週ごとの稼働状況の推移
+type UtilizationWeek struct {
+	WeekStart string `json:"week_start"`
+	UtilizationHours
+}
+
+// UtilizationExport 稼働状況のCSV
+type UtilizationExport struct {
+	Filename string
+	Data     []byte
+}
+
+func NewUtilizationService(slotRepo repositories.SlotRepository, userRepo repositories.UserRepository, bookingPolicyService *BookingPolicyService) *UtilizationService {
+	return &UtilizationService{
+		slotRepo:             slotRepo,
+		userRepo:             userRepo,
+		bookingPolicyService: bookingPolicyService,
+	}
+}
+
+// GetUtilization 医師ごと・週ごとの稼働状況の取得
+// 管理者は全医師（doctorID指定時はその医師）、医師は自身のみ閲覧できる
+// 週は予約受付ルールのタイムゾーンの月曜日から数える
+func (s *UtilizationService) GetUtilization(viewerID, doctorID uint, from, to string) (*UtilizationReport, error) {
+	doctorID, err := s.resolveDoctor(viewerID, doctorID)
+	if err != nil {
+		return nil, err
+	}
+	location, err := s.bookingPolicyService.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := utilizationRange(from, to, location)
+	if err != nil {
+		return nil, err
+	}
+
+	slots, err := s.slotRepo.FindInRangeWithBookingStatus(start, end, doctorID)
+	if err != nil {
+		return nil, err
+	}
+	names, err := s.doctorNames()
+	if err != nil {
+		return nil, err
+	}
+
+	type rowKey struct {
+		doctorID  uint
+		weekStart time.Time
+	}
+	rows := make(map[rowKey]*UtilizationRow)
+	var order []rowKey
+	report := &UtilizationReport{From: start, To: end, Rows: []UtilizationRow{}}
+
+	for _, slot := range slots {
+		key := rowKey{doctorID: slot.DoctorID, weekStart: weekStart(slot.StartTime, location)}
+		row, ok := rows[key]
+		if !ok {
+			row = &UtilizationRow{DoctorID: slot.DoctorID, DoctorName: names[slot.DoctorID], WeekStart: key.weekStart.Format("2006-01-02")}
+			rows[key] = row
+			order = append(order, key)
+		}
+		row.add(slot)
+		report.Totals.add(slot)
+	}
+
+	// 医師ID・週の順（スロットの取得順）で並べる
+	for _, key := range order {
+		row := rows[key]
+		row.finish()
+		report.Rows = append(report.Rows, *row)
+	}
+	report.Totals.finish()
+
+	return report, nil
+}
+
+// GetUtilizationTrend 直近の週ごとの稼働状況の推移（今週を含む）
+func (s *UtilizationService) GetUtilizationTrend(viewerID, doctorID uint, weeks int) ([]UtilizationWeek, error) {
+	doctorID, err := s.resolveDoctor(viewerID, doctorID)
+	if err != nil {
+		return nil, err
+	}
+	if weeks <= 0 {
+		weeks = utilizationDefaultWeeks
+	}
+	if weeks > utilizationMaxWeeks {
+		return nil, fmt.Errorf("weeks must be at most %d", utilizationMaxWeeks)
+	}
+
+	location, err := s.bookingPolicyService.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	end := weekStart(time.Now(), location).AddDate(0, 0, 7)
+	start := end.AddDate(0, 0, -7*weeks)
+	slots, err := s.slotRepo.FindInRangeWithBookingStatus(start, end, doctorID)
+	if err != nil {
+		return nil, err
+	}
+
+	trend := make([]UtilizationWeek, weeks)
+	for i := range trend {
+		trend[i].WeekStart = start.AddDate(0, 0, 7*i).Format("2006-01-02")
+	}
+	for _, slot := range slots {
+		i := int(math.Round(weekStart(slot.StartTime, location).Sub(start).Hours() / (24 * 7)))
+		if i >= 0 && i < weeks {
+			trend[i].add(slot)
+		}
+	}
+	for i := range trend {
+		trend[i].finish()
+	}
+
+	return trend, nil
+}
+
+// ExportUtilization 医師ごと・週ごとの稼働状況をCSVで出力
+func (s *UtilizationService) ExportUtilization(viewerID, doctorID uint, from, to string) (*UtilizationExport, error) {
+	report, err := s.GetUtilization(viewerID, doctorID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(utilizationExportHeaders); err != nil {
+		return nil, err
+	}
+	for _, row := range report.Rows {
+		if err := writer.Write(utilizationRecord(row)); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+
+	return &UtilizationExport{
+		Filename: fmt.Sprintf("slot_utilization_%s_%s.csv", report.From.Format("20060102"), report.To.AddDate(0, 0, -1).Format("20060102")),
+		Data:     buf.Bytes(),
+	}, nil
+}
+
+// utilizationRecord 稼働状況のCSVの1行（utilizationExportHeaders の順）
+func utilizationRecord(row UtilizationRow) []string {
+	return []string{
+		strconv.FormatUint(uint64(row.DoctorID), 10),
+		row.DoctorName,
+		row.WeekStart,
+		formatHours(row.OpenHours),
+		formatHours(row.BookedHours),
+		formatHours(row.BlockedHours),
+		formatHours(row.UtilizationRate),
+	}
+}
+
+// resolveDoctor 閲覧できる医師の確認（医師は自身に限定し、管理者は指定どおり）
+func (s *UtilizationService) resolveDoctor(viewerID, doctorID uint) (uint, error) {
+	viewer, err := s.userRepo.FindByID(viewerID)
+	if err != nil || viewer == nil {
+		return 0, errors.New("user not found")
+	}
+
+	switch viewer.Role {
+	case "admin":
+		return doctorID, nil
+	case "doctor":
+		if doctorID != 0 && doctorID != viewerID {
+			return 0, errors.New("unauthorized to view utilization of other doctors")
+		}
+		return viewerID, nil
+	}
+	return 0, errors.New("unauthorized: admin or doctor access required")
+}
+
+func (s *UtilizationService) doctorNames() (map[uint]string, error) {
+	doctors, err := s.userRepo.FindDoctors("")
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(doctors))
+	for _, doctor := range doctors {
+		names[doctor.UserID] = doctor.Name
+	}
+	return names, nil
+}
+
+// add 診療枠の時間数を加算する（キャンセル以外の予約がある枠は停止中でも予約ありとして数える）
+func (h *UtilizationHours) add(slot models.AvailabilitySlot) {
+	hours := slot.EndTime.Sub(slot.StartTime).Hours()
+	switch {
+	case slot.Appointment != nil:
+		h.BookedHours += hours
+	case slot.Status == "blocked":
+		h.BlockedHours += hours
+	default:
+		h.OpenHours += hours
+	}
+}
+
+// finish 時間数の丸めと稼働率の計算
+func (h *UtilizationHours) finish() {
+	offered := h.OpenHours + h.BookedHours
+	if offered > 0 {
+		h.UtilizationRate = roundHours(h.BookedHours / offered)
+	}
+	h.OpenHours = roundHours(h.OpenHours)
+	h.BookedHours = roundHours(h.BookedHours)
+	h.BlockedHours = roundHours(h.BlockedHours)
+}
+
+// utilizationRange 集計期間（週単位に切り上げ、toは指定日を含む）
+// 未指定の場合は今週を含む直近4週間
+func utilizationRange(from, to string, location *time.Location) (time.Time, time.Time, error) {
+	end := weekStart(time.Now(), location).AddDate(0, 0, 7)
+	if to != "" {
+		date, err := time.ParseInLocation("2006-01-02", to, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid to date (expected YYYY-MM-DD)")
+		}
+		end = weekStart(date, location).AddDate(0, 0, 7)
+	}
+
+	start := end.AddDate(0, 0, -7*utilizationDefaultWeeks)
+	if from != "" {
+		date, err := time.ParseInLocation("2006-01-02", from, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid from date (expected YYYY-MM-DD)")
+		}
+		start = weekStart(date, location)
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, errors.New("from must not be after to")
+	}
+	if end.Sub(start) > time.Duration(utilizationMaxWeeks)*7*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("period must be at most %d weeks", utilizationMaxWeeks)
+	}
+	return start, end, nil
+}
+
+// weekStart 指定時刻を含む週の月曜日0時
+func weekStart(t time.Time, location *time.Location) time.Time {
+	local := t.In(location)
+	offset := (int(local.Weekday()) + 6) % 7
+	return time.Date(local.Year(), local.Month(), local.Day()-offset, 0, 0, 0, 0, location)
+}
+
+func roundHours(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func formatHours(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
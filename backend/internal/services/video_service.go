@@ -1,20 +1,57 @@
 package services
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/realtime"
 	"online_medical_consultation_app/backend/internal/repositories"
 )
 
+// 着信に応答がない場合に不在とするまでの時間
+const callRingTimeout = 60 * time.Second
+
+// ルームトークンの有効期間（通話中は期限前に更新する）
+const roomTokenTTL = time.Hour
+
+// ICE候補の受信待ちの上限
+const (
+	maxICECandidateBytes   = 2048          // 1件の大きさ（RTCIceCandidateInit のJSON）
+	maxQueuedICECandidates = 200           // 送信者ごと・セッションごとの件数
+	iceCandidateTTL        = 2 * time.Hour // 取得されないまま残った候補の保持期間
+)
+
+// ICECandidateEvent ICE候補の受信（WebSocketでは "video.candidate" で届く）
+const ICECandidateEvent = "video.candidate"
+
 type VideoService struct {
-	videoSessionRepo repositories.VideoSessionRepository
-	appointmentRepo  repositories.AppointmentRepository
-	userRepo         repositories.UserRepository
+	videoSessionRepo    repositories.VideoSessionRepository
+	iceCandidateRepo    repositories.ICECandidateRepository
+	presenceRepo        repositories.VideoPresenceRepository
+	appointmentRepo     repositories.AppointmentRepository
+	userRepo            repositories.UserRepository
+	deviceService       *DeviceService
+	notificationService *NotificationService
+	auditService        *AuditService
+	recordingService    *RecordingService
+	usageQuotaService   *UsageQuotaService
+	hub                 *realtime.Hub
+	iceServers          ICEServerConfig
+
+	// このインスタンスに接続中のシグナリングの接続（在室の定期的な更新用）
+	presenceMu     sync.Mutex
+	localPresences map[uint]struct{}
 }
 
 type CreateVideoSessionRequest struct {
@@ -25,22 +62,105 @@ type CreateVideoSessionRequest struct {
 	RecordingEnabled  bool   `json:"recording_enabled"`
 }
 
+type RecordingConsentRequest struct {
+	Consent *bool `json:"consent" binding:"required"`
+}
+
+// WebRTCAnswerRequest 応答待ちのオファーへのアンサー（SDP）
 type WebRTCAnswerRequest struct {
 	Answer string `json:"answer" binding:"required"`
 }
 
+// WebRTCOffer 他の参加者からの応答待ちのオファー
+type WebRTCOffer struct {
+	SessionID  uint            `json:"session_id"`
+	FromUserID uint            `json:"from_user_id"`
+	Offer      json.RawMessage `json:"offer"` // RTCSessionDescriptionInit
+	OfferedAt  time.Time       `json:"offered_at"`
+}
+
+// ICECandidateRequest ICE候補の送信（WebSocketの "video.candidate" ではsession_idも指定する）
+type ICECandidateRequest struct {
+	SessionID uint            `json:"session_id"`
+	Candidate json.RawMessage `json:"candidate" binding:"required"`
+}
+
+// QueuedICECandidate 受信待ちのICE候補
+type QueuedICECandidate struct {
+	ID         uint            `json:"id"`
+	SessionID  uint            `json:"session_id"`
+	FromUserID uint            `json:"from_user_id"`
+	Candidate  json.RawMessage `json:"candidate"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// SignalMessage WebSocketで中継するシグナリング（offer・answer・ICE候補・切断）
+type SignalMessage struct {
+	SessionID uint            `json:"session_id"`
+	Kind      string          `json:"kind"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
 type SignalingInfo struct {
 	RoomID      string   `json:"room_id"`
 	ICEServers  []string `json:"ice_servers"`
 	RoomToken   string   `json:"room_token"`
 	ExpiresAt   string   `json:"expires_at"`
+
+	// TURNサーバー（ice_servers の turn:・turns: のURL）の認証情報（TURNを設定していない場合は省略）
+	TURNUsername   string     `json:"turn_username,omitempty"`
+	TURNCredential string     `json:"turn_credential,omitempty"`
+	TURNExpiresAt  *time.Time `json:"turn_expires_at,omitempty"`
+}
+
+// ICEServerConfig ビデオ通話のSTUN/TURNサーバーの設定
+// TURNは coturn の use-auth-secret（TURN REST API）の方式で、共有の秘密鍵から期限付きの認証情報を発行する
+type ICEServerConfig struct {
+	STUNServers       []string
+	TURNURLs          []string
+	TURNSecret        string
+	TURNCredentialTTL time.Duration
 }
 
-func NewVideoService(videoSessionRepo repositories.VideoSessionRepository, appointmentRepo repositories.AppointmentRepository, userRepo repositories.UserRepository) *VideoService {
+// ValidateICEServerConfig STUN/TURNサーバーの設定の検証
+func ValidateICEServerConfig(cfg *ICEServerConfig) error {
+	for _, url := range cfg.STUNServers {
+		if !strings.HasPrefix(url, "stun:") && !strings.HasPrefix(url, "stuns:") {
+			return fmt.Errorf("invalid STUN server URL: %s", url)
+		}
+	}
+	if len(cfg.TURNURLs) == 0 {
+		return nil
+	}
+	for _, url := range cfg.TURNURLs {
+		if !strings.HasPrefix(url, "turn:") && !strings.HasPrefix(url, "turns:") {
+			return fmt.Errorf("invalid TURN server URL: %s", url)
+		}
+	}
+	if cfg.TURNSecret == "" {
+		return errors.New("TURN secret is required when TURN servers are configured")
+	}
+	if cfg.TURNCredentialTTL <= 0 {
+		return errors.New("TURN credential TTL must be positive")
+	}
+	return nil
+}
+
+func NewVideoService(videoSessionRepo repositories.VideoSessionRepository, iceCandidateRepo repositories.ICECandidateRepository, presenceRepo repositories.VideoPresenceRepository, appointmentRepo repositories.AppointmentRepository, userRepo repositories.UserRepository, deviceService *DeviceService, notificationService *NotificationService, auditService *AuditService, recordingService *RecordingService, usageQuotaService *UsageQuotaService, hub *realtime.Hub, iceServers ICEServerConfig) *VideoService {
 	return &VideoService{
-		videoSessionRepo: videoSessionRepo,
-		appointmentRepo:  appointmentRepo,
-		userRepo:         userRepo,
+		videoSessionRepo:    videoSessionRepo,
+		iceCandidateRepo:    iceCandidateRepo,
+		presenceRepo:        presenceRepo,
+		appointmentRepo:     appointmentRepo,
+		userRepo:            userRepo,
+		deviceService:       deviceService,
+		notificationService: notificationService,
+		auditService:        auditService,
+		recordingService:    recordingService,
+		usageQuotaService:   usageQuotaService,
+		hub:                 hub,
+		iceServers:          iceServers,
+		localPresences:      make(map[uint]struct{}),
 	}
 }
 
@@ -52,8 +172,8 @@ func (s *VideoService) CreateVideoSession(req *CreateVideoSessionRequest, userID
 		return nil, errors.New("appointment not found")
 	}
 
-	// 権限確認（予約に関連する患者または医師のみ）
-	if appointment.PatientID != userID && appointment.DoctorID != userID {
+	// 権限確認（患者・医師・通訳者のみ）
+	if !appointment.IsParticipant(userID) {
 		return nil, errors.New("unauthorized to create video session for this appointment")
 	}
 
@@ -65,6 +185,11 @@ func (s *VideoService) CreateVideoSession(req *CreateVideoSessionRequest, userID
 		}
 	}
 
+	// 予約ごとのビデオセッションの数の上限（患者のプランによる）
+	if err := s.usageQuotaService.CheckVideoSession(appointment, userID); err != nil {
+		return nil, err
+	}
+
 	// ルームIDの生成
 	roomID, err := s.generateRoomID()
 	if err != nil {
@@ -76,11 +201,35 @@ func (s *VideoService) CreateVideoSession(req *CreateVideoSessionRequest, userID
 		AppointmentID: req.AppointmentID,
 		RoomID:        roomID,
 	}
+	// 録音する場合は患者の同意を求める（同意するまで録音・文字起こしは行わない、録画は医師の同意も必要）
+	if req.RecordingEnabled {
+		videoSession.RecordingConsent = "requested"
+		videoSession.DoctorRecordingConsent = "requested"
+	}
 
 	if err := s.videoSessionRepo.Create(videoSession); err != nil {
 		return nil, err
 	}
 
+	if req.RecordingEnabled && appointment.PatientID != userID {
+		if _, err := s.notificationService.Notify(appointment.PatientID, NotificationMessage{
+			Type:  "recording_consent_requested",
+			Title: "ビデオ診療の録音・文字起こしへの同意をお願いします",
+			Data:  map[string]interface{}{"appointment_id": appointment.ID, "session_id": videoSession.ID},
+		}); err != nil {
+			log.Printf("Warning: Failed to request recording consent for video session %d: %v", videoSession.ID, err)
+		}
+	}
+	if req.RecordingEnabled && appointment.DoctorID != userID {
+		if _, err := s.notificationService.Notify(appointment.DoctorID, NotificationMessage{
+			Type:  "recording_consent_requested",
+			Title: "ビデオ診療の録画への同意をお願いします",
+			Data:  map[string]interface{}{"appointment_id": appointment.ID, "session_id": videoSession.ID},
+		}); err != nil {
+			log.Printf("Warning: Failed to request recording consent for video session %d: %v", videoSession.ID, err)
+		}
+	}
+
 	// 関連データの読み込み
 	if err := s.videoSessionRepo.LoadRelations(videoSession); err != nil {
 		return nil, err
@@ -117,8 +266,8 @@ func (s *VideoService) ValidateSessionAccess(sessionID, userID uint) error {
 		return errors.New("appointment not found")
 	}
 
-	// 権限確認（予約に関連する患者または医師のみ）
-	if appointment.PatientID != userID && appointment.DoctorID != userID {
+	// 権限確認（患者・医師・通訳者のみ）
+	if !appointment.IsParticipant(userID) {
 		return errors.New("unauthorized to access this video session")
 	}
 
@@ -134,7 +283,20 @@ func (s *VideoService) StartVideoSession(sessionID, userID uint) error {
 
 	// セッションの開始
 	now := time.Now()
-	return s.videoSessionRepo.UpdateStartedAt(sessionID, &now)
+	if err := s.videoSessionRepo.UpdateStartedAt(sessionID, &now); err != nil {
+		return err
+	}
+
+	// 医師が開始した場合は患者を呼び出す（失敗しても開始は妨げない）
+	session, err := s.videoSessionRepo.FindByID(sessionID)
+	if err != nil || session == nil {
+		return nil
+	}
+	appointment, err := s.appointmentRepo.FindByID(session.AppointmentID)
+	if err == nil && appointment != nil && appointment.DoctorID == userID {
+		s.ringPatient(session, appointment, now)
+	}
+	return nil
 }
 
 // EndVideoSession ビデオセッションの終了
@@ -146,7 +308,131 @@ func (s *VideoService) EndVideoSession(sessionID, userID uint) error {
 
 	// セッションの終了
 	now := time.Now()
-	return s.videoSessionRepo.UpdateEndedAt(sessionID, &now)
+	if err := s.videoSessionRepo.UpdateEndedAt(sessionID, &now); err != nil {
+		return err
+	}
+
+	// 応答前に終了した呼び出しは不在とする
+	missed, err := s.videoSessionRepo.MarkRingingMissed(sessionID, now)
+	if err != nil {
+		log.Printf("Warning: Failed to mark calls of session %d as missed: %v", sessionID, err)
+	}
+	for _, participant := range missed {
+		s.notifyMissedCall(participant)
+	}
+
+	// 録画中の録画は通話の終了で停止する
+	if session, err := s.videoSessionRepo.FindByID(sessionID); err == nil && session != nil {
+		if appointment, err := s.appointmentRepo.FindByID(session.AppointmentID); err == nil && appointment != nil {
+			s.recordingService.StopInProgress(session, appointment, RecordingStopVideoSessionEnded)
+		}
+	}
+
+	// 終了したセッションのICE候補は使われない
+	if _, err := s.iceCandidateRepo.DeleteBySession(sessionID); err != nil {
+		log.Printf("Warning: Failed to delete ICE candidates of session %d: %v", sessionID, err)
+	}
+	s.closeSignalingRoom(sessionID)
+	return nil
+}
+
+// SetRecordingConsent 録音・文字起こし・録画への同意・撤回（患者本人・担当医師のみ、終了前のセッション）
+// 録音・文字起こしは患者の同意、録画は患者・医師の双方の同意が必要で、どちらかが撤回した場合は録画中の録画を停止する
+func (s *VideoService) SetRecordingConsent(sessionID, userID uint, consent bool) (*models.VideoSession, error) {
+	session, err := s.videoSessionRepo.FindByID(sessionID)
+	if err != nil || session == nil {
+		return nil, errors.New("video session not found")
+	}
+	appointment, err := s.appointmentRepo.FindByID(session.AppointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.PatientID != userID && appointment.DoctorID != userID {
+		return nil, errors.New("only the patient or doctor can consent to recording")
+	}
+	if session.EndedAt != nil {
+		return nil, errors.New("video session has ended")
+	}
+
+	state := "declined"
+	if consent {
+		state = "granted"
+	}
+	role := "patient"
+	now := time.Now()
+	if appointment.PatientID == userID {
+		if err := s.videoSessionRepo.UpdateRecordingConsent(sessionID, state, now); err != nil {
+			return nil, err
+		}
+		session.RecordingConsent = state
+		session.RecordingConsentAt = &now
+	} else {
+		role = "doctor"
+		if err := s.videoSessionRepo.UpdateDoctorRecordingConsent(sessionID, state, now); err != nil {
+			return nil, err
+		}
+		session.DoctorRecordingConsent = state
+		session.DoctorRecordingConsentAt = &now
+	}
+
+	s.auditService.LogUserAction(userID, "recording_consent_"+state, "video_session", fmt.Sprintf("%d", sessionID), map[string]interface{}{
+		"appointment_id": appointment.ID,
+		"role":           role,
+	})
+	if !consent {
+		s.recordingService.StopInProgress(session, appointment, RecordingStopConsentWithdrawn)
+	}
+	if err := s.hub.Publish(appointment.ParticipantIDs(), "video.recording_consent", map[string]interface{}{
+		"session_id": sessionID,
+		"role":       role,
+		"consent":    state,
+	}); err != nil {
+		log.Printf("Warning: Failed to publish recording consent for video session %d: %v", sessionID, err)
+	}
+	return session, nil
+}
+
+// AcceptCall 着信への応答（呼び出された本人のみ）
+func (s *VideoService) AcceptCall(sessionID, userID uint) (*models.VideoParticipant, error) {
+	return s.respondCall(sessionID, userID, models.CallAccepted)
+}
+
+// DeclineCall 着信の拒否（呼び出された本人のみ）
+func (s *VideoService) DeclineCall(sessionID, userID uint) (*models.VideoParticipant, error) {
+	return s.respondCall(sessionID, userID, models.CallDeclined)
+}
+
+// GetCallParticipants セッションの呼び出し状況の取得
+func (s *VideoService) GetCallParticipants(sessionID, userID uint) ([]models.VideoParticipant, error) {
+	if err := s.ValidateSessionAccess(sessionID, userID); err != nil {
+		return nil, err
+	}
+	return s.videoSessionRepo.FindParticipants(sessionID)
+}
+
+// RunCallTimeoutJob 応答がないまま時間切れになった着信を不在にする
+func (s *VideoService) RunCallTimeoutJob() error {
+	now := time.Now()
+	participants, err := s.videoSessionRepo.FindRingingBefore(now.Add(-callRingTimeout))
+	if err != nil {
+		return err
+	}
+
+	for _, participant := range participants {
+		updated, err := s.videoSessionRepo.RespondParticipant(participant.VideoSessionID, participant.UserID, models.CallMissed, now)
+		if err != nil {
+			log.Printf("Warning: Failed to mark call %d as missed: %v", participant.ID, err)
+			continue
+		}
+		if !updated {
+			// 期限の直前に応答された
+			continue
+		}
+		participant.State = models.CallMissed
+		participant.RespondedAt = &now
+		s.notifyMissedCall(participant)
+	}
+	return nil
 }
 
 // GetVideoSessionsByAppointment 予約に関連するビデオセッション一覧の取得
@@ -157,8 +443,8 @@ func (s *VideoService) GetVideoSessionsByAppointment(appointmentID, userID uint)
 		return nil, errors.New("appointment not found")
 	}
 
-	// 権限確認（予約に関連する患者または医師のみ）
-	if appointment.PatientID != userID && appointment.DoctorID != userID {
+	// 権限確認（患者・医師・通訳者のみ）
+	if !appointment.IsParticipant(userID) {
 		return nil, errors.New("unauthorized to view video sessions for this appointment")
 	}
 
@@ -190,6 +476,36 @@ func (s *VideoService) GetSignalingInfo(sessionID, userID uint) (*SignalingInfo,
 		return nil, errors.New("video session not found")
 	}
 
+	return s.issueSignalingInfo(session, userID)
+}
+
+// RefreshSignalingInfo 通話中のルームトークンの再発行（通話を切らずに有効期限を延ばす）
+// 開始済みで終了していないセッションの、入室を認められた参加者のみ
+// （呼び出された参加者は着信に応答している必要がある）
+func (s *VideoService) RefreshSignalingInfo(sessionID, userID uint) (*SignalingInfo, error) {
+	if err := s.ValidateSessionAccess(sessionID, userID); err != nil {
+		return nil, err
+	}
+
+	session, err := s.videoSessionRepo.FindByID(sessionID)
+	if err != nil || session == nil {
+		return nil, errors.New("video session not found")
+	}
+	if session.StartedAt == nil {
+		return nil, errors.New("video session has not started")
+	}
+	if session.EndedAt != nil {
+		return nil, errors.New("video session has ended")
+	}
+	if participant, err := s.videoSessionRepo.FindParticipant(sessionID, userID); err == nil && participant.State != models.CallAccepted {
+		return nil, errors.New("participant has not been admitted to this video session")
+	}
+
+	return s.issueSignalingInfo(session, userID)
+}
+
+// issueSignalingInfo ルームトークンとICEサーバーの発行
+func (s *VideoService) issueSignalingInfo(session *models.VideoSession, userID uint) (*SignalingInfo, error) {
 	// ルームトークンの生成
 	roomToken, err := s.generateRoomToken(session.RoomID, userID)
 	if err != nil {
@@ -197,46 +513,306 @@ func (s *VideoService) GetSignalingInfo(sessionID, userID uint) (*SignalingInfo,
 	}
 
 	// ICEサーバーの設定（STUN/TURNサーバー）
-	iceServers := []string{
-		"stun:stun.l.google.com:19302",
-		"stun:stun1.l.google.com:19302",
-	}
+	iceServers := append([]string{}, s.iceServers.STUNServers...)
 
-	// 有効期限の設定（1時間）
-	expiresAt := time.Now().Add(1 * time.Hour).Format(time.RFC3339)
+	// 有効期限の設定
+	now := time.Now()
+	expiresAt := now.Add(roomTokenTTL).Format(time.RFC3339)
 
-	return &SignalingInfo{
+	info := &SignalingInfo{
 		RoomID:     session.RoomID,
 		ICEServers: iceServers,
 		RoomToken:  roomToken,
 		ExpiresAt:  expiresAt,
-	}, nil
-}
+	}
 
-// GetWebRTCOffer WebRTCオファーの取得
-func (s *VideoService) GetWebRTCOffer(sessionID, userID uint) (string, error) {
-	// 権限確認
-	if err := s.ValidateSessionAccess(sessionID, userID); err != nil {
-		return "", err
+	// 対称型NATの環境でも接続できるよう、TURNサーバーの期限付きの認証情報を発行する
+	if len(s.iceServers.TURNURLs) > 0 {
+		turnExpiresAt := now.Add(s.iceServers.TURNCredentialTTL)
+		info.ICEServers = append(info.ICEServers, s.iceServers.TURNURLs...)
+		info.TURNUsername, info.TURNCredential = generateTURNCredential(s.iceServers.TURNSecret, userID, turnExpiresAt)
+		info.TURNExpiresAt = &turnExpiresAt
 	}
+	return info, nil
+}
 
-	// 実際の実装では、WebRTCのオファー生成ロジックが必要
-	// ここでは簡易的な実装
-	return "webrtc_offer_data", nil
+// generateTURNCredential TURNサーバーの期限付きの認証情報（coturn の use-auth-secret）
+// ユーザー名は「有効期限のUNIX時刻:ユーザーID」、パスワードはユーザー名の HMAC-SHA1（Base64）
+func generateTURNCredential(secret string, userID uint, expiresAt time.Time) (username, credential string) {
+	username = fmt.Sprintf("%d:%d", expiresAt.Unix(), userID)
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(username))
+	return username, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// GetWebRTCOffer 他の参加者からの応答待ちのオファーの取得（シグナリングの接続を使わないクライアント用）
+func (s *VideoService) GetWebRTCOffer(sessionID, userID uint) (*WebRTCOffer, error) {
+	session, _, err := s.activeSession(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if session.PendingOfferFromID == nil || *session.PendingOfferFromID == userID || session.PendingOfferAt == nil {
+		return nil, errors.New("no pending offer")
+	}
+	return &WebRTCOffer{
+		SessionID:  sessionID,
+		FromUserID: *session.PendingOfferFromID,
+		Offer:      json.RawMessage(session.PendingOffer),
+		OfferedAt:  *session.PendingOfferAt,
+	}, nil
 }
 
-// SetWebRTCAnswer WebRTCアンサーの設定
+// SetWebRTCAnswer 応答待ちのオファーへのアンサーをオファーの送信者へ中継する
 func (s *VideoService) SetWebRTCAnswer(sessionID, userID uint, req WebRTCAnswerRequest) error {
-	// 権限確認
-	if err := s.ValidateSessionAccess(sessionID, userID); err != nil {
+	payload, err := json.Marshal(map[string]string{"type": "answer", "sdp": req.Answer})
+	if err != nil {
 		return err
 	}
+	return s.relaySignal(sessionID, userID, "answer", payload)
+}
+
+// HandleSignal WebSocketで受信したシグナリングを同じ予約の他の参加者へ中継する（"video.signal"）
+func (s *VideoService) HandleSignal(userID uint, data json.RawMessage) error {
+	var signal SignalMessage
+	if err := json.Unmarshal(data, &signal); err != nil {
+		return errors.New("invalid signal format")
+	}
+	switch signal.Kind {
+	case "offer", "answer", "hangup":
+		return s.relaySignal(signal.SessionID, userID, signal.Kind, signal.Payload)
+	case "candidate":
+		// ICE候補は受信待ちに登録してから届ける（"video.candidate" で受信する）
+		_, err := s.AddICECandidate(signal.SessionID, userID, signal.Payload)
+		return err
+	}
+	return errors.New("kind must be offer, answer, candidate or hangup")
+}
+
+// HandleCandidate WebSocketで受信したICE候補の登録（"video.candidate"）
+func (s *VideoService) HandleCandidate(userID uint, data json.RawMessage) error {
+	var req ICECandidateRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return errors.New("invalid candidate format")
+	}
+	_, err := s.AddICECandidate(req.SessionID, userID, req.Candidate)
+	return err
+}
+
+// AddICECandidate ICE候補を他の参加者それぞれの受信待ちに登録し、接続中の端末へ届ける
+// 受信側は届いた候補、または取得APIで受信待ちの候補を使い、取得の確認で受信待ちから削除する
+func (s *VideoService) AddICECandidate(sessionID, userID uint, candidate json.RawMessage) ([]models.ICECandidate, error) {
+	if len(candidate) > maxICECandidateBytes {
+		return nil, errors.New("candidate is too large")
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(candidate, &fields); err != nil || fields == nil {
+		return nil, errors.New("candidate must be a JSON object")
+	}
+
+	appointment, err := s.activeSessionAppointment(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	queued, err := s.iceCandidateRepo.CountBySender(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if queued >= maxQueuedICECandidates {
+		return nil, errors.New("too many ICE candidates queued for this session")
+	}
+
+	var candidates []models.ICECandidate
+	for _, recipientID := range otherParticipants(appointment, userID) {
+		candidates = append(candidates, models.ICECandidate{
+			VideoSessionID: sessionID,
+			RecipientID:    recipientID,
+			SenderID:       userID,
+			Candidate:      string(candidate),
+		})
+	}
+	if err := s.iceCandidateRepo.CreateBatch(candidates); err != nil {
+		return nil, err
+	}
+
+	for _, queued := range candidates {
+		if err := s.hub.Publish([]uint{queued.RecipientID}, ICECandidateEvent, newQueuedICECandidate(queued)); err != nil {
+			log.Printf("Warning: Failed to deliver ICE candidate %d to user %d: %v", queued.ID, queued.RecipientID, err)
+		}
+		if err := s.hub.PublishRoom(signalingRoom(sessionID), []uint{queued.RecipientID}, ICECandidateEvent, newQueuedICECandidate(queued)); err != nil {
+			log.Printf("Warning: Failed to deliver ICE candidate %d to signaling room of user %d: %v", queued.ID, queued.RecipientID, err)
+		}
+	}
+	return candidates, nil
+}
 
-	// 実際の実装では、WebRTCのアンサー処理ロジックが必要
-	// ここでは簡易的な実装
+// GetICECandidates 自分宛ての受信待ちのICE候補の取得
+// ackIDを指定した場合は、それ以前の候補を取得済みとして先に削除する
+func (s *VideoService) GetICECandidates(sessionID, userID, ackID uint) ([]QueuedICECandidate, error) {
+	if _, err := s.activeSessionAppointment(sessionID, userID); err != nil {
+		return nil, err
+	}
+	if ackID > 0 {
+		if _, err := s.iceCandidateRepo.Acknowledge(sessionID, userID, ackID); err != nil {
+			return nil, err
+		}
+	}
+
+	candidates, err := s.iceCandidateRepo.FindQueued(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]QueuedICECandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		result = append(result, newQueuedICECandidate(candidate))
+	}
+	return result, nil
+}
+
+// RunICECandidateCleanupJob 定期ジョブ：終了したセッションと、取得されないまま保持期間を過ぎたICE候補の削除
+func (s *VideoService) RunICECandidateCleanupJob() error {
+	deleted, err := s.iceCandidateRepo.DeleteStale(time.Now().Add(-iceCandidateTTL))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d stale ICE candidates", deleted)
+	}
 	return nil
 }
 
+// activeSessionAppointment 終了していないセッションの予約（参加者のみ）
+func (s *VideoService) activeSessionAppointment(sessionID, userID uint) (*models.Appointment, error) {
+	_, appointment, err := s.activeSession(sessionID, userID)
+	return appointment, err
+}
+
+// activeSession 終了していないセッションとその予約（参加者のみ）
+func (s *VideoService) activeSession(sessionID, userID uint) (*models.VideoSession, *models.Appointment, error) {
+	session, err := s.videoSessionRepo.FindByID(sessionID)
+	if err != nil || session == nil {
+		return nil, nil, errors.New("video session not found")
+	}
+	if session.EndedAt != nil {
+		return nil, nil, errors.New("video session has ended")
+	}
+	appointment, err := s.appointmentRepo.FindByID(session.AppointmentID)
+	if err != nil || appointment == nil {
+		return nil, nil, errors.New("appointment not found")
+	}
+	if !appointment.IsParticipant(userID) {
+		return nil, nil, errors.New("unauthorized to access this video session")
+	}
+	return session, appointment, nil
+}
+
+// otherParticipants 予約の参加者（患者・医師・通訳者）のうち本人以外
+func otherParticipants(appointment *models.Appointment, userID uint) []uint {
+	var recipients []uint
+	for _, id := range appointment.ParticipantIDs() {
+		if id != userID {
+			recipients = append(recipients, id)
+		}
+	}
+	return recipients
+}
+
+func newQueuedICECandidate(candidate models.ICECandidate) QueuedICECandidate {
+	return QueuedICECandidate{
+		ID:         candidate.ID,
+		SessionID:  candidate.VideoSessionID,
+		FromUserID: candidate.SenderID,
+		Candidate:  json.RawMessage(candidate.Candidate),
+		CreatedAt:  candidate.CreatedAt,
+	}
+}
+
+// ringPatient 患者の端末へ着信を通知する（高優先度のプッシュ通知と接続中のクライアントへのイベント）
+func (s *VideoService) ringPatient(session *models.VideoSession, appointment *models.Appointment, now time.Time) {
+	participant := &models.VideoParticipant{
+		VideoSessionID: session.ID,
+		UserID:         appointment.PatientID,
+		CallerID:       appointment.DoctorID,
+		State:          models.CallRinging,
+		RangAt:         now,
+	}
+	if err := s.videoSessionRepo.SaveParticipant(participant); err != nil {
+		log.Printf("Warning: Failed to ring patient for video session %d: %v", session.ID, err)
+		return
+	}
+
+	callerName := ""
+	if profile, err := s.userRepo.FindDoctorProfileByUserID(appointment.DoctorID); err == nil && profile != nil {
+		callerName = profile.Name
+	}
+	data := map[string]interface{}{
+		"appointment_id": appointment.ID,
+		"session_id":     session.ID,
+		"caller_id":      appointment.DoctorID,
+		"caller_name":    callerName,
+		"expires_at":     now.Add(callRingTimeout),
+	}
+
+	s.deviceService.PushToUser(appointment.PatientID, PushMessage{
+		Type:     "incoming_call",
+		Title:    "ビデオ通話の着信",
+		Body:     fmt.Sprintf("%s 医師から着信があります", callerName),
+		Priority: "high",
+		TTL:      callRingTimeout,
+		Data:     data,
+	})
+	if err := s.hub.Publish([]uint{appointment.PatientID}, "video.incoming_call", data); err != nil {
+		log.Printf("Warning: Failed to publish incoming call for video session %d: %v", session.ID, err)
+	}
+}
+
+// respondCall 着信への応答を記録し、発信者（と応答者の他の端末）へ即時に知らせる
+func (s *VideoService) respondCall(sessionID, userID uint, state string) (*models.VideoParticipant, error) {
+	if err := s.ValidateSessionAccess(sessionID, userID); err != nil {
+		return nil, err
+	}
+	session, err := s.videoSessionRepo.FindByID(sessionID)
+	if err != nil || session == nil {
+		return nil, errors.New("video session not found")
+	}
+	if session.EndedAt != nil {
+		return nil, errors.New("video session has ended")
+	}
+
+	updated, err := s.videoSessionRepo.RespondParticipant(sessionID, userID, state, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	participant, err := s.videoSessionRepo.FindParticipant(sessionID, userID)
+	if err != nil || participant == nil {
+		return nil, errors.New("no incoming call for this session")
+	}
+	if !updated {
+		return nil, fmt.Errorf("call is already %s", participant.State)
+	}
+
+	s.publishCallState(participant)
+	return participant, nil
+}
+
+// notifyMissedCall 不在着信を発信者・患者へ知らせる
+func (s *VideoService) notifyMissedCall(participant models.VideoParticipant) {
+	s.publishCallState(&participant)
+
+	if _, err := s.notificationService.Notify(participant.UserID, NotificationMessage{
+		Type:  "missed_call",
+		Title: "ビデオ通話の不在着信があります",
+		Data:  map[string]interface{}{"session_id": participant.VideoSessionID},
+	}); err != nil {
+		log.Printf("Warning: Failed to notify user %d of missed call: %v", participant.UserID, err)
+	}
+}
+
+func (s *VideoService) publishCallState(participant *models.VideoParticipant) {
+	if err := s.hub.Publish([]uint{participant.CallerID, participant.UserID}, "video.call_state", participant); err != nil {
+		log.Printf("Warning: Failed to publish call state for video session %d: %v", participant.VideoSessionID, err)
+	}
+}
+
 // generateRoomID ユニークなルームIDを生成
 func (s *VideoService) generateRoomID() (string, error) {
 	bytes := make([]byte, 16)
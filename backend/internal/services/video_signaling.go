@@ -0,0 +1,344 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/realtime"
+)
+
+const (
+	// maxSDPBytes オファー・アンサー（RTCSessionDescriptionInit のJSON）の大きさの上限
+	maxSDPBytes = 32 * 1024
+	// signalingPresenceStaleAfter 在室の更新が途絶えた接続を切断したとみなすまでの時間（更新は1分ごと）
+	signalingPresenceStaleAfter = 3 * time.Minute
+)
+
+// シグナリングのルームで送受信するイベント（受信は offer・answer・candidate・hangup）
+const (
+	SignalingJoinedEvent     = "video.joined"      // 接続直後に本人へ（接続中の他の参加者と応答待ちのオファー）
+	SignalingPeerJoinedEvent = "video.peer_joined" // 他の参加者の接続
+	SignalingPeerLeftEvent   = "video.peer_left"   // 他の参加者の切断
+	SignalingOfferEvent      = "video.offer"
+	SignalingAnswerEvent     = "video.answer"
+	SignalingHangupEvent     = "video.hangup"
+	SignalingEndedEvent      = "video.ended" // セッションの終了（クライアントは接続を閉じる）
+)
+
+// SignalingPeer シグナリングに接続中の参加者
+type SignalingPeer struct {
+	UserID      uint      `json:"user_id"`
+	Role        string    `json:"role"` // patient | doctor | interpreter
+	ConnectedAt time.Time `json:"connected_at"`
+}
+
+// SignalingRoomState 接続直後に送るルームの状態
+// 先に接続していた参加者がいる場合は、後から接続した側がオファーを送る
+type SignalingRoomState struct {
+	SessionID    uint            `json:"session_id"`
+	UserID       uint            `json:"user_id"`
+	Peers        []SignalingPeer `json:"peers"`
+	PendingOffer *WebRTCOffer    `json:"pending_offer,omitempty"`
+}
+
+// signalingMember シグナリングのルームへの1つの接続
+type signalingMember struct {
+	service   *VideoService
+	sessionID uint
+	userID    uint
+	presence  *models.VideoPresence
+}
+
+// signalingRoom セッションのシグナリングのルーム名
+func signalingRoom(sessionID uint) string {
+	return fmt.Sprintf("video:%d", sessionID)
+}
+
+// OpenSignaling セッションのシグナリングへの接続の確認（終了していないセッションの、入室を認められた参加者のみ）
+// 返したルームとメンバーで接続を開始すると在室が記録され、他の参加者へ知らせる
+func (s *VideoService) OpenSignaling(sessionID, userID uint) (string, realtime.RoomMember, error) {
+	if _, _, err := s.activeSession(sessionID, userID); err != nil {
+		return "", nil, err
+	}
+	if participant, err := s.videoSessionRepo.FindParticipant(sessionID, userID); err == nil && participant.State != models.CallAccepted {
+		return "", nil, errors.New("participant has not been admitted to this video session")
+	}
+	return signalingRoom(sessionID), &signalingMember{service: s, sessionID: sessionID, userID: userID}, nil
+}
+
+func (m *signalingMember) Join() (*realtime.Event, error) {
+	s := m.service
+	session, appointment, err := s.activeSession(m.sessionID, m.userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	presence := &models.VideoPresence{
+		VideoSessionID: m.sessionID,
+		UserID:         m.userID,
+		ConnectedAt:    now,
+		LastSeenAt:     now,
+	}
+	if err := s.presenceRepo.Create(presence); err != nil {
+		return nil, errors.New("failed to join signaling")
+	}
+	m.presence = presence
+	s.trackPresence(presence.ID, true)
+
+	peers, err := s.signalingPeers(m.sessionID, appointment)
+	if err != nil {
+		log.Printf("Warning: Failed to load signaling peers of session %d: %v", m.sessionID, err)
+	}
+	if err := s.hub.PublishRoom(signalingRoom(m.sessionID), otherParticipants(appointment, m.userID), SignalingPeerJoinedEvent, map[string]interface{}{
+		"session_id": m.sessionID,
+		"user_id":    m.userID,
+		"peers":      peers,
+	}); err != nil {
+		log.Printf("Warning: Failed to publish signaling join of user %d in session %d: %v", m.userID, m.sessionID, err)
+	}
+
+	state := SignalingRoomState{SessionID: m.sessionID, UserID: m.userID, Peers: []SignalingPeer{}}
+	for _, peer := range peers {
+		if peer.UserID != m.userID {
+			state.Peers = append(state.Peers, peer)
+		}
+	}
+	if session.PendingOfferFromID != nil && *session.PendingOfferFromID != m.userID && session.PendingOfferAt != nil {
+		state.PendingOffer = &WebRTCOffer{
+			SessionID:  m.sessionID,
+			FromUserID: *session.PendingOfferFromID,
+			Offer:      json.RawMessage(session.PendingOffer),
+			OfferedAt:  *session.PendingOfferAt,
+		}
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	return &realtime.Event{Type: SignalingJoinedEvent, Data: data}, nil
+}
+
+func (m *signalingMember) Handle(eventType string, data json.RawMessage) error {
+	switch eventType {
+	case SignalingOfferEvent, SignalingAnswerEvent, SignalingHangupEvent:
+		return m.service.relaySignal(m.sessionID, m.userID, strings.TrimPrefix(eventType, "video."), data)
+	case ICECandidateEvent:
+		_, err := m.service.AddICECandidate(m.sessionID, m.userID, data)
+		return err
+	}
+	return errors.New("unknown event type: " + eventType)
+}
+
+func (m *signalingMember) Leave() {
+	if m.presence == nil {
+		return
+	}
+	s := m.service
+	s.trackPresence(m.presence.ID, false)
+	deleted, err := s.presenceRepo.Delete(m.presence.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to delete signaling presence %d: %v", m.presence.ID, err)
+		return
+	}
+	// 定期ジョブで削除済みの場合は切断を知らせ済み
+	if deleted {
+		s.publishPeerLeft(m.sessionID, m.userID)
+	}
+}
+
+// GetSignalingPeers シグナリングに接続中の参加者の取得
+func (s *VideoService) GetSignalingPeers(sessionID, userID uint) ([]SignalingPeer, error) {
+	_, appointment, err := s.activeSession(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.signalingPeers(sessionID, appointment)
+}
+
+// RunSignalingPresenceJob 定期ジョブ：このインスタンスに接続中の在室の更新と、更新が途絶えた接続の削除
+func (s *VideoService) RunSignalingPresenceJob() error {
+	now := time.Now()
+	s.presenceMu.Lock()
+	ids := make([]uint, 0, len(s.localPresences))
+	for id := range s.localPresences {
+		ids = append(ids, id)
+	}
+	s.presenceMu.Unlock()
+	if err := s.presenceRepo.Touch(ids, now); err != nil {
+		return err
+	}
+
+	stale, err := s.presenceRepo.DeleteStale(now.Add(-signalingPresenceStaleAfter))
+	if err != nil {
+		return err
+	}
+	for _, presence := range stale {
+		s.publishPeerLeft(presence.VideoSessionID, presence.UserID)
+	}
+	return nil
+}
+
+// relaySignal オファー・アンサー・切断を同じ予約の他の参加者へ中継する
+// 通常の接続へは "video.signal"、シグナリングのルームへは "video.offer" 等で届ける
+func (s *VideoService) relaySignal(sessionID, userID uint, kind string, payload json.RawMessage) error {
+	session, appointment, err := s.activeSession(sessionID, userID)
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case "offer":
+		if err := validateSessionDescription(payload, "offer"); err != nil {
+			return err
+		}
+		// 応答待ちとして残し、後から接続した参加者・取得APIでも応答できるようにする
+		if err := s.videoSessionRepo.SetPendingOffer(sessionID, userID, string(payload), time.Now()); err != nil {
+			return err
+		}
+	case "answer":
+		if err := validateSessionDescription(payload, "answer"); err != nil {
+			return err
+		}
+		if session.PendingOfferFromID == nil || *session.PendingOfferFromID == userID {
+			return errors.New("no pending offer to answer")
+		}
+		if _, err := s.videoSessionRepo.ClearPendingOffer(sessionID, *session.PendingOfferFromID); err != nil {
+			return err
+		}
+	case "hangup":
+		// 切断した参加者の候補・オファーは再接続で使えないため破棄する
+		if _, err := s.iceCandidateRepo.DeleteForParticipant(sessionID, userID); err != nil {
+			log.Printf("Warning: Failed to delete ICE candidates of user %d in session %d: %v", userID, sessionID, err)
+		}
+		if _, err := s.videoSessionRepo.ClearPendingOffer(sessionID, userID); err != nil {
+			log.Printf("Warning: Failed to clear pending offer of user %d in session %d: %v", userID, sessionID, err)
+		}
+	default:
+		return errors.New("kind must be offer, answer or hangup")
+	}
+
+	recipients := otherParticipants(appointment, userID)
+	if err := s.hub.Publish(recipients, "video.signal", map[string]interface{}{
+		"session_id":   sessionID,
+		"from_user_id": userID,
+		"kind":         kind,
+		"payload":      payload,
+	}); err != nil {
+		return err
+	}
+	return s.hub.PublishRoom(signalingRoom(sessionID), recipients, "video."+kind, map[string]interface{}{
+		"session_id":   sessionID,
+		"from_user_id": userID,
+		"payload":      payload,
+	})
+}
+
+// validateSessionDescription オファー・アンサー（RTCSessionDescriptionInit）の形式の確認
+func validateSessionDescription(payload json.RawMessage, kind string) error {
+	if len(payload) > maxSDPBytes {
+		return errors.New(kind + " is too large")
+	}
+	var description struct {
+		Type string `json:"type"`
+		SDP  string `json:"sdp"`
+	}
+	if err := json.Unmarshal(payload, &description); err != nil {
+		return errors.New(kind + " must be a session description object")
+	}
+	if description.Type != kind || !strings.HasPrefix(description.SDP, "v=0") {
+		return fmt.Errorf("%s must have type %q and an SDP body", kind, kind)
+	}
+	return nil
+}
+
+// closeSignalingRoom 終了したセッションのシグナリングの接続へ終了を知らせ、在室を削除する
+func (s *VideoService) closeSignalingRoom(sessionID uint) {
+	session, err := s.videoSessionRepo.FindByID(sessionID)
+	if err != nil || session == nil {
+		return
+	}
+	appointment, err := s.appointmentRepo.FindByID(session.AppointmentID)
+	if err != nil || appointment == nil {
+		return
+	}
+	if _, err := s.presenceRepo.DeleteBySession(sessionID); err != nil {
+		log.Printf("Warning: Failed to delete signaling presences of session %d: %v", sessionID, err)
+	}
+	if err := s.hub.PublishRoom(signalingRoom(sessionID), appointment.ParticipantIDs(), SignalingEndedEvent, map[string]interface{}{
+		"session_id": sessionID,
+	}); err != nil {
+		log.Printf("Warning: Failed to publish end of video session %d: %v", sessionID, err)
+	}
+}
+
+// publishPeerLeft 参加者の切断を他の参加者へ知らせる（同じ参加者の他の接続が残っている場合も含め、接続中の一覧を添える）
+func (s *VideoService) publishPeerLeft(sessionID, userID uint) {
+	session, err := s.videoSessionRepo.FindByID(sessionID)
+	if err != nil || session == nil {
+		return
+	}
+	appointment, err := s.appointmentRepo.FindByID(session.AppointmentID)
+	if err != nil || appointment == nil {
+		return
+	}
+	peers, err := s.signalingPeers(sessionID, appointment)
+	if err != nil {
+		log.Printf("Warning: Failed to load signaling peers of session %d: %v", sessionID, err)
+	}
+	if err := s.hub.PublishRoom(signalingRoom(sessionID), otherParticipants(appointment, userID), SignalingPeerLeftEvent, map[string]interface{}{
+		"session_id": sessionID,
+		"user_id":    userID,
+		"peers":      peers,
+	}); err != nil {
+		log.Printf("Warning: Failed to publish signaling leave of user %d in session %d: %v", userID, sessionID, err)
+	}
+}
+
+// signalingPeers セッションに接続中の参加者（同じ参加者の複数の接続は最初の接続のみ）
+func (s *VideoService) signalingPeers(sessionID uint, appointment *models.Appointment) ([]SignalingPeer, error) {
+	presences, err := s.presenceRepo.FindBySession(sessionID)
+	if err != nil {
+		return []SignalingPeer{}, err
+	}
+	peers := []SignalingPeer{}
+	seen := make(map[uint]bool)
+	for _, presence := range presences {
+		if seen[presence.UserID] || !appointment.IsParticipant(presence.UserID) {
+			continue
+		}
+		seen[presence.UserID] = true
+		peers = append(peers, SignalingPeer{
+			UserID:      presence.UserID,
+			Role:        participantRole(appointment, presence.UserID),
+			ConnectedAt: presence.ConnectedAt,
+		})
+	}
+	return peers, nil
+}
+
+// participantRole 予約での参加者の役割
+func participantRole(appointment *models.Appointment, userID uint) string {
+	switch userID {
+	case appointment.PatientID:
+		return "patient"
+	case appointment.DoctorID:
+		return "doctor"
+	}
+	return "interpreter"
+}
+
+func (s *VideoService) trackPresence(id uint, connected bool) {
+	s.presenceMu.Lock()
+	defer s.presenceMu.Unlock()
+	if connected {
+		s.localPresences[id] = struct{}{}
+	} else {
+		delete(s.localPresences, id)
+	}
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+	"online_medical_consultation_app/backend/internal/repositories"
+)
+
+type VisitSummaryService struct {
+	appointmentRepo      repositories.AppointmentRepository
+	taskRepo             repositories.TaskRepository
+	bookingPolicyService *BookingPolicyService
+	brandingService      *BrandingService
+	auditService         *AuditService
+	sender               ContactSender
+	appBaseURL           string
+}
+
+// VisitSummaryPrescription サマリーに記載する処方
+type VisitSummaryPrescription struct {
+	IssuedAt time.Time          `json:"issued_at"`
+	Items    []PrescriptionItem `json:"items"`
+	Notes    string             `json:"notes"`
+}
+
+// VisitSummaryFollowUp サマリーに記載する患者のフォローアップ（患者に割り当てられたタスク）
+type VisitSummaryFollowUp struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueAt       *time.Time `json:"due_at"`
+	Done        bool       `json:"done"`
+}
+
+// VisitSummary 完了した診療のサマリー
+type VisitSummary struct {
+	AppointmentID    uint                       `json:"appointment_id"`
+	VisitedAt        time.Time                  `json:"visited_at"`
+	ConsultationType string                     `json:"consultation_type"` // scheduled | instant | async
+	PatientName      string                     `json:"patient_name"`
+	DoctorName       string                     `json:"doctor_name"`
+	DoctorSpecialty  string                     `json:"doctor_specialty"`
+	Notes            string                     `json:"notes"`
+	Prescriptions    []VisitSummaryPrescription `json:"prescriptions"`
+	FollowUps        []VisitSummaryFollowUp     `json:"follow_ups"`
+	GeneratedAt      time.Time                  `json:"generated_at"`
+}
+
+func NewVisitSummaryService(appointmentRepo repositories.AppointmentRepository, taskRepo repositories.TaskRepository, bookingPolicyService *BookingPolicyService, brandingService *BrandingService, auditService *AuditService, sender ContactSender, appBaseURL string) *VisitSummaryService {
+	return &VisitSummaryService{
+		appointmentRepo:      appointmentRepo,
+		taskRepo:             taskRepo,
+		bookingPolicyService: bookingPolicyService,
+		brandingService:      brandingService,
+		auditService:         auditService,
+		sender:               sender,
+		appBaseURL:           strings.TrimRight(appBaseURL, "/"),
+	}
+}
+
+// GetSummary 完了した診療のサマリーの取得（予約した患者のみ）
+func (s *VisitSummaryService) GetSummary(appointmentID, patientID uint) (*VisitSummary, error) {
+	appointment, err := s.appointmentRepo.FindForSummary(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, errors.New("appointment not found")
+	}
+	if appointment.PatientID != patientID {
+		return nil, errors.New("unauthorized to view this appointment summary")
+	}
+	if appointment.Status != "completed" {
+		return nil, errors.New("summary is available after the appointment is completed")
+	}
+
+	summary, err := s.buildSummary(appointment)
+	if err != nil {
+		return nil, err
+	}
+
+	// PHI閲覧ログの記録
+	s.auditService.LogPHIAccess(patientID, appointment.PatientID, "visit_summary", fmt.Sprintf("%d", appointment.ID), nil)
+
+	return summary, nil
+}
+
+// RenderPDF サマリーのPDF（ファイル名とデータ）
+func (s *VisitSummaryService) RenderPDF(summary *VisitSummary) (string, []byte, error) {
+	doc := s.brandingService.NewPDFDocument()
+	doc.Heading("診療サマリー")
+	doc.Blank()
+	for _, section := range s.summarySections(summary) {
+		if section.title != "" {
+			doc.Blank()
+			doc.Text("■ " + section.title)
+		}
+		for _, line := range section.lines {
+			doc.Text(line)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("visit_summary_%d.pdf", summary.AppointmentID), buf.Bytes(), nil
+}
+
+// SendSummaryEmail 診療の完了時に患者へサマリーをメールで送る（失敗しても完了処理は妨げない）
+func (s *VisitSummaryService) SendSummaryEmail(appointmentID uint) {
+	appointment, err := s.appointmentRepo.FindForSummary(appointmentID)
+	if err != nil || appointment == nil {
+		log.Printf("Warning: Failed to load appointment %d for visit summary: %v", appointmentID, err)
+		return
+	}
+	// デモアカウントには実際のメールを送らない
+	if appointment.Patient.IsDemo {
+		return
+	}
+
+	summary, err := s.buildSummary(appointment)
+	if err != nil {
+		log.Printf("Warning: Failed to build visit summary for appointment %d: %v", appointmentID, err)
+		return
+	}
+
+	var body strings.Builder
+	body.WriteString("診療が完了しました。診療内容のサマリーをお送りします。\n")
+	body.WriteString(s.summaryText(summary))
+	fmt.Fprintf(&body, "\nPDF版は次のページからダウンロードできます。\n%s/patient/appointments/%d/summary\n", s.appBaseURL, appointment.ID)
+
+	if err := s.sender.SendEmail(appointment.Patient.Email, "【診療サマリー】診療が完了しました", body.String()); err != nil {
+		log.Printf("Warning: Failed to send visit summary for appointment %d: %v", appointmentID, err)
+		return
+	}
+
+	s.auditService.LogSystemAction("visit_summary_sent", "appointment", fmt.Sprintf("%d", appointment.ID), map[string]interface{}{
+		"patient_id": appointment.PatientID,
+	})
+}
+
+// ShareSummary 担当医師がチャットで患者へ共有するサマリーと本文（診療中の確定した予約でも作成できる）
+func (s *VisitSummaryService) ShareSummary(appointmentID, doctorID uint) (*VisitSummary, string, error) {
+	appointment, err := s.appointmentRepo.FindForSummary(appointmentID)
+	if err != nil || appointment == nil {
+		return nil, "", errors.New("appointment not found")
+	}
+	if appointment.DoctorID != doctorID {
+		return nil, "", errors.New("unauthorized to share this appointment summary")
+	}
+	if appointment.Status != "confirmed" && appointment.Status != "completed" {
+		return nil, "", errors.New("summary can be shared after the appointment is confirmed")
+	}
+
+	summary, err := s.buildSummary(appointment)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.auditService.LogUserAction(doctorID, "visit_summary_shared", "appointment", fmt.Sprintf("%d", appointment.ID), map[string]interface{}{
+		"patient_id": appointment.PatientID,
+	})
+
+	return summary, s.summaryText(summary), nil
+}
+
+// buildSummary 予約・処方・患者のタスクからサマリーを作成する
+func (s *VisitSummaryService) buildSummary(appointment *models.Appointment) (*VisitSummary, error) {
+	summary := &VisitSummary{
+		AppointmentID:    appointment.ID,
+		VisitedAt:        appointment.CreatedAt,
+		ConsultationType: "scheduled",
+		Notes:            appointment.Notes,
+		Prescriptions:    []VisitSummaryPrescription{},
+		FollowUps:        []VisitSummaryFollowUp{},
+		GeneratedAt:      time.Now(),
+	}
+
+	switch {
+	case appointment.Slot != nil:
+		summary.VisitedAt = appointment.Slot.StartTime
+	case appointment.IsAsync:
+		summary.ConsultationType = "async"
+	case appointment.IsInstant:
+		summary.ConsultationType = "instant"
+	}
+
+	// 家族の代理予約は受診者の氏名を記載する
+	switch {
+	case appointment.Dependent != nil:
+		summary.PatientName = appointment.Dependent.Name
+	case appointment.Patient.PatientProfile != nil:
+		summary.PatientName = appointment.Patient.PatientProfile.Name
+	}
+	if profile := appointment.Doctor.DoctorProfile; profile != nil {
+		summary.DoctorName = profile.Name
+		summary.DoctorSpecialty = profile.Specialty
+	}
+
+	for _, prescription := range appointment.Prescriptions {
+		var items []PrescriptionItem
+		if err := json.Unmarshal([]byte(prescription.ItemsJSON), &items); err != nil {
+			return nil, fmt.Errorf("invalid prescription items: %d", prescription.ID)
+		}
+		summary.Prescriptions = append(summary.Prescriptions, VisitSummaryPrescription{
+			IssuedAt: prescription.CreatedAt,
+			Items:    items,
+			Notes:    prescription.Notes,
+		})
+	}
+
+	tasks, err := s.taskRepo.FindByAppointmentID(appointment.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		if task.AssigneeID != appointment.PatientID {
+			continue
+		}
+		summary.FollowUps = append(summary.FollowUps, VisitSummaryFollowUp{
+			Title:       task.Title,
+			Description: task.Description,
+			DueAt:       task.DueAt,
+			Done:        task.Status == "done",
+		})
+	}
+
+	return summary, nil
+}
+
+// summaryText メール本文・チャットに共通のサマリーの本文
+func (s *VisitSummaryService) summaryText(summary *VisitSummary) string {
+	var text strings.Builder
+	for _, section := range s.summarySections(summary) {
+		if section.title != "" {
+			fmt.Fprintf(&text, "\n■ %s\n", section.title)
+		}
+		for _, line := range section.lines {
+			text.WriteString(line + "\n")
+		}
+	}
+	return text.String()
+}
+
+type summarySection struct {
+	title string
+	lines []string
+}
+
+// summarySections メール本文・PDFに共通の記載内容（日時は予約受付ルールのタイムゾーンで表示）
+func (s *VisitSummaryService) summarySections(summary *VisitSummary) []summarySection {
+	location, err := s.bookingPolicyService.Location()
+	if err != nil {
+		location = time.Local
+	}
+	formatTime := func(t time.Time) string {
+		return t.In(location).Format("2006年1月2日 15:04")
+	}
+
+	doctor := summary.DoctorName
+	if summary.DoctorSpecialty != "" {
+		doctor += "（" + summary.DoctorSpecialty + "）"
+	}
+	sections := []summarySection{{lines: []string{
+		"受診日時: " + formatTime(summary.VisitedAt),
+		"受診者: " + summary.PatientName,
+		"担当医: " + doctor,
+	}}}
+
+	notes := []string{"記載なし"}
+	if strings.TrimSpace(summary.Notes) != "" {
+		notes = []string{summary.Notes}
+	}
+	sections = append(sections, summarySection{title: "診療メモ", lines: notes})
+
+	prescriptions := summarySection{title: "処方"}
+	for _, prescription := range summary.Prescriptions {
+		prescriptions.lines = append(prescriptions.lines, "発行日時: "+formatTime(prescription.IssuedAt))
+		for _, item := range prescription.Items {
+			line := fmt.Sprintf("・%s %s %s %s", item.MedicationName, item.Dosage, item.Frequency, item.Duration)
+			if item.Instructions != "" {
+				line += "（" + item.Instructions + "）"
+			}
+			prescriptions.lines = append(prescriptions.lines, line)
+		}
+		if prescription.Notes != "" {
+			prescriptions.lines = append(prescriptions.lines, "備考: "+prescription.Notes)
+		}
+	}
+	if len(prescriptions.lines) == 0 {
+		prescriptions.lines = []string{"なし"}
+	}
+	sections = append(sections, prescriptions)
+
+	followUps := summarySection{title: "今後の対応"}
+	for _, followUp := range summary.FollowUps {
+		line := "・" + followUp.Title
+		if followUp.DueAt != nil {
+			line += "（期限: " + formatTime(*followUp.DueAt) + "）"
+		}
+		if followUp.Done {
+			line += " [完了]"
+		}
+		followUps.lines = append(followUps.lines, line)
+		if followUp.Description != "" {
+			followUps.lines = append(followUps.lines, "  "+followUp.Description)
+		}
+	}
+	if len(followUps.lines) == 0 {
+		followUps.lines = []string{"なし"}
+	}
+	sections = append(sections, followUps)
+
+	return sections
+}
@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 本文を署名に含めない（アップロード中に全体のハッシュを計算しないため、HTTPSでのみ使用する）
+const s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+// 本文のないリクエストの本文のハッシュ（空文字のSHA-256）
+const s3EmptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Store S3（またはS3互換のストレージ）への保存、署名は AWS Signature Version 4
+type S3Store struct {
+	bucket          string
+	region          string
+	endpoint        string // パス形式でアクセスする場合のみ
+	accessKeyID     string
+	secretAccessKey string
+	client          *http.Client
+}
+
+func NewS3Store(cfg Config) *S3Store {
+	return &S3Store{
+		bucket:          cfg.S3Bucket,
+		region:          cfg.S3Region,
+		endpoint:        strings.TrimRight(cfg.S3Endpoint, "/"),
+		accessKeyID:     cfg.S3AccessKeyID,
+		secretAccessKey: cfg.S3SecretAccessKey,
+		client:          &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (s *S3Store) Name() string { return "s3" }
+
+// Put オブジェクトのアップロード（PutObject）
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, s3UnsignedPayload, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s.responseError("put", key, resp)
+	}
+	return nil
+}
+
+// Open オブジェクトのダウンロード（GetObject、呼び出し側で閉じる）
+func (s *S3Store) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, s3EmptyPayloadHash, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, s.responseError("get", key, resp)
+	}
+	return resp.Body, nil
+}
+
+// Delete オブジェクトの削除（DeleteObject、既にない場合も成功する）
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, s3EmptyPayloadHash, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return s.responseError("delete", key, resp)
+	}
+	return nil
+}
+
+// Check バケットにアクセスできるかどうかの確認（HeadBucket）
+func (s *S3Store) Check(ctx context.Context) error {
+	rawURL := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/", s.bucket, s.region)
+	if s.endpoint != "" {
+		rawURL = s.endpoint + "/" + s3EscapePath(s.bucket)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, s3EmptyPayloadHash, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("s3 head bucket %s failed with status %d", s.bucket, resp.StatusCode)
+	}
+	return nil
+}
+
+// 署名付きURLの有効期限の上限（SigV4の仕様で7日まで）
+const s3MaxPresignExpires = 7 * 24 * time.Hour
+
+// PresignGet GetObjectの署名付きURL（クエリ文字列による署名、host のみを署名に含める）
+func (s *S3Store) PresignGet(key, filename string, expires time.Duration) (string, error) {
+	if expires <= 0 || expires > s3MaxPresignExpires {
+		return "", fmt.Errorf("invalid presign expiry: %s", expires)
+	}
+	req, err := s.newRequest(context.Background(), http.MethodGet, key, nil)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := amzDate[:8] + "/" + s.region + "/s3/aws4_request"
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.accessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if filename != "" {
+		query.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+	canonicalQuery := s3CanonicalQuery(query)
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		req.URL.EscapedPath(),
+		canonicalQuery,
+		"host:" + req.URL.Host + "\n",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+	signature := hex.EncodeToString(s3HMAC(s.signingKey(amzDate[:8]), s.stringToSign(amzDate, scope, canonicalRequest)))
+
+	return req.URL.Scheme + "://" + req.URL.Host + req.URL.EscapedPath() + "?" + canonicalQuery + "&X-Amz-Signature=" + signature, nil
+}
+
+// newRequest オブジェクトのURL（エンドポイントを指定した場合はパス形式、それ以外は仮想ホスト形式）へのリクエスト
+func (s *S3Store) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if key == "" {
+		return nil, fmt.Errorf("invalid object key: %q", key)
+	}
+	var rawURL string
+	if s.endpoint != "" {
+		rawURL = s.endpoint + "/" + s3EscapePath(s.bucket) + "/" + s3EscapePath(key)
+	} else {
+		rawURL = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, s3EscapePath(key))
+	}
+	return http.NewRequestWithContext(ctx, method, rawURL, body)
+}
+
+// sign リクエストへの署名（host・x-amz-content-sha256・x-amz-date を署名に含める）
+func (s *S3Store) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	signature := hex.EncodeToString(s3HMAC(s.signingKey(date), s.stringToSign(amzDate, scope, canonicalRequest)))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.accessKeyID, scope, signedHeaders, signature))
+}
+
+func (s *S3Store) stringToSign(amzDate, scope, canonicalRequest string) string {
+	digest := sha256.Sum256([]byte(canonicalRequest))
+	return "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(digest[:])
+}
+
+// signingKey 日付・リージョン・サービスから導出した署名の鍵
+func (s *S3Store) signingKey(date string) []byte {
+	key := s3HMAC([]byte("AWS4"+s.secretAccessKey), date)
+	key = s3HMAC(key, s.region)
+	key = s3HMAC(key, "s3")
+	return s3HMAC(key, "aws4_request")
+}
+
+// responseError エラーの応答（本文の先頭のみを含める）
+func (s *S3Store) responseError(op, key string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 %s %s failed with status %d: %s", op, key, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3EscapePath キーの各区切りのURLエンコード（署名の正規化に合わせ、英数字と -._~ 以外をエンコードする、/ はそのまま残す）
+func s3EscapePath(key string) string {
+	return s3Escape(key, true)
+}
+
+// s3CanonicalQuery 署名用に正規化したクエリ文字列（キーの昇順、/ を含め英数字と -._~ 以外をエンコードする）
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, s3Escape(k, false)+"="+s3Escape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func s3Escape(value string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if (c == '/' && keepSlash) || c == '-' || c == '.' || c == '_' || c == '~' ||
+			('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,223 @@
+// Package storage ビデオ診療の録画・チャットの添付ファイル等の保存先（ローカルディスク・S3互換のオブジェクトストレージ）
+//
+// ファイルはキー（「recordings/12/34_1700000000.webm」のような / 区切りの名前）で保存・取得する。
+// 保存先は設定で切り替え、呼び出し側はどちらに保存されているかを意識しない。
+// 複数のインスタンスで共有する場合はS3を使う（ローカルディスクは単一インスタンスの構成・開発用）。
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound 指定したキーのファイルがない
+var ErrNotFound = errors.New("object not found")
+
+// ErrPresignNotConfigured 署名付きURLの発行に必要な設定がない
+var ErrPresignNotConfigured = errors.New("presigned urls are not configured")
+
+// ErrInvalidSignature 署名付きURLの署名が一致しない、または有効期限が切れている
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// Store ファイルの保存先
+type Store interface {
+	Name() string
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// PresignGet 認証なしで一定時間ダウンロードできるURL（filename を指定した場合は添付としてその名前で保存させる）
+	PresignGet(key, filename string, expires time.Duration) (string, error)
+	// Check 保存先を利用できるかどうかの確認（準備状況の確認用）
+	Check(ctx context.Context) error
+}
+
+// Config 保存先の設定
+type Config struct {
+	Provider          string // local | s3
+	LocalDir          string // localの保存先のディレクトリ
+	LocalURL          string // localの署名付きURLで配信するルートのURL（空の場合は署名付きURLを発行しない）
+	LocalSigningKey   string // localの署名付きURLの署名の鍵
+	S3Bucket          string
+	S3Region          string
+	S3Endpoint        string // S3互換のストレージ（MinIO等）の場合のみ、指定した場合はパス形式でアクセスする
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	Timeout           time.Duration // 1回のアップロード・ダウンロードの上限
+}
+
+// New 設定に応じた保存先の作成
+func New(cfg Config) (Store, error) {
+	switch cfg.Provider {
+	case "", "local":
+		if cfg.LocalDir == "" {
+			return nil, errors.New("local storage directory is required")
+		}
+		if cfg.LocalURL != "" && cfg.LocalSigningKey == "" {
+			return nil, errors.New("local signing key is required for presigned urls")
+		}
+		return NewLocalStore(cfg.LocalDir, cfg.LocalURL, cfg.LocalSigningKey), nil
+	case "s3":
+		if cfg.S3Bucket == "" || cfg.S3Region == "" {
+			return nil, errors.New("s3 bucket and region are required")
+		}
+		if cfg.S3AccessKeyID == "" || cfg.S3SecretAccessKey == "" {
+			return nil, errors.New("s3 access key is required")
+		}
+		return NewS3Store(cfg), nil
+	}
+	return nil, fmt.Errorf("unknown storage provider: %s", cfg.Provider)
+}
+
+// LocalStore ローカルディスクへの保存（公開アップロードとは別のディレクトリを指定する）
+// 署名付きURLは自身のAPIのルート（LocalURL）を指し、そのルートで VerifyPresigned により検証して配信する
+type LocalStore struct {
+	dir        string
+	url        string
+	signingKey []byte
+}
+
+func NewLocalStore(dir, url, signingKey string) *LocalStore {
+	return &LocalStore{dir: dir, url: strings.TrimRight(url, "/"), signingKey: []byte(signingKey)}
+}
+
+func (s *LocalStore) Name() string { return "local" }
+
+// Put ファイルの保存（書き込み中のファイルは完了後に名前を変える）
+func (s *LocalStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	partial := path + ".partial"
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	written, err := io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size >= 0 && written != size {
+		err = fmt.Errorf("wrote %d of %d bytes", written, size)
+	}
+	if err != nil {
+		os.Remove(partial)
+		return err
+	}
+	if err := os.Rename(partial, path); err != nil {
+		os.Remove(partial)
+		return err
+	}
+	return nil
+}
+
+// Open ファイルの読み込み
+func (s *LocalStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete ファイルの削除（既にない場合は何もしない）
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Check 保存先のディレクトリに書き込めるかどうかの確認（ディレクトリがない場合は作成する）
+func (s *LocalStore) Check(ctx context.Context) error {
+	return CheckWritableDir(s.dir)
+}
+
+// PresignGet 署名付きURL（<LocalURL>/<key>?expires=...&filename=...&signature=...）
+func (s *LocalStore) PresignGet(key, filename string, expires time.Duration) (string, error) {
+	if s.url == "" {
+		return "", ErrPresignNotConfigured
+	}
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	expiresAt := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expiresAt)
+	if filename != "" {
+		query.Set("filename", filename)
+	}
+	query.Set("signature", s.signature(key, filename, expiresAt))
+	return s.url + "/" + s3EscapePath(key) + "?" + query.Encode(), nil
+}
+
+// VerifyPresigned 署名付きURLの検証（PresignGet で発行したURLのキーとクエリを渡す）
+func (s *LocalStore) VerifyPresigned(key, filename, expires, signature string) error {
+	if s.url == "" {
+		return ErrPresignNotConfigured
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return ErrInvalidSignature
+	}
+	expected := s.signature(key, filename, expires)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (s *LocalStore) signature(key, filename, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key + "\n" + filename + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path キーに対応するファイルのパス（保存先のディレクトリの外を指すキーは拒否する）
+func (s *LocalStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid object key: %q", key)
+	}
+	return filepath.Join(s.dir, clean), nil
+}
+
+// CheckWritableDir ディレクトリに書き込めるかどうかの確認（一時ファイルを作成して削除する）
+func CheckWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(dir, ".check-*")
+	if err != nil {
+		return err
+	}
+	name := file.Name()
+	closeErr := file.Close()
+	if err := os.Remove(name); err != nil {
+		return err
+	}
+	return closeErr
+}
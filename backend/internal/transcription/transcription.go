@@ -0,0 +1,149 @@
+package transcription
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Segment 文字起こしの区間（開始・終了は録音の先頭からのミリ秒）
+type Segment struct {
+	StartMs int64
+	EndMs   int64
+	Speaker string // 話者分離に対応した事業者のみ
+	Text    string
+}
+
+// Provider 音声認識（STT）の事業者
+type Provider interface {
+	Name() string
+	Transcribe(ctx context.Context, audioPath, language string) ([]Segment, error)
+}
+
+// Config 音声認識の設定
+type Config struct {
+	Provider string // none | whisper
+	APIURL   string
+	APIKey   string
+	Model    string
+	Timeout  time.Duration
+}
+
+// NewProvider 設定に応じた事業者の作成（noneの場合はnilを返し、文字起こしを無効にする）
+func NewProvider(cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case "", "none":
+		return nil, nil
+	case "whisper":
+		if cfg.APIKey == "" {
+			return nil, errors.New("transcription API key is required for the whisper provider")
+		}
+		return NewWhisperProvider(cfg.APIURL, cfg.APIKey, cfg.Model, cfg.Timeout), nil
+	}
+	return nil, fmt.Errorf("unknown transcription provider: %s", cfg.Provider)
+}
+
+// WhisperProvider OpenAI互換の音声認識API（/v1/audio/transcriptions、verbose_json形式）
+type WhisperProvider struct {
+	apiURL string
+	apiKey string
+	model  string
+	client *http.Client
+}
+
+func NewWhisperProvider(apiURL, apiKey, model string, timeout time.Duration) *WhisperProvider {
+	return &WhisperProvider{
+		apiURL: apiURL,
+		apiKey: apiKey,
+		model:  model,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *WhisperProvider) Name() string { return "whisper" }
+
+type whisperResponse struct {
+	Segments []struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+	} `json:"segments"`
+}
+
+func (p *WhisperProvider) Transcribe(ctx context.Context, audioPath, language string) ([]Segment, error) {
+	audio, err := os.Open(audioPath)
+	if err != nil {
+		return nil, err
+	}
+	defer audio.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filepath.Base(audioPath))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, audio); err != nil {
+		return nil, err
+	}
+	fields := map[string]string{
+		"model":                     p.model,
+		"language":                  language,
+		"response_format":           "verbose_json",
+		"timestamp_granularities[]": "segment",
+	}
+	for key, value := range fields {
+		if err := writer.WriteField(key, value); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("transcription API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var result whisperResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid transcription response: %v", err)
+	}
+
+	segments := make([]Segment, 0, len(result.Segments))
+	for _, s := range result.Segments {
+		text := strings.TrimSpace(s.Text)
+		if text == "" {
+			continue
+		}
+		segments = append(segments, Segment{
+			StartMs: int64(s.Start * 1000),
+			EndMs:   int64(s.End * 1000),
+			Text:    text,
+		})
+	}
+	return segments, nil
+}
@@ -0,0 +1,290 @@
+package triage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 緊急度（低い順）
+const (
+	UrgencyRoutine   = "routine"   // 通常予約で可
+	UrgencySoon      = "soon"      // 数日以内の受診を推奨
+	UrgencyUrgent    = "urgent"    // 当日中の受診を推奨
+	UrgencyEmergency = "emergency" // 救急受診が必要
+)
+
+var urgencyLevels = map[string]int{
+	UrgencyRoutine:   0,
+	UrgencySoon:      1,
+	UrgencyUrgent:    2,
+	UrgencyEmergency: 3,
+}
+
+// 質問の回答形式
+const (
+	QuestionYesNo  = "yes_no"
+	QuestionChoice = "choice"
+	QuestionNumber = "number"
+)
+
+// DefaultSpecialty 該当するルールがない場合の推奨診療科
+const DefaultSpecialty = "内科"
+
+// Option 選択肢
+type Option struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// Question 問診票の質問
+type Question struct {
+	ID       string   `json:"id"`
+	Text     string   `json:"text"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Options  []Option `json:"options,omitempty"`
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+}
+
+// Rule 回答に対する判定ルール
+type Rule struct {
+	QuestionID string
+	Match      func(value interface{}) bool
+	Urgency    string
+	Specialty  string
+	RedFlag    bool
+	Message    string
+}
+
+// Result 判定結果
+type Result struct {
+	Urgency            string   `json:"urgency"`
+	SuggestedSpecialty string   `json:"suggested_specialty"`
+	RedFlags           []string `json:"red_flags"`
+	Summary            string   `json:"summary"`
+}
+
+// HasRedFlag 緊急対応が必要な回答が含まれるか
+func (r *Result) HasRedFlag() bool {
+	return len(r.RedFlags) > 0
+}
+
+func float(v float64) *float64 { return &v }
+
+// Questionnaire 症状チェックの問診票
+var Questionnaire = []Question{
+	{ID: "chief_complaint", Text: "最も気になる症状を選んでください", Type: QuestionChoice, Required: true, Options: []Option{
+		{Value: "fever", Label: "発熱"},
+		{Value: "cough", Label: "咳・のどの痛み"},
+		{Value: "headache", Label: "頭痛"},
+		{Value: "chest_pain", Label: "胸の痛み"},
+		{Value: "abdominal_pain", Label: "腹痛・下痢・嘔吐"},
+		{Value: "rash", Label: "発疹・かゆみ"},
+		{Value: "joint_pain", Label: "関節・腰の痛み"},
+		{Value: "mental", Label: "気分の落ち込み・不安・不眠"},
+		{Value: "other", Label: "その他"},
+	}},
+	{ID: "duration_days", Text: "症状が始まってから何日経ちますか", Type: QuestionNumber, Required: true, Min: float(0), Max: float(3650)},
+	{ID: "pain_scale", Text: "痛みや辛さの強さ（0〜10）", Type: QuestionNumber, Required: true, Min: float(0), Max: float(10)},
+	{ID: "temperature", Text: "体温（測定していない場合は空欄）", Type: QuestionNumber, Min: float(34), Max: float(43)},
+	{ID: "breathing_difficulty", Text: "息苦しさや呼吸困難がありますか", Type: QuestionYesNo, Required: true},
+	{ID: "severe_chest_pain", Text: "締め付けられるような強い胸の痛みがありますか", Type: QuestionYesNo, Required: true},
+	{ID: "altered_consciousness", Text: "意識がもうろうとする・ろれつが回らない・手足に力が入らないなどの症状がありますか", Type: QuestionYesNo, Required: true},
+	{ID: "severe_bleeding", Text: "止まらない出血や吐血・下血がありますか", Type: QuestionYesNo, Required: true},
+	{ID: "self_harm", Text: "自分を傷つけたい、または死にたいという気持ちがありますか", Type: QuestionYesNo, Required: true},
+	{ID: "pregnant", Text: "妊娠中、または妊娠の可能性がありますか", Type: QuestionYesNo},
+}
+
+// Rules 判定ルール（上から順に評価し、最も高い緊急度を採用）
+// 同じ質問に対するルールは最初に一致したもののみ適用する
+var Rules = []Rule{
+	// レッドフラグ（即時対応）
+	{QuestionID: "severe_chest_pain", Match: isYes, Urgency: UrgencyEmergency, Specialty: "循環器内科", RedFlag: true, Message: "強い胸の痛み"},
+	{QuestionID: "breathing_difficulty", Match: isYes, Urgency: UrgencyEmergency, Specialty: "呼吸器内科", RedFlag: true, Message: "呼吸困難"},
+	{QuestionID: "altered_consciousness", Match: isYes, Urgency: UrgencyEmergency, Specialty: "脳神経内科", RedFlag: true, Message: "意識障害・神経症状"},
+	{QuestionID: "severe_bleeding", Match: isYes, Urgency: UrgencyEmergency, RedFlag: true, Message: "止まらない出血"},
+	{QuestionID: "self_harm", Match: isYes, Urgency: UrgencyEmergency, Specialty: "精神科", RedFlag: true, Message: "自傷・希死念慮"},
+
+	// 緊急度の判定
+	{QuestionID: "temperature", Match: atLeast(39), Urgency: UrgencyUrgent, Message: "39度以上の発熱"},
+	{QuestionID: "temperature", Match: atLeast(37.5), Urgency: UrgencySoon, Message: "発熱"},
+	{QuestionID: "pain_scale", Match: atLeast(8), Urgency: UrgencyUrgent, Message: "強い痛み"},
+	{QuestionID: "pain_scale", Match: atLeast(5), Urgency: UrgencySoon, Message: "中等度の痛み"},
+	{QuestionID: "pregnant", Match: isYes, Urgency: UrgencySoon, Specialty: "産婦人科", Message: "妊娠中・妊娠の可能性"},
+
+	// 主訴による診療科の推定
+	{QuestionID: "chief_complaint", Match: equals("fever"), Specialty: "内科"},
+	{QuestionID: "chief_complaint", Match: equals("cough"), Specialty: "呼吸器内科"},
+	{QuestionID: "chief_complaint", Match: equals("headache"), Specialty: "脳神経内科"},
+	{QuestionID: "chief_complaint", Match: equals("chest_pain"), Urgency: UrgencySoon, Specialty: "循環器内科", Message: "胸の痛み"},
+	{QuestionID: "chief_complaint", Match: equals("abdominal_pain"), Specialty: "消化器内科"},
+	{QuestionID: "chief_complaint", Match: equals("rash"), Specialty: "皮膚科"},
+	{QuestionID: "chief_complaint", Match: equals("joint_pain"), Specialty: "整形外科"},
+	{QuestionID: "chief_complaint", Match: equals("mental"), Specialty: "精神科"},
+}
+
+// Evaluate 回答を検証し、緊急度・推奨診療科・レッドフラグを判定する
+func Evaluate(answers map[string]interface{}) (*Result, error) {
+	if err := Validate(answers); err != nil {
+		return nil, err
+	}
+
+	result := &Result{Urgency: UrgencyRoutine, RedFlags: []string{}}
+	var findings []string
+	redFlagSpecialty := ""
+	complaintSpecialty := ""
+	matched := make(map[string]bool)
+
+	for _, rule := range Rules {
+		if matched[rule.QuestionID] {
+			continue
+		}
+		value, ok := answers[rule.QuestionID]
+		if !ok || value == nil || !rule.Match(value) {
+			continue
+		}
+		matched[rule.QuestionID] = true
+
+		if rule.Urgency != "" && urgencyLevels[rule.Urgency] > urgencyLevels[result.Urgency] {
+			result.Urgency = rule.Urgency
+		}
+		if rule.RedFlag {
+			result.RedFlags = append(result.RedFlags, rule.Message)
+			if redFlagSpecialty == "" {
+				redFlagSpecialty = rule.Specialty
+			}
+		} else if rule.Message != "" {
+			findings = append(findings, rule.Message)
+		}
+		if !rule.RedFlag && rule.Specialty != "" && complaintSpecialty == "" {
+			complaintSpecialty = rule.Specialty
+		}
+	}
+
+	// レッドフラグに対応する診療科を優先する
+	switch {
+	case redFlagSpecialty != "":
+		result.SuggestedSpecialty = redFlagSpecialty
+	case complaintSpecialty != "":
+		result.SuggestedSpecialty = complaintSpecialty
+	default:
+		result.SuggestedSpecialty = DefaultSpecialty
+	}
+
+	result.Summary = summarize(answers, result, findings)
+	return result, nil
+}
+
+// Validate 回答の検証（必須項目・型・範囲）
+func Validate(answers map[string]interface{}) error {
+	known := make(map[string]bool, len(Questionnaire))
+	for _, q := range Questionnaire {
+		known[q.ID] = true
+
+		value, ok := answers[q.ID]
+		if !ok || value == nil {
+			if q.Required {
+				return fmt.Errorf("answer required: %s", q.ID)
+			}
+			continue
+		}
+
+		switch q.Type {
+		case QuestionYesNo:
+			if _, ok := value.(bool); !ok {
+				return fmt.Errorf("answer must be true or false: %s", q.ID)
+			}
+		case QuestionChoice:
+			s, ok := value.(string)
+			if !ok || !hasOption(q.Options, s) {
+				return fmt.Errorf("invalid choice: %s", q.ID)
+			}
+		case QuestionNumber:
+			n, ok := value.(float64)
+			if !ok {
+				return fmt.Errorf("answer must be a number: %s", q.ID)
+			}
+			if (q.Min != nil && n < *q.Min) || (q.Max != nil && n > *q.Max) {
+				return fmt.Errorf("answer out of range: %s", q.ID)
+			}
+		}
+	}
+
+	for id := range answers {
+		if !known[id] {
+			return errors.New("unknown question: " + id)
+		}
+	}
+	return nil
+}
+
+// summarize 医師向けの問診サマリーを作成する
+func summarize(answers map[string]interface{}, result *Result, findings []string) string {
+	var b strings.Builder
+
+	if complaint, ok := answers["chief_complaint"].(string); ok {
+		fmt.Fprintf(&b, "主訴: %s", optionLabel("chief_complaint", complaint))
+	}
+	if days, ok := answers["duration_days"].(float64); ok {
+		fmt.Fprintf(&b, "（%g日前から）", days)
+	}
+	if pain, ok := answers["pain_scale"].(float64); ok {
+		fmt.Fprintf(&b, " / 痛み: %g/10", pain)
+	}
+	if temperature, ok := answers["temperature"].(float64); ok {
+		fmt.Fprintf(&b, " / 体温: %.1f℃", temperature)
+	}
+	if len(result.RedFlags) > 0 {
+		fmt.Fprintf(&b, " / 要緊急対応: %s", strings.Join(result.RedFlags, "、"))
+	}
+	if len(findings) > 0 {
+		fmt.Fprintf(&b, " / 所見: %s", strings.Join(findings, "、"))
+	}
+	fmt.Fprintf(&b, " / 緊急度: %s / 推奨診療科: %s", result.Urgency, result.SuggestedSpecialty)
+
+	return b.String()
+}
+
+func optionLabel(questionID, value string) string {
+	for _, q := range Questionnaire {
+		if q.ID != questionID {
+			continue
+		}
+		for _, o := range q.Options {
+			if o.Value == value {
+				return o.Label
+			}
+		}
+	}
+	return value
+}
+
+func hasOption(options []Option, value string) bool {
+	for _, o := range options {
+		if o.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isYes(value interface{}) bool {
+	b, ok := value.(bool)
+	return ok && b
+}
+
+func equals(expected string) func(interface{}) bool {
+	return func(value interface{}) bool {
+		s, ok := value.(string)
+		return ok && s == expected
+	}
+}
+
+func atLeast(threshold float64) func(interface{}) bool {
+	return func(value interface{}) bool {
+		n, ok := value.(float64)
+		return ok && n >= threshold
+	}
+}
@@ -0,0 +1,266 @@
+// Package views 予約・メッセージ・処方のレスポンスに含める相手のユーザー情報をロールに応じて絞り込む
+//
+// 患者には医師の公開プロフィール、医師には患者の診療に必要な情報（生年月日・アレルギー）のみを返し、
+// メールアドレス・連絡先・保険情報等は本人と管理者にのみ返す。
+package views
+
+import (
+	"time"
+
+	"online_medical_consultation_app/backend/internal/models"
+)
+
+// Viewer レスポンスの受け手
+type Viewer struct {
+	UserID uint
+	Role   string
+}
+
+// UserView 本人・管理者以外に返すユーザー情報
+type UserView struct {
+	ID                 uint                    `json:"id"`
+	Role               string                  `json:"role"`
+	PatientProfile     *PatientProfileView     `json:"patient_profile,omitempty"`
+	DoctorProfile      *DoctorProfileView      `json:"doctor_profile,omitempty"`
+	InterpreterProfile *InterpreterProfileView `json:"interpreter_profile,omitempty"`
+}
+
+// PatientProfileView 患者のプロフィール（生年月日・アレルギーは医師にのみ返す）
+type PatientProfileView struct {
+	Name      string     `json:"name"`
+	Birthdate *time.Time `json:"birthdate,omitempty"`
+	Allergies *string    `json:"allergies,omitempty"`
+}
+
+// DoctorProfileView 医師の公開プロフィール
+type DoctorProfileView struct {
+	Name            string `json:"name"`
+	Specialty       string `json:"specialty"`
+	LicenseNumber   string `json:"license_number"`
+	Bio             string `json:"bio"`
+	Languages       string `json:"languages"`
+	AcceptsInstant  bool   `json:"accepts_instant"`
+	IntakeLeadHours int    `json:"intake_lead_hours"`
+	ConsultationFee int64  `json:"consultation_fee"`
+}
+
+// InterpreterProfileView 通訳者の公開プロフィール
+type InterpreterProfileView struct {
+	Name      string `json:"name"`
+	Languages string `json:"languages"`
+}
+
+// AppointmentView 予約（患者・医師・通訳者と、含まれるメッセージ・処方をロールに応じて絞り込む）
+type AppointmentView struct {
+	*models.Appointment
+	Patient       interface{}         `json:"patient"`
+	Doctor        interface{}         `json:"doctor"`
+	Interpreter   interface{}         `json:"interpreter,omitempty"`
+	Messages      []*MessageView      `json:"messages,omitempty"`
+	Prescriptions []*PrescriptionView `json:"prescriptions,omitempty"`
+}
+
+// MessageView メッセージ（送信者と予約をロールに応じて絞り込む）
+type MessageView struct {
+	*models.Message
+	Appointment *AppointmentView `json:"appointment,omitempty"`
+	Sender      interface{}      `json:"sender"`
+}
+
+// PrescriptionView 処方（処方医と予約をロールに応じて絞り込む）
+type PrescriptionView struct {
+	*models.Prescription
+	Appointment     *AppointmentView `json:"appointment,omitempty"`
+	CreatedByDoctor interface{}      `json:"created_by_doctor"`
+}
+
+// CoverageView 代診（休診する医師と代診医をロールに応じて絞り込む）
+type CoverageView struct {
+	*models.DoctorCoverage
+	Doctor         interface{} `json:"doctor,omitempty"`
+	CoveringDoctor interface{} `json:"covering_doctor,omitempty"`
+}
+
+// TransferView 予約の引き継ぎ（予約と代診医をロールに応じて絞り込む）
+type TransferView struct {
+	*models.AppointmentTransfer
+	Appointment *AppointmentView `json:"appointment,omitempty"`
+	ToDoctor    interface{}      `json:"to_doctor,omitempty"`
+}
+
+// User 受け手に返すユーザー情報（本人・管理者には全情報、読み込んでいない場合はnil）
+func User(viewer Viewer, user *models.User) interface{} {
+	if user == nil || user.ID == 0 {
+		return nil
+	}
+	if viewer.Role == "admin" || viewer.UserID == user.ID {
+		return user
+	}
+
+	view := &UserView{ID: user.ID, Role: user.Role}
+	switch user.Role {
+	case "patient":
+		if user.PatientProfile != nil {
+			view.PatientProfile = &PatientProfileView{Name: user.PatientProfile.Name}
+			if viewer.Role == "doctor" {
+				view.PatientProfile.Birthdate = user.PatientProfile.Birthdate
+				view.PatientProfile.Allergies = user.PatientProfile.Allergies
+			}
+		}
+	case "doctor":
+		if user.DoctorProfile != nil {
+			view.DoctorProfile = &DoctorProfileView{
+				Name:            user.DoctorProfile.Name,
+				Specialty:       user.DoctorProfile.Specialty,
+				LicenseNumber:   user.DoctorProfile.LicenseNumber,
+				Bio:             user.DoctorProfile.Bio,
+				Languages:       user.DoctorProfile.Languages,
+				AcceptsInstant:  user.DoctorProfile.AcceptsInstant,
+				IntakeLeadHours: user.DoctorProfile.IntakeLeadHours,
+				ConsultationFee: user.DoctorProfile.ConsultationFee,
+			}
+		}
+	case "interpreter":
+		if user.InterpreterProfile != nil {
+			view.InterpreterProfile = &InterpreterProfileView{
+				Name:      user.InterpreterProfile.Name,
+				Languages: user.InterpreterProfile.Languages,
+			}
+		}
+	}
+	return view
+}
+
+// Appointment 受け手に返す予約
+func Appointment(viewer Viewer, appointment *models.Appointment) *AppointmentView {
+	if appointment == nil {
+		return nil
+	}
+
+	view := &AppointmentView{
+		Appointment: appointment,
+		Patient:     User(viewer, &appointment.Patient),
+		Doctor:      User(viewer, &appointment.Doctor),
+		Interpreter: User(viewer, appointment.Interpreter),
+	}
+	for i := range appointment.Messages {
+		view.Messages = append(view.Messages, Message(viewer, &appointment.Messages[i]))
+	}
+	for i := range appointment.Prescriptions {
+		view.Prescriptions = append(view.Prescriptions, Prescription(viewer, &appointment.Prescriptions[i]))
+	}
+	return view
+}
+
+// Appointments 受け手に返す予約の一覧
+func Appointments(viewer Viewer, appointments []models.Appointment) []*AppointmentView {
+	views := make([]*AppointmentView, 0, len(appointments))
+	for i := range appointments {
+		views = append(views, Appointment(viewer, &appointments[i]))
+	}
+	return views
+}
+
+// Message 受け手に返すメッセージ
+func Message(viewer Viewer, message *models.Message) *MessageView {
+	if message == nil {
+		return nil
+	}
+
+	view := &MessageView{
+		Message: message,
+		Sender:  User(viewer, &message.Sender),
+	}
+	if message.Appointment.ID != 0 {
+		view.Appointment = Appointment(viewer, &message.Appointment)
+	}
+	return view
+}
+
+// Messages 受け手に返すメッセージの一覧
+func Messages(viewer Viewer, messages []models.Message) []*MessageView {
+	views := make([]*MessageView, 0, len(messages))
+	for i := range messages {
+		views = append(views, Message(viewer, &messages[i]))
+	}
+	return views
+}
+
+// Prescription 受け手に返す処方
+func Prescription(viewer Viewer, prescription *models.Prescription) *PrescriptionView {
+	if prescription == nil {
+		return nil
+	}
+
+	view := &PrescriptionView{
+		Prescription:    prescription,
+		CreatedByDoctor: User(viewer, &prescription.CreatedByDoctor),
+	}
+	if prescription.Appointment.ID != 0 {
+		view.Appointment = Appointment(viewer, &prescription.Appointment)
+	}
+	return view
+}
+
+// Prescriptions 受け手に返す処方の一覧
+func Prescriptions(viewer Viewer, prescriptions []models.Prescription) []*PrescriptionView {
+	views := make([]*PrescriptionView, 0, len(prescriptions))
+	for i := range prescriptions {
+		views = append(views, Prescription(viewer, &prescriptions[i]))
+	}
+	return views
+}
+
+// AppointmentParticipant 予約の参加者の受け手（予約のリアルタイム配信で参加者ごとに絞り込む）
+func AppointmentParticipant(appointment *models.Appointment, userID uint) Viewer {
+	switch {
+	case userID == appointment.PatientID:
+		return Viewer{UserID: userID, Role: "patient"}
+	case userID == appointment.DoctorID:
+		return Viewer{UserID: userID, Role: "doctor"}
+	default:
+		return Viewer{UserID: userID, Role: "interpreter"}
+	}
+}
+
+// Coverage 受け手に返す代診
+func Coverage(viewer Viewer, coverage *models.DoctorCoverage) *CoverageView {
+	if coverage == nil {
+		return nil
+	}
+	return &CoverageView{
+		DoctorCoverage: coverage,
+		Doctor:         User(viewer, coverage.Doctor),
+		CoveringDoctor: User(viewer, coverage.CoveringDoctor),
+	}
+}
+
+// Coverages 受け手に返す代診の一覧
+func Coverages(viewer Viewer, coverages []models.DoctorCoverage) []*CoverageView {
+	views := make([]*CoverageView, 0, len(coverages))
+	for i := range coverages {
+		views = append(views, Coverage(viewer, &coverages[i]))
+	}
+	return views
+}
+
+// Transfer 受け手に返す予約の引き継ぎ
+func Transfer(viewer Viewer, transfer *models.AppointmentTransfer) *TransferView {
+	if transfer == nil {
+		return nil
+	}
+	return &TransferView{
+		AppointmentTransfer: transfer,
+		Appointment:         Appointment(viewer, transfer.Appointment),
+		ToDoctor:            User(viewer, transfer.ToDoctor),
+	}
+}
+
+// Transfers 受け手に返す予約の引き継ぎの一覧
+func Transfers(viewer Viewer, transfers []models.AppointmentTransfer) []*TransferView {
+	views := make([]*TransferView, 0, len(transfers))
+	for i := range transfers {
+		views = append(views, Transfer(viewer, &transfers[i]))
+	}
+	return views
+}
@@ -2,6 +2,7 @@
 
 import { useState, useEffect } from 'react'
 import { useRouter } from 'next/navigation'
+import { fetchAllPages } from '@/lib/pagination'
 
 interface Appointment {
   id: number
@@ -32,18 +33,13 @@ export default function DoctorAppointments() {
         return
       }
 
-      const response = await fetch('/api/v1/doctors/me/appointments', {
-        headers: {
-          'Authorization': `Bearer ${token}`
-        }
-      })
+      const result = await fetchAllPages<Appointment>('/api/v1/doctors/me/appointments', token)
 
-      if (!response.ok) {
+      if (!result.ok) {
         throw new Error('予約の取得に失敗しました')
       }
 
-      const data = await response.json()
-      setAppointments(data.appointments)
+      setAppointments(result.items)
     } catch (err) {
       setError(err instanceof Error ? err.message : 'エラーが発生しました')
     } finally {
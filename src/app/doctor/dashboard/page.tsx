@@ -4,6 +4,7 @@ import { useState, useEffect } from 'react'
 import { useRouter } from 'next/navigation'
 import { Calendar, MessageSquare, Video, FileText, LogOut, Plus, Clock, CheckCircle, XCircle, Users, Edit3 } from 'lucide-react'
 import toast from 'react-hot-toast'
+import { fetchAllPages } from '@/lib/pagination'
 
 interface Appointment {
   id: number
@@ -62,23 +63,17 @@ export default function DoctorDashboard() {
 
   const fetchData = async (token: string) => {
     try {
-      const [appointmentsRes, slotsRes] = await Promise.all([
-        fetch('/api/v1/doctors/me/appointments', {
-          headers: { 'Authorization': `Bearer ${token}` },
-        }),
-        fetch('/api/v1/doctors/me/slots', {
-          headers: { 'Authorization': `Bearer ${token}` },
-        }),
+      const [appointmentsResult, slotsResult] = await Promise.all([
+        fetchAllPages<Appointment>('/api/v1/doctors/me/appointments', token),
+        fetchAllPages<Slot>('/api/v1/doctors/me/slots', token),
       ])
 
-      if (appointmentsRes.ok) {
-        const data = await appointmentsRes.json()
-        setAppointments(data.appointments || [])
+      if (appointmentsResult.ok) {
+        setAppointments(appointmentsResult.items)
       }
 
-      if (slotsRes.ok) {
-        const data = await slotsRes.json()
-        setSlots(data.slots || [])
+      if (slotsResult.ok) {
+        setSlots(slotsResult.items)
       }
     } catch (error) {
       toast.error('データの取得に失敗しました')
@@ -82,7 +82,7 @@ export default function DoctorPrescriptionsPage() {
       
       if (response.ok) {
         const data = await response.json();
-        setPrescriptions(data.prescriptions || []);
+        setPrescriptions(data.items || []);
       }
     } catch (error) {
       console.error('処方の読み込みに失敗しました:', error);
@@ -4,6 +4,7 @@ import { useState, useEffect } from 'react'
 import { useRouter } from 'next/navigation'
 import { Calendar, Clock, Edit, Trash2, Plus, ArrowLeft, CheckCircle, XCircle } from 'lucide-react'
 import toast from 'react-hot-toast'
+import { fetchAllPages } from '@/lib/pagination'
 
 interface Slot {
   id: number
@@ -31,16 +32,10 @@ export default function SlotsPage() {
         return
       }
 
-      const response = await fetch('/api/v1/doctors/me/slots', {
-        headers: {
-          'Authorization': `Bearer ${token}`
-        }
-      })
+      const result = await fetchAllPages<Slot>('/api/v1/doctors/me/slots', token)
 
-      if (response.ok) {
-        const data = await response.json()
-        console.log('Fetched slots data:', data.slots)
-        setSlots(data.slots || [])
+      if (result.ok) {
+        setSlots(result.items)
       } else {
         toast.error('診療枠の取得に失敗しました')
       }
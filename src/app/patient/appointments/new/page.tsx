@@ -50,7 +50,7 @@ export default function NewAppointmentPage() {
 
       if (response.ok) {
         const data = await response.json()
-        setDoctors(data.doctors || [])
+        setDoctors(data.items || [])
       } else {
         toast.error('医師情報の取得に失敗しました')
       }
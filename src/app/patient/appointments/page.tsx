@@ -2,6 +2,7 @@
 
 import { useState, useEffect } from 'react'
 import { useRouter } from 'next/navigation'
+import { fetchAllPages } from '@/lib/pagination'
 
 interface Appointment {
   id: number
@@ -33,18 +34,13 @@ export default function PatientAppointments() {
         return
       }
 
-      const response = await fetch('/api/v1/patients/appointments', {
-        headers: {
-          'Authorization': `Bearer ${token}`
-        }
-      })
+      const result = await fetchAllPages<Appointment>('/api/v1/patients/appointments', token)
 
-      if (!response.ok) {
+      if (!result.ok) {
         throw new Error('予約の取得に失敗しました')
       }
 
-      const data = await response.json()
-      setAppointments(data.appointments)
+      setAppointments(result.items)
     } catch (err) {
       setError(err instanceof Error ? err.message : 'エラーが発生しました')
     } finally {
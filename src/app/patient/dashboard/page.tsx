@@ -4,6 +4,7 @@ import { useState, useEffect } from 'react'
 import { useRouter } from 'next/navigation'
 import { Calendar, MessageSquare, Video, FileText, LogOut, Plus, Clock, CheckCircle, XCircle } from 'lucide-react'
 import toast from 'react-hot-toast'
+import { fetchAllPages } from '@/lib/pagination'
 
 interface Appointment {
   id: number
@@ -55,15 +56,10 @@ export default function PatientDashboard() {
 
   const fetchAppointments = async (token: string) => {
     try {
-      const response = await fetch('/api/v1/patients/appointments', {
-        headers: {
-          'Authorization': `Bearer ${token}`,
-        },
-      })
+      const result = await fetchAllPages<Appointment>('/api/v1/patients/appointments', token)
 
-      if (response.ok) {
-        const data = await response.json()
-        setAppointments(data.appointments || [])
+      if (result.ok) {
+        setAppointments(result.items)
       } else {
         toast.error('予約情報の取得に失敗しました')
       }
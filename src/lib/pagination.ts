@@ -0,0 +1,37 @@
+// 一覧APIのページ（items と次のページのカーソル）
+export interface Page<T> {
+  items: T[]
+  total: number
+  next_cursor: string | null
+}
+
+// 一覧の最大取得ページ数（カーソルが循環した場合の打ち切り）
+const MAX_PAGES = 50
+
+// fetchAllPages 一覧APIの全ページを next_cursor をたどって取得する
+// 途中のページの取得に失敗した場合は ok: false を返す
+export async function fetchAllPages<T>(url: string, token: string): Promise<{ ok: boolean; items: T[] }> {
+  const items: T[] = []
+  let cursor: string | null = null
+
+  for (let i = 0; i < MAX_PAGES; i++) {
+    const pageUrl: string = cursor
+      ? `${url}${url.includes('?') ? '&' : '?'}cursor=${encodeURIComponent(cursor)}`
+      : url
+    const response = await fetch(pageUrl, {
+      headers: { 'Authorization': `Bearer ${token}` },
+    })
+    if (!response.ok) {
+      return { ok: false, items }
+    }
+
+    const page: Page<T> = await response.json()
+    items.push(...(page.items || []))
+    if (!page.next_cursor) {
+      break
+    }
+    cursor = page.next_cursor
+  }
+
+  return { ok: true, items }
+}